@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// apikeysCmd represents the apikeys command
+var apikeysCmd = &cobra.Command{
+	Use:   "apikeys",
+	Short: "Manage tenant API keys",
+	Long: `Commands for issuing, rotating and revoking a tenant's API keys.
+
+API keys are for automation and CI pipelines. They are limited to their
+scopes (permissions such as job:read or job:create) and can never register
+workers. Worker keys (--worker) can only register workers and run jobs, so a
+leaked CI key cannot be used to attach rogue workers.
+
+Every apikeys command requires --tenant with the ID of the tenant owning the keys.`,
+}
+
+// apikeysCreateCmd represents the apikeys create command
+var apikeysCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Issue a new API key",
+	Long: `Issue a new API key for a tenant. Without --scope the key has every
+permission except worker registration. The key is only shown once.`,
+	Example: `  ffrtmp apikeys create ci --tenant <tenant-id> --scope job:create --scope job:read --expires-in 2160h
+  ffrtmp apikeys create rack-1 --tenant <tenant-id> --worker`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAPIKeysCreate,
+}
+
+// apikeysListCmd represents the apikeys list command
+var apikeysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a tenant's API keys",
+	RunE:  runAPIKeysList,
+}
+
+// apikeysRotateCmd represents the apikeys rotate command
+var apikeysRotateCmd = &cobra.Command{
+	Use:   "rotate <key-id>",
+	Short: "Replace an API key with a new one",
+	Long: `Issue a replacement key with the same name, kind, scopes and expiry. The old
+key is revoked immediately unless --grace keeps it working for a while.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAPIKeysRotate,
+}
+
+// apikeysRevokeCmd represents the apikeys revoke command
+var apikeysRevokeCmd = &cobra.Command{
+	Use:   "revoke <key-id>",
+	Short: "Revoke an API key",
+	Long:  `Revoke an API key. It stops working immediately.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAPIKeysRevoke,
+}
+
+var (
+	apikeyScopes    []string
+	apikeyWorker    bool
+	apikeyExpiresIn time.Duration
+	apikeyGrace     time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(apikeysCmd)
+	apikeysCmd.AddCommand(apikeysCreateCmd)
+	apikeysCmd.AddCommand(apikeysListCmd)
+	apikeysCmd.AddCommand(apikeysRotateCmd)
+	apikeysCmd.AddCommand(apikeysRevokeCmd)
+
+	apikeysCreateCmd.Flags().StringSliceVar(&apikeyScopes, "scope", nil, "permission granted to the key (repeatable, e.g. job:read)")
+	apikeysCreateCmd.Flags().BoolVar(&apikeyWorker, "worker", false, "issue a worker registration key instead of an API key")
+	apikeysCreateCmd.Flags().DurationVar(&apikeyExpiresIn, "expires-in", 0, "key lifetime (e.g. 720h; default: never expires)")
+
+	apikeysRotateCmd.Flags().DurationVar(&apikeyGrace, "grace", 0, "keep the old key working for this long (e.g. 1h)")
+}
+
+// apiKeysTenant returns the tenant selected with --tenant, which owns the keys
+func apiKeysTenant() (string, error) {
+	if tenantID == "" {
+		return "", fmt.Errorf("--tenant is required: specify the tenant ID owning the keys")
+	}
+	return tenantID, nil
+}
+
+// formatKeyScopes renders the permissions of a key
+func formatKeyScopes(key models.TenantAPIKey) string {
+	if key.Kind == "worker" {
+		return "worker"
+	}
+	if len(key.Scopes) == 0 {
+		return "all (except node:register)"
+	}
+	return strings.Join(key.Scopes, ", ")
+}
+
+// printIssuedKey prints a newly issued key, which cannot be retrieved again
+func printIssuedKey(key client.IssuedAPIKey) {
+	fmt.Printf("  ID:      %s\n", key.ID)
+	fmt.Printf("  Kind:    %s\n", key.Kind)
+	fmt.Printf("  Scopes:  %s\n", formatKeyScopes(key.TenantAPIKey))
+	if key.ExpiresAt != nil {
+		fmt.Printf("  Expires: %s\n", key.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	fmt.Printf("  API key: %s\n", key.APIKey)
+	fmt.Println("\nStore the API key now; it cannot be retrieved again.")
+}
+
+func runAPIKeysCreate(cmd *cobra.Command, args []string) error {
+	req := models.TenantAPIKeyRequest{
+		Name:   args[0],
+		Kind:   "api",
+		Scopes: apikeyScopes,
+	}
+	if apikeyWorker {
+		if len(apikeyScopes) > 0 {
+			return fmt.Errorf("--scope cannot be used with --worker: worker keys have fixed permissions")
+		}
+		req.Kind = "worker"
+	}
+	if apikeyExpiresIn > 0 {
+		expiresAt := time.Now().Add(apikeyExpiresIn).UTC()
+		req.ExpiresAt = &expiresAt
+	}
+
+	tenant, err := apiKeysTenant()
+	if err != nil {
+		return err
+	}
+	key, err := GetAPIClient().CreateTenantAPIKey(context.Background(), tenant, &req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(key)
+	}
+
+	fmt.Printf("✓ API key created: %s\n", key.Name)
+	printIssuedKey(*key)
+	return nil
+}
+
+func runAPIKeysList(cmd *cobra.Command, args []string) error {
+	tenant, err := apiKeysTenant()
+	if err != nil {
+		return err
+	}
+	result, err := GetAPIClient().ListTenantAPIKeys(context.Background(), tenant)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if len(result.Keys) == 0 {
+		fmt.Println("No API keys found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("ID", "Name", "Prefix", "Scopes", "Status", "Expires", "Last Used")
+	for _, key := range result.Keys {
+		expires := "never"
+		if key.ExpiresAt != nil {
+			expires = key.ExpiresAt.Local().Format("2006-01-02 15:04")
+		}
+		lastUsed := "never"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.Local().Format("2006-01-02 15:04")
+		}
+		table.Append(
+			key.ID,
+			key.Name,
+			key.KeyPrefix,
+			formatKeyScopes(key),
+			key.Status,
+			expires,
+			lastUsed,
+		)
+	}
+	table.Render()
+	fmt.Printf("\nTotal API keys: %d\n", result.Count)
+	return nil
+}
+
+func runAPIKeysRotate(cmd *cobra.Command, args []string) error {
+	var req client.APIKeyRotation
+	if apikeyGrace > 0 {
+		grace := apikeyGrace.String()
+		req.GracePeriod = &grace
+	}
+
+	tenant, err := apiKeysTenant()
+	if err != nil {
+		return err
+	}
+	key, err := GetAPIClient().RotateTenantAPIKey(context.Background(), tenant, args[0], &req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(key)
+	}
+
+	fmt.Printf("✓ API key %s rotated\n", key.Name)
+	if apikeyGrace > 0 {
+		fmt.Printf("  The old key keeps working for %s\n", apikeyGrace)
+	} else {
+		fmt.Println("  The old key has been revoked")
+	}
+	printIssuedKey(key.IssuedAPIKey)
+	return nil
+}
+
+func runAPIKeysRevoke(cmd *cobra.Command, args []string) error {
+	tenant, err := apiKeysTenant()
+	if err != nil {
+		return err
+	}
+	result, err := GetAPIClient().RevokeTenantAPIKey(context.Background(), tenant, args[0])
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	fmt.Printf("✓ API key %s revoked\n", args[0])
+	return nil
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of API changes",
+	Long: `Commands for the master's audit log. Every mutating API request (job
+submissions, cancellations, tenant, key and user changes, node registrations,
+...) is recorded with who made it, what it acted on and whether it succeeded.
+
+Tenant-bound credentials only see their own tenant's events.`,
+}
+
+// auditListCmd represents the audit list command
+var auditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List audit events, newest first",
+	Example: `  ffrtmp audit list
+  ffrtmp audit list --action job.cancel --since 24h
+  ffrtmp audit list --outcome denied --limit 500`,
+	Args: cobra.NoArgs,
+	RunE: runAuditList,
+}
+
+// auditExportCmd represents the audit export command
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export audit events as JSON Lines",
+	Long:  `Export all matching audit events as JSON Lines (one event per line, oldest first).`,
+	Example: `  ffrtmp audit export --since 720h -f audit.jsonl
+  ffrtmp audit export --actor-type apikey > apikey-audit.jsonl`,
+	Args: cobra.NoArgs,
+	RunE: runAuditExport,
+}
+
+var (
+	auditActorType string
+	auditActor     string
+	auditAction    string
+	auditTarget    string
+	auditOutcome   string
+	auditSince     string
+	auditUntil     string
+	auditLimit     int
+	auditFile      string
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditListCmd)
+	auditCmd.AddCommand(auditExportCmd)
+
+	for _, c := range []*cobra.Command{auditListCmd, auditExportCmd} {
+		c.Flags().StringVar(&auditActorType, "actor-type", "", "filter by actor type (user, apikey, node, admin, certificate, anonymous)")
+		c.Flags().StringVar(&auditActor, "actor", "", "filter by actor ID (user ID, API key ID, node ID or certificate name)")
+		c.Flags().StringVar(&auditAction, "action", "", "filter by action (e.g. job.create, apikey.revoke)")
+		c.Flags().StringVar(&auditTarget, "target", "", "filter by target ID")
+		c.Flags().StringVar(&auditOutcome, "outcome", "", "filter by outcome (success, denied, failure)")
+		c.Flags().StringVar(&auditSince, "since", "", "only events after this RFC 3339 time or within this duration (e.g. 24h)")
+		c.Flags().StringVar(&auditUntil, "until", "", "only events before this RFC 3339 time or duration ago")
+	}
+	auditListCmd.Flags().IntVar(&auditLimit, "limit", 100, "maximum number of events (at most 1000)")
+	auditExportCmd.Flags().StringVarP(&auditFile, "file", "f", "", "file to write (default: stdout)")
+}
+
+func runAuditList(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().ListAuditEvents(context.Background(), &client.ListAuditEventsParams{
+		ActorType: auditActorType,
+		Actor:     auditActor,
+		Action:    auditAction,
+		Target:    auditTarget,
+		Outcome:   auditOutcome,
+		Since:     auditSince,
+		Until:     auditUntil,
+		Limit:     auditLimit,
+	})
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if len(result.Events) == 0 {
+		fmt.Println("No audit events found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Time", "Actor", "Action", "Target", "Source", "Outcome")
+	for _, event := range result.Events {
+		actor := event.ActorType
+		if event.ActorID != "" {
+			actor += ":" + event.ActorID
+		}
+		table.Append(
+			event.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			actor,
+			event.Action,
+			event.TargetID,
+			event.SourceIP,
+			fmt.Sprintf("%s (%d)", event.Outcome, event.StatusCode),
+		)
+	}
+	table.Render()
+	fmt.Printf("\nTotal events: %d\n", result.Count)
+	return nil
+}
+
+func runAuditExport(cmd *cobra.Command, args []string) error {
+	body, err := GetAPIClient().ExportAuditEvents(context.Background(), &client.ExportAuditEventsParams{
+		ActorType: auditActorType,
+		Actor:     auditActor,
+		Action:    auditAction,
+		Target:    auditTarget,
+		Outcome:   auditOutcome,
+		Since:     auditSince,
+		Until:     auditUntil,
+	})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if auditFile == "" {
+		_, err := io.Copy(os.Stdout, body)
+		return err
+	}
+	out, err := os.OpenFile(auditFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", auditFile, err)
+	}
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write %s: %w", auditFile, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", auditFile, err)
+	}
+	fmt.Printf("✓ Audit events exported to %s\n", auditFile)
+	return nil
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// sessionTokenConfigKey is the config key holding the token saved by ffrtmp login
+const sessionTokenConfigKey = "session_token"
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to the master with a user account",
+	Long: `Log in with an email and password. The session token is saved to the config
+file and used by later commands instead of the API key until it expires or
+you run 'ffrtmp logout'.`,
+	RunE: runLogin,
+}
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "End the saved login session",
+	RunE:  runLogout,
+}
+
+var (
+	loginEmail         string
+	loginPasswordStdin bool
+)
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+
+	loginCmd.Flags().StringVar(&loginEmail, "email", "", "user email (prompted if omitted)")
+	loginCmd.Flags().BoolVar(&loginPasswordStdin, "password-stdin", false, "read the password from stdin")
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	email := loginEmail
+	if email == "" {
+		fmt.Print("Email: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read email: %w", err)
+		}
+		email = strings.TrimSpace(line)
+	}
+
+	var password string
+	if loginPasswordStdin {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	} else {
+		var err error
+		if password, err = readPassword(reader); err != nil {
+			return err
+		}
+	}
+
+	// Login must not send an existing token, which may have expired
+	api := client.New(GetMasterURL(), client.WithHTTPClient(GetHTTPClient()))
+	result, err := api.Login(context.Background(), &models.LoginRequest{Email: email, Password: password})
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	path, err := updateConfigFile(func(config map[string]interface{}) {
+		config[sessionTokenConfigKey] = result.Token
+		config["master_url"] = GetMasterURL()
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Logged in as %s (%s, tenant %s)\n", result.User.Email, result.User.Role, result.User.TenantID)
+	fmt.Printf("  Session expires: %s\n", result.ExpiresAt.Local().Format(time.RFC3339))
+	fmt.Printf("  Token saved to:  %s\n", path)
+	return nil
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	token := viper.GetString(sessionTokenConfigKey)
+	if token == "" {
+		fmt.Println("Not logged in")
+		return nil
+	}
+
+	// The saved token is removed even if the master cannot be reached or the session already expired
+	api := client.New(GetMasterURL(), client.WithHTTPClient(GetHTTPClient()), client.WithBearerToken(token))
+	if err := api.Logout(context.Background()); err != nil && client.StatusCode(err) != http.StatusUnauthorized {
+		fmt.Fprintf(os.Stderr, "Warning: failed to end session on the master: %v\n", err)
+	}
+
+	if _, err := updateConfigFile(func(config map[string]interface{}) {
+		delete(config, sessionTokenConfigKey)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println("✓ Logged out")
+	return nil
+}
+
+// readPassword prompts for a password, hiding the input when stdin is a terminal
+func readPassword(reader *bufio.Reader) (string, error) {
+	fmt.Print("Password: ")
+
+	stty := func(arg string) error {
+		c := exec.Command("stty", arg)
+		c.Stdin = os.Stdin
+		return c.Run()
+	}
+	if stty("-echo") == nil {
+		defer func() {
+			stty("echo")
+			fmt.Println()
+		}()
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// configFilePath returns the config file in use, or the default location
+func configFilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".ffrtmp", "config.yaml"), nil
+}
+
+// updateConfigFile applies update to the config file, keeping its other
+// settings, and writes it back readable only by the owner since it holds
+// credentials. It returns the path written.
+func updateConfigFile(update func(config map[string]interface{})) (string, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	config := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if config == nil {
+			config = make(map[string]interface{})
+		}
+	}
+
+	update(config)
+
+	data, err = yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/backup"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupFile          string
+	restoreValidateOnly bool
+	restoreYes          bool
+)
+
+// dbCmd represents the db command
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Back up and restore the master database",
+	Long:  `Commands for taking consistent backups of the master database and restoring them.`,
+}
+
+// dbBackupCmd represents the db backup command
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Download a consistent snapshot of the master database",
+	Long: `Download a consistent snapshot of the master database.
+
+SQLite masters produce a database file, PostgreSQL masters a pg_dump-compatible
+SQL script and in-memory masters a JSON snapshot.`,
+	RunE: runDBBackup,
+}
+
+// dbRestoreCmd represents the db restore command
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore the master database from a backup",
+	Long: `Validate a backup and restore it into the master database, replacing all existing data.
+
+Use --validate-only to check a backup without changing anything.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+
+	dbBackupCmd.Flags().StringVarP(&backupFile, "file", "f", "", "backup file to write (default: ffrtmp-backup-<timestamp> in the current directory)")
+
+	dbRestoreCmd.Flags().BoolVar(&restoreValidateOnly, "validate-only", false, "only validate the backup, do not restore it")
+	dbRestoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "skip the confirmation prompt")
+}
+
+func runDBBackup(cmd *cobra.Command, args []string) error {
+	download, err := GetAPIClient().BackupDatabase(context.Background())
+	if err != nil {
+		return err
+	}
+	defer download.Close()
+
+	format := download.Header.Get("X-Backup-Format")
+	path := backupFile
+	if path == "" {
+		path = backup.FileName(format, time.Now())
+	}
+
+	// Write to a temp file first so an interrupted download never leaves a partial backup behind
+	tmpPath := path + ".partial"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	size, err := io.Copy(f, download)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to download backup: %w", err)
+	}
+	if size == 0 {
+		os.Remove(tmpPath)
+		return fmt.Errorf("master returned an empty backup (check master logs)")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	if IsJSONOutput() {
+		return printJSON(map[string]interface{}{
+			"file":       path,
+			"format":     format,
+			"size_bytes": size,
+		})
+	}
+
+	fmt.Printf("✓ Backup written to %s\n", path)
+	fmt.Printf("  Format: %s\n", format)
+	fmt.Printf("  Size:   %d bytes\n", size)
+	return nil
+}
+
+func runDBRestore(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	if !restoreValidateOnly && !restoreYes {
+		fmt.Printf("This will replace ALL data on %s with the contents of %s.\n", GetMasterURL(), path)
+		fmt.Print("Continue? [y/N]: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("Restore cancelled")
+			return nil
+		}
+	}
+
+	params := &client.RestoreDatabaseParams{ValidateOnly: restoreValidateOnly}
+	result, err := GetAPIClient().RestoreDatabase(context.Background(), params, f)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if restoreValidateOnly {
+		fmt.Printf("✓ Backup %s is valid (format: %s)\n", path, result.Format)
+	} else {
+		fmt.Printf("✓ Database restored from %s (format: %s)\n", path, result.Format)
+	}
+	return nil
+}
@@ -1,15 +1,14 @@
 package cmd
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -120,44 +119,7 @@ func init() {
 	jobsStatusCmd.Flags().BoolVar(&followStatus, "follow", false, "poll job status every 2 seconds until completion")
 }
 
-type jobRequest struct {
-	Scenario   string                 `json:"scenario"`
-	Confidence string                 `json:"confidence,omitempty"`
-	Engine     string                 `json:"engine,omitempty"`
-	Parameters map[string]interface{} `json:"parameters,omitempty"`
-	Queue      string                 `json:"queue,omitempty"`
-	Priority   string                 `json:"priority,omitempty"`
-}
-
-type jobResponse struct {
-	ID             string                 `json:"id"`
-	SequenceNumber int                    `json:"sequence_number,omitempty"`
-	Scenario       string                 `json:"scenario"`
-	Confidence     string                 `json:"confidence"`
-	Engine         string                 `json:"engine,omitempty"`
-	Parameters     map[string]interface{} `json:"parameters,omitempty"`
-	Status         string                 `json:"status"`
-	Queue          string                 `json:"queue,omitempty"`
-	Priority       string                 `json:"priority,omitempty"`
-	Progress       int                    `json:"progress,omitempty"`
-	NodeID         string                 `json:"node_id,omitempty"`
-	NodeName       string                 `json:"node_name,omitempty"`
-	CreatedAt      time.Time              `json:"created_at"`
-	StartedAt      *time.Time             `json:"started_at,omitempty"`
-	CompletedAt    *time.Time             `json:"completed_at,omitempty"`
-	RetryCount     int                    `json:"retry_count"`
-	Error          string                 `json:"error,omitempty"`
-	FailureReason  string                 `json:"failure_reason,omitempty"`
-}
-
-type jobsListResponse struct {
-	Jobs  []jobResponse `json:"jobs"`
-	Count int           `json:"count"`
-}
-
 func runJobsSubmit(cmd *cobra.Command, args []string) error {
-	url := fmt.Sprintf("%s/jobs", GetMasterURL())
-
 	// Build parameters
 	params := make(map[string]interface{})
 	if duration > 0 {
@@ -171,7 +133,7 @@ func runJobsSubmit(cmd *cobra.Command, args []string) error {
 		params["engine"] = engine
 	}
 
-	req := jobRequest{
+	req := models.JobRequest{
 		Scenario:   scenario,
 		Confidence: confidence,
 		Engine:     engine,
@@ -182,39 +144,9 @@ func runJobsSubmit(cmd *cobra.Command, args []string) error {
 		req.Parameters = params
 	}
 
-	// Marshal request
-	reqBody, err := json.Marshal(req)
+	result, err := GetAPIClient().CreateJob(context.Background(), &req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Create authenticated POST request
-	httpReq, err := CreateAuthenticatedRequest("POST", url, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result jobResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return err
 	}
 
 	if IsJSONOutput() {
@@ -232,7 +164,7 @@ func runJobsSubmit(cmd *cobra.Command, args []string) error {
 		table.Append("Job #", fmt.Sprintf("%d", result.SequenceNumber))
 		table.Append("Scenario", result.Scenario)
 		table.Append("Confidence", result.Confidence)
-		table.Append("Status", result.Status)
+		table.Append("Status", string(result.Status))
 		table.Append("Created At", result.CreatedAt.Format(time.RFC3339))
 
 		table.Render()
@@ -284,34 +216,9 @@ func runJobsStatus(cmd *cobra.Command, args []string) error {
 }
 
 func listAllJobs() error {
-	url := fmt.Sprintf("%s/jobs", GetMasterURL())
-
-	// Create authenticated GET request
-	httpReq, err := CreateAuthenticatedRequest("GET", url, nil)
+	result, err := GetAPIClient().ListJobs(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var result jobsListResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return err
 	}
 
 	if IsJSONOutput() {
@@ -338,7 +245,7 @@ func listAllJobs() error {
 			// Format failure reason
 			failureDisplay := "-"
 			if job.FailureReason != "" {
-				failureDisplay = formatFailureReason(job.FailureReason)
+				failureDisplay = formatFailureReason(string(job.FailureReason))
 			} else if job.Status == "failed" || job.Status == "rejected" {
 				failureDisplay = "unknown"
 			}
@@ -348,7 +255,7 @@ func listAllJobs() error {
 			table.Append(
 				fmt.Sprintf("%d", job.SequenceNumber),
 				job.Scenario,
-				job.Status,
+				string(job.Status),
 				progress,
 				nodeName,
 				failureDisplay,
@@ -363,40 +270,11 @@ func listAllJobs() error {
 	return nil
 }
 
-func fetchJobStatus(jobID string) (*jobResponse, error) {
-	url := fmt.Sprintf("%s/jobs/%s", GetMasterURL(), jobID)
-
-	// Create authenticated GET request
-	httpReq, err := CreateAuthenticatedRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result jobResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	return &result, nil
+func fetchJobStatus(jobID string) (*models.Job, error) {
+	return GetAPIClient().GetJob(context.Background(), jobID)
 }
 
-func displayJobStatus(result *jobResponse, renderTable bool) {
+func displayJobStatus(result *models.Job, renderTable bool) {
 	if IsJSONOutput() {
 		// Output as JSON
 		output, _ := json.MarshalIndent(result, "", "  ")
@@ -411,7 +289,7 @@ func displayJobStatus(result *jobResponse, renderTable bool) {
 	table.Append("Job #", fmt.Sprintf("%d", result.SequenceNumber))
 	table.Append("Scenario", result.Scenario)
 	table.Append("Confidence", result.Confidence)
-	table.Append("Status", result.Status)
+	table.Append("Status", string(result.Status))
 	
 	if result.Queue != "" {
 		table.Append("Queue", result.Queue)
@@ -447,7 +325,7 @@ func displayJobStatus(result *jobResponse, renderTable bool) {
 	}
 	
 	if result.FailureReason != "" {
-		table.Append("Failure Reason", formatFailureReason(result.FailureReason))
+		table.Append("Failure Reason", formatFailureReason(string(result.FailureReason)))
 	}
 
 	// Display parameters if any
@@ -495,28 +373,23 @@ func runJobsResume(cmd *cobra.Command, args []string) error {
 }
 
 func controlJob(jobID, action string) error {
-	url := fmt.Sprintf("%s/jobs/%s/%s", GetMasterURL(), jobID, action)
-
-	// Create authenticated POST request
-	httpReq, err := CreateAuthenticatedRequest("POST", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	api := GetAPIClient()
+	ctx := context.Background()
+	var err error
+	switch action {
+	case "cancel":
+		_, err = api.CancelJob(ctx, jobID)
+	case "pause":
+		_, err = api.PauseJob(ctx, jobID)
+	case "resume":
+		_, err = api.ResumeJob(ctx, jobID)
+	case "retry":
+		_, err = api.RetryJob(ctx, jobID)
+	default:
+		return fmt.Errorf("unknown job action %q", action)
 	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return err
 	}
 
 	fmt.Printf("✓ Job %s %sed successfully\n", jobID, action)
@@ -525,38 +398,9 @@ func controlJob(jobID, action string) error {
 
 func runJobsLogs(cmd *cobra.Command, args []string) error {
 	jobID := args[0]
-	url := fmt.Sprintf("%s/jobs/%s/logs", GetMasterURL(), jobID)
-
-	// Create authenticated GET request
-	httpReq, err := CreateAuthenticatedRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	result, err := GetAPIClient().GetJobLogs(context.Background(), jobID)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	type logsResponse struct {
-		JobID string `json:"job_id"`
-		Logs  string `json:"logs"`
-	}
-
-	var result logsResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return err
 	}
 
 	if IsJSONOutput() {
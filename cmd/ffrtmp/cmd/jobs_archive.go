@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/archive"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Job archive query flags
+	archiveDir           string
+	archiveJobID         string
+	archiveStatus        string
+	archiveScenario      string
+	archiveNode          string
+	archiveTenant        string
+	archiveFailureReason string
+	archiveSince         string
+	archiveUntil         string
+	archiveContains      string
+	archiveLimit         int
+)
+
+// jobsArchiveCmd represents the jobs archive command
+var jobsArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Work with archived jobs",
+	Long:  `Commands for searching jobs that the master archived before cleanup (see --archive on the master).`,
+}
+
+// jobsArchiveQueryCmd represents the jobs archive query command
+var jobsArchiveQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Search archived jobs",
+	Long: `Search the master's job archive files (JSONL or Parquet) for matching jobs.
+
+The archive directory must be readable from this machine (e.g. run on the master host
+or mount the archive directory). Dates are archive days in UTC (YYYY-MM-DD).`,
+	Example: `  ffrtmp jobs archive query --dir /var/lib/ffrtmp/archive --status failed --since 2026-01-01
+  ffrtmp jobs archive query --dir ./archive --contains "connection refused" --output json`,
+	RunE: runJobsArchiveQuery,
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsArchiveCmd)
+	jobsArchiveCmd.AddCommand(jobsArchiveQueryCmd)
+
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveDir, "dir", "./archive", "job archive directory")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveJobID, "job-id", "", "job ID or ID prefix")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveStatus, "status", "", "final job status (completed, failed, canceled, ...)")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveScenario, "scenario", "", "scenario name")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveNode, "node", "", "node ID")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveTenant, "tenant", "", "tenant ID")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveFailureReason, "failure-reason", "", "failure reason (e.g., runtime_error)")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveSince, "since", "", "only jobs completed on or after this date (YYYY-MM-DD)")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveUntil, "until", "", "only jobs completed before this date (YYYY-MM-DD)")
+	jobsArchiveQueryCmd.Flags().StringVar(&archiveContains, "contains", "", "case-insensitive text to find in errors, logs or parameters")
+	jobsArchiveQueryCmd.Flags().IntVar(&archiveLimit, "limit", 100, "maximum number of results (0 = unlimited)")
+}
+
+func runJobsArchiveQuery(cmd *cobra.Command, args []string) error {
+	query := archive.Query{
+		JobID:         archiveJobID,
+		Status:        archiveStatus,
+		Scenario:      archiveScenario,
+		NodeID:        archiveNode,
+		TenantID:      archiveTenant,
+		FailureReason: archiveFailureReason,
+		Contains:      archiveContains,
+		Limit:         archiveLimit,
+	}
+
+	var err error
+	if archiveSince != "" {
+		if query.Since, err = time.Parse("2006-01-02", archiveSince); err != nil {
+			return fmt.Errorf("invalid --since date: %w", err)
+		}
+	}
+	if archiveUntil != "" {
+		if query.Until, err = time.Parse("2006-01-02", archiveUntil); err != nil {
+			return fmt.Errorf("invalid --until date: %w", err)
+		}
+	}
+
+	records, err := archive.Search(archiveDir, query)
+	if err != nil {
+		return fmt.Errorf("failed to search archive: %w", err)
+	}
+
+	if IsJSONOutput() {
+		output, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No archived jobs found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Job ID", "Job #", "Scenario", "Status", "Node", "Failure", "Completed")
+
+	for _, rec := range records {
+		job := rec.Job
+
+		nodeID := job.NodeID
+		if nodeID == "" {
+			nodeID = "-"
+		}
+
+		failureDisplay := "-"
+		if job.FailureReason != "" {
+			failureDisplay = formatFailureReason(string(job.FailureReason))
+		}
+
+		completedAt := "-"
+		if job.CompletedAt != nil {
+			completedAt = job.CompletedAt.Format("2006-01-02 15:04")
+		}
+
+		table.Append(
+			job.ID,
+			fmt.Sprintf("%d", job.SequenceNumber),
+			job.Scenario,
+			string(job.Status),
+			nodeID,
+			failureDisplay,
+			completedAt,
+		)
+	}
+
+	table.Render()
+	fmt.Printf("\nArchived jobs found: %d\n", len(records))
+	if archiveLimit > 0 && len(records) == archiveLimit {
+		fmt.Printf("(limited to %d results, use --limit to change)\n", archiveLimit)
+	}
+	return nil
+}
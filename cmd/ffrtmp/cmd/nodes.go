@@ -1,10 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 
 	"github.com/olekukonko/tablewriter"
@@ -51,57 +50,10 @@ func init() {
 	nodesCmd.AddCommand(nodesRemoveCmd)
 }
 
-type nodesListResponse struct {
-	Nodes []nodeInfo `json:"nodes"`
-	Count int        `json:"count"`
-}
-
-type nodeInfo struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	Address         string   `json:"address"`
-	Type            string   `json:"type"`
-	CPUThreads      int      `json:"cpu_threads"`
-	CPUModel        string   `json:"cpu_model"`
-	CPULoadPercent  float64  `json:"cpu_load_percent,omitempty"`
-	HasGPU          bool     `json:"has_gpu"`
-	GPUType         string   `json:"gpu_type,omitempty"`
-	GPUCapabilities []string `json:"gpu_capabilities,omitempty"`
-	RAMTotalBytes   uint64   `json:"ram_total_bytes,omitempty"`
-	RAMFreeBytes    uint64   `json:"ram_free_bytes,omitempty"`
-	Status          string   `json:"status"`
-	CurrentJobID    string   `json:"current_job_id,omitempty"`
-}
-
 func runNodesList(cmd *cobra.Command, args []string) error {
-	url := fmt.Sprintf("%s/nodes", GetMasterURL())
-
-	// Create authenticated GET request
-	httpReq, err := CreateAuthenticatedRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	result, err := GetAPIClient().ListNodes(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var result nodesListResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return err
 	}
 
 	if IsJSONOutput() {
@@ -143,7 +95,7 @@ func runNodesList(cmd *cobra.Command, args []string) error {
 			table.Append(
 				nodeName,
 				node.Status,
-				node.Type,
+				string(node.Type),
 				cpuInfo,
 				gpuInfo,
 			)
@@ -158,33 +110,9 @@ func runNodesList(cmd *cobra.Command, args []string) error {
 
 func runNodesDescribe(cmd *cobra.Command, args []string) error {
 	nodeID := args[0]
-	url := fmt.Sprintf("%s/nodes/%s", GetMasterURL(), nodeID)
-
-	// Create authenticated GET request
-	httpReq, err := CreateAuthenticatedRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
+	node, err := GetAPIClient().GetNode(context.Background(), nodeID)
 	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	var node nodeInfo
-	if err := json.Unmarshal(body, &node); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return err
 	}
 
 	if IsJSONOutput() {
@@ -201,7 +129,7 @@ func runNodesDescribe(cmd *cobra.Command, args []string) error {
 
 		table.Append([]string{"Node ID", node.ID})
 		table.Append([]string{"Address", node.Address})
-		table.Append([]string{"Type", node.Type})
+		table.Append([]string{"Type", string(node.Type)})
 		table.Append([]string{"Status", node.Status})
 
 		// CPU Information
@@ -261,28 +189,8 @@ func runNodesDescribe(cmd *cobra.Command, args []string) error {
 
 func runNodesRemove(cmd *cobra.Command, args []string) error {
 	nodeID := args[0]
-	url := fmt.Sprintf("%s/nodes/%s", GetMasterURL(), nodeID)
-
-	// Create authenticated DELETE request
-	httpReq, err := CreateAuthenticatedRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := GetHTTPClient()
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to connect to master API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	if _, err := GetAPIClient().RemoveNode(context.Background(), nodeID); err != nil {
+		return err
 	}
 
 	fmt.Printf("✓ Node %s removed successfully\n", nodeID)
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// pkiCmd represents the pki command
+var pkiCmd = &cobra.Command{
+	Use:   "pki",
+	Short: "Manage worker enrollment and client certificates",
+	Long: `Commands for the master's built-in certificate authority (master --pki).
+
+Workers enroll once with a one-time bootstrap token, receive a short-lived
+client certificate and renew it automatically before it expires:
+
+  ffrtmp pki create-token --description rack-1
+  agent --master https://master:8080 --register --enroll-token <token>
+
+A compromised worker is cut off by revoking its certificate.`,
+}
+
+// pkiCreateTokenCmd represents the pki create-token command
+var pkiCreateTokenCmd = &cobra.Command{
+	Use:   "create-token",
+	Short: "Create a one-time bootstrap token for enrolling a worker",
+	Long:  `Create a bootstrap token. It can enroll a single worker and is only shown once.`,
+	Example: `  ffrtmp pki create-token
+  ffrtmp pki create-token --description "encoder-07" --expires-in 1h`,
+	Args: cobra.NoArgs,
+	RunE: runPKICreateToken,
+}
+
+// pkiTokensCmd represents the pki tokens command
+var pkiTokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "List bootstrap tokens",
+	Args:  cobra.NoArgs,
+	RunE:  runPKITokens,
+}
+
+// pkiCertsCmd represents the pki certs command
+var pkiCertsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "List issued worker certificates",
+	Args:  cobra.NoArgs,
+	RunE:  runPKICerts,
+}
+
+// pkiRevokeCmd represents the pki revoke command
+var pkiRevokeCmd = &cobra.Command{
+	Use:   "revoke <serial>",
+	Short: "Revoke a worker certificate",
+	Long: `Revoke a worker certificate by serial number (see 'ffrtmp pki certs').
+The worker can no longer reach the master or renew the certificate, and must
+be enrolled again with a new bootstrap token.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPKIRevoke,
+}
+
+var (
+	pkiTokenDescription string
+	pkiTokenExpiresIn   time.Duration
+	pkiRevokedOnly      bool
+)
+
+func init() {
+	rootCmd.AddCommand(pkiCmd)
+	pkiCmd.AddCommand(pkiCreateTokenCmd)
+	pkiCmd.AddCommand(pkiTokensCmd)
+	pkiCmd.AddCommand(pkiCertsCmd)
+	pkiCmd.AddCommand(pkiRevokeCmd)
+
+	pkiCreateTokenCmd.Flags().StringVar(&pkiTokenDescription, "description", "", "note identifying the worker the token is for")
+	pkiCreateTokenCmd.Flags().DurationVar(&pkiTokenExpiresIn, "expires-in", 24*time.Hour, "how long the token can be used")
+
+	pkiCertsCmd.Flags().BoolVar(&pkiRevokedOnly, "revoked", false, "only list revoked certificates")
+}
+
+func runPKICreateToken(cmd *cobra.Command, args []string) error {
+	req := models.BootstrapTokenRequest{
+		Description: pkiTokenDescription,
+		ExpiresIn:   pkiTokenExpiresIn.String(),
+	}
+
+	token, err := GetAPIClient().CreateBootstrapToken(context.Background(), &req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(token)
+	}
+
+	fmt.Println("✓ Bootstrap token created")
+	fmt.Printf("  ID:      %s\n", token.ID)
+	fmt.Printf("  Expires: %s\n", token.ExpiresAt.Local().Format(time.RFC3339))
+	fmt.Printf("  Token:   %s\n", token.Token)
+	fmt.Println("\nStart the worker with --enroll-token (or FFRTMP_ENROLL_TOKEN) to enroll it.")
+	fmt.Println("The token can be used once and cannot be retrieved again.")
+	return nil
+}
+
+func runPKITokens(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().ListBootstrapTokens(context.Background())
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if len(result.Tokens) == 0 {
+		fmt.Println("No bootstrap tokens found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("ID", "Description", "Status", "Expires", "Used By")
+	for _, token := range result.Tokens {
+		status := "unused"
+		if token.UsedAt != nil {
+			status = "used " + token.UsedAt.Local().Format("2006-01-02 15:04")
+		} else if time.Now().After(token.ExpiresAt) {
+			status = "expired"
+		}
+		table.Append(
+			token.ID,
+			token.Description,
+			status,
+			token.ExpiresAt.Local().Format("2006-01-02 15:04"),
+			token.UsedBy,
+		)
+	}
+	table.Render()
+	fmt.Printf("\nTotal bootstrap tokens: %d\n", result.Count)
+	return nil
+}
+
+func runPKICerts(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().ListCertificates(context.Background(), &client.ListCertificatesParams{Revoked: pkiRevokedOnly})
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if len(result.Certificates) == 0 {
+		fmt.Println("No certificates found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Serial", "Worker", "Issued", "Expires", "Status")
+	for _, cert := range result.Certificates {
+		status := "valid"
+		if cert.RevokedAt != nil {
+			status = "revoked " + cert.RevokedAt.Local().Format("2006-01-02 15:04")
+		} else if time.Now().After(cert.NotAfter) {
+			status = "expired"
+		}
+		table.Append(
+			cert.Serial,
+			cert.CommonName,
+			cert.NotBefore.Local().Format("2006-01-02 15:04"),
+			cert.NotAfter.Local().Format("2006-01-02 15:04"),
+			status,
+		)
+	}
+	table.Render()
+	fmt.Printf("\nTotal certificates: %d\n", result.Count)
+	return nil
+}
+
+func runPKIRevoke(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().RevokeCertificate(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	fmt.Printf("✓ Certificate %s revoked\n", args[0])
+	return nil
+}
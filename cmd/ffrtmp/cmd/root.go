@@ -2,14 +2,15 @@ package cmd
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -19,6 +20,7 @@ var (
 	outputFormat        string
 	cfgFile             string
 	apiKey              string
+	tenantID            string
 	httpClient          *http.Client
 	httpClientMasterURL string // Track which masterURL the client was initialized with
 	httpClientMutex     sync.Mutex
@@ -43,6 +45,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ffrtmp/config.yaml)")
 	rootCmd.PersistentFlags().StringVar(&masterURL, "master", "", "master API URL (default from config or https://localhost:8080)")
 	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "table", "output format: table or json")
+	rootCmd.PersistentFlags().StringVar(&tenantID, "tenant", "", "act as this tenant ID (requires cluster-wide credentials)")
 }
 
 // initConfig reads in config file and ENV variables if set
@@ -97,6 +100,11 @@ func initConfig() {
 	if apiKey == "" && viper.GetString("api_key") != "" {
 		apiKey = viper.GetString("api_key")
 	}
+
+	// A session saved by 'ffrtmp login' takes precedence over the API key
+	if token := viper.GetString(sessionTokenConfigKey); token != "" {
+		apiKey = token
+	}
 	if masterURL == "" && viper.GetString("master_url") != "" {
 		masterURL = viper.GetString("master_url")
 	}
@@ -141,6 +149,16 @@ func IsJSONOutput() bool {
 	return outputFormat == "json"
 }
 
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) error {
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
 // GetAPIKey returns the configured API key
 func GetAPIKey() string {
 	return apiKey
@@ -159,16 +177,18 @@ func GetHTTPClient() *http.Client {
 	return httpClient
 }
 
-// CreateAuthenticatedRequest creates an HTTP request with authentication header if API key is configured
-func CreateAuthenticatedRequest(method, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, err
-	}
+// GetAPIClient returns a master API client using the configured HTTP client and credentials
+func GetAPIClient() *client.Client {
+	return client.New(GetMasterURL(), client.WithHTTPClient(GetHTTPClient()), client.WithRequestEditor(addAuthHeaders))
+}
 
+// addAuthHeaders adds the API key and tenant headers if configured
+func addAuthHeaders(req *http.Request) error {
 	if apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
-
-	return req, nil
+	if tenantID != "" {
+		req.Header.Set("X-Tenant-ID", tenantID)
+	}
+	return nil
 }
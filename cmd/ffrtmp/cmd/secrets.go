@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// secretsCmd represents the secrets command
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage secrets used in job parameters",
+	Long: `Commands for managing secrets such as RTMP stream keys and SRT passphrases.
+
+Secrets are stored encrypted on the master. Jobs reference them by name in
+their parameters instead of embedding the value:
+
+  {"stream_key": {"$secret": "youtube_key"}}
+
+The value is only sent to the worker the job is assigned to, and is redacted
+from job results, logs and results files. Secret values cannot be read back.`,
+}
+
+// secretsCreateCmd represents the secrets create command
+var secretsCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Store a new secret",
+	Long: `Store a new secret. The value is read from --value, --from-file or, if
+neither is given, standard input (a trailing newline is removed).`,
+	Example: `  ffrtmp secrets create youtube_key --from-file youtube.key
+  echo -n "$STREAM_KEY" | ffrtmp secrets create twitch_key --description "Twitch ingest"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretsCreate,
+}
+
+// secretsListCmd represents the secrets list command
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List secrets (without their values)",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretsList,
+}
+
+// secretsUpdateCmd represents the secrets update command
+var secretsUpdateCmd = &cobra.Command{
+	Use:   "update <name>",
+	Short: "Replace the value of a secret",
+	Long: `Replace the value of a secret, read like secrets create. Jobs already
+running keep the previous value.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretsUpdate,
+}
+
+// secretsDeleteCmd represents the secrets delete command
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a secret",
+	Long:  `Delete a secret. Queued jobs referencing it fail when they are scheduled.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSecretsDelete,
+}
+
+var (
+	secretValue       string
+	secretFile        string
+	secretDescription string
+)
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.AddCommand(secretsCreateCmd)
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsUpdateCmd)
+	secretsCmd.AddCommand(secretsDeleteCmd)
+
+	for _, c := range []*cobra.Command{secretsCreateCmd, secretsUpdateCmd} {
+		c.Flags().StringVar(&secretValue, "value", "", "secret value (visible in shell history; prefer --from-file or stdin)")
+		c.Flags().StringVar(&secretFile, "from-file", "", "read the secret value from a file")
+		c.Flags().StringVar(&secretDescription, "description", "", "description of the secret")
+	}
+}
+
+// readSecretValue returns the secret value given with --value, --from-file or on stdin
+func readSecretValue() (string, error) {
+	if secretValue != "" && secretFile != "" {
+		return "", fmt.Errorf("--value and --from-file cannot be used together")
+	}
+	if secretValue != "" {
+		return secretValue, nil
+	}
+
+	var data []byte
+	var err error
+	if secretFile != "" {
+		data, err = os.ReadFile(secretFile)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("the secret value is empty")
+	}
+	return value, nil
+}
+
+// secretRequest builds the body of a create or update request
+func secretRequest(cmd *cobra.Command) (*models.SecretRequest, error) {
+	value, err := readSecretValue()
+	if err != nil {
+		return nil, err
+	}
+	req := &models.SecretRequest{Value: value}
+	if cmd.Flags().Changed("description") {
+		req.Description = &secretDescription
+	}
+	return req, nil
+}
+
+func runSecretsCreate(cmd *cobra.Command, args []string) error {
+	req, err := secretRequest(cmd)
+	if err != nil {
+		return err
+	}
+	req.Name = args[0]
+
+	secret, err := GetAPIClient().CreateSecret(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(secret)
+	}
+
+	fmt.Printf("✓ Secret created: %s\n", args[0])
+	fmt.Printf("  Reference it in job parameters as {\"$secret\": %q}\n", args[0])
+	return nil
+}
+
+func runSecretsList(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().ListSecrets(context.Background())
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if len(result.Secrets) == 0 {
+		fmt.Println("No secrets found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Name", "Description", "Created By", "Updated")
+	for _, secret := range result.Secrets {
+		table.Append(
+			secret.Name,
+			secret.Description,
+			secret.CreatedBy,
+			secret.UpdatedAt.Local().Format("2006-01-02 15:04"),
+		)
+	}
+	table.Render()
+	fmt.Printf("\nTotal secrets: %d\n", result.Count)
+	return nil
+}
+
+func runSecretsUpdate(cmd *cobra.Command, args []string) error {
+	req, err := secretRequest(cmd)
+	if err != nil {
+		return err
+	}
+
+	secret, err := GetAPIClient().UpdateSecret(context.Background(), args[0], req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(secret)
+	}
+
+	fmt.Printf("✓ Secret %s updated\n", args[0])
+	return nil
+}
+
+func runSecretsDelete(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().DeleteSecret(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	fmt.Printf("✓ Secret %s deleted\n", args[0])
+	return nil
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// tenantsCmd represents the tenants command
+var tenantsCmd = &cobra.Command{
+	Use:   "tenants",
+	Short: "Manage tenants",
+	Long: `Commands for creating and managing tenants. Each tenant's jobs, nodes and
+webhooks are isolated from other tenants. Managing tenants requires the master API key or an admin user of the default tenant.`,
+}
+
+// tenantsCreateCmd represents the tenants create command
+var tenantsCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a tenant",
+	Long: `Create a tenant and issue its API key. The name must consist of lowercase
+letters, digits and hyphens. The API key is only shown once.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTenantsCreate,
+}
+
+// tenantsListCmd represents the tenants list command
+var tenantsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all tenants",
+	RunE:  runTenantsList,
+}
+
+// tenantsGetCmd represents the tenants get command
+var tenantsGetCmd = &cobra.Command{
+	Use:   "get <tenant-id>",
+	Short: "Get tenant details and quotas",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTenantsGet,
+}
+
+// tenantsUpdateCmd represents the tenants update command
+var tenantsUpdateCmd = &cobra.Command{
+	Use:   "update <tenant-id>",
+	Short: "Update a tenant",
+	Long:  `Update a tenant's display name, plan or status. Changing the plan resets its quotas to the plan defaults.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTenantsUpdate,
+}
+
+// tenantsDeleteCmd represents the tenants delete command
+var tenantsDeleteCmd = &cobra.Command{
+	Use:   "delete <tenant-id>",
+	Short: "Delete a tenant",
+	Long:  `Mark a tenant as deleted. Its API keys stop working immediately; its jobs are kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTenantsDelete,
+}
+
+// tenantsStatsCmd represents the tenants stats command
+var tenantsStatsCmd = &cobra.Command{
+	Use:   "stats <tenant-id>",
+	Short: "Show tenant usage against its quotas",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTenantsStats,
+}
+
+var (
+	tenantDisplayName string
+	tenantPlan        string
+	tenantNewName     string
+	tenantNewPlan     string
+	tenantNewStatus   string
+)
+
+func init() {
+	rootCmd.AddCommand(tenantsCmd)
+	tenantsCmd.AddCommand(tenantsCreateCmd)
+	tenantsCmd.AddCommand(tenantsListCmd)
+	tenantsCmd.AddCommand(tenantsGetCmd)
+	tenantsCmd.AddCommand(tenantsUpdateCmd)
+	tenantsCmd.AddCommand(tenantsDeleteCmd)
+	tenantsCmd.AddCommand(tenantsStatsCmd)
+
+	tenantsCreateCmd.Flags().StringVar(&tenantDisplayName, "display-name", "", "human-friendly tenant name")
+	tenantsCreateCmd.Flags().StringVar(&tenantPlan, "plan", "free", "tenant plan: free, pro or enterprise")
+
+	tenantsUpdateCmd.Flags().StringVar(&tenantNewName, "display-name", "", "new display name")
+	tenantsUpdateCmd.Flags().StringVar(&tenantNewPlan, "plan", "", "new plan: free, pro or enterprise")
+	tenantsUpdateCmd.Flags().StringVar(&tenantNewStatus, "status", "", "new status: active or suspended")
+}
+
+// formatQuota renders a quota limit, where negative values mean unlimited
+func formatQuota(limit int) string {
+	if limit < 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d", limit)
+}
+
+func runTenantsCreate(cmd *cobra.Command, args []string) error {
+	req := models.TenantRequest{
+		Name:        args[0],
+		DisplayName: tenantDisplayName,
+		Plan:        tenantPlan,
+	}
+
+	tenant, err := GetAPIClient().CreateTenant(context.Background(), &req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(tenant)
+	}
+
+	fmt.Printf("✓ Tenant created: %s\n", tenant.Name)
+	fmt.Printf("  ID:      %s\n", tenant.ID)
+	fmt.Printf("  Plan:    %s\n", tenant.Plan)
+	fmt.Printf("  API key: %s\n", tenant.APIKey)
+	fmt.Println("\nStore the API key now; it cannot be retrieved again.")
+	return nil
+}
+
+func runTenantsList(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().ListTenants(context.Background())
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	if len(result.Tenants) == 0 {
+		fmt.Println("No tenants found")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("ID", "Name", "Display Name", "Plan", "Status", "Created")
+	for _, tenant := range result.Tenants {
+		table.Append(
+			tenant.ID,
+			tenant.Name,
+			tenant.DisplayName,
+			tenant.Plan,
+			tenant.Status,
+			tenant.CreatedAt.Format("2006-01-02 15:04"),
+		)
+	}
+	table.Render()
+	fmt.Printf("\nTotal tenants: %d\n", result.Count)
+	return nil
+}
+
+func runTenantsGet(cmd *cobra.Command, args []string) error {
+	tenant, err := GetAPIClient().GetTenant(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(tenant)
+	}
+
+	expires := "never"
+	if tenant.ExpiresAt != nil {
+		expires = tenant.ExpiresAt.Format(time.RFC3339)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Property", "Value")
+	table.Append("ID", tenant.ID)
+	table.Append("Name", tenant.Name)
+	table.Append("Display Name", tenant.DisplayName)
+	table.Append("Plan", tenant.Plan)
+	table.Append("Status", tenant.Status)
+	table.Append("Created", tenant.CreatedAt.Format(time.RFC3339))
+	table.Append("Expires", expires)
+	table.Append("Max Concurrent Jobs", formatQuota(tenant.Quotas.MaxConcurrentJobs))
+	table.Append("Max Total Jobs", formatQuota(tenant.Quotas.MaxTotalJobs))
+	table.Append("Max Workers", formatQuota(tenant.Quotas.MaxWorkers))
+	table.Append("Max CPU Cores", formatQuota(tenant.Quotas.MaxCPUCores))
+	table.Append("Max GPUs", formatQuota(tenant.Quotas.MaxGPUs))
+	table.Append("Max API Requests/Hour", formatQuota(tenant.Quotas.MaxAPIRequestsPerHour))
+	table.Render()
+	return nil
+}
+
+func runTenantsUpdate(cmd *cobra.Command, args []string) error {
+	var req client.TenantUpdate
+	if tenantNewName != "" {
+		req.DisplayName = &tenantNewName
+	}
+	if tenantNewPlan != "" {
+		req.Plan = &tenantNewPlan
+	}
+	if tenantNewStatus != "" {
+		req.Status = &tenantNewStatus
+	}
+	if req.DisplayName == nil && req.Plan == nil && req.Status == nil {
+		return fmt.Errorf("nothing to update: specify --display-name, --plan or --status")
+	}
+
+	tenant, err := GetAPIClient().UpdateTenant(context.Background(), args[0], &req)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(tenant)
+	}
+
+	fmt.Printf("✓ Tenant %s updated\n", args[0])
+	return nil
+}
+
+func runTenantsDelete(cmd *cobra.Command, args []string) error {
+	result, err := GetAPIClient().DeleteTenant(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(result)
+	}
+
+	fmt.Printf("✓ Tenant %s deleted\n", args[0])
+	return nil
+}
+
+func runTenantsStats(cmd *cobra.Command, args []string) error {
+	stats, err := GetAPIClient().GetTenantStats(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return printJSON(stats)
+	}
+
+	fmt.Printf("Tenant: %s (%s, %s)\n\n", stats.Name, stats.Plan, stats.Status)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Resource", "Used", "Limit")
+	table.Append("Concurrent Jobs", fmt.Sprintf("%d", stats.Usage.CurrentJobs), formatQuota(stats.Quotas.MaxConcurrentJobs))
+	table.Append("Total Jobs", fmt.Sprintf("%d", stats.Usage.TotalJobsLifetime), formatQuota(stats.Quotas.MaxTotalJobs))
+	table.Append("Workers", fmt.Sprintf("%d", stats.Usage.CurrentWorkers), formatQuota(stats.Quotas.MaxWorkers))
+	table.Append("CPU Cores", fmt.Sprintf("%d", stats.Usage.CurrentCPUCores), formatQuota(stats.Quotas.MaxCPUCores))
+	table.Append("GPUs", fmt.Sprintf("%d", stats.Usage.CurrentGPUs), formatQuota(stats.Quotas.MaxGPUs))
+	table.Render()
+	fmt.Printf("\nJobs submitted today: %d\n", stats.Usage.TotalJobsToday)
+	return nil
+}
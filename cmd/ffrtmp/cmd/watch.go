@@ -9,7 +9,7 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"github.com/psantana5/ffmpeg-rtmp/internal/discover"
 	"github.com/spf13/cobra"
 )
@@ -21,6 +21,9 @@ var (
 	daemonCPUWeight int
 	daemonMemLimit int
 	watchConfigFile string // Config file path
+	discoveryMode   string
+	rescanInterval  time.Duration
+	reloadDryRun    bool
 	
 	// State persistence (Phase 3.1)
 	enableStatePersistence bool
@@ -41,6 +44,15 @@ and automatically attaches resource governance to them.
 CRITICAL for production environments where processes may start outside
 of the wrapper's control (client-initiated streams, external triggers, etc.).
 
+New processes are discovered from kernel process events (Linux proc
+connector) within milliseconds of exec, falling back to scanning /proc
+when events are unavailable.
+
+With --watch-config the file is reloaded on SIGHUP and whenever it changes:
+the new configuration is validated, the changes are printed, then limits of
+attached processes are reconciled and processes that no longer match the
+filters are detached. Tracking state is kept across reloads.
+
 The daemon runs in the background and automatically applies resource limits
 to any discovered processes matching the target commands.
 
@@ -54,8 +66,11 @@ Example:
 func init() {
 	rootCmd.AddCommand(watchCmd)
 	
-	watchCmd.Flags().StringVar(&watchConfigFile, "watch-config", "", "Path to watch daemon configuration file (YAML)")
+	watchCmd.Flags().StringVar(&watchConfigFile, "watch-config", "", "Path to watch daemon configuration file (YAML), reloaded on change and SIGHUP")
+	watchCmd.Flags().BoolVar(&reloadDryRun, "reload-dry-run", false, "Print what a config reload would change without applying it")
 	watchCmd.Flags().DurationVar(&scanInterval, "scan-interval", 10*time.Second, "How often to scan for new processes")
+	watchCmd.Flags().StringVar(&discoveryMode, "discovery", "auto", "Process discovery: auto (events, else polling), events or poll")
+	watchCmd.Flags().DurationVar(&rescanInterval, "rescan-interval", discover.DefaultRescanInterval, "How often to scan /proc while using process events")
 	watchCmd.Flags().StringSliceVar(&targetCommands, "target", []string{"ffmpeg", "gst-launch-1.0"}, "Target commands to discover")
 	watchCmd.Flags().IntVar(&daemonCPUQuota, "cpu-quota", 0, "Default CPU quota for discovered processes (0=unlimited)")
 	watchCmd.Flags().IntVar(&daemonCPUWeight, "cpu-weight", 100, "Default CPU weight for discovered processes")
@@ -78,28 +93,12 @@ func runWatchDaemon(cmd *cobra.Command, args []string) error {
 	
 	// Check if config file provided
 	if watchConfigFile != "" {
-		watchCfg, err := discover.LoadConfig(watchConfigFile)
+		var err error
+		config, filterConfig, err = loadWatchConfig(cmd)
 		if err != nil {
 			return fmt.Errorf("failed to load config file: %w", err)
 		}
 		
-		// Convert to AttachConfig
-		config, err = watchCfg.ToAttachConfig()
-		if err != nil {
-			return fmt.Errorf("failed to parse config: %w", err)
-		}
-		
-		// Convert filters
-		filterConfig, err = watchCfg.Filters.ToFilterConfig()
-		if err != nil {
-			return fmt.Errorf("failed to parse filters: %w", err)
-		}
-		
-		// Apply command-specific filters
-		if err := watchCfg.ApplyCommandFilters(filterConfig); err != nil {
-			return fmt.Errorf("failed to apply command filters: %w", err)
-		}
-		
 		scanInterval = config.ScanInterval
 		targetCommands = config.TargetCommands
 		
@@ -108,15 +107,23 @@ func runWatchDaemon(cmd *cobra.Command, args []string) error {
 		fmt.Printf("╠════════════════════════════════════════════════════════════════╣\n")
 		fmt.Printf("║ Config File: %-49s ║\n", watchConfigFile)
 		fmt.Printf("║ Scan Interval: %-47s ║\n", scanInterval)
+		fmt.Printf("║ Discovery: %-51s ║\n", string(config.Discovery))
 		fmt.Printf("║ Target Commands: %-45s ║\n", fmt.Sprintf("%v", targetCommands))
 		fmt.Printf("║ Filters Active: %-46s ║\n", "Yes")
+		fmt.Printf("║ Profile Rules: %-47d ║\n", config.Policy.RuleCount())
 		fmt.Printf("╚════════════════════════════════════════════════════════════════╝\n")
 	} else {
+		discovery, err := discover.ParseDiscoveryMode(discoveryMode)
+		if err != nil {
+			return err
+		}
+		
 		// Use command-line flags
 		fmt.Printf("╔════════════════════════════════════════════════════════════════╗\n")
 		fmt.Printf("║ FFmpeg Auto-Attach Daemon                                      ║\n")
 		fmt.Printf("╠════════════════════════════════════════════════════════════════╣\n")
 		fmt.Printf("║ Scan Interval: %-47s ║\n", scanInterval)
+		fmt.Printf("║ Discovery: %-51s ║\n", string(discovery))
 		fmt.Printf("║ Target Commands: %-45s ║\n", fmt.Sprintf("%v", targetCommands))
 		fmt.Printf("║ Default CPU Quota: %-43d ║\n", daemonCPUQuota)
 		fmt.Printf("║ Default CPU Weight: %-42d ║\n", daemonCPUWeight)
@@ -128,17 +135,13 @@ func runWatchDaemon(cmd *cobra.Command, args []string) error {
 			CPUWeight: daemonCPUWeight,
 		}
 		
-		if daemonCPUQuota > 0 {
-			quota := daemonCPUQuota * 1000
-			limits.CPUMax = fmt.Sprintf("%d 100000", quota)
-		}
-		
-		if daemonMemLimit > 0 {
-			limits.MemoryMax = int64(daemonMemLimit) * 1024 * 1024
-		}
+		limits.CPUMax = cgroups.CPUPercent(daemonCPUQuota)
+		limits.MemoryMax = int64(daemonMemLimit) * cgroups.MiB
 		
 		config = &discover.AttachConfig{
 			ScanInterval:   scanInterval,
+			Discovery:      discovery,
+			RescanInterval: rescanInterval,
 			TargetCommands: targetCommands,
 			DefaultLimits:  limits,
 		}
@@ -189,14 +192,48 @@ func runWatchDaemon(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	
+	// Reload the config file on SIGHUP and when it changes
+	reloadCh := make(chan string, 1)
+	requestReload := func(reason string) {
+		select {
+		case reloadCh <- reason:
+		default: // A reload is already pending
+		}
+	}
+	if watchConfigFile != "" {
+		if err := discover.WatchConfigFile(ctx, watchConfigFile, func() { requestReload("config file changed") }); err != nil {
+			logger.Printf("Warning: %v; reload with SIGHUP", err)
+		}
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case reason := <-reloadCh:
+					reloadWatchConfig(cmd, service, logger, reason)
+				}
+			}
+		}()
+	}
+	
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	
 	go func() {
-		sig := <-sigChan
-		logger.Printf("Received signal %v, shutting down...", sig)
-		cancel()
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				if watchConfigFile == "" {
+					logger.Println("Received SIGHUP, but there is no config file to reload (--watch-config)")
+					continue
+				}
+				requestReload("SIGHUP")
+				continue
+			}
+			logger.Printf("Received signal %v, shutting down...", sig)
+			cancel()
+			return
+		}
 	}()
 	
 	// Start service
@@ -210,3 +247,44 @@ func runWatchDaemon(cmd *cobra.Command, args []string) error {
 	logger.Println("Service stopped gracefully")
 	return nil
 }
+
+// loadWatchConfig loads --watch-config; flags given explicitly override it
+func loadWatchConfig(cmd *cobra.Command) (*discover.AttachConfig, *discover.FilterConfig, error) {
+	config, filterConfig, err := discover.LoadAttachConfig(watchConfigFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	if cmd.Flags().Changed("discovery") {
+		if config.Discovery, err = discover.ParseDiscoveryMode(discoveryMode); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cmd.Flags().Changed("rescan-interval") {
+		config.RescanInterval = rescanInterval
+	}
+	return config, filterConfig, nil
+}
+
+// reloadWatchConfig validates the config file, prints what changes and
+// applies it unless --reload-dry-run is set. An invalid file keeps the
+// running configuration.
+func reloadWatchConfig(cmd *cobra.Command, service *discover.AutoAttachService, logger *log.Logger, reason string) {
+	logger.Printf("Reloading %s (%s)", watchConfigFile, reason)
+	
+	config, filterConfig, err := loadWatchConfig(cmd)
+	if err != nil {
+		logger.Printf("Reload rejected, keeping the running configuration: %v", err)
+		return
+	}
+	
+	fmt.Print(service.PlanReload(config, filterConfig))
+	if reloadDryRun {
+		logger.Println("Dry run: configuration not applied")
+		return
+	}
+	
+	if _, err := service.Reload(config, filterConfig); err != nil {
+		logger.Printf("Reload failed: %v", err)
+	}
+}
@@ -7,8 +7,9 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
 	"github.com/spf13/cobra"
 )
@@ -24,12 +25,18 @@ var (
 	cpuWeight   int
 	memoryMax   int64
 	memoryLimit int
+	memorySwap  int64
 	ioMax       string
 	niceValue   int
 	
 	// Run mode
 	workDir string
 	
+	// Stall supervision (run mode)
+	stallWindow    time.Duration
+	stallOutput    string
+	stallTerminate bool
+	
 	// Attach mode
 	attachPID int
 	
@@ -47,7 +54,8 @@ This is governance, not execution.
 
 Example:
   ffrtmp run --job-id job-001 -- ffmpeg -i input.mp4 output.mp4
-  ffrtmp run --job-id transcode-001 --sla-eligible --cpu-quota 200 --memory-limit 4096 -- ffmpeg -i input.mp4 -c:v h264_nvenc output.mp4`,
+  ffrtmp run --job-id transcode-001 --sla-eligible --cpu-quota 200 --memory-limit 4096 -- ffmpeg -i input.mp4 -c:v h264_nvenc output.mp4
+  ffrtmp run --job-id live-001 --stall-window 30s --stall-terminate -- ffmpeg -i rtmp://src/live -f flv rtmp://dst/live`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runWorkload,
 }
@@ -79,12 +87,16 @@ func init() {
 		cmd.Flags().IntVar(&cpuWeight, "cpu-weight", 100, "CPU weight (1-10000)")
 		cmd.Flags().Int64Var(&memoryMax, "memory-max", 0, "Memory limit in bytes (0=unlimited)")
 		cmd.Flags().IntVar(&memoryLimit, "memory-limit", 0, "Memory limit in MB (0=unlimited)")
-		cmd.Flags().StringVar(&ioMax, "io-max", "", "IO max (major:minor rbps=X wbps=Y)")
+		cmd.Flags().Int64Var(&memorySwap, "memory-swap-max", 0, "Swap limit in bytes on top of the memory limit (0=unlimited)")
+		cmd.Flags().StringVar(&ioMax, "io-max", "", "IO max (major:minor rbps=X wbps=Y riops=X wiops=Y)")
 		cmd.Flags().BoolVar(&jsonOutput, "json", false, "JSON output")
 	}
 	
 	// Run mode flags
 	runCmd.Flags().StringVar(&workDir, "workdir", "", "Working directory")
+	runCmd.Flags().DurationVar(&stallWindow, "stall-window", 0, "Report the workload as stalled after this long without output, output file growth or CPU usage (0=disabled)")
+	runCmd.Flags().StringVar(&stallOutput, "stall-output", "", "Output file whose growth counts as progress")
+	runCmd.Flags().BoolVar(&stallTerminate, "stall-terminate", false, "Send SIGTERM to the workload once stalled")
 	
 	// Attach mode flags
 	attachCmd.Flags().IntVar(&attachPID, "pid", 0, "PID to attach to")
@@ -111,15 +123,16 @@ func runWorkload(cmd *cobra.Command, args []string) error {
 	// Convert memory-limit (MB) to memory-max (bytes) if provided
 	memoryMaxValue := memoryMax
 	if memoryLimit > 0 {
-		memoryMaxValue = int64(memoryLimit) * 1024 * 1024
+		memoryMaxValue = int64(memoryLimit) * cgroups.MiB
 	}
 	
 	// Build limits
 	limits := &cgroups.Limits{
-		CPUMax:      cpuMaxValue,
-		CPUWeight:   cpuWeight,
-		MemoryMax:   memoryMaxValue,
-		IOMax:       ioMax,
+		CPUMax:        cpuMaxValue,
+		CPUWeight:     cpuWeight,
+		MemoryMax:     memoryMaxValue,
+		MemorySwapMax: memorySwap,
+		IOMax:         ioMax,
 	}
 	
 	// Setup context
@@ -135,8 +148,18 @@ func runWorkload(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 	
+	// Watch for stalls
+	var supervision *wrapper.Supervision
+	if stallWindow > 0 {
+		supervision = &wrapper.Supervision{
+			Window:     stallWindow,
+			OutputPath: stallOutput,
+			Terminate:  stallTerminate,
+		}
+	}
+	
 	// Run workload
-	result, err := wrapper.Run(ctx, jobID, limits, command, cmdArgs)
+	result, err := wrapper.RunStarted(ctx, jobID, limits, command, cmdArgs, nil, supervision, "")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 	}
@@ -153,6 +176,11 @@ func runWorkload(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Exit Code: %d\n", result.ExitCode)
 	fmt.Printf("Duration: %.2fs\n", result.Duration.Seconds())
 	fmt.Printf("Platform SLA: %v (%s)\n", result.PlatformSLA, result.PlatformSLAReason)
+	printLimitOutcomes(result.Limits)
+	printUsage(result.Usage)
+	if s := result.Stall; s != nil {
+		fmt.Printf("Stalled: no progress for %.0fs (terminated: %v)\n", s.Idle.Seconds(), s.Terminated)
+	}
 	
 	return nil
 }
@@ -165,22 +193,22 @@ func attachToWorkload(cmd *cobra.Command, args []string) error {
 	// Convert cpu-quota to cpu-max format if provided
 	cpuMaxValue := cpuMax
 	if cpuQuota > 0 {
-		quota := cpuQuota * 1000
-		cpuMaxValue = fmt.Sprintf("%d 100000", quota)
+		cpuMaxValue = cgroups.CPUPercent(cpuQuota)
 	}
 	
 	// Convert memory-limit (MB) to memory-max (bytes) if provided
 	memoryMaxValue := memoryMax
 	if memoryLimit > 0 {
-		memoryMaxValue = int64(memoryLimit) * 1024 * 1024
+		memoryMaxValue = int64(memoryLimit) * cgroups.MiB
 	}
 	
 	// Build limits
 	limits := &cgroups.Limits{
-		CPUMax:      cpuMaxValue,
-		CPUWeight:   cpuWeight,
-		MemoryMax:   memoryMaxValue,
-		IOMax:       ioMax,
+		CPUMax:        cpuMaxValue,
+		CPUWeight:     cpuWeight,
+		MemoryMax:     memoryMaxValue,
+		MemorySwapMax: memorySwap,
+		IOMax:         ioMax,
 	}
 	
 	// Apply nice value if specified
@@ -221,6 +249,35 @@ func attachToWorkload(cmd *cobra.Command, args []string) error {
 	fmt.Printf("PID: %d\n", result.PID)
 	fmt.Printf("Duration: %.2fs\n", result.Duration.Seconds())
 	fmt.Printf("Platform SLA: %v (%s)\n", result.PlatformSLA, result.PlatformSLAReason)
+	printLimitOutcomes(result.Limits)
+	printUsage(result.Usage)
 	
 	return nil
 }
+
+// printLimitOutcomes prints whether each requested limit was applied
+func printLimitOutcomes(outcomes []cgroups.Outcome) {
+	if len(outcomes) == 0 {
+		return
+	}
+	fmt.Println("Limits:")
+	for _, o := range outcomes {
+		if o.Reason != "" {
+			fmt.Printf("  %s (%s): %s - %s\n", o.Limit, o.Controller, o.Status, o.Reason)
+		} else {
+			fmt.Printf("  %s (%s): %s\n", o.Limit, o.Controller, o.Status)
+		}
+	}
+}
+
+// printUsage prints the resource usage of the job cgroup
+func printUsage(usage *cgroups.Stats) {
+	if usage == nil {
+		return
+	}
+	fmt.Println("Usage:")
+	fmt.Printf("  CPU: %.1fs (user %.1fs, system %.1fs)\n", usage.CPUSeconds, usage.CPUUserSeconds, usage.CPUSystemSeconds)
+	fmt.Printf("  Peak memory: %d MB\n", usage.MemoryPeak/cgroups.MiB)
+	fmt.Printf("  IO: %d MB read, %d MB written\n", usage.IOReadBytes/cgroups.MiB, usage.IOWriteBytes/cgroups.MiB)
+	fmt.Printf("  OOM: %d events, %d kills\n", usage.OOMEvents, usage.OOMKills)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateCPUMax      string
+	updateCPUQuota    int
+	updateCPUWeight   int
+	updateMemoryLimit int
+	updateMemorySwap  int
+	updateIOMax       string
+	updateWorkerURL   string
+)
+
+var wrapperCmd = &cobra.Command{
+	Use:   "wrapper",
+	Short: "Manage running wrapped workloads",
+}
+
+var wrapperUpdateCmd = &cobra.Command{
+	Use:   "update <job-id>",
+	Short: "Change the limits of a running workload",
+	Long: `Update rewrites the cgroup limits of a running workload.
+Limits that are not given are left as they are.
+
+Without --worker the cgroup of the job on this host is updated directly.
+With --worker the update is sent to the worker agent running the job,
+which keeps batch jobs throttled while a live job runs.
+
+Example:
+  ffrtmp wrapper update job-001 --cpu-quota 150 --memory-limit 4096
+  ffrtmp wrapper update job-001 --cpu-weight 500 --worker http://worker-1:9091`,
+	Args: cobra.ExactArgs(1),
+	RunE: updateWorkload,
+}
+
+func init() {
+	rootCmd.AddCommand(wrapperCmd)
+	wrapperCmd.AddCommand(wrapperUpdateCmd)
+
+	wrapperUpdateCmd.Flags().StringVar(&updateCPUMax, "cpu-max", "", "CPU max (quota period format, e.g. '200000 100000', or 'max')")
+	wrapperUpdateCmd.Flags().IntVar(&updateCPUQuota, "cpu-quota", 0, "CPU quota percentage (100=1 core)")
+	wrapperUpdateCmd.Flags().IntVar(&updateCPUWeight, "cpu-weight", 0, "CPU weight (1-10000)")
+	wrapperUpdateCmd.Flags().IntVar(&updateMemoryLimit, "memory-limit", 0, "Memory limit in MB")
+	wrapperUpdateCmd.Flags().IntVar(&updateMemorySwap, "memory-swap-max", 0, "Swap limit in MB on top of the memory limit")
+	wrapperUpdateCmd.Flags().StringVar(&updateIOMax, "io-max", "", "IO max (major:minor rbps=X wbps=Y riops=X wiops=Y)")
+	wrapperUpdateCmd.Flags().StringVar(&updateWorkerURL, "worker", "", "Worker agent URL (e.g. http://worker-1:9091)")
+}
+
+func updateWorkload(cmd *cobra.Command, args []string) error {
+	jobID := args[0]
+
+	constraints := models.WrapperConstraints{
+		CPUMax:          updateCPUMax,
+		CPUWeight:       updateCPUWeight,
+		MemoryMaxMB:     int64(updateMemoryLimit),
+		MemorySwapMaxMB: int64(updateMemorySwap),
+		IOMax:           updateIOMax,
+	}
+	if updateCPUQuota > 0 {
+		constraints.CPUMax = cgroups.CPUPercent(updateCPUQuota)
+	}
+	if constraints == (models.WrapperConstraints{}) {
+		return fmt.Errorf("no limits given")
+	}
+
+	var outcomes []cgroups.Outcome
+	var err error
+	if updateWorkerURL != "" {
+		outcomes, err = updateOnWorker(jobID, &constraints)
+	} else {
+		outcomes, err = cgroups.New().Update(jobID, &cgroups.Limits{
+			CPUMax:        constraints.CPUMax,
+			CPUWeight:     constraints.CPUWeight,
+			MemoryMax:     constraints.MemoryMaxMB * cgroups.MiB,
+			MemorySwapMax: constraints.MemorySwapMaxMB * cgroups.MiB,
+			IOMax:         constraints.IOMax,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update job %s: %w", jobID, err)
+	}
+
+	if IsJSONOutput() {
+		return printJSON(outcomes)
+	}
+
+	fmt.Printf("Updated job %s\n", jobID)
+	printLimitOutcomes(outcomes)
+	return nil
+}
+
+// updateOnWorker sends a limits update to the worker agent running the job
+func updateOnWorker(jobID string, constraints *models.WrapperConstraints) ([]cgroups.Outcome, error) {
+	worker := client.New(updateWorkerURL, client.WithHTTPClient(GetHTTPClient()), client.WithRequestEditor(addAuthHeaders))
+	result, err := worker.UpdateJobLimits(context.Background(), jobID, constraints)
+	if err != nil {
+		return nil, err
+	}
+	return result.Limits, nil
+}
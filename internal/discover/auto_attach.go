@@ -7,8 +7,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/internal/report"
 	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
+	constraints "github.com/psantana5/ffmpeg-rtmp/pkg/wrapper"
 )
 
 // AttachConfig configures the auto-attach service
@@ -16,18 +18,38 @@ type AttachConfig struct {
 	// ScanInterval is how often to scan for new processes
 	ScanInterval time.Duration
 	
+	// Discovery selects process events or polling (default: auto)
+	Discovery DiscoveryMode
+	
+	// RescanInterval is how often to scan /proc while process events are
+	// used, to catch processes whose events were lost (default: 5m)
+	RescanInterval time.Duration
+	
 	// TargetCommands are the commands to look for (e.g., "ffmpeg", "gst-launch-1.0")
 	TargetCommands []string
 	
 	// DefaultLimits are resource limits to apply to discovered processes
 	DefaultLimits *cgroups.Limits
 	
+	// Policy selects constraint profiles by process (optional).
+	// Processes it does not match get DefaultLimits.
+	Policy *Policy
+	
 	// OnAttach is called when a process is attached
 	OnAttach func(pid int, jobID string)
 	
 	// OnDetach is called when a monitored process exits
 	OnDetach func(pid int, jobID string)
 	
+	// OnProcessAttached is called with the discovered process when it is
+	// attached (optional)
+	OnProcessAttached func(proc *Process, jobID string)
+	
+	// OnProcessExited is called when an attached process exited (optional).
+	// The result is nil if the process was gone before it could be attached.
+	// It is not called for processes still running when the service stops.
+	OnProcessExited func(proc *Process, jobID string, result *report.Result)
+	
 	// Logger for output (optional)
 	Logger *log.Logger
 	
@@ -39,15 +61,31 @@ type AttachConfig struct {
 	MaxRetryAttempts int  // Max retry attempts (default: 3)
 }
 
+// attachment is an attached process and the constraints applied to it
+type attachment struct {
+	proc   *Process
+	jobID  string
+	limits *cgroups.Limits
+	match  *Match // nil for the default limits
+	cancel context.CancelFunc
+}
+
 // AutoAttachService automatically discovers and attaches to running processes
 type AutoAttachService struct {
 	config  *AttachConfig
 	scanner *Scanner
 	
 	// Track active attachments
-	attachments map[int]context.CancelFunc
+	attachments map[int]*attachment
+	exitCodes   map[int]int // Exit codes of attached processes from exit events
 	mu          sync.Mutex
 	
+	// Process events (nil while polling)
+	events *ProcEvents
+	
+	// Configuration reloads, applied by Start
+	reloadCh chan reloadRequest
+	
 	// Statistics
 	stats struct {
 		TotalScans       int64
@@ -76,6 +114,12 @@ func NewAutoAttachService(config *AttachConfig) *AutoAttachService {
 	if config.ScanInterval == 0 {
 		config.ScanInterval = 10 * time.Second
 	}
+	if config.Discovery == "" {
+		config.Discovery = DiscoveryAuto
+	}
+	if config.RescanInterval == 0 {
+		config.RescanInterval = DefaultRescanInterval
+	}
 	
 	if config.DefaultLimits == nil {
 		config.DefaultLimits = &cgroups.Limits{
@@ -91,7 +135,9 @@ func NewAutoAttachService(config *AttachConfig) *AutoAttachService {
 	service := &AutoAttachService{
 		config:          config,
 		scanner:         NewScanner(config.TargetCommands),
-		attachments:     make(map[int]context.CancelFunc),
+		attachments:     make(map[int]*attachment),
+		exitCodes:       make(map[int]int),
+		reloadCh:        make(chan reloadRequest),
 		stopCh:          make(chan struct{}),
 		logger:          logger,
 		healthCheck:     NewHealthCheck(),
@@ -154,9 +200,35 @@ func (s *AutoAttachService) Start(ctx context.Context) error {
 		s.logger.Println("Retry worker started")
 	}
 	
-	ticker := time.NewTicker(s.config.ScanInterval)
+	// Subscribe to process events before the initial scan so no process
+	// started in between is missed
+	var events <-chan []ProcEvent
+	interval := s.config.ScanInterval
+	if s.config.Discovery != DiscoveryPoll {
+		procEvents, err := OpenProcEvents()
+		switch {
+		case err == nil:
+			s.mu.Lock()
+			s.events = procEvents
+			s.mu.Unlock()
+			events = s.readEvents(procEvents)
+			interval = s.config.RescanInterval
+			s.logger.Printf("Discovery: process events (rescan interval: %v)", interval)
+		case s.config.Discovery == DiscoveryEvents:
+			return fmt.Errorf("process events unavailable: %w", err)
+		default:
+			s.logger.Printf("Process events unavailable, polling /proc: %v", err)
+		}
+	} else {
+		s.logger.Println("Discovery: polling /proc")
+	}
+	
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	
+	// Processes seen too young to pass the runtime filters
+	recheck := make(chan int)
+	
 	// Initial scan
 	s.logger.Println("Performing initial scan...")
 	if err := s.scanAndAttach(); err != nil {
@@ -177,6 +249,28 @@ func (s *AutoAttachService) Start(ctx context.Context) error {
 		case <-s.stopCh:
 			s.logger.Println("Stop signal received")
 			return nil
+		case batch, ok := <-events:
+			if !ok {
+				// Fall back to polling
+				s.logger.Printf("Process events stopped, polling /proc every %v", s.config.ScanInterval)
+				events = nil
+				ticker.Reset(s.config.ScanInterval)
+				s.scanAndAttach()
+				continue
+			}
+			for _, ev := range batch {
+				s.handleEvent(ev, recheck)
+			}
+		case pid := <-recheck:
+			s.attachPID(pid, recheck)
+		case req := <-s.reloadCh:
+			plan := s.reload(req.config, req.filter)
+			if events != nil {
+				ticker.Reset(s.config.RescanInterval)
+			} else {
+				ticker.Reset(s.config.ScanInterval)
+			}
+			req.reply <- plan
 		case <-ticker.C:
 			s.logger.Println("Scanning for processes...")
 			if err := s.scanAndAttach(); err != nil {
@@ -195,6 +289,85 @@ func (s *AutoAttachService) Start(ctx context.Context) error {
 	}
 }
 
+// readEvents forwards process events until reading fails, then closes the
+// returned channel
+func (s *AutoAttachService) readEvents(procEvents *ProcEvents) <-chan []ProcEvent {
+	ch := make(chan []ProcEvent, 64)
+	go func() {
+		defer close(ch)
+		for {
+			batch, err := procEvents.Read()
+			if err != nil {
+				select {
+				case <-s.stopCh:
+				default:
+					s.logger.Printf("Reading process events failed: %v", err)
+				}
+				return
+			}
+			select {
+			case ch <- batch:
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// handleEvent attaches to executed processes and records the exit codes of
+// attached ones
+func (s *AutoAttachService) handleEvent(ev ProcEvent, recheck chan<- int) {
+	switch ev.Type {
+	case ProcEventExec:
+		s.attachPID(ev.PID, recheck)
+	case ProcEventExit:
+		s.mu.Lock()
+		if _, ok := s.attachments[ev.PID]; ok {
+			s.exitCodes[ev.PID] = ev.ExitCode
+		}
+		s.mu.Unlock()
+	case ProcEventLost:
+		s.logger.Println("Process events lost, rescanning")
+		s.scanAndAttach()
+	}
+}
+
+// attachPID attaches to a single process if it is a target that passes the
+// filters. Processes too young for the runtime filters are checked again
+// once they are old enough.
+func (s *AutoAttachService) attachPID(pid int, recheck chan<- int) {
+	s.mu.Lock()
+	_, tracked := s.attachments[pid]
+	s.mu.Unlock()
+	if tracked {
+		return
+	}
+	
+	proc := s.scanner.Inspect(pid)
+	if proc == nil {
+		return
+	}
+	if wait := s.scanner.filter.TooYoung(proc); wait > 0 {
+		time.AfterFunc(wait, func() {
+			select {
+			case recheck <- pid:
+			case <-s.stopCh:
+			}
+		})
+		return
+	}
+	if !s.scanner.filter.ShouldDiscover(proc) {
+		return
+	}
+	
+	s.statsMu.Lock()
+	s.stats.TotalDiscovered++
+	s.statsMu.Unlock()
+	
+	s.attachToProcess(proc)
+}
+
 // Stop stops the auto-attach service
 func (s *AutoAttachService) Stop() {
 	s.logger.Println("Stopping auto-attach service...")
@@ -203,9 +376,9 @@ func (s *AutoAttachService) Stop() {
 	defer s.mu.Unlock()
 	
 	// Cancel all active attachments
-	for pid, cancel := range s.attachments {
+	for pid, a := range s.attachments {
 		s.logger.Printf("Detaching from PID %d", pid)
-		cancel()
+		a.cancel()
 	}
 	
 	// Stop state manager if enabled
@@ -215,6 +388,9 @@ func (s *AutoAttachService) Stop() {
 	}
 	
 	close(s.stopCh)
+	if s.events != nil {
+		s.events.Close()
+	}
 	s.logger.Println("Auto-attach service stopped")
 }
 
@@ -292,7 +468,16 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 	// Generate job ID for this process
 	jobID := fmt.Sprintf("auto-%s-%d", proc.Command, proc.PID)
 	
-	s.logger.Printf("Attaching to PID %d (%s) as job %s", proc.PID, proc.Command, jobID)
+	// Select constraints: matching profile rule, command limits, then defaults
+	limits := s.config.DefaultLimits
+	match := s.config.Policy.Match(proc)
+	if match != nil {
+		limits = match.Constraints.Limits()
+		s.logger.Printf("Attaching to PID %d (%s) as job %s (rule: %s, profile: %s)",
+			proc.PID, proc.Command, jobID, match.Rule, match.Profile)
+	} else {
+		s.logger.Printf("Attaching to PID %d (%s) as job %s", proc.PID, proc.Command, jobID)
+	}
 	
 	// Record discovery in state manager
 	if s.stateManager != nil {
@@ -304,12 +489,15 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 	
 	// Create context for this attachment
 	ctx, cancel := context.WithCancel(context.Background())
-	s.attachments[proc.PID] = cancel
+	s.attachments[proc.PID] = &attachment{proc: proc, jobID: jobID, limits: limits, match: match, cancel: cancel}
 	
 	// Call OnAttach callback
 	if s.config.OnAttach != nil {
 		s.config.OnAttach(proc.PID, jobID)
 	}
+	if s.config.OnProcessAttached != nil {
+		s.config.OnProcessAttached(proc, jobID)
+	}
 	
 	// Attach in background
 	go func() {
@@ -318,8 +506,18 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 			s.stateManager.RecordAttachment(proc.PID)
 		}
 		
+		// Per-process constraints of the profile (best effort)
+		if match != nil {
+			if err := constraints.ApplyNicePriority(proc.PID, match.Constraints.NicePriority); err != nil {
+				s.logger.Printf("Failed to set nice priority of PID %d: %v", proc.PID, err)
+			}
+			if err := constraints.ApplyOOMScoreAdj(proc.PID, match.Constraints.OOMScoreAdj); err != nil {
+				s.logger.Printf("Failed to set OOM score of PID %d: %v", proc.PID, err)
+			}
+		}
+		
 		attachStart := time.Now()
-		result, err := wrapper.Attach(ctx, jobID, proc.PID, s.config.DefaultLimits)
+		result, err := wrapper.Attach(ctx, jobID, proc.PID, limits)
 		
 		// Handle attachment error
 		if err != nil && err != context.Canceled {
@@ -352,6 +550,7 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 			// Clean up failed attachment
 			s.mu.Lock()
 			delete(s.attachments, proc.PID)
+			delete(s.exitCodes, proc.PID)
 			s.scanner.UnmarkTracked(proc.PID)
 			s.mu.Unlock()
 			
@@ -360,6 +559,9 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 				s.stateManager.RemoveProcess(proc.PID)
 			}
 			
+			if s.config.OnProcessExited != nil {
+				s.config.OnProcessExited(proc, jobID, nil)
+			}
 			return
 		}
 		
@@ -377,8 +579,17 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 		s.mu.Lock()
 		delete(s.attachments, proc.PID)
 		s.scanner.UnmarkTracked(proc.PID)
+		exitCode, exitKnown := s.exitCodes[proc.PID]
+		delete(s.exitCodes, proc.PID)
 		s.mu.Unlock()
 		
+		// The exit event carries the exit code the attach cannot observe
+		if result != nil && exitKnown {
+			exited := *result
+			exited.ExitCode = exitCode
+			result = &exited
+		}
+		
 		// Remove from state manager
 		if s.stateManager != nil {
 			s.stateManager.RemoveProcess(proc.PID)
@@ -393,6 +604,9 @@ func (s *AutoAttachService) attachToProcess(proc *Process) {
 		if s.config.OnDetach != nil {
 			s.config.OnDetach(proc.PID, jobID)
 		}
+		if err == nil && s.config.OnProcessExited != nil {
+			s.config.OnProcessExited(proc, jobID, result)
+		}
 	}()
 }
 
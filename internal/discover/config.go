@@ -6,13 +6,15 @@ import (
 	"time"
 	
 	"gopkg.in/yaml.v3"
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 )
 
 // WatchConfig represents the complete configuration for watch daemon
 type WatchConfig struct {
 	// Scanning configuration
 	ScanInterval string   `yaml:"scan_interval"` // e.g., "10s", "1m"
+	Discovery      string   `yaml:"discovery"`       // auto, events or poll
+	RescanInterval string   `yaml:"rescan_interval"` // Full scans while using process events
 	TargetCommands []string `yaml:"target_commands"`
 	
 	// Default resource limits
@@ -23,6 +25,12 @@ type WatchConfig struct {
 	
 	// Command-specific overrides
 	Commands map[string]CommandConfig `yaml:"commands"`
+	
+	// Named constraint profiles, selected by rules
+	Profiles map[string]Profile `yaml:"profiles"`
+	
+	// Rules matching processes to profiles by command line, user, parent and cwd
+	Rules []Rule `yaml:"rules"`
 }
 
 // ResourceLimits defines cgroup resource limits
@@ -91,6 +99,30 @@ func LoadConfig(path string) (*WatchConfig, error) {
 	return &config, nil
 }
 
+// LoadAttachConfig loads and validates a configuration file and returns the
+// service configuration and filters it describes
+func LoadAttachConfig(path string) (*AttachConfig, *FilterConfig, error) {
+	watchCfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	config, err := watchCfg.ToAttachConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	
+	filter, err := watchCfg.Filters.ToFilterConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse filters: %w", err)
+	}
+	if err := watchCfg.ApplyCommandFilters(filter); err != nil {
+		return nil, nil, fmt.Errorf("failed to apply command filters: %w", err)
+	}
+	
+	return config, filter, nil
+}
+
 // ToAttachConfig converts WatchConfig to AttachConfig
 func (c *WatchConfig) ToAttachConfig() (*AttachConfig, error) {
 	// Parse scan interval
@@ -99,24 +131,39 @@ func (c *WatchConfig) ToAttachConfig() (*AttachConfig, error) {
 		return nil, fmt.Errorf("invalid scan_interval: %w", err)
 	}
 	
+	discovery, err := ParseDiscoveryMode(c.Discovery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery: %w", err)
+	}
+	var rescanInterval time.Duration
+	if c.RescanInterval != "" {
+		rescanInterval, err = time.ParseDuration(c.RescanInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rescan_interval: %w", err)
+		}
+	}
+	
 	// Convert resource limits
 	limits := &cgroups.Limits{
 		CPUWeight: c.DefaultLimits.CPUWeight,
 	}
 	
-	if c.DefaultLimits.CPUQuota > 0 {
-		quota := c.DefaultLimits.CPUQuota * 1000
-		limits.CPUMax = fmt.Sprintf("%d 100000", quota)
-	}
+	limits.CPUMax = cgroups.CPUPercent(c.DefaultLimits.CPUQuota)
+	limits.MemoryMax = int64(c.DefaultLimits.MemoryLimit) * cgroups.MiB
 	
-	if c.DefaultLimits.MemoryLimit > 0 {
-		limits.MemoryMax = int64(c.DefaultLimits.MemoryLimit) * 1024 * 1024
+	// Compile profile rules and per-command limits
+	policy, err := NewPolicy(c.Profiles, c.Rules, c.Commands)
+	if err != nil {
+		return nil, err
 	}
 	
 	return &AttachConfig{
 		ScanInterval:  scanInterval,
+		Discovery:     discovery,
+		RescanInterval: rescanInterval,
 		TargetCommands: c.TargetCommands,
 		DefaultLimits: limits,
+		Policy:        policy,
 	}, nil
 }
 
@@ -245,4 +292,39 @@ commands:
       memory_limit: 2048
     filters:
       min_runtime: "30s"  # Only discover GStreamer jobs running >30s
+
+# Named constraint profiles
+profiles:
+  uhd-hevc:
+    cpu_quota: 800      # 8 cores
+    cpu_weight: 50      # Yield to other work under contention
+    memory_limit: 16384
+    io_weight: 50       # IO weight percentage (1-100)
+    nice: 10
+    oom_score_adj: 500  # First to go under memory pressure
+  live-ingest:
+    cpu_weight: 1000
+    nice: -5
+    oom_score_adj: -500
+
+# Rules select a profile by command line, output, user, parent process and
+# working directory. Higher priority first; the first match wins. Processes
+# no rule matches get the per-command limits, then the default limits.
+rules:
+  - name: uhd-hevc-encodes
+    priority: 100
+    profile: uhd-hevc
+    match:
+      command: ffmpeg
+      args:               # Regexes, all must match the command line
+        - '-c:v\s+libx265'
+        - '-s\s+3840x2160'
+  - name: live-ingest
+    priority: 50
+    profile: live-ingest
+    match:
+      output: '^rtmps?://'  # Regex on the output file or URL
+      parent: '^nginx'      # Regex on the parent process name
+      users: [video]
+      cwd: '^/srv/live(/|$)'
 `
@@ -16,6 +16,22 @@ const (
 	ErrorTypeResource            // Resource exhaustion
 )
 
+// String returns the error type name for logs
+func (t ErrorType) String() string {
+	switch t {
+	case ErrorTypeTransient:
+		return "transient"
+	case ErrorTypePermanent:
+		return "permanent"
+	case ErrorTypeRateLimit:
+		return "rate_limit"
+	case ErrorTypeResource:
+		return "resource"
+	default:
+		return "unknown"
+	}
+}
+
 // DiscoveryError wraps errors with context and categorization
 type DiscoveryError struct {
 	Type      ErrorType
@@ -0,0 +1,54 @@
+package discover
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DiscoveryMode selects how new processes are discovered
+type DiscoveryMode string
+
+const (
+	// DiscoveryAuto uses process events and falls back to polling when the
+	// kernel or the privileges of the daemon do not allow them
+	DiscoveryAuto DiscoveryMode = "auto"
+	// DiscoveryEvents uses process events and fails if they are unavailable
+	DiscoveryEvents DiscoveryMode = "events"
+	// DiscoveryPoll scans /proc every scan interval
+	DiscoveryPoll DiscoveryMode = "poll"
+)
+
+// DefaultRescanInterval is how often /proc is scanned while process events
+// are used, to catch processes whose events were lost
+const DefaultRescanInterval = 5 * time.Minute
+
+// ParseDiscoveryMode parses a discovery mode; an empty string is DiscoveryAuto
+func ParseDiscoveryMode(s string) (DiscoveryMode, error) {
+	switch mode := DiscoveryMode(s); mode {
+	case "":
+		return DiscoveryAuto, nil
+	case DiscoveryAuto, DiscoveryEvents, DiscoveryPoll:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid discovery mode %q (auto, events or poll)", s)
+}
+
+// ProcEventType is the kind of a process event
+type ProcEventType int
+
+const (
+	ProcEventExec ProcEventType = iota + 1 // A process executed a new program
+	ProcEventExit                          // A process exited
+	ProcEventLost                          // Events were dropped; rescan to catch up
+)
+
+// ProcEvent is a process exec or exit reported by the kernel
+type ProcEvent struct {
+	Type     ProcEventType
+	PID      int
+	ExitCode int // Exit code, or 128+signal for a killed process (exit events only)
+}
+
+// ErrProcEventsUnsupported is returned where the proc connector does not exist
+var ErrProcEventsUnsupported = errors.New("process events are only supported on Linux")
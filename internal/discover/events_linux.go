@@ -0,0 +1,166 @@
+package discover
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Linux proc connector (include/uapi/linux/connector.h, cn_proc.h)
+const (
+	cnIdxProc = 1
+	cnValProc = 1
+
+	procCnMcastListen = 1
+	procCnMcastIgnore = 2
+
+	procEventExec = 0x00000002
+	procEventExit = 0x80000000
+
+	cnMsgLen       = 20 // struct cn_msg without data
+	procEventHdr   = 16 // what, cpu, timestamp_ns
+	readBufferSize = 64 * 1024
+)
+
+// ProcEvents receives process exec and exit events from the kernel through
+// the netlink proc connector. It requires CAP_NET_ADMIN.
+type ProcEvents struct {
+	file *os.File
+	conn syscall.RawConn
+}
+
+// OpenProcEvents subscribes to process events
+func OpenProcEvents() (*ProcEvents, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind netlink socket: %w", err)
+	}
+
+	// The runtime poller lets Close interrupt a blocked Read
+	file := os.NewFile(uintptr(fd), "proc-connector")
+	conn, err := file.SyscallConn()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	e := &ProcEvents{file: file, conn: conn}
+	if err := e.control(procCnMcastListen); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to subscribe to process events: %w", err)
+	}
+	return e, nil
+}
+
+// Read blocks until events arrive and returns the exec events and the exits
+// of processes (not threads). A ProcEventLost event is returned when the
+// socket buffer overflowed.
+func (e *ProcEvents) Read() ([]ProcEvent, error) {
+	buf := make([]byte, readBufferSize)
+	for {
+		var n int
+		var from syscall.Sockaddr
+		var recvErr error
+		err := e.conn.Read(func(fd uintptr) bool {
+			n, from, recvErr = syscall.Recvfrom(int(fd), buf, 0)
+			return recvErr != syscall.EAGAIN
+		})
+		if err == nil {
+			err = recvErr
+		}
+		if err != nil {
+			switch err {
+			case syscall.EINTR:
+				continue
+			case syscall.ENOBUFS:
+				return []ProcEvent{{Type: ProcEventLost}}, nil
+			}
+			return nil, err
+		}
+		// Only the kernel may send process events
+		if sa, ok := from.(*syscall.SockaddrNetlink); !ok || sa.Pid != 0 {
+			continue
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		var events []ProcEvent
+		for _, msg := range msgs {
+			if ev, ok := parseProcEvent(msg.Data); ok {
+				events = append(events, ev)
+			}
+		}
+		if len(events) > 0 {
+			return events, nil
+		}
+	}
+}
+
+// Close unsubscribes and closes the socket
+func (e *ProcEvents) Close() error {
+	e.control(procCnMcastIgnore)
+	return e.file.Close()
+}
+
+// control sends a proc connector control operation
+func (e *ProcEvents) control(op uint32) error {
+	msg := make([]byte, syscall.NLMSG_HDRLEN+cnMsgLen+4)
+	ne := binary.NativeEndian
+
+	// struct nlmsghdr
+	ne.PutUint32(msg[0:], uint32(len(msg)))
+	ne.PutUint16(msg[4:], syscall.NLMSG_DONE)
+	// struct cn_msg
+	cn := msg[syscall.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], 4) // len
+	// enum proc_cn_mcast_op
+	ne.PutUint32(cn[cnMsgLen:], op)
+
+	var sendErr error
+	err := e.conn.Control(func(fd uintptr) {
+		sendErr = syscall.Sendto(int(fd), msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// parseProcEvent decodes a cn_msg carrying a struct proc_event
+func parseProcEvent(data []byte) (ProcEvent, bool) {
+	ne := binary.NativeEndian
+	if len(data) < cnMsgLen+procEventHdr+8 {
+		return ProcEvent{}, false
+	}
+	if ne.Uint32(data[0:]) != cnIdxProc || ne.Uint32(data[4:]) != cnValProc {
+		return ProcEvent{}, false
+	}
+	ev := data[cnMsgLen:]
+	what := ne.Uint32(ev[0:])
+	pid := int(ne.Uint32(ev[procEventHdr:]))    // process_pid (thread ID)
+	tgid := int(ne.Uint32(ev[procEventHdr+4:])) // process_tgid (process ID)
+
+	switch what {
+	case procEventExec:
+		return ProcEvent{Type: ProcEventExec, PID: tgid}, true
+	case procEventExit:
+		if pid != tgid || len(ev) < procEventHdr+12 {
+			return ProcEvent{}, false // A thread exited, not the process
+		}
+		status := syscall.WaitStatus(ne.Uint32(ev[procEventHdr+8:]))
+		code := status.ExitStatus()
+		if status.Signaled() {
+			code = 128 + int(status.Signal())
+		}
+		return ProcEvent{Type: ProcEventExit, PID: tgid, ExitCode: code}, true
+	}
+	return ProcEvent{}, false
+}
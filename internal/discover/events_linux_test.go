@@ -0,0 +1,111 @@
+package discover
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+)
+
+// procMsg builds a cn_msg carrying a struct proc_event. words follow the
+// event header: process_pid, process_tgid, then the event specific fields.
+func procMsg(what uint32, words ...uint32) []byte {
+	ne := binary.NativeEndian
+	data := make([]byte, cnMsgLen+procEventHdr+4*len(words))
+	ne.PutUint32(data[0:], cnIdxProc)
+	ne.PutUint32(data[4:], cnValProc)
+	ne.PutUint16(data[16:], uint16(procEventHdr+4*len(words)))
+
+	ev := data[cnMsgLen:]
+	ne.PutUint32(ev[0:], what)
+	for i, w := range words {
+		ne.PutUint32(ev[procEventHdr+4*i:], w)
+	}
+	return data
+}
+
+func TestParseProcEvent(t *testing.T) {
+	// Wait statuses as the kernel encodes them
+	exited := func(code uint32) uint32 { return code << 8 }
+	killed := func(sig syscall.Signal) uint32 { return uint32(sig) }
+
+	wrongIdx := procMsg(procEventExec, 100, 100)
+	binary.NativeEndian.PutUint32(wrongIdx[0:], cnIdxProc+1)
+
+	tests := []struct {
+		name string
+		data []byte
+		want ProcEvent
+		ok   bool
+	}{
+		{
+			name: "exec",
+			data: procMsg(procEventExec, 100, 100),
+			want: ProcEvent{Type: ProcEventExec, PID: 100},
+			ok:   true,
+		},
+		{
+			name: "exec reports the process, not the thread",
+			data: procMsg(procEventExec, 101, 100),
+			want: ProcEvent{Type: ProcEventExec, PID: 100},
+			ok:   true,
+		},
+		{
+			name: "normal exit",
+			data: procMsg(procEventExit, 100, 100, exited(3), 17),
+			want: ProcEvent{Type: ProcEventExit, PID: 100, ExitCode: 3},
+			ok:   true,
+		},
+		{
+			name: "successful exit",
+			data: procMsg(procEventExit, 100, 100, exited(0), 17),
+			want: ProcEvent{Type: ProcEventExit, PID: 100, ExitCode: 0},
+			ok:   true,
+		},
+		{
+			name: "signaled exit",
+			data: procMsg(procEventExit, 100, 100, killed(syscall.SIGKILL), 17),
+			want: ProcEvent{Type: ProcEventExit, PID: 100, ExitCode: 128 + int(syscall.SIGKILL)},
+			ok:   true,
+		},
+		{
+			name: "thread exit is ignored",
+			data: procMsg(procEventExit, 101, 100, exited(0), 17),
+		},
+		{
+			name: "other events are ignored",
+			data: procMsg(0x00000001, 100, 100, 1, 1), // PROC_EVENT_FORK
+		},
+		{
+			name: "other connector is ignored",
+			data: wrongIdx,
+		},
+		{
+			name: "empty buffer",
+			data: nil,
+		},
+		{
+			name: "cn_msg header only",
+			data: procMsg(procEventExec)[:cnMsgLen],
+		},
+		{
+			name: "truncated event header",
+			data: procMsg(procEventExec, 100, 100)[:cnMsgLen+procEventHdr+4],
+		},
+		{
+			name: "exit without exit code",
+			data: procMsg(procEventExit, 100, 100),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseProcEvent(tt.data)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package discover
+
+// ProcEvents receives process exec and exit events; Linux only
+type ProcEvents struct{}
+
+// OpenProcEvents returns ErrProcEventsUnsupported outside Linux
+func OpenProcEvents() (*ProcEvents, error) {
+	return nil, ErrProcEventsUnsupported
+}
+
+// Read never returns events outside Linux
+func (e *ProcEvents) Read() ([]ProcEvent, error) {
+	return nil, ErrProcEventsUnsupported
+}
+
+// Close does nothing outside Linux
+func (e *ProcEvents) Close() error {
+	return nil
+}
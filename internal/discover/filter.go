@@ -192,6 +192,19 @@ func (f *FilterConfig) checkRuntimeFilter(proc *Process, minRuntime, maxRuntime
 	return true
 }
 
+// TooYoung returns how much longer a process must run before the minimum
+// runtime filters can pass, or 0 if they already do
+func (f *FilterConfig) TooYoung(proc *Process) time.Duration {
+	minRuntime := f.MinRuntime
+	if cmdFilter, ok := f.CommandFilters[proc.Command]; ok && cmdFilter.MinRuntime > minRuntime {
+		minRuntime = cmdFilter.MinRuntime
+	}
+	if proc.ProcessAge >= minRuntime {
+		return 0
+	}
+	return minRuntime - proc.ProcessAge
+}
+
 // checkDirFilter checks working directory whitelist/blacklist
 func (f *FilterConfig) checkDirFilter(proc *Process) bool {
 	if proc.WorkingDir == "" {
@@ -0,0 +1,255 @@
+package discover
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	constraints "github.com/psantana5/ffmpeg-rtmp/pkg/wrapper"
+)
+
+// Profile is a named set of constraints for discovered processes
+type Profile struct {
+	CPUQuota    int   `yaml:"cpu_quota"`     // CPU quota percentage (e.g., 200 = 2 cores)
+	CPUWeight   int   `yaml:"cpu_weight"`    // CPU weight 1-10000 (default 100)
+	MemoryLimit int64 `yaml:"memory_limit"`  // Memory limit in MB
+	MemorySwap  int64 `yaml:"memory_swap"`   // Memory + swap limit in MB
+	IOWeight    int   `yaml:"io_weight"`     // IO weight percentage 1-100
+	Nice        int   `yaml:"nice"`          // Nice priority -20 to 19
+	OOMScoreAdj int   `yaml:"oom_score_adj"` // OOM score adjustment -1000 to 1000
+}
+
+// Constraints converts the profile to wrapper constraints
+func (p *Profile) Constraints() *constraints.Constraints {
+	c := constraints.DefaultConstraints()
+	c.CPUQuotaPercent = p.CPUQuota
+	if p.CPUWeight > 0 {
+		c.CPUWeight = p.CPUWeight
+	}
+	c.MemoryLimitMB = p.MemoryLimit
+	c.MemorySwapMB = p.MemorySwap
+	c.IOWeightPercent = p.IOWeight
+	c.NicePriority = p.Nice
+	c.OOMScoreAdj = p.OOMScoreAdj
+	c.Validate()
+	return c
+}
+
+// Rule selects a profile for the processes it matches.
+// Rules are evaluated by descending priority, then in file order;
+// the first match wins.
+type Rule struct {
+	Name     string    `yaml:"name"`
+	Priority int       `yaml:"priority"`
+	Profile  string    `yaml:"profile"`
+	Match    RuleMatch `yaml:"match"`
+}
+
+// RuleMatch holds the conditions of a rule. All set conditions must match.
+type RuleMatch struct {
+	Command string   `yaml:"command"` // Binary name, e.g. "ffmpeg"
+	Args    []string `yaml:"args"`    // Regexes, each must match the command line
+	Output  string   `yaml:"output"`  // Regex, must match one output (file or URL)
+	Users   []string `yaml:"users"`   // Process owner is one of these users
+	Parent  string   `yaml:"parent"`  // Regex, must match the parent process name
+	Cwd     string   `yaml:"cwd"`     // Regex, must match the working directory
+}
+
+// Match is the profile selected for a process
+type Match struct {
+	Rule        string // Rule name, or "command:<name>" for per-command limits
+	Profile     string
+	Constraints *constraints.Constraints
+}
+
+// compiledRule is a Rule with its regexes compiled
+type compiledRule struct {
+	name        string
+	priority    int
+	profile     string
+	constraints *constraints.Constraints
+	command     string
+	args        []*regexp.Regexp
+	output      *regexp.Regexp
+	users       []string
+	parent      *regexp.Regexp
+	cwd         *regexp.Regexp
+}
+
+// Policy matches discovered processes to constraint profiles.
+// Processes no rule matches get the limits of their command, if any.
+type Policy struct {
+	rules    []*compiledRule
+	commands map[string]*constraints.Constraints
+}
+
+// NewPolicy compiles rules against the named profiles
+func NewPolicy(profiles map[string]Profile, rules []Rule, commands map[string]CommandConfig) (*Policy, error) {
+	p := &Policy{commands: make(map[string]*constraints.Constraints)}
+
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule-%d", i+1)
+		}
+		profile, ok := profiles[rule.Profile]
+		if !ok {
+			return nil, fmt.Errorf("rule %s: unknown profile %q", name, rule.Profile)
+		}
+
+		compiled := &compiledRule{
+			name:        name,
+			priority:    rule.Priority,
+			profile:     rule.Profile,
+			constraints: profile.Constraints(),
+			command:     rule.Match.Command,
+			users:       rule.Match.Users,
+		}
+		for _, expr := range rule.Match.Args {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid args pattern: %w", name, err)
+			}
+			compiled.args = append(compiled.args, re)
+		}
+		var err error
+		if compiled.output, err = compileOptional(rule.Match.Output); err != nil {
+			return nil, fmt.Errorf("rule %s: invalid output pattern: %w", name, err)
+		}
+		if compiled.parent, err = compileOptional(rule.Match.Parent); err != nil {
+			return nil, fmt.Errorf("rule %s: invalid parent pattern: %w", name, err)
+		}
+		if compiled.cwd, err = compileOptional(rule.Match.Cwd); err != nil {
+			return nil, fmt.Errorf("rule %s: invalid cwd pattern: %w", name, err)
+		}
+		p.rules = append(p.rules, compiled)
+	}
+
+	// Highest priority first, file order among equals
+	sort.SliceStable(p.rules, func(i, j int) bool {
+		return p.rules[i].priority > p.rules[j].priority
+	})
+
+	for name, cmd := range commands {
+		if cmd.Limits == nil {
+			continue
+		}
+		p.commands[name] = (&Profile{
+			CPUQuota:    cmd.Limits.CPUQuota,
+			CPUWeight:   cmd.Limits.CPUWeight,
+			MemoryLimit: int64(cmd.Limits.MemoryLimit),
+		}).Constraints()
+	}
+
+	return p, nil
+}
+
+// Match returns the profile for a process, or nil to use the default limits
+func (p *Policy) Match(proc *Process) *Match {
+	if p == nil {
+		return nil
+	}
+	for _, rule := range p.rules {
+		if rule.matches(proc) {
+			return &Match{Rule: rule.name, Profile: rule.profile, Constraints: rule.constraints}
+		}
+	}
+	if c, ok := p.commands[proc.Command]; ok {
+		return &Match{Rule: "command:" + proc.Command, Constraints: c}
+	}
+	return nil
+}
+
+// RuleCount returns the number of rules
+func (p *Policy) RuleCount() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.rules)
+}
+
+// matches reports whether all set conditions of the rule hold for proc
+func (r *compiledRule) matches(proc *Process) bool {
+	if r.command != "" && r.command != proc.Command {
+		return false
+	}
+	if len(r.args) > 0 {
+		cmdline := strings.Join(proc.CommandLine, " ")
+		for _, re := range r.args {
+			if !re.MatchString(cmdline) {
+				return false
+			}
+		}
+	}
+	if r.output != nil && !matchesAny(r.output, processOutputs(proc)) {
+		return false
+	}
+	if len(r.users) > 0 && !containsString(r.users, proc.Username) {
+		return false
+	}
+	if r.parent != nil && !r.parent.MatchString(proc.ParentCommand) {
+		return false
+	}
+	if r.cwd != nil && !r.cwd.MatchString(proc.WorkingDir) {
+		return false
+	}
+	return true
+}
+
+// processOutputs returns the outputs of a process: for gst-launch the
+// location= properties, otherwise the last argument and every URL that is
+// not an -i input
+func processOutputs(proc *Process) []string {
+	args := proc.CommandLine
+	if len(args) < 2 {
+		return nil
+	}
+
+	var outputs []string
+	if strings.HasPrefix(filepath.Base(args[0]), "gst-launch") {
+		for _, arg := range args[1:] {
+			if strings.HasPrefix(arg, "location=") {
+				outputs = append(outputs, strings.TrimPrefix(arg, "location="))
+			}
+		}
+		return outputs
+	}
+
+	last := len(args) - 1
+	for i := 1; i < last; i++ {
+		if strings.Contains(args[i], "://") && args[i-1] != "-i" {
+			outputs = append(outputs, args[i])
+		}
+	}
+	if args[last-1] != "-i" {
+		outputs = append(outputs, args[last])
+	}
+	return outputs
+}
+
+func compileOptional(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+func matchesAny(re *regexp.Regexp, values []string) bool {
+	for _, v := range values {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
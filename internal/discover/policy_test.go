@@ -0,0 +1,209 @@
+package discover
+
+import (
+	"strings"
+	"testing"
+)
+
+var testProfiles = map[string]Profile{
+	"live":  {CPUQuota: 400, Nice: -5},
+	"batch": {CPUQuota: 100, Nice: 10, OOMScoreAdj: 500},
+	"small": {MemoryLimit: 512},
+}
+
+func ffmpegProcess() *Process {
+	return &Process{
+		PID:           100,
+		Command:       "ffmpeg",
+		CommandLine:   []string{"ffmpeg", "-i", "rtmp://in/live", "-c:v", "libx264", "rtmp://out/live/stream"},
+		Username:      "media",
+		ParentCommand: "supervisord",
+		WorkingDir:    "/srv/encode",
+	}
+}
+
+func TestPolicy_Matchers(t *testing.T) {
+	tests := []struct {
+		name  string
+		match RuleMatch
+		want  bool
+	}{
+		{"no conditions", RuleMatch{}, true},
+		{"command", RuleMatch{Command: "ffmpeg"}, true},
+		{"other command", RuleMatch{Command: "gst-launch-1.0"}, false},
+		{"args regex", RuleMatch{Args: []string{`-c:v libx26[45]`}}, true},
+		{"all args regexes must match", RuleMatch{Args: []string{`libx264`, `-preset`}}, false},
+		{"output regex", RuleMatch{Output: `^rtmp://out/`}, true},
+		{"input is not an output", RuleMatch{Output: `^rtmp://in/`}, false},
+		{"user", RuleMatch{Users: []string{"root", "media"}}, true},
+		{"other user", RuleMatch{Users: []string{"root"}}, false},
+		{"parent regex", RuleMatch{Parent: `^supervisor`}, true},
+		{"other parent", RuleMatch{Parent: `^systemd$`}, false},
+		{"cwd regex", RuleMatch{Cwd: `^/srv/`}, true},
+		{"other cwd", RuleMatch{Cwd: `^/tmp`}, false},
+		{"all conditions", RuleMatch{Command: "ffmpeg", Users: []string{"media"}, Parent: "supervisord", Cwd: "encode"}, true},
+		{"one failing condition", RuleMatch{Command: "ffmpeg", Users: []string{"media"}, Cwd: "^/tmp"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(testProfiles, []Rule{{Name: "rule", Profile: "live", Match: tt.match}}, nil)
+			if err != nil {
+				t.Fatalf("NewPolicy failed: %v", err)
+			}
+			got := policy.Match(ffmpegProcess())
+			if (got != nil) != tt.want {
+				t.Fatalf("Match = %+v, want match %v", got, tt.want)
+			}
+			if got != nil && (got.Rule != "rule" || got.Profile != "live" || got.Constraints.CPUQuotaPercent != 400) {
+				t.Errorf("Unexpected match %+v", got)
+			}
+		})
+	}
+}
+
+func TestPolicy_Ordering(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  string
+	}{
+		{
+			name: "first match in file order",
+			rules: []Rule{
+				{Name: "first", Profile: "live", Match: RuleMatch{Command: "ffmpeg"}},
+				{Name: "second", Profile: "batch", Match: RuleMatch{Command: "ffmpeg"}},
+			},
+			want: "first",
+		},
+		{
+			name: "higher priority wins over file order",
+			rules: []Rule{
+				{Name: "low", Profile: "live", Match: RuleMatch{Command: "ffmpeg"}},
+				{Name: "high", Priority: 10, Profile: "batch", Match: RuleMatch{Command: "ffmpeg"}},
+			},
+			want: "high",
+		},
+		{
+			name: "non-matching higher priority is skipped",
+			rules: []Rule{
+				{Name: "low", Profile: "live", Match: RuleMatch{Command: "ffmpeg"}},
+				{Name: "high", Priority: 10, Profile: "batch", Match: RuleMatch{Users: []string{"root"}}},
+			},
+			want: "low",
+		},
+		{
+			name: "file order among equal priorities",
+			rules: []Rule{
+				{Name: "a", Priority: 5, Profile: "live", Match: RuleMatch{Cwd: "/srv"}},
+				{Name: "b", Priority: 5, Profile: "batch", Match: RuleMatch{Command: "ffmpeg"}},
+				{Name: "c", Priority: 1, Profile: "small"},
+			},
+			want: "a",
+		},
+		{
+			name:  "unnamed rules are numbered",
+			rules: []Rule{{Profile: "live", Match: RuleMatch{Users: []string{"root"}}}, {Profile: "batch"}},
+			want:  "rule-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(testProfiles, tt.rules, nil)
+			if err != nil {
+				t.Fatalf("NewPolicy failed: %v", err)
+			}
+			got := policy.Match(ffmpegProcess())
+			if got == nil || got.Rule != tt.want {
+				t.Errorf("Match = %+v, want rule %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_CommandLimits(t *testing.T) {
+	commands := map[string]CommandConfig{
+		"ffmpeg":         {Limits: &ResourceLimits{CPUQuota: 150, MemoryLimit: 1024}},
+		"gst-launch-1.0": {}, // No limits, no fallback
+	}
+	policy, err := NewPolicy(testProfiles, []Rule{{Name: "root", Profile: "batch", Match: RuleMatch{Users: []string{"root"}}}}, commands)
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+
+	got := policy.Match(ffmpegProcess())
+	if got == nil || got.Rule != "command:ffmpeg" || got.Constraints.CPUQuotaPercent != 150 || got.Constraints.MemoryLimitMB != 1024 {
+		t.Errorf("Expected the ffmpeg command limits, got %+v", got)
+	}
+	if got := policy.Match(&Process{Command: "gst-launch-1.0", Username: "media"}); got != nil {
+		t.Errorf("Expected no match without rule or command limits, got %+v", got)
+	}
+}
+
+func TestPolicy_RuleCount(t *testing.T) {
+	var nilPolicy *Policy
+	if nilPolicy.RuleCount() != 0 || nilPolicy.Match(ffmpegProcess()) != nil {
+		t.Error("Expected a nil policy to have no rules and match nothing")
+	}
+
+	policy, err := NewPolicy(testProfiles, []Rule{
+		{Profile: "live"},
+		{Profile: "batch"},
+		{Profile: "small"},
+	}, map[string]CommandConfig{"ffmpeg": {Limits: &ResourceLimits{CPUQuota: 100}}})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+	if got := policy.RuleCount(); got != 3 {
+		t.Errorf("RuleCount = %d, want 3", got)
+	}
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{"unknown profile", Rule{Name: "r", Profile: "missing"}, `unknown profile "missing"`},
+		{"invalid args regex", Rule{Name: "r", Profile: "live", Match: RuleMatch{Args: []string{"("}}}, "invalid args pattern"},
+		{"invalid output regex", Rule{Name: "r", Profile: "live", Match: RuleMatch{Output: "[a-"}}, "invalid output pattern"},
+		{"invalid parent regex", Rule{Name: "r", Profile: "live", Match: RuleMatch{Parent: "*"}}, "invalid parent pattern"},
+		{"invalid cwd regex", Rule{Name: "r", Profile: "live", Match: RuleMatch{Cwd: "a)"}}, "invalid cwd pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(testProfiles, []Rule{tt.rule}, nil)
+			if err == nil {
+				t.Fatalf("Expected an error, got policy with %d rules", policy.RuleCount())
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) || !strings.HasPrefix(err.Error(), "rule r:") {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdline []string
+		want    []string
+	}{
+		{"file output", []string{"ffmpeg", "-i", "in.mp4", "out.mp4"}, []string{"out.mp4"}},
+		{"tee of urls", []string{"ffmpeg", "-i", "rtmp://in/a", "-f", "flv", "rtmp://out/a", "-f", "flv", "rtmp://out/b"}, []string{"rtmp://out/a", "rtmp://out/b"}},
+		{"gst-launch locations", []string{"gst-launch-1.0", "filesrc", "location=in.mp4", "!", "filesink", "location=out.mp4"}, []string{"in.mp4", "out.mp4"}},
+		{"no arguments", []string{"ffmpeg"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := processOutputs(&Process{CommandLine: tt.cmdline})
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("processOutputs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	constraints "github.com/psantana5/ffmpeg-rtmp/pkg/wrapper"
+)
+
+// ReloadDebounce is how long a changed config file must stay unchanged
+// before it is reloaded, so editors writing in several steps reload once
+const ReloadDebounce = 500 * time.Millisecond
+
+// defaultWeight is the CPU and IO weight of a cgroup that sets none
+const defaultWeight = 100
+
+// ReloadPlan is what reloading a configuration changes
+type ReloadPlan struct {
+	Settings []string       // Changed service settings, e.g. "scan_interval: 10s → 5s"
+	Changes  []ReloadChange // Attached processes whose constraints change or that are detached
+	Attached int            // Attached processes when the plan was made
+}
+
+// ReloadChange is the reconciliation of one attached process
+type ReloadChange struct {
+	PID     int
+	JobID   string
+	Command string
+
+	// Detach is set if the process no longer matches the target commands or
+	// filters; Reason says why
+	Detach bool
+	Reason string
+
+	OldProfile string // Empty for the default limits
+	NewProfile string
+	Limits     []string // Changed limits, e.g. "memory_max: 4096MB → 8192MB"
+
+	limits *cgroups.Limits // Limits to write to the cgroup
+	match  *Match
+}
+
+// reloadRequest asks Start to apply a configuration
+type reloadRequest struct {
+	config *AttachConfig
+	filter *FilterConfig
+	reply  chan *ReloadPlan
+}
+
+// PlanReload returns what Reload would change, without changing anything
+func (s *AutoAttachService) PlanReload(config *AttachConfig, filter *FilterConfig) *ReloadPlan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.planReload(config, filter)
+}
+
+// Reload applies a new configuration: target commands, filters, profile
+// rules, default limits and scan intervals. Attached processes that no
+// longer match are detached and the cgroups of the others are reconciled
+// with their new limits. The discovery mode only changes on restart.
+// Start must be running.
+func (s *AutoAttachService) Reload(config *AttachConfig, filter *FilterConfig) (*ReloadPlan, error) {
+	req := reloadRequest{config: config, filter: filter, reply: make(chan *ReloadPlan, 1)}
+	select {
+	case s.reloadCh <- req:
+	case <-s.stopCh:
+		return nil, errors.New("auto-attach service stopped")
+	}
+	return <-req.reply, nil
+}
+
+// planReload compares the running configuration and attached processes with
+// a new configuration; s.mu must be held
+func (s *AutoAttachService) planReload(config *AttachConfig, filter *FilterConfig) *ReloadPlan {
+	plan := &ReloadPlan{Attached: len(s.attachments)}
+
+	old := s.config
+	if config.ScanInterval != 0 && config.ScanInterval != old.ScanInterval {
+		plan.Settings = append(plan.Settings, fmt.Sprintf("scan_interval: %v → %v", old.ScanInterval, config.ScanInterval))
+	}
+	if config.RescanInterval != 0 && config.RescanInterval != old.RescanInterval {
+		plan.Settings = append(plan.Settings, fmt.Sprintf("rescan_interval: %v → %v", old.RescanInterval, config.RescanInterval))
+	}
+	if config.Discovery != "" && config.Discovery != old.Discovery {
+		plan.Settings = append(plan.Settings, fmt.Sprintf("discovery: %s → %s (applies on restart)", old.Discovery, config.Discovery))
+	}
+	targets := config.TargetCommands
+	if len(targets) == 0 {
+		targets = s.scanner.targetCommands
+	}
+	if !slices.Equal(targets, s.scanner.targetCommands) {
+		plan.Settings = append(plan.Settings, fmt.Sprintf("target_commands: %v → %v", s.scanner.targetCommands, targets))
+	}
+	if rules := config.Policy.RuleCount(); rules != old.Policy.RuleCount() {
+		plan.Settings = append(plan.Settings, fmt.Sprintf("profile rules: %d → %d", old.Policy.RuleCount(), rules))
+	}
+	defaults := config.DefaultLimits
+	if defaults == nil {
+		defaults = old.DefaultLimits
+	}
+	if diff := limitsDiff(old.DefaultLimits, defaults); len(diff) > 0 {
+		plan.Settings = append(plan.Settings, "default_limits: "+strings.Join(diff, ", "))
+	}
+
+	pids := make([]int, 0, len(s.attachments))
+	for pid := range s.attachments {
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+
+	for _, pid := range pids {
+		a := s.attachments[pid]
+		change := ReloadChange{PID: pid, JobID: a.jobID, Command: a.proc.Command, OldProfile: a.match.profile()}
+
+		// Filters see the process as it is now
+		proc := *a.proc
+		if !proc.StartTime.IsZero() {
+			proc.ProcessAge = time.Since(proc.StartTime)
+		}
+		switch {
+		case !slices.Contains(targets, proc.Command):
+			change.Detach, change.Reason = true, "command no longer targeted"
+		case filter != nil && !filter.ShouldDiscover(&proc):
+			change.Detach, change.Reason = true, "no longer matches filters"
+		}
+		if change.Detach {
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		change.match = config.Policy.Match(&proc)
+		change.NewProfile = change.match.profile()
+		limits := defaults
+		if change.match != nil {
+			limits = change.match.Constraints.Limits()
+		}
+		change.limits, change.Limits = reconcileLimits(a.limits, limits)
+		if change.OldProfile != change.NewProfile || len(change.Limits) > 0 || processConstraintsChanged(a.match, change.match) {
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+	return plan
+}
+
+// reload applies a configuration; called by Start
+func (s *AutoAttachService) reload(config *AttachConfig, filter *FilterConfig) *ReloadPlan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan := s.planReload(config, filter)
+
+	if config.ScanInterval != 0 {
+		s.config.ScanInterval = config.ScanInterval
+	}
+	if config.RescanInterval != 0 {
+		s.config.RescanInterval = config.RescanInterval
+	}
+	if config.DefaultLimits != nil {
+		s.config.DefaultLimits = config.DefaultLimits
+	}
+	s.config.Policy = config.Policy
+	if len(config.TargetCommands) > 0 {
+		s.scanner.targetCommands = config.TargetCommands
+	}
+	if filter != nil {
+		s.scanner.SetFilter(filter)
+	}
+
+	mgr := cgroups.New()
+	for _, change := range plan.Changes {
+		a := s.attachments[change.PID]
+		if a == nil {
+			continue // Exited since the plan was made
+		}
+		if change.Detach {
+			s.logger.Printf("Detaching from PID %d (%s): %s", change.PID, change.Command, change.Reason)
+			a.cancel()
+			continue
+		}
+
+		if len(change.Limits) > 0 {
+			outcomes, err := mgr.Update(a.jobID, change.limits)
+			if err != nil {
+				s.logger.Printf("Failed to update limits of PID %d: %v", change.PID, err)
+			}
+			for _, o := range outcomes {
+				if o.Status != cgroups.StatusApplied {
+					s.logger.Printf("Limit %s of PID %d %s: %s", o.Limit, change.PID, o.Status, o.Reason)
+				}
+			}
+		}
+		if change.match != nil && processConstraintsChanged(a.match, change.match) {
+			if err := constraints.ApplyNicePriority(change.PID, change.match.Constraints.NicePriority); err != nil {
+				s.logger.Printf("Failed to set nice priority of PID %d: %v", change.PID, err)
+			}
+			if err := constraints.ApplyOOMScoreAdj(change.PID, change.match.Constraints.OOMScoreAdj); err != nil {
+				s.logger.Printf("Failed to set OOM score of PID %d: %v", change.PID, err)
+			}
+		}
+		a.limits = change.limits
+		a.match = change.match
+	}
+
+	s.logger.Printf("Configuration reloaded: %d setting(s) and %d process(es) changed",
+		len(plan.Settings), len(plan.Changes))
+	return plan
+}
+
+// String formats the plan as a diff for operators
+func (p *ReloadPlan) String() string {
+	var b strings.Builder
+	if len(p.Settings) == 0 && len(p.Changes) == 0 {
+		fmt.Fprintf(&b, "No changes (%d attached process(es))\n", p.Attached)
+		return b.String()
+	}
+	for _, setting := range p.Settings {
+		fmt.Fprintf(&b, "~ %s\n", setting)
+	}
+	for _, c := range p.Changes {
+		if c.Detach {
+			fmt.Fprintf(&b, "- PID %d (%s, job %s): detach, %s\n", c.PID, c.Command, c.JobID, c.Reason)
+			continue
+		}
+		profile := profileName(c.NewProfile)
+		if c.OldProfile != c.NewProfile {
+			profile = profileName(c.OldProfile) + " → " + profile
+		}
+		fmt.Fprintf(&b, "~ PID %d (%s, job %s): profile %s\n", c.PID, c.Command, c.JobID, profile)
+		for _, limit := range c.Limits {
+			fmt.Fprintf(&b, "    %s\n", limit)
+		}
+	}
+	fmt.Fprintf(&b, "%d of %d attached process(es) change\n", len(p.Changes), p.Attached)
+	return b.String()
+}
+
+// profile returns the profile of a match, empty for the default limits
+func (m *Match) profile() string {
+	if m == nil {
+		return ""
+	}
+	if m.Profile == "" {
+		return m.Rule // Per-command limits
+	}
+	return m.Profile
+}
+
+func profileName(profile string) string {
+	if profile == "" {
+		return "default"
+	}
+	return profile
+}
+
+// processConstraintsChanged reports whether the nice priority or OOM score of
+// the new match differ from the old one. Without a new match the process
+// keeps what it has.
+func processConstraintsChanged(old, new *Match) bool {
+	if new == nil {
+		return false
+	}
+	if old == nil {
+		return true
+	}
+	return old.Constraints.NicePriority != new.Constraints.NicePriority ||
+		old.Constraints.OOMScoreAdj != new.Constraints.OOMScoreAdj
+}
+
+// reconcileLimits returns the limits to write to move a cgroup from old to
+// new and the changes. A CPU quota that is removed is lifted and removed
+// weights return to the default; memory and IO limits that are removed are
+// kept, since lifting them is not reversible for a workload that grows into
+// the space.
+func reconcileLimits(old, new *cgroups.Limits) (*cgroups.Limits, []string) {
+	if old == nil {
+		old = &cgroups.Limits{}
+	}
+	target := new.Merge(nil)
+	if old.CPUMax != "" && target.CPUMax == "" {
+		target.CPUMax = "max"
+	}
+	if old.CPUWeight > 0 && target.CPUWeight == 0 {
+		target.CPUWeight = defaultWeight
+	}
+	if old.IOWeight > 0 && target.IOWeight == 0 {
+		target.IOWeight = defaultWeight
+	}
+
+	// Keep what cannot be lifted
+	var kept []string
+	if target.MemoryMax == 0 && old.MemoryMax > 0 {
+		target.MemoryMax = old.MemoryMax
+		kept = append(kept, "memory_max")
+	}
+	if target.MemorySwapMax == 0 && old.MemorySwapMax > 0 {
+		target.MemorySwapMax = old.MemorySwapMax
+		kept = append(kept, "memory_swap_max")
+	}
+	if target.IOMax == "" && old.IOMax != "" {
+		target.IOMax = old.IOMax
+		kept = append(kept, "io_max")
+	}
+
+	if reflect.DeepEqual(old, target) {
+		return target, nil
+	}
+	diff := limitsDiff(old, target)
+	for _, limit := range kept {
+		diff = append(diff, limit+": kept (cannot be lifted while attached)")
+	}
+	return target, diff
+}
+
+// limitsDiff describes the limits that differ between old and new
+func limitsDiff(old, new *cgroups.Limits) []string {
+	if old == nil {
+		old = &cgroups.Limits{}
+	}
+	if new == nil {
+		new = &cgroups.Limits{}
+	}
+	var diff []string
+	add := func(limit, from, to string) {
+		if from != to {
+			diff = append(diff, fmt.Sprintf("%s: %s → %s", limit, from, to))
+		}
+	}
+	add("cpu_max", orNone(old.CPUMax), orNone(new.CPUMax))
+	add("cpu_weight", formatInt(int64(old.CPUWeight), ""), formatInt(int64(new.CPUWeight), ""))
+	add("memory_max", formatInt(old.MemoryMax/cgroups.MiB, "MB"), formatInt(new.MemoryMax/cgroups.MiB, "MB"))
+	add("memory_swap_max", formatInt(old.MemorySwapMax/cgroups.MiB, "MB"), formatInt(new.MemorySwapMax/cgroups.MiB, "MB"))
+	add("io_max", orNone(old.IOMax), orNone(new.IOMax))
+	add("io_weight", formatInt(int64(old.IOWeight), ""), formatInt(int64(new.IOWeight), ""))
+	return diff
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func formatInt(n int64, unit string) string {
+	if n <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d%s", n, unit)
+}
+
+// WatchConfigFile calls onChange whenever the file at path is written,
+// replaced or recreated, until ctx is done. The directory is watched so
+// editors that save by renaming a new file over the old one are seen.
+func WatchConfigFile(ctx context.Context, path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(ReloadDebounce)
+				}
+			case <-debounce:
+				debounce = nil
+				onChange()
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,325 @@
+package discover
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+)
+
+// newReloadService returns a service with the given attached processes
+func newReloadService(t *testing.T, config *AttachConfig, attached ...*attachment) *AutoAttachService {
+	t.Helper()
+	config.Logger = log.New(io.Discard, "", 0)
+	s := NewAutoAttachService(config)
+	for _, a := range attached {
+		if a.cancel == nil {
+			a.cancel = func() {}
+		}
+		s.attachments[a.proc.PID] = a
+	}
+	return s
+}
+
+func mustPolicy(t *testing.T, profiles map[string]Profile, rules ...Rule) *Policy {
+	t.Helper()
+	policy, err := NewPolicy(profiles, rules, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+	return policy
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "watch.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReload_InvalidConfigKeepsRunningConfig(t *testing.T) {
+	config, filter, err := LoadAttachConfig(writeConfig(t, `
+scan_interval: 10s
+target_commands: [ffmpeg]
+profiles:
+  live: {cpu_quota: 200}
+rules:
+  - name: live
+    profile: live
+    match: {args: ["rtmp://"]}
+`))
+	if err != nil {
+		t.Fatalf("LoadAttachConfig failed: %v", err)
+	}
+	s := newReloadService(t, config)
+	s.scanner.SetFilter(filter)
+	before := *s.config
+
+	invalid := []string{
+		"scan_interval: soon\n",
+		"rules:\n  - {profile: missing}\n",
+		"profiles:\n  live: {}\nrules:\n  - {profile: live, match: {cwd: \"(\"}}\n",
+		"target_commands: [ffmpeg\n",
+	}
+	for _, content := range invalid {
+		if _, _, err := LoadAttachConfig(writeConfig(t, content)); err == nil {
+			t.Errorf("Expected %q to be rejected", content)
+		}
+	}
+
+	// Planning a valid change does not apply it either
+	plan := s.PlanReload(&AttachConfig{ScanInterval: time.Second, TargetCommands: []string{"gst-launch-1.0"}}, nil)
+	if len(plan.Settings) != 3 { // Scan interval, target commands and the dropped rule
+		t.Errorf("Expected 3 changed settings, got %v", plan.Settings)
+	}
+	if !reflect.DeepEqual(*s.config, before) || !reflect.DeepEqual(s.scanner.targetCommands, []string{"ffmpeg"}) {
+		t.Error("Expected the running configuration to be untouched")
+	}
+}
+
+func TestReload_Detach(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *AttachConfig
+		filter *FilterConfig
+		reason string
+	}{
+		{
+			name:   "removed target command",
+			config: &AttachConfig{TargetCommands: []string{"gst-launch-1.0"}},
+			reason: "command no longer targeted",
+		},
+		{
+			name:   "failed filter",
+			config: &AttachConfig{},
+			filter: &FilterConfig{BlockedUsers: []string{"media"}},
+			reason: "no longer matches filters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canceled := false
+			s := newReloadService(t, &AttachConfig{TargetCommands: []string{"ffmpeg"}}, &attachment{
+				proc:   ffmpegProcess(),
+				jobID:  "job-1",
+				limits: &cgroups.Limits{CPUWeight: 100},
+				cancel: func() { canceled = true },
+			})
+
+			plan := s.reload(tt.config, tt.filter)
+			if len(plan.Changes) != 1 {
+				t.Fatalf("Expected 1 change, got %+v", plan.Changes)
+			}
+			change := plan.Changes[0]
+			if !change.Detach || change.Reason != tt.reason || change.PID != 100 {
+				t.Errorf("Unexpected change %+v", change)
+			}
+			if !canceled {
+				t.Error("Expected the attachment to be canceled")
+			}
+		})
+	}
+}
+
+func TestReconcileLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		old  *cgroups.Limits
+		new  *cgroups.Limits
+		want *cgroups.Limits
+		diff []string
+	}{
+		{
+			name: "unchanged",
+			old:  &cgroups.Limits{CPUMax: "200000 100000", CPUWeight: 500},
+			new:  &cgroups.Limits{CPUMax: "200000 100000", CPUWeight: 500},
+			want: &cgroups.Limits{CPUMax: "200000 100000", CPUWeight: 500},
+		},
+		{
+			name: "removed cpu quota is lifted",
+			old:  &cgroups.Limits{CPUMax: "200000 100000"},
+			new:  &cgroups.Limits{},
+			want: &cgroups.Limits{CPUMax: "max"},
+			diff: []string{"cpu_max: 200000 100000 → max"},
+		},
+		{
+			name: "removed weights return to the default",
+			old:  &cgroups.Limits{CPUWeight: 500, IOWeight: 2000},
+			new:  &cgroups.Limits{},
+			want: &cgroups.Limits{CPUWeight: 100, IOWeight: 100},
+			diff: []string{"cpu_weight: 500 → 100", "io_weight: 2000 → 100"},
+		},
+		{
+			name: "removed memory and io limits are kept",
+			old:  &cgroups.Limits{MemoryMax: 4096 * cgroups.MiB, MemorySwapMax: 1024 * cgroups.MiB, IOMax: "8:0 wbps=1048576"},
+			new:  &cgroups.Limits{CPUWeight: 200},
+			want: &cgroups.Limits{CPUWeight: 200, MemoryMax: 4096 * cgroups.MiB, MemorySwapMax: 1024 * cgroups.MiB, IOMax: "8:0 wbps=1048576"},
+			diff: []string{
+				"cpu_weight: none → 200",
+				"memory_max: kept (cannot be lifted while attached)",
+				"memory_swap_max: kept (cannot be lifted while attached)",
+				"io_max: kept (cannot be lifted while attached)",
+			},
+		},
+		{
+			name: "memory limit is raised",
+			old:  &cgroups.Limits{MemoryMax: 4096 * cgroups.MiB},
+			new:  &cgroups.Limits{MemoryMax: 8192 * cgroups.MiB},
+			want: &cgroups.Limits{MemoryMax: 8192 * cgroups.MiB},
+			diff: []string{"memory_max: 4096MB → 8192MB"},
+		},
+		{
+			name: "nothing attached before",
+			old:  nil,
+			new:  &cgroups.Limits{CPUMax: "50000 100000"},
+			want: &cgroups.Limits{CPUMax: "50000 100000"},
+			diff: []string{"cpu_max: none → 50000 100000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diff := reconcileLimits(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("limits = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(diff, tt.diff) {
+				t.Errorf("diff = %q, want %q", diff, tt.diff)
+			}
+		})
+	}
+}
+
+func TestReload_ReconcilesLimits(t *testing.T) {
+	old := &cgroups.Limits{CPUMax: "200000 100000", MemoryMax: 2048 * cgroups.MiB}
+	s := newReloadService(t, &AttachConfig{TargetCommands: []string{"ffmpeg"}, DefaultLimits: old}, &attachment{
+		proc:   ffmpegProcess(),
+		jobID:  "job-1",
+		limits: old,
+	})
+
+	plan := s.reload(&AttachConfig{DefaultLimits: &cgroups.Limits{CPUWeight: 300}}, nil)
+	if len(plan.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %+v", plan.Changes)
+	}
+	want := []string{
+		"cpu_max: 200000 100000 → max",
+		"cpu_weight: none → 300",
+		"memory_max: kept (cannot be lifted while attached)",
+	}
+	if !reflect.DeepEqual(plan.Changes[0].Limits, want) {
+		t.Errorf("Limits = %q, want %q", plan.Changes[0].Limits, want)
+	}
+
+	// The attachment records what was written to its cgroup
+	got := s.attachments[100].limits
+	if got.CPUMax != "max" || got.CPUWeight != 300 || got.MemoryMax != 2048*cgroups.MiB {
+		t.Errorf("Unexpected attachment limits %+v", got)
+	}
+}
+
+func TestReload_ProfileChangeAppliesProcessConstraints(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start a process: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	profiles := map[string]Profile{
+		"live":  {CPUQuota: 400},
+		"batch": {CPUQuota: 100, Nice: 7, OOMScoreAdj: 300},
+	}
+	proc := ffmpegProcess()
+	proc.PID = cmd.Process.Pid
+	s := newReloadService(t, &AttachConfig{TargetCommands: []string{"ffmpeg"}}, &attachment{
+		proc:   proc,
+		jobID:  "job-1",
+		limits: &cgroups.Limits{CPUMax: "400000 100000"},
+		match:  mustPolicy(t, profiles, Rule{Name: "r", Profile: "live"}).Match(proc),
+	})
+
+	plan := s.reload(&AttachConfig{Policy: mustPolicy(t, profiles, Rule{Name: "r", Profile: "batch"})}, nil)
+	if len(plan.Changes) != 1 || plan.Changes[0].OldProfile != "live" || plan.Changes[0].NewProfile != "batch" {
+		t.Fatalf("Expected a profile change live → batch, got %+v", plan.Changes)
+	}
+	if s.attachments[proc.PID].match.Profile != "batch" {
+		t.Error("Expected the attachment to record the new profile")
+	}
+
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(proc.PID), "stat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+2:]))
+	if nice := fields[16]; nice != "7" {
+		t.Errorf("nice = %s, want 7", nice)
+	}
+	oom, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(proc.PID), "oom_score_adj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(oom)); got != "300" {
+		t.Errorf("oom_score_adj = %s, want 300", got)
+	}
+}
+
+func TestReloadPlan_String(t *testing.T) {
+	tests := []struct {
+		name string
+		plan *ReloadPlan
+		want string
+	}{
+		{
+			name: "no changes",
+			plan: &ReloadPlan{Attached: 2},
+			want: "No changes (2 attached process(es))\n",
+		},
+		{
+			name: "settings and processes",
+			plan: &ReloadPlan{
+				Attached: 3,
+				Settings: []string{"scan_interval: 10s → 5s"},
+				Changes: []ReloadChange{
+					{PID: 10, JobID: "job-10", Command: "ffmpeg", Detach: true, Reason: "command no longer targeted"},
+					{PID: 11, JobID: "job-11", Command: "ffmpeg", OldProfile: "", NewProfile: "live", Limits: []string{"cpu_max: none → 400000 100000"}},
+					{PID: 12, JobID: "job-12", Command: "ffmpeg", OldProfile: "batch", NewProfile: "batch"},
+				},
+			},
+			want: "~ scan_interval: 10s → 5s\n" +
+				"- PID 10 (ffmpeg, job job-10): detach, command no longer targeted\n" +
+				"~ PID 11 (ffmpeg, job job-11): profile default → live\n" +
+				"    cpu_max: none → 400000 100000\n" +
+				"~ PID 12 (ffmpeg, job job-12): profile batch\n" +
+				"3 of 3 attached process(es) change\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.plan.String(); got != tt.want {
+				t.Errorf("String() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Reload hands the request to Start; a stopped service refuses it
+func TestReload_Stopped(t *testing.T) {
+	s := newReloadService(t, &AttachConfig{})
+	close(s.stopCh)
+	if _, err := s.Reload(&AttachConfig{}, nil); err == nil {
+		t.Error("Expected Reload to fail on a stopped service")
+	}
+}
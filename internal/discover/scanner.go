@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -22,6 +23,7 @@ type Process struct {
 	UserID         int           // UID of process owner
 	Username       string        // Username of process owner
 	ParentPID      int           // Parent process ID
+	ParentCommand  string        // Parent process name
 	WorkingDir     string        // Current working directory
 	ProcessAge     time.Duration // Time since process started
 }
@@ -29,6 +31,7 @@ type Process struct {
 // Scanner discovers running FFmpeg/transcoding processes
 type Scanner struct {
 	targetCommands []string
+	trackedMu      sync.Mutex       // Guards trackedPIDs, shared with attach goroutines
 	trackedPIDs    map[int]bool
 	ownPID         int              // Scanner's own PID (to filter out self)
 	excludePPIDs   map[int]bool     // Parent PIDs to exclude
@@ -61,8 +64,7 @@ func (s *Scanner) ExcludeParentPID(ppid int) {
 
 // ScanRunningProcesses discovers all matching processes
 func (s *Scanner) ScanRunningProcesses() ([]*Process, error) {
-	procDir := "/proc"
-	entries, err := os.ReadDir(procDir)
+	entries, err := os.ReadDir("/proc")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read /proc: %w", err)
 	}
@@ -75,79 +77,15 @@ func (s *Scanner) ScanRunningProcesses() ([]*Process, error) {
 			continue
 		}
 
-		pidStr := entry.Name()
-		pid, err := strconv.Atoi(pidStr)
+		pid, err := strconv.Atoi(entry.Name())
 		if err != nil {
 			continue
 		}
 
-		// Read command line
-		cmdlinePath := filepath.Join(procDir, pidStr, "cmdline")
-		cmdlineBytes, err := os.ReadFile(cmdlinePath)
-		if err != nil {
-			continue // Process may have exited
-		}
-
-		// Parse command line (null-separated)
-		cmdline := string(cmdlineBytes)
-		if cmdline == "" {
-			continue
-		}
-
-		parts := strings.Split(cmdline, "\x00")
-		if len(parts) == 0 {
+		proc := s.Inspect(pid)
+		if proc == nil {
 			continue
 		}
-
-		// Check if this is a target command
-		command := filepath.Base(parts[0])
-		if !s.isTargetCommand(command) {
-			continue
-		}
-
-		// Filter out our own PID
-		if pid == s.ownPID {
-			continue
-		}
-
-		// Get parent PID and check if we should exclude it
-		statPath := filepath.Join(procDir, pidStr, "stat")
-		ppid := s.getParentPID(statPath)
-		if s.excludePPIDs[ppid] {
-			continue
-		}
-
-		// Get process start time
-		startTime, err := s.getProcessStartTime(statPath)
-		if err != nil {
-			startTime = time.Time{}
-		}
-		
-		// Calculate process age
-		processAge := time.Duration(0)
-		if !startTime.IsZero() {
-			processAge = time.Since(startTime)
-		}
-		
-		// Get user ID and username
-		uid := s.getUserID(filepath.Join(procDir, pidStr))
-		username := s.getUsername(uid)
-		
-		// Get working directory
-		workingDir := s.getWorkingDir(filepath.Join(procDir, pidStr, "cwd"))
-
-		proc := &Process{
-			PID:         pid,
-			Command:     command,
-			CommandLine: parts,
-			StartTime:   startTime,
-			Monitored:   s.trackedPIDs[pid],
-			UserID:      uid,
-			Username:    username,
-			ParentPID:   ppid,
-			WorkingDir:  workingDir,
-			ProcessAge:  processAge,
-		}
 		
 		// Apply filtering rules
 		if !s.filter.ShouldDiscover(proc) {
@@ -160,18 +98,100 @@ func (s *Scanner) ScanRunningProcesses() ([]*Process, error) {
 	return processes, nil
 }
 
+// Inspect reads a single process from /proc. It returns nil if the process
+// is gone, is not a target command or is excluded; filters are not applied.
+func (s *Scanner) Inspect(pid int) *Process {
+	procDir := "/proc"
+	pidStr := strconv.Itoa(pid)
+
+	// Read command line
+	cmdlinePath := filepath.Join(procDir, pidStr, "cmdline")
+	cmdlineBytes, err := os.ReadFile(cmdlinePath)
+	if err != nil {
+		return nil // Process may have exited
+	}
+
+	// Parse command line (null-separated)
+	cmdline := string(cmdlineBytes)
+	if cmdline == "" {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimRight(cmdline, "\x00"), "\x00")
+	if len(parts) == 0 {
+		return nil
+	}
+
+	// Check if this is a target command
+	command := filepath.Base(parts[0])
+	if !s.isTargetCommand(command) {
+		return nil
+	}
+
+	// Filter out our own PID
+	if pid == s.ownPID {
+		return nil
+	}
+
+	// Get parent PID and check if we should exclude it
+	statPath := filepath.Join(procDir, pidStr, "stat")
+	ppid := s.getParentPID(statPath)
+	if s.excludePPIDs[ppid] {
+		return nil
+	}
+
+	// Get process start time
+	startTime, err := s.getProcessStartTime(statPath)
+	if err != nil {
+		startTime = time.Time{}
+	}
+	
+	// Calculate process age
+	processAge := time.Duration(0)
+	if !startTime.IsZero() {
+		processAge = time.Since(startTime)
+	}
+	
+	// Get user ID and username
+	uid := s.getUserID(filepath.Join(procDir, pidStr))
+	username := s.getUsername(uid)
+	
+	// Get working directory
+	workingDir := s.getWorkingDir(filepath.Join(procDir, pidStr, "cwd"))
+
+	return &Process{
+		PID:         pid,
+		Command:     command,
+		CommandLine: parts,
+		StartTime:   startTime,
+		Monitored:   s.IsTracked(pid),
+		UserID:      uid,
+		Username:    username,
+		ParentPID:   ppid,
+		ParentCommand: s.getCommandName(filepath.Join(procDir, strconv.Itoa(ppid), "comm")),
+		WorkingDir:  workingDir,
+		ProcessAge:  processAge,
+	}
+}
+
 // MarkAsTracked marks a PID as being monitored
 func (s *Scanner) MarkAsTracked(pid int) {
+	s.trackedMu.Lock()
+	defer s.trackedMu.Unlock()
 	s.trackedPIDs[pid] = true
 }
 
 // UnmarkTracked removes a PID from tracked list
 func (s *Scanner) UnmarkTracked(pid int) {
+	s.trackedMu.Lock()
+	defer s.trackedMu.Unlock()
 	delete(s.trackedPIDs, pid)
 }
 
 // IsTracked checks if a PID is already being monitored
 func (s *Scanner) IsTracked(pid int) bool {
+	s.trackedMu.Lock()
+	defer s.trackedMu.Unlock()
 	return s.trackedPIDs[pid]
 }
 
@@ -184,7 +204,7 @@ func (s *Scanner) GetNewProcesses() ([]*Process, error) {
 
 	var newProcesses []*Process
 	for _, proc := range allProcesses {
-		if !s.trackedPIDs[proc.PID] {
+		if !s.IsTracked(proc.PID) {
 			newProcesses = append(newProcesses, proc)
 		}
 	}
@@ -281,6 +301,15 @@ func (s *Scanner) getParentPID(statPath string) int {
 	return ppid
 }
 
+// getCommandName reads the name of a process from /proc/[pid]/comm
+func (s *Scanner) getCommandName(commPath string) string {
+	data, err := os.ReadFile(commPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
 // getUserID extracts the UID of the process owner from /proc/[pid]
 func (s *Scanner) getUserID(procPath string) int {
 	fileInfo, err := os.Stat(procPath)
@@ -0,0 +1,44 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestScanner_ConcurrentTracking runs attach-style tracking updates while
+// processes are inspected; run with -race
+func TestScanner_ConcurrentTracking(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner([]string{filepath.Base(self)})
+	pid := os.Getpid()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.MarkAsTracked(pid + j)
+				s.UnmarkTracked(pid + j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				s.Inspect(pid)
+				s.IsTracked(pid)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.MarkAsTracked(pid)
+	if proc := s.Inspect(pid); proc != nil && !proc.Monitored {
+		t.Error("Expected the inspected process to be reported as monitored")
+	}
+}
@@ -0,0 +1,79 @@
+package observe
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StartTicks returns when a process started, in clock ticks since boot
+// (field 22 of /proc/<pid>/stat). A PID that is reused by another process
+// has a different start time.
+func StartTicks(pid int) (uint64, error) {
+	fields, err := statFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(fields[19], 10, 64) // starttime is field 22
+}
+
+// clockTicks is USER_HZ, the unit of times in /proc/<pid>/stat
+const clockTicks = 100
+
+// CPUSeconds returns the CPU time (user and system) a process used so far
+func CPUSeconds(pid int) (float64, error) {
+	fields, err := statFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64) // utime is field 14
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64) // stime is field 15
+	if err != nil {
+		return 0, err
+	}
+	return float64(utime+stime) / clockTicks, nil
+}
+
+// WrittenBytes returns the bytes a process passed to write calls so far
+// (wchar of /proc/<pid>/io), whether to files, pipes or sockets
+func WrittenBytes(pid int) (uint64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/io", pid))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "wchar:"); ok {
+			return strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("no wchar in io of pid %d", pid)
+}
+
+// statFields returns the fields of /proc/<pid>/stat from field 3 (state) on
+func statFields(pid int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	// The command name may contain spaces and parentheses; fields follow the last ')'
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	return fields, nil
+}
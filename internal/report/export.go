@@ -43,6 +43,23 @@ func PrometheusExport() string {
 	b.WriteString(fmt.Sprintf("ffrtmp_jobs_by_exit_total{exit=\"0\"} %d\n", snapshot["jobs_exit_zero"]))
 	b.WriteString(fmt.Sprintf("ffrtmp_jobs_by_exit_total{exit=\"non_zero\"} %d\n", snapshot["jobs_exit_non_zero"]))
 	
+	// Limits
+	b.WriteString("\n# HELP ffrtmp_limits_total cgroup limits by outcome\n")
+	b.WriteString("# TYPE ffrtmp_limits_total counter\n")
+	b.WriteString(fmt.Sprintf("ffrtmp_limits_total{status=\"applied\"} %d\n", snapshot["limits_applied"]))
+	b.WriteString(fmt.Sprintf("ffrtmp_limits_total{status=\"skipped\"} %d\n", snapshot["limits_skipped"]))
+	b.WriteString(fmt.Sprintf("ffrtmp_limits_total{status=\"failed\"} %d\n", snapshot["limits_failed"]))
+	
+	// Usage
+	b.WriteString("\n# HELP ffrtmp_jobs_oom_killed_total Jobs with a process killed by the OOM killer\n")
+	b.WriteString("# TYPE ffrtmp_jobs_oom_killed_total counter\n")
+	b.WriteString(fmt.Sprintf("ffrtmp_jobs_oom_killed_total %d\n", snapshot["jobs_oom_killed"]))
+	
+	// Supervision
+	b.WriteString("\n# HELP ffrtmp_jobs_stalled_total Jobs that stopped making progress\n")
+	b.WriteString("# TYPE ffrtmp_jobs_stalled_total counter\n")
+	b.WriteString(fmt.Sprintf("ffrtmp_jobs_stalled_total %d\n", snapshot["jobs_stalled"]))
+	
 	// Derived metric (SLA rate) - optional but useful
 	completed := snapshot["jobs_completed"]
 	if completed > 0 {
@@ -5,7 +5,11 @@ package report
 // If something is not reversible, DO NOT TOUCH IT.
 // This is governance, not execution.
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+)
 
 // Metrics are Layer 2 visibility: boring counters only.
 // No histograms, no percentiles, no interpretation.
@@ -26,6 +30,17 @@ type Metrics struct {
 	// Exit codes (source of truth: Result.ExitCode)
 	JobsExitZero    atomic.Uint64 // exit_code=0
 	JobsExitNonZero atomic.Uint64 // exit_code!=0
+
+	// Limits (source of truth: Result.Limits)
+	LimitsApplied atomic.Uint64 // status=applied
+	LimitsSkipped atomic.Uint64 // status=skipped
+	LimitsFailed  atomic.Uint64 // status=failed
+
+	// Usage (source of truth: Result.Usage)
+	JobsOOMKilled atomic.Uint64 // usage.oom_kills>0
+
+	// Supervision (source of truth: Result.Stall)
+	JobsStalled atomic.Uint64 // stall!=nil
 }
 
 var globalMetrics = &Metrics{}
@@ -61,6 +76,28 @@ func (m *Metrics) RecordResult(r *Result) {
 	} else {
 		m.JobsExitNonZero.Add(1)
 	}
+
+	// Limits
+	for _, o := range r.Limits {
+		switch o.Status {
+		case cgroups.StatusApplied:
+			m.LimitsApplied.Add(1)
+		case cgroups.StatusSkipped:
+			m.LimitsSkipped.Add(1)
+		case cgroups.StatusFailed:
+			m.LimitsFailed.Add(1)
+		}
+	}
+
+	// Usage
+	if r.Usage != nil && r.Usage.OOMKills > 0 {
+		m.JobsOOMKilled.Add(1)
+	}
+
+	// Supervision
+	if r.Stall != nil {
+		m.JobsStalled.Add(1)
+	}
 }
 
 // IncrStarted increments jobs started counter
@@ -80,5 +117,10 @@ func (m *Metrics) Snapshot() map[string]uint64 {
 		"jobs_attach":             m.JobsAttach.Load(),
 		"jobs_exit_zero":          m.JobsExitZero.Load(),
 		"jobs_exit_non_zero":      m.JobsExitNonZero.Load(),
+		"limits_applied":          m.LimitsApplied.Load(),
+		"limits_skipped":          m.LimitsSkipped.Load(),
+		"limits_failed":           m.LimitsFailed.Load(),
+		"jobs_oom_killed":         m.JobsOOMKilled.Load(),
+		"jobs_stalled":            m.JobsStalled.Load(),
 	}
 }
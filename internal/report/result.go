@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 )
 
 // Result is immutable job-level truth. Set once, never change.
@@ -33,6 +35,23 @@ type Result struct {
 
 	// Intent (optional, for filtering production vs test)
 	Intent string `json:"intent,omitempty"` // "production", "test", etc.
+
+	// Limits (immutable): what was actually applied, skipped or failed per limit
+	Limits []cgroups.Outcome `json:"limits,omitempty"`
+
+	// Usage (immutable): resources used by every process in the job cgroup
+	Usage *cgroups.Stats `json:"usage,omitempty"`
+
+	// Stall (immutable): set if the workload stopped making progress
+	Stall *Stall `json:"stall,omitempty"`
+}
+
+// Stall describes a workload that showed no progress output, output file
+// growth or CPU usage for the supervision window
+type Stall struct {
+	DetectedAt time.Time     `json:"detected_at"`
+	Idle       time.Duration `json:"idle_seconds"`
+	Terminated bool          `json:"terminated"` // SIGTERM was sent to the workload
 }
 
 // NewResult creates an immutable result
@@ -61,6 +80,35 @@ func (r *Result) SetIntent(intent string) {
 	r.Intent = intent
 }
 
+// SetLimits records the outcome of applying cgroup limits.
+// Set once, when the limits are applied.
+func (r *Result) SetLimits(outcomes []cgroups.Outcome) {
+	r.Limits = outcomes
+}
+
+// SetUsage records the resource usage read from the job cgroup.
+// Set once, after the workload exited.
+func (r *Result) SetUsage(usage *cgroups.Stats) {
+	r.Usage = usage
+}
+
+// SetStall records that the workload stalled.
+// Set once, after the workload exited.
+func (r *Result) SetStall(stall *Stall) {
+	r.Stall = stall
+}
+
+// LimitsNotApplied returns the limits that were skipped or failed
+func (r *Result) LimitsNotApplied() []cgroups.Outcome {
+	var missing []cgroups.Outcome
+	for _, o := range r.Limits {
+		if o.Status != cgroups.StatusApplied {
+			missing = append(missing, o)
+		}
+	}
+	return missing
+}
+
 // LogSummary emits Layer 3 visibility: human-readable one-line summary
 // This is what ops grep for at 03:00
 func (r *Result) LogSummary() {
@@ -83,4 +131,18 @@ func (r *Result) LogSummary() {
 		r.ExitCode,
 		r.PID,
 	)
+
+	if u := r.Usage; u != nil {
+		log.Printf("JOB %s | usage | cpu=%.1fs | peak_mem=%dMB | io_read=%dMB | io_write=%dMB | oom_events=%d | oom_kills=%d",
+			r.JobID, u.CPUSeconds, u.MemoryPeak/cgroups.MiB, u.IOReadBytes/cgroups.MiB, u.IOWriteBytes/cgroups.MiB, u.OOMEvents, u.OOMKills)
+	}
+
+	if s := r.Stall; s != nil {
+		log.Printf("JOB %s | stalled | idle=%.0fs | terminated=%v", r.JobID, s.Idle.Seconds(), s.Terminated)
+	}
+
+	// A limit that was asked for but not enforced is never silent
+	for _, o := range r.LimitsNotApplied() {
+		log.Printf("JOB %s | limit %s %s | controller=%s | %s", r.JobID, o.Limit, o.Status, o.Controller, o.Reason)
+	}
 }
@@ -12,7 +12,7 @@ import (
 	"syscall"
 	"time"
 	
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"github.com/psantana5/ffmpeg-rtmp/internal/observe"
 	"github.com/psantana5/ffmpeg-rtmp/internal/report"
 )
@@ -30,12 +30,15 @@ func Attach(ctx context.Context, jobID string, pid int, limits *cgroups.Limits)
 	timing := observe.NewTiming()
 	
 	// Apply limits (best effort, no errors)
-	cgroupPath := applyLimits(jobID, pid, limits)
+	mgr := cgroups.New()
+	cgroupPath, limitOutcomes := applyLimits(mgr, jobID, pid, limits)
+	
+	// Account resource usage from the moment the workload joined
+	accounting := mgr.Account(cgroupPath, cgroups.DefaultAccountingInterval)
 	
 	// Cleanup cgroup on exit (best effort)
 	defer func() {
 		if cgroupPath != "" {
-			mgr := cgroups.New()
 			mgr.Delete(cgroupPath)
 		}
 	}()
@@ -58,6 +61,8 @@ func Attach(ctx context.Context, jobID string, pid int, limits *cgroups.Limits)
 		// Wrapper told to stop, workload continues
 		endTime := time.Now()
 		result := report.NewResult(jobID, pid, -1, startTime, endTime, "attach")
+		result.SetLimits(limitOutcomes)
+		result.SetUsage(accounting.Stop())
 		result.SetPlatformSLA(true, "detached_workload_continues")
 		
 		// Record all visibility layers
@@ -71,6 +76,8 @@ func Attach(ctx context.Context, jobID string, pid int, limits *cgroups.Limits)
 		// Process exited naturally
 		endTime := time.Now()
 		result := report.NewResult(jobID, pid, -1, startTime, endTime, "attach")
+		result.SetLimits(limitOutcomes)
+		result.SetUsage(accounting.Stop())
 		result.SetPlatformSLA(true, "observed_to_completion")
 		
 		// Record all visibility layers
@@ -8,57 +8,114 @@ package wrapper
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"syscall"
 	"time"
 	
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"github.com/psantana5/ffmpeg-rtmp/internal/observe"
 	"github.com/psantana5/ffmpeg-rtmp/internal/report"
 )
 
 // Run spawns a workload. Process survives wrapper crash.
 func Run(ctx context.Context, jobID string, limits *cgroups.Limits, command string, args []string) (*report.Result, error) {
+	return RunStarted(ctx, jobID, limits, command, args, nil, nil, "")
+}
+
+// RunStarted is Run, calling started (optional) once the workload runs in
+// its cgroup, so the caller can record what to re-attach to after a crash.
+// With supervision (optional) a workload that stops making progress is
+// reported as stalled.
+// With statusPath (optional) the workload runs under a shim (see Shim) that
+// records its exit status there, for a wrapper that re-attaches after a
+// crash; started then gets the PID of the shim, which exits once the
+// status is recorded.
+func RunStarted(ctx context.Context, jobID string, limits *cgroups.Limits, command string, args []string, started func(pid int, cgroupPath string), supervision *Supervision, statusPath string) (*report.Result, error) {
 	report.Global().IncrStarted()
 	
 	timing := observe.NewTiming()
 	
 	// Create command
-	cmd := exec.CommandContext(ctx, command, args...)
+	var cmd *exec.Cmd
+	var ready, pids *os.File
+	if statusPath != "" {
+		var err error
+		cmd, ready, pids, err = shimCommand(ctx, statusPath, command, args)
+		if err != nil {
+			// Still run the workload, its exit status is just not recorded
+			log.Printf("JOB %s | running without exit status file: %v", jobID, err)
+		}
+	}
+	if cmd == nil {
+		cmd = exec.CommandContext(ctx, command, args...)
+	}
 	
 	// CRITICAL: Set process group so workload is independent
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true, // New process group
 		Pgid:    0,    // Process becomes its own group leader
 	}
+	if ready != nil {
+		// The shim leads the group; killing it alone would orphan the workload
+		cmd.Cancel = func() error {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+	}
 	
 	// Forward stdout/stderr
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	
 	// Start process
-	if err := cmd.Start(); err != nil {
+	err := cmd.Start()
+	for _, f := range cmd.ExtraFiles {
+		f.Close() // The shim's ends of its pipes
+	}
+	if err != nil {
+		if ready != nil {
+			ready.Close()
+			pids.Close()
+		}
 		return nil, fmt.Errorf("failed to start: %w", err)
 	}
 	
 	pid := cmd.Process.Pid
+	group := pid
 	
-	// Apply limits (best effort)
-	cgroupPath := applyLimits(jobID, pid, limits)
+	// Apply limits (best effort); a shim joins before it starts the workload
+	mgr := cgroups.New()
+	cgroupPath, limitOutcomes := applyLimits(mgr, jobID, pid, limits)
+	
+	// Account resource usage of the whole cgroup, children included
+	accounting := mgr.Account(cgroupPath, cgroups.DefaultAccountingInterval)
 	
 	// Cleanup cgroup on exit
 	defer func() {
 		if cgroupPath != "" {
-			mgr := cgroups.New()
 			mgr.Delete(cgroupPath)
 		}
 	}()
 	
+	if started != nil {
+		started(pid, cgroupPath)
+	}
+	if ready != nil {
+		ready.Close()
+		if workload := readWorkloadPID(pids); workload > 0 {
+			pid = workload
+		}
+		pids.Close()
+	}
+	supervisor := newSupervisor(supervision, jobID)
+	supervisor.start(pid, group, mgr, cgroupPath)
+	
 	// Wait for completion
 	startTime := timing.Start
-	err := cmd.Wait()
+	err = cmd.Wait()
 	endTime := time.Now()
+	stall := supervisor.finish()
 	
 	exitCode := 0
 	if err != nil {
@@ -69,6 +126,9 @@ func Run(ctx context.Context, jobID string, limits *cgroups.Limits, command stri
 	
 	// Create immutable result (Layer 1 truth)
 	result := report.NewResult(jobID, pid, exitCode, startTime, endTime, "run")
+	result.SetLimits(limitOutcomes)
+	result.SetUsage(accounting.Stop())
+	result.SetStall(stall)
 	
 	// Calculate SLA ONCE (never update after this)
 	calculatePlatformSLA(result, exitCode)
@@ -85,39 +145,26 @@ func Run(ctx context.Context, jobID string, limits *cgroups.Limits, command stri
 	return result, nil
 }
 
-// applyLimits applies cgroup limits (best effort)
-// Returns cgroup path for cleanup
-func applyLimits(jobID string, pid int, limits *cgroups.Limits) string {
-	if limits == nil {
-		return ""
-	}
-	
-	mgr := cgroups.New()
+// applyLimits moves the workload into a job cgroup and applies limits (best effort).
+// The cgroup is created without limits too, for resource accounting.
+// Returns cgroup path for cleanup and the outcome of every set limit
+func applyLimits(mgr *cgroups.Manager, jobID string, pid int, limits *cgroups.Limits) (string, []cgroups.Outcome) {
 	cgroupPath, err := mgr.Create(jobID)
-	if err != nil || cgroupPath == "" {
-		return "" // Can't create cgroup, continue anyway
+	if err != nil {
+		return "", mgr.Skip(limits, fmt.Sprintf("cgroup not created: %v", err)) // Continue anyway
+	}
+	if cgroupPath == "" {
+		return "", mgr.Skip(limits, "cgroup not created: "+mgr.Delegation().Reason)
 	}
 	
 	// Join cgroup
 	if err := mgr.Join(cgroupPath, pid); err != nil {
-		return "" // Failed to join, skip limits
-	}
-	
-	// Apply limits (all best effort)
-	if limits.CPUMax != "" {
-		cgroups.WriteCPUMax(cgroupPath, limits.CPUMax)
-	}
-	if limits.CPUWeight > 0 {
-		cgroups.WriteCPUWeight(cgroupPath, limits.CPUWeight)
-	}
-	if limits.MemoryMax > 0 {
-		cgroups.WriteMemoryMax(cgroupPath, limits.MemoryMax)
-	}
-	if limits.IOMax != "" {
-		cgroups.WriteIOMax(cgroupPath, limits.IOMax)
+		mgr.Delete(cgroupPath) // Nothing joined, don't leak the empty cgroup
+		return "", mgr.Skip(limits, fmt.Sprintf("failed to join cgroup: %v", err))
 	}
 	
-	return cgroupPath
+	// Apply limits (all best effort, outcome recorded per limit)
+	return cgroupPath, mgr.Apply(cgroupPath, limits)
 }
 
 // calculatePlatformSLA determines if platform behaved correctly
@@ -128,6 +175,9 @@ func calculatePlatformSLA(result *report.Result, exitCode int) {
 	
 	if exitCode == 0 {
 		result.SetPlatformSLA(true, "completed_successfully")
+	} else if result.Stall != nil {
+		// A hung input or stream is not the platform's fault
+		result.SetPlatformSLA(true, "workload_stalled_platform_ok")
 	} else {
 		// Workload failed, but did platform fail?
 		// For now: platform succeeded in executing the workload
@@ -0,0 +1,24 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+)
+
+func TestApplyLimits_JoinFailureRemovesCgroup(t *testing.T) {
+	fake := cgroups.NewFakeFS(2, "cpu", "memory")
+	mgr := cgroups.NewWithFS(fake, cgroups.DefaultRoot)
+
+	// An invalid pid cannot join
+	path, outcomes := applyLimits(mgr, "job-1", 0, &cgroups.Limits{CPUWeight: 100})
+	if path != "" {
+		t.Errorf("Expected no cgroup path, got %q", path)
+	}
+	if len(outcomes) != 1 || outcomes[0].Status != cgroups.StatusSkipped {
+		t.Errorf("Expected the limit to be skipped, got %+v", outcomes)
+	}
+	if _, err := mgr.Lookup("job-1"); err == nil {
+		t.Error("Expected the job cgroup to be removed after the join failed")
+	}
+}
@@ -0,0 +1,196 @@
+package wrapper
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// shimStatusEnv carries the status file of a shim; set only in shims
+const shimStatusEnv = "FFRTMP_WRAPPER_STATUS_FILE"
+
+// ExitStatus is how a workload exited, as recorded by its shim
+type ExitStatus struct {
+	ExitCode int       `json:"exit_code"`        // -1 if killed by a signal
+	Signal   string    `json:"signal,omitempty"` // Signal that killed the workload
+	ExitedAt time.Time `json:"exited_at"`
+}
+
+// ReadExitStatus reads the status file a shim wrote for its workload.
+// The error wraps os.ErrNotExist if the workload did not exit yet or its
+// shim died before recording it.
+func ReadExitStatus(path string) (*ExitStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var status ExitStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse exit status %s: %w", path, err)
+	}
+	return &status, nil
+}
+
+// Shim runs a workload and records its exit status when this process was
+// started as a shim by RunStarted, and returns otherwise. Binaries that pass
+// a status file to RunStarted must call it first thing in main.
+//
+// A workload outlives the wrapper, but its exit status is only reported to
+// its parent. The shim is that parent: it survives the wrapper too and
+// writes the status to a file the next wrapper reads. It exits as its
+// workload did.
+func Shim() {
+	statusPath, ok := os.LookupEnv(shimStatusEnv)
+	if !ok {
+		return
+	}
+	os.Unsetenv(shimStatusEnv)
+
+	// fd 3 closes once the wrapper moved the shim into the job cgroup, so the
+	// workload starts in it; it also closes if the wrapper crashed
+	ready := os.NewFile(3, "ready")
+	io.Copy(io.Discard, ready)
+	ready.Close()
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "wrapper shim: no command")
+		os.Exit(2)
+	}
+	cmd := exec.Command(os.Args[1], os.Args[2:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Catch termination signals before the workload starts; they are
+	// forwarded, not obeyed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "wrapper shim: failed to start: %v\n", err)
+		writeExitStatus(statusPath, &ExitStatus{ExitCode: 127, ExitedAt: time.Now()})
+		os.Exit(127)
+	}
+
+	// fd 4 tells the wrapper which process to observe; the write fails
+	// harmlessly if the wrapper is gone
+	pids := os.NewFile(4, "pid")
+	pids.WriteString(strconv.Itoa(cmd.Process.Pid))
+	pids.Close()
+
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	cmd.Wait()
+	state := cmd.ProcessState
+	status := &ExitStatus{ExitCode: state.ExitCode(), ExitedAt: time.Now()}
+	ws, signaled := state.Sys().(syscall.WaitStatus)
+	signaled = signaled && ws.Signaled()
+	if signaled {
+		status.Signal = ws.Signal().String()
+	}
+	if err := writeExitStatus(statusPath, status); err != nil {
+		fmt.Fprintf(os.Stderr, "wrapper shim: %v\n", err)
+	}
+
+	if signaled {
+		// Die the same way, so a waiting wrapper sees what the workload did
+		signal.Reset(ws.Signal())
+		syscall.Kill(os.Getpid(), ws.Signal())
+		time.Sleep(time.Second)
+		os.Exit(128 + int(ws.Signal()))
+	}
+	os.Exit(status.ExitCode)
+}
+
+// writeExitStatus writes a status file atomically and durably
+func writeExitStatus(path string, status *ExitStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exit status: %w", err)
+	}
+
+	tempPath := path + ".tmp"
+	f, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write exit status: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write exit status: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync exit status: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write exit status: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to replace exit status: %w", err)
+	}
+	return nil
+}
+
+// shimCommand prepares a shim that runs command and records its exit status
+// at statusPath. The returned files are the wrapper's ends of the ready and
+// PID pipes; the wrapper closes ready once the shim may start the workload
+// and reads the workload PID from pids.
+func shimCommand(ctx context.Context, statusPath, command string, args []string) (cmd *exec.Cmd, ready, pids *os.File, err error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to locate the shim binary: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(statusPath), 0755); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create exit status directory: %w", err)
+	}
+	// A status left by an earlier run of the job must not be mistaken for this one
+	if err := os.Remove(statusPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, fmt.Errorf("failed to remove stale exit status: %w", err)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pidsR, pidsW, err := os.Pipe()
+	if err != nil {
+		readyR.Close()
+		readyW.Close()
+		return nil, nil, nil, err
+	}
+
+	cmd = exec.CommandContext(ctx, self, append([]string{command}, args...)...)
+	cmd.Env = append(os.Environ(), shimStatusEnv+"="+statusPath)
+	cmd.ExtraFiles = []*os.File{readyR, pidsW} // fd 3 and 4 in the shim
+	return cmd, readyW, pidsR, nil
+}
+
+// readWorkloadPID reads the PID the shim started its workload with
+// Returns: 0 if the shim exited without starting it
+func readWorkloadPID(pids *os.File) int {
+	data, _ := io.ReadAll(pids)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
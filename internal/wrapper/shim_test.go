@@ -0,0 +1,83 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain lets the test binary serve as the shim of RunStarted
+func TestMain(m *testing.M) {
+	Shim()
+	os.Exit(m.Run())
+}
+
+func TestRunStarted_RecordsExitStatus(t *testing.T) {
+	statusPath := filepath.Join(t.TempDir(), "exit-status", "job-1.json")
+
+	var shimPID int
+	result, err := RunStarted(context.Background(), "job-1", nil, "sh", []string{"-c", "exit 3"},
+		func(pid int, cgroupPath string) { shimPID = pid }, nil, statusPath)
+	if err != nil {
+		t.Fatalf("RunStarted failed: %v", err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", result.ExitCode)
+	}
+	if shimPID == 0 || result.PID == shimPID {
+		t.Errorf("Expected the result to name the workload, not the shim (shim %d, result %d)", shimPID, result.PID)
+	}
+
+	status, err := ReadExitStatus(statusPath)
+	if err != nil {
+		t.Fatalf("ReadExitStatus failed: %v", err)
+	}
+	if status.ExitCode != 3 || status.Signal != "" {
+		t.Errorf("Unexpected exit status: %+v", status)
+	}
+}
+
+func TestRunStarted_RecordsSignal(t *testing.T) {
+	statusPath := filepath.Join(t.TempDir(), "job-1.json")
+
+	result, err := RunStarted(context.Background(), "job-1", nil, "sh", []string{"-c", "kill -KILL $$"}, nil, nil, statusPath)
+	if err != nil {
+		t.Fatalf("RunStarted failed: %v", err)
+	}
+	if result.ExitCode != -1 {
+		t.Errorf("Expected exit code -1 for a killed workload, got %d", result.ExitCode)
+	}
+
+	status, err := ReadExitStatus(statusPath)
+	if err != nil {
+		t.Fatalf("ReadExitStatus failed: %v", err)
+	}
+	if status.ExitCode != -1 || status.Signal != "killed" {
+		t.Errorf("Unexpected exit status: %+v", status)
+	}
+}
+
+func TestRunStarted_RemovesStaleExitStatus(t *testing.T) {
+	statusPath := filepath.Join(t.TempDir(), "job-1.json")
+	os.WriteFile(statusPath, []byte(`{"exit_code":1}`), 0600)
+
+	if _, err := RunStarted(context.Background(), "job-1", nil, "true", nil, nil, nil, statusPath); err != nil {
+		t.Fatalf("RunStarted failed: %v", err)
+	}
+	status, err := ReadExitStatus(statusPath)
+	if err != nil {
+		t.Fatalf("ReadExitStatus failed: %v", err)
+	}
+	if status.ExitCode != 0 {
+		t.Errorf("Expected the exit status of this run, got %+v", status)
+	}
+}
+
+func TestReadExitStatus_Missing(t *testing.T) {
+	_, err := ReadExitStatus(filepath.Join(t.TempDir(), "job-1.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package wrapper
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/internal/observe"
+	"github.com/psantana5/ffmpeg-rtmp/internal/report"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	lifecycle "github.com/psantana5/ffmpeg-rtmp/pkg/wrapper"
+)
+
+const (
+	// stallCheckInterval is how often the liveness signals are read
+	stallCheckInterval = time.Second
+
+	// idleCPUFraction is the CPU use (of one core) below which a workload
+	// counts as idle; blocked processes still wake up now and then
+	idleCPUFraction = 0.01
+)
+
+// Supervision watches the liveness of a workload: progress output, output
+// file growth and CPU usage. A workload that shows none of them for Window
+// is stalled, typically a hung input or a stuck RTMP handshake.
+// Output is read from the bytes the process wrote (stderr progress lines,
+// files and sockets alike), never by piping its stderr: a pipe would break
+// the workload if the wrapper crashed.
+type Supervision struct {
+	Window     time.Duration                  // Time without progress before the workload is stalled
+	OutputPath string                         // Output file expected to grow, empty for streams
+	Terminate  bool                           // Send SIGTERM to the workload's process group once stalled
+	OnEvent    func(lifecycle.LifecycleEvent) // Called with the stalled event (optional)
+}
+
+// supervisor applies a Supervision to one workload
+type supervisor struct {
+	cfg        *Supervision
+	jobID      string
+	pid        int
+	group      int
+	mgr        *cgroups.Manager
+	cgroupPath string
+
+	interval time.Duration                           // How often the signals are read
+	kill     func(pid int, sig syscall.Signal) error // Sends the termination signal
+
+	stop    chan struct{}
+	stopped chan struct{}
+	stall   *report.Stall
+}
+
+// newSupervisor prepares supervision of a workload; nil if disabled
+func newSupervisor(cfg *Supervision, jobID string) *supervisor {
+	if cfg == nil || cfg.Window <= 0 {
+		return nil
+	}
+	return &supervisor{
+		cfg:      cfg,
+		jobID:    jobID,
+		interval: stallCheckInterval,
+		kill:     syscall.Kill,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// start begins watching the workload pid, which runs in process group group
+func (s *supervisor) start(pid, group int, mgr *cgroups.Manager, cgroupPath string) {
+	if s == nil {
+		return
+	}
+	s.pid = pid
+	s.group = group
+	s.mgr = mgr
+	s.cgroupPath = cgroupPath
+	go s.run()
+}
+
+// run checks the liveness signals until the workload stalls or exits
+func (s *supervisor) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	lastProgress := time.Now()
+	lastCheck := lastProgress
+	lastWritten := s.writtenBytes()
+	lastSize := s.outputSize()
+	lastCPU := s.cpuSeconds()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		written := s.writtenBytes()
+		size := s.outputSize()
+		cpu := s.cpuSeconds()
+
+		if written > lastWritten || size > lastSize || cpu-lastCPU > idleCPUFraction*now.Sub(lastCheck).Seconds() {
+			lastProgress = now
+		}
+		lastCheck, lastWritten, lastSize, lastCPU = now, written, size, cpu
+
+		if idle := now.Sub(lastProgress); idle >= s.cfg.Window {
+			s.stalled(now, idle)
+			return
+		}
+	}
+}
+
+// stalled records the stall, emits the lifecycle event and terminates the
+// workload if configured
+func (s *supervisor) stalled(now time.Time, idle time.Duration) {
+	s.stall = &report.Stall{
+		DetectedAt: now,
+		Idle:       idle,
+	}
+	log.Printf("JOB %s | stalled | no progress for %.0fs | pid=%d", s.jobID, idle.Seconds(), s.pid)
+
+	if s.cfg.Terminate {
+		if err := s.kill(-s.group, syscall.SIGTERM); err != nil {
+			log.Printf("JOB %s | failed to terminate stalled workload: %v", s.jobID, err)
+		} else {
+			s.stall.Terminated = true
+		}
+	}
+
+	if s.cfg.OnEvent != nil {
+		message := fmt.Sprintf("No progress for %.0fs", idle.Seconds())
+		if s.stall.Terminated {
+			message += ", sent SIGTERM"
+		}
+		s.cfg.OnEvent(lifecycle.LifecycleEvent{
+			PID:       s.pid,
+			State:     lifecycle.StateStalled,
+			Timestamp: now,
+			Message:   message,
+		})
+	}
+}
+
+// finish stops watching once the workload exited
+// Returns: the stall, nil if the workload did not stall
+func (s *supervisor) finish() *report.Stall {
+	if s == nil {
+		return nil
+	}
+	close(s.stop)
+	<-s.stopped
+	return s.stall
+}
+
+// writtenBytes returns the bytes the workload process wrote so far, 0 if
+// they cannot be read
+func (s *supervisor) writtenBytes() uint64 {
+	written, err := observe.WrittenBytes(s.pid)
+	if err != nil {
+		return 0
+	}
+	return written
+}
+
+// outputSize returns the size of the output file, 0 if there is none yet
+func (s *supervisor) outputSize() int64 {
+	if s.cfg.OutputPath == "" {
+		return 0
+	}
+	info, err := os.Stat(s.cfg.OutputPath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// cpuSeconds returns the CPU time of the whole job cgroup, or of the
+// workload process alone without a cgroup
+func (s *supervisor) cpuSeconds() float64 {
+	if s.cgroupPath != "" {
+		if stats, err := s.mgr.Stats(s.cgroupPath); err == nil && stats.CPUSeconds > 0 {
+			return stats.CPUSeconds
+		}
+	}
+	seconds, err := observe.CPUSeconds(s.pid)
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
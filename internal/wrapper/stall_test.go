@@ -0,0 +1,186 @@
+package wrapper
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	lifecycle "github.com/psantana5/ffmpeg-rtmp/pkg/wrapper"
+)
+
+const testStallWindow = 300 * time.Millisecond
+
+// killCall records a signal the supervisor sent
+type killCall struct {
+	pid int
+	sig syscall.Signal
+}
+
+// startWorkload starts a command in its own process group, killed on cleanup
+func startWorkload(t *testing.T, name string, args ...string) int {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
+
+// newTestSupervisor returns a fast supervisor recording the signals it sends
+func newTestSupervisor(cfg *Supervision) (*supervisor, func() []killCall) {
+	var mu sync.Mutex
+	var calls []killCall
+
+	s := newSupervisor(cfg, "job-1")
+	s.interval = 20 * time.Millisecond
+	s.kill = func(pid int, sig syscall.Signal) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, killCall{pid, sig})
+		return nil
+	}
+	return s, func() []killCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]killCall(nil), calls...)
+	}
+}
+
+// waitStopped waits for the supervisor to give up on the workload
+func waitStopped(t *testing.T, s *supervisor, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-s.stopped:
+	case <-time.After(timeout):
+		t.Fatal("Expected the workload to be stalled")
+	}
+}
+
+func TestNewSupervisor_Disabled(t *testing.T) {
+	if s := newSupervisor(nil, "job-1"); s != nil {
+		t.Error("Expected no supervisor without supervision")
+	}
+	if s := newSupervisor(&Supervision{}, "job-1"); s != nil {
+		t.Error("Expected no supervisor without a window")
+	}
+
+	// A nil supervisor is a no-op
+	var s *supervisor
+	s.start(1, 1, nil, "")
+	if stall := s.finish(); stall != nil {
+		t.Errorf("Expected no stall, got %+v", stall)
+	}
+}
+
+func TestSupervisor_Stalled(t *testing.T) {
+	var events []lifecycle.LifecycleEvent
+	s, kills := newTestSupervisor(&Supervision{
+		Window:  testStallWindow,
+		OnEvent: func(e lifecycle.LifecycleEvent) { events = append(events, e) },
+	})
+
+	pid := startWorkload(t, "sleep", "30")
+	s.start(pid, pid, nil, "")
+	waitStopped(t, s, 10*testStallWindow)
+
+	stall := s.finish()
+	if stall == nil {
+		t.Fatal("Expected a stall")
+	}
+	if stall.Idle < testStallWindow {
+		t.Errorf("Expected at least %v idle, got %v", testStallWindow, stall.Idle)
+	}
+	if stall.Terminated {
+		t.Error("Expected the workload not to be terminated")
+	}
+	if calls := kills(); len(calls) != 0 {
+		t.Errorf("Expected no signal without Terminate, got %+v", calls)
+	}
+	if len(events) != 1 || events[0].State != lifecycle.StateStalled || events[0].PID != pid {
+		t.Errorf("Expected one stalled event, got %+v", events)
+	}
+}
+
+func TestSupervisor_Terminate(t *testing.T) {
+	s, kills := newTestSupervisor(&Supervision{Window: testStallWindow, Terminate: true})
+
+	pid := startWorkload(t, "sleep", "30")
+	s.start(pid, pid, nil, "")
+	waitStopped(t, s, 10*testStallWindow)
+
+	stall := s.finish()
+	if stall == nil || !stall.Terminated {
+		t.Fatalf("Expected a terminated stall, got %+v", stall)
+	}
+	calls := kills()
+	if len(calls) != 1 || calls[0] != (killCall{-pid, syscall.SIGTERM}) {
+		t.Errorf("Expected SIGTERM to process group %d, got %+v", -pid, calls)
+	}
+}
+
+func TestSupervisor_Progress(t *testing.T) {
+	tests := []struct {
+		name   string
+		output bool // Grow the output file while the workload runs
+		args   []string
+	}{
+		{"written bytes", false, []string{"sh", "-c", "while :; do echo progress; sleep 0.05; done >/dev/null"}},
+		{"output file growth", true, []string{"sleep", "30"}},
+		{"cpu time", false, []string{"sh", "-c", "while :; do :; done"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Supervision{Window: testStallWindow, Terminate: true}
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			if tt.output {
+				cfg.OutputPath = filepath.Join(t.TempDir(), "out.mp4")
+				f, err := os.Create(cfg.OutputPath)
+				if err != nil {
+					t.Fatalf("Failed to create output: %v", err)
+				}
+				defer f.Close()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ticker := time.NewTicker(50 * time.Millisecond)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-done:
+							return
+						case <-ticker.C:
+							f.Write([]byte("segment"))
+						}
+					}
+				}()
+			}
+
+			s, kills := newTestSupervisor(cfg)
+			pid := startWorkload(t, tt.args[0], tt.args[1:]...)
+			s.start(pid, pid, nil, "")
+
+			time.Sleep(4 * testStallWindow)
+			stall := s.finish()
+			close(done)
+			wg.Wait()
+
+			if stall != nil {
+				t.Errorf("Expected no stall while the workload progresses, got %+v", stall)
+			}
+			if calls := kills(); len(calls) != 0 {
+				t.Errorf("Expected no signal, got %+v", calls)
+			}
+		})
+	}
+}
@@ -2,26 +2,36 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/psantana5/ffmpeg-rtmp/master/exporters/prometheus"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/archive"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/backup"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/bandwidth"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/cleanup"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/leader"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/logging"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/ratelimit"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/scheduler"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/secrets"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/shutdown"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
 	tlsutil "github.com/psantana5/ffmpeg-rtmp/pkg/tls"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/tracing"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/webhooks"
 )
 
 var logger *logging.Logger
@@ -41,6 +51,18 @@ func main() {
 	certIPs := flag.String("cert-ips", "", "Comma-separated list of IP addresses to include in certificate SANs (e.g., '192.168.0.51,10.0.0.5')")
 	certHosts := flag.String("cert-hosts", "", "Comma-separated list of hostnames to include in certificate SANs (e.g., 'depa,server1')")
 	apiKeyFlag := flag.String("api-key", "", "API key for authentication (leave empty to use environment variable)")
+	sessionTTL := flag.Duration("session-ttl", 24*time.Hour, "Lifetime of user login sessions")
+	enableNodeTokens := flag.Bool("node-tokens", true, "Issue per-node identity tokens and require them on heartbeats, job polls and results")
+	nodeTokenTTL := flag.Duration("node-token-ttl", 0, "Lifetime of node identity tokens (0: valid until the node re-registers or is removed)")
+	enablePKI := flag.Bool("pki", false, "Act as a certificate authority: workers enroll with bootstrap tokens and receive short-lived client certificates")
+	pkiDir := flag.String("pki-dir", "certs/pki", "Directory holding the worker CA certificate and key (created on first start)")
+	pkiCertTTL := flag.Duration("pki-cert-ttl", 24*time.Hour, "Lifetime of worker client certificates issued by the CA")
+	enableSecrets := flag.Bool("secrets", true, "Store job parameter secrets encrypted and resolve {\"$secret\": name} references for the assigned worker")
+	secretsKeyFile := flag.String("secrets-key-file", "certs/secrets.key", "File holding the secrets encryption key (created on first start; FFRTMP_SECRETS_KEY overrides it)")
+	enableRateLimit := flag.Bool("rate-limit", true, "Enforce API rate limit policies")
+	rateLimitConfig := flag.String("rate-limit-config", "", "YAML file with rate limit policies (default: built-in login and per-identity policies)")
+	rateLimitBackend := flag.String("rate-limit-backend", "", "Where rate limit buckets are kept: 'local' or 'store' (shared by all masters; default: store with --ha, local otherwise)")
+	enableAudit := flag.Bool("audit", true, "Record every mutating API request in the audit log (GET /audit)")
 	maxRetries := flag.Int("max-retries", 3, "Maximum job retry attempts on failure")
 	enableMetrics := flag.Bool("metrics", true, "Enable Prometheus metrics endpoint")
 	metricsPort := flag.String("metrics-port", "9090", "Prometheus metrics port")
@@ -49,7 +71,22 @@ func main() {
 	tracingEndpoint := flag.String("tracing-endpoint", "localhost:4318", "OpenTelemetry OTLP endpoint")
 	enableCleanup := flag.Bool("cleanup", true, "Enable automatic cleanup of old jobs")
 	cleanupRetention := flag.Int("cleanup-retention", 7, "Job retention period in days")
+	cleanupStatusRetention := flag.String("cleanup-retention-status", "", "Per-status retention in days overriding --cleanup-retention (e.g., 'failed=30,canceled=3'; 0 keeps forever)")
+	enableArchive := flag.Bool("archive", false, "Archive expired jobs to compressed files before cleanup deletes them")
+	archiveDir := flag.String("archive-dir", "./archive", "Directory for job archive files")
+	archiveFormat := flag.String("archive-format", "jsonl", "Job archive format: 'jsonl' (gzip) or 'parquet'")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	enableWebhooks := flag.Bool("webhooks", true, "Enable webhook delivery for job lifecycle events")
+	webhookWorkers := flag.Int("webhook-workers", 4, "Number of concurrent webhook delivery workers")
+	webhookMaxRetries := flag.Int("webhook-max-retries", 5, "Maximum webhook delivery retry attempts")
+	webhookRetention := flag.Duration("webhook-retention", 7*24*time.Hour, "How long finished webhook deliveries are kept")
+	enableHA := flag.Bool("ha", false, "Enable multi-master high availability with leader election (requires PostgreSQL)")
+	masterID := flag.String("master-id", "", "Unique master identity for leader election (default: hostname-pid)")
+	leaderLease := flag.Duration("leader-lease", 15*time.Second, "Leader election lease duration")
+	enableBackup := flag.Bool("backup", false, "Enable scheduled database backups")
+	backupDir := flag.String("backup-dir", "./backups", "Directory for scheduled database backups")
+	backupInterval := flag.Duration("backup-interval", 6*time.Hour, "Interval between scheduled database backups")
+	backupRetention := flag.Int("backup-retention", 14, "Number of scheduled backups to keep")
 	flag.Parse()
 
 	// Initialize file logger: /var/log/ffrtmp/master/master.log
@@ -243,17 +280,93 @@ func main() {
 		logger.Info("✓ Tracing middleware enabled")
 	}
 
+	// Record mutating requests before authentication so rejected ones are audited too
+	if *enableAudit {
+		router.Use(handler.AuditMiddleware)
+		logger.Info("✓ Audit log enabled")
+	} else {
+		logger.Info("WARNING: Audit log disabled - mutating API requests are not recorded")
+	}
+
 	// Add bandwidth monitoring middleware
 	bandwidthMonitor := bandwidth.NewBandwidthMonitor()
 	router.Use(bandwidthMonitor.Middleware)
 	logger.Info("✓ Bandwidth monitoring enabled")
 
+	// Bind requests to tenants and roles. Tenant API keys are verified here and always act as
+	// their own tenant; session tokens act with their user's role; the master API key acts as
+	// admin and may act as any tenant via the X-Tenant-ID header.
+	isAdmin := func(r *http.Request) bool {
+		return apiKey == "" || auth.SecureCompare(r.Header.Get("Authorization"), "Bearer "+apiKey)
+	}
+	router.Use(tenancy.TenantMiddleware(tenancy.NewStoreResolver(dataStore), isAdmin))
+	logger.Info("✓ Multi-tenancy enabled")
+
+	// Enforce role permissions on every route
+	handler.EnableRBAC()
+	handler.SetSessionTTL(*sessionTTL)
+	logger.Info(fmt.Sprintf("✓ Role-based access control enabled (session TTL: %v)", *sessionTTL))
+
+	// Bind worker requests to the node that registered; tokens are persisted so restarts keep workers
+	if *enableNodeTokens {
+		handler.EnableNodeTokens(auth.NewPersistentTokenManager(dataStore), *nodeTokenTTL)
+		logger.Info("✓ Node identity tokens enabled")
+	} else {
+		logger.Info("WARNING: Node identity tokens disabled - any worker credential can report results for any job")
+	}
+
+	// Issue worker client certificates from a built-in CA and reject revoked ones.
+	// With --mtls a certificate is required everywhere except enrollment.
+	var workerCA *tlsutil.CA
+	if *enablePKI {
+		if !*useTLS {
+			logger.Fatal("The worker certificate authority (--pki) requires TLS")
+		}
+		workerCA, err = tlsutil.LoadOrCreateCA(filepath.Join(*pkiDir, "ca.crt"), filepath.Join(*pkiDir, "ca.key"), "ffmpeg-rtmp worker CA")
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to load worker CA: %v", err))
+		}
+		handler.EnablePKI(workerCA, *pkiCertTTL)
+		router.Use(handler.CertificateMiddleware(*requireClientCert))
+		logger.Info(fmt.Sprintf("✓ Worker certificate authority enabled (dir: %s, certificate TTL: %v)", *pkiDir, *pkiCertTTL))
+	}
+
+	// Encrypt job parameter secrets at rest. Masters sharing a database must share the key.
+	if *enableSecrets {
+		var secretsKey []byte
+		source := *secretsKeyFile
+		if encoded := os.Getenv("FFRTMP_SECRETS_KEY"); encoded != "" {
+			secretsKey, err = secrets.ParseKey(encoded)
+			source = "FFRTMP_SECRETS_KEY"
+		} else {
+			secretsKey, err = secrets.LoadOrCreateKey(*secretsKeyFile)
+		}
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to load secrets key: %v", err))
+		}
+		cipher, err := secrets.NewCipher(secretsKey)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to initialize secrets: %v", err))
+		}
+		handler.EnableSecrets(cipher)
+		logger.Info(fmt.Sprintf("✓ Job secrets enabled (key: %s)", source))
+	}
+
+	// Enforce tenant quotas: per-tenant API rate limits here, submission limits in the handlers
+	quotaEnforcer := tenancy.NewQuotaEnforcer(dataStore)
+	router.Use(quotaEnforcer.Middleware)
+	handler.SetQuotaEnforcer(quotaEnforcer)
+	quotaEnforcer.Start()
+	logger.Info("✓ Tenant quota enforcement enabled")
+
 	// Add authentication middleware if API key is set
 	if apiKey != "" {
 		router.Use(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Skip auth for health endpoint
-				if r.URL.Path == "/health" {
+				// Skip auth for health, the API document, login and certificate enrollment endpoints
+				// (enrollment is authenticated by bootstrap tokens, renewal by client certificates)
+				switch r.URL.Path {
+				case "/health", "/openapi.json", "/auth/login", "/pki/enroll", "/pki/renew", "/pki/ca.crt":
 					next.ServeHTTP(w, r)
 					return
 				}
@@ -265,6 +378,13 @@ func main() {
 					return
 				}
 
+				// Tenant API keys and session tokens were already verified by the tenancy middleware
+				token := strings.TrimPrefix(authHeader, "Bearer ")
+				if auth.IsTenantAPIKey(token) || auth.IsSessionToken(token) {
+					next.ServeHTTP(w, r)
+					return
+				}
+
 				// Simple bearer token check with constant-time comparison
 				expectedAuth := "Bearer " + apiKey
 				if !auth.SecureCompare(authHeader, expectedAuth) {
@@ -277,6 +397,47 @@ func main() {
 		})
 	}
 
+	// Enforce rate limits once requests are authenticated, so they are counted by their credentials
+	var rateLimiter *ratelimit.Enforcer
+	if *enableRateLimit {
+		policies := ratelimit.DefaultPolicies()
+		if *rateLimitConfig != "" {
+			config, err := ratelimit.LoadConfig(*rateLimitConfig)
+			if err != nil {
+				logger.Fatal(fmt.Sprintf("Failed to load rate limit config: %v", err))
+			}
+			policies = config.Policies
+		}
+
+		backendName := *rateLimitBackend
+		if backendName == "" {
+			backendName = "local"
+			if *enableHA {
+				backendName = "store"
+			}
+		}
+		var backend ratelimit.Backend
+		switch backendName {
+		case "local":
+			backend = ratelimit.NewLimiter(1, 1)
+		case "store":
+			backend = ratelimit.NewStoreBackend(dataStore)
+		default:
+			logger.Fatal(fmt.Sprintf("Unknown rate limit backend: %s (use 'local' or 'store')", backendName))
+		}
+
+		rateLimiter, err = ratelimit.NewEnforcer(policies, backend, api.RateLimitKeyFuncs())
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Invalid rate limit config: %v", err))
+		}
+		router.Use(rateLimiter.Middleware)
+		rateLimiter.Start(time.Minute)
+		for _, policy := range rateLimiter.Policies() {
+			logger.Info(fmt.Sprintf("✓ Rate limit policy %s: %d requests per %v (burst %d) per %s", policy.Name, policy.Requests, policy.Per, policy.Burst, policy.Key))
+		}
+		logger.Info(fmt.Sprintf("✓ Rate limiting enabled (backend: %s)", backendName))
+	}
+
 	handler.RegisterRoutes(router)
 
 	// Add metrics endpoint if enabled
@@ -316,24 +477,103 @@ func main() {
 		}()
 	}
 
+	// Setup leader election: API handling is active-active, job assignment and background loops run on the leader only
+	var elector *leader.Elector
+	if *enableHA {
+		pgStore, ok := dataStore.(*store.PostgreSQLStore)
+		if !ok {
+			logger.Fatal("High availability (--ha) requires the PostgreSQL store (--db-type=postgres)")
+		}
+
+		id := *masterID
+		if id == "" {
+			hostname, _ := os.Hostname()
+			id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+
+		leaderConfig := leader.DefaultConfig(id)
+		leaderConfig.LeaseDuration = *leaderLease
+		leaderConfig.RenewInterval = *leaderLease / 3
+		leaderConfig.RetryInterval = *leaderLease / 3
+
+		elector = leader.NewElector(leaderConfig, pgStore.NewLeaderLock(leader.DefaultLeaseName, id, *leaderLease))
+		elector.Start()
+		handler.SetLeaderStatus(elector)
+		logger.Info(fmt.Sprintf("✓ Leader election enabled (id: %s, lease: %v)", id, *leaderLease))
+	}
+
 	// Start automatic cleanup manager
 	var cleanupMgr *cleanup.CleanupManager
 	if *enableCleanup {
 		logger.Info("Initializing cleanup manager...")
+		statusRetention, err := cleanup.ParseStatusRetention(*cleanupStatusRetention)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Invalid --cleanup-retention-status: %v", err))
+		}
 		cleanupConfig := cleanup.CleanupConfig{
-			Enabled:          true,
-			JobRetentionDays: *cleanupRetention,
-			CleanupInterval:  24 * time.Hour,
-			VacuumInterval:   7 * 24 * time.Hour,
-			DeleteBatchSize:  100,
+			Enabled:             true,
+			JobRetentionDays:    *cleanupRetention,
+			CleanupInterval:     24 * time.Hour,
+			VacuumInterval:      7 * 24 * time.Hour,
+			DeleteBatchSize:     100,
+			StatusRetentionDays: statusRetention,
 		}
 		cleanupMgr = cleanup.NewCleanupManager(cleanupConfig, dataStore)
+		if elector != nil {
+			cleanupMgr.SetLeadership(elector, leader.DefaultLeaseName)
+		}
+		if *enableArchive {
+			archiver, err := archive.NewArchiver(archive.Config{Dir: *archiveDir, Format: *archiveFormat})
+			if err != nil {
+				logger.Fatal(fmt.Sprintf("Failed to initialize job archive: %v", err))
+			}
+			cleanupMgr.SetArchiver(archiver)
+			logger.Info(fmt.Sprintf("✓ Job archival enabled (dir: %s, format: %s)", *archiveDir, *archiveFormat))
+		}
 		cleanupMgr.Start()
 		logger.Info(fmt.Sprintf("✓ Cleanup manager started (retention: %d days)", *cleanupRetention))
 	}
 
+	// Start scheduled backups
+	var backupMgr *backup.Manager
+	if *enableBackup {
+		logger.Info("Initializing backup manager...")
+		backupMgr = backup.NewManager(backup.Config{
+			Enabled:   true,
+			Dir:       *backupDir,
+			Interval:  *backupInterval,
+			Retention: *backupRetention,
+		}, dataStore)
+		if elector != nil {
+			backupMgr.SetLeaderCheck(elector)
+		}
+		backupMgr.Start()
+		logger.Info(fmt.Sprintf("✓ Backup manager started (dir: %s, interval: %v, retention: %d)", *backupDir, *backupInterval, *backupRetention))
+	}
+
+	// Start webhook dispatcher
+	var webhookDispatcher *webhooks.Dispatcher
+	if *enableWebhooks {
+		webhookConfig := webhooks.DefaultConfig()
+		webhookConfig.Workers = *webhookWorkers
+		webhookConfig.Retry.MaxRetries = *webhookMaxRetries
+		webhookConfig.Retention = *webhookRetention
+		webhookDispatcher = webhooks.NewDispatcher(webhookConfig, dataStore)
+		webhookDispatcher.Start()
+		handler.SetWebhookDispatcher(webhookDispatcher)
+		logger.Info(fmt.Sprintf("✓ Webhook dispatcher started (workers: %d, max retries: %d)", *webhookWorkers, *webhookMaxRetries))
+	}
+
 	// Start background scheduler
 	sched := scheduler.New(dataStore, *schedulerInterval)
+	if webhookDispatcher != nil {
+		sched.SetNotifier(scheduler.MultiNotifier{webhookDispatcher, quotaEnforcer})
+	} else {
+		sched.SetNotifier(quotaEnforcer)
+	}
+	if elector != nil {
+		sched.SetLeadership(elector, leader.DefaultLeaseName)
+	}
 	sched.Start()
 	logger.Info(fmt.Sprintf("Background scheduler started (interval: %v)", *schedulerInterval))
 
@@ -370,6 +610,15 @@ func main() {
 		if err != nil {
 			logger.Fatal(fmt.Sprintf("Failed to load TLS config: %v", err))
 		}
+		if workerCA != nil {
+			// Accept certificates from the worker CA; workers without one must still reach
+			// the enrollment endpoint, so CertificateMiddleware enforces --mtls instead
+			if tlsConfig.ClientCAs == nil {
+				tlsConfig.ClientCAs = x509.NewCertPool()
+			}
+			tlsConfig.ClientCAs.AddCert(workerCA.Certificate())
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
 		srv.TLSConfig = tlsConfig
 	} else {
 		logger.Info("WARNING: TLS disabled")
@@ -389,6 +638,43 @@ func main() {
 		return nil
 	})
 	
+	shutdownMgr.Register(func(ctx context.Context) error {
+		if rateLimiter != nil {
+			rateLimiter.Stop()
+		}
+		return nil
+	})
+
+	shutdownMgr.Register(func(ctx context.Context) error {
+		logger.Info("Flushing tenant usage...")
+		quotaEnforcer.Stop()
+		return nil
+	})
+	
+	shutdownMgr.Register(func(ctx context.Context) error {
+		if webhookDispatcher != nil {
+			logger.Info("Stopping webhook dispatcher...")
+			webhookDispatcher.Stop()
+		}
+		return nil
+	})
+	
+	shutdownMgr.Register(func(ctx context.Context) error {
+		if elector != nil {
+			logger.Info("Releasing leadership...")
+			elector.Stop()
+		}
+		return nil
+	})
+	
+	shutdownMgr.Register(func(ctx context.Context) error {
+		if backupMgr != nil {
+			logger.Info("Stopping backup manager...")
+			backupMgr.Stop()
+		}
+		return nil
+	})
+	
 	shutdownMgr.Register(func(ctx context.Context) error {
 		if cleanupMgr != nil {
 			logger.Info("Stopping cleanup manager...")
@@ -432,6 +718,39 @@ func main() {
 		logger.Info("  GET    /jobs")
 		logger.Info("  GET    /jobs/next?node_id=<id>")
 		logger.Info("  POST   /results")
+		logger.Info("  GET    /jobs/{id}/result")
+		logger.Info("  GET    /results/aggregates?group_by=scenario|engine|node")
+		logger.Info("  POST   /webhooks")
+		logger.Info("  GET    /webhooks")
+		logger.Info("  GET    /webhooks/{id}/deliveries")
+		logger.Info("  POST   /webhooks/deliveries/{id}/redeliver")
+		logger.Info("  POST   /tenants")
+		logger.Info("  GET    /tenants")
+		logger.Info("  GET    /tenants/{id}/stats")
+		logger.Info("  POST   /auth/login")
+		logger.Info("  POST   /auth/logout")
+		logger.Info("  POST   /users")
+		logger.Info("  GET    /users")
+		logger.Info("  GET    /admin/backup")
+		logger.Info("  POST   /admin/restore")
+		if *enableSecrets {
+			logger.Info("  POST   /secrets")
+			logger.Info("  GET    /secrets")
+			logger.Info("  PUT    /secrets/{name}")
+			logger.Info("  DELETE /secrets/{name}")
+		}
+		if *enableAudit {
+			logger.Info("  GET    /audit")
+			logger.Info("  GET    /audit/export")
+		}
+		if workerCA != nil {
+			logger.Info("  POST   /pki/bootstrap-tokens")
+			logger.Info("  GET    /pki/certificates")
+			logger.Info("  DELETE /pki/certificates/{serial}")
+			logger.Info("  POST   /pki/enroll")
+			logger.Info("  POST   /pki/renew")
+			logger.Info("  GET    /pki/ca.crt")
+		}
 		logger.Info("  GET    /health")
 
 		var err error
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -104,6 +105,27 @@ func loadLatestResults() error {
 
 // metricsHandler handles the /metrics endpoint
 func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	// Exporter metadata
+	fmt.Fprintln(w, "# HELP results_exporter_up Results exporter is running")
+	fmt.Fprintln(w, "# TYPE results_exporter_up gauge")
+	fmt.Fprintln(w, "results_exporter_up 1")
+
+	if source != nil {
+		writeStoreMetrics(w)
+	} else {
+		writeFileMetrics(w)
+	}
+
+	// Exporter info
+	fmt.Fprintln(w, "# HELP results_exporter_info Results exporter information")
+	fmt.Fprintln(w, "# TYPE results_exporter_info gauge")
+	fmt.Fprintln(w, "results_exporter_info{version=\"1.0.0\",language=\"go\"} 1")
+}
+
+// writeFileMetrics writes the results_scenario_* metrics from the latest results file
+func writeFileMetrics(w io.Writer) {
 	// Load latest results
 	if err := loadLatestResults(); err != nil {
 		log.Printf("Error loading results: %v", err)
@@ -112,13 +134,6 @@ func metricsHandler(w http.ResponseWriter, r *http.Request) {
 	resultsData.RLock()
 	defer resultsData.RUnlock()
 
-	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
-
-	// Exporter metadata
-	fmt.Fprintln(w, "# HELP results_exporter_up Results exporter is running")
-	fmt.Fprintln(w, "# TYPE results_exporter_up gauge")
-	fmt.Fprintln(w, "results_exporter_up 1")
-
 	// Scenarios count
 	fmt.Fprintln(w, "# HELP results_scenarios_total Total number of scenarios loaded")
 	fmt.Fprintln(w, "# TYPE results_scenarios_total gauge")
@@ -216,11 +231,6 @@ func metricsHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-
-	// Exporter info
-	fmt.Fprintln(w, "# HELP results_exporter_info Results exporter information")
-	fmt.Fprintln(w, "# TYPE results_exporter_info gauge")
-	fmt.Fprintln(w, "results_exporter_info{version=\"1.0.0\",language=\"go\"} 1")
 }
 
 // healthHandler handles the /health endpoint
@@ -233,6 +243,8 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 func main() {
 	port := flag.Int("port", defaultPort, "Port to listen on")
 	resultsPath := flag.String("results-dir", "./test_results", "Directory containing test results")
+	masterURL := flag.String("master-url", "", "Read aggregated results from this master's result store instead of results files")
+	masterInsecure := flag.Bool("master-insecure", false, "Skip TLS certificate verification when connecting to the master")
 	flag.Parse()
 
 	// Override with environment variable if set
@@ -246,19 +258,33 @@ func main() {
 		*resultsPath = envResultsDir
 	}
 
+	if envMasterURL := os.Getenv("MASTER_URL"); envMasterURL != "" {
+		*masterURL = envMasterURL
+	}
+
 	resultsDir = *resultsPath
 
 	log.Println("Starting Results Exporter (Go)")
-	log.Printf("Results directory: %s", resultsDir)
 
-	// Check if results directory exists
-	if _, err := os.Stat(resultsDir); os.IsNotExist(err) {
-		log.Printf("Warning: Results directory %s does not exist", resultsDir)
-	}
+	if *masterURL != "" {
+		source = newStoreSource(*masterURL, os.Getenv("MASTER_API_KEY"), *masterInsecure)
+		log.Printf("Result store: %s/results/aggregates", source.masterURL)
 
-	// Load initial results
-	if err := loadLatestResults(); err != nil {
-		log.Printf("Warning: Initial results load failed: %v", err)
+		if err := loadStoreAggregates(); err != nil {
+			log.Printf("Warning: Initial results load failed: %v", err)
+		}
+	} else {
+		log.Printf("Results directory: %s", resultsDir)
+
+		// Check if results directory exists
+		if _, err := os.Stat(resultsDir); os.IsNotExist(err) {
+			log.Printf("Warning: Results directory %s does not exist", resultsDir)
+		}
+
+		// Load initial results
+		if err := loadLatestResults(); err != nil {
+			log.Printf("Warning: Initial results load failed: %v", err)
+		}
 	}
 
 	// Register HTTP handlers
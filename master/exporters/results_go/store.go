@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// resultGroups are the groupings fetched from the master's aggregate endpoint
+var resultGroups = []string{models.ResultGroupScenario, models.ResultGroupEngine, models.ResultGroupNode}
+
+// storeSource reads aggregated job results from the master's result store
+type storeSource struct {
+	masterURL string
+	apiKey    string
+	client    *http.Client
+}
+
+var (
+	storeData = struct {
+		sync.RWMutex
+		aggregates []*models.ResultAggregate
+		lastLoad   time.Time
+	}{}
+	source *storeSource // nil when reading result files only
+)
+
+// newStoreSource creates a source for the master at masterURL
+func newStoreSource(masterURL, apiKey string, insecure bool) *storeSource {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if insecure {
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402 - opt-in for self-signed master certificates
+		}
+	}
+	return &storeSource{
+		masterURL: strings.TrimSuffix(masterURL, "/"),
+		apiKey:    apiKey,
+		client:    client,
+	}
+}
+
+// fetch retrieves the aggregates of one grouping from GET /results/aggregates
+func (s *storeSource) fetch(groupBy string) ([]*models.ResultAggregate, error) {
+	req, err := http.NewRequest("GET", s.masterURL+"/results/aggregates?group_by="+groupBy, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("master returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Aggregates []*models.ResultAggregate `json:"aggregates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse aggregates: %w", err)
+	}
+	return result.Aggregates, nil
+}
+
+// loadStoreAggregates refreshes the cached aggregates from the master
+func loadStoreAggregates() error {
+	storeData.Lock()
+	defer storeData.Unlock()
+
+	// Check cache
+	if time.Since(storeData.lastLoad) < cacheTTL {
+		return nil
+	}
+
+	var aggregates []*models.ResultAggregate
+	for _, groupBy := range resultGroups {
+		aggs, err := source.fetch(groupBy)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s aggregates: %w", groupBy, err)
+		}
+		aggregates = append(aggregates, aggs...)
+	}
+
+	storeData.aggregates = aggregates
+	storeData.lastLoad = time.Now()
+	return nil
+}
+
+// writeStoreMetrics writes the results_store_* metrics for the cached aggregates
+func writeStoreMetrics(w io.Writer) {
+	if err := loadStoreAggregates(); err != nil {
+		log.Printf("Error loading results from master: %v", err)
+	}
+
+	storeData.RLock()
+	defer storeData.RUnlock()
+
+	gauges := []struct {
+		name  string
+		help  string
+		value func(a *models.ResultAggregate) float64
+	}{
+		{"results_store_jobs_completed", "Completed jobs with stored results", func(a *models.ResultAggregate) float64 { return float64(a.Count) }},
+		{"results_store_avg_fps", "Average encoding frames per second", func(a *models.ResultAggregate) float64 { return a.AvgFPS }},
+		{"results_store_avg_bitrate_kbps", "Average output bitrate in kbit/s", func(a *models.ResultAggregate) float64 { return a.AvgBitrateKbps }},
+		{"results_store_avg_duration_seconds", "Average job duration in seconds", func(a *models.ResultAggregate) float64 { return a.AvgDurationSeconds }},
+		{"results_store_avg_vmaf_score", "Average VMAF quality score (0-100)", func(a *models.ResultAggregate) float64 { return a.AvgVMAFScore }},
+		{"results_store_avg_energy_joules", "Average energy consumed per job in joules", func(a *models.ResultAggregate) float64 { return a.AvgEnergyJoules }},
+		{"results_store_energy_joules_total", "Total energy consumed in joules", func(a *models.ResultAggregate) float64 { return a.TotalEnergyJoules }},
+		{"results_store_frames_total", "Total processed frames", func(a *models.ResultAggregate) float64 { return float64(a.TotalFrames) }},
+		{"results_store_dropped_frames_total", "Total dropped frames", func(a *models.ResultAggregate) float64 { return float64(a.TotalDroppedFrames) }},
+	}
+
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+		for _, agg := range storeData.aggregates {
+			fmt.Fprintf(w, "%s{group_by=\"%s\",key=\"%s\"} %.2f\n", g.name, agg.GroupBy, agg.Key, g.value(agg))
+		}
+	}
+}
@@ -1,15 +1,13 @@
 package agent
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
 
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/retry"
 )
@@ -18,34 +16,40 @@ import (
 type Client struct {
 	masterURL   string
 	httpClient  *http.Client
+	api         *client.Client // Authenticated master API client
+	pki         *client.Client // Unauthenticated client for certificate enrollment and renewal
 	nodeID      string
+	nodeToken   string // Identity token issued by the master at registration
 	apiKey      string
 	retryConfig retry.Config
 }
 
 // NewClient creates a new agent client
 func NewClient(masterURL string) *Client {
-	return &Client{
-		masterURL: masterURL,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
-		retryConfig: retry.DefaultConfig(),
-	}
+	return newClient(masterURL, &http.Client{
+		Timeout: client.DefaultTimeout,
+	})
 }
 
 // NewClientWithTLS creates a new agent client with TLS support
 func NewClientWithTLS(masterURL string, tlsConfig *tls.Config) *Client {
-	return &Client{
-		masterURL: masterURL,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: tlsConfig,
-			},
+	return newClient(masterURL, &http.Client{
+		Timeout: client.DefaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
 		},
+	})
+}
+
+func newClient(masterURL string, httpClient *http.Client) *Client {
+	c := &Client{
+		masterURL:   masterURL,
+		httpClient:  httpClient,
 		retryConfig: retry.DefaultConfig(),
 	}
+	c.api = client.New(masterURL, client.WithHTTPClient(httpClient), client.WithRequestEditor(c.addAuthHeader))
+	c.pki = client.New(masterURL, client.WithHTTPClient(httpClient))
+	return c
 }
 
 // SetAPIKey sets the API key for authentication
@@ -53,53 +57,31 @@ func (c *Client) SetAPIKey(apiKey string) {
 	c.apiKey = apiKey
 }
 
-// addAuthHeader adds authentication header to request
-func (c *Client) addAuthHeader(req *http.Request) {
+// addAuthHeader adds authentication headers to request
+func (c *Client) addAuthHeader(req *http.Request) error {
 	if c.apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
+	if c.nodeToken != "" {
+		req.Header.Set(auth.NodeTokenHeader, c.nodeToken)
+	}
+	return nil
 }
 
-// Register registers the node with the master
+// Register registers the node with the master. The master answers a
+// re-registration of a known address with the existing node.
 func (c *Client) Register(reg *models.NodeRegistration) (*models.Node, error) {
-	data, err := json.Marshal(reg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal registration: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", c.masterURL+"/nodes/register", bytes.NewBuffer(data))
+	registered, err := c.api.RegisterNode(context.Background(), reg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	c.addAuthHeader(req)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send registration: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Accept both 201 (new registration) and 200 (re-registration)
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("registration failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var node models.Node
-	if err := json.NewDecoder(resp.Body).Decode(&node); err != nil {
-		return nil, fmt.Errorf("failed to decode node: %w", err)
+		return nil, fmt.Errorf("registration failed: %w", err)
 	}
 
+	node := registered.Node
 	c.nodeID = node.ID
-	
-	// Log re-registration vs new registration
-	if resp.StatusCode == http.StatusOK {
-		// Re-registration (existing node)
-		return &node, nil
+	c.nodeToken = ""
+	if registered.NodeToken != nil {
+		c.nodeToken = *registered.NodeToken
 	}
-	// New registration
 	return &node, nil
 }
 
@@ -111,25 +93,9 @@ func (c *Client) SendHeartbeat() error {
 	}
 
 	return retry.Do(context.Background(), c.retryConfig, func() error {
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/nodes/%s/heartbeat", c.masterURL, c.nodeID), nil)
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+		if err := c.api.NodeHeartbeat(context.Background(), c.nodeID); err != nil {
+			return fmt.Errorf("heartbeat failed: %w", err)
 		}
-
-		req.Header.Set("Content-Type", "application/json")
-		c.addAuthHeader(req)
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("failed to send heartbeat: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("heartbeat failed with status %d: %s", resp.StatusCode, string(body))
-		}
-
 		return nil
 	})
 }
@@ -143,32 +109,11 @@ func (c *Client) GetNextJob() (*models.Job, error) {
 
 	var job *models.Job
 	err := retry.Do(context.Background(), c.retryConfig, func() error {
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/jobs/next?node_id=%s", c.masterURL, c.nodeID), nil)
+		next, err := c.api.GetNextJob(context.Background(), c.nodeID)
 		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
-		}
-
-		c.addAuthHeader(req)
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("failed to get next job: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("get next job failed with status %d: %s", resp.StatusCode, string(body))
-		}
-
-		var result struct {
-			Job *models.Job `json:"job"`
+			return fmt.Errorf("get next job failed: %w", err)
 		}
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return fmt.Errorf("failed to decode job: %w", err)
-		}
-
-		job = result.Job
+		job = next.Job
 		return nil
 	})
 
@@ -178,33 +123,50 @@ func (c *Client) GetNextJob() (*models.Job, error) {
 // SendResults sends job results to the master
 // Uses retry logic for transient network failures (messages, not work)
 func (c *Client) SendResults(result *models.JobResult) error {
-	data, err := json.Marshal(result)
-	if err != nil {
-		return fmt.Errorf("failed to marshal results: %w", err)
-	}
-
 	return retry.Do(context.Background(), c.retryConfig, func() error {
-		req, err := http.NewRequest("POST", c.masterURL+"/results", bytes.NewBuffer(data))
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+		if _, err := c.api.SendResults(context.Background(), result); err != nil {
+			return fmt.Errorf("send results failed: %w", err)
 		}
+		return nil
+	})
+}
 
-		req.Header.Set("Content-Type", "application/json")
-		c.addAuthHeader(req)
+// RegisterExternalJob reports a process discovered on this node; the master
+// records it as a read-only external job
+func (c *Client) RegisterExternalJob(proc *models.ExternalProcess) (*models.Job, error) {
+	if c.nodeID == "" {
+		return nil, fmt.Errorf("node not registered")
+	}
 
-		resp, err := c.httpClient.Do(req)
+	var job *models.Job
+	err := retry.Do(context.Background(), c.retryConfig, func() error {
+		registered, err := c.api.RegisterExternalJob(context.Background(), c.nodeID, proc)
 		if err != nil {
-			return fmt.Errorf("failed to send results: %w", err)
+			return fmt.Errorf("register external job failed: %w", err)
 		}
-		defer resp.Body.Close()
+		job = registered
+		return nil
+	})
+	return job, err
+}
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("send results failed with status %d: %s", resp.StatusCode, string(body))
-		}
+// UpdateExternalJob reports the usage of an external job and, once the
+// process exited, its exit code
+func (c *Client) UpdateExternalJob(jobID string, proc *models.ExternalProcess) (*models.Job, error) {
+	if c.nodeID == "" {
+		return nil, fmt.Errorf("node not registered")
+	}
 
+	var job *models.Job
+	err := retry.Do(context.Background(), c.retryConfig, func() error {
+		updated, err := c.api.UpdateExternalJob(context.Background(), c.nodeID, jobID, proc)
+		if err != nil {
+			return fmt.Errorf("update external job failed: %w", err)
+		}
+		job = updated
 		return nil
 	})
+	return job, err
 }
 
 // GetNodeID returns the node ID
@@ -221,29 +183,34 @@ func (c *Client) GetMasterURL() string {
 
 // GetJob retrieves a specific job by ID from the master
 func (c *Client) GetJob(jobID string) (*models.Job, error) {
-	req, err := http.NewRequest("GET", c.masterURL+"/api/v1/jobs/"+jobID, nil)
+	job, err := c.api.GetJob(context.Background(), jobID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("get job failed: %w", err)
 	}
+	return job, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	c.addAuthHeader(req)
-
-	resp, err := c.httpClient.Do(req)
+// Enroll exchanges a one-time bootstrap token and CSR for a client certificate
+func (c *Client) Enroll(token string, csrPEM []byte) (*models.CertificateResponse, error) {
+	cert, err := c.pki.EnrollWorker(context.Background(), &models.EnrollmentRequest{Token: token, CSR: string(csrPEM)})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get job: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get job failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("certificate request failed: %w", err)
 	}
+	return cert, nil
+}
 
-	var job models.Job
-	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
-		return nil, fmt.Errorf("failed to decode job: %w", err)
+// RenewCertificate requests a new client certificate for csrPEM. The request
+// must be made over a connection presenting the current certificate.
+func (c *Client) RenewCertificate(csrPEM []byte) (*models.CertificateResponse, error) {
+	cert, err := c.pki.RenewCertificate(context.Background(), &models.RenewalRequest{CSR: string(csrPEM)})
+	if err != nil {
+		return nil, fmt.Errorf("certificate request failed: %w", err)
 	}
+	return cert, nil
+}
 
-	return &job, nil
+// CloseIdleConnections closes idle connections to the master, so the next
+// request presents the current client certificate
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
 }
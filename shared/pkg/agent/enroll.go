@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	tlsutil "github.com/psantana5/ffmpeg-rtmp/pkg/tls"
+)
+
+// Files written to the worker's PKI directory
+const (
+	clientCertFileName = "client.crt"
+	clientKeyFileName  = "client.key"
+	caCertFileName     = "ca.crt"
+)
+
+// CertificateEnroller obtains a worker's client certificate from the master's
+// certificate authority and renews it before it expires
+type CertificateEnroller struct {
+	client     *Client
+	cert       *tlsutil.ClientCertificate
+	dir        string
+	commonName string
+}
+
+// NewCertificateEnroller creates an enroller keeping the certificate for
+// commonName in dir and presenting it through cert
+func NewCertificateEnroller(client *Client, cert *tlsutil.ClientCertificate, dir, commonName string) *CertificateEnroller {
+	return &CertificateEnroller{
+		client:     client,
+		cert:       cert,
+		dir:        dir,
+		commonName: commonName,
+	}
+}
+
+// HasCertificate reports whether a certificate was enrolled into dir
+func HasCertificate(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, clientCertFileName))
+	return err == nil
+}
+
+// EnsureCertificate loads the certificate from disk, enrolling with the
+// bootstrap token if there is none or it has expired. A certificate that is
+// due for renewal is renewed straight away.
+func (e *CertificateEnroller) EnsureCertificate(token string) error {
+	certFile := filepath.Join(e.dir, clientCertFileName)
+	keyFile := filepath.Join(e.dir, clientKeyFileName)
+
+	if err := e.cert.Load(certFile, keyFile); err == nil && time.Now().Before(e.cert.Leaf().NotAfter) {
+		log.Printf("✓ Loaded client certificate %s (expires %s)", certFile, e.cert.Leaf().NotAfter.Format(time.RFC3339))
+		return e.RenewIfDue()
+	}
+
+	if token == "" {
+		return fmt.Errorf("no valid client certificate in %s: enroll with a bootstrap token", e.dir)
+	}
+
+	log.Printf("Enrolling with the master as %s...", e.commonName)
+	keyPEM, csrPEM, err := tlsutil.GenerateKeyAndCSR(e.commonName)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Enroll(token, csrPEM)
+	if err != nil {
+		return fmt.Errorf("enrollment failed: %w", err)
+	}
+	if err := e.install(keyPEM, resp.Certificate, resp.CACertificate); err != nil {
+		return err
+	}
+	log.Printf("✓ Enrolled: certificate %s expires %s", resp.Serial, resp.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// RenewIfDue renews the certificate once two thirds of its lifetime have passed
+func (e *CertificateEnroller) RenewIfDue() error {
+	leaf := e.cert.Leaf()
+	if leaf == nil || !tlsutil.RenewalDue(leaf, time.Now()) {
+		return nil
+	}
+
+	keyPEM, csrPEM, err := tlsutil.GenerateKeyAndCSR(e.commonName)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.RenewCertificate(csrPEM)
+	if err != nil {
+		return fmt.Errorf("certificate renewal failed: %w", err)
+	}
+	if err := e.install(keyPEM, resp.Certificate, resp.CACertificate); err != nil {
+		return err
+	}
+	log.Printf("✓ Client certificate renewed: %s expires %s", resp.Serial, resp.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// Run checks for renewal every interval until stop is closed
+func (e *CertificateEnroller) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.RenewIfDue(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// install writes a new key pair and CA certificate and starts presenting the new certificate
+func (e *CertificateEnroller) install(keyPEM []byte, certPEM, caPEM string) error {
+	certFile := filepath.Join(e.dir, clientCertFileName)
+	keyFile := filepath.Join(e.dir, clientKeyFileName)
+
+	if err := tlsutil.WriteKeyPair(certFile, keyFile, []byte(certPEM), keyPEM); err != nil {
+		return fmt.Errorf("failed to save client certificate: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(e.dir, caCertFileName), []byte(caPEM), 0644); err != nil {
+		return fmt.Errorf("failed to save CA certificate: %w", err)
+	}
+	if err := e.cert.Load(certFile, keyFile); err != nil {
+		return err
+	}
+
+	// Connections keep the certificate they were opened with
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"log"
+	"sync"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// externalJob is a reported process and the master job it was registered as
+type externalJob struct {
+	proc       *models.ExternalProcess
+	jobID      string        // Empty if the registration failed
+	registered chan struct{} // Closed once the registration finished
+}
+
+// ExternalJobReporter reports processes discovered on this node to the master
+// as read-only external jobs. Reports are sent in the background, so a slow or
+// unreachable master never delays governing the process.
+type ExternalJobReporter struct {
+	client *Client
+
+	// OnFinished is called with the job of a process that exited (optional)
+	OnFinished func(job *models.Job)
+
+	mu   sync.Mutex
+	jobs map[int]*externalJob
+}
+
+// NewExternalJobReporter creates a reporter that sends reports through client
+func NewExternalJobReporter(client *Client) *ExternalJobReporter {
+	return &ExternalJobReporter{
+		client: client,
+		jobs:   make(map[int]*externalJob),
+	}
+}
+
+// Attached registers a discovered process with the master
+func (r *ExternalJobReporter) Attached(proc *models.ExternalProcess) {
+	job := &externalJob{proc: proc, registered: make(chan struct{})}
+	r.mu.Lock()
+	r.jobs[proc.PID] = job
+	r.mu.Unlock()
+
+	go func() {
+		defer close(job.registered)
+		registered, err := r.client.RegisterExternalJob(proc)
+		if err != nil {
+			log.Printf("WARNING: Failed to report PID %d to the master: %v", proc.PID, err)
+			return
+		}
+		job.jobID = registered.ID
+		log.Printf("Reported PID %d (%s) as external job %s", proc.PID, proc.Command, registered.ID)
+	}()
+}
+
+// Exited reports the exit code and resource usage of a process reported with
+// Attached. An exit code of -1 means it is unknown.
+func (r *ExternalJobReporter) Exited(pid int, exitCode int, usage *cgroups.Stats) {
+	r.mu.Lock()
+	job, ok := r.jobs[pid]
+	delete(r.jobs, pid)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	go func() {
+		<-job.registered
+		if job.jobID == "" {
+			return
+		}
+		proc := *job.proc
+		proc.ExitCode = &exitCode
+		proc.Usage = ExternalUsage(usage)
+		finished, err := r.client.UpdateExternalJob(job.jobID, &proc)
+		if err != nil {
+			log.Printf("WARNING: Failed to report the exit of external job %s: %v", job.jobID, err)
+			return
+		}
+		if r.OnFinished != nil {
+			r.OnFinished(finished)
+		}
+	}()
+}
+
+// ExternalUsage converts cgroup statistics to the usage of an external job
+func ExternalUsage(stats *cgroups.Stats) *models.ExternalUsage {
+	if stats == nil {
+		return nil
+	}
+	return &models.ExternalUsage{
+		CPUSeconds:   stats.CPUSeconds,
+		MemoryPeak:   stats.MemoryPeak,
+		IOReadBytes:  stats.IOReadBytes,
+		IOWriteBytes: stats.IOWriteBytes,
+		OOMKills:     stats.OOMKills,
+	}
+}
@@ -88,10 +88,7 @@ func (e *FFmpegEngine) BuildCommand(job *models.Job, hostURL string) ([]string,
 	}
 
 	// Get output file (only used in file mode)
-	outputFile := fmt.Sprintf("/tmp/job_%s_output.mp4", job.ID)
-	if output, ok := params["output"].(string); ok && output != "" {
-		outputFile = output
-	}
+	outputFile := outputFileParam(params, job.ID)
 
 	// Get transcode parameters with defaults
 	bitrate := "2000k"
@@ -224,3 +221,19 @@ func (e *FFmpegEngine) BuildCommand(job *models.Job, hostURL string) ([]string,
 
 	return args, nil
 }
+
+// OutputFile returns the file an FFmpeg job writes, or "" if it streams
+func OutputFile(job *models.Job) string {
+	if mode, ok := job.Parameters["output_mode"].(string); ok && (mode == "rtmp" || mode == "stream") {
+		return ""
+	}
+	return outputFileParam(job.Parameters, job.ID)
+}
+
+// outputFileParam returns the output file parameter, or the default file
+func outputFileParam(params map[string]interface{}, jobID string) string {
+	if output, ok := params["output"].(string); ok && output != "" {
+		return output
+	}
+	return fmt.Sprintf("/tmp/job_%s_output.mp4", jobID)
+}
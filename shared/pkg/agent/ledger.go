@@ -0,0 +1,278 @@
+package agent
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/internal/observe"
+	"github.com/psantana5/ffmpeg-rtmp/internal/report"
+	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// LedgerEntry is a workload the agent started for a job
+type LedgerEntry struct {
+	JobID      string          `json:"job_id"`
+	Queue      string          `json:"queue,omitempty"`
+	PID        int             `json:"pid"`
+	StartTicks uint64          `json:"start_ticks"` // Process start time in clock ticks since boot
+	StartedAt  time.Time       `json:"started_at"`
+	Command    string          `json:"command"`
+	CgroupPath string          `json:"cgroup_path,omitempty"`
+	OutputPath string          `json:"output_path,omitempty"` // Output file, empty for streams
+	StatusPath string          `json:"status_path,omitempty"` // Exit status file written by the workload's shim
+	Limits     *cgroups.Limits `json:"limits,omitempty"`      // Limits of the job before the governor adjusts them
+}
+
+// JobLedger persists the workloads the agent started until their results
+// are delivered, so an agent that crashed or restarted can re-attach to the
+// ones still running and report the ones that finished instead of leaving
+// their jobs to orphan detection. A nil ledger records nothing.
+type JobLedger struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*LedgerEntry
+}
+
+// ledgerFile is the on-disk format of the ledger
+type ledgerFile struct {
+	Version string         `json:"version"`
+	Jobs    []*LedgerEntry `json:"jobs"`
+}
+
+// OpenJobLedger loads the ledger at path, creating it on the first write
+func OpenJobLedger(path string) (*JobLedger, error) {
+	l := &JobLedger{path: path, entries: make(map[string]*LedgerEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job ledger: %w", err)
+	}
+
+	var file ledgerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse job ledger %s: %w", path, err)
+	}
+	for _, entry := range file.Jobs {
+		l.entries[entry.JobID] = entry
+	}
+	return l, nil
+}
+
+// Add records a started workload
+func (l *JobLedger) Add(entry *LedgerEntry) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[entry.JobID] = entry
+	return l.save()
+}
+
+// Remove forgets the workload of a job once its result is delivered
+func (l *JobLedger) Remove(jobID string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[jobID]
+	if !ok {
+		return nil
+	}
+	delete(l.entries, jobID)
+	if err := l.save(); err != nil {
+		return err
+	}
+	if entry.StatusPath != "" {
+		if err := os.Remove(entry.StatusPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove exit status: %w", err)
+		}
+	}
+	return nil
+}
+
+// StatusPath returns the file the shim of a job's workload records its exit
+// status in, next to the ledger; empty for a nil ledger
+func (l *JobLedger) StatusPath(jobID string) string {
+	if l == nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(l.path), "exit-status", jobID+".json")
+}
+
+// Entries returns the recorded workloads, oldest first
+func (l *JobLedger) Entries() []*LedgerEntry {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]*LedgerEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StartedAt.Before(entries[j].StartedAt) })
+	return entries
+}
+
+// save writes the ledger atomically and durably; l.mu must be held
+func (l *JobLedger) save() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create job ledger directory: %w", err)
+	}
+
+	file := ledgerFile{Version: "1.0", Jobs: make([]*LedgerEntry, 0, len(l.entries))}
+	for _, entry := range l.entries {
+		file.Jobs = append(file.Jobs, entry)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal job ledger: %w", err)
+	}
+
+	tempPath := l.path + ".tmp"
+	f, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write job ledger: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write job ledger: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync job ledger: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write job ledger: %w", err)
+	}
+	if err := os.Rename(tempPath, l.path); err != nil {
+		return fmt.Errorf("failed to replace job ledger: %w", err)
+	}
+	return nil
+}
+
+// NewLedgerEntry describes the workload Run started for a job. pid is the
+// workload's shim if it records the exit status at statusPath.
+func NewLedgerEntry(job *models.Job, command string, pid int, cgroupPath, statusPath string) *LedgerEntry {
+	// Without a start time the PID cannot be told apart from a reused one,
+	// and the workload is treated as finished after a restart
+	ticks, _ := observe.StartTicks(pid)
+	return &LedgerEntry{
+		JobID:      job.ID,
+		Queue:      job.Queue,
+		PID:        pid,
+		StartTicks: ticks,
+		StartedAt:  time.Now(),
+		Command:    command,
+		CgroupPath: cgroupPath,
+		OutputPath: workloadOutput(job, command),
+		StatusPath: statusPath,
+		Limits:     WrapperLimits(job),
+	}
+}
+
+// workloadOutput returns the output file the workload command writes for a
+// job, empty if it streams
+func workloadOutput(job *models.Job, command string) string {
+	if filepath.Base(command) == "gst-launch-1.0" {
+		return "" // GStreamer jobs always stream
+	}
+	return OutputFile(job)
+}
+
+// Running reports whether the workload still runs: its PID exists and was
+// not reused by another process
+func (e *LedgerEntry) Running() bool {
+	if e.StartTicks == 0 {
+		return false
+	}
+	ticks, err := observe.StartTicks(e.PID)
+	return err == nil && ticks == e.StartTicks
+}
+
+// Readopt re-attaches to the running workload of an entry in attach mode
+// and waits for it to exit. The workload is never restarted or signaled.
+// The exit code of the result is unknown (-1); see ExitStatus.
+func (e *LedgerEntry) Readopt(ctx context.Context, limits *cgroups.Limits) (*report.Result, error) {
+	return wrapper.Attach(ctx, e.JobID, e.PID, limits)
+}
+
+// ExitStatus returns how the workload exited, as its shim recorded it.
+// Returns: nil if the status is unknown: the workload ran without a shim
+// (older agents) or the shim was killed before it recorded the status
+func (e *LedgerEntry) ExitStatus() *wrapper.ExitStatus {
+	if e.StatusPath == "" {
+		return nil
+	}
+	status, err := wrapper.ReadExitStatus(e.StatusPath)
+	if err != nil {
+		return nil
+	}
+	return status
+}
+
+// Collect returns the resource usage of a workload that exited while the
+// agent was down and removes its cgroup. The usage is nil if the cgroup is
+// gone or usage is not available.
+func (e *LedgerEntry) Collect() *cgroups.Stats {
+	if e.CgroupPath == "" {
+		return nil
+	}
+	mgr := cgroups.New()
+	stats, err := mgr.Stats(e.CgroupPath)
+	mgr.Delete(e.CgroupPath)
+	if err != nil {
+		return nil
+	}
+	return stats
+}
+
+// OutputWritten reports whether the output file of the workload exists and
+// is not empty. Workloads that stream have no output file and always pass.
+func (e *LedgerEntry) OutputWritten() bool {
+	if e.OutputPath == "" {
+		return true
+	}
+	info, err := os.Stat(e.OutputPath)
+	return err == nil && info.Size() > 0
+}
+
+// RecoveredStatus decides the outcome of a workload started by a previous run
+// of the agent from the exit status its shim recorded. A captured exit
+// status decides as for any run, and a job that writes a file also needs the
+// file. Without a status the job fails with FailureReasonExitUnknown, which
+// is not retried: the workload may have completed, or crashed and left a
+// partial output behind.
+// Returns: the job status, the failure reason and the error, empty on success
+func (e *LedgerEntry) RecoveredStatus(status *wrapper.ExitStatus) (models.JobStatus, models.FailureReason, string) {
+	switch {
+	case status == nil:
+		return models.JobStatusFailed, models.FailureReasonExitUnknown, "recovered after agent restart, exit status unknown"
+	case status.Signal != "":
+		return models.JobStatusFailed, "", fmt.Sprintf("recovered workload was killed by signal: %s", status.Signal)
+	case status.ExitCode != 0:
+		return models.JobStatusFailed, "", fmt.Sprintf("recovered workload exited with code %d", status.ExitCode)
+	case !e.OutputWritten():
+		return models.JobStatusFailed, "", fmt.Sprintf("output %s was not written by the recovered workload", e.OutputPath)
+	}
+	return models.JobStatusCompleted, "", ""
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/psantana5/ffmpeg-rtmp/internal/observe"
+	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+func TestJobLedger_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger", "jobs.json")
+	ledger, err := OpenJobLedger(path)
+	if err != nil {
+		t.Fatalf("OpenJobLedger failed: %v", err)
+	}
+
+	job := &models.Job{ID: "job-1", Queue: "live", Parameters: map[string]interface{}{"output": "/tmp/out.mp4"}}
+	if err := ledger.Add(NewLedgerEntry(job, "/usr/bin/ffmpeg", os.Getpid(), "", ledger.StatusPath("job-1"))); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	status2 := ledger.StatusPath("job-2")
+	if err := ledger.Add(NewLedgerEntry(&models.Job{ID: "job-2"}, "/usr/bin/ffmpeg", os.Getpid(), "", status2)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	os.MkdirAll(filepath.Dir(status2), 0755)
+	os.WriteFile(status2, []byte(`{"exit_code":0}`), 0600)
+	if err := ledger.Remove("job-2"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(status2); !os.IsNotExist(err) {
+		t.Errorf("Expected the exit status of a removed job to be deleted, got %v", err)
+	}
+
+	reopened, err := OpenJobLedger(path)
+	if err != nil {
+		t.Fatalf("reopening the ledger failed: %v", err)
+	}
+	entries := reopened.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.JobID != "job-1" || entry.Queue != "live" || entry.PID != os.Getpid() {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.OutputPath != "/tmp/out.mp4" {
+		t.Errorf("Expected output path /tmp/out.mp4, got %q", entry.OutputPath)
+	}
+	if want := filepath.Join(filepath.Dir(path), "exit-status", "job-1.json"); entry.StatusPath != want {
+		t.Errorf("Expected status path %s, got %q", want, entry.StatusPath)
+	}
+}
+
+func TestJobLedger_Nil(t *testing.T) {
+	var ledger *JobLedger
+	if err := ledger.Add(&LedgerEntry{JobID: "job-1"}); err != nil {
+		t.Errorf("Add on a nil ledger failed: %v", err)
+	}
+	if err := ledger.Remove("job-1"); err != nil {
+		t.Errorf("Remove on a nil ledger failed: %v", err)
+	}
+	if entries := ledger.Entries(); len(entries) != 0 {
+		t.Errorf("Expected no entries, got %d", len(entries))
+	}
+}
+
+func TestLedgerEntry_Running(t *testing.T) {
+	ticks, err := observe.StartTicks(os.Getpid())
+	if err != nil {
+		t.Skipf("/proc not available: %v", err)
+	}
+
+	entry := &LedgerEntry{PID: os.Getpid(), StartTicks: ticks}
+	if !entry.Running() {
+		t.Error("Expected the test process to be running")
+	}
+
+	// A reused PID has a different start time
+	entry.StartTicks = ticks + 1
+	if entry.Running() {
+		t.Error("Expected a mismatched start time to be treated as exited")
+	}
+
+	entry.StartTicks = 0
+	if entry.Running() {
+		t.Error("Expected an unknown start time to be treated as exited")
+	}
+}
+
+func TestLedgerEntry_OutputWritten(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.mp4")
+	entry := &LedgerEntry{OutputPath: output}
+	if entry.OutputWritten() {
+		t.Error("Expected a missing output to fail")
+	}
+
+	os.WriteFile(output, nil, 0644)
+	if entry.OutputWritten() {
+		t.Error("Expected an empty output to fail")
+	}
+
+	os.WriteFile(output, []byte("data"), 0644)
+	if !entry.OutputWritten() {
+		t.Error("Expected a written output to pass")
+	}
+
+	if !(&LedgerEntry{}).OutputWritten() {
+		t.Error("Expected a streaming workload to pass")
+	}
+}
+
+func TestLedgerEntry_ExitStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job-1.json")
+	entry := &LedgerEntry{JobID: "job-1", StatusPath: path}
+
+	if status := entry.ExitStatus(); status != nil {
+		t.Errorf("Expected no status before the shim wrote it, got %+v", status)
+	}
+	os.WriteFile(path, []byte(`{"exit_code":-1,"signal":"killed"}`), 0600)
+	status := entry.ExitStatus()
+	if status == nil || status.ExitCode != -1 || status.Signal != "killed" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if status := (&LedgerEntry{}).ExitStatus(); status != nil {
+		t.Errorf("Expected no status for a workload without shim, got %+v", status)
+	}
+}
+
+func TestLedgerEntry_RecoveredStatus(t *testing.T) {
+	// A crashed ffmpeg leaves a partial, non-empty file behind
+	partial := filepath.Join(t.TempDir(), "partial.mp4")
+	os.WriteFile(partial, []byte("partial"), 0644)
+	missing := filepath.Join(t.TempDir(), "missing.mp4")
+
+	exited := func(code int) *wrapper.ExitStatus { return &wrapper.ExitStatus{ExitCode: code} }
+	tests := []struct {
+		name       string
+		entry      *LedgerEntry
+		exitStatus *wrapper.ExitStatus
+		want       models.JobStatus
+		wantReason models.FailureReason
+	}{
+		{"unknown with partial output", &LedgerEntry{OutputPath: partial}, nil, models.JobStatusFailed, models.FailureReasonExitUnknown},
+		{"stream with unknown exit status", &LedgerEntry{}, nil, models.JobStatusFailed, models.FailureReasonExitUnknown},
+		{"captured failure", &LedgerEntry{OutputPath: partial}, exited(1), models.JobStatusFailed, ""},
+		{"killed by a signal", &LedgerEntry{OutputPath: partial}, &wrapper.ExitStatus{ExitCode: -1, Signal: "killed"}, models.JobStatusFailed, ""},
+		{"captured success without output", &LedgerEntry{OutputPath: missing}, exited(0), models.JobStatusFailed, ""},
+		{"captured success", &LedgerEntry{OutputPath: partial}, exited(0), models.JobStatusCompleted, ""},
+		{"captured success of a stream", &LedgerEntry{}, exited(0), models.JobStatusCompleted, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, failureReason, reason := tt.entry.RecoveredStatus(tt.exitStatus)
+			if status != tt.want {
+				t.Errorf("status = %s, want %s", status, tt.want)
+			}
+			if failureReason != tt.wantReason {
+				t.Errorf("failure reason = %q, want %q", failureReason, tt.wantReason)
+			}
+			if (reason == "") != (tt.want == models.JobStatusCompleted) {
+				t.Errorf("unexpected error %q for status %s", reason, status)
+			}
+		})
+	}
+}
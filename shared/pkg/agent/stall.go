@@ -0,0 +1,97 @@
+package agent
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/internal/report"
+	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	lifecycle "github.com/psantana5/ffmpeg-rtmp/pkg/wrapper"
+)
+
+// networkSchemes are the URL schemes of inputs and outputs read or written
+// over the network
+var networkSchemes = []string{"rtmp://", "rtmps://", "rtsp://", "srt://", "udp://", "tcp://", "rtp://", "http://", "https://"}
+
+// StallPolicy configures stall detection for wrapper workloads
+type StallPolicy struct {
+	Window    time.Duration // Time without progress before a workload is stalled (0 disables)
+	Terminate bool          // Send SIGTERM to stalled workloads instead of waiting for the job timeout
+}
+
+// Supervision returns the supervision of the workload command runs for a
+// job, nil if stall detection is disabled
+func (p StallPolicy) Supervision(job *models.Job, command string) *wrapper.Supervision {
+	if p.Window <= 0 {
+		return nil
+	}
+	return &wrapper.Supervision{
+		Window:     p.Window,
+		OutputPath: workloadOutput(job, command),
+		Terminate:  p.Terminate,
+		OnEvent: func(event lifecycle.LifecycleEvent) {
+			log.Printf("⚠️  Job %s %s (PID %d): %s", job.ID, event.State, event.PID, event.Message)
+		},
+	}
+}
+
+// StallError is the failure of a workload that stopped making progress
+type StallError struct {
+	Command    string
+	Idle       time.Duration
+	Terminated bool
+	Reason     models.FailureReason // FailureReasonNetworkError or FailureReasonRuntimeError
+}
+
+// Error describes the stall
+func (e *StallError) Error() string {
+	msg := fmt.Sprintf("%s stalled: no progress for %.0fs", filepath.Base(e.Command), e.Idle.Seconds())
+	if e.Reason == models.FailureReasonNetworkError {
+		msg += " while reading or writing a network stream"
+	}
+	if e.Terminated {
+		msg += ", terminated"
+	}
+	return msg
+}
+
+// NewStallError classifies the stall of a job's workload. A workload with a
+// network input or output most likely waits on the network (a stuck RTMP
+// handshake, a source that stopped sending); any other stall is a runtime
+// error of the workload.
+func NewStallError(job *models.Job, command string, args []string, stall *report.Stall) *StallError {
+	reason := models.FailureReasonRuntimeError
+	if usesNetwork(job, args) {
+		reason = models.FailureReasonNetworkError
+	}
+	return &StallError{
+		Command:    command,
+		Idle:       stall.Idle,
+		Terminated: stall.Terminated,
+		Reason:     reason,
+	}
+}
+
+// usesNetwork reports whether a workload streams or reads a network input
+func usesNetwork(job *models.Job, args []string) bool {
+	if OutputFile(job) == "" {
+		return true // Streaming output mode
+	}
+	for _, arg := range args {
+		for _, scheme := range networkSchemes {
+			if strings.Contains(strings.ToLower(arg), scheme) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/internal/report"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+func TestNewStallError_Classification(t *testing.T) {
+	stall := &report.Stall{Idle: 30 * time.Second, Terminated: true}
+
+	tests := []struct {
+		name   string
+		job    *models.Job
+		args   []string
+		reason models.FailureReason
+	}{
+		{
+			name:   "local file transcode",
+			job:    &models.Job{ID: "job-1", Parameters: map[string]interface{}{"output": "/tmp/out.mp4"}},
+			args:   []string{"-i", "/tmp/in.mp4", "/tmp/out.mp4"},
+			reason: models.FailureReasonRuntimeError,
+		},
+		{
+			name:   "network input",
+			job:    &models.Job{ID: "job-2", Parameters: map[string]interface{}{"output": "/tmp/out.mp4"}},
+			args:   []string{"-i", "rtmp://source/live/key", "/tmp/out.mp4"},
+			reason: models.FailureReasonNetworkError,
+		},
+		{
+			name:   "GStreamer network element",
+			job:    &models.Job{ID: "job-3", Parameters: map[string]interface{}{}},
+			args:   []string{"rtmpsrc", "location=RTMP://source/live", "!", "fakesink"},
+			reason: models.FailureReasonNetworkError,
+		},
+		{
+			name:   "streaming output mode",
+			job:    &models.Job{ID: "job-4", Parameters: map[string]interface{}{"output_mode": "rtmp"}},
+			args:   []string{"-i", "/tmp/in.mp4"},
+			reason: models.FailureReasonNetworkError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewStallError(tt.job, "/usr/bin/ffmpeg", tt.args, stall)
+			if err.Reason != tt.reason {
+				t.Errorf("Expected reason %s, got %s", tt.reason, err.Reason)
+			}
+			if !strings.Contains(err.Error(), "ffmpeg stalled: no progress for 30s") {
+				t.Errorf("Unexpected error message: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestStallPolicy_Supervision(t *testing.T) {
+	job := &models.Job{ID: "job-1", Parameters: map[string]interface{}{"output": "/tmp/out.mp4"}}
+
+	if s := (StallPolicy{}).Supervision(job, "ffmpeg"); s != nil {
+		t.Error("Expected no supervision without a window")
+	}
+
+	policy := StallPolicy{Window: 30 * time.Second, Terminate: true}
+	s := policy.Supervision(job, "ffmpeg")
+	if s == nil {
+		t.Fatal("Expected supervision")
+	}
+	if s.Window != 30*time.Second || !s.Terminate || s.OutputPath != "/tmp/out.mp4" {
+		t.Errorf("Unexpected supervision: %+v", s)
+	}
+
+	// GStreamer jobs stream, so there is no output file to watch
+	if s := policy.Supervision(job, "/usr/bin/gst-launch-1.0"); s.OutputPath != "" {
+		t.Errorf("Expected no output path for GStreamer, got %q", s.OutputPath)
+	}
+}
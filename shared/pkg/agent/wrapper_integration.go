@@ -10,20 +10,20 @@ import (
 	"fmt"
 	"log"
 	
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"github.com/psantana5/ffmpeg-rtmp/internal/report"
 	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 )
 
 // ExecuteWithWrapper executes a job using the edge workload wrapper
-// This provides process governance without owning the workload
-func ExecuteWithWrapper(ctx context.Context, job *models.Job, command string, args []string) (*report.Result, error) {
+// This provides process governance without owning the workload.
+// limits usually come from WrapperLimits, adjusted by the worker's policy.
+// The started workload is recorded in ledger (optional) for crash recovery
+// and watched for stalls as stall configures.
+func ExecuteWithWrapper(ctx context.Context, job *models.Job, limits *cgroups.Limits, command string, args []string, ledger *JobLedger, stall StallPolicy) (*report.Result, error) {
 	log.Printf("🔧 Using workload wrapper for job %s", job.ID)
 	
-	// Build constraints from job
-	limits := buildWrapperLimits(job)
-	
 	// Log constraints
 	if limits != nil {
 		log.Printf("Wrapper constraints:")
@@ -36,13 +36,22 @@ func ExecuteWithWrapper(ctx context.Context, job *models.Job, command string, ar
 		if limits.MemoryMax > 0 {
 			log.Printf("  Memory max: %d MB", limits.MemoryMax/(1024*1024))
 		}
+		if limits.MemorySwapMax > 0 {
+			log.Printf("  Swap max: %d MB", limits.MemorySwapMax/(1024*1024))
+		}
 		if limits.IOMax != "" {
 			log.Printf("  IO max: %s", limits.IOMax)
 		}
 	}
 	
-	// Execute with wrapper
-	result, err := wrapper.Run(ctx, job.ID, limits, command, args)
+	// Execute with wrapper; with a ledger, a shim records the exit status
+	// for an agent that recovers the workload after a restart
+	statusPath := ledger.StatusPath(job.ID)
+	result, err := wrapper.RunStarted(ctx, job.ID, limits, command, args, func(pid int, cgroupPath string) {
+		if err := ledger.Add(NewLedgerEntry(job, command, pid, cgroupPath, statusPath)); err != nil {
+			log.Printf("WARNING: Failed to record job %s in the job ledger: %v", job.ID, err)
+		}
+	}, stall.Supervision(job, command), statusPath)
 	if err != nil {
 		return nil, fmt.Errorf("wrapper execution failed: %w", err)
 	}
@@ -52,26 +61,47 @@ func ExecuteWithWrapper(ctx context.Context, job *models.Job, command string, ar
 	log.Printf("  Exit Code: %d", result.ExitCode)
 	log.Printf("  Duration: %.2fs", result.Duration.Seconds())
 	log.Printf("  Platform SLA: %v (%s)", result.PlatformSLA, result.PlatformSLAReason)
+	for _, o := range result.Limits {
+		log.Printf("  Limit %s: %s %s", o.Limit, o.Status, o.Reason)
+	}
+	if u := result.Usage; u != nil {
+		log.Printf("  Usage: cpu=%.1fs peak_mem=%dMB io_read=%dMB io_write=%dMB oom_kills=%d",
+			u.CPUSeconds, u.MemoryPeak/cgroups.MiB, u.IOReadBytes/cgroups.MiB, u.IOWriteBytes/cgroups.MiB, u.OOMKills)
+	}
+	if s := result.Stall; s != nil {
+		log.Printf("  Stalled: no progress for %.0fs (terminated: %v)", s.Idle.Seconds(), s.Terminated)
+	}
 	
 	return result, nil
 }
 
-// buildWrapperLimits converts job parameters to wrapper constraints
-func buildWrapperLimits(job *models.Job) *cgroups.Limits {
+// ConstraintLimits converts wrapper constraints to cgroup limits
+func ConstraintLimits(c *models.WrapperConstraints) *cgroups.Limits {
+	if c == nil {
+		return nil
+	}
+	limits := &cgroups.Limits{
+		CPUMax:    c.CPUMax,
+		CPUWeight: c.CPUWeight,
+		IOMax:     c.IOMax,
+	}
+	
+	// Convert MB to bytes for memory
+	if c.MemoryMaxMB > 0 {
+		limits.MemoryMax = c.MemoryMaxMB * cgroups.MiB
+	}
+	if c.MemorySwapMaxMB > 0 {
+		limits.MemorySwapMax = c.MemorySwapMaxMB * cgroups.MiB
+	}
+	
+	return limits
+}
+
+// WrapperLimits converts job parameters to wrapper constraints
+func WrapperLimits(job *models.Job) *cgroups.Limits {
 	// If job has explicit wrapper constraints, use those
 	if job.WrapperConstraints != nil {
-		limits := &cgroups.Limits{
-			CPUMax:    job.WrapperConstraints.CPUMax,
-			CPUWeight: job.WrapperConstraints.CPUWeight,
-			IOMax:     job.WrapperConstraints.IOMax,
-		}
-		
-		// Convert MB to bytes for memory
-		if job.WrapperConstraints.MemoryMaxMB > 0 {
-			limits.MemoryMax = job.WrapperConstraints.MemoryMaxMB * 1024 * 1024
-		}
-		
-		return limits
+		return ConstraintLimits(job.WrapperConstraints)
 	}
 	
 	// Otherwise, try to infer from legacy resource_limits
@@ -88,17 +118,13 @@ func buildWrapperLimits(job *models.Job) *cgroups.Limits {
 	
 	// CPU percentage to quota conversion
 	if maxCPU, ok := resourceLimits["max_cpu_percent"].(float64); ok && maxCPU > 0 {
-		// Convert percentage to quota format: "quota period"
-		// period = 100000 (100ms), quota = (percent * period) / 100
-		period := 100000
-		quota := int((maxCPU * float64(period)) / 100)
-		limits.CPUMax = fmt.Sprintf("%d %d", quota, period)
+		limits.CPUMax = cgroups.CPUPercent(int(maxCPU))
 		limits.CPUWeight = 100 // default
 	}
 	
 	// Memory limit
 	if maxMem, ok := resourceLimits["max_memory_mb"].(float64); ok && maxMem > 0 {
-		limits.MemoryMax = int64(maxMem) * 1024 * 1024
+		limits.MemoryMax = int64(maxMem) * cgroups.MiB
 	}
 	
 	return limits
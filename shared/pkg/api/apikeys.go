@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/rbac"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// issuedAPIKey is the response for a newly issued key; the key itself is only returned once
+type issuedAPIKey struct {
+	*models.TenantAPIKey
+	APIKey string `json:"api_key"`
+}
+
+// requestActor identifies who made a request, for the CreatedBy field of new records
+func requestActor(r *http.Request) string {
+	if userID, err := tenancy.GetUserID(r.Context()); err == nil {
+		return userID
+	}
+	if keyID := tenancy.GetTenantKeyID(r.Context()); keyID != "" {
+		return "apikey:" + keyID
+	}
+	return "admin"
+}
+
+// issueTenantAPIKey generates a key for tenant and stores key (with its name,
+// kind, scopes, expiry and creator already set) as its record.
+// It returns the key, which is never stored.
+func (h *MasterHandler) issueTenantAPIKey(tenant *models.Tenant, key *models.TenantAPIKey) (string, error) {
+	apiKey, prefix, hash, err := auth.GenerateTenantAPIKey(tenant.Name)
+	if err != nil {
+		return "", err
+	}
+
+	key.ID = uuid.New().String()
+	key.TenantID = tenant.ID
+	key.KeyHash = hash
+	key.KeyPrefix = prefix
+	key.CreatedAt = time.Now()
+	key.Status = models.TenantAPIKeyStatusActive
+	if key.Kind == "" {
+		key.Kind = models.TenantAPIKeyKindAPI
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+
+	if err := h.store.CreateTenantAPIKey(key); err != nil {
+		return "", err
+	}
+	return apiKey, nil
+}
+
+// checkGrantable rejects keys granting permissions the caller does not have,
+// so a scoped key cannot mint a broader one. It writes a 403 response and
+// returns false if the key may not be issued.
+func (h *MasterHandler) checkGrantable(w http.ResponseWriter, r *http.Request, key *models.TenantAPIKey) bool {
+	if !h.rbacEnabled {
+		return true
+	}
+	for _, perm := range key.Permissions() {
+		if !rbac.HasPermission(r.Context(), perm) {
+			http.Error(w, fmt.Sprintf("Forbidden: cannot grant permission %s", perm), http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// lookupTenantAPIKey fetches the key named in the route, writing a 404
+// response if it does not exist or belongs to a different tenant
+func (h *MasterHandler) lookupTenantAPIKey(w http.ResponseWriter, r *http.Request, tenant *models.Tenant) (*models.TenantAPIKey, bool) {
+	key, err := h.store.GetTenantAPIKey(mux.Vars(r)["keyID"])
+	if err == store.ErrTenantAPIKeyNotFound || (err == nil && key.TenantID != tenant.ID) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error getting API key: %v", err)
+		http.Error(w, "Failed to get API key", http.StatusInternalServerError)
+		return nil, false
+	}
+	return key, true
+}
+
+// CreateTenantAPIKey issues a new API key for a tenant.
+// API keys are limited to their scopes and can never register workers;
+// worker keys can only register workers and run jobs.
+func (h *MasterHandler) CreateTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+	if !tenant.IsActive() {
+		http.Error(w, "Tenant is not active", http.StatusConflict)
+		return
+	}
+
+	var req models.TenantAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "A key name is required", http.StatusBadRequest)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = models.TenantAPIKeyKindAPI
+	}
+	if err := models.ValidateTenantAPIKeyScopes(req.Kind, req.Scopes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	key := &models.TenantAPIKey{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: requestActor(r),
+	}
+	if !h.checkGrantable(w, r, key) {
+		return
+	}
+
+	apiKey, err := h.issueTenantAPIKey(tenant, key)
+	if err != nil {
+		log.Printf("Error creating API key for tenant %s: %v", tenant.Name, err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, key.ID)
+	log.Printf("API key %s (%s, %s) created for tenant %s", key.Name, key.KeyPrefix, key.Kind, tenant.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issuedAPIKey{key, apiKey})
+}
+
+// ListTenantAPIKeys returns a tenant's API keys, newest first. Key hashes are never returned.
+func (h *MasterHandler) ListTenantAPIKeys(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.store.ListTenantAPIKeys(tenant.ID)
+	if err != nil {
+		log.Printf("Error listing API keys: %v", err)
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// RotateTenantAPIKey issues a replacement for a key with the same name, kind,
+// scopes and expiry. The old key is revoked, or kept working for the
+// requested grace period so clients can switch over.
+func (h *MasterHandler) RotateTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+	if !tenant.IsActive() {
+		http.Error(w, "Tenant is not active", http.StatusConflict)
+		return
+	}
+	old, ok := h.lookupTenantAPIKey(w, r, tenant)
+	if !ok {
+		return
+	}
+	if !old.IsUsable() {
+		http.Error(w, "Only active keys can be rotated", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		GracePeriod string `json:"grace_period,omitempty"` // e.g. "1h"; empty revokes the old key immediately
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var grace time.Duration
+	if req.GracePeriod != "" {
+		var err error
+		if grace, err = time.ParseDuration(req.GracePeriod); err != nil || grace < 0 {
+			http.Error(w, "Invalid grace_period: use a duration such as 30m or 24h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	key := &models.TenantAPIKey{
+		Name:      old.Name,
+		Kind:      old.Kind,
+		Scopes:    old.Scopes,
+		ExpiresAt: old.ExpiresAt,
+		CreatedBy: requestActor(r),
+	}
+	if !h.checkGrantable(w, r, key) {
+		return
+	}
+
+	apiKey, err := h.issueTenantAPIKey(tenant, key)
+	if err != nil {
+		log.Printf("Error rotating API key %s: %v", old.ID, err)
+		http.Error(w, "Failed to rotate API key", http.StatusInternalServerError)
+		return
+	}
+
+	if grace > 0 {
+		graceEnd := time.Now().Add(grace)
+		if old.ExpiresAt == nil || graceEnd.Before(*old.ExpiresAt) {
+			old.ExpiresAt = &graceEnd
+		}
+	} else {
+		old.Status = models.TenantAPIKeyStatusRevoked
+	}
+	if err := h.store.UpdateTenantAPIKey(old); err != nil {
+		log.Printf("Error retiring rotated API key %s: %v", old.ID, err)
+		http.Error(w, "New API key issued but failed to retire the old key", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("API key %s (%s) of tenant %s rotated to %s", old.Name, old.KeyPrefix, tenant.Name, key.KeyPrefix)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		issuedAPIKey
+		Replaced *models.TenantAPIKey `json:"replaced"`
+	}{issuedAPIKey{key, apiKey}, old})
+}
+
+// RevokeTenantAPIKey revokes a key. It stops working immediately.
+func (h *MasterHandler) RevokeTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+	key, ok := h.lookupTenantAPIKey(w, r, tenant)
+	if !ok {
+		return
+	}
+
+	key.Status = models.TenantAPIKeyStatusRevoked
+	if err := h.store.UpdateTenantAPIKey(key); err != nil {
+		log.Printf("Error revoking API key %s: %v", key.ID, err)
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("API key %s (%s) of tenant %s revoked", key.Name, key.KeyPrefix, tenant.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "revoked",
+		"key_id": key.ID,
+	})
+}
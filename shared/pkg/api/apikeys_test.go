@@ -0,0 +1,191 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// TestTenantAPIKeys verifies key issuance, scopes, worker keys, rotation and revocation
+func TestTenantAPIKeys(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	handler.EnableRBAC()
+
+	isAdmin := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin-key"
+	}
+	router := mux.NewRouter()
+	router.Use(tenancy.TenantMiddleware(tenancy.NewStoreResolver(testStore), isAdmin))
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	type issued struct {
+		ID     string `json:"id"`
+		APIKey string `json:"api_key"`
+		Kind   string `json:"kind"`
+	}
+	createKey := func(tenantID, token, body string) issued {
+		w := do("POST", "/tenants/"+tenantID+"/apikeys", token, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 creating API key, got %d: %s", w.Code, w.Body.String())
+		}
+		var key issued
+		json.Unmarshal(w.Body.Bytes(), &key)
+		if key.APIKey == "" {
+			t.Fatal("Expected API key in create response")
+		}
+		return key
+	}
+
+	w := do("POST", "/tenants", "admin-key", `{"name":"studio","plan":"pro"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating tenant, got %d: %s", w.Code, w.Body.String())
+	}
+	var tenant struct {
+		ID     string `json:"id"`
+		APIKey string `json:"api_key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &tenant)
+	nodeBody := `{"address":"http://worker-1:9000","type":"server","cpu_threads":4}`
+
+	t.Run("APIKeysCannotRegisterWorkers", func(t *testing.T) {
+		if w := do("POST", "/jobs", tenant.APIKey, `{"scenario":"1080p"}`); w.Code != http.StatusCreated {
+			t.Errorf("Expected status 201 creating job with the default key, got %d", w.Code)
+		}
+		if w := do("POST", "/nodes/register", tenant.APIKey, nodeBody); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 registering a node with an API key, got %d", w.Code)
+		}
+		if w := do("POST", "/tenants/"+tenant.ID+"/apikeys", "admin-key", `{"name":"ci","scopes":["node:register"]}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 granting node:register to an API key, got %d", w.Code)
+		}
+	})
+
+	t.Run("WorkerKeys", func(t *testing.T) {
+		// API keys cannot mint worker keys either
+		if w := do("POST", "/tenants/"+tenant.ID+"/apikeys", tenant.APIKey, `{"name":"workers","kind":"worker"}`); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 issuing a worker key with an API key, got %d", w.Code)
+		}
+		worker := createKey(tenant.ID, "admin-key", `{"name":"workers","kind":"worker"}`)
+		if worker.Kind != "worker" {
+			t.Errorf("Expected worker key, got %q", worker.Kind)
+		}
+		if w := do("POST", "/nodes/register", worker.APIKey, nodeBody); w.Code != http.StatusCreated {
+			t.Errorf("Expected status 201 registering a node with a worker key, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := do("POST", "/jobs", worker.APIKey, `{"scenario":"1080p"}`); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 submitting a job with a worker key, got %d", w.Code)
+		}
+	})
+
+	t.Run("ScopedKeys", func(t *testing.T) {
+		readOnly := createKey(tenant.ID, "admin-key", `{"name":"dashboards","scopes":["job:read"]}`)
+		if w := do("GET", "/jobs", readOnly.APIKey, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 listing jobs, got %d", w.Code)
+		}
+		if w := do("POST", "/jobs", readOnly.APIKey, `{"scenario":"1080p"}`); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 creating a job outside the key's scopes, got %d", w.Code)
+		}
+
+		// A key cannot issue keys broader than itself
+		keyAdmin := createKey(tenant.ID, "admin-key", `{"name":"key-admin","scopes":["apikey:create","job:read"]}`)
+		if w := do("POST", "/tenants/"+tenant.ID+"/apikeys", keyAdmin.APIKey, `{"name":"wide"}`); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 issuing a broader key, got %d", w.Code)
+		}
+		createKey(tenant.ID, keyAdmin.APIKey, `{"name":"narrow","scopes":["job:read"]}`)
+	})
+
+	t.Run("ListHidesHashes", func(t *testing.T) {
+		w := do("GET", "/tenants/"+tenant.ID+"/apikeys", tenant.APIKey, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 listing keys, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "key_hash") || strings.Contains(w.Body.String(), "$2a$") {
+			t.Errorf("Key listing must not include key hashes: %s", w.Body.String())
+		}
+		var list struct {
+			Keys []struct {
+				Name       string  `json:"name"`
+				LastUsedAt *string `json:"last_used_at"`
+			} `json:"keys"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		for _, key := range list.Keys {
+			if key.Name == "default" && key.LastUsedAt == nil {
+				t.Error("Expected last use of the default key to be recorded")
+			}
+		}
+	})
+
+	t.Run("RotateAndRevoke", func(t *testing.T) {
+		ci := createKey(tenant.ID, "admin-key", `{"name":"ci","scopes":["job:read","job:create"]}`)
+
+		w := do("POST", "/tenants/"+tenant.ID+"/apikeys/"+ci.ID+"/rotate", "admin-key", "")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 rotating key, got %d: %s", w.Code, w.Body.String())
+		}
+		var rotated issued
+		json.Unmarshal(w.Body.Bytes(), &rotated)
+		if w := do("GET", "/jobs", ci.APIKey, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for rotated key, got %d", w.Code)
+		}
+		if w := do("POST", "/jobs", rotated.APIKey, `{"scenario":"1080p"}`); w.Code != http.StatusCreated {
+			t.Errorf("Expected replacement key to keep its scopes, got %d", w.Code)
+		}
+
+		// A grace period keeps the old key working until it ends
+		w = do("POST", "/tenants/"+tenant.ID+"/apikeys/"+rotated.ID+"/rotate", "admin-key", `{"grace_period":"1h"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 rotating key with grace period, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := do("GET", "/jobs", rotated.APIKey, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected old key to work during the grace period, got %d", w.Code)
+		}
+
+		if w := do("DELETE", "/tenants/"+tenant.ID+"/apikeys/"+rotated.ID, "admin-key", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 revoking key, got %d", w.Code)
+		}
+		if w := do("GET", "/jobs", rotated.APIKey, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for revoked key, got %d", w.Code)
+		}
+	})
+
+	t.Run("OtherTenantsKeys", func(t *testing.T) {
+		w := do("POST", "/tenants", "admin-key", `{"name":"other","plan":"free"}`)
+		var other struct {
+			ID     string `json:"id"`
+			APIKey string `json:"api_key"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &other)
+
+		if w := do("GET", "/tenants/"+tenant.ID+"/apikeys", other.APIKey, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 listing another tenant's keys, got %d", w.Code)
+		}
+		var list struct {
+			Keys []struct {
+				ID string `json:"id"`
+			} `json:"keys"`
+		}
+		json.Unmarshal(do("GET", "/tenants/"+other.ID+"/apikeys", "admin-key", "").Body.Bytes(), &list)
+		if len(list.Keys) != 1 {
+			t.Fatalf("Expected 1 key for the other tenant, got %d", len(list.Keys))
+		}
+		if w := do("DELETE", "/tenants/"+tenant.ID+"/apikeys/"+list.Keys[0].ID, "admin-key", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 revoking a key through the wrong tenant, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// RequestIDHeader carries the ID correlating a request with its audit event
+const RequestIDHeader = "X-Request-ID"
+
+// Default and maximum number of events returned by GET /audit
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// unauditedRoutes are mutating routes left out of the audit log. Heartbeats
+// only refresh node liveness and would drown out every other event.
+var unauditedRoutes = map[string]bool{
+	"node.heartbeat": true,
+}
+
+// auditContextKey is the context key of the audit event being recorded for a request
+type auditContextKey struct{}
+
+// auditRecorder captures the status code of an audited response
+type auditRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *auditRecorder) WriteHeader(code int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *auditRecorder) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection
+func (rw *auditRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// AuditMiddleware records every mutating request in the audit log. The action
+// is the route name; routes without one are recorded as "METHOD /path/template".
+// Register it before the authentication middlewares so that requests they
+// reject are recorded as denied.
+func (h *MasterHandler) AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isMutatingMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		action := ""
+		if route := mux.CurrentRoute(r); route != nil {
+			action = route.GetName()
+			if action == "" {
+				template, _ := route.GetPathTemplate()
+				action = r.Method + " " + template
+			}
+		}
+		if unauditedRoutes[action] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		// Named actions are "<target type>.<verb>"
+		targetType := ""
+		if !strings.Contains(action, " ") {
+			targetType = strings.SplitN(action, ".", 2)[0]
+		}
+
+		event := &models.AuditEvent{
+			ID:           uuid.New().String(),
+			Action:       action,
+			TargetType:   targetType,
+			TargetID:     auditRouteTarget(r),
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			RequestID:    requestID,
+			SourceIP:     remoteIP(r),
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		}
+		event.ActorType, event.ActorID = requestAuditActor(r)
+
+		rec := &auditRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), auditContextKey{}, event)))
+
+		if rec.statusCode == 0 {
+			rec.statusCode = http.StatusOK
+		}
+		event.Timestamp = time.Now()
+		event.StatusCode = rec.statusCode
+		event.Outcome = models.AuditOutcome(rec.statusCode)
+		if err := h.store.CreateAuditEvent(event); err != nil {
+			log.Printf("Error recording audit event %s (%s %s by %s %s): %v",
+				requestID, event.Action, event.TargetID, event.ActorType, event.ActorID, err)
+		}
+	})
+}
+
+// auditIdentity completes the request's audit event with the tenant and actor
+// established by the authentication middlewares
+func (h *MasterHandler) auditIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if event := auditEvent(r); event != nil {
+			event.TenantID = requestTenantID(r)
+			event.ActorType, event.ActorID = requestAuditActor(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isMutatingMethod reports whether requests with method may change state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// auditRouteTarget returns the ID of the object named in the route, if any
+func auditRouteTarget(r *http.Request) string {
+	vars := mux.Vars(r)
+	for _, name := range []string{"keyID", "serial", "name", "id"} {
+		if id := vars[name]; id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// requestAuditActor identifies the credentials a request was made with
+func requestAuditActor(r *http.Request) (actorType, actorID string) {
+	if userID, err := tenancy.GetUserID(r.Context()); err == nil && userID != "" {
+		return models.AuditActorUser, userID
+	}
+	if keyID := tenancy.GetTenantKeyID(r.Context()); keyID != "" {
+		return models.AuditActorAPIKey, keyID
+	}
+	if tenancy.GetUserRole(r.Context()) == string(models.RoleAdmin) {
+		return models.AuditActorAdmin, ""
+	}
+	if cert := peerCertificate(r); cert != nil {
+		return models.AuditActorCertificate, cert.Subject.CommonName
+	}
+	return models.AuditActorAnonymous, ""
+}
+
+// remoteIP returns the address of the client connection without its port
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// auditEvent returns the audit event being recorded for a request, or nil
+func auditEvent(r *http.Request) *models.AuditEvent {
+	event, _ := r.Context().Value(auditContextKey{}).(*models.AuditEvent)
+	return event
+}
+
+// auditTarget records the object a request acted on, for requests that create
+// objects or name them in the body rather than the route
+func auditTarget(r *http.Request, targetID string) {
+	if event := auditEvent(r); event != nil {
+		event.TargetID = targetID
+	}
+}
+
+// auditActor records who made a request once a handler has authenticated
+// them more specifically than the request's credentials (e.g. a node token)
+func auditActor(r *http.Request, actorType, actorID string) {
+	if event := auditEvent(r); event != nil {
+		event.ActorType = actorType
+		event.ActorID = actorID
+	}
+}
+
+// auditFilterFromQuery parses the audit filters of a request.
+// Tenant-bound credentials only see their own tenant's events.
+func auditFilterFromQuery(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		TenantID:  requestTenantID(r),
+		ActorType: query.Get("actor_type"),
+		ActorID:   query.Get("actor"),
+		Action:    query.Get("action"),
+		TargetID:  query.Get("target"),
+		Outcome:   query.Get("outcome"),
+	}
+
+	for name, dest := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			// Durations select the most recent events, e.g. since=24h
+			d, durErr := time.ParseDuration(value)
+			if durErr != nil || d <= 0 {
+				return filter, fmt.Errorf("invalid %s: use an RFC 3339 timestamp or a duration such as 24h", name)
+			}
+			t = time.Now().Add(-d)
+		}
+		*dest = t
+	}
+	return filter, nil
+}
+
+// ListAuditEvents returns audit events, newest first. Supports filtering by
+// actor, actor_type, action, target, outcome, since and until, and limit
+// (default 100, maximum 1000).
+func (h *MasterHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit = defaultAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit: must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		if limit > maxAuditLimit {
+			limit = maxAuditLimit
+		}
+		filter.Limit = limit
+	}
+
+	events, err := h.store.ListAuditEvents(filter)
+	if err != nil {
+		log.Printf("Error listing audit events: %v", err)
+		http.Error(w, "Failed to list audit events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// ExportAuditEvents streams all audit events matching the filters of
+// ListAuditEvents as JSON Lines, oldest first
+func (h *MasterHandler) ExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.store.ListAuditEvents(filter)
+	if err != nil {
+		log.Printf("Error exporting audit events: %v", err)
+		http.Error(w, "Failed to export audit events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-`+time.Now().UTC().Format("20060102-150405")+`.jsonl"`)
+	encoder := json.NewEncoder(w)
+	for i := len(events) - 1; i >= 0; i-- {
+		if err := encoder.Encode(events[i]); err != nil {
+			log.Printf("Error writing audit export: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// TestAuditLog verifies that mutating requests are recorded with actor, target and outcome,
+// and that the log can be filtered and exported
+func TestAuditLog(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	handler.EnableRBAC()
+
+	isAdmin := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin-key"
+	}
+	router := mux.NewRouter()
+	router.Use(handler.AuditMiddleware)
+	router.Use(tenancy.TenantMiddleware(tenancy.NewStoreResolver(testStore), isAdmin))
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, token, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listEvents := func(query string) []models.AuditEvent {
+		w := do("GET", "/audit"+query, "admin-key", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 listing audit events, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Events []models.AuditEvent `json:"events"`
+			Count  int                 `json:"count"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Count != len(resp.Events) {
+			t.Errorf("Expected count %d to match events, got %d", len(resp.Events), resp.Count)
+		}
+		return resp.Events
+	}
+
+	w := do("POST", "/tenants", "admin-key", `{"name":"studio","plan":"pro"}`, api.RequestIDHeader, "req-tenant-1")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating tenant, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(api.RequestIDHeader); got != "req-tenant-1" {
+		t.Errorf("Expected request ID to be echoed, got %q", got)
+	}
+	var tenant struct {
+		ID     string `json:"id"`
+		APIKey string `json:"api_key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &tenant)
+
+	w = do("POST", "/jobs", tenant.APIKey, `{"scenario":"1080p"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating job, got %d: %s", w.Code, w.Body.String())
+	}
+	var job models.Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+
+	// Rejected by authorization before reaching the handler
+	if w := do("POST", "/jobs", "wrong-key", `{"scenario":"1080p"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for an invalid key, got %d", w.Code)
+	}
+	// Read-only requests are not audited
+	do("GET", "/jobs", "admin-key", "")
+
+	t.Run("RecordsMutatingRequests", func(t *testing.T) {
+		events := listEvents("")
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 audit events, got %d: %+v", len(events), events)
+		}
+
+		denied, created, tenantEvent := events[0], events[1], events[2]
+		if tenantEvent.Action != "tenant.create" || tenantEvent.TargetType != "tenant" || tenantEvent.TargetID != tenant.ID ||
+			tenantEvent.ActorType != models.AuditActorAdmin || tenantEvent.RequestID != "req-tenant-1" ||
+			tenantEvent.Outcome != models.AuditOutcomeSuccess || tenantEvent.StatusCode != http.StatusCreated {
+			t.Errorf("Unexpected tenant creation event: %+v", tenantEvent)
+		}
+		if created.Action != "job.create" || created.TargetID != job.ID || created.TenantID != tenant.ID ||
+			created.ActorType != models.AuditActorAPIKey || created.ActorID == "" || created.SourceIP == "" {
+			t.Errorf("Unexpected job creation event: %+v", created)
+		}
+		if denied.Action != "job.create" || denied.Outcome != models.AuditOutcomeDenied ||
+			denied.ActorType != models.AuditActorAnonymous || denied.RequestID == "" {
+			t.Errorf("Unexpected denied event: %+v", denied)
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		if events := listEvents("?outcome=denied"); len(events) != 1 {
+			t.Errorf("Expected 1 denied event, got %d", len(events))
+		}
+		if events := listEvents("?action=job.create&actor_type=apikey"); len(events) != 1 || events[0].TargetID != job.ID {
+			t.Errorf("Expected the job creation by API key, got %+v", events)
+		}
+		if events := listEvents("?target=" + tenant.ID); len(events) != 1 {
+			t.Errorf("Expected 1 event targeting the tenant, got %d", len(events))
+		}
+		if events := listEvents("?since=1h&limit=2"); len(events) != 2 {
+			t.Errorf("Expected limit to cap the events at 2, got %d", len(events))
+		}
+		if events := listEvents("?until=2000-01-01T00:00:00Z"); len(events) != 0 {
+			t.Errorf("Expected no events before 2000, got %d", len(events))
+		}
+		if w := do("GET", "/audit?since=yesterday", "admin-key", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid since, got %d", w.Code)
+		}
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		w := do("GET", "/audit", tenant.APIKey, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 listing audit events as tenant admin, got %d", w.Code)
+		}
+		var resp struct {
+			Events []models.AuditEvent `json:"events"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Events) != 1 || resp.Events[0].TenantID != tenant.ID {
+			t.Errorf("Expected only the tenant's own event, got %+v", resp.Events)
+		}
+	})
+
+	t.Run("ExportJSONL", func(t *testing.T) {
+		w := do("GET", "/audit/export", "admin-key", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 exporting audit events, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Expected JSON Lines content type, got %q", ct)
+		}
+
+		var actions []string
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var event models.AuditEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+			}
+			actions = append(actions, event.Action)
+		}
+		// Exports are oldest first
+		if len(actions) != 3 || actions[0] != "tenant.create" {
+			t.Errorf("Unexpected exported actions: %v", actions)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/backup"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// maxRestoreSize limits the size of an uploaded backup (10 GiB)
+const maxRestoreSize = 10 << 30
+
+// clearDeadlines lifts the server read and write timeouts for a request.
+// Backups and restores stream for as long as the database takes and would
+// otherwise be cut off by the API server's 30s timeouts.
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Failed to clear read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Failed to clear write deadline: %v", err)
+	}
+}
+
+// BackupDatabase streams a consistent snapshot of the database
+func (h *MasterHandler) BackupDatabase(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	clearDeadlines(w)
+
+	format := h.store.BackupFormat()
+	filename := backup.FileName(format, time.Now())
+
+	log.Printf("Starting database backup (format: %s)", format)
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Backup-Format", format)
+
+	// Headers are already sent once data is written, so failures can only be logged
+	if err := h.store.Backup(w); err != nil {
+		log.Printf("Database backup failed: %v", err)
+		return
+	}
+
+	log.Printf("Database backup completed (%s)", filename)
+}
+
+// RestoreDatabase validates an uploaded backup and restores it into the database.
+// With ?validate_only=true the backup is only checked and nothing is changed.
+func (h *MasterHandler) RestoreDatabase(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	clearDeadlines(w)
+
+	validateOnly := r.URL.Query().Get("validate_only") == "true"
+
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, maxRestoreSize))
+	header, _ := body.Peek(64)
+	format, err := store.DetectBackupFormat(header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if expected := h.store.BackupFormat(); format != expected {
+		http.Error(w, fmt.Sprintf("backup format %s does not match database format %s", format, expected), http.StatusBadRequest)
+		return
+	}
+
+	if validateOnly {
+		err = h.store.ValidateBackup(body)
+	} else {
+		log.Printf("Restoring database from %s backup", format)
+		err = h.store.Restore(body)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidBackup) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Database restore failed: %v", err)
+		http.Error(w, fmt.Sprintf("Restore failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	status := "restored"
+	if validateOnly {
+		status = "valid"
+	} else {
+		log.Printf("Database restored from %s backup", format)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": status,
+		"format": format,
+	})
+}
@@ -0,0 +1,145 @@
+package api_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// TestBackupRestoreEndpoints verifies a backup downloaded from one master restores into another
+func TestBackupRestoreEndpoints(t *testing.T) {
+	src := store.NewMemoryStore()
+	src.CreateJob(&models.Job{ID: "job-1", Scenario: "test", Status: models.JobStatusPending, CreatedAt: time.Now()})
+
+	srcRouter := mux.NewRouter()
+	api.NewMasterHandler(src).RegisterRoutes(srcRouter)
+
+	req := httptest.NewRequest("GET", "/admin/backup", nil)
+	w := httptest.NewRecorder()
+	srcRouter.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if format := w.Header().Get("X-Backup-Format"); format != store.BackupFormatJSON {
+		t.Errorf("Expected X-Backup-Format json, got %q", format)
+	}
+	backupData := w.Body.Bytes()
+
+	dst := store.NewMemoryStore()
+	dstRouter := mux.NewRouter()
+	api.NewMasterHandler(dst).RegisterRoutes(dstRouter)
+
+	// Validation does not modify the store
+	req = httptest.NewRequest("POST", "/admin/restore?validate_only=true", bytes.NewReader(backupData))
+	w = httptest.NewRecorder()
+	dstRouter.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for validation, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := dst.GetJob("job-1"); err == nil {
+		t.Error("Expected validate_only to leave the store unchanged")
+	}
+
+	req = httptest.NewRequest("POST", "/admin/restore", bytes.NewReader(backupData))
+	w = httptest.NewRecorder()
+	dstRouter.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for restore, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := dst.GetJob("job-1"); err != nil {
+		t.Errorf("Expected job-1 after restore: %v", err)
+	}
+
+	// A backup from a different backend is rejected
+	req = httptest.NewRequest("POST", "/admin/restore", strings.NewReader("SQLite format 3\x00"))
+	w = httptest.NewRecorder()
+	dstRouter.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for mismatched format, got %d", w.Code)
+	}
+}
+
+// slowBackupStore streams its backup in chunks spread over several server write timeouts
+type slowBackupStore struct {
+	*store.MemoryStore
+}
+
+func (s *slowBackupStore) Backup(w io.Writer) error {
+	var buf bytes.Buffer
+	if err := s.MemoryStore.Backup(&buf); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	for len(data) > 0 {
+		n := min(len(data), 128)
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+		time.Sleep(25 * time.Millisecond)
+	}
+	return nil
+}
+
+// TestBackupRestoreOutlastServerTimeouts verifies backup and restore streams are not cut off by the server timeouts
+func TestBackupRestoreOutlastServerTimeouts(t *testing.T) {
+	src := &slowBackupStore{store.NewMemoryStore()}
+	src.CreateJob(&models.Job{ID: "job-1", Scenario: "test", Status: models.JobStatusPending, CreatedAt: time.Now()})
+
+	handler := api.NewMasterHandler(src)
+	router := mux.NewRouter()
+	router.Use(handler.AuditMiddleware)
+	handler.RegisterRoutes(router)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/admin/backup")
+	if err != nil {
+		t.Fatalf("Backup request failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Backup stream was cut off: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*srv.Config.WriteTimeout {
+		t.Fatalf("Backup finished in %v, too fast to outlast the write timeout", elapsed)
+	}
+
+	// Upload the backup slower than the read timeout, through the audit middleware's
+	// wrapper; validation also proves the downloaded backup is complete
+	pr, pw := io.Pipe()
+	go func() {
+		data := body
+		for len(data) > 0 {
+			n := min(len(data), 64)
+			pw.Write(data[:n])
+			data = data[n:]
+			time.Sleep(25 * time.Millisecond)
+		}
+		pw.Close()
+	}()
+	resp, err = http.Post(srv.URL+"/admin/restore?validate_only=true", "application/octet-stream", pr)
+	if err != nil {
+		t.Fatalf("Restore request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		t.Fatalf("Expected status 200 for restore, got %d: %s", resp.StatusCode, msg)
+	}
+}
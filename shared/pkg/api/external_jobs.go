@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// RegisterExternalJob records a process a worker discovered as a running,
+// read-only external job. The job is assigned to the reporting node from the
+// start, so it is never scheduled. Registering the same process again returns
+// the existing job.
+func (h *MasterHandler) RegisterExternalJob(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	node, err := h.getNodeForRequest(r, nodeID)
+	if err != nil {
+		if err == store.ErrNodeNotFound {
+			http.Error(w, "Node not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to register external job", http.StatusInternalServerError)
+		return
+	}
+	if !h.verifyNodeToken(w, r, nodeID) {
+		return
+	}
+
+	var proc models.ExternalProcess
+	if err := json.NewDecoder(r.Body).Decode(&proc); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if proc.PID <= 0 || proc.Command == "" {
+		http.Error(w, "pid and command are required", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.findExternalJob(nodeID, &proc)
+	if err != nil {
+		log.Printf("Error looking up external jobs of node %s: %v", nodeID, err)
+		http.Error(w, "Failed to register external job", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		auditTarget(r, existing.ID)
+		h.populateJob(existing)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(existing)
+		return
+	}
+
+	now := time.Now()
+	startedAt := proc.StartTime
+	if startedAt.IsZero() {
+		startedAt = now
+	}
+	job := &models.Job{
+		ID:         uuid.New().String(),
+		TenantID:   node.TenantID,
+		Scenario:   "external-" + proc.Command,
+		Confidence: "auto",
+		Engine:     externalEngine(proc.Command),
+		Status:     models.JobStatusRunning,
+		Queue:      "default",
+		Priority:   "medium",
+		NodeID:     nodeID,
+		CreatedAt:  now,
+		StartedAt:  &startedAt,
+	}
+	if err := job.SetExternal(&proc); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.CreateJob(job); err != nil {
+		log.Printf("Error creating external job: %v", err)
+		http.Error(w, "Failed to register external job", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, job.ID)
+	log.Printf("External job registered: %s (PID %d, %s on node %s)", job.ID, proc.PID, proc.Command, nodeID)
+	h.notifyJobEvent(job.ID)
+	h.populateJob(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job)
+}
+
+// UpdateExternalJob records the resource usage of an external job and, once
+// the process exited, its exit code. A positive exit code fails the job; 0
+// completes it, as does a negative one, which means the process exited but
+// its exit code is unknown.
+func (h *MasterHandler) UpdateExternalJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nodeID := vars["id"]
+	jobID := vars["jobID"]
+	auditTarget(r, jobID)
+
+	if _, err := h.getNodeForRequest(r, nodeID); err != nil {
+		if err == store.ErrNodeNotFound {
+			http.Error(w, "Node not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update external job", http.StatusInternalServerError)
+		return
+	}
+	if !h.verifyNodeToken(w, r, nodeID) {
+		return
+	}
+
+	var update models.ExternalProcess
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.store.GetJob(jobID)
+	if err != nil {
+		if err == store.ErrJobNotFound {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting job: %v", err)
+		http.Error(w, "Failed to update external job", http.StatusInternalServerError)
+		return
+	}
+	if !job.IsExternal() || job.NodeID != nodeID {
+		http.Error(w, "Job is not an external job of this node", http.StatusConflict)
+		return
+	}
+	if job.Status != models.JobStatusRunning {
+		http.Error(w, fmt.Sprintf("External job already %s", job.Status), http.StatusConflict)
+		return
+	}
+
+	// Only usage and exit code change; the process identity is fixed at registration
+	proc := job.External()
+	if update.Usage != nil {
+		proc.Usage = update.Usage
+	}
+	if update.ExitCode != nil {
+		proc.ExitCode = update.ExitCode
+		now := time.Now()
+		job.CompletedAt = &now
+		job.Status = models.JobStatusCompleted
+		if *update.ExitCode > 0 {
+			job.Status = models.JobStatusFailed
+			job.Error = fmt.Sprintf("process exited with code %d", *update.ExitCode)
+		}
+	}
+	if err := job.SetExternal(proc); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.UpdateJob(job); err != nil {
+		log.Printf("Error updating external job: %v", err)
+		http.Error(w, "Failed to update external job", http.StatusInternalServerError)
+		return
+	}
+	if job.Status == models.JobStatusFailed {
+		// The process failed on its own; the platform only governed it
+		job.FailureReason = models.FailureReasonRuntimeError
+		if err := h.store.UpdateJobFailureReason(job.ID, job.FailureReason, job.Error); err != nil {
+			log.Printf("Warning: Failed to set failure reason of job %s: %v", job.ID, err)
+		}
+	}
+
+	if job.Status != models.JobStatusRunning {
+		log.Printf("External job %s finished (status: %s)", job.ID, job.Status)
+	}
+	h.notifyJobEvent(job.ID)
+	h.populateJob(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// findExternalJob returns the running external job of a process on a node,
+// or nil. A process is identified by its PID and start time.
+func (h *MasterHandler) findExternalJob(nodeID string, proc *models.ExternalProcess) (*models.Job, error) {
+	jobs, err := h.store.GetNodeJobsInState(nodeID, models.JobStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		existing := job.External()
+		if existing != nil && existing.PID == proc.PID && existing.StartTime.Equal(proc.StartTime) {
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// externalEngine returns the job engine of a discovered command
+func externalEngine(command string) string {
+	name := filepath.Base(command)
+	switch {
+	case strings.HasPrefix(name, "ffmpeg"):
+		return "ffmpeg"
+	case strings.HasPrefix(name, "gst-launch"):
+		return "gstreamer"
+	}
+	return "auto"
+}
@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+func TestExternalJobs(t *testing.T) {
+	st := store.NewMemoryStore()
+	node := &models.Node{ID: "node-1", Address: "worker-1", Status: "available", LastHeartbeat: time.Now(), RegisteredAt: time.Now()}
+	if err := st.RegisterNode(node); err != nil {
+		t.Fatalf("Failed to register node: %v", err)
+	}
+	handler := api.NewMasterHandler(st)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := client.New(server.URL)
+	ctx := context.Background()
+	proc := &models.ExternalProcess{
+		PID:         4242,
+		Command:     "ffmpeg",
+		CommandLine: []string{"ffmpeg", "-i", "input.mp4", "rtmp://live/stream"},
+		User:        "video",
+		StartTime:   time.Now().Add(-time.Minute).UTC(),
+	}
+
+	job, err := c.RegisterExternalJob(ctx, node.ID, proc)
+	if err != nil {
+		t.Fatalf("RegisterExternalJob failed: %v", err)
+	}
+	if job.Status != models.JobStatusRunning || job.NodeID != node.ID || job.Engine != "ffmpeg" {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+	if job.Classification != models.JobClassificationExternal {
+		t.Errorf("classification = %q, want external", job.Classification)
+	}
+
+	again, err := c.RegisterExternalJob(ctx, node.ID, proc)
+	if err != nil || again.ID != job.ID {
+		t.Errorf("Registering the process again = %v, %v; want job %s", again, err, job.ID)
+	}
+
+	t.Run("NotScheduled", func(t *testing.T) {
+		next, err := c.GetNextJob(ctx, node.ID)
+		if err != nil {
+			t.Fatalf("GetNextJob failed: %v", err)
+		}
+		if next.Job != nil {
+			t.Errorf("External job was scheduled: %+v", next.Job)
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		if _, err := c.CancelJob(ctx, job.ID); client.StatusCode(err) != http.StatusConflict {
+			t.Errorf("CancelJob error = %v, want 409", err)
+		}
+		_, err := c.CreateJob(ctx, &models.JobRequest{Scenario: "720p30-h264", Parameters: map[string]interface{}{"external": map[string]interface{}{}}})
+		if client.StatusCode(err) != http.StatusBadRequest {
+			t.Errorf("CreateJob with the external parameter = %v, want 400", err)
+		}
+	})
+
+	t.Run("Listed", func(t *testing.T) {
+		list, err := c.ListJobs(ctx)
+		if err != nil {
+			t.Fatalf("ListJobs failed: %v", err)
+		}
+		if list.Count != 1 || list.Jobs[0].Classification != models.JobClassificationExternal {
+			t.Errorf("Unexpected jobs: %+v", list.Jobs)
+		}
+		if got := list.Jobs[0].External(); got == nil || got.PID != proc.PID || got.User != "video" {
+			t.Errorf("External process = %+v", got)
+		}
+	})
+
+	t.Run("Exit", func(t *testing.T) {
+		exitCode := 1
+		update := *proc
+		update.ExitCode = &exitCode
+		update.Usage = &models.ExternalUsage{CPUSeconds: 12.5, MemoryPeak: 256 << 20}
+		finished, err := c.UpdateExternalJob(ctx, node.ID, job.ID, &update)
+		if err != nil {
+			t.Fatalf("UpdateExternalJob failed: %v", err)
+		}
+		if finished.Status != models.JobStatusFailed || finished.CompletedAt == nil {
+			t.Errorf("Unexpected job after exit: %+v", finished)
+		}
+		got := finished.External()
+		if got == nil || got.ExitCode == nil || *got.ExitCode != 1 || got.Usage == nil || got.Usage.CPUSeconds != 12.5 {
+			t.Errorf("External process after exit = %+v", got)
+		}
+
+		if _, err := c.UpdateExternalJob(ctx, node.ID, job.ID, &update); client.StatusCode(err) != http.StatusConflict {
+			t.Errorf("Updating a finished job = %v, want 409", err)
+		}
+		if _, err := c.UpdateExternalJob(ctx, "node-2", job.ID, &update); client.StatusCode(err) != http.StatusNotFound {
+			t.Errorf("Updating from an unknown node = %v, want 404", err)
+		}
+	})
+}
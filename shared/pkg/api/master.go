@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/openapi"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/scheduler"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/secrets"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/webhooks"
 )
 
 // MetricsRecorder is an interface for recording metrics
@@ -18,12 +25,26 @@ type MetricsRecorder interface {
 	RecordScheduleAttempt(result string)
 }
 
+// LeaderStatus reports whether this master is the elected leader
+type LeaderStatus interface {
+	IsLeader() bool
+}
+
 // MasterHandler handles master node API requests
 type MasterHandler struct {
-	store           store.Store
-	maxRetries      int
-	metricsRecorder MetricsRecorder
-	resultsWriter   *ResultsWriter
+	store             store.Store
+	maxRetries        int
+	metricsRecorder   MetricsRecorder
+	resultsWriter     *ResultsWriter
+	webhookDispatcher *webhooks.Dispatcher
+	leaderStatus      LeaderStatus
+	quotas            *tenancy.QuotaEnforcer
+	rbacEnabled       bool
+	sessionTTL        time.Duration
+	nodeTokens        *auth.TokenManager
+	nodeTokenTTL      time.Duration
+	pki               *pkiState
+	secrets           *secrets.Cipher
 }
 
 // NewMasterHandler creates a new master handler
@@ -32,6 +53,7 @@ func NewMasterHandler(s store.Store) *MasterHandler {
 		store:         s,
 		maxRetries:    0, // No retries by default
 		resultsWriter: NewResultsWriter("./test_results"),
+		sessionTTL:    defaultSessionTTL,
 	}
 }
 
@@ -41,6 +63,7 @@ func NewMasterHandlerWithRetry(s store.Store, maxRetries int) *MasterHandler {
 		store:         s,
 		maxRetries:    maxRetries,
 		resultsWriter: NewResultsWriter("./test_results"),
+		sessionTTL:    defaultSessionTTL,
 	}
 }
 
@@ -49,11 +72,16 @@ func (h *MasterHandler) SetMetricsRecorder(recorder MetricsRecorder) {
 	h.metricsRecorder = recorder
 }
 
+// SetLeaderStatus enables reporting of this master's leader election role in /health
+func (h *MasterHandler) SetLeaderStatus(status LeaderStatus) {
+	h.leaderStatus = status
+}
+
 // getJobByIDOrSequence retrieves a job by ID (UUID) or sequence number
 func (h *MasterHandler) getJobByIDOrSequence(idOrSeq string) (*models.Job, error) {
 	// Try to parse as sequence number first
-	var seqNum int
-	if _, parseErr := fmt.Sscanf(idOrSeq, "%d", &seqNum); parseErr == nil && seqNum > 0 {
+	// (the whole string must be numeric, since UUIDs may start with digits)
+	if seqNum, parseErr := strconv.Atoi(idOrSeq); parseErr == nil && seqNum > 0 {
 		// It's a number, try sequence number lookup
 		return h.store.GetJobBySequenceNumber(seqNum)
 	}
@@ -63,37 +91,95 @@ func (h *MasterHandler) getJobByIDOrSequence(idOrSeq string) (*models.Job, error
 
 // RegisterRoutes registers all API routes
 func (h *MasterHandler) RegisterRoutes(r *mux.Router) {
+	// Attribute audited requests (see AuditMiddleware); route names are the audited actions
+	r.Use(h.auditIdentity)
+	// Reject requests that do not match the OpenAPI document before they reach a handler
+	r.Use(openapi.NewValidator(openapi.Spec()).Middleware)
+
 	// Node routes
-	r.HandleFunc("/nodes/register", h.RegisterNode).Methods("POST")
-	r.HandleFunc("/nodes/{id}", h.GetNodeDetails).Methods("GET")
-	r.HandleFunc("/nodes/{id}", h.RemoveNode).Methods("DELETE")
-	r.HandleFunc("/nodes", h.ListNodes).Methods("GET")
-	r.HandleFunc("/nodes/{id}/heartbeat", h.NodeHeartbeat).Methods("POST")
+	r.Handle("/nodes/register", h.authorize(models.PermNodeRegister, h.RegisterNode)).Methods("POST").Name("node.register")
+	r.Handle("/nodes/{id}", h.authorize(models.PermNodeRead, h.GetNodeDetails)).Methods("GET")
+	r.Handle("/nodes/{id}", h.authorize(models.PermNodeDelete, h.RemoveNode)).Methods("DELETE").Name("node.delete")
+	r.Handle("/nodes", h.authorize(models.PermNodeRead, h.ListNodes)).Methods("GET")
+	r.Handle("/nodes/{id}/heartbeat", h.authorize(models.PermNodeUpdate, h.NodeHeartbeat)).Methods("POST").Name("node.heartbeat")
+	// Workers report processes they discovered as read-only external jobs
+	r.Handle("/nodes/{id}/external-jobs", h.authorize(models.PermJobUpdate, h.RegisterExternalJob)).Methods("POST").Name("job.external.register")
+	r.Handle("/nodes/{id}/external-jobs/{jobID}", h.authorize(models.PermJobUpdate, h.UpdateExternalJob)).Methods("PUT").Name("job.external.update")
 	
 	// Job routes (register specific routes before parameterized routes)
-	r.HandleFunc("/jobs/next", h.GetNextJob).Methods("GET")
-	r.HandleFunc("/jobs", h.CreateJob).Methods("POST")
-	r.HandleFunc("/jobs", h.ListJobs).Methods("GET")
-	r.HandleFunc("/jobs/{id}", h.GetJob).Methods("GET")
-	r.HandleFunc("/jobs/{id}/pause", h.PauseJob).Methods("POST")
-	r.HandleFunc("/jobs/{id}/resume", h.ResumeJob).Methods("POST")
-	r.HandleFunc("/jobs/{id}/cancel", h.CancelJob).Methods("POST")
-	r.HandleFunc("/jobs/{id}/retry", h.RetryJob).Methods("POST")
-	r.HandleFunc("/jobs/{id}/logs", h.GetJobLogs).Methods("GET")
+	// Workers fetch jobs and report results with job:update
+	r.Handle("/jobs/next", h.authorize(models.PermJobUpdate, h.GetNextJob)).Methods("GET")
+	r.Handle("/jobs", h.authorize(models.PermJobCreate, h.CreateJob)).Methods("POST").Name("job.create")
+	r.Handle("/jobs", h.authorize(models.PermJobRead, h.ListJobs)).Methods("GET")
+	r.Handle("/jobs/{id}", h.authorize(models.PermJobRead, h.GetJob)).Methods("GET")
+	r.Handle("/jobs/{id}/pause", h.authorize(models.PermJobUpdate, h.PauseJob)).Methods("POST").Name("job.pause")
+	r.Handle("/jobs/{id}/resume", h.authorize(models.PermJobUpdate, h.ResumeJob)).Methods("POST").Name("job.resume")
+	r.Handle("/jobs/{id}/cancel", h.authorize(models.PermJobCancel, h.CancelJob)).Methods("POST").Name("job.cancel")
+	r.Handle("/jobs/{id}/retry", h.authorize(models.PermJobCreate, h.RetryJob)).Methods("POST").Name("job.retry")
+	r.Handle("/jobs/{id}/logs", h.authorize(models.PermJobRead, h.GetJobLogs)).Methods("GET")
+	r.Handle("/jobs/{id}/result", h.authorize(models.PermJobRead, h.GetJobResult)).Methods("GET")
 	
 	// Tenant routes (multi-tenancy)
-	r.HandleFunc("/tenants", h.CreateTenant).Methods("POST")
-	r.HandleFunc("/tenants", h.ListTenants).Methods("GET")
-	r.HandleFunc("/tenants/{id}", h.GetTenant).Methods("GET")
-	r.HandleFunc("/tenants/{id}", h.UpdateTenant).Methods("PUT")
-	r.HandleFunc("/tenants/{id}", h.DeleteTenant).Methods("DELETE")
-	r.HandleFunc("/tenants/{id}/stats", h.GetTenantStats).Methods("GET")
-	r.HandleFunc("/tenants/{id}/jobs", h.GetTenantJobs).Methods("GET")
-	r.HandleFunc("/tenants/{id}/nodes", h.GetTenantNodes).Methods("GET")
+	r.Handle("/tenants", h.authorize(models.PermTenantUpdate, h.CreateTenant)).Methods("POST").Name("tenant.create")
+	r.Handle("/tenants", h.authorize(models.PermTenantRead, h.ListTenants)).Methods("GET")
+	r.Handle("/tenants/{id}", h.authorize(models.PermTenantRead, h.GetTenant)).Methods("GET")
+	r.Handle("/tenants/{id}", h.authorize(models.PermTenantUpdate, h.UpdateTenant)).Methods("PUT").Name("tenant.update")
+	r.Handle("/tenants/{id}", h.authorize(models.PermTenantDelete, h.DeleteTenant)).Methods("DELETE").Name("tenant.delete")
+	r.Handle("/tenants/{id}/stats", h.authorize(models.PermTenantRead, h.GetTenantStats)).Methods("GET")
+	r.Handle("/tenants/{id}/jobs", h.authorize(models.PermJobRead, h.GetTenantJobs)).Methods("GET")
+	r.Handle("/tenants/{id}/nodes", h.authorize(models.PermNodeRead, h.GetTenantNodes)).Methods("GET")
+	r.Handle("/tenants/{id}/apikeys", h.authorize(models.PermAPIKeyCreate, h.CreateTenantAPIKey)).Methods("POST").Name("apikey.create")
+	r.Handle("/tenants/{id}/apikeys", h.authorize(models.PermAPIKeyRead, h.ListTenantAPIKeys)).Methods("GET")
+	r.Handle("/tenants/{id}/apikeys/{keyID}/rotate", h.authorize(models.PermAPIKeyCreate, h.RotateTenantAPIKey)).Methods("POST").Name("apikey.rotate")
+	r.Handle("/tenants/{id}/apikeys/{keyID}", h.authorize(models.PermAPIKeyRevoke, h.RevokeTenantAPIKey)).Methods("DELETE").Name("apikey.revoke")
+
+	// Authentication and user routes
+	r.HandleFunc("/auth/login", h.Login).Methods("POST").Name("user.login")
+	r.HandleFunc("/auth/logout", h.Logout).Methods("POST").Name("user.logout")
+	r.Handle("/users", h.authorize(models.PermUserCreate, h.CreateUser)).Methods("POST").Name("user.create")
+	r.Handle("/users", h.authorize(models.PermUserRead, h.ListUsers)).Methods("GET")
+	r.Handle("/users/{id}", h.authorize(models.PermUserRead, h.GetUser)).Methods("GET")
+	r.Handle("/users/{id}", h.authorize(models.PermUserUpdate, h.UpdateUser)).Methods("PUT").Name("user.update")
+	r.Handle("/users/{id}", h.authorize(models.PermUserDelete, h.DeleteUser)).Methods("DELETE").Name("user.delete")
 	
 	// Other routes
-	r.HandleFunc("/results", h.ReceiveResults).Methods("POST")
+	// Webhook routes (tenant configuration)
+	r.Handle("/webhooks", h.authorize(models.PermTenantUpdate, h.CreateWebhook)).Methods("POST").Name("webhook.create")
+	r.Handle("/webhooks", h.authorize(models.PermTenantRead, h.ListWebhooks)).Methods("GET")
+	r.Handle("/webhooks/deliveries/{id}/redeliver", h.authorize(models.PermTenantUpdate, h.RedeliverWebhook)).Methods("POST").Name("webhook.redeliver")
+	r.Handle("/webhooks/{id}", h.authorize(models.PermTenantRead, h.GetWebhook)).Methods("GET")
+	r.Handle("/webhooks/{id}", h.authorize(models.PermTenantUpdate, h.UpdateWebhook)).Methods("PUT").Name("webhook.update")
+	r.Handle("/webhooks/{id}", h.authorize(models.PermTenantUpdate, h.DeleteWebhook)).Methods("DELETE").Name("webhook.delete")
+	r.Handle("/webhooks/{id}/deliveries", h.authorize(models.PermTenantRead, h.ListWebhookDeliveries)).Methods("GET")
+
+	// Secret routes; values are write-only
+	r.Handle("/secrets", h.authorize(models.PermSecretWrite, h.CreateSecret)).Methods("POST").Name("secret.create")
+	r.Handle("/secrets", h.authorize(models.PermSecretRead, h.ListSecrets)).Methods("GET")
+	r.Handle("/secrets/{name}", h.authorize(models.PermSecretWrite, h.UpdateSecret)).Methods("PUT").Name("secret.update")
+	r.Handle("/secrets/{name}", h.authorize(models.PermSecretWrite, h.DeleteSecret)).Methods("DELETE").Name("secret.delete")
+
+	// Admin routes
+	r.Handle("/admin/backup", h.authorize(models.PermSystemBackup, h.BackupDatabase)).Methods("GET")
+	r.Handle("/admin/restore", h.authorize(models.PermSystemBackup, h.RestoreDatabase)).Methods("POST").Name("system.restore")
+
+	// Worker certificate authority routes; enrollment authenticates with a bootstrap
+	// token and renewal with the worker's current certificate
+	r.Handle("/pki/bootstrap-tokens", h.authorize(models.PermSystemPKI, h.CreateBootstrapToken)).Methods("POST").Name("bootstrap_token.create")
+	r.Handle("/pki/bootstrap-tokens", h.authorize(models.PermSystemPKI, h.ListBootstrapTokens)).Methods("GET")
+	r.Handle("/pki/certificates", h.authorize(models.PermSystemPKI, h.ListCertificates)).Methods("GET")
+	r.Handle("/pki/certificates/{serial}", h.authorize(models.PermSystemPKI, h.RevokeCertificate)).Methods("DELETE").Name("certificate.revoke")
+	r.HandleFunc("/pki/enroll", h.EnrollWorker).Methods("POST").Name("certificate.enroll")
+	r.HandleFunc("/pki/renew", h.RenewCertificate).Methods("POST").Name("certificate.renew")
+	r.HandleFunc("/pki/ca.crt", h.GetCACertificate).Methods("GET")
+
+	r.Handle("/results", h.authorize(models.PermJobUpdate, h.ReceiveResults)).Methods("POST").Name("job.result")
+	r.Handle("/results/aggregates", h.authorize(models.PermMetricsRead, h.GetResultAggregates)).Methods("GET")
+
+	// Audit log routes
+	r.Handle("/audit", h.authorize(models.PermAuditRead, h.ListAuditEvents)).Methods("GET")
+	r.Handle("/audit/export", h.authorize(models.PermAuditRead, h.ExportAuditEvents)).Methods("GET")
 	r.HandleFunc("/health", h.Health).Methods("GET")
+	r.Handle("/openapi.json", openapi.Handler()).Methods("GET")
 }
 
 // RegisterNode handles node registration
@@ -107,6 +193,11 @@ func (h *MasterHandler) RegisterNode(w http.ResponseWriter, r *http.Request) {
 	// Check if a node with this address already exists
 	existingNode, err := h.store.GetNodeByAddress(reg.Address)
 	if err == nil && existingNode != nil {
+		if !canAccessTenant(r, existingNode.TenantID) {
+			http.Error(w, "A node with this address is registered to another tenant", http.StatusConflict)
+			return
+		}
+
 		// Node with this address already exists - handle re-registration
 		// This handles cases where:
 		// 1. Agent restarted and tries to re-register
@@ -138,12 +229,21 @@ func (h *MasterHandler) RegisterNode(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Warning: failed to update status during re-registration: %v", err)
 		}
 		
+		// The restarted agent gets a new identity token; the previous one stops working
+		token, err := h.issueNodeToken(existingNode)
+		if err != nil {
+			log.Printf("Error issuing token for node %s: %v", existingNode.ID, err)
+			http.Error(w, "Failed to register node", http.StatusInternalServerError)
+			return
+		}
+
+		auditTarget(r, existingNode.ID)
 		log.Printf("Node re-registered: %s [%s] (%s, %d threads, %s)", existingNode.Name, existingNode.ID, existingNode.Type, existingNode.CPUThreads, existingNode.CPUModel)
 		
 		// Return the existing node (with updated info)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK) // 200 OK for re-registration (not 201 Created)
-		json.NewEncoder(w).Encode(existingNode)
+		json.NewEncoder(w).Encode(registeredNode{existingNode, token})
 		return
 	}
 
@@ -166,6 +266,15 @@ func (h *MasterHandler) RegisterNode(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Nodes dedicated to a tenant count against its worker, CPU and GPU quotas
+	if tenantID := requestTenantID(r); tenantID != "" && h.quotas != nil {
+		candidate := &models.Node{CPUThreads: reg.CPUThreads, HasGPU: reg.HasGPU}
+		if err := h.quotas.CheckNode(tenantID, candidate); err != nil {
+			writeQuotaError(w, err, "Failed to register node")
+			return
+		}
+	}
+
 	// Create new node
 	node := &models.Node{
 		ID:              uuid.New().String(),
@@ -182,6 +291,7 @@ func (h *MasterHandler) RegisterNode(w http.ResponseWriter, r *http.Request) {
 		Status:          "available",
 		LastHeartbeat:   time.Now(),
 		RegisteredAt:    time.Now(),
+		TenantID:        requestTenantID(r), // Nodes registered with the master API key are shared
 	}
 
 	if err := h.store.RegisterNode(node); err != nil {
@@ -190,16 +300,35 @@ func (h *MasterHandler) RegisterNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	token, err := h.issueNodeToken(node)
+	if err != nil {
+		log.Printf("Error issuing token for node %s: %v", node.ID, err)
+		http.Error(w, "Failed to register node", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, node.ID)
 	log.Printf("Node registered: %s [%s] (%s, %d threads, %s)", node.Name, node.ID, node.Type, node.CPUThreads, node.CPUModel)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(node)
+	json.NewEncoder(w).Encode(registeredNode{node, token})
 }
 
-// ListNodes returns all registered nodes
+// ListNodes returns all registered nodes, or only a tenant's dedicated nodes for tenant requests
 func (h *MasterHandler) ListNodes(w http.ResponseWriter, r *http.Request) {
-	nodes := h.store.GetAllNodes()
+	var nodes []*models.Node
+	if tenantID := requestTenantID(r); tenantID != "" {
+		var err error
+		nodes, err = h.store.GetNodesByTenant(tenantID)
+		if err != nil {
+			log.Printf("Error listing nodes: %v", err)
+			http.Error(w, "Failed to list nodes", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		nodes = h.store.GetAllNodes()
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -213,6 +342,18 @@ func (h *MasterHandler) NodeHeartbeat(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nodeID := vars["id"]
 
+	if _, err := h.getNodeForRequest(r, nodeID); err != nil {
+		if err == store.ErrNodeNotFound {
+			http.Error(w, "Node not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update heartbeat", http.StatusInternalServerError)
+		return
+	}
+	if !h.verifyNodeToken(w, r, nodeID) {
+		return
+	}
+
 	if err := h.store.UpdateNodeHeartbeat(nodeID); err != nil {
 		if err == store.ErrNodeNotFound {
 			http.Error(w, "Node not found", http.StatusNotFound)
@@ -255,6 +396,7 @@ func (h *MasterHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 		Status:     models.JobStatusPending,
 		CreatedAt:  time.Now(),
 		RetryCount: 0,
+		TenantID:   requestTenantID(r),
 	}
 
 	// Set defaults for queue, priority, and engine
@@ -279,30 +421,63 @@ func (h *MasterHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// External jobs are only reported by workers
+	if _, ok := job.Parameters[models.ExternalParameter]; ok {
+		http.Error(w, fmt.Sprintf("Invalid parameters: %q is reserved for external jobs", models.ExternalParameter), http.StatusBadRequest)
+		return
+	}
+
+	// Secret references are resolved when the job is handed to its worker
+	if err := h.checkSecretRefs(job); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Enforce tenant quotas (jobs over the concurrent job quota stay queued)
+	if h.quotas != nil {
+		tenantID := job.TenantID
+		if tenantID == "" {
+			tenantID = models.DefaultTenantID
+		}
+		requiresGPU := scheduler.ExtractJobRequirements(job).RequiresGPU
+		if err := h.quotas.CheckJob(tenantID, requiresGPU); err != nil {
+			writeQuotaError(w, err, "Failed to create job")
+			return
+		}
+	}
+
 	if err := h.store.CreateJob(job); err != nil {
 		log.Printf("Error creating job: %v", err)
 		http.Error(w, "Failed to create job", http.StatusInternalServerError)
 		return
 	}
 
+	auditTarget(r, job.ID)
 	log.Printf("Job created: %s (%s)", job.ID, job.Scenario)
+	h.notifyJobEvent(job.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(job)
 }
 
-// ListJobs returns all jobs
+// ListJobs returns all jobs, or only the requesting tenant's jobs for tenant requests
 func (h *MasterHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
-	jobs := h.store.GetAllJobs()
+	var jobs []*models.Job
+	if tenantID := requestTenantID(r); tenantID != "" {
+		var err error
+		jobs, err = h.store.GetJobsByTenant(tenantID)
+		if err != nil {
+			log.Printf("Error listing jobs: %v", err)
+			http.Error(w, "Failed to list jobs", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		jobs = h.store.GetAllJobs()
+	}
 
-	// Populate NodeName for each job
 	for _, job := range jobs {
-		if job.NodeID != "" {
-			if node, err := h.store.GetNode(job.NodeID); err == nil {
-				job.NodeName = node.Name
-			}
-		}
+		h.populateJob(job)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -317,7 +492,7 @@ func (h *MasterHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID := vars["id"]
 
-	job, err := h.getJobByIDOrSequence(jobID)
+	job, err := h.getJobForRequest(r, jobID)
 
 	if err != nil {
 		if err == store.ErrJobNotFound {
@@ -329,15 +504,23 @@ func (h *MasterHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Populate NodeName if NodeID is set
+	h.populateJob(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// populateJob fills in the fields of a job that are not stored: the node name
+// and the classification of external jobs
+func (h *MasterHandler) populateJob(job *models.Job) {
 	if job.NodeID != "" {
 		if node, err := h.store.GetNode(job.NodeID); err == nil {
 			job.NodeName = node.Name
 		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(job)
+	if job.IsExternal() {
+		job.Classification = models.JobClassificationExternal
+	}
 }
 
 // GetNextJob retrieves the next pending job for a node
@@ -347,7 +530,20 @@ func (h *MasterHandler) GetNextJob(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "node_id parameter is required", http.StatusBadRequest)
 		return
 	}
+	if _, err := h.getNodeForRequest(r, nodeID); err != nil {
+		if err == store.ErrNodeNotFound {
+			http.Error(w, "Node not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting node: %v", err)
+		http.Error(w, "Failed to get next job", http.StatusInternalServerError)
+		return
+	}
+	if !h.verifyNodeToken(w, r, nodeID) {
+		return
+	}
 
+	// Every master assigns jobs; the store makes concurrent assignment safe
 	job, err := h.store.GetNextJob(nodeID)
 	if err != nil {
 		if err == store.ErrJobNotFound {
@@ -370,16 +566,32 @@ func (h *MasterHandler) GetNextJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only the assigned worker receives secret values
+	assigned, err := h.resolveJobSecrets(job)
+	if err != nil {
+		log.Printf("Job %s failed: cannot resolve secrets: %v", job.ID, err)
+		if err := h.store.UpdateJobStatus(job.ID, models.JobStatusFailed, fmt.Sprintf("Failed to resolve secrets: %v", err)); err != nil {
+			log.Printf("Error failing job %s: %v", job.ID, err)
+		}
+		h.notifyJobEvent(job.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"job": nil,
+		})
+		return
+	}
+
 	// Record successful scheduling attempt
 	if h.metricsRecorder != nil {
 		h.metricsRecorder.RecordScheduleAttempt("success")
 	}
 
 	log.Printf("Job %s assigned to node %s", job.ID, nodeID)
+	h.notifyJobEvent(job.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"job": job,
+		"job": assigned,
 	})
 }
 
@@ -390,9 +602,48 @@ func (h *MasterHandler) ReceiveResults(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	auditTarget(r, result.JobID)
+
+	// Tenant-scoped workers may only report results for their tenant's jobs
+	if requestTenantID(r) != "" {
+		if _, err := h.getJobForRequest(r, result.JobID); err != nil {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	// With node tokens, results are only accepted from the node the job is assigned to
+	if h.nodeTokens != nil {
+		if result.NodeID == "" {
+			http.Error(w, "node_id is required", http.StatusBadRequest)
+			return
+		}
+		if !h.verifyNodeToken(w, r, result.NodeID) {
+			return
+		}
+		job, err := h.store.GetJob(result.JobID)
+		if err != nil {
+			if err == store.ErrJobNotFound {
+				http.Error(w, "Job not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Error getting job: %v", err)
+			http.Error(w, "Failed to update job status", http.StatusInternalServerError)
+			return
+		}
+		if job.NodeID != result.NodeID {
+			log.Printf("Rejected results for job %s from node %s (assigned to %q)", result.JobID, result.NodeID, job.NodeID)
+			http.Error(w, "Job is not assigned to this node", http.StatusConflict)
+			return
+		}
+	}
+
+	// Keep secret values out of stored logs, errors and results files
+	h.redactJobResult(&result)
 
-	// Handle retry logic for failed jobs
-	if result.Status == models.JobStatusFailed && h.maxRetries > 0 {
+	// Handle retry logic for failed jobs; a workload whose exit status was
+	// lost may have completed, so it is left for inspection instead
+	if result.Status == models.JobStatusFailed && h.maxRetries > 0 && result.FailureReason != models.FailureReasonExitUnknown {
 		job, err := h.store.GetJob(result.JobID)
 		if err != nil {
 			log.Printf("Error getting job for retry check: %v", err)
@@ -414,6 +665,7 @@ func (h *MasterHandler) ReceiveResults(w http.ResponseWriter, r *http.Request) {
 				
 				log.Printf("Job %s failed on node %s (attempt %d/%d) - re-queued for retry",
 					result.JobID, result.NodeID, retryCount, h.maxRetries)
+				h.notifyJobEvent(result.JobID)
 				
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusOK)
@@ -436,6 +688,11 @@ func (h *MasterHandler) ReceiveResults(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update job status", http.StatusInternalServerError)
 		return
 	}
+	if result.FailureReason != "" {
+		if err := h.store.UpdateJobFailureReason(result.JobID, result.FailureReason, result.Error); err != nil {
+			log.Printf("Warning: Failed to update job failure reason: %v", err)
+		}
+	}
 	
 	// Update logs if provided
 	if result.Logs != "" {
@@ -448,6 +705,14 @@ func (h *MasterHandler) ReceiveResults(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Persist the result so it can be queried and aggregated
+	if result.CompletedAt.IsZero() {
+		result.CompletedAt = time.Now()
+	}
+	if err := h.store.SaveJobResult(&result); err != nil {
+		log.Printf("Warning: Failed to save job result: %v", err)
+	}
+
 	// Write results to JSON file for exporters
 	if result.Status == models.JobStatusCompleted {
 		job, err := h.store.GetJob(result.JobID)
@@ -459,6 +724,7 @@ func (h *MasterHandler) ReceiveResults(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Results received for job %s (status: %s)", result.JobID, result.Status)
+	h.notifyJobEvent(result.JobID)
 	if result.Error != "" {
 		log.Printf("  Error: %s", result.Error)
 	}
@@ -475,7 +741,7 @@ func (h *MasterHandler) GetNodeDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nodeID := vars["id"]
 
-	node, err := h.store.GetNode(nodeID)
+	node, err := h.getNodeForRequest(r, nodeID)
 	if err != nil {
 		if err == store.ErrNodeNotFound {
 			http.Error(w, "Node not found", http.StatusNotFound)
@@ -496,7 +762,7 @@ func (h *MasterHandler) PauseJob(w http.ResponseWriter, r *http.Request) {
 	jobIDOrSeq := vars["id"]
 
 	// Resolve to actual job ID
-	job, err := h.getJobByIDOrSequence(jobIDOrSeq)
+	job, err := h.getJobForRequest(r, jobIDOrSeq)
 	if err != nil {
 		if err == store.ErrJobNotFound {
 			http.Error(w, "Job not found", http.StatusNotFound)
@@ -507,6 +773,11 @@ func (h *MasterHandler) PauseJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	jobID := job.ID
+	auditTarget(r, jobID)
+	if job.IsExternal() {
+		http.Error(w, "External jobs are read-only", http.StatusConflict)
+		return
+	}
 
 	if err := h.store.PauseJob(jobID); err != nil {
 		if err == store.ErrJobNotFound {
@@ -519,6 +790,7 @@ func (h *MasterHandler) PauseJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Job %s paused", jobID)
+	h.notifyJobEvent(jobID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -534,7 +806,7 @@ func (h *MasterHandler) ResumeJob(w http.ResponseWriter, r *http.Request) {
 	jobIDOrSeq := vars["id"]
 
 	// Resolve to actual job ID
-	job, err := h.getJobByIDOrSequence(jobIDOrSeq)
+	job, err := h.getJobForRequest(r, jobIDOrSeq)
 	if err != nil {
 		if err == store.ErrJobNotFound {
 			http.Error(w, "Job not found", http.StatusNotFound)
@@ -545,6 +817,11 @@ func (h *MasterHandler) ResumeJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	jobID := job.ID
+	auditTarget(r, jobID)
+	if job.IsExternal() {
+		http.Error(w, "External jobs are read-only", http.StatusConflict)
+		return
+	}
 
 	if err := h.store.ResumeJob(jobID); err != nil {
 		if err == store.ErrJobNotFound {
@@ -557,6 +834,7 @@ func (h *MasterHandler) ResumeJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Job %s resumed", jobID)
+	h.notifyJobEvent(jobID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -572,7 +850,7 @@ func (h *MasterHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
 	jobIDOrSeq := vars["id"]
 
 	// Resolve to actual job ID
-	job, err := h.getJobByIDOrSequence(jobIDOrSeq)
+	job, err := h.getJobForRequest(r, jobIDOrSeq)
 	if err != nil {
 		if err == store.ErrJobNotFound {
 			http.Error(w, "Job not found", http.StatusNotFound)
@@ -583,6 +861,11 @@ func (h *MasterHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	jobID := job.ID
+	auditTarget(r, jobID)
+	if job.IsExternal() {
+		http.Error(w, "External jobs are read-only", http.StatusConflict)
+		return
+	}
 
 	if err := h.store.CancelJob(jobID); err != nil {
 		if err == store.ErrJobNotFound {
@@ -595,6 +878,7 @@ func (h *MasterHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Job %s canceled", jobID)
+	h.notifyJobEvent(jobID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -610,7 +894,7 @@ func (h *MasterHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
 	jobIDOrSeq := vars["id"]
 
 	// Get the job to verify it exists and can be retried
-	job, err := h.getJobByIDOrSequence(jobIDOrSeq)
+	job, err := h.getJobForRequest(r, jobIDOrSeq)
 	if err != nil {
 		if err == store.ErrJobNotFound {
 			http.Error(w, "Job not found", http.StatusNotFound)
@@ -620,6 +904,11 @@ func (h *MasterHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to retrieve job: %v", err), http.StatusInternalServerError)
 		return
 	}
+	auditTarget(r, job.ID)
+	if job.IsExternal() {
+		http.Error(w, "External jobs are read-only", http.StatusConflict)
+		return
+	}
 
 	// Only allow retry for failed or canceled jobs
 	if job.Status != "failed" && job.Status != "canceled" {
@@ -642,6 +931,7 @@ func (h *MasterHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Job %s queued for retry (attempt %d)", job.ID, job.RetryCount)
+	h.notifyJobEvent(job.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -658,7 +948,7 @@ func (h *MasterHandler) GetJobLogs(w http.ResponseWriter, r *http.Request) {
 	jobIDOrSeq := vars["id"]
 
 	// Get the job to verify it exists
-	job, err := h.getJobByIDOrSequence(jobIDOrSeq)
+	job, err := h.getJobForRequest(r, jobIDOrSeq)
 	if err != nil {
 		if err == store.ErrJobNotFound {
 			http.Error(w, "Job not found", http.StatusNotFound)
@@ -692,7 +982,7 @@ func (h *MasterHandler) RemoveNode(w http.ResponseWriter, r *http.Request) {
 	nodeID := vars["id"]
 
 	// Get the node to check if it exists
-	node, err := h.store.GetNode(nodeID)
+	node, err := h.getNodeForRequest(r, nodeID)
 	if err != nil {
 		if err == store.ErrNodeNotFound {
 			http.Error(w, "Node not found", http.StatusNotFound)
@@ -715,6 +1005,7 @@ func (h *MasterHandler) RemoveNode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to remove node: %v", err), http.StatusInternalServerError)
 		return
 	}
+	h.revokeNodeToken(nodeID)
 
 	log.Printf("Node %s (%s) removed from cluster", nodeID, node.Name)
 
@@ -728,41 +1019,16 @@ func (h *MasterHandler) RemoveNode(w http.ResponseWriter, r *http.Request) {
 
 // Health returns the health status of the master node
 func (h *MasterHandler) Health(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
+	response := map[string]string{
 		"status": "healthy",
-	})
-}
-
-// Tenant API stubs
-func (h *MasterHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
-
-func (h *MasterHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
-
-func (h *MasterHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
-
-func (h *MasterHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
-
-func (h *MasterHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
-
-func (h *MasterHandler) GetTenantStats(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
-
-func (h *MasterHandler) GetTenantJobs(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
-}
+	}
+	if h.leaderStatus != nil {
+		response["role"] = "follower"
+		if h.leaderStatus.IsLeader() {
+			response["role"] = "leader"
+		}
+	}
 
-func (h *MasterHandler) GetTenantNodes(w http.ResponseWriter, r *http.Request) {
-http.Error(w, "Tenant management not yet implemented", http.StatusNotImplemented)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
@@ -179,3 +179,53 @@ func TestJobLifecycle(t *testing.T) {
 		t.Errorf("Expected job ID %s, got %s", createdJob.ID, retrievedJob.ID)
 	}
 }
+
+// TestGetJobByIDOrSequence verifies that job IDs starting with digits are not
+// mistaken for sequence numbers
+func TestGetJobByIDOrSequence(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	job := &models.Job{
+		ID:        "123e4567-e89b-12d3-a456-426614174000",
+		Scenario:  "test-scenario",
+		Status:    models.JobStatusPending,
+		CreatedAt: time.Now(),
+	}
+	if err := testStore.CreateJob(job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	handler := api.NewMasterHandler(testStore)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	tests := []struct {
+		name       string
+		idOrSeq    string
+		wantStatus int
+	}{
+		{"UUIDStartingWithDigits", job.ID, http.StatusOK},
+		{"SequenceNumber", "1", http.StatusOK},
+		{"UnknownSequenceNumber", "123", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/jobs/"+tt.idOrSeq, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.Job
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if got.ID != job.ID {
+				t.Errorf("Expected job %s, got %s", job.ID, got.ID)
+			}
+		})
+	}
+}
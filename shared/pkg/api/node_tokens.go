@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// registeredNode is the registration response; the node token is only returned once
+type registeredNode struct {
+	*models.Node
+	NodeToken string `json:"node_token,omitempty"`
+}
+
+// EnableNodeTokens issues an identity token to every node that registers and
+// requires it on heartbeats, job polls and results. Tokens expire after ttl,
+// or never if ttl is zero.
+func (h *MasterHandler) EnableNodeTokens(tokens *auth.TokenManager, ttl time.Duration) {
+	h.nodeTokens = tokens
+	h.nodeTokenTTL = ttl
+}
+
+// issueNodeToken issues a new token for node, replacing its previous one.
+// It returns an empty token if node tokens are disabled.
+func (h *MasterHandler) issueNodeToken(node *models.Node) (string, error) {
+	if h.nodeTokens == nil {
+		return "", nil
+	}
+	return h.nodeTokens.GenerateToken(node.ID, h.nodeTokenTTL)
+}
+
+// revokeNodeToken revokes the token of a removed node
+func (h *MasterHandler) revokeNodeToken(nodeID string) {
+	if h.nodeTokens == nil {
+		return
+	}
+	if err := h.nodeTokens.RevokeToken(nodeID); err != nil && err != store.ErrNodeTokenNotFound {
+		log.Printf("Warning: failed to revoke token of node %s: %v", nodeID, err)
+	}
+}
+
+// verifyNodeToken checks that the request carries the identity token of nodeID.
+// It writes a 401 response and returns false if it does not.
+func (h *MasterHandler) verifyNodeToken(w http.ResponseWriter, r *http.Request, nodeID string) bool {
+	if h.nodeTokens == nil {
+		return true
+	}
+
+	token := r.Header.Get(auth.NodeTokenHeader)
+	if token == "" {
+		http.Error(w, "Missing node token: register the node to obtain one", http.StatusUnauthorized)
+		return false
+	}
+	if err := h.nodeTokens.ValidateToken(nodeID, token); err != nil {
+		if errors.Is(err, auth.ErrTokenExpired) {
+			http.Error(w, "Node token expired: register the node again", http.StatusUnauthorized)
+			return false
+		}
+		log.Printf("Rejected request with invalid token for node %s: %v", nodeID, err)
+		http.Error(w, "Invalid node token", http.StatusUnauthorized)
+		return false
+	}
+	auditActor(r, models.AuditActorNode, nodeID)
+	return true
+}
@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// TestNodeTokens verifies that worker requests are bound to the node that registered
+func TestNodeTokens(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	newRouter := func() *mux.Router {
+		handler := api.NewMasterHandler(testStore)
+		handler.EnableNodeTokens(auth.NewPersistentTokenManager(testStore), 0)
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+		return router
+	}
+	router := newRouter()
+
+	do := func(method, path, nodeToken, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if nodeToken != "" {
+			req.Header.Set(auth.NodeTokenHeader, nodeToken)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	type registered struct {
+		ID        string `json:"id"`
+		NodeToken string `json:"node_token"`
+	}
+	register := func(address string) registered {
+		w := do("POST", "/nodes/register", "", `{"address":"`+address+`","type":"server","cpu_threads":4}`)
+		if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+			t.Fatalf("Expected node registration to succeed, got %d: %s", w.Code, w.Body.String())
+		}
+		var node registered
+		json.Unmarshal(w.Body.Bytes(), &node)
+		if !strings.HasPrefix(node.NodeToken, auth.NodeTokenPrefix) {
+			t.Fatalf("Expected node token in registration response, got %q", node.NodeToken)
+		}
+		return node
+	}
+
+	node1 := register("http://worker-1:9000")
+	node2 := register("http://worker-2:9000")
+
+	t.Run("Heartbeat", func(t *testing.T) {
+		if w := do("POST", "/nodes/"+node1.ID+"/heartbeat", "", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 without a node token, got %d", w.Code)
+		}
+		if w := do("POST", "/nodes/"+node1.ID+"/heartbeat", node2.NodeToken, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 with another node's token, got %d", w.Code)
+		}
+		if w := do("POST", "/nodes/"+node1.ID+"/heartbeat", node1.NodeToken, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 with the node's token, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("ResultsFromAssignedNodeOnly", func(t *testing.T) {
+		if w := do("POST", "/jobs", "", `{"scenario":"1080p"}`); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 creating job, got %d", w.Code)
+		}
+		if w := do("GET", "/jobs/next?node_id="+node1.ID, node2.NodeToken, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 polling as another node, got %d", w.Code)
+		}
+		w := do("GET", "/jobs/next?node_id="+node1.ID, node1.NodeToken, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 polling for jobs, got %d", w.Code)
+		}
+		var next struct {
+			Job struct {
+				ID string `json:"id"`
+			} `json:"job"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &next)
+		if next.Job.ID == "" {
+			t.Fatal("Expected a job to be assigned")
+		}
+
+		result := func(nodeID string) string {
+			return `{"job_id":"` + next.Job.ID + `","node_id":"` + nodeID + `","status":"completed"}`
+		}
+		if w := do("POST", "/results", node2.NodeToken, result(node2.ID)); w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 for results from a node the job is not assigned to, got %d", w.Code)
+		}
+		if w := do("POST", "/results", node2.NodeToken, result(node1.ID)); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for results claiming another node, got %d", w.Code)
+		}
+		if w := do("POST", "/results", node1.NodeToken, result(node1.ID)); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for results from the assigned node, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("TokensSurviveRestart", func(t *testing.T) {
+		router = newRouter()
+		if w := do("POST", "/nodes/"+node1.ID+"/heartbeat", node1.NodeToken, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected token to remain valid after a master restart, got %d", w.Code)
+		}
+	})
+
+	t.Run("ReRegistrationReplacesToken", func(t *testing.T) {
+		again := register("http://worker-1:9000")
+		if again.ID != node1.ID || again.NodeToken == node1.NodeToken {
+			t.Fatalf("Expected a new token for the same node, got %+v", again)
+		}
+		if w := do("POST", "/nodes/"+node1.ID+"/heartbeat", node1.NodeToken, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for the replaced token, got %d", w.Code)
+		}
+		if w := do("POST", "/nodes/"+node1.ID+"/heartbeat", again.NodeToken, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for the new token, got %d", w.Code)
+		}
+	})
+
+	t.Run("RemovedNodeTokenRevoked", func(t *testing.T) {
+		if w := do("DELETE", "/nodes/"+node2.ID, "", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 removing node, got %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := testStore.GetNodeToken(node2.ID); err != store.ErrNodeTokenNotFound {
+			t.Errorf("Expected token of removed node to be deleted, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/client"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/openapi"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// TestOpenAPIDocument verifies that the document describes every route and
+// that the master validates requests against it
+func TestOpenAPIDocument(t *testing.T) {
+	handler := api.NewMasterHandler(store.NewMemoryStore())
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	t.Run("EveryRouteDocumented", func(t *testing.T) {
+		spec := openapi.Spec()
+		registered := make(map[string]bool)
+		err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return nil
+			}
+			methods, _ := route.GetMethods()
+			for _, method := range methods {
+				registered[method+" "+path] = true
+				if spec.Operation(method, path) == nil {
+					t.Errorf("%s %s is not in openapi.yaml", method, path)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk routes: %v", err)
+		}
+		for _, route := range spec.Routes() {
+			if !registered[route.Method+" "+route.Path] {
+				t.Errorf("openapi.yaml documents %s %s, which is not registered", route.Method, route.Path)
+			}
+		}
+	})
+
+	t.Run("ServesDocument", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/openapi.json")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode)
+		}
+		var doc struct {
+			OpenAPI string                 `json:"openapi"`
+			Paths   map[string]interface{} `json:"paths"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+			t.Fatalf("Failed to decode document: %v", err)
+		}
+		if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Paths["/jobs"] == nil {
+			t.Errorf("Unexpected document: %+v", doc)
+		}
+	})
+
+	t.Run("RejectsInvalidRequests", func(t *testing.T) {
+		cases := []struct {
+			name, method, path, body string
+			want                     string
+		}{
+			{"MissingRequiredField", "POST", "/jobs", `{"confidence":"auto"}`, "scenario: is required"},
+			{"WrongType", "POST", "/jobs", `{"scenario":"720p30-h264","parameters":[]}`, "parameters: must be an object"},
+			{"UnknownEnumValue", "POST", "/jobs", `{"scenario":"720p30-h264","queue":"urgent"}`, "queue: must be one of"},
+			{"MalformedJSON", "POST", "/nodes/register", `{"address":`, "invalid JSON"},
+			{"MissingQueryParameter", "GET", "/jobs/next", "", "node_id: query parameter is required"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusBadRequest {
+					t.Fatalf("Expected 400, got %d: %s", w.Code, w.Body.String())
+				}
+				if !strings.Contains(w.Body.String(), tc.want) {
+					t.Errorf("Expected error containing %q, got %q", tc.want, w.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("GeneratedClient", func(t *testing.T) {
+		c := client.New(server.URL)
+		ctx := context.Background()
+
+		job, err := c.CreateJob(ctx, &models.JobRequest{Scenario: "720p30-h264", Queue: "batch"})
+		if err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+		got, err := c.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetJob failed: %v", err)
+		}
+		if got.ID != job.ID || got.Queue != "batch" {
+			t.Errorf("Unexpected job: %+v", got)
+		}
+
+		list, err := c.ListJobs(ctx)
+		if err != nil {
+			t.Fatalf("ListJobs failed: %v", err)
+		}
+		if list.Count != 1 || len(list.Jobs) != 1 {
+			t.Errorf("Expected 1 job, got %d", list.Count)
+		}
+
+		if _, err := c.GetJob(ctx, "missing"); client.StatusCode(err) != http.StatusNotFound {
+			t.Errorf("Expected 404 for a missing job, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,405 @@
+package api
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	tlsutil "github.com/psantana5/ffmpeg-rtmp/pkg/tls"
+)
+
+// defaultBootstrapTokenTTL is how long a bootstrap token can be used when no expiry is requested
+const defaultBootstrapTokenTTL = 24 * time.Hour
+
+// revocationRefreshInterval is how often the revoked serials are reloaded from the store,
+// so revocations made through another master take effect
+const revocationRefreshInterval = 30 * time.Second
+
+// workerCommonName restricts the names workers can enroll with
+var workerCommonName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// pkiPublicPaths can be used without a client certificate, so workers can enroll
+var pkiPublicPaths = map[string]bool{
+	"/health":       true,
+	"/openapi.json": true,
+	"/pki/enroll":   true,
+	"/pki/ca.crt":   true,
+}
+
+// issuedBootstrapToken is the response for a new bootstrap token; the token itself is only returned once
+type issuedBootstrapToken struct {
+	*models.BootstrapToken
+	Token string `json:"token"`
+}
+
+// pkiState is the worker certificate authority and a cache of revoked serials
+type pkiState struct {
+	ca      *tlsutil.CA
+	certTTL time.Duration
+
+	mu          sync.RWMutex
+	revoked     map[string]bool
+	refreshedAt time.Time
+}
+
+// EnablePKI makes the master a certificate authority for workers. Workers
+// enroll with a one-time bootstrap token and receive client certificates
+// valid for certTTL, which they renew over mTLS before they expire.
+func (h *MasterHandler) EnablePKI(ca *tlsutil.CA, certTTL time.Duration) {
+	h.pki = &pkiState{ca: ca, certTTL: certTTL}
+	h.pki.refresh(h.store)
+}
+
+// refresh reloads the revoked serials from the store
+func (p *pkiState) refresh(s store.Store) {
+	certs, err := s.ListCertificates(true)
+	if err != nil {
+		log.Printf("Warning: failed to load revoked certificates: %v", err)
+		return
+	}
+	revoked := make(map[string]bool, len(certs))
+	for _, cert := range certs {
+		revoked[cert.Serial] = true
+	}
+
+	p.mu.Lock()
+	p.revoked = revoked
+	p.refreshedAt = time.Now()
+	p.mu.Unlock()
+}
+
+// isRevoked reports whether the certificate with serial has been revoked
+func (p *pkiState) isRevoked(s store.Store, serial string) bool {
+	p.mu.RLock()
+	stale := time.Since(p.refreshedAt) > revocationRefreshInterval
+	p.mu.RUnlock()
+	if stale {
+		p.refresh(s)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.revoked[serial]
+}
+
+// markRevoked records a revocation made through this master
+func (p *pkiState) markRevoked(serial string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.revoked == nil {
+		p.revoked = make(map[string]bool)
+	}
+	p.revoked[serial] = true
+}
+
+// requirePKI writes a 404 response and returns false if the CA is disabled
+func (h *MasterHandler) requirePKI(w http.ResponseWriter) bool {
+	if h.pki == nil {
+		http.Error(w, "Certificate authority is not enabled on this master", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// peerCertificate returns the client certificate of a request, or nil
+func peerCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
+
+// CertificateMiddleware rejects requests presenting a revoked worker
+// certificate. If requireCert is set, requests without a client certificate
+// are rejected too, except for health checks, enrollment and the CA certificate.
+func (h *MasterHandler) CertificateMiddleware(requireCert bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h.pki == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cert := peerCertificate(r)
+			if cert == nil {
+				if requireCert && !pkiPublicPaths[r.URL.Path] {
+					http.Error(w, "Client certificate required: enroll the worker to obtain one", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if h.pki.ca.Issued(cert) && h.pki.isRevoked(h.store, tlsutil.SerialString(cert.SerialNumber)) {
+				log.Printf("Rejected request with revoked certificate %s (%s)", tlsutil.SerialString(cert.SerialNumber), cert.Subject.CommonName)
+				http.Error(w, "Client certificate has been revoked", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// issueCertificate signs csrPEM for commonName and records the certificate
+func (h *MasterHandler) issueCertificate(csrPEM []byte, commonName string) (*models.CertificateResponse, error) {
+	cert, certPEM, err := h.pki.ca.SignClientCSR(csrPEM, commonName, h.pki.certTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	serial := tlsutil.SerialString(cert.SerialNumber)
+	if err := h.store.SaveCertificate(&models.IssuedCertificate{
+		Serial:     serial,
+		CommonName: commonName,
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.CertificateResponse{
+		Certificate:   string(certPEM),
+		CACertificate: string(h.pki.ca.CertPEM()),
+		Serial:        serial,
+		ExpiresAt:     cert.NotAfter,
+	}, nil
+}
+
+// CreateBootstrapToken creates a one-time token a worker can enroll with
+func (h *MasterHandler) CreateBootstrapToken(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) || !requireAdmin(w, r) {
+		return
+	}
+
+	var req models.BootstrapTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ttl := defaultBootstrapTokenTTL
+	if req.ExpiresIn != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil || ttl <= 0 {
+			http.Error(w, "Invalid expires_in: use a duration such as 1h or 24h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	token, hash, err := auth.GenerateBootstrapToken()
+	if err != nil {
+		log.Printf("Error generating bootstrap token: %v", err)
+		http.Error(w, "Failed to create bootstrap token", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	record := &models.BootstrapToken{
+		ID:          uuid.New().String(),
+		TokenHash:   hash,
+		Description: req.Description,
+		CreatedBy:   requestActor(r),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	if err := h.store.CreateBootstrapToken(record); err != nil {
+		log.Printf("Error storing bootstrap token: %v", err)
+		http.Error(w, "Failed to create bootstrap token", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, record.ID)
+	log.Printf("Bootstrap token %s created (expires %s)", record.ID, record.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issuedBootstrapToken{record, token})
+}
+
+// ListBootstrapTokens returns all bootstrap tokens, newest first. Token hashes are never returned.
+func (h *MasterHandler) ListBootstrapTokens(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) || !requireAdmin(w, r) {
+		return
+	}
+
+	tokens, err := h.store.ListBootstrapTokens()
+	if err != nil {
+		log.Printf("Error listing bootstrap tokens: %v", err)
+		http.Error(w, "Failed to list bootstrap tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+// ListCertificates returns the issued worker certificates, newest first.
+// With ?revoked=true only revoked certificates are returned.
+func (h *MasterHandler) ListCertificates(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) || !requireAdmin(w, r) {
+		return
+	}
+
+	certs, err := h.store.ListCertificates(r.URL.Query().Get("revoked") == "true")
+	if err != nil {
+		log.Printf("Error listing certificates: %v", err)
+		http.Error(w, "Failed to list certificates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"certificates": certs,
+		"count":        len(certs),
+	})
+}
+
+// RevokeCertificate revokes a worker certificate. Requests presenting it are
+// rejected immediately on this master and within revocationRefreshInterval on others.
+func (h *MasterHandler) RevokeCertificate(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) || !requireAdmin(w, r) {
+		return
+	}
+
+	serial := mux.Vars(r)["serial"]
+	if err := h.store.RevokeCertificate(serial, time.Now()); err != nil {
+		if err == store.ErrCertificateNotFound {
+			http.Error(w, "Certificate not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error revoking certificate %s: %v", serial, err)
+		http.Error(w, "Failed to revoke certificate", http.StatusInternalServerError)
+		return
+	}
+	h.pki.markRevoked(serial)
+
+	log.Printf("Certificate %s revoked", serial)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "revoked",
+		"serial": serial,
+	})
+}
+
+// EnrollWorker exchanges a bootstrap token and CSR for a client certificate.
+// The certificate is issued to the common name in the CSR.
+func (h *MasterHandler) EnrollWorker(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) {
+		return
+	}
+
+	var req models.EnrollmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !auth.IsBootstrapToken(req.Token) {
+		http.Error(w, "A bootstrap token is required", http.StatusUnauthorized)
+		return
+	}
+
+	// Check the CSR before using the token, so a malformed request does not burn it
+	csr, err := tlsutil.ParseCSR([]byte(req.CSR))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	commonName := csr.Subject.CommonName
+	if !workerCommonName.MatchString(commonName) {
+		http.Error(w, "CSR common name must be 1-64 letters, digits, dots, dashes or underscores", http.StatusBadRequest)
+		return
+	}
+	auditTarget(r, commonName)
+
+	if _, err := h.store.UseBootstrapToken(auth.HashBootstrapToken(req.Token), commonName, time.Now()); err != nil {
+		if err == store.ErrBootstrapTokenNotFound {
+			log.Printf("Rejected enrollment of %s: bootstrap token invalid, expired or already used", commonName)
+			http.Error(w, "Bootstrap token is invalid, expired or already used", http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Error using bootstrap token: %v", err)
+		http.Error(w, "Failed to enroll worker", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := h.issueCertificate([]byte(req.CSR), commonName)
+	if err != nil {
+		log.Printf("Error issuing certificate for %s: %v", commonName, err)
+		http.Error(w, "Failed to issue certificate", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, resp.Serial)
+	log.Printf("Worker %s enrolled (certificate %s, expires %s)", commonName, resp.Serial, resp.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RenewCertificate issues a new certificate to a worker presenting a valid
+// certificate from this CA. The new certificate keeps the current common name.
+func (h *MasterHandler) RenewCertificate(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) {
+		return
+	}
+
+	current := peerCertificate(r)
+	if current == nil || !h.pki.ca.Issued(current) {
+		http.Error(w, "Renewal requires a client certificate issued by this master", http.StatusUnauthorized)
+		return
+	}
+	serial := tlsutil.SerialString(current.SerialNumber)
+	if time.Now().After(current.NotAfter) || h.pki.isRevoked(h.store, serial) {
+		http.Error(w, "Client certificate is expired or revoked: enroll the worker again", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.RenewalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if _, err := tlsutil.ParseCSR([]byte(req.CSR)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	commonName := current.Subject.CommonName
+	resp, err := h.issueCertificate([]byte(req.CSR), commonName)
+	if err != nil {
+		log.Printf("Error renewing certificate %s for %s: %v", serial, commonName, err)
+		http.Error(w, "Failed to issue certificate", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, resp.Serial)
+	log.Printf("Certificate %s of worker %s renewed as %s (expires %s)", serial, commonName, resp.Serial, resp.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetCACertificate returns the PEM-encoded CA certificate, so workers can
+// verify client certificates and operators can distribute it
+func (h *MasterHandler) GetCACertificate(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePKI(w) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Write(h.pki.ca.CertPEM())
+}
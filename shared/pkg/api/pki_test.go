@@ -0,0 +1,173 @@
+package api_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	tlsutil "github.com/psantana5/ffmpeg-rtmp/pkg/tls"
+)
+
+// TestWorkerEnrollment verifies bootstrap-token enrollment, renewal and revocation of worker certificates
+func TestWorkerEnrollment(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := tlsutil.LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), "test-ca")
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	handler.EnablePKI(ca, time.Hour)
+	router := mux.NewRouter()
+	router.Use(handler.CertificateMiddleware(true))
+	handler.RegisterRoutes(router)
+
+	do := func(method, path string, peer *x509.Certificate, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if peer != nil {
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{peer}}
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	csrFor := func(commonName string) string {
+		_, csrPEM, err := tlsutil.GenerateKeyAndCSR(commonName)
+		if err != nil {
+			t.Fatalf("Failed to generate CSR: %v", err)
+		}
+		body, _ := json.Marshal(string(csrPEM))
+		return string(body)
+	}
+	parseIssued := func(w *httptest.ResponseRecorder) (*models.CertificateResponse, *x509.Certificate) {
+		var resp models.CertificateResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		block, _ := pem.Decode([]byte(resp.Certificate))
+		if block == nil {
+			t.Fatalf("Expected PEM certificate in response: %s", w.Body.String())
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("Failed to parse issued certificate: %v", err)
+		}
+		return &resp, cert
+	}
+
+	// Admin routes are not reachable without a client certificate once certificates are required
+	if w := do("POST", "/pki/bootstrap-tokens", nil, `{}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 without a client certificate, got %d", w.Code)
+	}
+	if w := do("GET", "/pki/ca.crt", nil, ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "BEGIN CERTIFICATE") {
+		t.Errorf("Expected CA certificate without a client certificate, got %d", w.Code)
+	}
+
+	// Create a bootstrap token as an operator using a certificate from the CA
+	_, adminCSR, _ := tlsutil.GenerateKeyAndCSR("admin")
+	adminCert, _, err := ca.SignClientCSR(adminCSR, "admin", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to sign admin certificate: %v", err)
+	}
+	w := do("POST", "/pki/bootstrap-tokens", adminCert, `{"description":"rack 1","expires_in":"1h"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating bootstrap token, got %d: %s", w.Code, w.Body.String())
+	}
+	var token struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &token)
+
+	var workerCert *x509.Certificate
+	t.Run("Enroll", func(t *testing.T) {
+		if w := do("POST", "/pki/enroll", nil, `{"token":"`+token.Token+`","csr":"not a csr"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for invalid CSR, got %d", w.Code)
+		}
+		if w := do("POST", "/pki/enroll", nil, `{"token":"`+token.Token+`","csr":`+csrFor("../etc")+`}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for invalid common name, got %d", w.Code)
+		}
+
+		w := do("POST", "/pki/enroll", nil, `{"token":"`+token.Token+`","csr":`+csrFor("worker-1")+`}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 enrolling worker, got %d: %s", w.Code, w.Body.String())
+		}
+		resp, cert := parseIssued(w)
+		if cert.Subject.CommonName != "worker-1" || !ca.Issued(cert) || resp.Serial != tlsutil.SerialString(cert.SerialNumber) {
+			t.Errorf("Unexpected enrolled certificate: %+v", resp)
+		}
+		if cert.NotAfter.After(time.Now().Add(time.Hour + time.Minute)) {
+			t.Errorf("Expected certificate lifetime to be capped at 1h, expires %v", cert.NotAfter)
+		}
+		workerCert = cert
+
+		if w := do("POST", "/pki/enroll", nil, `{"token":"`+token.Token+`","csr":`+csrFor("worker-2")+`}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 reusing a bootstrap token, got %d", w.Code)
+		}
+		if w := do("POST", "/pki/enroll", nil, `{"token":"ffboot_unknown","csr":`+csrFor("worker-2")+`}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for unknown bootstrap token, got %d", w.Code)
+		}
+	})
+
+	var renewed *x509.Certificate
+	t.Run("Renew", func(t *testing.T) {
+		if w := do("POST", "/pki/renew", nil, `{"csr":`+csrFor("worker-1")+`}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 renewing without a certificate, got %d", w.Code)
+		}
+
+		// The renewed certificate keeps the enrolled name, whatever the CSR asks for
+		w := do("POST", "/pki/renew", workerCert, `{"csr":`+csrFor("admin")+`}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 renewing certificate, got %d: %s", w.Code, w.Body.String())
+		}
+		_, renewed = parseIssued(w)
+		if renewed.Subject.CommonName != "worker-1" || renewed.SerialNumber.Cmp(workerCert.SerialNumber) == 0 {
+			t.Errorf("Expected a new certificate for worker-1, got %s", renewed.Subject.CommonName)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		serial := tlsutil.SerialString(workerCert.SerialNumber)
+		if w := do("DELETE", "/pki/certificates/"+serial, adminCert, ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 revoking certificate, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := do("DELETE", "/pki/certificates/ffff", adminCert, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 revoking unknown certificate, got %d", w.Code)
+		}
+
+		if w := do("GET", "/nodes", workerCert, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for revoked certificate, got %d", w.Code)
+		}
+		if w := do("GET", "/nodes", renewed, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected renewed certificate to keep working, got %d", w.Code)
+		}
+
+		var list struct {
+			Certificates []models.IssuedCertificate `json:"certificates"`
+		}
+		json.Unmarshal(do("GET", "/pki/certificates?revoked=true", adminCert, "").Body.Bytes(), &list)
+		if len(list.Certificates) != 1 || list.Certificates[0].Serial != serial {
+			t.Errorf("Expected the revoked certificate to be listed, got %+v", list.Certificates)
+		}
+	})
+
+	t.Run("ListTokensHidesHashes", func(t *testing.T) {
+		w := do("GET", "/pki/bootstrap-tokens", adminCert, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 listing bootstrap tokens, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "token_hash") || !strings.Contains(w.Body.String(), `"used_by":"worker-1"`) {
+			t.Errorf("Unexpected bootstrap token listing: %s", w.Body.String())
+		}
+	})
+}
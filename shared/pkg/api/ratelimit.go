@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/ratelimit"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// RateLimitKeyFuncs returns the rate limit key of every policy key type, based on
+// the credentials established by the authentication middlewares.
+// Forwarded-for headers are not trusted, so clients cannot pick their own key.
+func RateLimitKeyFuncs() map[string]ratelimit.KeyFunc {
+	return map[string]ratelimit.KeyFunc{
+		ratelimit.KeyIP:     remoteIP,
+		ratelimit.KeyTenant: requestTenantID,
+		ratelimit.KeyAPIKey: func(r *http.Request) string {
+			return tenancy.GetTenantKeyID(r.Context())
+		},
+		ratelimit.KeyUser: func(r *http.Request) string {
+			userID, _ := tenancy.GetUserID(r.Context())
+			return userID
+		},
+		ratelimit.KeyIdentity: rateLimitIdentity,
+	}
+}
+
+// rateLimitIdentity identifies the client of a request as precisely as possible.
+// Requests made with the master API key are counted per address, since all
+// workers may share that key.
+func rateLimitIdentity(r *http.Request) string {
+	actorType, actorID := requestAuditActor(r)
+	switch actorType {
+	case models.AuditActorUser:
+		return "user:" + actorID
+	case models.AuditActorAPIKey:
+		return "apikey:" + actorID
+	case models.AuditActorCertificate:
+		return "cert:" + actorID
+	case models.AuditActorAdmin:
+		return "admin:" + remoteIP(r)
+	default:
+		return "ip:" + remoteIP(r)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// GetJobResult returns the stored final result of a job (metrics, analyzer output and scores).
+// Logs are served by GET /jobs/{id}/logs.
+func (h *MasterHandler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	job, err := h.getJobForRequest(r, mux.Vars(r)["id"])
+	if err != nil {
+		if err == store.ErrJobNotFound {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error retrieving job: %v", err)
+		http.Error(w, "Failed to retrieve job", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := h.store.GetJobResult(job.ID)
+	if err != nil {
+		if err == store.ErrJobResultNotFound {
+			http.Error(w, "Job result not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error retrieving job result: %v", err)
+		http.Error(w, "Failed to retrieve job result", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetResultAggregates returns averages of completed job results grouped by scenario, engine or node.
+// Query parameters: group_by (default scenario) and since (RFC3339 time or duration such as 24h).
+func (h *MasterHandler) GetResultAggregates(w http.ResponseWriter, r *http.Request) {
+	// Aggregates span all tenants, so they are not available to tenant-scoped requests
+	if requestTenantID(r) != "" {
+		http.Error(w, "Result aggregates are only available to administrators", http.StatusForbidden)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = models.ResultGroupScenario
+	}
+	if !models.IsValidResultGroup(groupBy) {
+		http.Error(w, fmt.Sprintf("Invalid group_by '%s' (use scenario, engine or node)", groupBy), http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		var err error
+		since, err = parseSince(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	aggregates, err := h.store.GetResultAggregates(groupBy, since)
+	if err != nil {
+		log.Printf("Error aggregating job results: %v", err)
+		http.Error(w, "Failed to aggregate job results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"group_by":   groupBy,
+		"aggregates": aggregates,
+		"count":      len(aggregates),
+	})
+}
+
+// parseSince accepts an RFC3339 timestamp or a duration relative to now
+func parseSince(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid since '%s' (use an RFC3339 time or a duration such as 24h)", raw)
+	}
+	return time.Now().Add(-d), nil
+}
@@ -0,0 +1,136 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// TestJobResultPersistence verifies that reported results are stored and aggregated
+func TestJobResultPersistence(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	createJob := func(scenario string) *models.Job {
+		w := do("POST", "/jobs", `{"scenario":"`+scenario+`"}`)
+		var job models.Job
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to parse job: %v", err)
+		}
+		return &job
+	}
+
+	job1 := createJob("1080p")
+	job2 := createJob("1080p")
+	job3 := createJob("720p")
+
+	// No result before the worker reports
+	if w := do("GET", "/jobs/"+job1.ID+"/result", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 before results, got %d", w.Code)
+	}
+
+	do("POST", "/results", `{"job_id":"`+job1.ID+`","node_id":"n1","status":"completed","metrics":{"fps":30,"bitrate":"4M","engine":"ffmpeg"},"vmaf_score":90,"energy_joules":100}`)
+	do("POST", "/results", `{"job_id":"`+job2.ID+`","node_id":"n2","status":"completed","metrics":{"fps":"50","bitrate_kbps":2000},"energy_joules":300}`)
+	do("POST", "/results", `{"job_id":"`+job3.ID+`","node_id":"n1","status":"failed","error":"boom"}`)
+
+	// Result is retrievable by ID and by sequence number
+	w := do("GET", "/jobs/"+job1.ID+"/result", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Response: %s", w.Code, w.Body.String())
+	}
+	var result models.JobResult
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if result.VMAFScore != 90 || result.Metrics["bitrate"] != "4M" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.CompletedAt.IsZero() {
+		t.Error("Expected completion time to be set")
+	}
+	if w := do("GET", "/jobs/3/result", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected failed job result by sequence number, got %d", w.Code)
+	}
+
+	// Only completed results are aggregated
+	w = do("GET", "/results/aggregates?group_by=scenario&since=24h", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Response: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		GroupBy    string                    `json:"group_by"`
+		Aggregates []*models.ResultAggregate `json:"aggregates"`
+		Count      int                       `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Count != 1 || resp.Aggregates[0].Key != "1080p" {
+		t.Fatalf("Expected one 1080p aggregate, got %+v", resp)
+	}
+	agg := resp.Aggregates[0]
+	if agg.Count != 2 || agg.AvgFPS != 40 || agg.AvgBitrateKbps != 3000 || agg.TotalEnergyJoules != 400 || agg.AvgVMAFScore != 90 {
+		t.Errorf("Unexpected aggregate: %+v", agg)
+	}
+
+	// Invalid parameters are rejected
+	if w := do("GET", "/results/aggregates?group_by=tenant", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid group_by, got %d", w.Code)
+	}
+	if w := do("GET", "/results/aggregates?since=yesterday", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid since, got %d", w.Code)
+	}
+}
+
+// TestReceiveResultsExitUnknownNotRetried verifies that a job whose workload
+// exit status was lost fails for inspection instead of running twice
+func TestReceiveResultsExitUnknownNotRetried(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandlerWithRetry(testStore, 3)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	createJob := func() *models.Job {
+		w := do("POST", "/jobs", `{"scenario":"1080p"}`)
+		var job models.Job
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to parse job: %v", err)
+		}
+		return &job
+	}
+
+	crashed := createJob()
+	unknown := createJob()
+	do("POST", "/results", `{"job_id":"`+crashed.ID+`","node_id":"n1","status":"failed","error":"recovered workload exited with code 1"}`)
+	do("POST", "/results", `{"job_id":"`+unknown.ID+`","node_id":"n1","status":"failed","error":"recovered after agent restart, exit status unknown","failure_reason":"exit_unknown"}`)
+
+	job, _ := testStore.GetJob(crashed.ID)
+	if job.Status != models.JobStatusPending || job.RetryCount != 1 {
+		t.Errorf("Expected the failed job to be retried, got %s (retries %d)", job.Status, job.RetryCount)
+	}
+	job, _ = testStore.GetJob(unknown.ID)
+	if job.Status != models.JobStatusFailed || job.RetryCount != 0 {
+		t.Errorf("Expected the job with unknown exit status to stay failed, got %s (retries %d)", job.Status, job.RetryCount)
+	}
+	if job.FailureReason != models.FailureReasonExitUnknown {
+		t.Errorf("Expected failure reason %s, got %q", models.FailureReasonExitUnknown, job.FailureReason)
+	}
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/secrets"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// secretName restricts secret names to characters that are safe in URLs and logs
+var secretName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// maxSecretSize is the largest secret value accepted
+const maxSecretSize = 64 * 1024
+
+// EnableSecrets stores secrets encrypted with cipher and resolves secret
+// references in job parameters when jobs are handed to their workers
+func (h *MasterHandler) EnableSecrets(cipher *secrets.Cipher) {
+	h.secrets = cipher
+}
+
+// requireSecrets writes a 404 response if secrets are not enabled
+func (h *MasterHandler) requireSecrets(w http.ResponseWriter) bool {
+	if h.secrets == nil {
+		http.Error(w, "Secrets are not enabled on this master", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// secretTenantID returns the tenant owning the secrets of a request or job.
+// Cluster-wide requests and jobs belong to the default tenant.
+func secretTenantID(tenantID string) string {
+	if tenantID == "" {
+		return models.DefaultTenantID
+	}
+	return tenantID
+}
+
+// secretID identifies a secret for encryption, binding its ciphertext to its tenant and name
+func secretID(tenantID, name string) string {
+	return tenantID + "/" + name
+}
+
+// sealSecret encrypts value into secret
+func (h *MasterHandler) sealSecret(secret *models.Secret, value string) error {
+	ciphertext, err := h.secrets.Seal(secretID(secret.TenantID, secret.Name), []byte(value))
+	if err != nil {
+		return err
+	}
+	secret.Ciphertext = ciphertext
+	return nil
+}
+
+// decodeSecretRequest decodes and validates the value of a secret request
+func decodeSecretRequest(w http.ResponseWriter, r *http.Request) (*models.SecretRequest, bool) {
+	var req models.SecretRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxSecretSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	if req.Value == "" {
+		http.Error(w, "A secret value is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(req.Value) > maxSecretSize {
+		http.Error(w, fmt.Sprintf("Secret values are limited to %d bytes", maxSecretSize), http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+// CreateSecret stores a new secret for the requesting tenant. The value is never returned.
+func (h *MasterHandler) CreateSecret(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSecrets(w) {
+		return
+	}
+	req, ok := decodeSecretRequest(w, r)
+	if !ok {
+		return
+	}
+	if !secretName.MatchString(req.Name) {
+		http.Error(w, "Secret names must be 1-128 letters, digits, dots, dashes or underscores", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	secret := &models.Secret{
+		ID:        uuid.New().String(),
+		TenantID:  secretTenantID(requestTenantID(r)),
+		Name:      req.Name,
+		CreatedBy: requestActor(r),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Description != nil {
+		secret.Description = *req.Description
+	}
+	if err := h.sealSecret(secret, req.Value); err != nil {
+		log.Printf("Error encrypting secret %s: %v", secret.Name, err)
+		http.Error(w, "Failed to create secret", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.CreateSecret(secret); err != nil {
+		if err == store.ErrSecretExists {
+			http.Error(w, "A secret with this name already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Error creating secret: %v", err)
+		http.Error(w, "Failed to create secret", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, secret.Name)
+	log.Printf("Secret %s created", secretID(secret.TenantID, secret.Name))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(secret)
+}
+
+// ListSecrets returns the requesting tenant's secrets without their values
+func (h *MasterHandler) ListSecrets(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSecrets(w) {
+		return
+	}
+
+	list, err := h.store.ListSecrets(secretTenantID(requestTenantID(r)))
+	if err != nil {
+		log.Printf("Error listing secrets: %v", err)
+		http.Error(w, "Failed to list secrets", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"secrets": list,
+		"count":   len(list),
+	})
+}
+
+// UpdateSecret replaces the value (and optionally the description) of a secret.
+// Jobs already handed to workers keep the previous value.
+func (h *MasterHandler) UpdateSecret(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSecrets(w) {
+		return
+	}
+	req, ok := decodeSecretRequest(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := h.store.GetSecret(secretTenantID(requestTenantID(r)), mux.Vars(r)["name"])
+	if err != nil {
+		if err == store.ErrSecretNotFound {
+			http.Error(w, "Secret not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting secret: %v", err)
+		http.Error(w, "Failed to update secret", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Description != nil {
+		secret.Description = *req.Description
+	}
+	secret.UpdatedAt = time.Now()
+	if err := h.sealSecret(secret, req.Value); err != nil {
+		log.Printf("Error encrypting secret %s: %v", secret.Name, err)
+		http.Error(w, "Failed to update secret", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.UpdateSecret(secret); err != nil {
+		if err == store.ErrSecretNotFound {
+			http.Error(w, "Secret not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating secret: %v", err)
+		http.Error(w, "Failed to update secret", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Secret %s updated", secretID(secret.TenantID, secret.Name))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(secret)
+}
+
+// DeleteSecret deletes a secret. Pending jobs referencing it fail when they are scheduled.
+func (h *MasterHandler) DeleteSecret(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSecrets(w) {
+		return
+	}
+
+	tenantID, name := secretTenantID(requestTenantID(r)), mux.Vars(r)["name"]
+	if err := h.store.DeleteSecret(tenantID, name); err != nil {
+		if err == store.ErrSecretNotFound {
+			http.Error(w, "Secret not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting secret: %v", err)
+		http.Error(w, "Failed to delete secret", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Secret %s deleted", secretID(tenantID, name))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "deleted",
+		"name":   name,
+	})
+}
+
+// checkSecretRefs verifies that the secrets referenced by a new job's parameters exist
+func (h *MasterHandler) checkSecretRefs(job *models.Job) error {
+	names, err := secrets.Refs(job.Parameters)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if h.secrets == nil {
+		return fmt.Errorf("secret references are not supported: secrets are not enabled on this master")
+	}
+	for _, name := range names {
+		if _, err := h.store.GetSecret(secretTenantID(job.TenantID), name); err != nil {
+			if err == store.ErrSecretNotFound {
+				return fmt.Errorf("unknown secret '%s'", name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupSecret decrypts a secret of the given tenant
+func (h *MasterHandler) lookupSecret(tenantID string) func(name string) (string, error) {
+	tenantID = secretTenantID(tenantID)
+	return func(name string) (string, error) {
+		if h.secrets == nil {
+			return "", fmt.Errorf("secrets are not enabled on this master")
+		}
+		secret, err := h.store.GetSecret(tenantID, name)
+		if err != nil {
+			if err == store.ErrSecretNotFound {
+				return "", fmt.Errorf("secret '%s' not found", name)
+			}
+			return "", err
+		}
+		value, err := h.secrets.Open(secretID(tenantID, name), secret.Ciphertext)
+		if err != nil {
+			return "", fmt.Errorf("secret '%s': %w", name, err)
+		}
+		return string(value), nil
+	}
+}
+
+// resolveJobSecrets returns a copy of job with its secret references replaced by
+// their values, for handing the job to its assigned worker. The stored job keeps
+// the references, so values never appear in job listings.
+func (h *MasterHandler) resolveJobSecrets(job *models.Job) (*models.Job, error) {
+	names, err := secrets.Refs(job.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return job, nil
+	}
+
+	params, values, err := secrets.Resolve(job.Parameters, h.lookupSecret(job.TenantID))
+	if err != nil {
+		return nil, err
+	}
+	resolved := *job
+	resolved.Parameters = params
+	resolved.SecretValues = values
+	return &resolved, nil
+}
+
+// redactJobResult removes the values of the secrets a job references from its
+// result before it is stored, logged or written to results files. Workers redact
+// their results too; this also covers workers that do not.
+func (h *MasterHandler) redactJobResult(result *models.JobResult) {
+	job, err := h.store.GetJob(result.JobID)
+	if err != nil {
+		return
+	}
+	names, err := secrets.Refs(job.Parameters)
+	if err != nil || len(names) == 0 {
+		return
+	}
+
+	lookup := h.lookupSecret(job.TenantID)
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		value, err := lookup(name)
+		if err != nil {
+			log.Printf("Warning: cannot redact secret %s from results of job %s: %v", name, job.ID, err)
+			continue
+		}
+		values = append(values, value)
+	}
+	secrets.NewRedactor(values...).RedactResult(result)
+}
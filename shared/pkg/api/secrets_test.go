@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/secrets"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// TestJobSecrets verifies that secret values are only handed to the assigned worker
+// and are redacted from job listings and results
+func TestJobSecrets(t *testing.T) {
+	const streamKey = "live_9f8e7d6c5b4a"
+
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	jobBody := `{"scenario":"1080p","parameters":{"output":"rtmp://live.example.com/app","stream_key":{"$secret":"stream_key"}}}`
+
+	t.Run("Disabled", func(t *testing.T) {
+		if w := do("POST", "/secrets", `{"name":"stream_key","value":"x"}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 with secrets disabled, got %d", w.Code)
+		}
+		if w := do("POST", "/jobs", jobBody); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for secret references with secrets disabled, got %d", w.Code)
+		}
+	})
+
+	cipher, err := secrets.NewCipher(bytes.Repeat([]byte{7}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	handler.EnableSecrets(cipher)
+
+	if w := do("POST", "/jobs", jobBody); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 referencing an unknown secret, got %d", w.Code)
+	}
+	if w := do("POST", "/secrets", `{"name":"../key","value":"x"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid name, got %d", w.Code)
+	}
+	w := do("POST", "/secrets", `{"name":"stream_key","value":"`+streamKey+`","description":"YouTube"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating secret, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), streamKey) {
+		t.Error("Secret value returned on creation")
+	}
+	if w := do("POST", "/secrets", `{"name":"stream_key","value":"other"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a duplicate secret, got %d", w.Code)
+	}
+	if w := do("GET", "/secrets", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), streamKey) ||
+		!strings.Contains(w.Body.String(), `"description":"YouTube"`) {
+		t.Errorf("Unexpected secret listing (%d): %s", w.Code, w.Body.String())
+	}
+
+	w = do("POST", "/jobs", jobBody)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating job, got %d: %s", w.Code, w.Body.String())
+	}
+	var job models.Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+
+	w = do("POST", "/nodes/register", `{"address":"http://worker-1:9000","type":"server","cpu_threads":4}`)
+	var node models.Node
+	json.Unmarshal(w.Body.Bytes(), &node)
+
+	t.Run("ResolvedForWorker", func(t *testing.T) {
+		w := do("GET", "/jobs/next?node_id="+node.ID, "")
+		var resp struct {
+			Job *models.Job `json:"job"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Job == nil || resp.Job.ID != job.ID {
+			t.Fatalf("Expected job to be assigned, got %s", w.Body.String())
+		}
+		if resp.Job.Parameters["stream_key"] != streamKey || len(resp.Job.SecretValues) != 1 || resp.Job.SecretValues[0] != streamKey {
+			t.Errorf("Expected the worker to receive the secret value, got %+v", resp.Job.Parameters)
+		}
+
+		// The stored job keeps the reference
+		for _, path := range []string{"/jobs/" + job.ID, "/jobs"} {
+			if w := do("GET", path, ""); strings.Contains(w.Body.String(), streamKey) || !strings.Contains(w.Body.String(), `"$secret":"stream_key"`) {
+				t.Errorf("Expected %s to show the reference only, got %s", path, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("ResultsRedacted", func(t *testing.T) {
+		result := models.JobResult{
+			JobID:   job.ID,
+			NodeID:  node.ID,
+			Status:  models.JobStatusCompleted,
+			Logs:    "Command: ffmpeg -f flv rtmp://live.example.com/app/" + streamKey,
+			Metrics: map[string]interface{}{"output_url": "rtmp://live.example.com/app/" + streamKey},
+		}
+		body, _ := json.Marshal(result)
+		if w := do("POST", "/results", string(body)); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 posting results, got %d: %s", w.Code, w.Body.String())
+		}
+
+		stored, _ := testStore.GetJob(job.ID)
+		if strings.Contains(stored.Logs, streamKey) || !strings.Contains(stored.Logs, secrets.Placeholder) {
+			t.Errorf("Expected stored logs to be redacted, got %q", stored.Logs)
+		}
+		saved, err := testStore.GetJobResult(job.ID)
+		if err != nil || saved.Metrics["output_url"] != "rtmp://live.example.com/app/"+secrets.Placeholder {
+			t.Errorf("Expected stored result to be redacted, got %+v (%v)", saved, err)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		if w := do("PUT", "/secrets/stream_key", `{"value":"live_rotated"}`); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 updating secret, got %d", w.Code)
+		}
+		if w := do("PUT", "/secrets/missing", `{"value":"x"}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 updating unknown secret, got %d", w.Code)
+		}
+
+		// Jobs whose secrets are deleted before they run fail instead of running without them
+		w := do("POST", "/jobs", jobBody)
+		var pending models.Job
+		json.Unmarshal(w.Body.Bytes(), &pending)
+		if w := do("DELETE", "/secrets/stream_key", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 deleting secret, got %d", w.Code)
+		}
+		if w := do("GET", "/jobs/next?node_id="+node.ID, ""); strings.Contains(w.Body.String(), pending.ID) {
+			t.Errorf("Expected no job to be handed out, got %s", w.Body.String())
+		}
+		if failed, _ := testStore.GetJob(pending.ID); failed.Status != models.JobStatusFailed || !strings.Contains(failed.Error, "stream_key") {
+			t.Errorf("Expected job to fail for the missing secret, got %s: %s", failed.Status, failed.Error)
+		}
+	})
+}
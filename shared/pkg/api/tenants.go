@@ -0,0 +1,381 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// requestTenantID returns the tenant a request is bound to, or "" for
+// administrative requests that act across all tenants
+func requestTenantID(r *http.Request) string {
+	tenantID, _ := tenancy.GetTenantID(r.Context())
+	return tenantID
+}
+
+// canAccessTenant reports whether the request may see a resource owned by ownerID
+func canAccessTenant(r *http.Request, ownerID string) bool {
+	tenantID := requestTenantID(r)
+	return tenantID == "" || tenantID == ownerID
+}
+
+// requireAdmin rejects requests whose credentials are bound to a single tenant
+// (tenant API keys and tenant users), writing a 403 response. It returns true
+// if the request may continue.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if tenancy.IsTenantScoped(r.Context()) {
+		http.Error(w, "Forbidden: this operation requires cluster-wide credentials", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// getJobForRequest retrieves a job by ID or sequence number, hiding jobs
+// owned by other tenants as if they did not exist
+func (h *MasterHandler) getJobForRequest(r *http.Request, idOrSeq string) (*models.Job, error) {
+	job, err := h.getJobByIDOrSequence(idOrSeq)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessTenant(r, job.TenantID) {
+		return nil, store.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// getNodeForRequest retrieves a node, hiding nodes dedicated to other tenants
+// (and shared nodes from tenant-scoped requests) as if they did not exist
+func (h *MasterHandler) getNodeForRequest(r *http.Request, nodeID string) (*models.Node, error) {
+	node, err := h.store.GetNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessTenant(r, node.TenantID) {
+		return nil, store.ErrNodeNotFound
+	}
+	return node, nil
+}
+
+// SetQuotaEnforcer enables tenant quota enforcement for job submission and node registration
+func (h *MasterHandler) SetQuotaEnforcer(quotas *tenancy.QuotaEnforcer) {
+	h.quotas = quotas
+}
+
+// writeQuotaError writes the response for a failed quota check: 403 for
+// exceeded quotas and inactive tenants, 500 otherwise
+func writeQuotaError(w http.ResponseWriter, err error, failure string) {
+	var quotaErr *tenancy.QuotaError
+	switch {
+	case errors.As(err, &quotaErr):
+		http.Error(w, fmt.Sprintf("Quota exceeded: %s", quotaErr.Error()), http.StatusForbidden)
+	case err == tenancy.ErrTenantInactive, err == store.ErrTenantNotFound:
+		http.Error(w, "Tenant is not active", http.StatusForbidden)
+	default:
+		log.Printf("Error checking tenant quota: %v", err)
+		http.Error(w, failure, http.StatusInternalServerError)
+	}
+}
+
+// CreateTenant creates a new tenant and issues its first API key.
+// The key is only returned once, on creation.
+func (h *MasterHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var req models.TenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tenant := models.NewTenant(uuid.New().String(), req.Name, req.Plan)
+	if req.DisplayName != "" {
+		tenant.DisplayName = req.DisplayName
+	}
+	tenant.Metadata = req.Metadata
+	tenant.ExpiresAt = req.ExpiresAt
+
+	if err := tenant.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.CreateTenant(tenant); err != nil {
+		if err == store.ErrTenantExists {
+			http.Error(w, "Tenant with this name already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Error creating tenant: %v", err)
+		http.Error(w, "Failed to create tenant", http.StatusInternalServerError)
+		return
+	}
+
+	// The first key is an API key; workers need a separate worker key
+	apiKey, err := h.issueTenantAPIKey(tenant, &models.TenantAPIKey{
+		Name:      "default",
+		Kind:      models.TenantAPIKeyKindAPI,
+		CreatedBy: requestActor(r),
+	})
+	if err != nil {
+		log.Printf("Error creating API key for tenant %s: %v", tenant.Name, err)
+		http.Error(w, "Tenant created but failed to issue API key", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, tenant.ID)
+	log.Printf("Tenant created: %s [%s] (plan: %s)", tenant.Name, tenant.ID, tenant.Plan)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		*models.Tenant
+		APIKey string `json:"api_key"`
+	}{tenant, apiKey})
+}
+
+// ListTenants returns all tenants that have not been deleted
+func (h *MasterHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	tenants, err := h.store.ListTenants()
+	if err != nil {
+		log.Printf("Error listing tenants: %v", err)
+		http.Error(w, "Failed to list tenants", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tenants": tenants,
+		"count":   len(tenants),
+	})
+}
+
+// GetTenant retrieves a tenant by ID. Tenant API keys may only read their own tenant.
+func (h *MasterHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// UpdateTenant updates the display name, plan, status, quotas, metadata or expiry of a tenant.
+// Changing the plan resets the quotas to the plan defaults unless quotas are also given.
+func (h *MasterHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		DisplayName *string                 `json:"display_name,omitempty"`
+		Plan        *string                 `json:"plan,omitempty"`
+		Status      *string                 `json:"status,omitempty"`
+		Quotas      *models.TenantQuota     `json:"quotas,omitempty"`
+		Metadata    *map[string]interface{} `json:"metadata,omitempty"`
+		ExpiresAt   *time.Time              `json:"expires_at,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if req.DisplayName != nil {
+		tenant.DisplayName = *req.DisplayName
+	}
+	if req.Plan != nil && *req.Plan != tenant.Plan {
+		tenant.Plan = *req.Plan
+		tenant.Quotas = *models.DefaultTenantQuotas(tenant.Plan)
+	}
+	if req.Status != nil {
+		tenant.Status = *req.Status
+	}
+	if req.Quotas != nil {
+		tenant.Quotas = *req.Quotas
+	}
+	if req.Metadata != nil {
+		tenant.Metadata = *req.Metadata
+	}
+	if req.ExpiresAt != nil {
+		tenant.ExpiresAt = req.ExpiresAt
+	}
+	tenant.Quotas.TenantID = tenant.ID
+	tenant.Quotas.UpdatedAt = now
+	tenant.UpdatedAt = now
+
+	if err := tenant.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.UpdateTenant(tenant); err != nil {
+		if err == store.ErrTenantNotFound {
+			http.Error(w, "Tenant not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating tenant: %v", err)
+		http.Error(w, "Failed to update tenant", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Tenant %s updated", tenant.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// DeleteTenant soft-deletes a tenant. Its API keys stop working immediately.
+func (h *MasterHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	tenantID := mux.Vars(r)["id"]
+	if err := h.store.DeleteTenant(tenantID); err != nil {
+		switch err {
+		case store.ErrTenantNotFound:
+			http.Error(w, "Tenant not found", http.StatusNotFound)
+		case store.ErrDefaultTenant:
+			http.Error(w, "Cannot delete the default tenant", http.StatusForbidden)
+		default:
+			log.Printf("Error deleting tenant: %v", err)
+			http.Error(w, "Failed to delete tenant", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Printf("Tenant %s deleted", tenantID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":    "deleted",
+		"tenant_id": tenantID,
+	})
+}
+
+// GetTenantStats returns a tenant's live usage alongside its quotas
+func (h *MasterHandler) GetTenantStats(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.store.GetTenantStats(tenant.ID)
+	if err != nil {
+		log.Printf("Error getting tenant stats: %v", err)
+		http.Error(w, "Failed to get tenant stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tenant_id": tenant.ID,
+		"name":      tenant.Name,
+		"plan":      tenant.Plan,
+		"status":    tenant.Status,
+		"quotas":    tenant.Quotas,
+		"usage":     usage,
+		"remaining": map[string]int{
+			"concurrent_jobs": quotaRemaining(tenant.Quotas.MaxConcurrentJobs, usage.CurrentJobs),
+			"total_jobs":      quotaRemaining(tenant.Quotas.MaxTotalJobs, usage.TotalJobsLifetime),
+			"workers":         quotaRemaining(tenant.Quotas.MaxWorkers, usage.CurrentWorkers),
+			"cpu_cores":       quotaRemaining(tenant.Quotas.MaxCPUCores, usage.CurrentCPUCores),
+			"gpus":            quotaRemaining(tenant.Quotas.MaxGPUs, usage.CurrentGPUs),
+		},
+	})
+}
+
+// GetTenantJobs returns the jobs owned by a tenant
+func (h *MasterHandler) GetTenantJobs(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.store.GetJobsByTenant(tenant.ID)
+	if err != nil {
+		log.Printf("Error getting tenant jobs: %v", err)
+		http.Error(w, "Failed to get tenant jobs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetTenantNodes returns the nodes dedicated to a tenant
+func (h *MasterHandler) GetTenantNodes(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := h.lookupTenant(w, r)
+	if !ok {
+		return
+	}
+
+	nodes, err := h.store.GetNodesByTenant(tenant.ID)
+	if err != nil {
+		log.Printf("Error getting tenant nodes: %v", err)
+		http.Error(w, "Failed to get tenant nodes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"nodes": nodes,
+		"count": len(nodes),
+	})
+}
+
+// lookupTenant fetches the tenant named in the route, writing an error response
+// if it cannot be found or belongs to a different tenant than the request
+func (h *MasterHandler) lookupTenant(w http.ResponseWriter, r *http.Request) (*models.Tenant, bool) {
+	tenantID := mux.Vars(r)["id"]
+	if !canAccessTenant(r, tenantID) {
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	tenant, err := h.store.GetTenant(tenantID)
+	if err != nil {
+		if err == store.ErrTenantNotFound {
+			http.Error(w, "Tenant not found", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("Error getting tenant: %v", err)
+		http.Error(w, "Failed to get tenant", http.StatusInternalServerError)
+		return nil, false
+	}
+	return tenant, true
+}
+
+// quotaRemaining returns how much of a quota is left, or -1 if it is unlimited
+func quotaRemaining(limit, used int) int {
+	if limit < 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
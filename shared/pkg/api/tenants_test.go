@@ -0,0 +1,237 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// TestTenantIsolation verifies that tenant API keys only see their own tenant's data
+func TestTenantIsolation(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+
+	// The master key is "admin-key"; tenant keys are resolved from the store
+	isAdmin := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin-key"
+	}
+	router := mux.NewRouter()
+	router.Use(tenancy.TenantMiddleware(tenancy.NewStoreResolver(testStore), isAdmin))
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	createTenant := func(name string) (id, key string) {
+		w := do("POST", "/tenants", "admin-key", `{"name":"`+name+`","plan":"pro"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 creating tenant, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			ID     string `json:"id"`
+			APIKey string `json:"api_key"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if resp.APIKey == "" {
+			t.Fatal("Expected API key in create tenant response")
+		}
+		return resp.ID, resp.APIKey
+	}
+
+	idA, keyA := createTenant("unit-a")
+	idB, keyB := createTenant("unit-b")
+
+	if w := do("POST", "/tenants", "admin-key", `{"name":"unit-a"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for duplicate tenant, got %d", w.Code)
+	}
+
+	// Tenant A submits a job
+	w := do("POST", "/jobs", keyA, `{"scenario":"1080p"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating job, got %d: %s", w.Code, w.Body.String())
+	}
+	var job struct {
+		ID       string `json:"id"`
+		TenantID string `json:"tenant_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &job)
+	if job.TenantID != idA {
+		t.Errorf("Expected job owned by tenant %s, got %q", idA, job.TenantID)
+	}
+
+	t.Run("OwnerSeesJob", func(t *testing.T) {
+		if w := do("GET", "/jobs/"+job.ID, keyA, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		var list struct {
+			Count int `json:"count"`
+		}
+		json.Unmarshal(do("GET", "/jobs", keyA, "").Body.Bytes(), &list)
+		if list.Count != 1 {
+			t.Errorf("Expected 1 job for tenant A, got %d", list.Count)
+		}
+	})
+
+	t.Run("OtherTenantCannotSeeJob", func(t *testing.T) {
+		if w := do("GET", "/jobs/"+job.ID, keyB, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if w := do("POST", "/jobs/"+job.ID+"/cancel", keyB, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 canceling another tenant's job, got %d", w.Code)
+		}
+		var list struct {
+			Count int `json:"count"`
+		}
+		json.Unmarshal(do("GET", "/jobs", keyB, "").Body.Bytes(), &list)
+		if list.Count != 0 {
+			t.Errorf("Expected no jobs for tenant B, got %d", list.Count)
+		}
+		// X-Tenant-ID cannot be used by a tenant key to switch tenants
+		req := httptest.NewRequest("GET", "/jobs/"+job.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+keyB)
+		req.Header.Set("X-Tenant-ID", idA)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 with spoofed X-Tenant-ID, got %d", w.Code)
+		}
+	})
+
+	t.Run("AdminSeesAllJobs", func(t *testing.T) {
+		if w := do("GET", "/jobs/"+job.ID, "admin-key", ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for admin, got %d", w.Code)
+		}
+	})
+
+	t.Run("TenantRoutes", func(t *testing.T) {
+		if w := do("GET", "/tenants", keyA, ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 listing tenants with a tenant key, got %d", w.Code)
+		}
+		if w := do("GET", "/tenants/"+idA+"/stats", keyA, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for own tenant stats, got %d", w.Code)
+		}
+		if w := do("GET", "/tenants/"+idB, keyA, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for another tenant, got %d", w.Code)
+		}
+		if w := do("DELETE", "/tenants/default", "admin-key", ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 deleting the default tenant, got %d", w.Code)
+		}
+	})
+
+	t.Run("InvalidAndDeletedKeys", func(t *testing.T) {
+		if w := do("GET", "/jobs", "ffrtmp_unit-a_0000000000000000", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for invalid tenant key, got %d", w.Code)
+		}
+		if w := do("DELETE", "/tenants/"+idB, "admin-key", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 deleting tenant, got %d", w.Code)
+		}
+		// Keys stop working as soon as their tenant is deleted
+		if w := do("GET", "/tenants/"+idB, keyB, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for deleted tenant's key, got %d", w.Code)
+		}
+	})
+}
+
+// TestTenantQuotas verifies that submissions and API requests are limited by tenant quotas
+func TestTenantQuotas(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	quotas := tenancy.NewQuotaEnforcer(testStore)
+	handler.SetQuotaEnforcer(quotas)
+
+	isAdmin := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin-key"
+	}
+	router := mux.NewRouter()
+	router.Use(tenancy.TenantMiddleware(tenancy.NewStoreResolver(testStore), isAdmin))
+	router.Use(quotas.Middleware)
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/tenants", "admin-key", `{"name":"quota-team","plan":"free"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating tenant, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		ID     string `json:"id"`
+		APIKey string `json:"api_key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	tenant, err := testStore.GetTenant(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tenant: %v", err)
+	}
+	tenant.Quotas.MaxTotalJobs = 2
+	tenant.Quotas.MaxAPIRequestsPerHour = 5
+	if err := testStore.UpdateTenant(tenant); err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+
+	if w := do("POST", "/jobs", created.APIKey, `{"scenario":"1080p"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating job, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The free plan has no GPU quota
+	w = do("POST", "/jobs", created.APIKey, `{"scenario":"1080p","parameters":{"codec":"h264_nvenc"}}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "max_gpus") {
+		t.Errorf("Expected status 403 for GPU job, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := do("POST", "/jobs", created.APIKey, `{"scenario":"1080p"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating job, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do("POST", "/jobs", created.APIKey, `{"scenario":"1080p"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "max_total_jobs") {
+		t.Errorf("Expected status 403 over total job quota, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Five requests per hour are allowed; the sixth is rate limited
+	if w := do("GET", "/jobs", created.APIKey, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 within request quota, got %d", w.Code)
+	}
+	w = do("GET", "/jobs", created.APIKey, "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 over request quota, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on rate limited response")
+	}
+
+	// Other callers are not affected
+	if w := do("GET", "/jobs", "admin-key", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for admin, got %d", w.Code)
+	}
+
+	quotas.NotifyJobEvent(&models.Job{TenantID: created.ID})
+	quotas.FlushUsage()
+	tenant, _ = testStore.GetTenant(created.ID)
+	if tenant.Usage.TotalJobsLifetime != 2 || tenant.Usage.APIRequestsLastHour != 6 {
+		t.Errorf("Expected stored usage of 2 jobs and 6 requests, got %d jobs and %d requests",
+			tenant.Usage.TotalJobsLifetime, tenant.Usage.APIRequestsLastHour)
+	}
+}
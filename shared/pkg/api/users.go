@@ -0,0 +1,375 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/rbac"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// defaultSessionTTL is how long login sessions last unless configured otherwise
+const defaultSessionTTL = 24 * time.Hour
+
+// EnableRBAC enforces the route permissions of the request's role.
+// Roles are set by tenancy.TenantMiddleware, which must be mounted first.
+func (h *MasterHandler) EnableRBAC() {
+	h.rbacEnabled = true
+}
+
+// SetSessionTTL sets how long login sessions last
+func (h *MasterHandler) SetSessionTTL(ttl time.Duration) {
+	h.sessionTTL = ttl
+}
+
+// authorize wraps a route handler with a permission requirement.
+// Without RBAC the handler is mounted unauthenticated and every request is allowed.
+func (h *MasterHandler) authorize(perm models.Permission, handler http.HandlerFunc) http.Handler {
+	protected := rbac.RequirePermission(perm)(handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.rbacEnabled {
+			handler(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+}
+
+// normalizeEmail lowercases and trims an email address so lookups are case-insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Login verifies a user's email and password and starts a session.
+// The session token is only returned in this response.
+func (h *MasterHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	auditTarget(r, normalizeEmail(req.Email))
+
+	user, err := h.store.GetUserByEmail(normalizeEmail(req.Email))
+	if err != nil && err != store.ErrUserNotFound {
+		log.Printf("Error looking up user: %v", err)
+		http.Error(w, "Failed to log in", http.StatusInternalServerError)
+		return
+	}
+	passwordHash := ""
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+	// Unknown users and wrong passwords get the same response
+	if !auth.CheckPassword(passwordHash, req.Password) || !user.IsActive() {
+		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
+	auditActor(r, models.AuditActorUser, user.ID)
+	if event := auditEvent(r); event != nil {
+		// Login requests carry no credentials; file the event under the user's tenant
+		event.TenantID = user.TenantID
+	}
+	if tenant, err := h.store.GetTenant(user.TenantID); err != nil || !tenant.IsActive() {
+		http.Error(w, "Tenant is not active", http.StatusForbidden)
+		return
+	}
+
+	token, tokenHash, err := auth.GenerateSessionToken()
+	if err != nil {
+		log.Printf("Error generating session token: %v", err)
+		http.Error(w, "Failed to log in", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	session := &models.Session{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(h.sessionTTL),
+		CreatedAt: now,
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	}
+	if err := h.store.CreateSession(session); err != nil {
+		log.Printf("Error creating session: %v", err)
+		http.Error(w, "Failed to log in", http.StatusInternalServerError)
+		return
+	}
+
+	user.LastLoginAt = &now
+	if err := h.store.UpdateUser(user); err != nil {
+		log.Printf("Warning: Failed to record login for user %s: %v", user.ID, err)
+	}
+	if removed, err := h.store.DeleteExpiredSessions(); err != nil {
+		log.Printf("Warning: Failed to remove expired sessions: %v", err)
+	} else if removed > 0 {
+		log.Printf("Removed %d expired sessions", removed)
+	}
+
+	log.Printf("User %s logged in", user.Email)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LoginResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      *user,
+	})
+}
+
+// Logout ends the session the request was made with
+func (h *MasterHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !auth.IsSessionToken(token) {
+		http.Error(w, "Logout requires a session token", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.DeleteSession(auth.HashSessionToken(token)); err != nil && err != store.ErrSessionNotFound {
+		log.Printf("Error deleting session: %v", err)
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateUser creates a user account. Tenant-scoped callers create users in
+// their own tenant; cluster-wide callers may pick the tenant (default: the
+// default tenant, whose users act across all tenants).
+func (h *MasterHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	email := normalizeEmail(req.Email)
+	if !strings.Contains(email, "@") {
+		http.Error(w, "A valid email is required", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if !req.Role.IsValid() {
+		http.Error(w, "Invalid role. Valid values: admin, operator, developer, viewer", http.StatusBadRequest)
+		return
+	}
+
+	tenantID := requestTenantID(r)
+	if !tenancy.IsTenantScoped(r.Context()) && req.TenantID != "" {
+		tenantID = req.TenantID
+	}
+	if tenantID == "" {
+		tenantID = models.DefaultTenantID
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		if err == auth.ErrInvalidPassword {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error hashing password: %v", err)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Email:        email,
+		PasswordHash: passwordHash,
+		FullName:     req.FullName,
+		Role:         req.Role,
+		Status:       models.UserStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := h.store.CreateUser(user); err != nil {
+		switch err {
+		case store.ErrUserExists:
+			http.Error(w, "A user with this email already exists", http.StatusConflict)
+		case store.ErrTenantNotFound:
+			http.Error(w, "Tenant not found", http.StatusBadRequest)
+		default:
+			log.Printf("Error creating user: %v", err)
+			http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	auditTarget(r, user.ID)
+	log.Printf("User %s created with role %s in tenant %s", user.Email, user.Role, user.TenantID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// ListUsers returns the users visible to the request
+func (h *MasterHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.store.ListUsers(requestTenantID(r))
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users": users,
+		"count": len(users),
+	})
+}
+
+// lookupUser fetches the user named in the route, writing a 404 response if
+// it does not exist or belongs to another tenant
+func (h *MasterHandler) lookupUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, err := h.store.GetUser(mux.Vars(r)["id"])
+	if err == store.ErrUserNotFound || (err == nil && !canAccessTenant(r, user.TenantID)) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error getting user: %v", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return nil, false
+	}
+	return user, true
+}
+
+// GetUser returns a single user
+func (h *MasterHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateUser updates the email, name, role, status or password of a user.
+// Suspending a user or changing their password ends their sessions.
+func (h *MasterHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email    *string      `json:"email,omitempty"`
+		FullName *string      `json:"full_name,omitempty"`
+		Role     *models.Role `json:"role,omitempty"`
+		Status   *string      `json:"status,omitempty"`
+		Password *string      `json:"password,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	endSessions := false
+	if req.Email != nil {
+		user.Email = normalizeEmail(*req.Email)
+		if !strings.Contains(user.Email, "@") {
+			http.Error(w, "A valid email is required", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FullName != nil {
+		user.FullName = *req.FullName
+	}
+	if req.Role != nil {
+		if !req.Role.IsValid() {
+			http.Error(w, "Invalid role. Valid values: admin, operator, developer, viewer", http.StatusBadRequest)
+			return
+		}
+		user.Role = *req.Role
+	}
+	if req.Status != nil {
+		switch *req.Status {
+		case models.UserStatusActive:
+		case models.UserStatusSuspended:
+			endSessions = true
+		default:
+			http.Error(w, "Invalid status. Valid values: active, suspended", http.StatusBadRequest)
+			return
+		}
+		user.Status = *req.Status
+	}
+	if req.Password != nil {
+		passwordHash, err := auth.HashPassword(*req.Password)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		user.PasswordHash = passwordHash
+		endSessions = true
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := h.store.UpdateUser(user); err != nil {
+		switch err {
+		case store.ErrUserNotFound:
+			http.Error(w, "User not found", http.StatusNotFound)
+		case store.ErrUserExists:
+			http.Error(w, "A user with this email already exists", http.StatusConflict)
+		default:
+			log.Printf("Error updating user: %v", err)
+			http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if endSessions {
+		if err := h.store.DeleteUserSessions(user.ID); err != nil {
+			log.Printf("Warning: Failed to end sessions of user %s: %v", user.ID, err)
+		}
+	}
+
+	log.Printf("User %s updated", user.Email)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// DeleteUser deletes a user and ends their sessions
+func (h *MasterHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteUser(user.ID); err != nil {
+		if err == store.ErrUserNotFound {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting user: %v", err)
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("User %s deleted", user.Email)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "deleted",
+		"user_id": user.ID,
+	})
+}
@@ -0,0 +1,183 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/tenancy"
+)
+
+// TestUsersAndRBAC verifies login sessions and that routes enforce role permissions
+func TestUsersAndRBAC(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	handler.EnableRBAC()
+
+	isAdmin := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin-key"
+	}
+	router := mux.NewRouter()
+	router.Use(tenancy.TenantMiddleware(tenancy.NewStoreResolver(testStore), isAdmin))
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	createUser := func(token, body string) string {
+		w := do("POST", "/users", token, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 creating user, got %d: %s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "password") {
+			t.Errorf("User response must not include the password hash: %s", w.Body.String())
+		}
+		var user struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &user)
+		return user.ID
+	}
+	login := func(email, password string) string {
+		w := do("POST", "/auth/login", "", `{"email":"`+email+`","password":"`+password+`"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 logging in as %s, got %d: %s", email, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Token string `json:"token"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Token == "" {
+			t.Fatal("Expected session token in login response")
+		}
+		return resp.Token
+	}
+
+	viewerID := createUser("admin-key", `{"email":"Viewer@Example.com","password":"viewer-pass","role":"viewer"}`)
+	createUser("admin-key", `{"email":"dev@example.com","password":"dev-password","role":"developer"}`)
+
+	if w := do("POST", "/users", "admin-key", `{"email":"viewer@example.com","password":"another-pass"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for duplicate email, got %d", w.Code)
+	}
+	if w := do("POST", "/users", "admin-key", `{"email":"short@example.com","password":"short"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for short password, got %d", w.Code)
+	}
+
+	t.Run("BadCredentials", func(t *testing.T) {
+		if w := do("POST", "/auth/login", "", `{"email":"viewer@example.com","password":"wrong-pass"}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for wrong password, got %d", w.Code)
+		}
+		if w := do("POST", "/auth/login", "", `{"email":"nobody@example.com","password":"viewer-pass"}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for unknown user, got %d", w.Code)
+		}
+		if w := do("GET", "/jobs", "ffsess_bogus", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for unknown session, got %d", w.Code)
+		}
+	})
+
+	viewer := login("viewer@example.com", "viewer-pass")
+	developer := login("DEV@example.com", "dev-password")
+
+	w := do("POST", "/jobs", developer, `{"scenario":"1080p"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for developer job, got %d: %s", w.Code, w.Body.String())
+	}
+	var job struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &job)
+
+	t.Run("ViewerIsReadOnly", func(t *testing.T) {
+		if w := do("GET", "/jobs", viewer, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 listing jobs, got %d", w.Code)
+		}
+		if w := do("POST", "/jobs", viewer, `{"scenario":"1080p"}`); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 creating job, got %d", w.Code)
+		}
+		if w := do("POST", "/jobs/"+job.ID+"/cancel", viewer, ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 canceling job, got %d", w.Code)
+		}
+		if w := do("GET", "/users", viewer, ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 listing users, got %d", w.Code)
+		}
+	})
+
+	t.Run("DeveloperCannotManageNodes", func(t *testing.T) {
+		if w := do("POST", "/jobs/"+job.ID+"/cancel", developer, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 canceling job, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := do("DELETE", "/nodes/node-1", developer, ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 removing node, got %d", w.Code)
+		}
+		if w := do("GET", "/admin/backup", developer, ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for backup, got %d", w.Code)
+		}
+	})
+
+	t.Run("TenantUsersAreScoped", func(t *testing.T) {
+		w := do("POST", "/tenants", "admin-key", `{"name":"scoped-team","plan":"pro"}`)
+		var tenant struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &tenant)
+
+		createUser("admin-key", `{"email":"lead@scoped.example","password":"lead-password","role":"admin","tenant_id":"`+tenant.ID+`"}`)
+		lead := login("lead@scoped.example", "lead-password")
+
+		// Tenant admins manage their own users but not other tenants or cluster users
+		memberID := createUser(lead, `{"email":"member@scoped.example","password":"member-pass","role":"developer","tenant_id":"default"}`)
+		var list struct {
+			Count int `json:"count"`
+		}
+		json.Unmarshal(do("GET", "/users", lead, "").Body.Bytes(), &list)
+		if list.Count != 2 {
+			t.Errorf("Expected 2 users visible to tenant admin, got %d", list.Count)
+		}
+		var member struct {
+			TenantID string `json:"tenant_id"`
+		}
+		json.Unmarshal(do("GET", "/users/"+memberID, lead, "").Body.Bytes(), &member)
+		if member.TenantID != tenant.ID {
+			t.Errorf("Expected member created in tenant %s, got %q", tenant.ID, member.TenantID)
+		}
+		if w := do("GET", "/users/"+viewerID, lead, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for another tenant's user, got %d", w.Code)
+		}
+		if w := do("POST", "/tenants", lead, `{"name":"other-team"}`); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 creating tenant, got %d", w.Code)
+		}
+	})
+
+	t.Run("SuspensionEndsSessions", func(t *testing.T) {
+		if w := do("PUT", "/users/"+viewerID, "admin-key", `{"status":"suspended"}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 suspending user, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := do("GET", "/jobs", viewer, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for suspended user's session, got %d", w.Code)
+		}
+		if w := do("POST", "/auth/login", "", `{"email":"viewer@example.com","password":"viewer-pass"}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 logging in as suspended user, got %d", w.Code)
+		}
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		if w := do("POST", "/auth/logout", developer, ""); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204 logging out, got %d", w.Code)
+		}
+		if w := do("GET", "/jobs", developer, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 after logout, got %d", w.Code)
+		}
+	})
+}
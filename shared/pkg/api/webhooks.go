@@ -0,0 +1,330 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/webhooks"
+)
+
+// SetWebhookDispatcher sets the dispatcher used to deliver job lifecycle events
+func (h *MasterHandler) SetWebhookDispatcher(dispatcher *webhooks.Dispatcher) {
+	h.webhookDispatcher = dispatcher
+}
+
+// notifyJobEvent delivers the current state of a job to subscribed webhooks
+// and marks its tenant's usage as changed
+func (h *MasterHandler) notifyJobEvent(jobID string) {
+	if h.webhookDispatcher == nil && h.quotas == nil {
+		return
+	}
+
+	job, err := h.store.GetJob(jobID)
+	if err != nil {
+		log.Printf("Warning: Failed to load job %s for webhook notification: %v", jobID, err)
+		return
+	}
+	if h.webhookDispatcher != nil {
+		h.webhookDispatcher.NotifyJobEvent(job)
+	}
+	if h.quotas != nil {
+		h.quotas.NotifyJobEvent(job)
+	}
+}
+
+// validateWebhookURL ensures the webhook target is an absolute HTTP(S) URL
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL '%s'", raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use http or https")
+	}
+	return nil
+}
+
+// generateWebhookSecret returns a random hex-encoded signing secret
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook registers a new webhook subscription
+func (h *MasterHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateWebhookEvents(req.Events); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			log.Printf("Error generating webhook secret: %v", err)
+			http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	now := time.Now()
+	hook := &models.Webhook{
+		ID:        uuid.New().String(),
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    secret,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	hook.TenantID = requestTenantID(r)
+
+	if err := h.store.CreateWebhook(hook); err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	auditTarget(r, hook.ID)
+	log.Printf("Webhook created: %s (%s)", hook.ID, hook.URL)
+
+	// The secret is only returned once, on creation
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+// ListWebhooks returns all webhook subscriptions visible to the request's tenant
+func (h *MasterHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	all, err := h.store.ListWebhooks()
+	if err != nil {
+		log.Printf("Error listing webhooks: %v", err)
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	hooks := make([]*models.Webhook, 0, len(all))
+	for _, hook := range all {
+		if !canAccessTenant(r, hook.TenantID) {
+			continue
+		}
+		hook.Secret = ""
+		hooks = append(hooks, hook)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks": hooks,
+		"count":    len(hooks),
+	})
+}
+
+// GetWebhook retrieves a webhook subscription by ID
+func (h *MasterHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.lookupWebhook(w, r)
+	if !ok {
+		return
+	}
+	hook.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// UpdateWebhook updates the URL, event filter, secret or enabled state of a webhook
+func (h *MasterHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.lookupWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL != "" {
+		if err := validateWebhookURL(req.URL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hook.URL = req.URL
+	}
+	if req.Events != nil {
+		if err := models.ValidateWebhookEvents(req.Events); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hook.Events = req.Events
+	}
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	hook.UpdatedAt = time.Now()
+
+	if err := h.store.UpdateWebhook(hook); err != nil {
+		log.Printf("Error updating webhook: %v", err)
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Webhook %s updated", hook.ID)
+
+	hook.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// DeleteWebhook removes a webhook subscription
+func (h *MasterHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.lookupWebhook(w, r)
+	if !ok {
+		return
+	}
+	webhookID := hook.ID
+
+	if err := h.store.DeleteWebhook(webhookID); err != nil {
+		if err == store.ErrWebhookNotFound {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting webhook: %v", err)
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Webhook %s deleted", webhookID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":     "deleted",
+		"webhook_id": webhookID,
+	})
+}
+
+// ListWebhookDeliveries returns recent delivery attempts for a webhook
+func (h *MasterHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.lookupWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.store.ListWebhookDeliveries(hook.ID, limit)
+	if err != nil {
+		log.Printf("Error listing webhook deliveries: %v", err)
+		http.Error(w, "Failed to list webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// RedeliverWebhook re-sends a previous delivery with its original payload
+func (h *MasterHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.webhookDispatcher == nil {
+		http.Error(w, "Webhook delivery is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	deliveryID := mux.Vars(r)["id"]
+	if requestTenantID(r) != "" {
+		// Only allow tenants to redeliver to their own webhooks
+		original, err := h.store.GetWebhookDelivery(deliveryID)
+		if err == nil {
+			var hook *models.Webhook
+			if hook, err = h.store.GetWebhook(original.WebhookID); err == nil && !canAccessTenant(r, hook.TenantID) {
+				err = store.ErrWebhookDeliveryNotFound
+			}
+		}
+		if err != nil {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	delivery, err := h.webhookDispatcher.Redeliver(deliveryID)
+	if err != nil {
+		if err == store.ErrWebhookDeliveryNotFound {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+			return
+		}
+		if err == webhooks.ErrDeliveryPending {
+			http.Error(w, "Delivery is still pending", http.StatusConflict)
+			return
+		}
+		log.Printf("Error redelivering webhook: %v", err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Webhook delivery %s queued for redelivery", deliveryID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// lookupWebhook fetches the webhook named in the route, writing an error response
+// if it cannot be found or belongs to a different tenant than the request
+func (h *MasterHandler) lookupWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	hook, err := h.store.GetWebhook(mux.Vars(r)["id"])
+	if err == nil && !canAccessTenant(r, hook.TenantID) {
+		err = store.ErrWebhookNotFound
+	}
+	if err != nil {
+		if err == store.ErrWebhookNotFound {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("Error getting webhook: %v", err)
+		http.Error(w, "Failed to get webhook", http.StatusInternalServerError)
+		return nil, false
+	}
+	return hook, true
+}
@@ -0,0 +1,126 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/api"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/webhooks"
+)
+
+// TestWebhookCRUD verifies webhook registration, secret handling and job event recording
+func TestWebhookCRUD(t *testing.T) {
+	testStore := store.NewMemoryStore()
+	handler := api.NewMasterHandler(testStore)
+	// Dispatcher is not started so deliveries stay pending and can be inspected
+	handler.SetWebhookDispatcher(webhooks.NewDispatcher(webhooks.DefaultConfig(), testStore))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Invalid URL is rejected
+	if w := do("POST", "/webhooks", `{"url":"ftp://example.com/hook"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid URL, got %d", w.Code)
+	}
+
+	// Create returns the generated secret
+	w := do("POST", "/webhooks", `{"url":"http://example.com/hook","events":["completed","failed"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Response: %s", w.Code, w.Body.String())
+	}
+	var created models.Webhook
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if created.Secret == "" {
+		t.Error("Expected generated secret on creation")
+	}
+	if !created.Enabled {
+		t.Error("Expected webhook to be enabled by default")
+	}
+
+	// Get never returns the secret
+	w = do("GET", "/webhooks/"+created.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var fetched models.Webhook
+	json.Unmarshal(w.Body.Bytes(), &fetched)
+	if fetched.Secret != "" {
+		t.Error("Secret should not be returned by GET")
+	}
+
+	// Update the event filter
+	w = do("PUT", "/webhooks/"+created.ID, `{"events":["completed"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Response: %s", w.Code, w.Body.String())
+	}
+
+	// Job creation does not match the filter; completion does
+	w = do("POST", "/jobs", `{"scenario":"test"}`)
+	var job models.Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+	do("POST", "/results", `{"job_id":"`+job.ID+`","status":"completed"}`)
+
+	w = do("GET", "/webhooks/"+created.ID+"/deliveries", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Deliveries []models.WebhookDelivery `json:"deliveries"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(resp.Deliveries))
+	}
+	if resp.Deliveries[0].Event != "job.completed" || resp.Deliveries[0].JobID != job.ID {
+		t.Errorf("Unexpected delivery: %+v", resp.Deliveries[0])
+	}
+
+	// Redelivery of an unknown delivery returns 404
+	if w := do("POST", "/webhooks/deliveries/unknown/redeliver", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	// A pending delivery is not queued twice
+	delivery := resp.Deliveries[0]
+	if w := do("POST", "/webhooks/deliveries/"+delivery.ID+"/redeliver", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a pending delivery, got %d", w.Code)
+	}
+	delivery.Status = models.WebhookDeliveryFailed
+	if err := testStore.SaveWebhookDelivery(&delivery); err != nil {
+		t.Fatalf("Failed to save delivery: %v", err)
+	}
+	if w := do("POST", "/webhooks/deliveries/"+delivery.ID+"/redeliver", ""); w.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", w.Code)
+	}
+
+	// Event filters that never match are rejected
+	for _, events := range []string{`["finished"]`, `["job.done"]`, `["job."]`} {
+		if w := do("POST", "/webhooks", `{"url":"http://example.com/hook","events":`+events+`}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for events %s, got %d", events, w.Code)
+		}
+		if w := do("PUT", "/webhooks/"+created.ID, `{"events":`+events+`}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 when updating events to %s, got %d", events, w.Code)
+		}
+	}
+
+	// Delete
+	if w := do("DELETE", "/webhooks/"+created.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w := do("GET", "/webhooks/"+created.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
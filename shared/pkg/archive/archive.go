@@ -0,0 +1,168 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// Archive file formats
+const (
+	FormatJSONL   = "jsonl"   // Gzip-compressed JSON lines (one gzip member per archive run)
+	FormatParquet = "parquet" // Apache Parquet, one file per day, GZIP-compressed columns
+)
+
+// filePrefix is the name prefix of every archive file
+const filePrefix = "jobs-"
+
+// dayLayout is the date format used in archive file names
+const dayLayout = "2006-01-02"
+
+// Record is one archived job. Job.StateTransitions carries the job's lifecycle events.
+type Record struct {
+	Job        models.Job        `json:"job"`
+	Result     *models.JobResult `json:"result,omitempty"`
+	ArchivedAt time.Time         `json:"archived_at"`
+}
+
+// Day returns the archive day of a record (completion time, falling back to creation time)
+func (r *Record) Day() time.Time {
+	t := r.Job.CreatedAt
+	if r.Job.CompletedAt != nil {
+		t = *r.Job.CompletedAt
+	}
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// Config defines where and how jobs are archived
+type Config struct {
+	Dir    string
+	Format string // FormatJSONL or FormatParquet
+}
+
+// DefaultConfig returns sensible defaults for job archival
+func DefaultConfig() Config {
+	return Config{
+		Dir:    "./archive",
+		Format: FormatJSONL,
+	}
+}
+
+// Archiver writes job records to daily archive files
+type Archiver struct {
+	config Config
+	mu     sync.Mutex // Serializes writes to archive files
+}
+
+// NewArchiver creates a new archiver
+func NewArchiver(config Config) (*Archiver, error) {
+	if config.Dir == "" {
+		config.Dir = DefaultConfig().Dir
+	}
+	if config.Format == "" {
+		config.Format = FormatJSONL
+	}
+	if config.Format != FormatJSONL && config.Format != FormatParquet {
+		return nil, fmt.Errorf("unsupported archive format %q (use %s or %s)", config.Format, FormatJSONL, FormatParquet)
+	}
+	if err := os.MkdirAll(config.Dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &Archiver{config: config}, nil
+}
+
+// Archive appends records to the archive file of their day.
+// Either all records are durably written or an error is returned.
+func (a *Archiver) Archive(records []Record) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	byDay := make(map[time.Time][]Record)
+	for _, rec := range records {
+		day := rec.Day()
+		byDay[day] = append(byDay[day], rec)
+	}
+
+	for day, recs := range byDay {
+		path := filepath.Join(a.config.Dir, FileName(a.config.Format, day))
+
+		var err error
+		switch a.config.Format {
+		case FormatParquet:
+			err = appendParquet(path, recs)
+		default:
+			err = appendJSONL(path, recs)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// FileName returns the archive file name for a format and day
+func FileName(format string, day time.Time) string {
+	ext := ".jsonl.gz"
+	if format == FormatParquet {
+		ext = ".parquet"
+	}
+	return filePrefix + day.UTC().Format(dayLayout) + ext
+}
+
+// archiveFile is an archive file found in a directory
+type archiveFile struct {
+	path   string
+	format string
+	day    time.Time
+}
+
+// listFiles returns the archive files in dir sorted by day
+func listFiles(dir string) ([]archiveFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []archiveFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) {
+			continue
+		}
+
+		var format, dayStr string
+		switch {
+		case strings.HasSuffix(name, ".jsonl.gz"):
+			format, dayStr = FormatJSONL, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), ".jsonl.gz")
+		case strings.HasSuffix(name, ".parquet"):
+			format, dayStr = FormatParquet, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), ".parquet")
+		default:
+			continue
+		}
+
+		day, err := time.Parse(dayLayout, dayStr)
+		if err != nil {
+			continue
+		}
+		files = append(files, archiveFile{path: filepath.Join(dir, name), format: format, day: day})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// readFile reads all records from an archive file
+func readFile(f archiveFile) ([]Record, error) {
+	if f.format == FormatParquet {
+		return readParquet(f.path)
+	}
+	return readJSONL(f.path)
+}
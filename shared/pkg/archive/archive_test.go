@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+func archiveTestRecords() []Record {
+	day1 := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	started := day1.Add(-time.Minute)
+
+	return []Record{
+		{
+			Job: models.Job{
+				ID:             "job-1",
+				SequenceNumber: 1,
+				Scenario:       "4K60-h264",
+				Engine:         "ffmpeg",
+				Status:         models.JobStatusCompleted,
+				Queue:          "default",
+				Priority:       "high",
+				NodeID:         "node-1",
+				Parameters:     map[string]interface{}{"bitrate": "10M"},
+				CreatedAt:      started,
+				StartedAt:      &started,
+				CompletedAt:    &day1,
+				StateTransitions: []models.StateTransition{
+					{From: models.JobStatusRunning, To: models.JobStatusCompleted, Timestamp: day1, Reason: "done"},
+				},
+				Logs:        "frame=100\nfps=30",
+				PlatformSLA: true,
+			},
+			Result:     &models.JobResult{JobID: "job-1", Status: models.JobStatusCompleted, Metrics: map[string]interface{}{"fps": 30.0}},
+			ArchivedAt: day2,
+		},
+		{
+			Job: models.Job{
+				ID:            "job-2",
+				Scenario:      "1080p30-h265",
+				Status:        models.JobStatusFailed,
+				Error:         "encoder crashed",
+				FailureReason: models.FailureReasonRuntimeError,
+				CreatedAt:     day2,
+				CompletedAt:   &day2,
+			},
+			ArchivedAt: day2,
+		},
+	}
+}
+
+// TestParquetRoundTrip verifies records survive a Parquet write/read cycle
+func TestParquetRoundTrip(t *testing.T) {
+	path := t.TempDir() + "/jobs.parquet"
+	records := archiveTestRecords()
+
+	if err := appendParquet(path, records[:1]); err != nil {
+		t.Fatalf("appendParquet failed: %v", err)
+	}
+	if err := appendParquet(path, records[1:]); err != nil {
+		t.Fatalf("appendParquet failed: %v", err)
+	}
+
+	got, err := readParquet(path)
+	if err != nil {
+		t.Fatalf("readParquet failed: %v", err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("Expected %d records, got %d", len(records), len(got))
+	}
+	for i := range records {
+		if !reflect.DeepEqual(got[i], records[i]) {
+			t.Errorf("Record %d mismatch:\n got  %+v\n want %+v", i, got[i], records[i])
+		}
+	}
+}
+
+// TestArchiveAndSearch verifies daily files are written and can be searched in both formats
+func TestArchiveAndSearch(t *testing.T) {
+	for _, format := range []string{FormatJSONL, FormatParquet} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			a, err := NewArchiver(Config{Dir: dir, Format: format})
+			if err != nil {
+				t.Fatalf("NewArchiver failed: %v", err)
+			}
+
+			records := archiveTestRecords()
+			if err := a.Archive(records); err != nil {
+				t.Fatalf("Archive failed: %v", err)
+			}
+			// A second run appends to the existing daily file
+			if err := a.Archive(records[:1]); err != nil {
+				t.Fatalf("Archive failed: %v", err)
+			}
+
+			files, err := listFiles(dir)
+			if err != nil || len(files) != 2 {
+				t.Fatalf("Expected 2 daily files, got %d (%v)", len(files), err)
+			}
+
+			all, err := Search(dir, Query{})
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(all) != 3 {
+				t.Errorf("Expected 3 records, got %d", len(all))
+			}
+
+			failed, _ := Search(dir, Query{Status: "failed", Contains: "CRASHED"})
+			if len(failed) != 1 || failed[0].Job.ID != "job-2" {
+				t.Errorf("Expected job-2 for failed query, got %+v", failed)
+			}
+
+			since, _ := Search(dir, Query{Since: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)})
+			if len(since) != 1 || since[0].Job.ID != "job-2" {
+				t.Errorf("Expected only job-2 since Jan 3, got %d records", len(since))
+			}
+
+			limited, _ := Search(dir, Query{Limit: 1})
+			if len(limited) != 1 {
+				t.Errorf("Expected limit to cap results, got %d", len(limited))
+			}
+		})
+	}
+
+	if _, err := NewArchiver(Config{Dir: t.TempDir(), Format: "csv"}); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
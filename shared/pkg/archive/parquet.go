@@ -0,0 +1,566 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// This file implements the subset of Apache Parquet needed for job archives:
+// a flat schema of OPTIONAL columns, one row group per file, one PLAIN-encoded
+// v1 data page per column and GZIP compression. Files are readable by standard
+// Parquet tools (DuckDB, Spark, pandas/pyarrow).
+
+var parquetMagic = []byte("PAR1")
+
+// Parquet physical types, converted types, codecs and encodings used by the writer
+const (
+	parquetInt64     int32 = 2
+	parquetByteArray int32 = 6
+
+	parquetUTF8            int32 = 0
+	parquetTimestampMicros int32 = 10
+	parquetJSON            int32 = 19
+	parquetNoConverted     int32 = -1
+
+	parquetOptional int32 = 1
+
+	parquetCodecUncompressed int32 = 0
+	parquetCodecGzip         int32 = 2
+
+	parquetEncodingPlain int32 = 0
+	parquetEncodingRLE   int32 = 3
+
+	parquetDataPage int32 = 0
+)
+
+// parquetColumn maps one archive column to a Record field.
+// get returns a string or int64 value, or nil for NULL.
+type parquetColumn struct {
+	name      string
+	physical  int32
+	converted int32
+	get       func(r *Record) interface{}
+	set       func(r *Record, v interface{}) error
+}
+
+// parquetColumns is the archive schema. Columns may be added over time; readers ignore unknown columns.
+var parquetColumns = []parquetColumn{
+	stringColumn("job_id", func(r *Record) *string { return &r.Job.ID }),
+	intColumn("sequence_number", func(r *Record) *int { return &r.Job.SequenceNumber }),
+	stringColumn("tenant_id", func(r *Record) *string { return &r.Job.TenantID }),
+	stringColumn("user_id", func(r *Record) *string { return &r.Job.UserID }),
+	stringColumn("scenario", func(r *Record) *string { return &r.Job.Scenario }),
+	stringColumn("confidence", func(r *Record) *string { return &r.Job.Confidence }),
+	stringColumn("engine", func(r *Record) *string { return &r.Job.Engine }),
+	stringColumn("classification", func(r *Record) *string { return (*string)(&r.Job.Classification) }),
+	stringColumn("queue", func(r *Record) *string { return &r.Job.Queue }),
+	stringColumn("priority", func(r *Record) *string { return &r.Job.Priority }),
+	stringColumn("status", func(r *Record) *string { return (*string)(&r.Job.Status) }),
+	intColumn("progress", func(r *Record) *int { return &r.Job.Progress }),
+	stringColumn("node_id", func(r *Record) *string { return &r.Job.NodeID }),
+	timeColumn("created_at", func(r *Record) *time.Time { return &r.Job.CreatedAt }),
+	optionalTimeColumn("started_at", func(r *Record) **time.Time { return &r.Job.StartedAt }),
+	optionalTimeColumn("last_activity_at", func(r *Record) **time.Time { return &r.Job.LastActivityAt }),
+	optionalTimeColumn("completed_at", func(r *Record) **time.Time { return &r.Job.CompletedAt }),
+	optionalTimeColumn("timeout_at", func(r *Record) **time.Time { return &r.Job.TimeoutAt }),
+	intColumn("retry_count", func(r *Record) *int { return &r.Job.RetryCount }),
+	intColumn("max_retries", func(r *Record) *int { return &r.Job.MaxRetries }),
+	stringColumn("retry_reason", func(r *Record) *string { return &r.Job.RetryReason }),
+	stringColumn("error", func(r *Record) *string { return &r.Job.Error }),
+	stringColumn("failure_reason", func(r *Record) *string { return (*string)(&r.Job.FailureReason) }),
+	boolColumn("wrapper_enabled", func(r *Record) *bool { return &r.Job.WrapperEnabled }),
+	jsonColumn("wrapper_constraints", func(r *Record) interface{} { return &r.Job.WrapperConstraints }),
+	boolColumn("platform_sla_compliant", func(r *Record) *bool { return &r.Job.PlatformSLA }),
+	stringColumn("platform_sla_reason", func(r *Record) *string { return &r.Job.PlatformSLAReason }),
+	jsonColumn("parameters", func(r *Record) interface{} { return &r.Job.Parameters }),
+	jsonColumn("state_transitions", func(r *Record) interface{} { return &r.Job.StateTransitions }),
+	stringColumn("logs", func(r *Record) *string { return &r.Job.Logs }),
+	jsonColumn("result", func(r *Record) interface{} { return &r.Result }),
+	timeColumn("archived_at", func(r *Record) *time.Time { return &r.ArchivedAt }),
+}
+
+func stringColumn(name string, field func(r *Record) *string) parquetColumn {
+	return parquetColumn{
+		name: name, physical: parquetByteArray, converted: parquetUTF8,
+		get: func(r *Record) interface{} {
+			if v := *field(r); v != "" {
+				return v
+			}
+			return nil
+		},
+		set: func(r *Record, v interface{}) error {
+			*field(r) = v.(string)
+			return nil
+		},
+	}
+}
+
+func intColumn(name string, field func(r *Record) *int) parquetColumn {
+	return parquetColumn{
+		name: name, physical: parquetInt64, converted: parquetNoConverted,
+		get: func(r *Record) interface{} { return int64(*field(r)) },
+		set: func(r *Record, v interface{}) error {
+			*field(r) = int(v.(int64))
+			return nil
+		},
+	}
+}
+
+func boolColumn(name string, field func(r *Record) *bool) parquetColumn {
+	return parquetColumn{
+		name: name, physical: parquetInt64, converted: parquetNoConverted,
+		get: func(r *Record) interface{} {
+			if *field(r) {
+				return int64(1)
+			}
+			return int64(0)
+		},
+		set: func(r *Record, v interface{}) error {
+			*field(r) = v.(int64) != 0
+			return nil
+		},
+	}
+}
+
+func timeColumn(name string, field func(r *Record) *time.Time) parquetColumn {
+	return parquetColumn{
+		name: name, physical: parquetInt64, converted: parquetTimestampMicros,
+		get: func(r *Record) interface{} {
+			if t := *field(r); !t.IsZero() {
+				return t.UnixMicro()
+			}
+			return nil
+		},
+		set: func(r *Record, v interface{}) error {
+			*field(r) = time.UnixMicro(v.(int64)).UTC()
+			return nil
+		},
+	}
+}
+
+func optionalTimeColumn(name string, field func(r *Record) **time.Time) parquetColumn {
+	return parquetColumn{
+		name: name, physical: parquetInt64, converted: parquetTimestampMicros,
+		get: func(r *Record) interface{} {
+			if t := *field(r); t != nil {
+				return t.UnixMicro()
+			}
+			return nil
+		},
+		set: func(r *Record, v interface{}) error {
+			t := time.UnixMicro(v.(int64)).UTC()
+			*field(r) = &t
+			return nil
+		},
+	}
+}
+
+func jsonColumn(name string, field func(r *Record) interface{}) parquetColumn {
+	return parquetColumn{
+		name: name, physical: parquetByteArray, converted: parquetJSON,
+		get: func(r *Record) interface{} {
+			data, err := json.Marshal(field(r))
+			if err != nil || string(data) == "null" {
+				return nil
+			}
+			return string(data)
+		},
+		set: func(r *Record, v interface{}) error {
+			return json.Unmarshal([]byte(v.(string)), field(r))
+		},
+	}
+}
+
+// appendParquet adds records to a daily Parquet file by rewriting it atomically
+func appendParquet(path string, records []Record) error {
+	existing, err := readParquet(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read existing archive: %w", err)
+	}
+	all := append(existing, records...)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if err := writeParquet(tmp, all); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0640); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// writeParquet writes records as a single row group
+func writeParquet(w io.Writer, records []Record) error {
+	var out bytes.Buffer
+	out.Write(parquetMagic)
+
+	chunks := make([]interface{}, 0, len(parquetColumns))
+	var totalSize int64
+
+	for _, col := range parquetColumns {
+		page, numValues, err := encodeColumnPage(col, records)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col.name, err)
+		}
+
+		compressed, err := gzipBytes(page)
+		if err != nil {
+			return err
+		}
+
+		header := encodeThrift(tStruct{
+			{1, parquetDataPage},
+			{2, int32(len(page))},
+			{3, int32(len(compressed))},
+			{5, tStruct{
+				{1, int32(numValues)},
+				{2, parquetEncodingPlain},
+				{3, parquetEncodingRLE},
+				{4, parquetEncodingRLE},
+			}},
+		})
+
+		offset := int64(out.Len())
+		out.Write(header)
+		out.Write(compressed)
+
+		uncompressedSize := int64(len(header) + len(page))
+		totalSize += uncompressedSize
+
+		chunks = append(chunks, tStruct{
+			{2, offset},
+			{3, tStruct{
+				{1, col.physical},
+				{2, tList{tTypeI32, []interface{}{parquetEncodingPlain, parquetEncodingRLE}}},
+				{3, tList{tTypeBinary, []interface{}{col.name}}},
+				{4, parquetCodecGzip},
+				{5, int64(numValues)},
+				{6, uncompressedSize},
+				{7, int64(len(header) + len(compressed))},
+				{9, offset},
+			}},
+		})
+	}
+
+	schema := []interface{}{
+		tStruct{{4, "schema"}, {5, int32(len(parquetColumns))}},
+	}
+	for _, col := range parquetColumns {
+		element := tStruct{{1, col.physical}, {3, parquetOptional}, {4, col.name}}
+		if col.converted != parquetNoConverted {
+			element = append(element, tField{6, col.converted})
+		}
+		schema = append(schema, element)
+	}
+
+	footer := encodeThrift(tStruct{
+		{1, int32(1)},
+		{2, tList{tTypeStruct, schema}},
+		{3, int64(len(records))},
+		{4, tList{tTypeStruct, []interface{}{
+			tStruct{
+				{1, tList{tTypeStruct, chunks}},
+				{2, totalSize},
+				{3, int64(len(records))},
+			},
+		}}},
+		{6, "ffrtmp job archive"},
+	})
+
+	out.Write(footer)
+	binary.Write(&out, binary.LittleEndian, uint32(len(footer)))
+	out.Write(parquetMagic)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// encodeColumnPage returns the uncompressed data page body for one column:
+// RLE/bit-packed definition levels followed by PLAIN-encoded non-null values
+func encodeColumnPage(col parquetColumn, records []Record) ([]byte, int, error) {
+	defined := make([]bool, len(records))
+	var values bytes.Buffer
+
+	for i := range records {
+		v := col.get(&records[i])
+		if v == nil {
+			continue
+		}
+		defined[i] = true
+
+		switch v := v.(type) {
+		case int64:
+			binary.Write(&values, binary.LittleEndian, v)
+		case string:
+			binary.Write(&values, binary.LittleEndian, uint32(len(v)))
+			values.WriteString(v)
+		default:
+			return nil, 0, fmt.Errorf("unsupported value %T", v)
+		}
+	}
+
+	levels := encodeDefinitionLevels(defined)
+
+	var page bytes.Buffer
+	binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+	page.Write(levels)
+	page.Write(values.Bytes())
+	return page.Bytes(), len(records), nil
+}
+
+// encodeDefinitionLevels encodes 1-bit definition levels as a single bit-packed run
+func encodeDefinitionLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+	buf := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, d := range defined {
+		if d {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return append(buf, packed...)
+}
+
+// decodeDefinitionLevels decodes n 1-bit definition levels from the RLE/bit-packed hybrid encoding
+func decodeDefinitionLevels(data []byte, n int) ([]bool, error) {
+	levels := make([]bool, 0, n)
+	pos := 0
+	for len(levels) < n {
+		header, size := binary.Uvarint(data[pos:])
+		if size <= 0 {
+			return nil, errors.New("truncated definition levels")
+		}
+		pos += size
+
+		if header&1 == 1 {
+			// Bit-packed run of header>>1 groups of 8 values
+			count := int(header>>1) * 8
+			if pos+count/8 > len(data) {
+				return nil, errors.New("truncated definition levels")
+			}
+			for i := 0; i < count && len(levels) < n; i++ {
+				levels = append(levels, data[pos+i/8]&(1<<(i%8)) != 0)
+			}
+			pos += count / 8
+		} else {
+			// RLE run of header>>1 repeated values
+			if pos >= len(data) {
+				return nil, errors.New("truncated definition levels")
+			}
+			value := data[pos] != 0
+			pos++
+			for i := 0; i < int(header>>1) && len(levels) < n; i++ {
+				levels = append(levels, value)
+			}
+		}
+	}
+	return levels, nil
+}
+
+// readParquet reads all records from a Parquet archive file
+func readParquet(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseParquet(data)
+}
+
+// parseParquet decodes a Parquet file written by writeParquet
+func parseParquet(data []byte) ([]Record, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], parquetMagic) || !bytes.Equal(data[len(data)-4:], parquetMagic) {
+		return nil, errors.New("not a parquet file")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen > len(data)-12 {
+		return nil, errors.New("invalid parquet footer length")
+	}
+	meta, _, err := decodeThrift(data[len(data)-8-footerLen : len(data)-8])
+	if err != nil {
+		return nil, fmt.Errorf("invalid parquet footer: %w", err)
+	}
+
+	columns := make(map[string]parquetColumn, len(parquetColumns))
+	for _, col := range parquetColumns {
+		columns[col.name] = col
+	}
+
+	var records []Record
+	for _, rg := range thriftList(meta, 4) {
+		rowGroup, _ := rg.(map[int16]interface{})
+		numRows := int(thriftInt(rowGroup, 3))
+		rows := make([]Record, numRows)
+
+		for _, cc := range thriftList(rowGroup, 1) {
+			chunk, _ := cc.(map[int16]interface{})
+			colMeta := thriftStruct(chunk, 3)
+			path := thriftList(colMeta, 3)
+			if len(path) != 1 {
+				continue
+			}
+			name, _ := path[0].([]byte)
+			col, ok := columns[string(name)]
+			if !ok {
+				continue // Column from a newer schema
+			}
+
+			values, err := readColumnChunk(data, colMeta, numRows)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", name, err)
+			}
+			for i, v := range values {
+				if v == nil {
+					continue
+				}
+				if err := col.set(&rows[i], v); err != nil {
+					return nil, fmt.Errorf("column %s row %d: %w", name, i, err)
+				}
+			}
+		}
+		records = append(records, rows...)
+	}
+	return records, nil
+}
+
+// readColumnChunk decodes the values of one column chunk (nil entries are NULL)
+func readColumnChunk(data []byte, colMeta map[int16]interface{}, numRows int) ([]interface{}, error) {
+	physical := int32(thriftInt(colMeta, 1))
+	codec := int32(thriftInt(colMeta, 4))
+	offset := int(thriftInt(colMeta, 9))
+
+	values := make([]interface{}, 0, numRows)
+	for len(values) < numRows {
+		if offset <= 0 || offset >= len(data) {
+			return nil, errors.New("invalid page offset")
+		}
+		header, size, err := decodeThrift(data[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid page header: %w", err)
+		}
+		offset += size
+
+		compressedSize := int(thriftInt(header, 3))
+		if offset+compressedSize > len(data) {
+			return nil, errors.New("truncated page")
+		}
+		body := data[offset : offset+compressedSize]
+		offset += compressedSize
+
+		if int32(thriftInt(header, 1)) != parquetDataPage {
+			return nil, errors.New("unsupported page type")
+		}
+		pageHeader := thriftStruct(header, 5)
+		if int32(thriftInt(pageHeader, 2)) != parquetEncodingPlain {
+			return nil, errors.New("unsupported encoding")
+		}
+		numValues := int(thriftInt(pageHeader, 1))
+
+		switch codec {
+		case parquetCodecGzip:
+			if body, err = gunzipBytes(body); err != nil {
+				return nil, err
+			}
+		case parquetCodecUncompressed:
+		default:
+			return nil, fmt.Errorf("unsupported codec %d", codec)
+		}
+
+		pageValues, err := decodePage(body, physical, numValues)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, pageValues...)
+	}
+	return values, nil
+}
+
+// decodePage decodes a PLAIN data page with 1-bit definition levels
+func decodePage(body []byte, physical int32, numValues int) ([]interface{}, error) {
+	if len(body) < 4 {
+		return nil, errors.New("truncated page")
+	}
+	levelsLen := int(binary.LittleEndian.Uint32(body))
+	if 4+levelsLen > len(body) {
+		return nil, errors.New("truncated definition levels")
+	}
+	defined, err := decodeDefinitionLevels(body[4:4+levelsLen], numValues)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(body[4+levelsLen:])
+	values := make([]interface{}, numValues)
+	for i, d := range defined {
+		if !d {
+			continue
+		}
+		switch physical {
+		case parquetInt64:
+			var v int64
+			if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
+				return nil, err
+			}
+			values[i] = v
+		case parquetByteArray:
+			var n uint32
+			if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+				return nil, err
+			}
+			if int(n) > r.Len() {
+				return nil, errors.New("truncated value")
+			}
+			b := make([]byte, n)
+			r.Read(b)
+			values[i] = string(b)
+		default:
+			return nil, fmt.Errorf("unsupported physical type %d", physical)
+		}
+	}
+	return values, nil
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
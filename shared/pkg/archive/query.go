@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Query filters archived jobs. Zero values match everything.
+type Query struct {
+	JobID         string // Job ID or prefix
+	Status        string
+	Scenario      string
+	NodeID        string
+	TenantID      string
+	FailureReason string
+	Since         time.Time // Archive day (completion time) on or after
+	Until         time.Time // Archive day (completion time) before
+	Contains      string    // Case-insensitive substring of error, logs or parameters
+	Limit         int
+}
+
+// Matches reports whether a record satisfies the query
+func (q *Query) Matches(rec *Record) bool {
+	job := &rec.Job
+	if q.JobID != "" && !strings.HasPrefix(job.ID, q.JobID) {
+		return false
+	}
+	if q.Status != "" && string(job.Status) != q.Status {
+		return false
+	}
+	if q.Scenario != "" && job.Scenario != q.Scenario {
+		return false
+	}
+	if q.NodeID != "" && job.NodeID != q.NodeID {
+		return false
+	}
+	if q.TenantID != "" && job.TenantID != q.TenantID {
+		return false
+	}
+	if q.FailureReason != "" && string(job.FailureReason) != q.FailureReason {
+		return false
+	}
+
+	completed := job.CreatedAt
+	if job.CompletedAt != nil {
+		completed = *job.CompletedAt
+	}
+	if !q.Since.IsZero() && completed.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !completed.Before(q.Until) {
+		return false
+	}
+
+	if q.Contains != "" {
+		needle := strings.ToLower(q.Contains)
+		haystack := strings.ToLower(job.Error + "\n" + job.Logs + "\n" + fmt.Sprint(job.Parameters))
+		if !strings.Contains(haystack, needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// Search scans the archive files in dir and returns matching records in archive order
+func Search(dir string, q Query) ([]Record, error) {
+	files, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Record, 0)
+	for _, f := range files {
+		// Skip whole days outside the requested range
+		if !q.Since.IsZero() && f.day.Add(24*time.Hour).Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && !f.day.Before(q.Until) {
+			continue
+		}
+
+		records, err := readFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+		}
+		for i := range records {
+			if !q.Matches(&records[i]) {
+				continue
+			}
+			results = append(results, records[i])
+			if q.Limit > 0 && len(results) >= q.Limit {
+				return results, nil
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,294 @@
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Minimal Thrift compact protocol encoder/decoder for Parquet file metadata.
+// Values are represented generically: tStruct for structs, tList for lists,
+// int32/int64 for integers and string/[]byte for binary.
+
+// Thrift compact protocol type IDs
+const (
+	tTypeBoolTrue  = 1
+	tTypeBoolFalse = 2
+	tTypeByte      = 3
+	tTypeI16       = 4
+	tTypeI32       = 5
+	tTypeI64       = 6
+	tTypeDouble    = 7
+	tTypeBinary    = 8
+	tTypeList      = 9
+	tTypeSet       = 10
+	tTypeMap       = 11
+	tTypeStruct    = 12
+)
+
+var errThriftTruncated = errors.New("truncated thrift data")
+
+// tField is one field of a struct being encoded
+type tField struct {
+	id    int16
+	value interface{}
+}
+
+// tStruct is a struct being encoded; fields must be in ascending id order
+type tStruct []tField
+
+// tList is a list being encoded
+type tList struct {
+	elemType byte
+	items    []interface{}
+}
+
+// encodeThrift encodes a struct using the compact protocol
+func encodeThrift(s tStruct) []byte {
+	var buf []byte
+	return appendStruct(buf, s)
+}
+
+func appendStruct(buf []byte, s tStruct) []byte {
+	var last int16
+	for _, f := range s {
+		typ := thriftType(f.value)
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf = append(buf, byte(delta)<<4|typ)
+		} else {
+			buf = append(buf, typ)
+			buf = appendVarint(buf, zigzag(int64(f.id)))
+		}
+		buf = appendValue(buf, f.value)
+		last = f.id
+	}
+	return append(buf, 0) // stop
+}
+
+func appendValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case int32:
+		return appendVarint(buf, zigzag(int64(v)))
+	case int64:
+		return appendVarint(buf, zigzag(v))
+	case string:
+		buf = appendVarint(buf, uint64(len(v)))
+		return append(buf, v...)
+	case []byte:
+		buf = appendVarint(buf, uint64(len(v)))
+		return append(buf, v...)
+	case tStruct:
+		return appendStruct(buf, v)
+	case tList:
+		if n := len(v.items); n < 15 {
+			buf = append(buf, byte(n)<<4|v.elemType)
+		} else {
+			buf = append(buf, 0xF0|v.elemType)
+			buf = appendVarint(buf, uint64(n))
+		}
+		for _, item := range v.items {
+			buf = appendValue(buf, item)
+		}
+		return buf
+	}
+	panic(fmt.Sprintf("archive: unsupported thrift value %T", v))
+}
+
+func thriftType(v interface{}) byte {
+	switch v.(type) {
+	case int32:
+		return tTypeI32
+	case int64:
+		return tTypeI64
+	case string, []byte:
+		return tTypeBinary
+	case tStruct:
+		return tTypeStruct
+	case tList:
+		return tTypeList
+	}
+	panic(fmt.Sprintf("archive: unsupported thrift value %T", v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	return binary.AppendUvarint(buf, v)
+}
+
+// thriftDecoder decodes compact protocol data into generic values:
+// structs become map[int16]interface{}, lists []interface{}, integers int64 and binary []byte.
+type thriftDecoder struct {
+	data []byte
+	pos  int
+}
+
+// decodeThrift decodes a struct and returns it with the number of bytes consumed
+func decodeThrift(data []byte) (map[int16]interface{}, int, error) {
+	d := &thriftDecoder{data: data}
+	s, err := d.readStruct()
+	return s, d.pos, err
+}
+
+func (d *thriftDecoder) readByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errThriftTruncated
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *thriftDecoder) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *thriftDecoder) readZigzag() (int64, error) {
+	v, err := d.readVarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (d *thriftDecoder) readStruct() (map[int16]interface{}, error) {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+
+		typ := header & 0x0F
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := d.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+
+		var value interface{}
+		switch typ {
+		case tTypeBoolTrue:
+			value = true
+		case tTypeBoolFalse:
+			value = false
+		default:
+			if value, err = d.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+		fields[id] = value
+		last = id
+	}
+}
+
+func (d *thriftDecoder) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case tTypeBoolTrue, tTypeBoolFalse:
+		// Bools inside lists carry a value byte
+		b, err := d.readByte()
+		return b == tTypeBoolTrue, err
+	case tTypeByte:
+		b, err := d.readByte()
+		return int64(int8(b)), err
+	case tTypeI16, tTypeI32, tTypeI64:
+		return d.readZigzag()
+	case tTypeDouble:
+		if d.pos+8 > len(d.data) {
+			return nil, errThriftTruncated
+		}
+		v := binary.LittleEndian.Uint64(d.data[d.pos:])
+		d.pos += 8
+		return v, nil
+	case tTypeBinary:
+		n, err := d.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(d.data)-d.pos) < n {
+			return nil, errThriftTruncated
+		}
+		b := d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return b, nil
+	case tTypeList, tTypeSet:
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(header >> 4)
+		if n == 15 {
+			if n, err = d.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+		items := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			item, err := d.readValue(header & 0x0F)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case tTypeMap:
+		n, err := d.readVarint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		types, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := d.readValue(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := d.readValue(types & 0x0F); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case tTypeStruct:
+		return d.readStruct()
+	}
+	return nil, fmt.Errorf("unsupported thrift type %d", typ)
+}
+
+// thriftInt returns an integer field of a decoded struct
+func thriftInt(s map[int16]interface{}, id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+// thriftString returns a binary field of a decoded struct as a string
+func thriftString(s map[int16]interface{}, id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+// thriftStruct returns a struct field of a decoded struct
+func thriftStruct(s map[int16]interface{}, id int16) map[int16]interface{} {
+	v, _ := s[id].(map[int16]interface{})
+	return v
+}
+
+// thriftList returns a list field of a decoded struct
+func thriftList(s map[int16]interface{}, id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
@@ -2,14 +2,16 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"sync"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 )
 
 var (
@@ -17,28 +19,44 @@ var (
 	ErrTokenExpired = errors.New("token expired")
 )
 
-// TokenManager manages API authentication tokens
-type TokenManager struct {
-	tokens map[string]*TokenInfo
-	mu     sync.RWMutex
+// NodeTokenPrefix starts every node identity token
+const NodeTokenPrefix = "ffnode_"
+
+// NodeTokenHeader carries a node's identity token on worker requests
+const NodeTokenHeader = "X-Node-Token"
+
+// TokenStore persists node tokens so that they survive master restarts
+// and are shared between masters
+type TokenStore interface {
+	SaveNodeToken(token *models.NodeToken) error
+	GetNodeToken(nodeID string) (*models.NodeToken, error)
+	DeleteNodeToken(nodeID string) error
 }
 
-// TokenInfo contains token metadata
-type TokenInfo struct {
-	Hash      string
-	NodeID    string
-	CreatedAt time.Time
-	ExpiresAt time.Time
+// TokenManager issues and validates per-node identity tokens
+type TokenManager struct {
+	tokens map[string]*models.NodeToken // Keyed by node ID
+	store  TokenStore
+	mu     sync.RWMutex
 }
 
-// NewTokenManager creates a new token manager
+// NewTokenManager creates a new token manager that keeps tokens in memory
 func NewTokenManager() *TokenManager {
 	return &TokenManager{
-		tokens: make(map[string]*TokenInfo),
+		tokens: make(map[string]*models.NodeToken),
 	}
 }
 
-// GenerateToken generates a new authentication token
+// NewPersistentTokenManager creates a token manager that persists tokens in s.
+// Tokens are cached in memory and loaded from s on a cache miss.
+func NewPersistentTokenManager(s TokenStore) *TokenManager {
+	tm := NewTokenManager()
+	tm.store = s
+	return tm
+}
+
+// GenerateToken generates a new token for a node, replacing any previous one.
+// A zero duration issues a token that never expires.
 func (tm *TokenManager) GenerateToken(nodeID string, duration time.Duration) (string, error) {
 	// Generate random token
 	tokenBytes := make([]byte, 32)
@@ -46,72 +64,106 @@ func (tm *TokenManager) GenerateToken(nodeID string, duration time.Duration) (st
 		return "", fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	token := base64.URLEncoding.EncodeToString(tokenBytes)
+	token := NodeTokenPrefix + hex.EncodeToString(tokenBytes)
 
-	// Hash token for storage
-	hash, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash token: %w", err)
+	info := &models.NodeToken{
+		NodeID:    nodeID,
+		TokenHash: hashNodeToken(token),
+		CreatedAt: time.Now(),
+	}
+	if duration > 0 {
+		expiresAt := info.CreatedAt.Add(duration)
+		info.ExpiresAt = &expiresAt
 	}
 
-	// Store token info
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	tm.tokens[nodeID] = &TokenInfo{
-		Hash:      string(hash),
-		NodeID:    nodeID,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(duration),
+	if tm.store != nil {
+		if err := tm.store.SaveNodeToken(info); err != nil {
+			return "", fmt.Errorf("failed to store token: %w", err)
+		}
 	}
+	tm.tokens[nodeID] = info
 
 	return token, nil
 }
 
-// ValidateToken validates an authentication token
+// ValidateToken validates the token presented by a node
 func (tm *TokenManager) ValidateToken(nodeID, token string) error {
-	tm.mu.RLock()
-	defer tm.mu.RUnlock()
+	hash := hashNodeToken(token)
 
-	tokenInfo, ok := tm.tokens[nodeID]
-	if !ok {
-		return ErrInvalidToken
+	tm.mu.RLock()
+	info, ok := tm.tokens[nodeID]
+	tm.mu.RUnlock()
+
+	if !ok || !SecureCompare(info.TokenHash, hash) {
+		// The token may have been issued after it was cached, e.g. by another master
+		var err error
+		if info, err = tm.load(nodeID); err != nil {
+			return err
+		}
+		if !SecureCompare(info.TokenHash, hash) {
+			return ErrInvalidToken
+		}
 	}
 
 	// Check expiration
-	if time.Now().After(tokenInfo.ExpiresAt) {
+	if info.IsExpired() {
 		return ErrTokenExpired
 	}
 
-	// Validate token hash
-	if err := bcrypt.CompareHashAndPassword([]byte(tokenInfo.Hash), []byte(token)); err != nil {
-		return ErrInvalidToken
+	return nil
+}
+
+// load reads a node's token from the store into the cache
+func (tm *TokenManager) load(nodeID string) (*models.NodeToken, error) {
+	if tm.store == nil {
+		return nil, ErrInvalidToken
+	}
+	info, err := tm.store.GetNodeToken(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
-	return nil
+	tm.mu.Lock()
+	tm.tokens[nodeID] = info
+	tm.mu.Unlock()
+	return info, nil
 }
 
-// RevokeToken revokes a token for a node
-func (tm *TokenManager) RevokeToken(nodeID string) {
+// RevokeToken revokes the token of a node
+func (tm *TokenManager) RevokeToken(nodeID string) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
 	delete(tm.tokens, nodeID)
+	if tm.store != nil {
+		return tm.store.DeleteNodeToken(nodeID)
+	}
+	return nil
 }
 
-// CleanupExpiredTokens removes expired tokens
+// CleanupExpiredTokens removes expired tokens from the cache
 func (tm *TokenManager) CleanupExpiredTokens() {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	now := time.Now()
 	for nodeID, tokenInfo := range tm.tokens {
-		if now.After(tokenInfo.ExpiresAt) {
+		if tokenInfo.IsExpired() {
 			delete(tm.tokens, nodeID)
 		}
 	}
 }
 
+// hashNodeToken returns the stored hash of a node token.
+// Node tokens are random and checked on every heartbeat and job poll,
+// so a fast hash is sufficient.
+func hashNodeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // APIKeyManager manages API keys for authentication
 type APIKeyManager struct {
 	keys map[string]string // key -> description
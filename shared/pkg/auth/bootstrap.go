@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// BootstrapTokenPrefix starts every worker enrollment bootstrap token
+const BootstrapTokenPrefix = "ffboot_"
+
+// GenerateBootstrapToken generates a new one-time enrollment token.
+// It returns the token and the hash to store; the token itself is never stored.
+func GenerateBootstrapToken() (token, hash string, err error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate bootstrap token: %w", err)
+	}
+	token = BootstrapTokenPrefix + hex.EncodeToString(tokenBytes)
+	return token, HashBootstrapToken(token), nil
+}
+
+// HashBootstrapToken returns the stored hash of a bootstrap token.
+// Bootstrap tokens are random, so a fast hash is sufficient.
+func HashBootstrapToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsBootstrapToken reports whether token has the bootstrap token format
+func IsBootstrapToken(token string) bool {
+	return strings.HasPrefix(token, BootstrapTokenPrefix) && len(token) > len(BootstrapTokenPrefix)
+}
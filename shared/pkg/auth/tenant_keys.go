@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TenantKeyPrefix starts every tenant API key (ffrtmp_<tenant>_<secret>)
+const TenantKeyPrefix = "ffrtmp_"
+
+// tenantKeyIDLength is the number of secret characters kept in the key prefix
+const tenantKeyIDLength = 8
+
+// GenerateTenantAPIKey generates a new API key for the named tenant.
+// It returns the key, the non-secret prefix used to look the key up and the
+// bcrypt hash to store. The key itself is never stored. Only the secret part
+// is hashed, since bcrypt ignores input beyond 72 bytes.
+func GenerateTenantAPIKey(tenantName string) (key, prefix, hash string, err error) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key = TenantKeyPrefix + tenantName + "_" + hex.EncodeToString(secretBytes)
+
+	hashBytes, err := bcrypt.GenerateFromPassword([]byte(tenantKeySecret(key)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to hash API key: %w", err)
+	}
+
+	_, prefix, _ = ParseTenantAPIKey(key)
+	return key, prefix, string(hashBytes), nil
+}
+
+// IsTenantAPIKey reports whether key has the tenant API key format
+func IsTenantAPIKey(key string) bool {
+	_, _, ok := ParseTenantAPIKey(key)
+	return ok
+}
+
+// ParseTenantAPIKey splits a tenant API key into its tenant name and lookup prefix
+func ParseTenantAPIKey(key string) (tenantName, prefix string, ok bool) {
+	if !strings.HasPrefix(key, TenantKeyPrefix) {
+		return "", "", false
+	}
+	rest := key[len(TenantKeyPrefix):]
+	idx := strings.Index(rest, "_")
+	if idx <= 0 || len(rest)-idx-1 < tenantKeyIDLength {
+		return "", "", false
+	}
+	tenantName = rest[:idx]
+	prefix = key[:len(TenantKeyPrefix)+idx+1+tenantKeyIDLength]
+	return tenantName, prefix, true
+}
+
+// VerifyTenantAPIKey checks key against its stored bcrypt hash
+func VerifyTenantAPIKey(hash, key string) bool {
+	if !IsTenantAPIKey(key) {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(tenantKeySecret(key))) == nil
+}
+
+// tenantKeySecret returns the random part of a tenant API key
+func tenantKeySecret(key string) string {
+	rest := key[len(TenantKeyPrefix):]
+	return rest[strings.Index(rest, "_")+1:]
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SessionTokenPrefix starts every user session token
+const SessionTokenPrefix = "ffsess_"
+
+// MaxPasswordLength is the longest password bcrypt can hash
+const MaxPasswordLength = 72
+
+// MinPasswordLength is the shortest password accepted for user accounts
+const MinPasswordLength = 8
+
+var ErrInvalidPassword = fmt.Errorf("password must be between %d and %d bytes", MinPasswordLength, MaxPasswordLength)
+
+// dummyPasswordHash is compared against when a login names an unknown user,
+// so that failed logins take the same time whether or not the user exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("ffrtmp-dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of a user password
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return "", ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches its bcrypt hash.
+// An empty hash (unknown user) is checked against a dummy hash and never matches.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// GenerateSessionToken generates a new session token.
+// It returns the token and the hash to store; the token itself is never stored.
+func GenerateSessionToken() (token, hash string, err error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token = SessionTokenPrefix + hex.EncodeToString(tokenBytes)
+	return token, HashSessionToken(token), nil
+}
+
+// HashSessionToken returns the stored hash of a session token.
+// Session tokens are random, so a fast hash is sufficient.
+func HashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsSessionToken reports whether token has the session token format
+func IsSessionToken(token string) bool {
+	return strings.HasPrefix(token, SessionTokenPrefix) && len(token) > len(SessionTokenPrefix)
+}
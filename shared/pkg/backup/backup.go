@@ -0,0 +1,258 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// filePrefix is the name prefix of every backup file written by the manager
+const filePrefix = "ffrtmp-backup-"
+
+// Config defines the backup schedule and retention policy
+type Config struct {
+	Enabled   bool
+	Dir       string        // Directory backups are written to
+	Interval  time.Duration // Time between scheduled backups
+	Retention int           // Number of backups to keep (oldest are deleted)
+}
+
+// DefaultConfig returns sensible defaults for scheduled backups
+func DefaultConfig() Config {
+	return Config{
+		Enabled:   false,
+		Dir:       "./backups",
+		Interval:  6 * time.Hour,
+		Retention: 14,
+	}
+}
+
+// Store interface for backup operations
+type Store interface {
+	BackupFormat() string
+	Backup(w io.Writer) error
+}
+
+// LeaderChecker reports whether this master is the elected leader
+type LeaderChecker interface {
+	IsLeader() bool
+}
+
+// File describes a backup file on disk
+type File struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manager writes scheduled backups and enforces retention
+type Manager struct {
+	config Config
+	store  Store
+	leader LeaderChecker
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu sync.Mutex // Serializes backup runs
+}
+
+// NewManager creates a new backup manager
+func NewManager(config Config, store Store) *Manager {
+	defaults := DefaultConfig()
+	if config.Dir == "" {
+		config.Dir = defaults.Dir
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		config: config,
+		store:  store,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// SetLeaderCheck restricts scheduled backups to the elected leader
+func (m *Manager) SetLeaderCheck(leader LeaderChecker) {
+	m.leader = leader
+}
+
+// Start begins the scheduled backup loop
+func (m *Manager) Start() {
+	if !m.config.Enabled {
+		log.Println("[Backup] Scheduled backups disabled")
+		return
+	}
+
+	log.Printf("[Backup] Starting backup manager (dir: %s, interval: %v, retention: %d)",
+		m.config.Dir, m.config.Interval, m.config.Retention)
+
+	m.wg.Add(1)
+	go m.backupLoop()
+}
+
+// Stop gracefully stops the backup manager
+func (m *Manager) Stop() {
+	log.Println("[Backup] Stopping backup manager...")
+	m.cancel()
+	m.wg.Wait()
+	log.Println("[Backup] Backup manager stopped")
+}
+
+// backupLoop runs periodic backups
+func (m *Manager) backupLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			if m.leader != nil && !m.leader.IsLeader() {
+				continue
+			}
+			if _, err := m.BackupNow(); err != nil {
+				log.Printf("[Backup] Scheduled backup failed: %v", err)
+			}
+		}
+	}
+}
+
+// BackupNow writes a backup immediately and prunes old backups
+func (m *Manager) BackupNow() (*File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	startTime := time.Now()
+	if err := os.MkdirAll(m.config.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := FileName(m.store.BackupFormat(), startTime)
+	path := filepath.Join(m.config.Dir, name)
+
+	// Write to a temp file first so a partial backup is never mistaken for a complete one
+	tmp, err := os.CreateTemp(m.config.Dir, ".tmp-"+filePrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if err := m.store.Backup(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Backup] Backup written to %s (%d bytes) in %v", path, info.Size(), time.Since(startTime))
+
+	if err := m.prune(); err != nil {
+		log.Printf("[Backup] Failed to prune old backups: %v", err)
+	}
+
+	return &File{Name: name, Path: path, SizeBytes: info.Size(), CreatedAt: startTime}, nil
+}
+
+// ListBackups returns backups in the backup directory, newest first
+func (m *Manager) ListBackups() ([]File, error) {
+	entries, err := os.ReadDir(m.config.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []File{}, nil
+		}
+		return nil, err
+	}
+
+	files := make([]File, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), filePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{
+			Name:      entry.Name(),
+			Path:      filepath.Join(m.config.Dir, entry.Name()),
+			SizeBytes: info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	// Names embed a sortable UTC timestamp
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name > files[j].Name
+	})
+	return files, nil
+}
+
+// prune deletes the oldest backups beyond the retention count
+func (m *Manager) prune() error {
+	files, err := m.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	for i := m.config.Retention; i < len(files); i++ {
+		if err := os.Remove(files[i].Path); err != nil {
+			return err
+		}
+		log.Printf("[Backup] Removed old backup %s", files[i].Name)
+	}
+	return nil
+}
+
+// FileName returns the backup file name for a format and time
+func FileName(format string, t time.Time) string {
+	return filePrefix + t.UTC().Format("20060102T150405Z") + FileExtension(format)
+}
+
+// FileExtension returns the file extension used for a backup format
+func FileExtension(format string) string {
+	switch format {
+	case "sqlite":
+		return ".db"
+	case "pgdump":
+		return ".sql"
+	case "json":
+		return ".json"
+	}
+	return ".bak"
+}
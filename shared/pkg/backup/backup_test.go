@@ -0,0 +1,70 @@
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	data string
+}
+
+func (f *fakeStore) BackupFormat() string {
+	return "json"
+}
+
+func (f *fakeStore) Backup(w io.Writer) error {
+	_, err := io.WriteString(w, f.data)
+	return err
+}
+
+// TestBackupNowRetention verifies backups are written atomically and old ones are pruned
+func TestBackupNowRetention(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(Config{Dir: dir, Retention: 2}, &fakeStore{data: `{"format":"json"}`})
+
+	// Seed older backups so names sort before the new one
+	for i := 1; i <= 3; i++ {
+		name := FileName("json", time.Date(2020, 1, i, 0, 0, 0, 0, time.UTC))
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatalf("Failed to seed backup: %v", err)
+		}
+	}
+	// Unrelated files are never touched
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	file, err := m.BackupNow()
+	if err != nil {
+		t.Fatalf("BackupNow failed: %v", err)
+	}
+	if filepath.Ext(file.Name) != ".json" {
+		t.Errorf("Expected .json extension, got %s", file.Name)
+	}
+
+	data, err := os.ReadFile(file.Path)
+	if err != nil || string(data) != `{"format":"json"}` {
+		t.Errorf("Unexpected backup contents %q (%v)", data, err)
+	}
+
+	files, err := m.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 backups after pruning, got %d", len(files))
+	}
+	if files[0].Name != file.Name {
+		t.Errorf("Expected newest backup first, got %s", files[0].Name)
+	}
+	if files[1].Name != FileName("json", time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected most recent seeded backup to be kept, got %s", files[1].Name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("Unrelated file was removed: %v", err)
+	}
+}
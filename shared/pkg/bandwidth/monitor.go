@@ -145,6 +145,11 @@ rw.statusCode = statusCode
 rw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap lets http.ResponseController reach the underlying connection
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+return rw.ResponseWriter
+}
+
 // BandwidthStats holds bandwidth statistics
 type BandwidthStats struct {
 TotalBytesReceived int64
@@ -0,0 +1,60 @@
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// jobsDir is the cgroup job cgroups are created in, under the delegated parent
+const jobsDir = "ffrtmp"
+
+// backend implements one cgroup version
+type backend interface {
+	version() int
+	// ioController is the controller IO limits are written to
+	ioController() string
+	// delegation finds where this process may create cgroups
+	delegation() Delegation
+	// create creates the job cgroup name under parent and returns its path
+	create(parent, name string) (string, error)
+	join(cgroupPath string, pid int) error
+	remove(cgroupPath string) error
+
+	writeCPUMax(cgroupPath string, quota, period int64) error
+	writeCPUWeight(cgroupPath string, weight int) error
+	writeMemoryMax(cgroupPath string, bytes int64) error
+	writeMemorySwapMax(cgroupPath string, memoryBytes, swapBytes int64) error
+	writeIOMax(cgroupPath string, dev ioDeviceLimit) error
+	writeIOWeight(cgroupPath string, weight int) error
+
+	// stats reads the statistics files that exist; the others stay zero
+	stats(cgroupPath string) Stats
+}
+
+// detect returns the backend of the hierarchy mounted at root
+func detect(fsys FS, root string) backend {
+	if fsys.Exists(filepath.Join(root, "cgroup.controllers")) {
+		return &v2{fs: fsys, root: root}
+	}
+	return &v1{fs: fsys, root: root}
+}
+
+// writeFile writes a cgroup interface file. A missing file means the
+// controller is not available, which is reported as ErrNotSupported.
+func writeFile(fsys FS, dir, name, value string) error {
+	path := filepath.Join(dir, name)
+	if err := fsys.WriteFile(path, []byte(value)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s does not exist", ErrNotSupported, path)
+		}
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Delegation describes where this process may create job cgroups.
+// Root can use the whole hierarchy; an unprivileged user on cgroup v2 can
+// use the subtree systemd delegates to its user@UID.service.
+type Delegation struct {
+	Version     int      `json:"version"`
+	Parent      string   `json:"parent"` // Job cgroups are created under Parent/ffrtmp (the cpu hierarchy on v1)
+	Writable    bool     `json:"writable"`
+	Controllers []string `json:"controllers,omitempty"` // Controllers job cgroups can use
+	Reason      string   `json:"reason,omitempty"`      // Why Parent is not writable
+}
+
+// Has reports whether job cgroups can use a controller
+func (d Delegation) Has(controller string) bool {
+	return contains(d.Controllers, controller)
+}
+
+// userService returns the user@UID.service cgroup this process runs under,
+// or "" if it does not run in a systemd user session
+func userService(fsys FS, root string) string {
+	data, err := fsys.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// v2 entry: "0::/user.slice/user-1000.slice/user@1000.service/app.slice/..."
+		path, ok := strings.CutPrefix(line, "0::")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		for i, part := range parts {
+			if strings.HasPrefix(part, "user@") && strings.HasSuffix(part, ".service") {
+				return filepath.Join(append([]string{root}, parts[:i+1]...)...)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,342 @@
+package cgroups
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// v2Files are the interface files of each v2 controller known to FakeFS
+var v2Files = map[string][]string{
+	"cpu":    {"cpu.max", "cpu.weight"},
+	"memory": {"memory.max", "memory.swap.max", "memory.current", "memory.peak", "memory.events"},
+	"io":     {"io.max", "io.weight", "io.stat"},
+	"pids":   {"pids.current", "pids.peak"},
+}
+
+// v1Files are the interface files of each v1 hierarchy known to FakeFS
+var v1Files = map[string][]string{
+	"cpu":    {"cpu.cfs_quota_us", "cpu.cfs_period_us", "cpu.shares", "cpuacct.usage", "cpuacct.stat"},
+	"memory": {"memory.limit_in_bytes", "memory.memsw.limit_in_bytes", "memory.usage_in_bytes", "memory.max_usage_in_bytes", "memory.oom_control"},
+	"pids":   {"pids.current"},
+	"blkio": {
+		"blkio.weight",
+		"blkio.throttle.io_service_bytes",
+		"blkio.throttle.read_bps_device",
+		"blkio.throttle.write_bps_device",
+		"blkio.throttle.read_iops_device",
+		"blkio.throttle.write_iops_device",
+	},
+}
+
+// FakeFS is an in-memory cgroupfs for tests. Like the kernel it creates the
+// interface files of every cgroup directory, enables v2 controllers through
+// cgroup.subtree_control and enforces the no-internal-processes rule.
+// Other files, such as /proc/self/cgroup, are added with AddFile.
+type FakeFS struct {
+	mu          sync.Mutex
+	root        string
+	version     int
+	controllers []string // Controllers of the root cgroup
+	dirs        map[string]bool
+	values      map[string]string // Written interface files
+	files       map[string]string // Regular files
+	readOnly    map[string]bool
+	unsupported map[string]bool // Interface file names the "kernel" lacks
+}
+
+// NewFakeFS returns a cgroup version 1 or 2 hierarchy mounted at DefaultRoot
+// with the given controllers. Like systemd, a v2 root enables all of them
+// for its children.
+func NewFakeFS(version int, controllers ...string) *FakeFS {
+	f := &FakeFS{
+		root:        DefaultRoot,
+		version:     version,
+		controllers: controllers,
+		dirs:        map[string]bool{"/": true, DefaultRoot: true},
+		values:      make(map[string]string),
+		files:       make(map[string]string),
+		readOnly:    make(map[string]bool),
+		unsupported: make(map[string]bool),
+	}
+	if version == 2 {
+		f.values[filepath.Join(DefaultRoot, "cgroup.subtree_control")] = strings.Join(controllers, " ")
+	} else {
+		for _, controller := range controllers {
+			f.dirs[filepath.Join(DefaultRoot, controller)] = true
+		}
+	}
+	return f
+}
+
+// AddFile creates a regular file and its parent directories
+func (f *FakeFS) AddFile(path, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for dir := filepath.Dir(path); !f.dirs[dir]; dir = filepath.Dir(dir) {
+		f.dirs[dir] = true
+	}
+	f.files[path] = content
+}
+
+// SetReadOnly makes dir unwritable, as a cgroup owned by another user is
+func (f *FakeFS) SetReadOnly(dir string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readOnly[dir] = true
+}
+
+// Unsupported removes interface files from every cgroup, e.g.
+// "memory.memsw.limit_in_bytes" for a kernel booted without swapaccount=1
+func (f *FakeFS) Unsupported(names ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, name := range names {
+		f.unsupported[name] = true
+	}
+}
+
+// Set sets an interface file as the kernel would, e.g. a statistics file
+// that cannot be written
+func (f *FakeFS) Set(path, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[path] = content
+}
+
+// Value returns the content of a file, or "" if it does not exist
+func (f *FakeFS) Value(path string) string {
+	data, _ := f.ReadFile(path)
+	return string(data)
+}
+
+func (f *FakeFS) ReadFile(path string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if content, ok := f.files[path]; ok {
+		return []byte(content), nil
+	}
+	if !f.isInterface(path) {
+		return nil, pathError("open", path, fs.ErrNotExist)
+	}
+	if filepath.Base(path) == "cgroup.controllers" {
+		return []byte(strings.Join(f.available(filepath.Dir(path)), " ")), nil
+	}
+	return []byte(f.values[path]), nil
+}
+
+func (f *FakeFS) WriteFile(path string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dir, name := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	if _, ok := f.files[path]; ok {
+		f.files[path] = string(data)
+		return nil
+	}
+	if !f.isInterface(path) {
+		return pathError("open", path, fs.ErrNotExist)
+	}
+	if f.readOnly[dir] {
+		return pathError("open", path, fs.ErrPermission)
+	}
+
+	value := strings.TrimSpace(string(data))
+	switch name {
+	case "cgroup.controllers":
+		return pathError("write", path, fs.ErrPermission)
+	case "cgroup.procs":
+		return f.move(dir, value)
+	case "cgroup.subtree_control":
+		return f.enable(dir, value)
+	}
+	f.values[path] = value
+	return nil
+}
+
+func (f *FakeFS) MkdirAll(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if f.dirs[path] {
+		return nil
+	}
+	var missing []string
+	for dir := path; !f.dirs[dir]; dir = filepath.Dir(dir) {
+		missing = append(missing, dir)
+	}
+	if parent := filepath.Dir(missing[len(missing)-1]); f.readOnly[parent] {
+		return pathError("mkdir", missing[len(missing)-1], fs.ErrPermission)
+	}
+	for _, dir := range missing {
+		f.dirs[dir] = true
+	}
+	return nil
+}
+
+func (f *FakeFS) Remove(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.files[path]; ok {
+		delete(f.files, path)
+		return nil
+	}
+	if !f.dirs[path] {
+		if f.isInterface(path) {
+			return pathError("remove", path, fs.ErrPermission)
+		}
+		return pathError("remove", path, fs.ErrNotExist)
+	}
+	if f.readOnly[filepath.Dir(path)] {
+		return pathError("remove", path, fs.ErrPermission)
+	}
+	prefix := path + "/"
+	for dir := range f.dirs {
+		if strings.HasPrefix(dir, prefix) {
+			return pathError("remove", path, syscall.EBUSY)
+		}
+	}
+	if f.values[filepath.Join(path, "cgroup.procs")] != "" {
+		return pathError("remove", path, syscall.EBUSY)
+	}
+	delete(f.dirs, path)
+	for file := range f.values {
+		if strings.HasPrefix(file, prefix) {
+			delete(f.values, file)
+		}
+	}
+	return nil
+}
+
+func (f *FakeFS) Exists(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, isFile := f.files[path]
+	return isFile || f.dirs[path] || f.isInterface(path)
+}
+
+func (f *FakeFS) Writable(dir string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dirs[dir] && !f.readOnly[dir]
+}
+
+// isCgroup reports whether dir is a cgroup directory
+func (f *FakeFS) isCgroup(dir string) bool {
+	if !f.dirs[dir] {
+		return false
+	}
+	if f.version == 2 {
+		return dir == f.root || strings.HasPrefix(dir, f.root+"/")
+	}
+	controller := f.v1Controller(dir)
+	return controller != "" && f.dirs[filepath.Join(f.root, controller)]
+}
+
+// v1Controller returns the hierarchy a v1 path is in
+func (f *FakeFS) v1Controller(path string) string {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return strings.Split(rel, "/")[0]
+}
+
+// isInterface reports whether path is an interface file of a cgroup
+func (f *FakeFS) isInterface(path string) bool {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	if !f.isCgroup(dir) || f.unsupported[name] {
+		return false
+	}
+	if f.version == 1 {
+		return name == "cgroup.procs" || contains(v1Files[f.v1Controller(dir)], name)
+	}
+	switch name {
+	case "cgroup.procs", "cgroup.controllers", "cgroup.subtree_control", "cpu.stat":
+		return true
+	}
+	for _, controller := range f.available(dir) {
+		if contains(v2Files[controller], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// available returns the controllers a v2 cgroup may use
+func (f *FakeFS) available(dir string) []string {
+	if dir == f.root {
+		return f.controllers
+	}
+	return strings.Fields(f.values[filepath.Join(filepath.Dir(dir), "cgroup.subtree_control")])
+}
+
+// enable applies "+controller -controller" to a v2 cgroup.subtree_control
+func (f *FakeFS) enable(dir, value string) error {
+	path := filepath.Join(dir, "cgroup.subtree_control")
+	if dir != f.root && f.values[filepath.Join(dir, "cgroup.procs")] != "" {
+		return pathError("write", path, syscall.EBUSY) // No internal processes
+	}
+	enabled := make(map[string]bool)
+	for _, controller := range strings.Fields(f.values[path]) {
+		enabled[controller] = true
+	}
+	for _, token := range strings.Fields(value) {
+		controller := token[1:]
+		switch {
+		case token[0] == '+' && contains(f.available(dir), controller):
+			enabled[controller] = true
+		case token[0] == '-':
+			delete(enabled, controller)
+		default:
+			return pathError("write", path, syscall.EINVAL)
+		}
+	}
+	var list []string
+	for controller := range enabled {
+		list = append(list, controller)
+	}
+	sort.Strings(list)
+	f.values[path] = strings.Join(list, " ")
+	return nil
+}
+
+// move moves a pid into the cgroup dir, out of the other cgroups of its hierarchy
+func (f *FakeFS) move(dir, pid string) error {
+	path := filepath.Join(dir, "cgroup.procs")
+	if f.version == 2 && dir != f.root && f.values[filepath.Join(dir, "cgroup.subtree_control")] != "" {
+		return pathError("write", path, syscall.EBUSY) // No internal processes
+	}
+	for file, procs := range f.values {
+		if filepath.Base(file) != "cgroup.procs" || (f.version == 1 && f.v1Controller(file) != f.v1Controller(dir)) {
+			continue
+		}
+		var kept []string
+		for _, p := range strings.Fields(procs) {
+			if p != pid {
+				kept = append(kept, p)
+			}
+		}
+		f.values[file] = strings.Join(kept, "\n")
+	}
+	f.values[path] = strings.TrimSpace(f.values[path] + "\n" + pid)
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pathError(op, path string, err error) error {
+	return &fs.PathError{Op: op, Path: path, Err: err}
+}
+
+var _ FS = (*FakeFS)(nil)
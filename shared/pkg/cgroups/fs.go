@@ -0,0 +1,69 @@
+// Package cgroups is the single cgroup subsystem shared by the worker, the
+// wrapper and the watch daemon. It creates, joins and deletes job cgroups on
+// cgroup v1 or v2, checks where this process is allowed to create them, and
+// writes one Limits model, reporting the outcome of every limit.
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultRoot is where the cgroup hierarchies are mounted
+const DefaultRoot = "/sys/fs/cgroup"
+
+// FS is the filesystem the cgroup hierarchies live on.
+// OSFS is the real one, FakeFS emulates cgroupfs for tests.
+type FS interface {
+	ReadFile(path string) ([]byte, error)
+	// WriteFile writes an existing file. cgroupfs interface files cannot be
+	// created, so a missing file is an os.ErrNotExist error.
+	WriteFile(path string, data []byte) error
+	MkdirAll(path string) error
+	Remove(path string) error
+	Exists(path string) bool
+	// Writable reports whether this process may create cgroups in dir
+	Writable(dir string) bool
+}
+
+// OSFS is the host filesystem
+type OSFS struct{}
+
+func (OSFS) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (OSFS) WriteFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (OSFS) MkdirAll(path string) error {
+	return os.MkdirAll(path, 0755)
+}
+
+func (OSFS) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (OSFS) Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (OSFS) Writable(dir string) bool {
+	return unix.Access(dir, unix.W_OK) == nil
+}
@@ -0,0 +1,252 @@
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Limits defines what can be written to cgroups.
+// Nothing else. No policy. No magic.
+type Limits struct {
+	CPUMax        string // "quota period", "quota" (period 100000) or "max"
+	CPUWeight     int    // 1-10000
+	MemoryMax     int64  // bytes, 0 = no limit
+	MemorySwapMax int64  // bytes of swap on top of MemoryMax, 0 = no limit
+	IOMax         string // "major:minor rbps=X wbps=Y riops=X wiops=Y", one device per line
+	IOWeight      int    // 1-10000, needs a proportional IO scheduler (BFQ)
+}
+
+// ErrNotSupported means a limit cannot be enforced on this host,
+// e.g. the controller is not mounted or swap accounting is disabled
+var ErrNotSupported = errors.New("not supported")
+
+// Status is what happened to one limit
+type Status string
+
+const (
+	StatusApplied Status = "applied"
+	StatusSkipped Status = "skipped" // The host cannot enforce the limit
+	StatusFailed  Status = "failed"  // The limit could not be written
+)
+
+// Outcome reports whether one limit was applied.
+// A limit that is not reported was not set.
+type Outcome struct {
+	Limit      string `json:"limit"`      // "cpu_max", "cpu_weight", "memory_max", "memory_swap_max", "io_max" or "io_weight"
+	Controller string `json:"controller"` // "cpu", "memory" or "io" ("blkio" on v1)
+	Status     Status `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// defaultCPUPeriod is the period used when CPUMax has no period, in microseconds
+const defaultCPUPeriod = 100000
+
+// MiB is one mebibyte, for limits given in MB
+const MiB = 1024 * 1024
+
+// CPUPercent returns the CPUMax for a percentage of one core
+// (100 = 1 core, 200 = 2 cores); 0 or less is no limit
+func CPUPercent(percent int) string {
+	if percent <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %d", int64(percent)*defaultCPUPeriod/100, defaultCPUPeriod)
+}
+
+// Merge returns a copy of l with every limit set in update replacing its own
+func (l *Limits) Merge(update *Limits) *Limits {
+	merged := &Limits{}
+	if l != nil {
+		*merged = *l
+	}
+	if update == nil {
+		return merged
+	}
+	if update.CPUMax != "" {
+		merged.CPUMax = update.CPUMax
+	}
+	if update.CPUWeight > 0 {
+		merged.CPUWeight = update.CPUWeight
+	}
+	if update.MemoryMax > 0 {
+		merged.MemoryMax = update.MemoryMax
+	}
+	if update.MemorySwapMax > 0 {
+		merged.MemorySwapMax = update.MemorySwapMax
+	}
+	if update.IOMax != "" {
+		merged.IOMax = update.IOMax
+	}
+	if update.IOWeight > 0 {
+		merged.IOWeight = update.IOWeight
+	}
+	return merged
+}
+
+// limitWriter writes one set limit
+type limitWriter struct {
+	limit      string
+	controller string
+	write      func() error
+}
+
+// writers returns a writer for every set limit, in the order they must be written
+func (l *Limits) writers(b backend, cgroupPath string) []limitWriter {
+	if l == nil {
+		return nil
+	}
+
+	var writers []limitWriter
+	if l.CPUMax != "" {
+		writers = append(writers, limitWriter{"cpu_max", "cpu", func() error {
+			quota, period, err := parseCPUMax(l.CPUMax)
+			if err != nil {
+				return err
+			}
+			return b.writeCPUMax(cgroupPath, quota, period)
+		}})
+	}
+	if l.CPUWeight > 0 {
+		writers = append(writers, limitWriter{"cpu_weight", "cpu", func() error {
+			if l.CPUWeight > 10000 {
+				return fmt.Errorf("invalid cpu weight: %d (must be 1-10000)", l.CPUWeight)
+			}
+			return b.writeCPUWeight(cgroupPath, l.CPUWeight)
+		}})
+	}
+	if l.MemoryMax > 0 {
+		writers = append(writers, limitWriter{"memory_max", "memory", func() error {
+			return b.writeMemoryMax(cgroupPath, l.MemoryMax)
+		}})
+	}
+	if l.MemorySwapMax > 0 {
+		// After the memory limit: v1 rejects memsw limits below it
+		writers = append(writers, limitWriter{"memory_swap_max", "memory", func() error {
+			return b.writeMemorySwapMax(cgroupPath, l.MemoryMax, l.MemorySwapMax)
+		}})
+	}
+	if l.IOMax != "" {
+		writers = append(writers, limitWriter{"io_max", b.ioController(), func() error {
+			devices, err := parseIOMax(l.IOMax)
+			if err != nil {
+				return err
+			}
+			for _, dev := range devices {
+				if err := b.writeIOMax(cgroupPath, dev); err != nil {
+					return err
+				}
+			}
+			return nil
+		}})
+	}
+	if l.IOWeight > 0 {
+		writers = append(writers, limitWriter{"io_weight", b.ioController(), func() error {
+			if l.IOWeight > 10000 {
+				return fmt.Errorf("invalid io weight: %d (must be 1-10000)", l.IOWeight)
+			}
+			return b.writeIOWeight(cgroupPath, l.IOWeight)
+		}})
+	}
+	return writers
+}
+
+func outcome(limit, controller string, err error) Outcome {
+	o := Outcome{Limit: limit, Controller: controller, Status: StatusApplied}
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrNotSupported):
+		o.Status = StatusSkipped
+		o.Reason = err.Error()
+	default:
+		o.Status = StatusFailed
+		o.Reason = err.Error()
+	}
+	return o
+}
+
+// parseCPUMax parses "quota period", "quota" or "max [period]"; a quota of -1 is no limit
+func parseCPUMax(value string) (quota, period int64, err error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, fmt.Errorf("invalid cpu max %q (want \"quota period\")", value)
+	}
+
+	period = defaultCPUPeriod
+	if len(fields) == 2 {
+		period, err = strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || period < 1000 || period > 1000000 {
+			return 0, 0, fmt.Errorf("invalid cpu period %q (must be 1000-1000000)", fields[1])
+		}
+	}
+
+	if fields[0] == "max" {
+		return -1, period, nil
+	}
+	quota, err = strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || quota < 1000 {
+		return 0, 0, fmt.Errorf("invalid cpu quota %q (must be at least 1000 or max)", fields[0])
+	}
+	return quota, period, nil
+}
+
+// v1ThrottleFiles maps io.max keys to the v1 blkio files with the same meaning
+var v1ThrottleFiles = map[string]string{
+	"rbps":  "blkio.throttle.read_bps_device",
+	"wbps":  "blkio.throttle.write_bps_device",
+	"riops": "blkio.throttle.read_iops_device",
+	"wiops": "blkio.throttle.write_iops_device",
+}
+
+type ioDeviceLimit struct {
+	line   string            // The io.max line as given
+	device string            // "major:minor"
+	limits map[string]string // Key to value, e.g. "rbps" to "1048576"
+}
+
+// parseIOMax parses io.max lines, rejecting keys v1 cannot express
+func parseIOMax(value string) ([]ioDeviceLimit, error) {
+	var devices []ioDeviceLimit
+	for _, line := range strings.Split(value, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 || !isDevice(fields[0]) {
+			return nil, fmt.Errorf("invalid io max %q (want \"major:minor rbps=X wbps=Y\")", line)
+		}
+
+		dev := ioDeviceLimit{line: strings.Join(fields, " "), device: fields[0], limits: make(map[string]string)}
+		for _, field := range fields[1:] {
+			key, limit, ok := strings.Cut(field, "=")
+			if _, known := v1ThrottleFiles[key]; !ok || !known {
+				return nil, fmt.Errorf("invalid io max limit %q (want rbps, wbps, riops or wiops)", field)
+			}
+			if _, err := strconv.ParseUint(limit, 10, 64); err != nil && limit != "max" {
+				return nil, fmt.Errorf("invalid io max limit %q", field)
+			}
+			dev.limits[key] = limit
+		}
+		devices = append(devices, dev)
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("invalid io max %q", value)
+	}
+	return devices, nil
+}
+
+func isDevice(s string) bool {
+	major, minor, ok := strings.Cut(s, ":")
+	if !ok {
+		return false
+	}
+	_, errMajor := strconv.ParseUint(major, 10, 32)
+	_, errMinor := strconv.ParseUint(minor, 10, 32)
+	return errMajor == nil && errMinor == nil
+}
@@ -0,0 +1,144 @@
+package cgroups
+
+import (
+	"strings"
+	"testing"
+)
+
+// newJob creates the cgroup of a job on a fake hierarchy
+func newJob(t *testing.T, fake *FakeFS) (*Manager, string) {
+	t.Helper()
+	m := NewWithFS(fake, DefaultRoot)
+	path, err := m.Create("job")
+	if err != nil || path == "" {
+		t.Fatalf("Create failed: %q, %v", path, err)
+	}
+	return m, path
+}
+
+func TestApplyV1(t *testing.T) {
+	fake := NewFakeFS(1, "cpu", "memory", "blkio")
+	m, cpuPath := newJob(t, fake)
+	limits := &Limits{
+		CPUMax:        "200000 100000",
+		CPUWeight:     100,
+		MemoryMax:     1 << 30,
+		MemorySwapMax: 1 << 29,
+		IOMax:         "8:0 rbps=1048576 wiops=100",
+		IOWeight:      500,
+	}
+
+	for _, o := range m.Apply(cpuPath, limits) {
+		if o.Status != StatusApplied {
+			t.Errorf("%s: %s (%s)", o.Limit, o.Status, o.Reason)
+		}
+	}
+
+	want := map[string]string{
+		"cpu/ffrtmp/job/cpu.cfs_quota_us":                   "200000",
+		"cpu/ffrtmp/job/cpu.cfs_period_us":                  "100000",
+		"cpu/ffrtmp/job/cpu.shares":                         "1024",
+		"memory/ffrtmp/job/memory.limit_in_bytes":           "1073741824",
+		"memory/ffrtmp/job/memory.memsw.limit_in_bytes":     "1610612736",
+		"blkio/ffrtmp/job/blkio.throttle.read_bps_device":   "8:0 1048576",
+		"blkio/ffrtmp/job/blkio.throttle.write_iops_device": "8:0 100",
+		"blkio/ffrtmp/job/blkio.weight":                     "50",
+	}
+	for file, value := range want {
+		if got := fake.Value(DefaultRoot + "/" + file); got != value {
+			t.Errorf("%s = %q, want %q", file, got, value)
+		}
+	}
+}
+
+func TestApplyReportsSkippedAndFailed(t *testing.T) {
+	// No swap accounting and no blkio hierarchy
+	fake := NewFakeFS(1, "cpu", "memory")
+	fake.Unsupported("cpu.shares", "memory.memsw.limit_in_bytes")
+	m, cpuPath := newJob(t, fake)
+	limits := &Limits{
+		CPUMax:        "max",
+		CPUWeight:     20000,
+		MemoryMax:     1 << 30,
+		MemorySwapMax: 1 << 29,
+		IOMax:         "8:0 wbps=1048576",
+	}
+
+	got := make(map[string]Outcome)
+	for _, o := range m.Apply(cpuPath, limits) {
+		got[o.Limit] = o
+	}
+	want := map[string]Status{
+		"cpu_max":         StatusApplied,
+		"cpu_weight":      StatusFailed,
+		"memory_max":      StatusApplied,
+		"memory_swap_max": StatusSkipped,
+		"io_max":          StatusSkipped,
+	}
+	for limit, status := range want {
+		if got[limit].Status != status {
+			t.Errorf("%s: got %s (%s), want %s", limit, got[limit].Status, got[limit].Reason, status)
+		}
+	}
+	if !strings.Contains(got["memory_swap_max"].Reason, "swapaccount") {
+		t.Errorf("Expected swap accounting reason, got %q", got["memory_swap_max"].Reason)
+	}
+	if got["io_max"].Controller != "blkio" {
+		t.Errorf("Expected blkio controller on v1, got %q", got["io_max"].Controller)
+	}
+	if quota := fake.Value(cpuPath + "/cpu.cfs_quota_us"); quota != "-1" {
+		t.Errorf("cpu.cfs_quota_us = %q, want -1", quota)
+	}
+}
+
+func TestApplyV2(t *testing.T) {
+	fake := NewFakeFS(2, "cpu", "memory", "io")
+	m, path := newJob(t, fake)
+	limits := &Limits{CPUMax: "50000", MemoryMax: 1 << 20, MemorySwapMax: 1 << 20, IOMax: "8:0 rbps=max", IOWeight: 200}
+	for _, o := range m.Apply(path, limits) {
+		if o.Status != StatusApplied {
+			t.Errorf("%s: %s (%s)", o.Limit, o.Status, o.Reason)
+		}
+	}
+	if got := fake.Value(path + "/cpu.max"); got != "50000 100000" {
+		t.Errorf("cpu.max = %q", got)
+	}
+	if got := fake.Value(path + "/memory.swap.max"); got != "1048576" {
+		t.Errorf("memory.swap.max = %q", got)
+	}
+	if got := fake.Value(path + "/io.weight"); got != "default 200" {
+		t.Errorf("io.weight = %q", got)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	for _, value := range []string{"", "abc", "100 100000", "200000 10", "1 2 3"} {
+		if _, _, err := parseCPUMax(value); err == nil {
+			t.Errorf("parseCPUMax(%q): expected an error", value)
+		}
+	}
+	for _, value := range []string{"8:0", "sda rbps=1", "8:0 foo=1", "8:0 rbps=fast"} {
+		if _, err := parseIOMax(value); err == nil {
+			t.Errorf("parseIOMax(%q): expected an error", value)
+		}
+	}
+	if got := CPUPercent(150); got != "150000 100000" {
+		t.Errorf("CPUPercent(150) = %q", got)
+	}
+	if got := CPUPercent(0); got != "" {
+		t.Errorf("CPUPercent(0) = %q, want no limit", got)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	m := NewWithFS(NewFakeFS(2, "cpu", "memory"), DefaultRoot)
+	outcomes := m.Skip(&Limits{CPUWeight: 100, MemoryMax: 1}, "cgroup not created")
+	if len(outcomes) != 2 {
+		t.Fatalf("Expected 2 outcomes, got %d", len(outcomes))
+	}
+	for _, o := range outcomes {
+		if o.Status != StatusSkipped || o.Reason != "cgroup not created" {
+			t.Errorf("Unexpected outcome: %+v", o)
+		}
+	}
+}
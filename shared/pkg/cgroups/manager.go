@@ -0,0 +1,141 @@
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoCgroup means a job has no cgroup, e.g. it is not running on this host
+var ErrNoCgroup = errors.New("no cgroup")
+
+// Manager handles cgroup lifecycle and limits.
+// Create. Join. Apply. Delete. Nothing else.
+type Manager struct {
+	fs         FS
+	backend    backend
+	delegation Delegation
+}
+
+// New creates a cgroup manager for the host
+func New() *Manager {
+	return NewWithFS(OSFS{}, DefaultRoot)
+}
+
+// NewWithFS creates a cgroup manager for the hierarchy mounted at root on fsys
+func NewWithFS(fsys FS, root string) *Manager {
+	b := detect(fsys, root)
+	return &Manager{
+		fs:         fsys,
+		backend:    b,
+		delegation: b.delegation(),
+	}
+}
+
+// Version returns the detected cgroup version (1 or 2)
+func (m *Manager) Version() int {
+	return m.backend.version()
+}
+
+// Delegation returns where job cgroups are created and which controllers they can use
+func (m *Manager) Delegation() Delegation {
+	return m.delegation
+}
+
+// Create creates a cgroup directory
+// Returns: cgroup path (empty if this process may not create cgroups, see Delegation)
+func (m *Manager) Create(jobID string) (string, error) {
+	if jobID == "" {
+		jobID = fmt.Sprintf("unnamed-%d", os.Getpid())
+	}
+	
+	if err := checkJobID(jobID); err != nil {
+		return "", err
+	}
+	
+	if !m.delegation.Writable {
+		return "", nil // not an error, just can't create
+	}
+	
+	return m.backend.create(m.delegation.Parent, jobID)
+}
+
+// Lookup returns the path of the cgroup Create made for a job
+func (m *Manager) Lookup(jobID string) (string, error) {
+	if err := checkJobID(jobID); err != nil {
+		return "", err
+	}
+	
+	path := filepath.Join(m.delegation.Parent, jobsDir, jobID)
+	if !m.fs.Exists(path) {
+		return "", fmt.Errorf("%w for job %s", ErrNoCgroup, jobID)
+	}
+	return path, nil
+}
+
+// checkJobID rejects job IDs that are not a single path element
+func checkJobID(jobID string) error {
+	if jobID == "." || jobID == ".." || strings.ContainsAny(jobID, "/\x00") {
+		return fmt.Errorf("invalid job id %q", jobID)
+	}
+	return nil
+}
+
+// Join moves a PID into the cgroup
+func (m *Manager) Join(cgroupPath string, pid int) error {
+	if cgroupPath == "" {
+		return nil // no cgroup, skip
+	}
+	
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid: %d", pid)
+	}
+	
+	return m.backend.join(cgroupPath, pid)
+}
+
+// Delete removes the cgroup directory
+func (m *Manager) Delete(cgroupPath string) error {
+	if cgroupPath == "" {
+		return nil
+	}
+	
+	return m.backend.remove(cgroupPath)
+}
+
+// Apply writes every set limit to the cgroup at cgroupPath (as returned by
+// Create) and reports the outcome of each. It never stops early:
+// a limit that fails does not prevent the others.
+func (m *Manager) Apply(cgroupPath string, limits *Limits) []Outcome {
+	var outcomes []Outcome
+	for _, w := range limits.writers(m.backend, cgroupPath) {
+		outcomes = append(outcomes, outcome(w.limit, w.controller, w.write()))
+	}
+	return outcomes
+}
+
+// Update applies limits to the cgroup of a running job. Limits that are
+// not set are left as they are.
+func (m *Manager) Update(jobID string, limits *Limits) ([]Outcome, error) {
+	path, err := m.Lookup(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return m.Apply(path, limits), nil
+}
+
+// Skip reports every set limit as skipped, e.g. when no cgroup could be created
+func (m *Manager) Skip(limits *Limits, reason string) []Outcome {
+	var outcomes []Outcome
+	for _, w := range limits.writers(m.backend, "") {
+		outcomes = append(outcomes, Outcome{Limit: w.limit, Controller: w.controller, Status: StatusSkipped, Reason: reason})
+	}
+	return outcomes
+}
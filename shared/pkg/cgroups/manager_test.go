@@ -0,0 +1,165 @@
+package cgroups
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const userService1000 = DefaultRoot + "/user.slice/user-1000.slice/user@1000.service"
+
+// userSession returns a v2 host where this process runs unprivileged in a
+// systemd user session that has the cpu and memory controllers delegated
+func userSession(t *testing.T) *FakeFS {
+	t.Helper()
+	fake := NewFakeFS(2, "cpu", "memory", "io", "pids")
+	if err := fake.MkdirAll(userService1000); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetReadOnly(DefaultRoot)
+	for _, dir := range []string{DefaultRoot + "/user.slice", DefaultRoot + "/user.slice/user-1000.slice"} {
+		if err := fake.WriteFile(dir+"/cgroup.subtree_control", []byte("+cpu +memory")); err != nil {
+			t.Fatal(err)
+		}
+		fake.SetReadOnly(dir)
+	}
+	fake.AddFile("/proc/self/cgroup", "0::/user.slice/user-1000.slice/user@1000.service/app.slice/ffrtmp.service\n")
+	return fake
+}
+
+func TestDelegation(t *testing.T) {
+	t.Run("Root", func(t *testing.T) {
+		d := NewWithFS(NewFakeFS(2, "cpu", "memory", "io", "pids"), DefaultRoot).Delegation()
+		want := Delegation{Version: 2, Parent: DefaultRoot, Writable: true, Controllers: []string{"cpu", "memory", "io", "pids"}}
+		if !reflect.DeepEqual(d, want) {
+			t.Errorf("got %+v, want %+v", d, want)
+		}
+	})
+
+	t.Run("UserService", func(t *testing.T) {
+		d := NewWithFS(userSession(t), DefaultRoot).Delegation()
+		want := Delegation{Version: 2, Parent: userService1000, Writable: true, Controllers: []string{"cpu", "memory"}}
+		if !reflect.DeepEqual(d, want) {
+			t.Errorf("got %+v, want %+v", d, want)
+		}
+	})
+
+	t.Run("NotDelegated", func(t *testing.T) {
+		fake := NewFakeFS(2, "cpu", "memory")
+		fake.SetReadOnly(DefaultRoot)
+		fake.AddFile("/proc/self/cgroup", "0::/system.slice/ffrtmp.service\n")
+		m := NewWithFS(fake, DefaultRoot)
+		if d := m.Delegation(); d.Writable || d.Reason == "" {
+			t.Errorf("Expected an unwritable delegation with a reason, got %+v", d)
+		}
+		if path, err := m.Create("job"); path != "" || err != nil {
+			t.Errorf("Create = %q, %v; want no cgroup and no error", path, err)
+		}
+	})
+
+	t.Run("V1NonRoot", func(t *testing.T) {
+		fake := NewFakeFS(1, "cpu", "memory")
+		fake.SetReadOnly(DefaultRoot + "/cpu")
+		if d := NewWithFS(fake, DefaultRoot).Delegation(); d.Version != 1 || d.Writable {
+			t.Errorf("Expected an unwritable v1 delegation, got %+v", d)
+		}
+	})
+}
+
+func TestCreateEnablesDelegatedControllers(t *testing.T) {
+	fake := userSession(t)
+	m := NewWithFS(fake, DefaultRoot)
+	path, err := m.Create("job")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if path != userService1000+"/ffrtmp/job" {
+		t.Fatalf("Unexpected path %q", path)
+	}
+	if got := fake.Value(path + "/cgroup.controllers"); got != "cpu memory" {
+		t.Errorf("Job cgroup controllers = %q, want \"cpu memory\"", got)
+	}
+
+	// io is not delegated, so its limits are skipped rather than failing
+	outcomes := m.Apply(path, &Limits{CPUMax: CPUPercent(50), IOMax: "8:0 rbps=1"})
+	if outcomes[0].Status != StatusApplied || outcomes[1].Status != StatusSkipped {
+		t.Errorf("Unexpected outcomes: %+v", outcomes)
+	}
+}
+
+func TestJoinAndDelete(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		fake := NewFakeFS(version, "cpu", "memory", "blkio", "io")
+		m, path := newJob(t, fake)
+		if err := m.Join(path, 42); err != nil {
+			t.Fatalf("v%d: Join failed: %v", version, err)
+		}
+		if got := fake.Value(path + "/cgroup.procs"); got != "42" {
+			t.Errorf("v%d: cgroup.procs = %q", version, got)
+		}
+		// A cgroup with processes cannot be removed
+		if err := m.Delete(path); err == nil {
+			t.Errorf("v%d: expected Delete of a populated cgroup to fail", version)
+		}
+		if err := m.Join(path, 0); err == nil {
+			t.Errorf("v%d: expected an error for pid 0", version)
+		}
+
+		if version == 2 {
+			if err := fake.WriteFile(DefaultRoot+"/cgroup.procs", []byte("42")); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			for _, controller := range []string{"cpu", "memory", "blkio"} {
+				fake.WriteFile(DefaultRoot+"/"+controller+"/ffrtmp/cgroup.procs", []byte("42"))
+			}
+		}
+		if err := m.Delete(path); err != nil {
+			t.Errorf("v%d: Delete failed: %v", version, err)
+		}
+		if fake.Exists(path) {
+			t.Errorf("v%d: %s still exists", version, path)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	fake := NewFakeFS(2, "cpu", "memory")
+	m, path := newJob(t, fake)
+	m.Apply(path, &Limits{CPUMax: CPUPercent(100), MemoryMax: 1 << 30})
+
+	outcomes, err := m.Update("job", &Limits{CPUMax: CPUPercent(150)})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(outcomes) != 1 || outcomes[0].Status != StatusApplied {
+		t.Errorf("Unexpected outcomes: %+v", outcomes)
+	}
+	if got := fake.Value(path + "/cpu.max"); got != "150000 100000" {
+		t.Errorf("cpu.max = %q", got)
+	}
+	if got := fake.Value(path + "/memory.max"); got != "1073741824" {
+		t.Errorf("memory.max = %q, want it left as it was", got)
+	}
+
+	if _, err := m.Update("other", &Limits{CPUWeight: 10}); !errors.Is(err, ErrNoCgroup) {
+		t.Errorf("Expected ErrNoCgroup, got %v", err)
+	}
+	for _, id := range []string{"..", "../job", "a/b"} {
+		if _, err := m.Lookup(id); err == nil || errors.Is(err, ErrNoCgroup) {
+			t.Errorf("Lookup(%q): expected an invalid job id error, got %v", id, err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := &Limits{CPUMax: "max", CPUWeight: 100, MemoryMax: 1}
+	got := base.Merge(&Limits{CPUWeight: 500, IOMax: "8:0 rbps=1"})
+	want := &Limits{CPUMax: "max", CPUWeight: 500, MemoryMax: 1, IOMax: "8:0 rbps=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if base.CPUWeight != 100 {
+		t.Error("Merge modified the receiver")
+	}
+}
@@ -0,0 +1,163 @@
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAccountingInterval is how often Account samples a job cgroup
+const DefaultAccountingInterval = 5 * time.Second
+
+// Stats is the resource usage of a job cgroup: every process and thread in
+// it, not just the leader. Counters are cumulative since the processes
+// joined; a statistic the host does not provide is zero.
+type Stats struct {
+	CPUSeconds       float64 `json:"cpu_seconds"`
+	CPUUserSeconds   float64 `json:"cpu_user_seconds"`
+	CPUSystemSeconds float64 `json:"cpu_system_seconds"`
+	MemoryCurrent    int64   `json:"memory_current_bytes"`
+	MemoryPeak       int64   `json:"memory_peak_bytes"` // Highest memory use, page cache included
+	OOMEvents        int64   `json:"oom_events"`        // Times the memory limit was hit (v2 only)
+	OOMKills         int64   `json:"oom_kills"`         // Processes killed by the OOM killer
+	IOReadBytes      int64   `json:"io_read_bytes"`
+	IOWriteBytes     int64   `json:"io_write_bytes"`
+	PIDs             int64   `json:"pids_current"`
+	PIDsPeak         int64   `json:"pids_peak"`
+}
+
+// Stats reads the current resource usage of the cgroup at cgroupPath
+func (m *Manager) Stats(cgroupPath string) (*Stats, error) {
+	if cgroupPath == "" || !m.fs.Exists(cgroupPath) {
+		return nil, fmt.Errorf("cgroup %q does not exist", cgroupPath)
+	}
+	s := m.backend.stats(cgroupPath)
+	if s.MemoryPeak < s.MemoryCurrent {
+		s.MemoryPeak = s.MemoryCurrent
+	}
+	if s.PIDsPeak < s.PIDs {
+		s.PIDsPeak = s.PIDs
+	}
+	return &s, nil
+}
+
+// Accountant samples the stats of a job cgroup until stopped, so the usage
+// outlives the cgroup and peaks the kernel does not track are not missed
+type Accountant struct {
+	mgr  *Manager
+	path string
+
+	mu      sync.Mutex
+	stats   *Stats
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// Account starts sampling the cgroup at cgroupPath every interval
+func (m *Manager) Account(cgroupPath string, interval time.Duration) *Accountant {
+	a := &Accountant{
+		mgr:     m,
+		path:    cgroupPath,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if cgroupPath == "" {
+		close(a.stopped)
+		return a
+	}
+
+	go func() {
+		defer close(a.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			a.sample()
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return a
+}
+
+// sample reads the stats, keeping the highest peaks seen
+func (a *Accountant) sample() {
+	s, err := a.mgr.Stats(a.path)
+	if err != nil {
+		return // cgroup gone, keep the last sample
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stats != nil {
+		s.MemoryPeak = max(s.MemoryPeak, a.stats.MemoryPeak)
+		s.PIDsPeak = max(s.PIDsPeak, a.stats.PIDsPeak)
+	}
+	a.stats = s
+}
+
+// Usage returns the last sample, nil if none succeeded yet
+func (a *Accountant) Usage() *Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stats == nil {
+		return nil
+	}
+	s := *a.stats
+	return &s
+}
+
+// Stop takes a final sample and stops sampling. Call it once the job
+// has exited and before the cgroup is deleted.
+// Returns: the usage of the job, nil without a cgroup
+func (a *Accountant) Stop() *Stats {
+	select {
+	case <-a.stop:
+	default:
+		close(a.stop)
+	}
+	<-a.stopped
+	if a.path != "" {
+		a.sample()
+	}
+	return a.Usage()
+}
+
+// readInt reads a file holding one integer; ok is false if it does not exist
+func readInt(fsys FS, dir, name string) (int64, bool) {
+	data, err := fsys.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return n, err == nil
+}
+
+// readKeyed reads a flat keyed file ("key value" per line)
+func readKeyed(fsys FS, dir, name string) map[string]int64 {
+	values := make(map[string]int64)
+	data, err := fsys.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return values
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if n, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			values[fields[0]] = n
+		}
+	}
+	return values
+}
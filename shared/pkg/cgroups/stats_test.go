@@ -0,0 +1,96 @@
+package cgroups
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatsV2(t *testing.T) {
+	fake := NewFakeFS(2, "cpu", "memory", "io", "pids")
+	m, path := newJob(t, fake)
+	fake.Set(path+"/cpu.stat", "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n")
+	fake.Set(path+"/memory.current", "1048576")
+	fake.Set(path+"/memory.peak", "4194304")
+	fake.Set(path+"/memory.events", "low 0\nhigh 0\nmax 7\noom 2\noom_kill 1\n")
+	fake.Set(path+"/io.stat", "8:0 rbytes=100 wbytes=200 rios=1 wios=2 dbytes=0 dios=0\n8:16 rbytes=1 wbytes=2 rios=1 wios=1\n")
+	fake.Set(path+"/pids.current", "12")
+
+	s, err := m.Stats(path)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	want := Stats{
+		CPUSeconds: 2.5, CPUUserSeconds: 2, CPUSystemSeconds: 0.5,
+		MemoryCurrent: 1 << 20, MemoryPeak: 4 << 20,
+		OOMEvents: 2, OOMKills: 1,
+		IOReadBytes: 101, IOWriteBytes: 202,
+		PIDs: 12, PIDsPeak: 12,
+	}
+	if !reflect.DeepEqual(*s, want) {
+		t.Errorf("got %+v\nwant %+v", *s, want)
+	}
+
+	if _, err := m.Stats(path + "-missing"); err == nil {
+		t.Error("Expected an error for a missing cgroup")
+	}
+}
+
+func TestStatsV1(t *testing.T) {
+	fake := NewFakeFS(1, "cpu", "memory", "blkio", "pids")
+	m, path := newJob(t, fake)
+	fake.Set(path+"/cpuacct.usage", "3000000000")
+	fake.Set(path+"/cpuacct.stat", "user 250\nsystem 50\n")
+	fake.Set(DefaultRoot+"/memory/ffrtmp/job/memory.usage_in_bytes", "1024")
+	fake.Set(DefaultRoot+"/memory/ffrtmp/job/memory.max_usage_in_bytes", "2048")
+	fake.Set(DefaultRoot+"/memory/ffrtmp/job/memory.oom_control", "oom_kill_disable 0\nunder_oom 0\noom_kill 3\n")
+	fake.Set(DefaultRoot+"/blkio/ffrtmp/job/blkio.throttle.io_service_bytes", "8:0 Read 10\n8:0 Write 20\n8:0 Sync 30\nTotal 30\n")
+	fake.Set(DefaultRoot+"/pids/ffrtmp/job/pids.current", "4")
+
+	s, err := m.Stats(path)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	want := Stats{
+		CPUSeconds: 3, CPUUserSeconds: 2.5, CPUSystemSeconds: 0.5,
+		MemoryCurrent: 1024, MemoryPeak: 2048, OOMKills: 3,
+		IOReadBytes: 10, IOWriteBytes: 20,
+		PIDs: 4, PIDsPeak: 4,
+	}
+	if !reflect.DeepEqual(*s, want) {
+		t.Errorf("got %+v\nwant %+v", *s, want)
+	}
+}
+
+func TestAccountant(t *testing.T) {
+	// No memory.peak (before Linux 5.19): the peak is the highest sample
+	fake := NewFakeFS(2, "cpu", "memory")
+	fake.Unsupported("memory.peak")
+	m, path := newJob(t, fake)
+
+	fake.Set(path+"/memory.current", "8192")
+	a := m.Account(path, time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for a.Usage() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Set(path+"/memory.current", "4096")
+	fake.Set(path+"/cpu.stat", "usage_usec 1000000\n")
+
+	usage := a.Stop()
+	if usage == nil || usage.MemoryPeak != 8192 || usage.MemoryCurrent != 4096 || usage.CPUSeconds != 1 {
+		t.Fatalf("Unexpected usage: %+v", usage)
+	}
+
+	// The usage outlives the cgroup
+	if err := m.Delete(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Stop(); got == nil || got.CPUSeconds != 1 {
+		t.Errorf("Expected the last usage after the cgroup was deleted, got %+v", got)
+	}
+
+	if got := m.Account("", time.Millisecond).Stop(); got != nil {
+		t.Errorf("Expected no usage without a cgroup, got %+v", got)
+	}
+}
@@ -0,0 +1,185 @@
+package cgroups
+
+// If the wrapper crashes, the workload MUST continue.
+// If we are unsure, DO LESS.
+// If something is not reversible, DO NOT TOUCH IT.
+// This is governance, not execution.
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// userHZ is the unit of cpuacct.stat, in ticks per second
+const userHZ = 100
+
+// v1Controllers are the v1 hierarchies used besides cpu
+var v1Controllers = []string{"memory", "blkio", "pids"}
+
+// v1 is the legacy hierarchy: one tree per controller. Job cgroups are
+// identified by their path in the cpu hierarchy.
+type v1 struct {
+	fs   FS
+	root string
+}
+
+func (v *v1) version() int         { return 1 }
+func (v *v1) ioController() string { return "blkio" }
+
+func (v *v1) delegation() Delegation {
+	parent := filepath.Join(v.root, "cpu")
+	if !v.fs.Writable(parent) {
+		return Delegation{
+			Version: 1,
+			Parent:  parent,
+			Reason:  fmt.Sprintf("%s is not writable (cgroup v1 cannot be delegated, run as root)", parent),
+		}
+	}
+	d := Delegation{Version: 1, Parent: parent, Writable: true, Controllers: []string{"cpu"}}
+	for _, controller := range v1Controllers {
+		if v.fs.Writable(filepath.Join(v.root, controller)) {
+			d.Controllers = append(d.Controllers, controller)
+		}
+	}
+	return d
+}
+
+func (v *v1) create(parent, name string) (string, error) {
+	cpuPath := filepath.Join(parent, jobsDir, name)
+	if err := v.fs.MkdirAll(cpuPath); err != nil {
+		return "", err
+	}
+
+	// Also create under memory, blkio and pids if mounted (best effort: limits
+	// for a missing hierarchy are reported as skipped)
+	for _, controller := range v1Controllers {
+		if v.fs.Exists(filepath.Join(v.root, controller)) {
+			v.fs.MkdirAll(v.path(cpuPath, controller))
+		}
+	}
+	return cpuPath, nil
+}
+
+func (v *v1) join(cgroupPath string, pid int) error {
+	if err := writeFile(v.fs, cgroupPath, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+		return err
+	}
+
+	// Also write to memory, blkio and pids cgroups (best effort)
+	for _, controller := range v1Controllers {
+		writeFile(v.fs, v.path(cgroupPath, controller), "cgroup.procs", strconv.Itoa(pid))
+	}
+	return nil
+}
+
+func (v *v1) remove(cgroupPath string) error {
+	for _, controller := range v1Controllers {
+		v.fs.Remove(v.path(cgroupPath, controller)) // best effort
+	}
+	return v.fs.Remove(cgroupPath)
+}
+
+// path returns the path of a job cgroup in another controller's hierarchy
+func (v *v1) path(cgroupPath, controller string) string {
+	rel, err := filepath.Rel(filepath.Join(v.root, "cpu"), cgroupPath)
+	if err != nil {
+		return cgroupPath
+	}
+	return filepath.Join(v.root, controller, rel)
+}
+
+func (v *v1) writeCPUMax(cgroupPath string, quota, period int64) error {
+	// The period is written first so the quota is checked against it; -1 is no limit
+	if err := writeFile(v.fs, cgroupPath, "cpu.cfs_period_us", strconv.FormatInt(period, 10)); err != nil {
+		return err
+	}
+	return writeFile(v.fs, cgroupPath, "cpu.cfs_quota_us", strconv.FormatInt(quota, 10))
+}
+
+func (v *v1) writeCPUWeight(cgroupPath string, weight int) error {
+	// Convert weight to shares (weight 100 = 1024 shares)
+	shares := (weight * 1024) / 100
+	return writeFile(v.fs, cgroupPath, "cpu.shares", strconv.Itoa(shares))
+}
+
+func (v *v1) writeMemoryMax(cgroupPath string, bytes int64) error {
+	return writeFile(v.fs, v.path(cgroupPath, "memory"), "memory.limit_in_bytes", strconv.FormatInt(bytes, 10))
+}
+
+func (v *v1) writeMemorySwapMax(cgroupPath string, memoryBytes, swapBytes int64) error {
+	// v1 limits memory and swap together, so it needs the memory limit as well
+	if memoryBytes <= 0 {
+		return fmt.Errorf("%w: cgroup v1 limits swap together with memory, set a memory limit too", ErrNotSupported)
+	}
+	err := writeFile(v.fs, v.path(cgroupPath, "memory"), "memory.memsw.limit_in_bytes", strconv.FormatInt(memoryBytes+swapBytes, 10))
+	if errors.Is(err, ErrNotSupported) {
+		return fmt.Errorf("%w: swap accounting is disabled (boot with swapaccount=1)", ErrNotSupported)
+	}
+	return err
+}
+
+func (v *v1) writeIOMax(cgroupPath string, dev ioDeviceLimit) error {
+	// One throttle file per limit; 0 removes the limit
+	blkioPath := v.path(cgroupPath, "blkio")
+	for _, key := range []string{"rbps", "wbps", "riops", "wiops"} {
+		limit, ok := dev.limits[key]
+		if !ok {
+			continue
+		}
+		if limit == "max" {
+			limit = "0"
+		}
+		if err := writeFile(v.fs, blkioPath, v1ThrottleFiles[key], dev.device+" "+limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *v1) writeIOWeight(cgroupPath string, weight int) error {
+	// blkio.weight is 10-1000, io.weight 1-10000
+	weight /= 10
+	if weight < 10 {
+		weight = 10
+	}
+	return writeFile(v.fs, v.path(cgroupPath, "blkio"), "blkio.weight", strconv.Itoa(weight))
+}
+
+func (v *v1) stats(cgroupPath string) Stats {
+	var s Stats
+	// cpuacct is mounted together with cpu
+	if usage, ok := readInt(v.fs, cgroupPath, "cpuacct.usage"); ok {
+		s.CPUSeconds = float64(usage) / 1e9
+	}
+	cpu := readKeyed(v.fs, cgroupPath, "cpuacct.stat")
+	s.CPUUserSeconds = float64(cpu["user"]) / userHZ
+	s.CPUSystemSeconds = float64(cpu["system"]) / userHZ
+
+	memoryPath := v.path(cgroupPath, "memory")
+	s.MemoryCurrent, _ = readInt(v.fs, memoryPath, "memory.usage_in_bytes")
+	s.MemoryPeak, _ = readInt(v.fs, memoryPath, "memory.max_usage_in_bytes")
+	s.OOMKills = readKeyed(v.fs, memoryPath, "memory.oom_control")["oom_kill"] // Linux 4.13+
+
+	// blkio.throttle.io_service_bytes: "major:minor Read|Write|... N" per device
+	if data, err := v.fs.ReadFile(filepath.Join(v.path(cgroupPath, "blkio"), "blkio.throttle.io_service_bytes")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			n, _ := strconv.ParseInt(fields[2], 10, 64)
+			switch fields[1] {
+			case "Read":
+				s.IOReadBytes += n
+			case "Write":
+				s.IOWriteBytes += n
+			}
+		}
+	}
+
+	s.PIDs, _ = readInt(v.fs, v.path(cgroupPath, "pids"), "pids.current")
+	return s
+}
@@ -2,13 +2,26 @@ package cleanup
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/psantana5/ffmpeg-rtmp/pkg/archive"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
 )
 
+// defaultCleanupStatuses are the terminal statuses cleaned up with JobRetentionDays
+var defaultCleanupStatuses = []models.JobStatus{
+	models.JobStatusCompleted,
+	models.JobStatusFailed,
+	models.JobStatusCanceled,
+}
+
 // CleanupConfig defines retention policies and cleanup intervals
 type CleanupConfig struct {
 	Enabled            bool
@@ -16,6 +29,10 @@ type CleanupConfig struct {
 	CleanupInterval    time.Duration
 	VacuumInterval     time.Duration
 	DeleteBatchSize    int
+
+	// StatusRetentionDays overrides JobRetentionDays per terminal status
+	// (e.g. keep failed jobs longer for SLA audits). A value of 0 keeps jobs forever.
+	StatusRetentionDays map[models.JobStatus]int
 }
 
 // DefaultConfig returns sensible defaults for cleanup
@@ -29,17 +46,63 @@ func DefaultConfig() CleanupConfig {
 	}
 }
 
+// ParseStatusRetention parses a per-status retention policy such as "failed=30,canceled=3".
+// Only terminal statuses are accepted.
+func ParseStatusRetention(spec string) (map[models.JobStatus]int, error) {
+	retention := make(map[models.JobStatus]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid retention %q (expected status=days)", entry)
+		}
+		status := models.JobStatus(strings.TrimSpace(parts[0]))
+		if !models.IsTerminalState(status) {
+			return nil, fmt.Errorf("invalid retention %q: %s is not a terminal status", entry, status)
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid retention %q: days must be a non-negative integer", entry)
+		}
+		retention[status] = days
+	}
+	return retention, nil
+}
+
 // Store interface for cleanup operations
 type Store interface {
 	GetJobs(status string) ([]models.Job, error)
+	GetJob(id string) (*models.Job, error)
 	DeleteJob(id string) error
 	Vacuum() error
 }
 
+// JobResultStore is implemented by stores that persist job results
+type JobResultStore interface {
+	GetJobResult(jobID string) (*models.JobResult, error)
+}
+
+// Archiver exports expired jobs before they are deleted
+type Archiver interface {
+	Archive(records []archive.Record) error
+}
+
+// Leadership reports whether this master is the elected leader (see pkg/leader)
+type Leadership interface {
+	IsLeader() bool
+	FencingToken() (int64, bool)
+}
+
 // CleanupManager handles automatic cleanup of old jobs and maintenance
 type CleanupManager struct {
 	config       CleanupConfig
 	store        Store
+	leader       Leadership
+	leaseName    string
+	archiver     Archiver
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
@@ -53,6 +116,7 @@ type CleanupStats struct {
 	LastCleanupTime      time.Time
 	LastVacuumTime       time.Time
 	TotalJobsDeleted     int64
+	TotalJobsArchived    int64
 	TotalVacuumRuns      int64
 	LastCleanupDuration  time.Duration
 	LastVacuumDuration   time.Duration
@@ -70,6 +134,24 @@ func NewCleanupManager(config CleanupConfig, store Store) *CleanupManager {
 	}
 }
 
+// SetLeadership restricts cleanup and vacuum runs to the elected leader.
+// Job deletions carry the leader's fencing token for the named lease when the store supports it.
+func (cm *CleanupManager) SetLeadership(leader Leadership, leaseName string) {
+	cm.leader = leader
+	cm.leaseName = leaseName
+}
+
+// SetArchiver enables archive mode: expired jobs are exported before deletion,
+// and jobs that fail to archive are kept until the next run
+func (cm *CleanupManager) SetArchiver(archiver Archiver) {
+	cm.archiver = archiver
+}
+
+// isFollower returns true if leader election is enabled and this master is not the leader
+func (cm *CleanupManager) isFollower() bool {
+	return cm.leader != nil && !cm.leader.IsLeader()
+}
+
 // Start begins the automatic cleanup process
 func (cm *CleanupManager) Start() {
 	if !cm.config.Enabled {
@@ -131,27 +213,29 @@ func (cm *CleanupManager) vacuumLoop() {
 	}
 }
 
-// cleanupOldJobs deletes completed/failed jobs older than retention period
+// cleanupOldJobs deletes (or archives and deletes) terminal jobs older than their retention period
 func (cm *CleanupManager) cleanupOldJobs() {
+	if cm.isFollower() {
+		log.Println("[Cleanup] Skipping job cleanup: not the leader")
+		return
+	}
+
 	startTime := time.Now()
 	log.Println("[Cleanup] Starting job cleanup...")
 
-	cutoffTime := time.Now().Add(-time.Duration(cm.config.JobRetentionDays) * 24 * time.Hour)
 	deletedCount := 0
+	archivedCount := 0
 
-	// Cleanup completed jobs
-	if err := cm.cleanupJobsByStatus("completed", cutoffTime, &deletedCount); err != nil {
-		log.Printf("[Cleanup] Error cleaning completed jobs: %v\n", err)
-	}
-
-	// Cleanup failed jobs
-	if err := cm.cleanupJobsByStatus("failed", cutoffTime, &deletedCount); err != nil {
-		log.Printf("[Cleanup] Error cleaning failed jobs: %v\n", err)
-	}
+	for _, status := range cm.cleanupStatuses() {
+		retentionDays := cm.retentionDays(status)
+		if retentionDays <= 0 {
+			continue // Keep forever
+		}
+		cutoffTime := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
 
-	// Cleanup canceled jobs
-	if err := cm.cleanupJobsByStatus("canceled", cutoffTime, &deletedCount); err != nil {
-		log.Printf("[Cleanup] Error cleaning canceled jobs: %v\n", err)
+		if err := cm.cleanupJobsByStatus(string(status), cutoffTime, &deletedCount, &archivedCount); err != nil {
+			log.Printf("[Cleanup] Error cleaning %s jobs: %v\n", status, err)
+		}
 	}
 
 	duration := time.Since(startTime)
@@ -160,18 +244,51 @@ func (cm *CleanupManager) cleanupOldJobs() {
 	cm.stats.LastCleanupTime = time.Now()
 	cm.stats.LastCleanupDuration = duration
 	cm.stats.TotalJobsDeleted += int64(deletedCount)
+	cm.stats.TotalJobsArchived += int64(archivedCount)
 	cm.mu.Unlock()
 
-	log.Printf("[Cleanup] Job cleanup complete: deleted %d jobs in %v\n", deletedCount, duration)
+	if cm.archiver != nil {
+		log.Printf("[Cleanup] Job cleanup complete: archived %d and deleted %d jobs in %v\n", archivedCount, deletedCount, duration)
+	} else {
+		log.Printf("[Cleanup] Job cleanup complete: deleted %d jobs in %v\n", deletedCount, duration)
+	}
+}
+
+// cleanupStatuses returns the statuses to clean up: the defaults plus any terminal status with its own retention
+func (cm *CleanupManager) cleanupStatuses() []models.JobStatus {
+	statuses := append([]models.JobStatus(nil), defaultCleanupStatuses...)
+	seen := make(map[models.JobStatus]bool)
+	for _, status := range statuses {
+		seen[status] = true
+	}
+
+	var extra []models.JobStatus
+	for status := range cm.config.StatusRetentionDays {
+		if !seen[status] && models.IsTerminalState(status) {
+			extra = append(extra, status)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	return append(statuses, extra...)
+}
+
+// retentionDays returns the retention period for a status
+func (cm *CleanupManager) retentionDays(status models.JobStatus) int {
+	if days, ok := cm.config.StatusRetentionDays[status]; ok {
+		return days
+	}
+	return cm.config.JobRetentionDays
 }
 
-// cleanupJobsByStatus deletes jobs of a specific status older than cutoff time
-func (cm *CleanupManager) cleanupJobsByStatus(status string, cutoffTime time.Time, deletedCount *int) error {
+// cleanupJobsByStatus deletes jobs of a specific status older than cutoff time.
+// In archive mode, jobs are archived in batches and only deleted once archived.
+func (cm *CleanupManager) cleanupJobsByStatus(status string, cutoffTime time.Time, deletedCount, archivedCount *int) error {
 	jobs, err := cm.store.GetJobs(status)
 	if err != nil {
 		return err
 	}
 
+	var expired []string
 	for _, job := range jobs {
 		// Check if job is older than retention period
 		// Use CompletedAt if available, otherwise CreatedAt
@@ -181,24 +298,107 @@ func (cm *CleanupManager) cleanupJobsByStatus(status string, cutoffTime time.Tim
 		}
 		
 		if compareTime.Before(cutoffTime) {
-			if err := cm.store.DeleteJob(job.ID); err != nil {
-				log.Printf("[Cleanup] Failed to delete job %s: %v\n", job.ID, err)
+			expired = append(expired, job.ID)
+		}
+	}
+
+	batchSize := cm.config.DeleteBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	for start := 0; start < len(expired); start += batchSize {
+		end := start + batchSize
+		if end > len(expired) {
+			end = len(expired)
+		}
+		batch := expired[start:end]
+
+		if cm.archiver != nil {
+			archived, err := cm.archiveJobs(batch)
+			if err != nil {
+				// Keep the jobs so no history is lost; they are retried on the next run
+				return err
+			}
+			*archivedCount += len(archived)
+			batch = archived
+		}
+
+		for _, id := range batch {
+			if err := cm.deleteJob(id); err != nil {
+				if err == store.ErrStaleFencingToken {
+					// Leadership was lost; the new leader cleans up from here
+					return err
+				}
+				log.Printf("[Cleanup] Failed to delete job %s: %v\n", id, err)
 				continue
 			}
 			*deletedCount++
-			
-			// Rate limit deletions to avoid overloading database
-			if *deletedCount%cm.config.DeleteBatchSize == 0 {
-				time.Sleep(100 * time.Millisecond)
-			}
 		}
+
+		// Rate limit deletions to avoid overloading database
+		time.Sleep(100 * time.Millisecond)
 	}
 
 	return nil
 }
 
+// deleteJob deletes a job, with the leader's fencing token when leader election is enabled
+func (cm *CleanupManager) deleteJob(id string) error {
+	if cm.leader == nil {
+		return cm.store.DeleteJob(id)
+	}
+	token, ok := cm.leader.FencingToken()
+	if !ok {
+		return store.ErrStaleFencingToken
+	}
+	if fenced, ok := cm.store.(store.FencedStore); ok {
+		return fenced.DeleteJobFenced(id, cm.leaseName, token)
+	}
+	return cm.store.DeleteJob(id)
+}
+
+// archiveJobs exports full job records (with state transitions, logs and results) and
+// returns the IDs that were archived
+func (cm *CleanupManager) archiveJobs(ids []string) ([]string, error) {
+	resultStore, _ := cm.store.(JobResultStore)
+
+	now := time.Now()
+	records := make([]archive.Record, 0, len(ids))
+	archived := make([]string, 0, len(ids))
+	for _, id := range ids {
+		job, err := cm.store.GetJob(id)
+		if err != nil {
+			log.Printf("[Cleanup] Failed to load job %s for archival: %v\n", id, err)
+			continue
+		}
+
+		record := archive.Record{Job: *job, ArchivedAt: now}
+		if resultStore != nil {
+			if result, err := resultStore.GetJobResult(id); err == nil {
+				record.Result = result
+			}
+		}
+		records = append(records, record)
+		archived = append(archived, id)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+	if err := cm.archiver.Archive(records); err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
 // vacuum performs database maintenance
 func (cm *CleanupManager) vacuum() {
+	if cm.isFollower() {
+		log.Println("[Cleanup] Skipping database vacuum: not the leader")
+		return
+	}
+
 	startTime := time.Now()
 	log.Println("[Cleanup] Starting database vacuum...")
 
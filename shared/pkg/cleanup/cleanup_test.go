@@ -0,0 +1,119 @@
+package cleanup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/archive"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+type recordingArchiver struct {
+	records []archive.Record
+	err     error
+}
+
+func (a *recordingArchiver) Archive(records []archive.Record) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.records = append(a.records, records...)
+	return nil
+}
+
+func createCleanupTestJob(t *testing.T, s *store.MemoryStore, id string, status models.JobStatus, age time.Duration) {
+	completed := time.Now().Add(-age)
+	job := &models.Job{ID: id, Scenario: "test", Status: status, CreatedAt: completed, CompletedAt: &completed}
+	if err := s.CreateJob(job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	// CreateJob may normalize the status, so set it explicitly
+	job.Status = status
+	job.CompletedAt = &completed
+	if err := s.UpdateJob(job); err != nil {
+		t.Fatalf("Failed to update job: %v", err)
+	}
+}
+
+// TestCleanupArchivesWithStatusRetention verifies per-status retention and archive-before-delete
+func TestCleanupArchivesWithStatusRetention(t *testing.T) {
+	s := store.NewMemoryStore()
+	createCleanupTestJob(t, s, "old-completed", models.JobStatusCompleted, 10*24*time.Hour)
+	createCleanupTestJob(t, s, "old-failed", models.JobStatusFailed, 10*24*time.Hour)
+	createCleanupTestJob(t, s, "old-rejected", models.JobStatusRejected, 10*24*time.Hour)
+	createCleanupTestJob(t, s, "new-completed", models.JobStatusCompleted, time.Hour)
+
+	config := DefaultConfig()
+	config.StatusRetentionDays = map[models.JobStatus]int{
+		models.JobStatusFailed:   30, // Keep failures longer
+		models.JobStatusRejected: 1,  // Not cleaned by default
+	}
+	cm := NewCleanupManager(config, s)
+
+	archiver := &recordingArchiver{err: errors.New("disk full")}
+	cm.SetArchiver(archiver)
+
+	// Archive failures keep the jobs
+	cm.CleanupNow()
+	if _, err := s.GetJob("old-completed"); err != nil {
+		t.Fatalf("Expected job to be kept when archival fails: %v", err)
+	}
+
+	archiver.err = nil
+	cm.CleanupNow()
+
+	for id, wantDeleted := range map[string]bool{
+		"old-completed": true,
+		"old-rejected":  true,
+		"old-failed":    false,
+		"new-completed": false,
+	} {
+		_, err := s.GetJob(id)
+		if deleted := err != nil; deleted != wantDeleted {
+			t.Errorf("Job %s: deleted=%v, want %v", id, deleted, wantDeleted)
+		}
+	}
+
+	if len(archiver.records) != 2 {
+		t.Fatalf("Expected 2 archived records, got %d", len(archiver.records))
+	}
+	stats := cm.GetStats()
+	if stats.TotalJobsArchived != 2 || stats.TotalJobsDeleted != 2 {
+		t.Errorf("Unexpected stats: archived=%d deleted=%d", stats.TotalJobsArchived, stats.TotalJobsDeleted)
+	}
+}
+
+// staleLeadership reports leadership that is lost before the fencing token is read
+type staleLeadership struct {
+	hasToken bool
+}
+
+func (l *staleLeadership) IsLeader() bool { return true }
+
+func (l *staleLeadership) FencingToken() (int64, bool) { return 1, l.hasToken }
+
+// TestCleanupDeposedLeader verifies that a master without a fencing token deletes nothing
+func TestCleanupDeposedLeader(t *testing.T) {
+	s := store.NewMemoryStore()
+	createCleanupTestJob(t, s, "old-completed", models.JobStatusCompleted, 10*24*time.Hour)
+
+	leadership := &staleLeadership{}
+	cm := NewCleanupManager(DefaultConfig(), s)
+	cm.SetLeadership(leadership, "master")
+
+	cm.CleanupNow()
+	if _, err := s.GetJob("old-completed"); err != nil {
+		t.Errorf("Expected deposed leader to keep the job: %v", err)
+	}
+	if stats := cm.GetStats(); stats.TotalJobsDeleted != 0 {
+		t.Errorf("Expected no deletions, got %d", stats.TotalJobsDeleted)
+	}
+
+	leadership.hasToken = true
+	cm.CleanupNow()
+	if _, err := s.GetJob("old-completed"); err == nil {
+		t.Error("Expected leader to delete the expired job")
+	}
+}
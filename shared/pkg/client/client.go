@@ -0,0 +1,180 @@
+// Package client is a typed Go client for the master API. The methods and
+// response types in client_gen.go are generated from the OpenAPI document in
+// pkg/openapi; request and model types come from pkg/models.
+package client
+
+//go:generate go run github.com/psantana5/ffmpeg-rtmp/pkg/openapi/clientgen -o client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the timeout of the HTTP client used when none is given
+const DefaultTimeout = 120 * time.Second
+
+// APIError is a response from the master with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Body       string // Error message sent by the master
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// StatusCode returns the status code of an *APIError, or 0 for other errors
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// Download is a raw response body, such as a backup, with the response headers
+type Download struct {
+	io.ReadCloser
+	Header http.Header
+}
+
+// RequestEditor changes requests before they are sent, e.g. to add credentials
+type RequestEditor func(req *http.Request) error
+
+// Client calls the master API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	editors    []RequestEditor
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with httpClient, e.g. one configured for TLS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBearerToken authenticates requests with an API key or session token
+func WithBearerToken(token string) Option {
+	return WithRequestEditor(func(req *http.Request) error {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return nil
+	})
+}
+
+// WithRequestEditor applies editor to every request
+func WithRequestEditor(editor RequestEditor) Option {
+	return func(c *Client) {
+		c.editors = append(c.editors, editor)
+	}
+}
+
+// New creates a client for the master at baseURL, e.g. https://master:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the URL of the master
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// HTTPClient returns the HTTP client requests are sent with
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// do sends a request and decodes its JSON response into out, if not nil.
+// An empty response body leaves out unchanged.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}, statuses ...int) error {
+	resp, err := c.send(ctx, method, path, query, body, statuses...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request and returns the response if its status is one of
+// statuses; otherwise it returns an *APIError. Readers are sent as raw
+// bodies and other values as JSON. The caller must close the response body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}, statuses ...int) (*http.Response, error) {
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+		contentType = "application/octet-stream"
+	default:
+		if v := reflect.ValueOf(body); v.Kind() == reflect.Ptr && v.IsNil() {
+			break
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, editor := range c.editors {
+		if err := editor(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to master API: %w", err)
+	}
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return nil, &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+}
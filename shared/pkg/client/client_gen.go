@@ -0,0 +1,937 @@
+// Code generated by go run github.com/psantana5/ffmpeg-rtmp/pkg/openapi/clientgen; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// APIKeyList is the APIKeyList schema of the master API
+type APIKeyList struct {
+	Count int                   `json:"count"`
+	Keys  []models.TenantAPIKey `json:"keys"`
+}
+
+// APIKeyRevoked is the APIKeyRevoked schema of the master API
+type APIKeyRevoked struct {
+	KeyID  string `json:"key_id"`
+	Status string `json:"status"`
+}
+
+// APIKeyRotation is the APIKeyRotation schema of the master API
+type APIKeyRotation struct {
+	// How long the old key keeps working, e.g. 1h; empty revokes it immediately
+	GracePeriod *string `json:"grace_period,omitempty"`
+}
+
+// AuditEventList is the AuditEventList schema of the master API
+type AuditEventList struct {
+	Count  int                 `json:"count"`
+	Events []models.AuditEvent `json:"events"`
+}
+
+// BootstrapTokenList is the BootstrapTokenList schema of the master API
+type BootstrapTokenList struct {
+	Count  int                     `json:"count"`
+	Tokens []models.BootstrapToken `json:"tokens"`
+}
+
+// CertificateList is the CertificateList schema of the master API
+type CertificateList struct {
+	Certificates []models.IssuedCertificate `json:"certificates"`
+	Count        int                        `json:"count"`
+}
+
+// CertificateRevoked is the CertificateRevoked schema of the master API
+type CertificateRevoked struct {
+	Serial string `json:"serial"`
+	Status string `json:"status"`
+}
+
+// CreatedTenant is the CreatedTenant schema of the master API
+type CreatedTenant struct {
+	models.Tenant
+	// The tenant's first API key, only returned once
+	APIKey string `json:"api_key"`
+}
+
+// DeliveryList is the DeliveryList schema of the master API
+type DeliveryList struct {
+	Count      int                      `json:"count"`
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+// Health is the Health schema of the master API
+type Health struct {
+	// leader or follower, when running with --ha
+	Role   *string `json:"role,omitempty"`
+	Status string  `json:"status"`
+}
+
+// IssuedAPIKey is the IssuedAPIKey schema of the master API
+type IssuedAPIKey struct {
+	models.TenantAPIKey
+	// The key itself, only returned once
+	APIKey string `json:"api_key"`
+}
+
+// IssuedBootstrapToken is the IssuedBootstrapToken schema of the master API
+type IssuedBootstrapToken struct {
+	models.BootstrapToken
+	// The token itself, only returned once
+	Token string `json:"token"`
+}
+
+// JobAction is the JobAction schema of the master API
+type JobAction struct {
+	JobID string `json:"job_id"`
+	// Only for retries
+	RetryCount *int `json:"retry_count,omitempty"`
+	// paused, resumed, canceled or queued
+	Status string `json:"status"`
+}
+
+// JobList is the JobList schema of the master API
+type JobList struct {
+	Count int          `json:"count"`
+	Jobs  []models.Job `json:"jobs"`
+}
+
+// JobLogs is the JobLogs schema of the master API
+type JobLogs struct {
+	JobID string `json:"job_id"`
+	Logs  string `json:"logs"`
+}
+
+// NextJob is the NextJob schema of the master API
+type NextJob struct {
+	Job *models.Job `json:"job"`
+}
+
+// NodeList is the NodeList schema of the master API
+type NodeList struct {
+	Count int           `json:"count"`
+	Nodes []models.Node `json:"nodes"`
+}
+
+// NodeRemoved is the NodeRemoved schema of the master API
+type NodeRemoved struct {
+	NodeID string `json:"node_id"`
+	Status string `json:"status"`
+}
+
+// QuotaRemaining is capacity left under each quota; -1 means unlimited
+type QuotaRemaining struct {
+	ConcurrentJobs int `json:"concurrent_jobs"`
+	CPUCores       int `json:"cpu_cores"`
+	GPUs           int `json:"gpus"`
+	TotalJobs      int `json:"total_jobs"`
+	Workers        int `json:"workers"`
+}
+
+// RegisteredNode is the RegisteredNode schema of the master API
+type RegisteredNode struct {
+	models.Node
+	// Identity token to send as X-Node-Token; empty when node tokens are disabled
+	NodeToken *string `json:"node_token,omitempty"`
+}
+
+// RestoreResult is the RestoreResult schema of the master API
+type RestoreResult struct {
+	Format string `json:"format"`
+	// restored or valid
+	Status string `json:"status"`
+}
+
+// ResultAggregates is the ResultAggregates schema of the master API
+type ResultAggregates struct {
+	Aggregates []models.ResultAggregate `json:"aggregates"`
+	Count      int                      `json:"count"`
+	GroupBy    string                   `json:"group_by"`
+}
+
+// ResultReceipt is the ResultReceipt schema of the master API
+type ResultReceipt struct {
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// Attempt number when the job was queued for a retry
+	Retry  *int   `json:"retry,omitempty"`
+	Status string `json:"status"`
+}
+
+// RotatedAPIKey is the RotatedAPIKey schema of the master API
+type RotatedAPIKey struct {
+	IssuedAPIKey
+	Replaced models.TenantAPIKey `json:"replaced"`
+}
+
+// SecretDeleted is the SecretDeleted schema of the master API
+type SecretDeleted struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// SecretList is the SecretList schema of the master API
+type SecretList struct {
+	Count   int             `json:"count"`
+	Secrets []models.Secret `json:"secrets"`
+}
+
+// TenantDeleted is the TenantDeleted schema of the master API
+type TenantDeleted struct {
+	Status   string `json:"status"`
+	TenantID string `json:"tenant_id"`
+}
+
+// TenantList is the TenantList schema of the master API
+type TenantList struct {
+	Count   int             `json:"count"`
+	Tenants []models.Tenant `json:"tenants"`
+}
+
+// TenantStats is the TenantStats schema of the master API
+type TenantStats struct {
+	Name      string             `json:"name"`
+	Plan      string             `json:"plan"`
+	Quotas    models.TenantQuota `json:"quotas"`
+	Remaining QuotaRemaining     `json:"remaining"`
+	Status    string             `json:"status"`
+	TenantID  string             `json:"tenant_id"`
+	Usage     models.TenantUsage `json:"usage"`
+}
+
+// TenantUpdate is the TenantUpdate schema of the master API
+type TenantUpdate struct {
+	DisplayName *string                `json:"display_name,omitempty"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Plan        *string                `json:"plan,omitempty"`
+	Quotas      *models.TenantQuota    `json:"quotas,omitempty"`
+	// active, suspended or deleted
+	Status *string `json:"status,omitempty"`
+}
+
+// UserDeleted is the UserDeleted schema of the master API
+type UserDeleted struct {
+	Status string `json:"status"`
+	UserID string `json:"user_id"`
+}
+
+// UserList is the UserList schema of the master API
+type UserList struct {
+	Count int           `json:"count"`
+	Users []models.User `json:"users"`
+}
+
+// UserUpdate is the UserUpdate schema of the master API
+type UserUpdate struct {
+	Email    *string `json:"email,omitempty"`
+	FullName *string `json:"full_name,omitempty"`
+	Password *string `json:"password,omitempty"`
+	Role     *string `json:"role,omitempty"`
+	// active or suspended
+	Status *string `json:"status,omitempty"`
+}
+
+// WebhookDeleted is the WebhookDeleted schema of the master API
+type WebhookDeleted struct {
+	Status    string `json:"status"`
+	WebhookID string `json:"webhook_id"`
+}
+
+// WebhookList is the WebhookList schema of the master API
+type WebhookList struct {
+	Count    int              `json:"count"`
+	Webhooks []models.Webhook `json:"webhooks"`
+}
+
+// BackupDatabase calls GET /admin/backup: download a backup of the database
+// The caller must close the returned body.
+func (c *Client) BackupDatabase(ctx context.Context) (*Download, error) {
+	resp, err := c.send(ctx, "GET", "/admin/backup", nil, nil, 200)
+	if err != nil {
+		return nil, err
+	}
+	return &Download{ReadCloser: resp.Body, Header: resp.Header}, nil
+}
+
+// RestoreDatabaseParams holds the optional query parameters of RestoreDatabase; zero values are omitted
+type RestoreDatabaseParams struct {
+	// Only check the backup
+	ValidateOnly bool
+}
+
+// RestoreDatabase calls POST /admin/restore: restore the database from a backup
+func (c *Client) RestoreDatabase(ctx context.Context, params *RestoreDatabaseParams, body io.Reader) (*RestoreResult, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.ValidateOnly != false {
+			query.Set("validate_only", strconv.FormatBool(params.ValidateOnly))
+		}
+	}
+	var out RestoreResult
+	if err := c.do(ctx, "POST", "/admin/restore", query, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAuditEventsParams holds the optional query parameters of ListAuditEvents; zero values are omitted
+type ListAuditEventsParams struct {
+	ActorType string
+	Actor     string
+	Action    string
+	Target    string
+	Outcome   string
+	// RFC 3339 timestamp or a duration such as 24h
+	Since string
+	// RFC 3339 timestamp or a duration such as 24h
+	Until string
+	// Maximum number of events
+	Limit int
+}
+
+// ListAuditEvents calls GET /audit: query the audit log, most recent first
+func (c *Client) ListAuditEvents(ctx context.Context, params *ListAuditEventsParams) (*AuditEventList, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.ActorType != "" {
+			query.Set("actor_type", params.ActorType)
+		}
+		if params.Actor != "" {
+			query.Set("actor", params.Actor)
+		}
+		if params.Action != "" {
+			query.Set("action", params.Action)
+		}
+		if params.Target != "" {
+			query.Set("target", params.Target)
+		}
+		if params.Outcome != "" {
+			query.Set("outcome", params.Outcome)
+		}
+		if params.Since != "" {
+			query.Set("since", params.Since)
+		}
+		if params.Until != "" {
+			query.Set("until", params.Until)
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var out AuditEventList
+	if err := c.do(ctx, "GET", "/audit", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportAuditEventsParams holds the optional query parameters of ExportAuditEvents; zero values are omitted
+type ExportAuditEventsParams struct {
+	ActorType string
+	Actor     string
+	Action    string
+	Target    string
+	Outcome   string
+	// RFC 3339 timestamp or a duration such as 24h
+	Since string
+	// RFC 3339 timestamp or a duration such as 24h
+	Until string
+}
+
+// ExportAuditEvents calls GET /audit/export: export matching audit events as JSON lines
+// The caller must close the returned body.
+func (c *Client) ExportAuditEvents(ctx context.Context, params *ExportAuditEventsParams) (*Download, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.ActorType != "" {
+			query.Set("actor_type", params.ActorType)
+		}
+		if params.Actor != "" {
+			query.Set("actor", params.Actor)
+		}
+		if params.Action != "" {
+			query.Set("action", params.Action)
+		}
+		if params.Target != "" {
+			query.Set("target", params.Target)
+		}
+		if params.Outcome != "" {
+			query.Set("outcome", params.Outcome)
+		}
+		if params.Since != "" {
+			query.Set("since", params.Since)
+		}
+		if params.Until != "" {
+			query.Set("until", params.Until)
+		}
+	}
+	resp, err := c.send(ctx, "GET", "/audit/export", query, nil, 200)
+	if err != nil {
+		return nil, err
+	}
+	return &Download{ReadCloser: resp.Body, Header: resp.Header}, nil
+}
+
+// Login calls POST /auth/login: log in and start a session
+func (c *Client) Login(ctx context.Context, body *models.LoginRequest) (*models.LoginResponse, error) {
+	var out models.LoginResponse
+	if err := c.do(ctx, "POST", "/auth/login", nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Logout calls POST /auth/logout: end the current session
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, "POST", "/auth/logout", nil, nil, nil, 204)
+}
+
+// Health calls GET /health: check that the master is running
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var out Health
+	if err := c.do(ctx, "GET", "/health", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobs calls GET /jobs: list the jobs of the requesting tenant, or all jobs for administrators
+func (c *Client) ListJobs(ctx context.Context) (*JobList, error) {
+	var out JobList
+	if err := c.do(ctx, "GET", "/jobs", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateJob calls POST /jobs: submit a job
+func (c *Client) CreateJob(ctx context.Context, body *models.JobRequest) (*models.Job, error) {
+	var out models.Job
+	if err := c.do(ctx, "POST", "/jobs", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetNextJob calls GET /jobs/next: assign the next queued job to a node
+func (c *Client) GetNextJob(ctx context.Context, nodeID string) (*NextJob, error) {
+	query := url.Values{}
+	query.Set("node_id", nodeID)
+	var out NextJob
+	if err := c.do(ctx, "GET", "/jobs/next", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJob calls GET /jobs/{id}: get a job by ID or sequence number
+func (c *Client) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	var out models.Job
+	if err := c.do(ctx, "GET", "/jobs/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelJob calls POST /jobs/{id}/cancel: cancel a queued or running job
+func (c *Client) CancelJob(ctx context.Context, id string) (*JobAction, error) {
+	var out JobAction
+	if err := c.do(ctx, "POST", "/jobs/"+url.PathEscape(id)+"/cancel", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobLogs calls GET /jobs/{id}/logs: get the execution logs of a job
+func (c *Client) GetJobLogs(ctx context.Context, id string) (*JobLogs, error) {
+	var out JobLogs
+	if err := c.do(ctx, "GET", "/jobs/"+url.PathEscape(id)+"/logs", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PauseJob calls POST /jobs/{id}/pause: pause a running job
+func (c *Client) PauseJob(ctx context.Context, id string) (*JobAction, error) {
+	var out JobAction
+	if err := c.do(ctx, "POST", "/jobs/"+url.PathEscape(id)+"/pause", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobResult calls GET /jobs/{id}/result: get the final result of a job
+func (c *Client) GetJobResult(ctx context.Context, id string) (*models.JobResult, error) {
+	var out models.JobResult
+	if err := c.do(ctx, "GET", "/jobs/"+url.PathEscape(id)+"/result", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResumeJob calls POST /jobs/{id}/resume: resume a paused job
+func (c *Client) ResumeJob(ctx context.Context, id string) (*JobAction, error) {
+	var out JobAction
+	if err := c.do(ctx, "POST", "/jobs/"+url.PathEscape(id)+"/resume", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RetryJob calls POST /jobs/{id}/retry: queue a failed job again
+func (c *Client) RetryJob(ctx context.Context, id string) (*JobAction, error) {
+	var out JobAction
+	if err := c.do(ctx, "POST", "/jobs/"+url.PathEscape(id)+"/retry", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListNodes calls GET /nodes: list the nodes of the requesting tenant, or all nodes for administrators
+func (c *Client) ListNodes(ctx context.Context) (*NodeList, error) {
+	var out NodeList
+	if err := c.do(ctx, "GET", "/nodes", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RegisterNode calls POST /nodes/register: register a worker node, or re-register one with the same address
+func (c *Client) RegisterNode(ctx context.Context, body *models.NodeRegistration) (*RegisteredNode, error) {
+	var out RegisteredNode
+	if err := c.do(ctx, "POST", "/nodes/register", nil, body, &out, 200, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveNode calls DELETE /nodes/{id}: remove a node
+func (c *Client) RemoveNode(ctx context.Context, id string) (*NodeRemoved, error) {
+	var out NodeRemoved
+	if err := c.do(ctx, "DELETE", "/nodes/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetNode calls GET /nodes/{id}: get a node
+func (c *Client) GetNode(ctx context.Context, id string) (*models.Node, error) {
+	var out models.Node
+	if err := c.do(ctx, "GET", "/nodes/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RegisterExternalJob calls POST /nodes/{id}/external-jobs: record a process the node discovered as a read-only external job
+func (c *Client) RegisterExternalJob(ctx context.Context, id string, body *models.ExternalProcess) (*models.Job, error) {
+	var out models.Job
+	if err := c.do(ctx, "POST", "/nodes/"+url.PathEscape(id)+"/external-jobs", nil, body, &out, 200, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateExternalJob calls PUT /nodes/{id}/external-jobs/{jobID}: report the resource usage and exit code of an external job
+func (c *Client) UpdateExternalJob(ctx context.Context, id string, jobID string, body *models.ExternalProcess) (*models.Job, error) {
+	var out models.Job
+	if err := c.do(ctx, "PUT", "/nodes/"+url.PathEscape(id)+"/external-jobs/"+url.PathEscape(jobID), nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// NodeHeartbeat calls POST /nodes/{id}/heartbeat: report that a node is alive
+func (c *Client) NodeHeartbeat(ctx context.Context, id string) error {
+	return c.do(ctx, "POST", "/nodes/"+url.PathEscape(id)+"/heartbeat", nil, nil, nil, 200)
+}
+
+// GetOpenAPISpec calls GET /openapi.json: get this document
+func (c *Client) GetOpenAPISpec(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/openapi.json", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListBootstrapTokens calls GET /pki/bootstrap-tokens: list bootstrap tokens
+func (c *Client) ListBootstrapTokens(ctx context.Context) (*BootstrapTokenList, error) {
+	var out BootstrapTokenList
+	if err := c.do(ctx, "GET", "/pki/bootstrap-tokens", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateBootstrapToken calls POST /pki/bootstrap-tokens: create a one-time worker enrollment token
+func (c *Client) CreateBootstrapToken(ctx context.Context, body *models.BootstrapTokenRequest) (*IssuedBootstrapToken, error) {
+	var out IssuedBootstrapToken
+	if err := c.do(ctx, "POST", "/pki/bootstrap-tokens", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCACertificate calls GET /pki/ca.crt: download the worker CA certificate
+// The caller must close the returned body.
+func (c *Client) GetCACertificate(ctx context.Context) (*Download, error) {
+	resp, err := c.send(ctx, "GET", "/pki/ca.crt", nil, nil, 200)
+	if err != nil {
+		return nil, err
+	}
+	return &Download{ReadCloser: resp.Body, Header: resp.Header}, nil
+}
+
+// ListCertificatesParams holds the optional query parameters of ListCertificates; zero values are omitted
+type ListCertificatesParams struct {
+	// Only list revoked certificates
+	Revoked bool
+}
+
+// ListCertificates calls GET /pki/certificates: list issued worker certificates
+func (c *Client) ListCertificates(ctx context.Context, params *ListCertificatesParams) (*CertificateList, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Revoked != false {
+			query.Set("revoked", strconv.FormatBool(params.Revoked))
+		}
+	}
+	var out CertificateList
+	if err := c.do(ctx, "GET", "/pki/certificates", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeCertificate calls DELETE /pki/certificates/{serial}: revoke a worker certificate
+func (c *Client) RevokeCertificate(ctx context.Context, serial string) (*CertificateRevoked, error) {
+	var out CertificateRevoked
+	if err := c.do(ctx, "DELETE", "/pki/certificates/"+url.PathEscape(serial), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EnrollWorker calls POST /pki/enroll: exchange a bootstrap token and CSR for a client certificate
+func (c *Client) EnrollWorker(ctx context.Context, body *models.EnrollmentRequest) (*models.CertificateResponse, error) {
+	var out models.CertificateResponse
+	if err := c.do(ctx, "POST", "/pki/enroll", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenewCertificate calls POST /pki/renew: renew a client certificate over a connection presenting it
+func (c *Client) RenewCertificate(ctx context.Context, body *models.RenewalRequest) (*models.CertificateResponse, error) {
+	var out models.CertificateResponse
+	if err := c.do(ctx, "POST", "/pki/renew", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SendResults calls POST /results: report the result of a job from the node running it
+func (c *Client) SendResults(ctx context.Context, body *models.JobResult) (*ResultReceipt, error) {
+	var out ResultReceipt
+	if err := c.do(ctx, "POST", "/results", nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetResultAggregatesParams holds the optional query parameters of GetResultAggregates; zero values are omitted
+type GetResultAggregatesParams struct {
+	GroupBy string
+	// RFC 3339 timestamp or a duration such as 24h
+	Since string
+}
+
+// GetResultAggregates calls GET /results/aggregates: average completed job results by scenario, engine or node
+func (c *Client) GetResultAggregates(ctx context.Context, params *GetResultAggregatesParams) (*ResultAggregates, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.GroupBy != "" {
+			query.Set("group_by", params.GroupBy)
+		}
+		if params.Since != "" {
+			query.Set("since", params.Since)
+		}
+	}
+	var out ResultAggregates
+	if err := c.do(ctx, "GET", "/results/aggregates", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSecrets calls GET /secrets: list secrets without their values
+func (c *Client) ListSecrets(ctx context.Context) (*SecretList, error) {
+	var out SecretList
+	if err := c.do(ctx, "GET", "/secrets", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateSecret calls POST /secrets: store a secret
+func (c *Client) CreateSecret(ctx context.Context, body *models.SecretRequest) (*models.Secret, error) {
+	var out models.Secret
+	if err := c.do(ctx, "POST", "/secrets", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSecret calls DELETE /secrets/{name}: delete a secret
+func (c *Client) DeleteSecret(ctx context.Context, name string) (*SecretDeleted, error) {
+	var out SecretDeleted
+	if err := c.do(ctx, "DELETE", "/secrets/"+url.PathEscape(name), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateSecret calls PUT /secrets/{name}: replace the value of a secret
+func (c *Client) UpdateSecret(ctx context.Context, name string, body *models.SecretRequest) (*models.Secret, error) {
+	var out models.Secret
+	if err := c.do(ctx, "PUT", "/secrets/"+url.PathEscape(name), nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenants calls GET /tenants: list tenants that have not been deleted
+func (c *Client) ListTenants(ctx context.Context) (*TenantList, error) {
+	var out TenantList
+	if err := c.do(ctx, "GET", "/tenants", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTenant calls POST /tenants: create a tenant and its first API key
+func (c *Client) CreateTenant(ctx context.Context, body *models.TenantRequest) (*CreatedTenant, error) {
+	var out CreatedTenant
+	if err := c.do(ctx, "POST", "/tenants", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTenant calls DELETE /tenants/{id}: delete a tenant
+func (c *Client) DeleteTenant(ctx context.Context, id string) (*TenantDeleted, error) {
+	var out TenantDeleted
+	if err := c.do(ctx, "DELETE", "/tenants/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenant calls GET /tenants/{id}: get a tenant
+func (c *Client) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	var out models.Tenant
+	if err := c.do(ctx, "GET", "/tenants/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTenant calls PUT /tenants/{id}: update a tenant; omitted fields are unchanged
+func (c *Client) UpdateTenant(ctx context.Context, id string, body *TenantUpdate) (*models.Tenant, error) {
+	var out models.Tenant
+	if err := c.do(ctx, "PUT", "/tenants/"+url.PathEscape(id), nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenantAPIKeys calls GET /tenants/{id}/apikeys: list the API keys of a tenant
+func (c *Client) ListTenantAPIKeys(ctx context.Context, id string) (*APIKeyList, error) {
+	var out APIKeyList
+	if err := c.do(ctx, "GET", "/tenants/"+url.PathEscape(id)+"/apikeys", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTenantAPIKey calls POST /tenants/{id}/apikeys: issue an API key for a tenant
+func (c *Client) CreateTenantAPIKey(ctx context.Context, id string, body *models.TenantAPIKeyRequest) (*IssuedAPIKey, error) {
+	var out IssuedAPIKey
+	if err := c.do(ctx, "POST", "/tenants/"+url.PathEscape(id)+"/apikeys", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeTenantAPIKey calls DELETE /tenants/{id}/apikeys/{keyID}: revoke an API key
+func (c *Client) RevokeTenantAPIKey(ctx context.Context, id string, keyID string) (*APIKeyRevoked, error) {
+	var out APIKeyRevoked
+	if err := c.do(ctx, "DELETE", "/tenants/"+url.PathEscape(id)+"/apikeys/"+url.PathEscape(keyID), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateTenantAPIKey calls POST /tenants/{id}/apikeys/{keyID}/rotate: replace an API key with a new one with the same name and scopes
+func (c *Client) RotateTenantAPIKey(ctx context.Context, id string, keyID string, body *APIKeyRotation) (*RotatedAPIKey, error) {
+	var out RotatedAPIKey
+	if err := c.do(ctx, "POST", "/tenants/"+url.PathEscape(id)+"/apikeys/"+url.PathEscape(keyID)+"/rotate", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenantJobs calls GET /tenants/{id}/jobs: list the jobs of a tenant
+func (c *Client) ListTenantJobs(ctx context.Context, id string) (*JobList, error) {
+	var out JobList
+	if err := c.do(ctx, "GET", "/tenants/"+url.PathEscape(id)+"/jobs", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenantNodes calls GET /tenants/{id}/nodes: list the nodes of a tenant
+func (c *Client) ListTenantNodes(ctx context.Context, id string) (*NodeList, error) {
+	var out NodeList
+	if err := c.do(ctx, "GET", "/tenants/"+url.PathEscape(id)+"/nodes", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenantStats calls GET /tenants/{id}/stats: get a tenant's usage alongside its quotas
+func (c *Client) GetTenantStats(ctx context.Context, id string) (*TenantStats, error) {
+	var out TenantStats
+	if err := c.do(ctx, "GET", "/tenants/"+url.PathEscape(id)+"/stats", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListUsers calls GET /users: list users
+func (c *Client) ListUsers(ctx context.Context) (*UserList, error) {
+	var out UserList
+	if err := c.do(ctx, "GET", "/users", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateUser calls POST /users: create a user
+func (c *Client) CreateUser(ctx context.Context, body *models.UserRequest) (*models.User, error) {
+	var out models.User
+	if err := c.do(ctx, "POST", "/users", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteUser calls DELETE /users/{id}: delete a user
+func (c *Client) DeleteUser(ctx context.Context, id string) (*UserDeleted, error) {
+	var out UserDeleted
+	if err := c.do(ctx, "DELETE", "/users/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUser calls GET /users/{id}: get a user
+func (c *Client) GetUser(ctx context.Context, id string) (*models.User, error) {
+	var out models.User
+	if err := c.do(ctx, "GET", "/users/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUser calls PUT /users/{id}: update a user; omitted fields are unchanged
+func (c *Client) UpdateUser(ctx context.Context, id string, body *UserUpdate) (*models.User, error) {
+	var out models.User
+	if err := c.do(ctx, "PUT", "/users/"+url.PathEscape(id), nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhooks calls GET /webhooks: list webhooks
+func (c *Client) ListWebhooks(ctx context.Context) (*WebhookList, error) {
+	var out WebhookList
+	if err := c.do(ctx, "GET", "/webhooks", nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateWebhook calls POST /webhooks: create a webhook
+func (c *Client) CreateWebhook(ctx context.Context, body *models.WebhookRequest) (*models.Webhook, error) {
+	var out models.Webhook
+	if err := c.do(ctx, "POST", "/webhooks", nil, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RedeliverWebhook calls POST /webhooks/deliveries/{id}/redeliver: send a webhook delivery again
+func (c *Client) RedeliverWebhook(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var out models.WebhookDelivery
+	if err := c.do(ctx, "POST", "/webhooks/deliveries/"+url.PathEscape(id)+"/redeliver", nil, nil, &out, 202); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook calls DELETE /webhooks/{id}: delete a webhook
+func (c *Client) DeleteWebhook(ctx context.Context, id string) (*WebhookDeleted, error) {
+	var out WebhookDeleted
+	if err := c.do(ctx, "DELETE", "/webhooks/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWebhook calls GET /webhooks/{id}: get a webhook
+func (c *Client) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	var out models.Webhook
+	if err := c.do(ctx, "GET", "/webhooks/"+url.PathEscape(id), nil, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWebhook calls PUT /webhooks/{id}: update a webhook; omitted fields are unchanged
+func (c *Client) UpdateWebhook(ctx context.Context, id string, body *models.WebhookRequest) (*models.Webhook, error) {
+	var out models.Webhook
+	if err := c.do(ctx, "PUT", "/webhooks/"+url.PathEscape(id), nil, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhookDeliveriesParams holds the optional query parameters of ListWebhookDeliveries; zero values are omitted
+type ListWebhookDeliveriesParams struct {
+	// Maximum number of deliveries (default 50)
+	Limit int
+}
+
+// ListWebhookDeliveries calls GET /webhooks/{id}/deliveries: list the most recent deliveries of a webhook
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, params *ListWebhookDeliveriesParams) (*DeliveryList, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var out DeliveryList
+	if err := c.do(ctx, "GET", "/webhooks/"+url.PathEscape(id)+"/deliveries", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// JobLimits is the result of a limits update on a worker agent
+type JobLimits struct {
+	JobID  string            `json:"job_id"`
+	Limits []cgroups.Outcome `json:"limits"`
+}
+
+// UpdateJobLimits calls PUT /jobs/{id}/limits on a worker agent's metrics port,
+// so the client must be created with the agent's URL instead of the master's.
+// It is not part of the master API and therefore not generated.
+func (c *Client) UpdateJobLimits(ctx context.Context, jobID string, constraints *models.WrapperConstraints) (*JobLimits, error) {
+	var out JobLimits
+	if err := c.do(ctx, "PUT", "/jobs/"+url.PathEscape(jobID)+"/limits", nil, constraints, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,235 @@
+package leader
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseName is the lease contended by masters for scheduling leadership
+const DefaultLeaseName = "master"
+
+// Lock is a distributed lock backend used for leader election
+type Lock interface {
+	// TryAcquire attempts to take the lock without blocking, returning a new fencing token on success
+	TryAcquire(ctx context.Context) (token int64, acquired bool, err error)
+	// Renew extends the lease for token, returning false if leadership was lost
+	Renew(ctx context.Context, token int64) (bool, error)
+	// Release gives up the lock
+	Release(ctx context.Context) error
+}
+
+// Config holds leader election configuration
+type Config struct {
+	ID            string        // Identity of this master (for logging and the lease holder column)
+	LeaseDuration time.Duration // How long leadership is valid without a successful renewal
+	RenewInterval time.Duration // How often the leader renews its lease
+	RetryInterval time.Duration // How often followers try to acquire leadership
+}
+
+// DefaultConfig returns sensible defaults for leader election
+func DefaultConfig(id string) Config {
+	return Config{
+		ID:            id,
+		LeaseDuration: 15 * time.Second,
+		RenewInterval: 5 * time.Second,
+		RetryInterval: 5 * time.Second,
+	}
+}
+
+// Elector runs leader election and tracks whether this process is the current leader
+type Elector struct {
+	config Config
+	lock   Lock
+
+	mu        sync.RWMutex
+	isLeader  bool
+	token     int64
+	lastRenew time.Time
+
+	onStartedLeading func(token int64)
+	onStoppedLeading func()
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewElector creates a new leader elector
+func NewElector(config Config, lock Lock) *Elector {
+	defaults := DefaultConfig(config.ID)
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = defaults.LeaseDuration
+	}
+	if config.RenewInterval <= 0 {
+		config.RenewInterval = defaults.RenewInterval
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaults.RetryInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Elector{
+		config: config,
+		lock:   lock,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// OnStartedLeading registers a callback invoked when this process becomes leader
+func (e *Elector) OnStartedLeading(fn func(token int64)) {
+	e.onStartedLeading = fn
+}
+
+// OnStoppedLeading registers a callback invoked when this process loses leadership
+func (e *Elector) OnStoppedLeading(fn func()) {
+	e.onStoppedLeading = fn
+}
+
+// Start begins the election loop
+func (e *Elector) Start() {
+	log.Printf("[Leader] Starting leader election (id: %s, lease: %v, renew: %v)",
+		e.config.ID, e.config.LeaseDuration, e.config.RenewInterval)
+
+	e.wg.Add(1)
+	go e.run()
+}
+
+// Stop ends the election loop and releases leadership if held
+func (e *Elector) Stop() {
+	log.Println("[Leader] Stopping leader election...")
+	e.cancel()
+	e.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.stepDown(ctx, "shutdown")
+	log.Println("[Leader] Leader election stopped")
+}
+
+// IsLeader returns true if this process holds an unexpired lease
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader && time.Since(e.lastRenew) < e.config.LeaseDuration
+}
+
+// FencingToken returns the current fencing token, and false if not leader.
+// Writes guarded by the token are rejected by the store once another master takes over.
+func (e *Elector) FencingToken() (int64, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if !e.isLeader || time.Since(e.lastRenew) >= e.config.LeaseDuration {
+		return 0, false
+	}
+	return e.token, true
+}
+
+// run alternates between acquiring and renewing leadership until stopped
+func (e *Elector) run() {
+	defer e.wg.Done()
+
+	for {
+		interval := e.config.RetryInterval
+		if e.leading() {
+			e.renew()
+			interval = e.config.RenewInterval
+		} else {
+			e.tryAcquire()
+			if e.leading() {
+				interval = e.config.RenewInterval
+			}
+		}
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// leading reports the raw leadership flag (without the lease check)
+func (e *Elector) leading() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// tryAcquire attempts to become leader
+func (e *Elector) tryAcquire() {
+	ctx, cancel := context.WithTimeout(e.ctx, e.config.RenewInterval)
+	defer cancel()
+
+	token, acquired, err := e.lock.TryAcquire(ctx)
+	if err != nil {
+		log.Printf("[Leader] Failed to acquire leadership: %v", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	e.mu.Lock()
+	e.isLeader = true
+	e.token = token
+	e.lastRenew = time.Now()
+	e.mu.Unlock()
+
+	log.Printf("[Leader] %s became leader (fencing token: %d)", e.config.ID, token)
+	if e.onStartedLeading != nil {
+		e.onStartedLeading(token)
+	}
+}
+
+// renew extends the lease, stepping down if it was lost or could not be renewed in time
+func (e *Elector) renew() {
+	ctx, cancel := context.WithTimeout(e.ctx, e.config.RenewInterval)
+	defer cancel()
+
+	e.mu.RLock()
+	token := e.token
+	lastRenew := e.lastRenew
+	e.mu.RUnlock()
+
+	ok, err := e.lock.Renew(ctx, token)
+	if err != nil {
+		if time.Since(lastRenew) >= e.config.LeaseDuration {
+			e.stepDown(ctx, "lease expired: "+err.Error())
+		} else {
+			log.Printf("[Leader] Failed to renew lease (will retry): %v", err)
+		}
+		return
+	}
+	if !ok {
+		e.stepDown(ctx, "lease taken over by another master")
+		return
+	}
+
+	e.mu.Lock()
+	e.lastRenew = time.Now()
+	e.mu.Unlock()
+}
+
+// stepDown relinquishes leadership if held
+func (e *Elector) stepDown(ctx context.Context, reason string) {
+	e.mu.Lock()
+	wasLeader := e.isLeader
+	e.isLeader = false
+	e.token = 0
+	e.mu.Unlock()
+
+	if !wasLeader {
+		return
+	}
+
+	log.Printf("[Leader] %s lost leadership (%s)", e.config.ID, reason)
+	if err := e.lock.Release(ctx); err != nil {
+		log.Printf("[Leader] Failed to release lock: %v", err)
+	}
+	if e.onStoppedLeading != nil {
+		e.onStoppedLeading()
+	}
+}
@@ -0,0 +1,134 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sharedLock simulates an advisory lock shared by several masters
+type sharedLock struct {
+	mu     sync.Mutex
+	holder string
+	token  int64
+}
+
+// handle is one master's session on the shared lock
+type handle struct {
+	lock *sharedLock
+	id   string
+}
+
+func (h *handle) TryAcquire(ctx context.Context) (int64, bool, error) {
+	h.lock.mu.Lock()
+	defer h.lock.mu.Unlock()
+	if h.lock.holder != "" && h.lock.holder != h.id {
+		return 0, false, nil
+	}
+	h.lock.holder = h.id
+	h.lock.token++
+	return h.lock.token, true, nil
+}
+
+func (h *handle) Renew(ctx context.Context, token int64) (bool, error) {
+	h.lock.mu.Lock()
+	defer h.lock.mu.Unlock()
+	return h.lock.holder == h.id && h.lock.token == token, nil
+}
+
+func (h *handle) Release(ctx context.Context) error {
+	h.lock.mu.Lock()
+	defer h.lock.mu.Unlock()
+	if h.lock.holder == h.id {
+		h.lock.holder = ""
+	}
+	return nil
+}
+
+func testConfig(id string) Config {
+	return Config{
+		ID:            id,
+		LeaseDuration: 200 * time.Millisecond,
+		RenewInterval: 20 * time.Millisecond,
+		RetryInterval: 20 * time.Millisecond,
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
+
+func TestSingleLeader(t *testing.T) {
+	lock := &sharedLock{}
+	a := NewElector(testConfig("a"), &handle{lock: lock, id: "a"})
+	b := NewElector(testConfig("b"), &handle{lock: lock, id: "b"})
+
+	a.Start()
+	waitFor(t, a.IsLeader, "a should become leader")
+	b.Start()
+	defer b.Stop()
+
+	// b must never lead while a holds the lock
+	time.Sleep(100 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("b should not be leader while a holds the lock")
+	}
+
+	tokenA, ok := a.FencingToken()
+	if !ok {
+		t.Fatal("leader should have a fencing token")
+	}
+	if _, ok := b.FencingToken(); ok {
+		t.Error("follower should not have a fencing token")
+	}
+
+	// Failover after a stops
+	a.Stop()
+	if a.IsLeader() {
+		t.Error("a should not be leader after stop")
+	}
+	waitFor(t, b.IsLeader, "b should take over leadership")
+
+	tokenB, _ := b.FencingToken()
+	if tokenB <= tokenA {
+		t.Errorf("Fencing token should increase on takeover: %d -> %d", tokenA, tokenB)
+	}
+}
+
+func TestStepDownWhenLeaseLost(t *testing.T) {
+	lock := &sharedLock{}
+	e := NewElector(testConfig("a"), &handle{lock: lock, id: "a"})
+
+	started := make(chan int64, 2)
+	stopped := make(chan struct{}, 2)
+	e.OnStartedLeading(func(token int64) { started <- token })
+	e.OnStoppedLeading(func() { stopped <- struct{}{} })
+
+	e.Start()
+	defer e.Stop()
+	<-started
+
+	// Another master takes over behind our back
+	lock.mu.Lock()
+	lock.holder = "b"
+	lock.token++
+	lock.mu.Unlock()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected elector to step down after losing the lease")
+	}
+	if e.IsLeader() {
+		t.Error("Elector should not report leadership after losing the lease")
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+)
+
+// Audit actor types
+const (
+	AuditActorUser        = "user"        // Logged-in user (session token)
+	AuditActorAPIKey      = "apikey"      // Tenant API key
+	AuditActorNode        = "node"        // Worker authenticated by its node token
+	AuditActorAdmin       = "admin"       // Master API key
+	AuditActorCertificate = "certificate" // Client certificate without other credentials
+	AuditActorAnonymous   = "anonymous"   // No credentials (e.g. login, enrollment)
+)
+
+// Audit outcomes
+const (
+	AuditOutcomeSuccess = "success" // 2xx/3xx response
+	AuditOutcomeDenied  = "denied"  // 401 or 403 response
+	AuditOutcomeFailure = "failure" // Any other error response
+)
+
+// AuditEvent records a mutating API request: who did what to which object, and how it ended
+type AuditEvent struct {
+	ID           string    `json:"id"`
+	Timestamp    time.Time `json:"timestamp"`
+	TenantID     string    `json:"tenant_id,omitempty"`
+	ActorType    string    `json:"actor_type"`
+	ActorID      string    `json:"actor_id,omitempty"` // User ID, API key ID, node ID or certificate common name
+	Action       string    `json:"action"`             // e.g. "job.cancel"
+	TargetType   string    `json:"target_type,omitempty"`
+	TargetID     string    `json:"target_id,omitempty"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	RequestID    string    `json:"request_id"`
+	SourceIP     string    `json:"source_ip"`
+	ForwardedFor string    `json:"forwarded_for,omitempty"` // X-Forwarded-For as sent by the client or proxy
+	StatusCode   int       `json:"status_code"`
+	Outcome      string    `json:"outcome"`
+}
+
+// AuditFilter selects audit events. Empty fields match everything.
+type AuditFilter struct {
+	TenantID  string
+	ActorType string
+	ActorID   string
+	Action    string
+	TargetID  string
+	Outcome   string
+	Since     time.Time // Inclusive; zero for no lower bound
+	Until     time.Time // Exclusive; zero for no upper bound
+	Limit     int       // Most recent events to return; 0 for all
+}
+
+// Matches reports whether event is selected by the filter (ignoring Limit)
+func (f *AuditFilter) Matches(event *AuditEvent) bool {
+	return (f.TenantID == "" || event.TenantID == f.TenantID) &&
+		(f.ActorType == "" || event.ActorType == f.ActorType) &&
+		(f.ActorID == "" || event.ActorID == f.ActorID) &&
+		(f.Action == "" || event.Action == f.Action) &&
+		(f.TargetID == "" || event.TargetID == f.TargetID) &&
+		(f.Outcome == "" || event.Outcome == f.Outcome) &&
+		(f.Since.IsZero() || !event.Timestamp.Before(f.Since)) &&
+		(f.Until.IsZero() || event.Timestamp.Before(f.Until))
+}
+
+// AuditOutcome classifies an HTTP status code
+func AuditOutcome(statusCode int) string {
+	switch {
+	case statusCode == 401 || statusCode == 403:
+		return AuditOutcomeDenied
+	case statusCode >= 400:
+		return AuditOutcomeFailure
+	default:
+		return AuditOutcomeSuccess
+	}
+}
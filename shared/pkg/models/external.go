@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExternalParameter is the job parameter that holds the process of an
+// external job. Parameters are persisted by every store.
+const ExternalParameter = "external"
+
+// ExternalProcess is a process started outside the platform that a worker
+// discovered and governs. The master records it as a read-only external job.
+type ExternalProcess struct {
+	PID         int            `json:"pid"`
+	Command     string         `json:"command"`
+	CommandLine []string       `json:"command_line,omitempty"`
+	User        string         `json:"user,omitempty"`
+	UID         int            `json:"uid"`
+	WorkingDir  string         `json:"working_dir,omitempty"`
+	StartTime   time.Time      `json:"start_time"`
+	ExitCode    *int           `json:"exit_code,omitempty"` // Set once the process exited, -1 if unknown
+	Usage       *ExternalUsage `json:"usage,omitempty"`
+}
+
+// ExternalUsage is the resource usage of an external process and its children
+type ExternalUsage struct {
+	CPUSeconds   float64 `json:"cpu_seconds"`
+	MemoryPeak   int64   `json:"memory_peak_bytes"`
+	IOReadBytes  int64   `json:"io_read_bytes"`
+	IOWriteBytes int64   `json:"io_write_bytes"`
+	OOMKills     int64   `json:"oom_kills"`
+}
+
+// IsExternal returns true if the job is a process reported by a worker
+// rather than a job submitted to the master
+func (j *Job) IsExternal() bool {
+	if j.Classification == JobClassificationExternal {
+		return true
+	}
+	_, ok := j.Parameters[ExternalParameter]
+	return ok
+}
+
+// External returns the process of an external job, or nil for other jobs
+func (j *Job) External() *ExternalProcess {
+	raw, ok := j.Parameters[ExternalParameter]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var proc ExternalProcess
+	if err := json.Unmarshal(data, &proc); err != nil {
+		return nil
+	}
+	return &proc
+}
+
+// SetExternal records the process of an external job in its parameters and
+// classifies the job as external
+func (j *Job) SetExternal(proc *ExternalProcess) error {
+	data, err := json.Marshal(proc)
+	if err != nil {
+		return err
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if j.Parameters == nil {
+		j.Parameters = make(map[string]interface{})
+	}
+	j.Parameters[ExternalParameter] = value
+	j.Classification = JobClassificationExternal
+	return nil
+}
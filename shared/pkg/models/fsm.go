@@ -232,6 +232,11 @@ func (rp *RetryPolicy) ShouldRetry(job *Job, reason string) bool {
 		return false
 	}
 
+	// Never retry workloads that may have completed
+	if job.FailureReason == FailureReasonExitUnknown {
+		return false
+	}
+
 	// Never retry if explicitly marked as non-retryable
 	if job.Error != "" && contains(job.Error, "non-retryable") {
 		return false
@@ -32,6 +32,7 @@ const (
 	FailureReasonInputError         FailureReason = "input_error"         // Corrupt/invalid input file (USER ERROR - not SLA violation)
 	FailureReasonPlatformError      FailureReason = "platform_error"      // Platform/scheduler/worker failure (PLATFORM - SLA violation)
 	FailureReasonResourceError      FailureReason = "resource_error"      // Resource exhaustion/management failure (PLATFORM - SLA violation)
+	FailureReasonExitUnknown        FailureReason = "exit_unknown"        // Workload exit status lost with the worker (NEEDS INSPECTION - never retried, it may have completed)
 )
 
 // JobClassification represents the business classification of a job
@@ -42,14 +43,16 @@ const (
 	JobClassificationTest       JobClassification = "test"       // Test/development (not SLA-worthy)
 	JobClassificationBenchmark  JobClassification = "benchmark"  // Performance testing (metrics only)
 	JobClassificationDebug      JobClassification = "debug"      // Debugging/troubleshooting (not SLA-worthy)
+	JobClassificationExternal   JobClassification = "external"   // Started outside the platform, reported by a worker (read-only)
 )
 
 // WrapperConstraints defines resource constraints for the workload wrapper
 type WrapperConstraints struct {
-	CPUMax      string `json:"cpu_max,omitempty"`       // CPU quota in "quota period" format
-	CPUWeight   int    `json:"cpu_weight,omitempty"`    // CPU weight (1-10000, default 100)
-	MemoryMaxMB int64  `json:"memory_max_mb,omitempty"` // Memory limit in MB
-	IOMax       string `json:"io_max,omitempty"`        // IO max (cgroup v2 only)
+	CPUMax          string `json:"cpu_max,omitempty"`            // CPU quota in "quota period" format
+	CPUWeight       int    `json:"cpu_weight,omitempty"`         // CPU weight (1-10000, default 100)
+	MemoryMaxMB     int64  `json:"memory_max_mb,omitempty"`      // Memory limit in MB
+	MemorySwapMaxMB int64  `json:"memory_swap_max_mb,omitempty"` // Swap limit in MB on top of the memory limit
+	IOMax           string `json:"io_max,omitempty"`             // IO max ("major:minor rbps=X wbps=Y riops=X wiops=Y")
 }
 
 // Job represents a workload to be executed on a compute node
@@ -61,10 +64,11 @@ type Job struct {
 	Scenario         string                 `json:"scenario"`                  // e.g., "4K60-h264"
 	Confidence       string                 `json:"confidence"`                // "auto", "high", "medium", "low"
 	Engine           string                 `json:"engine,omitempty"`          // "auto", "ffmpeg", "gstreamer"
-	Classification   JobClassification      `json:"classification,omitempty"`  // "production", "test", "benchmark", "debug"
+	Classification   JobClassification      `json:"classification,omitempty"`  // "production", "test", "benchmark", "debug", "external"
 	WrapperEnabled   bool                   `json:"wrapper_enabled,omitempty"` // Use wrapper for execution
 	WrapperConstraints *WrapperConstraints  `json:"wrapper_constraints,omitempty"` // Resource constraints
 	Parameters       map[string]interface{} `json:"parameters,omitempty"`
+	SecretValues     []string               `json:"secret_values,omitempty"` // Resolved secrets to redact; only sent to the assigned worker
 	Status           JobStatus              `json:"status"`
 	Queue            string                 `json:"queue,omitempty"`    // "live", "default", "batch"
 	Priority         string                 `json:"priority,omitempty"` // "high", "medium", "low"
@@ -109,7 +113,8 @@ type JobResult struct {
 	Metrics         map[string]interface{} `json:"metrics,omitempty"`
 	AnalyzerOutput  map[string]interface{} `json:"analyzer_output,omitempty"`
 	Error           string                 `json:"error,omitempty"`
-	Logs            string                 `json:"logs,omitempty"` // Worker execution logs
+	FailureReason   FailureReason          `json:"failure_reason,omitempty"` // Explicit failure classification
+	Logs            string                 `json:"logs,omitempty"`           // Worker execution logs
 	CompletedAt     time.Time              `json:"completed_at"`
 	QoEScore        float64                `json:"qoe_score,omitempty"`
 	EfficiencyScore float64                `json:"efficiency_score,omitempty"`
@@ -127,8 +132,8 @@ type StateTransition struct {
 
 // IsSLAWorthy returns true if the job should be counted towards SLA compliance
 func (j *Job) IsSLAWorthy() bool {
-	// Production jobs are always SLA-worthy
-	if j.Classification == JobClassificationProduction {
+	// Production and external jobs are always SLA-worthy
+	if j.Classification == JobClassificationProduction || j.IsExternal() {
 		return true
 	}
 
@@ -193,6 +198,9 @@ func (j *Job) IsSLAWorthy() bool {
 
 // GetSLACategory returns a descriptive category for the job's SLA classification
 func (j *Job) GetSLACategory() string {
+	if j.IsExternal() {
+		return "external"
+	}
 	if j.IsSLAWorthy() {
 		return "production"
 	}
@@ -266,6 +274,15 @@ func (j *Job) CalculatePlatformSLACompliance(targets SLATimingTargets) (bool, st
 		return true, "not_sla_worthy"
 	}
 
+	// External jobs were not queued or started by the platform, so only
+	// failures to govern them count against it
+	if j.IsExternal() {
+		if j.IsPlatformFailure() {
+			return false, "platform_failure"
+		}
+		return true, "external_workload"
+	}
+
 	// Check if job failed due to platform error (SLA violation)
 	if j.Status == JobStatusFailed || j.Status == JobStatusTimedOut {
 		switch j.FailureReason {
@@ -58,3 +58,18 @@ type NodeCapabilities struct {
 	RAMTotalBytes   uint64            `json:"ram_total_bytes"`
 	Labels          map[string]string `json:"labels,omitempty"`
 }
+
+// NodeToken is the identity token the master issues to a node when it registers.
+// Nodes present it on heartbeats, job polls and results so the master knows
+// which node is calling, independently of the shared or worker key.
+type NodeToken struct {
+	NodeID    string     `json:"node_id"`
+	TokenHash string     `json:"token_hash"` // SHA-256 of the token (the token itself is never stored)
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil if the token never expires
+}
+
+// IsExpired reports whether the token has expired
+func (t *NodeToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// BootstrapToken is a one-time token a worker exchanges for its first client certificate
+type BootstrapToken struct {
+	ID          string     `json:"id"`
+	TokenHash   string     `json:"-"` // SHA-256 of the token (the token itself is never stored)
+	Description string     `json:"description,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	UsedBy      string     `json:"used_by,omitempty"` // Common name of the certificate issued with the token
+}
+
+// BootstrapTokenRequest represents a request to create a bootstrap token
+type BootstrapTokenRequest struct {
+	Description string `json:"description,omitempty"`
+	ExpiresIn   string `json:"expires_in,omitempty"` // e.g. "24h"; defaults to 24 hours
+}
+
+// IssuedCertificate records a client certificate issued by the master's CA
+type IssuedCertificate struct {
+	Serial     string     `json:"serial"` // Hexadecimal serial number
+	CommonName string     `json:"common_name"`
+	NotBefore  time.Time  `json:"not_before"`
+	NotAfter   time.Time  `json:"not_after"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsRevoked reports whether the certificate has been revoked
+func (c *IssuedCertificate) IsRevoked() bool {
+	return c.RevokedAt != nil
+}
+
+// IsExpired reports whether the certificate has expired
+func (c *IssuedCertificate) IsExpired() bool {
+	return time.Now().After(c.NotAfter)
+}
+
+// EnrollmentRequest is sent by a worker to obtain its first client certificate.
+// The certificate's common name is taken from the CSR.
+type EnrollmentRequest struct {
+	Token string `json:"token"` // One-time bootstrap token
+	CSR   string `json:"csr"`   // PEM-encoded certificate signing request
+}
+
+// RenewalRequest is sent by a worker, over mTLS with its current certificate, to renew it.
+// The renewed certificate keeps the current certificate's common name.
+type RenewalRequest struct {
+	CSR string `json:"csr"` // PEM-encoded certificate signing request for a new key
+}
+
+// CertificateResponse carries a newly issued client certificate
+type CertificateResponse struct {
+	Certificate   string    `json:"certificate"`    // PEM-encoded client certificate
+	CACertificate string    `json:"ca_certificate"` // PEM-encoded CA certificate
+	Serial        string    `json:"serial"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimitBucket is the persisted state of a token bucket shared by masters
+type RateLimitBucket struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`     // Tokens left at UpdatedAt
+	UpdatedAt time.Time `json:"updated_at"` // Last time a token was taken
+	FullAt    time.Time `json:"full_at"`    // When the bucket will be full again; full buckets can be deleted
+}
+
+// RateLimitDecision is the outcome of taking a token from a bucket
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int           // Bucket size (burst)
+	Remaining  int           // Whole tokens left after this request
+	RetryAfter time.Duration // How long until a token is available, when not allowed
+	ResetAfter time.Duration // How long until the bucket is full again
+}
+
+// NewRateLimitBucket creates a full bucket
+func NewRateLimitBucket(key string, burst int, now time.Time) *RateLimitBucket {
+	return &RateLimitBucket{Key: key, Tokens: float64(burst), UpdatedAt: now, FullAt: now}
+}
+
+// Take refills the bucket at rate tokens per second up to burst and takes one
+// token if available. The bucket is only changed when the token is taken.
+func (b *RateLimitBucket) Take(rate float64, burst int, now time.Time) RateLimitDecision {
+	tokens := math.Min(float64(burst), b.Tokens)
+	if rate > 0 && now.After(b.UpdatedAt) {
+		tokens = math.Min(float64(burst), b.Tokens+now.Sub(b.UpdatedAt).Seconds()*rate)
+	}
+
+	decision := RateLimitDecision{Limit: burst}
+	if tokens < 1 {
+		decision.Remaining = 0
+		decision.RetryAfter = rateLimitDuration(1-tokens, rate)
+		decision.ResetAfter = rateLimitDuration(float64(burst)-tokens, rate)
+		return decision
+	}
+
+	tokens--
+	b.Tokens = tokens
+	b.UpdatedAt = now
+	b.FullAt = now.Add(rateLimitDuration(float64(burst)-tokens, rate))
+	decision.Allowed = true
+	decision.Remaining = int(tokens)
+	decision.ResetAfter = b.FullAt.Sub(now)
+	return decision
+}
+
+// rateLimitDuration returns how long refilling tokens takes at rate
+func rateLimitDuration(tokens, rate float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	seconds := tokens / rate
+	if rate <= 0 || seconds > math.MaxInt64/float64(time.Second) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result aggregation groupings
+const (
+	ResultGroupScenario = "scenario"
+	ResultGroupEngine   = "engine"
+	ResultGroupNode     = "node"
+)
+
+// IsValidResultGroup returns true if groupBy is a supported result aggregation grouping
+func IsValidResultGroup(groupBy string) bool {
+	return groupBy == ResultGroupScenario || groupBy == ResultGroupEngine || groupBy == ResultGroupNode
+}
+
+// ResultMetrics holds the common metrics extracted from JobResult.Metrics.
+// Zero values mean the metric was not reported.
+type ResultMetrics struct {
+	FPS             float64 `json:"fps,omitempty"`
+	BitrateKbps     float64 `json:"bitrate_kbps,omitempty"`
+	Frames          int64   `json:"frames,omitempty"`
+	DroppedFrames   int64   `json:"dropped_frames,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	OutputBytes     int64   `json:"output_bytes,omitempty"`
+	Engine          string  `json:"engine,omitempty"` // Engine actually used (may differ from the requested "auto")
+}
+
+// ExtractMetrics reads the common metrics from the free-form Metrics map.
+// Workers report some values as strings (parsed from ffmpeg output) and use
+// different keys depending on the engine, so several aliases are accepted.
+func (r *JobResult) ExtractMetrics() ResultMetrics {
+	m := r.Metrics
+	metrics := ResultMetrics{
+		FPS:             metricFloat(m, "fps", "avg_fps", "encoding_fps"),
+		Frames:          int64(metricFloat(m, "frames", "total_frames", "frames_encoded")),
+		DroppedFrames:   int64(metricFloat(m, "dropped_frames")),
+		DurationSeconds: metricFloat(m, "duration", "duration_seconds", "transcode_duration_sec"),
+		OutputBytes:     int64(metricFloat(m, "output_file_bytes", "output_size_bytes")),
+	}
+
+	if v, ok := m["bitrate_kbps"]; ok {
+		metrics.BitrateKbps, _ = toFloat(v)
+	} else if v, ok := m["bitrate"]; ok {
+		metrics.BitrateKbps = ParseBitrateKbps(v)
+	}
+
+	if engine, ok := m["engine"].(string); ok {
+		metrics.Engine = engine
+	}
+	return metrics
+}
+
+// ParseBitrateKbps converts a bitrate such as "10M", "2500k", "2500kbps" or a number (bits/s) to kbit/s
+func ParseBitrateKbps(v interface{}) float64 {
+	s, ok := v.(string)
+	if !ok {
+		bps, _ := toFloat(v)
+		return bps / 1000
+	}
+
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "bps"), "bit/s")
+	multiplier := 0.001 // Plain numbers are bits/s
+	switch {
+	case strings.HasSuffix(s, "g"):
+		multiplier, s = 1000000, strings.TrimSuffix(s, "g")
+	case strings.HasSuffix(s, "m"):
+		multiplier, s = 1000, strings.TrimSuffix(s, "m")
+	case strings.HasSuffix(s, "k"):
+		multiplier, s = 1, strings.TrimSuffix(s, "k")
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return value * multiplier
+}
+
+// metricFloat returns the first numeric value found under any of the keys
+func metricFloat(m map[string]interface{}, keys ...string) float64 {
+	for _, key := range keys {
+		if v, ok := m[key]; ok {
+			if f, ok := toFloat(v); ok {
+				return f
+			}
+		}
+	}
+	return 0
+}
+
+// toFloat converts JSON numbers and numeric strings to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// ResultAggregate summarizes completed job results for one scenario, engine or node.
+// Averages only include results that reported the metric.
+type ResultAggregate struct {
+	GroupBy            string    `json:"group_by"` // "scenario", "engine" or "node"
+	Key                string    `json:"key"`
+	Count              int       `json:"count"`
+	AvgFPS             float64   `json:"avg_fps"`
+	AvgBitrateKbps     float64   `json:"avg_bitrate_kbps"`
+	AvgDurationSeconds float64   `json:"avg_duration_seconds"`
+	AvgVMAFScore       float64   `json:"avg_vmaf_score"`
+	AvgQoEScore        float64   `json:"avg_qoe_score"`
+	AvgEfficiencyScore float64   `json:"avg_efficiency_score"`
+	AvgEnergyJoules    float64   `json:"avg_energy_joules"`
+	TotalEnergyJoules  float64   `json:"total_energy_joules"`
+	TotalFrames        int64     `json:"total_frames"`
+	TotalDroppedFrames int64     `json:"total_dropped_frames"`
+	LastCompletedAt    time.Time `json:"last_completed_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Secret is a named value, such as a stream key or storage credential, that jobs
+// reference in their parameters as {"$secret": "<name>"}. The value is encrypted
+// with the master's secrets key and never returned by the API.
+type Secret struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"` // The default tenant for cluster-wide jobs
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Ciphertext  []byte    `json:"-"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SecretRequest represents a request to create or update a secret
+type SecretRequest struct {
+	Name        string  `json:"name,omitempty"` // Only on creation
+	Value       string  `json:"value"`
+	Description *string `json:"description,omitempty"`
+}
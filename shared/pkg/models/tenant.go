@@ -1,18 +1,114 @@
 package models
 
 import (
+	"fmt"
+	"regexp"
 	"time"
 )
 
+// DefaultTenantID is the tenant that owns jobs submitted without a tenant API key
+const DefaultTenantID = "default"
+
+// Tenant status values
+const (
+	TenantStatusActive    = "active"
+	TenantStatusSuspended = "suspended"
+	TenantStatusDeleted   = "deleted"
+)
+
+// Tenant API key status values
+const (
+	TenantAPIKeyStatusActive  = "active"
+	TenantAPIKeyStatusRevoked = "revoked"
+)
+
+// Tenant API key kinds. API keys can never register workers, so a leaked
+// CI or automation key cannot be used to attach rogue nodes to the cluster.
+const (
+	TenantAPIKeyKindAPI    = "api"    // Automation, CI pipelines and integrations
+	TenantAPIKeyKindWorker = "worker" // Worker registration and job execution only
+)
+
+// WorkerKeyPermissions are the permissions of worker API keys
+var WorkerKeyPermissions = []Permission{
+	PermNodeRegister, PermNodeRead, PermNodeUpdate,
+	PermJobRead, PermJobUpdate,
+}
+
+// tenantNamePattern matches valid tenant names (lowercase letters, digits and hyphens)
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
 // Tenant represents an organization or customer in the system
 type Tenant struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Slug      string    `json:"slug"` // URL-friendly identifier
-	Plan      string    `json:"plan"` // "free", "pro", "enterprise"
-	Status    string    `json:"status"` // "active", "suspended", "deleted"
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`         // Unique URL-friendly identifier
+	DisplayName string                 `json:"display_name"` // Human-friendly name
+	Plan        string                 `json:"plan"`         // "free", "pro", "enterprise"
+	Status      string                 `json:"status"`       // "active", "suspended", "deleted"
+	Quotas      TenantQuota            `json:"quotas"`
+	Usage       TenantUsage            `json:"usage"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+}
+
+// NewTenant creates an active tenant with the default quotas of its plan
+func NewTenant(id, name, plan string) *Tenant {
+	if plan == "" {
+		plan = "free"
+	}
+	now := time.Now()
+	quotas := *DefaultTenantQuotas(plan)
+	quotas.TenantID = id
+	quotas.UpdatedAt = now
+	return &Tenant{
+		ID:          id,
+		Name:        name,
+		DisplayName: name,
+		Plan:        plan,
+		Status:      TenantStatusActive,
+		Quotas:      quotas,
+		Usage:       TenantUsage{TenantID: id, LastUpdated: now},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// Validate checks that the tenant has a valid name, plan and status
+func (t *Tenant) Validate() error {
+	if t.ID == "" {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if !tenantNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid tenant name '%s': use lowercase letters, digits and hyphens", t.Name)
+	}
+	if !IsValidTenantPlan(t.Plan) {
+		return fmt.Errorf("invalid plan '%s'. Valid values: free, pro, enterprise", t.Plan)
+	}
+	switch t.Status {
+	case TenantStatusActive, TenantStatusSuspended, TenantStatusDeleted:
+	default:
+		return fmt.Errorf("invalid status '%s'. Valid values: active, suspended, deleted", t.Status)
+	}
+	return nil
+}
+
+// IsActive reports whether the tenant may use the API
+func (t *Tenant) IsActive() bool {
+	if t.Status != TenantStatusActive {
+		return false
+	}
+	return t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt)
+}
+
+// IsValidTenantPlan reports whether plan is a known tenant plan
+func IsValidTenantPlan(plan string) bool {
+	switch plan {
+	case "free", "pro", "enterprise":
+		return true
+	}
+	return false
 }
 
 // TenantQuota represents resource limits for a tenant
@@ -28,6 +124,12 @@ type TenantQuota struct {
 	UpdatedAt          time.Time `json:"updated_at"`
 }
 
+// AllowsConcurrentJob reports whether a tenant running the given number of jobs may start another.
+// Negative limits are unlimited.
+func (q TenantQuota) AllowsConcurrentJob(running int) bool {
+	return q.MaxConcurrentJobs < 0 || running < q.MaxConcurrentJobs
+}
+
 // TenantUsage tracks current resource usage for a tenant
 type TenantUsage struct {
 	TenantID           string    `json:"tenant_id"`
@@ -62,9 +164,10 @@ type TenantAPIKey struct {
 	ID         string    `json:"id"`
 	TenantID   string    `json:"tenant_id"`
 	Name       string    `json:"name"`       // Human-friendly name
-	KeyHash    string    `json:"key_hash"`   // Hashed API key (never store plain)
+	Kind       string    `json:"kind"`       // "api" or "worker"
+	KeyHash    string    `json:"-"`          // Hashed API key (never store plain, never expose)
 	KeyPrefix  string    `json:"key_prefix"` // First 8 chars for identification (e.g., "ffrtmp_prod_abc123...")
-	Scopes     []string  `json:"scopes"`     // ["jobs:read", "jobs:write", "nodes:read"]
+	Scopes     []string  `json:"scopes"`     // Permissions of API keys, e.g. ["job:read", "job:create"]; empty grants all
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -72,11 +175,96 @@ type TenantAPIKey struct {
 	Status     string    `json:"status"`     // "active", "revoked"
 }
 
+// IsUsable reports whether the key is active and has not expired
+func (k *TenantAPIKey) IsUsable() bool {
+	if k.Status != TenantAPIKeyStatusActive {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+// Permissions returns the permissions granted by the key. Worker keys have
+// WorkerKeyPermissions; API keys have their scopes, or every permission
+// except worker registration if they have none.
+func (k *TenantAPIKey) Permissions() []Permission {
+	if k.Kind == TenantAPIKeyKindWorker {
+		return WorkerKeyPermissions
+	}
+	if len(k.Scopes) == 0 {
+		return apiKeyPermissions()
+	}
+	perms := make([]Permission, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		if perm := Permission(scope); perm != PermNodeRegister {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// HasPermission reports whether the key grants perm
+func (k *TenantAPIKey) HasPermission(perm Permission) bool {
+	for _, p := range k.Permissions() {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTenantAPIKeyScopes checks the kind and scopes of a new API key.
+// Scopes must be known permissions; worker keys have fixed permissions and
+// take no scopes, and API keys cannot be granted node:register.
+func ValidateTenantAPIKeyScopes(kind string, scopes []string) error {
+	switch kind {
+	case TenantAPIKeyKindWorker:
+		if len(scopes) > 0 {
+			return fmt.Errorf("worker keys have fixed permissions and take no scopes")
+		}
+		return nil
+	case TenantAPIKeyKindAPI:
+	default:
+		return fmt.Errorf("invalid key kind '%s'. Valid values: api, worker", kind)
+	}
+
+	for _, scope := range scopes {
+		if Permission(scope) == PermNodeRegister {
+			return fmt.Errorf("API keys cannot register workers: create a worker key instead")
+		}
+		if !RoleAdmin.HasPermission(Permission(scope)) {
+			return fmt.Errorf("unknown scope '%s'", scope)
+		}
+	}
+	return nil
+}
+
+// apiKeyPermissions returns every permission except worker registration
+func apiKeyPermissions() []Permission {
+	all := RoleAdmin.GetPermissions()
+	perms := make([]Permission, 0, len(all))
+	for _, perm := range all {
+		if perm != PermNodeRegister {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// TenantAPIKeyRequest represents a request to issue a tenant API key
+type TenantAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Kind      string     `json:"kind,omitempty"`   // "api" (default) or "worker"
+	Scopes    []string   `json:"scopes,omitempty"` // API key permissions; empty grants all but node:register
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
 // TenantRequest represents a request to create a new tenant
 type TenantRequest struct {
-	Name string `json:"name"`
-	Slug string `json:"slug,omitempty"` // Auto-generated if not provided
-	Plan string `json:"plan,omitempty"` // Defaults to "free"
+	Name        string                 `json:"name"`
+	DisplayName string                 `json:"display_name,omitempty"` // Defaults to the name
+	Plan        string                 `json:"plan,omitempty"`         // Defaults to "free"
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
 }
 
 // DefaultTenantQuotas returns default quotas based on plan
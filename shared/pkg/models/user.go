@@ -49,6 +49,23 @@ const (
 
 	// Metrics permissions
 	PermMetricsRead Permission = "metrics:read"
+
+	// Audit permissions
+	PermAuditRead Permission = "audit:read"
+
+	// Secret permissions; secret values can be written but never read back
+	PermSecretRead  Permission = "secret:read"
+	PermSecretWrite Permission = "secret:write"
+
+	// System permissions
+	PermSystemBackup Permission = "system:backup"
+	PermSystemPKI    Permission = "system:pki" // Bootstrap tokens and worker certificates
+)
+
+// User statuses
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
 )
 
 // User represents a user in the system
@@ -68,9 +85,11 @@ type User struct {
 // UserRequest represents a request to create or update a user
 type UserRequest struct {
 	Email    string `json:"email"`
-	Password string `json:"password,omitempty"` // Only for creation
+	Password string `json:"password,omitempty"` // Required on creation, optional on update
 	FullName string `json:"full_name"`
 	Role     Role   `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"` // Only honored for cluster-wide administrators
+	Status   string `json:"status,omitempty"`    // Only on update: "active" or "suspended"
 }
 
 // LoginRequest represents a login request
@@ -91,7 +110,8 @@ type Session struct {
 	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
 	TenantID  string    `json:"tenant_id"`
-	Token     string    `json:"token"`
+	Token     string    `json:"token"`       // Only set when the session is created
+	TokenHash string    `json:"-"`           // SHA-256 of the token (the token itself is never stored)
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 	IPAddress string    `json:"ip_address,omitempty"`
@@ -108,6 +128,9 @@ var RolePermissions = map[Role][]Permission{
 		PermUserCreate, PermUserRead, PermUserUpdate, PermUserDelete,
 		PermAPIKeyCreate, PermAPIKeyRead, PermAPIKeyRevoke,
 		PermMetricsRead,
+		PermAuditRead,
+		PermSecretRead, PermSecretWrite,
+		PermSystemBackup, PermSystemPKI,
 	},
 	RoleOperator: {
 		// Job and node management, read-only for tenant/users
@@ -117,12 +140,14 @@ var RolePermissions = map[Role][]Permission{
 		PermUserRead,
 		PermAPIKeyRead,
 		PermMetricsRead,
+		PermSecretRead, PermSecretWrite,
 	},
 	RoleDeveloper: {
 		// Can create and manage own jobs, read nodes
 		PermJobCreate, PermJobRead, PermJobCancel,
 		PermNodeRead,
 		PermMetricsRead,
+		PermSecretRead,
 	},
 	RoleViewer: {
 		// Read-only access
@@ -158,3 +183,13 @@ func (r Role) IsValid() bool {
 	_, ok := RolePermissions[r]
 	return ok
 }
+
+// IsActive reports whether the user may log in and use existing sessions
+func (u *User) IsActive() bool {
+	return u.Status == UserStatusActive
+}
+
+// IsExpired reports whether the session has expired
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
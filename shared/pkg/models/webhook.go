@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook represents a subscription to job lifecycle events
+type Webhook struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id,omitempty"` // Only receives events for this tenant's jobs (empty = all)
+	URL       string    `json:"url"`
+	Events    []string  `json:"events,omitempty"` // Job statuses to deliver, e.g. ["completed", "failed"] (empty = all)
+	Secret    string    `json:"secret,omitempty"` // HMAC-SHA256 signing secret (only returned on creation)
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookRequest represents a request to create or update a webhook
+type WebhookRequest struct {
+	URL     string   `json:"url"`
+	Events  []string `json:"events,omitempty"`
+	Secret  string   `json:"secret,omitempty"`  // Generated if not provided on creation
+	Enabled *bool    `json:"enabled,omitempty"` // Defaults to true on creation
+}
+
+// WebhookDelivery records a delivery of a single event to a webhook
+type WebhookDelivery struct {
+	ID           string     `json:"id"`
+	WebhookID    string     `json:"webhook_id"`
+	JobID        string     `json:"job_id"`
+	Event        string     `json:"event"` // e.g. "job.completed"
+	Payload      string     `json:"payload"`
+	Status       string     `json:"status"` // "pending", "succeeded", "failed"
+	Attempts     int        `json:"attempts"`
+	ResponseCode int        `json:"response_code,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the JSON body posted to webhook receivers
+type WebhookPayload struct {
+	DeliveryID string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Timestamp  time.Time `json:"timestamp"`
+	Job        *Job      `json:"job"`
+}
+
+// webhookStates are the job states events are delivered for
+var webhookStates = map[JobStatus]bool{
+	JobStatusQueued:    true,
+	JobStatusAssigned:  true,
+	JobStatusRunning:   true,
+	JobStatusCompleted: true,
+	JobStatusFailed:    true,
+	JobStatusTimedOut:  true,
+	JobStatusRetrying:  true,
+	JobStatusCanceled:  true,
+	JobStatusRejected:  true,
+}
+
+// webhookEventState returns the normalized job state an event filter names,
+// e.g. "failed" or "job.failed"
+func webhookEventState(event string) JobStatus {
+	return normalizeState(JobStatus(strings.TrimPrefix(event, "job.")))
+}
+
+// ValidateWebhookEvents rejects event filters that would never match a job event
+func ValidateWebhookEvents(events []string) error {
+	for _, event := range events {
+		if event != "*" && !webhookStates[webhookEventState(event)] {
+			return fmt.Errorf("unknown webhook event '%s'", event)
+		}
+	}
+	return nil
+}
+
+// WebhookEventForStatus returns the event name delivered for a job status
+func WebhookEventForStatus(status JobStatus) string {
+	return "job." + string(normalizeState(status))
+}
+
+// MatchesEvent returns true if the webhook subscribes to the given job status
+func (w *Webhook) MatchesEvent(status JobStatus) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	normalized := normalizeState(status)
+	for _, event := range w.Events {
+		if event == "*" || webhookEventState(event) == normalized {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestWebhookMatchesEvent(t *testing.T) {
+	tests := []struct {
+		events []string
+		status JobStatus
+		want   bool
+	}{
+		{nil, JobStatusCompleted, true},
+		{[]string{"*"}, JobStatusFailed, true},
+		{[]string{"timed_out"}, JobStatusTimedOut, true},
+		{[]string{"job.timed_out"}, JobStatusTimedOut, true},
+		{[]string{"completed"}, JobStatusFailed, false},
+		{[]string{"pending"}, JobStatusQueued, true},
+		{[]string{"job.processing"}, JobStatusRunning, true},
+		{[]string{"queued"}, JobStatusPending, true},
+	}
+
+	for _, tt := range tests {
+		hook := &Webhook{Events: tt.events}
+		if got := hook.MatchesEvent(tt.status); got != tt.want {
+			t.Errorf("MatchesEvent(%s) with events %v = %v, want %v", tt.status, tt.events, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhookEvents(t *testing.T) {
+	valid := [][]string{nil, {"*"}, {"completed", "job.failed", "timed_out"}, {"pending", "job.processing"}}
+	for _, events := range valid {
+		if err := ValidateWebhookEvents(events); err != nil {
+			t.Errorf("Expected events %v to be valid, got %v", events, err)
+		}
+	}
+
+	invalid := [][]string{{"finished"}, {"completed", "job.done"}, {"job."}, {""}, {"job.*"}}
+	for _, events := range invalid {
+		if err := ValidateWebhookEvents(events); err == nil {
+			t.Errorf("Expected events %v to be rejected", events)
+		}
+	}
+}
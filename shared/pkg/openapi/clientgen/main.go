@@ -0,0 +1,25 @@
+// Command clientgen generates the typed master API client in pkg/client from
+// the OpenAPI document. It is run by go generate in pkg/client.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/openapi"
+)
+
+func main() {
+	output := flag.String("o", "client_gen.go", "output file")
+	pkg := flag.String("package", "client", "package name")
+	flag.Parse()
+
+	src, err := openapi.GenerateClient(openapi.Spec(), *pkg)
+	if err != nil {
+		log.Fatalf("Failed to generate client: %v", err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *output, err)
+	}
+}
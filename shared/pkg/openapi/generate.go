@@ -0,0 +1,508 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GeneratedHeader marks the generated client source
+const GeneratedHeader = "// Code generated by go run github.com/psantana5/ffmpeg-rtmp/pkg/openapi/clientgen; DO NOT EDIT."
+
+// initialisms are written in upper case in Go names
+var initialisms = map[string]bool{
+	"api": true, "ca": true, "cpu": true, "csr": true, "gpu": true, "http": true,
+	"id": true, "ip": true, "json": true, "openapi": true, "ram": true, "sla": true,
+	"tls": true, "ttl": true, "url": true,
+}
+
+// GenerateClient generates Go source for package pkg with a method on Client
+// for every operation of doc, a type for every component schema without an
+// x-go-type, and a parameters type for operations with optional query
+// parameters. The methods rely on the hand-written Client.do and Client.send.
+func GenerateClient(doc *Document, pkg string) ([]byte, error) {
+	g := &generator{doc: doc, imports: map[string]bool{"context": true}}
+
+	var types bytes.Buffer
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema := doc.Components.Schemas[name]
+		if schema.GoType != "" {
+			continue
+		}
+		if err := g.writeType(&types, name, schema); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	var methods bytes.Buffer
+	for _, route := range doc.Routes() {
+		if err := g.writeMethod(&methods, route); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", route.Method, route.Path, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\nimport (\n", GeneratedHeader, pkg)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	// Standard library imports first, then module imports
+	for _, module := range []bool{false, true} {
+		if module {
+			out.WriteString("\n")
+		}
+		for _, imp := range imports {
+			if strings.Contains(strings.Split(imp, "/")[0], ".") == module {
+				fmt.Fprintf(&out, "\t%q\n", imp)
+			}
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(types.Bytes())
+	out.Write(methods.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go source: %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	doc     *Document
+	imports map[string]bool
+}
+
+// writeType writes the struct type of a component schema
+func (g *generator) writeType(buf *bytes.Buffer, name string, s *Schema) error {
+	if s.Description != "" {
+		writeComment(buf, "", name+" is "+lowerFirst(s.Description))
+	} else {
+		writeComment(buf, "", name+" is the "+name+" schema of the master API")
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+
+	// allOf references are embedded; inline allOf objects add fields
+	objects := []*Schema{s}
+	for _, part := range s.AllOf {
+		if part.Ref != "" {
+			typ, err := g.goType(part, true)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "\t%s\n", typ)
+			continue
+		}
+		objects = append(objects, part)
+	}
+	for _, object := range objects {
+		if err := g.writeFields(buf, object); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+// writeFields writes a field for every property of an object schema
+func (g *generator) writeFields(buf *bytes.Buffer, s *Schema) error {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	props := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		props = append(props, name)
+	}
+	sort.Strings(props)
+
+	for _, name := range props {
+		prop := s.Properties[name]
+		typ, err := g.goType(prop, required[name])
+		if err != nil {
+			return fmt.Errorf("property %s: %w", name, err)
+		}
+		tag := name
+		if !required[name] {
+			tag += ",omitempty"
+		}
+		if prop.Description != "" {
+			writeComment(buf, "\t", prop.Description)
+		}
+		fmt.Fprintf(buf, "\t%s %s `json:%q`\n", goName(name), typ, tag)
+	}
+	return nil
+}
+
+// goType returns the Go type of a schema. Optional and nullable values
+// are pointers, except slices and maps.
+func (g *generator) goType(s *Schema, required bool) (string, error) {
+	pointer := func(typ string) string {
+		if !required || s.Nullable {
+			return "*" + typ
+		}
+		return typ
+	}
+
+	// A single allOf reference makes a reference nullable
+	if s.Ref == "" && len(s.AllOf) == 1 && s.AllOf[0].Ref != "" && len(s.Properties) == 0 {
+		typ, err := g.goType(s.AllOf[0], true)
+		return pointer(typ), err
+	}
+	if s.Ref != "" {
+		target := g.doc.resolve(s)
+		if target == nil {
+			return "", fmt.Errorf("unknown schema %s", s.Ref)
+		}
+		typ := SchemaName(s.Ref)
+		if target.GoType != "" {
+			typ = target.GoType
+			g.importFor(typ)
+		}
+		return pointer(typ), nil
+	}
+
+	switch s.Type {
+	case "array":
+		if s.Items == nil {
+			return "[]interface{}", nil
+		}
+		item, err := g.goType(s.Items, true)
+		return "[]" + item, err
+	case "object":
+		if s.AdditionalProperties != nil {
+			value, err := g.goType(s.AdditionalProperties, true)
+			return "map[string]" + value, err
+		}
+		if len(s.Properties) == 0 {
+			return "map[string]interface{}", nil
+		}
+		return "", fmt.Errorf("inline objects are not supported: add a component schema")
+	case "string":
+		if s.Format == "date-time" {
+			g.imports["time"] = true
+			return pointer("time.Time"), nil
+		}
+		return pointer("string"), nil
+	case "integer":
+		if s.Format == "int64" {
+			return pointer("int64"), nil
+		}
+		return pointer("int"), nil
+	case "number":
+		return pointer("float64"), nil
+	case "boolean":
+		return pointer("bool"), nil
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.Type)
+}
+
+// importFor records the import of a qualified type such as models.Job
+func (g *generator) importFor(typ string) {
+	if strings.HasPrefix(typ, "models.") {
+		g.imports["github.com/psantana5/ffmpeg-rtmp/pkg/models"] = true
+	}
+}
+
+// writeMethod writes the client method of an operation
+func (g *generator) writeMethod(buf *bytes.Buffer, route Route) error {
+	op := route.Operation
+	name := goName(op.OperationID)
+
+	// Path parameters and required query parameters are arguments;
+	// optional query parameters are fields of a parameters type
+	args := []string{"ctx context.Context"}
+	var optional []*Parameter
+	var queryArgs []*Parameter
+	for _, param := range op.Parameters {
+		switch {
+		case param.In == "path":
+			args = append(args, argName(param.Name)+" string")
+		case param.In == "query" && param.Required:
+			typ, err := g.goType(param.Schema, true)
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", param.Name, err)
+			}
+			args = append(args, argName(param.Name)+" "+typ)
+			queryArgs = append(queryArgs, param)
+		case param.In == "query":
+			optional = append(optional, param)
+		}
+	}
+	if len(optional) > 0 {
+		if err := g.writeParams(buf, name, optional); err != nil {
+			return err
+		}
+		args = append(args, "params *"+name+"Params")
+	}
+
+	// The request body is a JSON value or a raw stream
+	body := "nil"
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content[ContentTypeJSON]; ok {
+			typ, err := g.bodyType(media.Schema)
+			if err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+			args = append(args, "body *"+typ)
+		} else {
+			g.imports["io"] = true
+			args = append(args, "body io.Reader")
+		}
+		body = "body"
+	}
+
+	// The result is the schema of the successful responses
+	var statuses []string
+	var result *MediaType
+	resultType := ""
+	raw := false
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		statuses = append(statuses, code)
+		resp := op.Responses[code]
+		if result != nil || len(resp.Content) == 0 {
+			continue
+		}
+		if media, ok := resp.Content[ContentTypeJSON]; ok {
+			result = media
+			typ, err := g.goType(media.Schema, true)
+			if err != nil {
+				return fmt.Errorf("response %s: %w", code, err)
+			}
+			resultType = typ
+		} else {
+			for _, media := range resp.Content {
+				result = media
+			}
+			raw = true
+		}
+	}
+	if len(statuses) == 0 {
+		return fmt.Errorf("no successful response")
+	}
+
+	comment := name + " calls " + route.Method + " " + route.Path
+	if op.Summary != "" {
+		comment += ": " + lowerFirst(op.Summary)
+	}
+	writeComment(buf, "", comment)
+	returns := "error"
+	switch {
+	case raw:
+		buf.WriteString("// The caller must close the returned body.\n")
+		returns = "(*Download, error)"
+	case result != nil:
+		if strings.HasPrefix(resultType, "map[") {
+			returns = "(" + resultType + ", error)"
+		} else {
+			returns = "(*" + resultType + ", error)"
+		}
+	}
+	fmt.Fprintf(buf, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), returns)
+
+	// Query
+	query := "nil"
+	if len(queryArgs) > 0 || len(optional) > 0 {
+		g.imports["net/url"] = true
+		query = "query"
+		buf.WriteString("\tquery := url.Values{}\n")
+		for _, param := range queryArgs {
+			fmt.Fprintf(buf, "\tquery.Set(%q, %s)\n", param.Name, g.formatQuery(param.Schema, argName(param.Name)))
+		}
+		if len(optional) > 0 {
+			buf.WriteString("\tif params != nil {\n")
+			for _, param := range optional {
+				field := "params." + goName(param.Name)
+				fmt.Fprintf(buf, "\t\tif %s != %s {\n\t\t\tquery.Set(%q, %s)\n\t\t}\n",
+					field, zeroValue(param.Schema), param.Name, g.formatQuery(param.Schema, field))
+			}
+			buf.WriteString("\t}\n")
+		}
+	}
+
+	path := g.pathExpr(route.Path)
+	statusList := strings.Join(statuses, ", ")
+	switch {
+	case raw:
+		fmt.Fprintf(buf, "\tresp, err := c.send(ctx, %q, %s, %s, %s, %s)\n", route.Method, path, query, body, statusList)
+		buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn &Download{ReadCloser: resp.Body, Header: resp.Header}, nil\n}\n\n")
+	case result == nil:
+		fmt.Fprintf(buf, "\treturn c.do(ctx, %q, %s, %s, %s, nil, %s)\n}\n\n", route.Method, path, query, body, statusList)
+	case strings.HasPrefix(resultType, "map["):
+		fmt.Fprintf(buf, "\tvar out %s\n", resultType)
+		fmt.Fprintf(buf, "\tif err := c.do(ctx, %q, %s, %s, %s, &out, %s); err != nil {\n\t\treturn nil, err\n\t}\n\treturn out, nil\n}\n\n",
+			route.Method, path, query, body, statusList)
+	default:
+		fmt.Fprintf(buf, "\tvar out %s\n", resultType)
+		fmt.Fprintf(buf, "\tif err := c.do(ctx, %q, %s, %s, %s, &out, %s); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n",
+			route.Method, path, query, body, statusList)
+	}
+	return nil
+}
+
+// bodyType returns the Go type of a JSON request body: a reference,
+// optionally combined with allOf constraints that add no properties
+func (g *generator) bodyType(s *Schema) (string, error) {
+	if s.Ref == "" && len(s.AllOf) > 0 && s.AllOf[0].Ref != "" {
+		for _, part := range s.AllOf[1:] {
+			if part.Ref != "" || len(part.Properties) > 0 {
+				return "", fmt.Errorf("allOf request bodies may only add constraints to one schema")
+			}
+		}
+		s = s.AllOf[0]
+	}
+	if s.Ref == "" {
+		return "", fmt.Errorf("request bodies must reference a component schema")
+	}
+	return g.goType(s, true)
+}
+
+// writeParams writes the type holding the optional query parameters of an operation
+func (g *generator) writeParams(buf *bytes.Buffer, method string, params []*Parameter) error {
+	fmt.Fprintf(buf, "// %sParams holds the optional query parameters of %s; zero values are omitted\n", method, method)
+	fmt.Fprintf(buf, "type %sParams struct {\n", method)
+	for _, param := range params {
+		typ, err := g.goType(param.Schema, true)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		if param.Description != "" {
+			writeComment(buf, "\t", param.Description)
+		}
+		fmt.Fprintf(buf, "\t%s %s\n", goName(param.Name), typ)
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+// formatQuery returns the expression formatting a query value
+func (g *generator) formatQuery(s *Schema, expr string) string {
+	switch s.Type {
+	case "integer":
+		g.imports["strconv"] = true
+		if s.Format == "int64" {
+			return "strconv.FormatInt(" + expr + ", 10)"
+		}
+		return "strconv.Itoa(" + expr + ")"
+	case "number":
+		g.imports["strconv"] = true
+		return "strconv.FormatFloat(" + expr + ", 'f', -1, 64)"
+	case "boolean":
+		g.imports["strconv"] = true
+		return "strconv.FormatBool(" + expr + ")"
+	}
+	return expr
+}
+
+// zeroValue returns the zero value of a query parameter type
+func zeroValue(s *Schema) string {
+	switch s.Type {
+	case "integer", "number":
+		return "0"
+	case "boolean":
+		return "false"
+	}
+	return `""`
+}
+
+// pathExpr returns the expression building a path with escaped parameters
+func (g *generator) pathExpr(path string) string {
+	var parts []string
+	for path != "" {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			parts = append(parts, strconv.Quote(path))
+			break
+		}
+		end := strings.Index(path[start:], "}") + start
+		if start > 0 {
+			parts = append(parts, strconv.Quote(path[:start]))
+		}
+		g.imports["net/url"] = true
+		parts = append(parts, "url.PathEscape("+argName(path[start+1:end])+")")
+		path = path[end+1:]
+	}
+	return strings.Join(parts, " + ")
+}
+
+// writeComment writes a comment
+func writeComment(buf *bytes.Buffer, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fmt.Fprintf(buf, "%s// %s\n", indent, strings.TrimRight(line, " "))
+	}
+}
+
+// goName converts a JSON or operation name to an exported Go name, e.g. node_id to NodeID
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range splitName(name) {
+		lower := strings.ToLower(part)
+		switch {
+		case initialisms[lower]:
+			b.WriteString(strings.ToUpper(lower))
+		case strings.HasSuffix(lower, "s") && initialisms[strings.TrimSuffix(lower, "s")]:
+			b.WriteString(strings.ToUpper(strings.TrimSuffix(lower, "s")) + "s")
+		default:
+			b.WriteString(strings.ToUpper(lower[:1]) + lower[1:])
+		}
+	}
+	return b.String()
+}
+
+// argName converts a parameter name to an unexported Go name, e.g. node_id to nodeID
+func argName(name string) string {
+	parts := splitName(name)
+	first := strings.ToLower(parts[0])
+	return first + goName(strings.Join(parts[1:], "_"))
+}
+
+// splitName splits snake_case and camelCase names into words
+func splitName(name string) []string {
+	var parts []string
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		start := 0
+		runes := []rune(word)
+		for i := 1; i < len(runes); i++ {
+			// Split before an upper case letter following a lower case one (keyID), and
+			// before the last upper case letter of an initialism followed by a word (APIKey)
+			if unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) > 1 && unicode.IsUpper(runes[1]) {
+		return s // An initialism such as CSR
+	}
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,277 @@
+// Package openapi holds the OpenAPI 3 document of the master API. It serves
+// the document, validates requests against it and generates the Go client in
+// pkg/client from it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// specYAML is the master API document. Update it with every change to a route,
+// request or response, then run go generate ./client.
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Document is the subset of an OpenAPI 3 document used by the validator and the client generator
+type Document struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Info       Info                `yaml:"info"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components Components          `yaml:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `yaml:"title"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of a path
+type PathItem map[string]*Operation
+
+// Operation is one method of a path
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Tags        []string             `yaml:"tags"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"` // "path" or "query"
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+// RequestBody is the body an operation accepts
+type RequestBody struct {
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string                `yaml:"description"`
+	Content     map[string]*MediaType `yaml:"content"`
+}
+
+// MediaType is the schema of a body with one content type
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Components holds the reusable schemas and parameters
+type Components struct {
+	Schemas    map[string]*Schema    `yaml:"schemas"`
+	Parameters map[string]*Parameter `yaml:"parameters"`
+}
+
+// Route is an operation with its method and path
+type Route struct {
+	Method    string // Uppercase, e.g. "POST"
+	Path      string // Path template, e.g. "/jobs/{id}"
+	Operation *Operation
+}
+
+// Content types used by the master API
+const (
+	ContentTypeJSON = "application/json"
+)
+
+const (
+	schemaRefPrefix    = "#/components/schemas/"
+	parameterRefPrefix = "#/components/parameters/"
+)
+
+var (
+	specOnce sync.Once
+	spec     *Document
+	specJSON []byte
+)
+
+// loadSpec parses the embedded document once; it is checked by the tests, so errors are fatal
+func loadSpec() {
+	specOnce.Do(func() {
+		var err error
+		if spec, err = Parse(specYAML); err != nil {
+			panic(fmt.Sprintf("openapi: invalid embedded document: %v", err))
+		}
+		if specJSON, err = toJSON(specYAML); err != nil {
+			panic(fmt.Sprintf("openapi: invalid embedded document: %v", err))
+		}
+	})
+}
+
+// Spec returns the master API document
+func Spec() *Document {
+	loadSpec()
+	return spec
+}
+
+// JSON returns the master API document as JSON
+func JSON() []byte {
+	loadSpec()
+	return specJSON
+}
+
+// YAML returns the master API document as written
+func YAML() []byte {
+	return specYAML
+}
+
+// Handler serves the master API document as JSON
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write(JSON())
+	})
+}
+
+// toJSON converts a YAML document to indented JSON
+func toJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Parse parses an OpenAPI document, resolves its parameter references and
+// checks that every schema reference and operation ID is valid
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		if err := doc.checkRefs(schema); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	operationIDs := make(map[string]string)
+	for _, route := range doc.Routes() {
+		op := route.Operation
+		where := route.Method + " " + route.Path
+		if op.OperationID == "" {
+			return nil, fmt.Errorf("%s: missing operationId", where)
+		}
+		if other, ok := operationIDs[op.OperationID]; ok {
+			return nil, fmt.Errorf("%s: operationId %s is also used by %s", where, op.OperationID, other)
+		}
+		operationIDs[op.OperationID] = where
+
+		for i, param := range op.Parameters {
+			if param.Ref != "" {
+				resolved, ok := doc.Components.Parameters[strings.TrimPrefix(param.Ref, parameterRefPrefix)]
+				if !ok || !strings.HasPrefix(param.Ref, parameterRefPrefix) {
+					return nil, fmt.Errorf("%s: unknown parameter %s", where, param.Ref)
+				}
+				op.Parameters[i] = resolved
+				param = resolved
+			}
+			if param.In == "path" && !strings.Contains(route.Path, "{"+param.Name+"}") {
+				return nil, fmt.Errorf("%s: path parameter %s is not in the path", where, param.Name)
+			}
+			if err := doc.checkRefs(param.Schema); err != nil {
+				return nil, fmt.Errorf("%s: parameter %s: %w", where, param.Name, err)
+			}
+		}
+		if op.RequestBody != nil {
+			for _, media := range op.RequestBody.Content {
+				if err := doc.checkRefs(media.Schema); err != nil {
+					return nil, fmt.Errorf("%s: request body: %w", where, err)
+				}
+			}
+		}
+		for status, resp := range op.Responses {
+			for _, media := range resp.Content {
+				if err := doc.checkRefs(media.Schema); err != nil {
+					return nil, fmt.Errorf("%s: response %s: %w", where, status, err)
+				}
+			}
+		}
+	}
+	return &doc, nil
+}
+
+// checkRefs checks that all references in a schema name component schemas
+func (d *Document) checkRefs(s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if d.resolve(s) == nil {
+			return fmt.Errorf("unknown schema %s", s.Ref)
+		}
+		return nil
+	}
+	children := append([]*Schema{s.Items, s.AdditionalProperties}, s.AllOf...)
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	for _, child := range children {
+		if err := d.checkRefs(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve follows the reference of a schema, returning nil if it is unknown
+func (d *Document) resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	if !strings.HasPrefix(s.Ref, schemaRefPrefix) {
+		return nil
+	}
+	return d.Components.Schemas[strings.TrimPrefix(s.Ref, schemaRefPrefix)]
+}
+
+// Routes returns all operations sorted by path and method
+func (d *Document) Routes() []Route {
+	var routes []Route
+	for path, item := range d.Paths {
+		for method, op := range item {
+			routes = append(routes, Route{Method: strings.ToUpper(method), Path: path, Operation: op})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Operation returns the operation for a method and path template, or nil
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// SchemaName returns the component name of a schema reference
+func SchemaName(ref string) string {
+	return strings.TrimPrefix(ref, schemaRefPrefix)
+}
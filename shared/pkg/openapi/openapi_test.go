@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRejectsInvalidDocuments(t *testing.T) {
+	cases := map[string]string{
+		"version": `openapi: 2.0.0`,
+		"unknown schema": `openapi: 3.0.3
+paths:
+  /jobs:
+    get:
+      operationId: listJobs
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Missing'`,
+		"duplicate operationId": `openapi: 3.0.3
+paths:
+  /a:
+    get:
+      operationId: same
+  /b:
+    get:
+      operationId: same`,
+		"path parameter": `openapi: 3.0.3
+paths:
+  /jobs:
+    get:
+      operationId: getJob
+      parameters:
+        - name: id
+          in: path`,
+	}
+	for name, doc := range cases {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc, err := Parse([]byte(`openapi: 3.0.3
+paths: {}
+components:
+  schemas:
+    Request:
+      type: object
+      additionalProperties: false
+      required: [name]
+      properties:
+        name:
+          type: string
+          minLength: 1
+        count:
+          type: integer
+          minimum: 1
+        tags:
+          type: object
+          additionalProperties:
+            type: string
+        at:
+          type: string
+          format: date-time
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	schema := &Schema{Ref: "#/components/schemas/Request"}
+
+	cases := []struct {
+		body string
+		want string // Empty if valid
+	}{
+		{`{"name":"a","count":2,"tags":{"k":"v"},"at":"2026-01-01T00:00:00Z"}`, ""},
+		{`{}`, "name: is required"},
+		{`{"name":""}`, "name: must not be empty"},
+		{`{"name":"a","count":0}`, "count: must be at least 1"},
+		{`{"name":"a","count":1.5}`, "count: must be an integer"},
+		{`{"name":"a","tags":{"k":1}}`, "tags.k: must be a string"},
+		{`{"name":"a","at":"yesterday"}`, "at: must be an RFC 3339 timestamp"},
+		{`{"name":"a","extra":true}`, "extra: is not a known field"},
+		{`{"name":"a"} {}`, "unexpected data"},
+	}
+	for _, tc := range cases {
+		err := doc.Decode(schema, []byte(tc.body))
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.body, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected %q, got %v", tc.body, tc.want, err)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	cases := map[string]string{
+		"node_id":            "NodeID",
+		"createTenantAPIKey": "CreateTenantAPIKey",
+		"keyID":              "KeyID",
+		"cpu_cores":          "CPUCores",
+		"gpus":               "GPUs",
+		"getOpenAPISpec":     "GetOpenAPISpec",
+	}
+	for in, want := range cases {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestGeneratedClientUpToDate fails when openapi.yaml changed without running go generate ./client
+func TestGeneratedClientUpToDate(t *testing.T) {
+	want, err := GenerateClient(Spec(), "client")
+	if err != nil {
+		t.Fatalf("GenerateClient failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "client", "client_gen.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated client: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client/client_gen.go is out of date: run go generate ./client")
+	}
+}
@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the subset of JSON Schema used by the master API document
+type Schema struct {
+	Ref         string             `yaml:"$ref"`
+	Type        string             `yaml:"type"`   // "object", "array", "string", "integer", "number" or "boolean"
+	Format      string             `yaml:"format"` // e.g. "date-time", "int64" or "binary"
+	Description string             `yaml:"description"`
+	Nullable    bool               `yaml:"nullable"`
+	Enum        []interface{}      `yaml:"enum"`
+	Required    []string           `yaml:"required"`
+	Properties  map[string]*Schema `yaml:"properties"`
+	Items       *Schema            `yaml:"items"`
+	AllOf       []*Schema          `yaml:"allOf"`
+	Minimum     *float64           `yaml:"minimum"`
+	Maximum     *float64           `yaml:"maximum"`
+	MinLength   *int               `yaml:"minLength"`
+	MaxLength   *int               `yaml:"maxLength"`
+
+	// GoType names the type in pkg/models a component schema describes, e.g. "models.Job".
+	// The generated client uses these types instead of generating its own.
+	GoType string `yaml:"x-go-type"`
+
+	// AdditionalProperties is the schema of properties not listed in Properties.
+	// With FreeForm (the default) they may have any value; with
+	// additionalProperties: false they are rejected.
+	AdditionalProperties *Schema `yaml:"-"`
+	FreeForm             bool    `yaml:"-"`
+}
+
+// UnmarshalYAML decodes a schema, whose additionalProperties may be a boolean or a schema
+func (s *Schema) UnmarshalYAML(value *yaml.Node) error {
+	type plain Schema
+	var raw struct {
+		plain                `yaml:",inline"`
+		AdditionalProperties yaml.Node `yaml:"additionalProperties"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	*s = Schema(raw.plain)
+
+	switch raw.AdditionalProperties.Kind {
+	case 0:
+		s.FreeForm = true
+	case yaml.ScalarNode:
+		return raw.AdditionalProperties.Decode(&s.FreeForm)
+	default:
+		s.AdditionalProperties = &Schema{}
+		return raw.AdditionalProperties.Decode(s.AdditionalProperties)
+	}
+	return nil
+}
+
+// ValidationError reports where a value does not match its schema
+type ValidationError struct {
+	Field   string // Dotted path of the field, e.g. "quotas.max_workers"; empty for the value itself
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks a value decoded from JSON with json.Decoder.UseNumber against a schema
+func (d *Document) Validate(s *Schema, value interface{}) error {
+	return d.validate(s, value, "")
+}
+
+// Decode decodes and validates a JSON document against a schema
+func (d *Document) Decode(s *Schema, data []byte) error {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Message: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if decoder.More() {
+		return &ValidationError{Message: "invalid JSON: unexpected data after the document"}
+	}
+	return d.Validate(s, value)
+}
+
+func (d *Document) validate(s *Schema, value interface{}, field string) error {
+	if s = d.resolve(s); s == nil {
+		return nil
+	}
+	if value == nil {
+		if s.Nullable || (s.Type == "" && len(s.AllOf) == 0) {
+			return nil
+		}
+		return &ValidationError{Field: field, Message: "must not be null"}
+	}
+	for _, sub := range s.AllOf {
+		if err := d.validate(sub, value, field); err != nil {
+			return err
+		}
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return &ValidationError{Field: field, Message: "must be one of " + enumList(s.Enum)}
+	}
+
+	switch s.Type {
+	case "object", "":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			if s.Type == "" {
+				return nil
+			}
+			return &ValidationError{Field: field, Message: "must be an object"}
+		}
+		return d.validateObject(s, object, field)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return &ValidationError{Field: field, Message: "must be an array"}
+		}
+		for i, item := range items {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return &ValidationError{Field: field, Message: "must be a string"}
+		}
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && length < *s.MinLength {
+			if *s.MinLength == 1 {
+				return &ValidationError{Field: field, Message: "must not be empty"}
+			}
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be at least %d characters", *s.MinLength)}
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d characters", *s.MaxLength)}
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return &ValidationError{Field: field, Message: "must be an RFC 3339 timestamp"}
+			}
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return &ValidationError{Field: field, Message: "must be " + numberName(s.Type)}
+		}
+		f, err := number.Float64()
+		if err != nil || (s.Type == "integer" && strings.ContainsAny(number.String(), ".eE")) {
+			return &ValidationError{Field: field, Message: "must be " + numberName(s.Type)}
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be at least %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be at most %v", *s.Maximum)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &ValidationError{Field: field, Message: "must be a boolean"}
+		}
+	}
+	return nil
+}
+
+// validateObject checks the required fields and properties of an object
+func (d *Document) validateObject(s *Schema, object map[string]interface{}, field string) error {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return &ValidationError{Field: joinField(field, name), Message: "is required"}
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		switch {
+		case ok:
+		case s.AdditionalProperties != nil:
+			prop = s.AdditionalProperties
+		case s.FreeForm || len(s.AllOf) > 0:
+			// Properties of allOf schemas are checked by their subschemas
+			continue
+		default:
+			return &ValidationError{Field: joinField(field, name), Message: "is not a known field"}
+		}
+		if err := d.validate(prop, object[name], joinField(field, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func numberName(schemaType string) string {
+	if schemaType == "integer" {
+		return "an integer"
+	}
+	return "a number"
+}
+
+// inEnum reports whether a decoded JSON value is one of the enum values
+func inEnum(enum []interface{}, value interface{}) bool {
+	if number, ok := value.(json.Number); ok {
+		value = number.String()
+	}
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = fmt.Sprint(value)
+	}
+	return strings.Join(values, ", ")
+}
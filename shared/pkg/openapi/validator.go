@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// MaxValidatedBodySize is the largest JSON request body the validator reads
+const MaxValidatedBodySize = 32 << 20
+
+// Validator checks requests against the operations of a document
+type Validator struct {
+	doc *Document
+}
+
+// NewValidator creates a validator for doc
+func NewValidator(doc *Document) *Validator {
+	return &Validator{doc: doc}
+}
+
+// Middleware rejects requests whose query parameters or JSON body do not
+// match the operation of their route with 400 Bad Request. It must be used
+// on the mux router serving the routes (Router.Use), which provides the path
+// template of each request. Routes the document does not describe pass
+// unchecked.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op := v.doc.Operation(r.Method, template)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := v.ValidateRequest(r, op); err != nil {
+			if err == errBodyTooLarge {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// ValidateRequest checks the query parameters and JSON body of r against op.
+// The body is read and replaced, so handlers can still decode it.
+func (v *Validator) ValidateRequest(r *http.Request, op *Operation) error {
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		raw, ok := query[param.Name]
+		if !ok || len(raw) == 0 || raw[0] == "" {
+			if param.Required {
+				return &ValidationError{Field: param.Name, Message: "query parameter is required"}
+			}
+			continue
+		}
+		value, err := queryValue(param.Schema, raw[0])
+		if err != nil {
+			return &ValidationError{Field: param.Name, Message: err.Error()}
+		}
+		if err := v.doc.validate(param.Schema, value, param.Name); err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	media, ok := op.RequestBody.Content[ContentTypeJSON]
+	if !ok || r.Body == nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxValidatedBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		return &ValidationError{Message: fmt.Sprintf("failed to read request body: %v", err)}
+	}
+	if len(data) > MaxValidatedBodySize {
+		return errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if op.RequestBody.Required {
+			return &ValidationError{Message: "request body is required"}
+		}
+		return nil
+	}
+	return v.doc.Decode(media.Schema, data)
+}
+
+// queryValue converts a query parameter to the JSON value its schema describes
+func queryValue(s *Schema, raw string) (interface{}, error) {
+	if s == nil {
+		return raw, nil
+	}
+	switch s.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return json.Number(raw), nil
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,304 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy key types: what a policy counts requests by
+const (
+	KeyIP       = "ip"       // Client address
+	KeyTenant   = "tenant"   // Tenant the request acts as
+	KeyAPIKey   = "apikey"   // Tenant API key ID
+	KeyUser     = "user"     // Logged-in user ID
+	KeyIdentity = "identity" // Most specific credentials: user, API key, client certificate, or address
+)
+
+// Rate limit response headers
+const (
+	HeaderLimit     = "X-RateLimit-Limit"     // Bucket size of the most restrictive policy
+	HeaderRemaining = "X-RateLimit-Remaining" // Requests left in that bucket
+	HeaderReset     = "X-RateLimit-Reset"     // Seconds until that bucket is full again
+	HeaderPolicy    = "X-RateLimit-Policy"    // Name of that policy
+)
+
+// Limit is a request rate: Requests per Per, in bursts of up to Burst requests
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`             // Default: 1m
+	Burst    int           `yaml:"burst,omitempty"` // Default: Requests
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Policy limits the requests matching its paths and methods, counted per key
+type Policy struct {
+	Name    string   `yaml:"name"`
+	Paths   []string `yaml:"paths,omitempty"`   // Path prefixes; empty matches every path
+	Methods []string `yaml:"methods,omitempty"` // Empty matches every method
+	Key     string   `yaml:"key"`               // One of the Key* types
+	Limit   `yaml:",inline"`
+
+	// Overrides replaces the limit for single keys, e.g. a busy tenant ID or API key ID.
+	// Identity keys are "user:<id>", "apikey:<id>", "cert:<name>", "admin:<ip>" or "ip:<ip>".
+	Overrides map[string]Limit `yaml:"overrides,omitempty"`
+}
+
+// matches reports whether the policy applies to r
+func (p *Policy) matches(r *http.Request) bool {
+	if len(p.Methods) > 0 {
+		found := false
+		for _, method := range p.Methods {
+			if strings.EqualFold(method, r.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(p.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range p.Paths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitFor returns the limit of a key
+func (p *Policy) limitFor(key string) Limit {
+	if override, ok := p.Overrides[key]; ok {
+		return override
+	}
+	return p.Limit
+}
+
+// Config is a rate limit configuration file
+type Config struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// DefaultPolicies returns the policies used without a configuration file:
+// brute force protection for login and enrollment, and a per-identity limit
+// generous enough for workers polling for jobs and reporting progress.
+func DefaultPolicies() []Policy {
+	return []Policy{
+		{
+			Name:  "auth",
+			Paths: []string{"/auth/login", "/pki/enroll", "/pki/renew"},
+			Key:   KeyIP,
+			Limit: Limit{Requests: 20, Per: time.Minute, Burst: 10},
+		},
+		{
+			Name:  "api",
+			Key:   KeyIdentity,
+			Limit: Limit{Requests: 1200, Per: time.Minute, Burst: 200},
+		},
+	}
+}
+
+// LoadConfig reads a YAML rate limit configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// normalize applies defaults to a limit and validates it
+func (l *Limit) normalize() error {
+	if l.Requests <= 0 {
+		return fmt.Errorf("requests must be positive")
+	}
+	if l.Per == 0 {
+		l.Per = time.Minute
+	}
+	if l.Per < 0 {
+		return fmt.Errorf("per must be positive")
+	}
+	if l.Burst == 0 {
+		l.Burst = l.Requests
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("burst must be positive")
+	}
+	return nil
+}
+
+// KeyFunc returns the key a request is counted by, or "" to exempt it from a policy
+type KeyFunc func(r *http.Request) string
+
+// Backend keeps token buckets. Limiter keeps them in process; StoreBackend
+// keeps them in the data store, shared by all masters.
+type Backend interface {
+	// Take takes a token for key from a bucket refilled at rate tokens per second up to burst
+	Take(key string, rate float64, burst int) (models.RateLimitDecision, error)
+	// Prune removes buckets unused for idle that have refilled completely
+	Prune(idle time.Duration) (int, error)
+}
+
+// Enforcer applies rate limit policies to HTTP requests
+type Enforcer struct {
+	policies []Policy
+	backend  Backend
+	keyFuncs map[string]KeyFunc
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewEnforcer creates an enforcer for policies. keyFuncs maps the key type of
+// every policy to the function extracting it from requests.
+func NewEnforcer(policies []Policy, backend Backend, keyFuncs map[string]KeyFunc) (*Enforcer, error) {
+	names := make(map[string]bool)
+	normalized := make([]Policy, len(policies))
+	for i, policy := range policies {
+		if policy.Name == "" {
+			return nil, fmt.Errorf("rate limit policy %d has no name", i+1)
+		}
+		if names[policy.Name] {
+			return nil, fmt.Errorf("duplicate rate limit policy %q", policy.Name)
+		}
+		names[policy.Name] = true
+		if keyFuncs[policy.Key] == nil {
+			return nil, fmt.Errorf("rate limit policy %q: unknown key %q", policy.Name, policy.Key)
+		}
+		if err := policy.Limit.normalize(); err != nil {
+			return nil, fmt.Errorf("rate limit policy %q: %w", policy.Name, err)
+		}
+		overrides := make(map[string]Limit, len(policy.Overrides))
+		for key, limit := range policy.Overrides {
+			if err := limit.normalize(); err != nil {
+				return nil, fmt.Errorf("rate limit policy %q override %q: %w", policy.Name, key, err)
+			}
+			overrides[key] = limit
+		}
+		policy.Overrides = overrides
+		normalized[i] = policy
+	}
+
+	return &Enforcer{
+		policies: normalized,
+		backend:  backend,
+		keyFuncs: keyFuncs,
+		stopCh:   make(chan struct{}),
+	}, nil
+}
+
+// Policies returns the enforced policies with defaults applied
+func (e *Enforcer) Policies() []Policy {
+	return e.policies
+}
+
+// Middleware rejects requests over any matching policy with 429 Too Many
+// Requests and a Retry-After header. Responses carry X-RateLimit-* headers
+// for the most restrictive matching policy. Mount it after the authentication
+// middlewares so requests can be counted by their credentials.
+func (e *Enforcer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tightest *models.RateLimitDecision
+		tightestPolicy := ""
+		for i := range e.policies {
+			policy := &e.policies[i]
+			if !policy.matches(r) {
+				continue
+			}
+			key := e.keyFuncs[policy.Key](r)
+			if key == "" {
+				continue
+			}
+
+			limit := policy.limitFor(key)
+			decision, err := e.backend.Take(policy.Name+":"+key, limit.rate(), limit.Burst)
+			if err != nil {
+				log.Printf("Rate limit check failed for policy %s: %v", policy.Name, err)
+				continue
+			}
+
+			if !decision.Allowed {
+				setHeaders(w, policy.Name, &decision)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+				http.Error(w, fmt.Sprintf(`{"error":"rate_limited","message":"Rate limit exceeded (policy %s)"}`, policy.Name), http.StatusTooManyRequests)
+				return
+			}
+			if tightest == nil || decision.Remaining < tightest.Remaining {
+				tightest = &decision
+				tightestPolicy = policy.Name
+			}
+		}
+
+		if tightest != nil {
+			setHeaders(w, tightestPolicy, tightest)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setHeaders writes the X-RateLimit-* headers of a decision
+func setHeaders(w http.ResponseWriter, policy string, decision *models.RateLimitDecision) {
+	w.Header().Set(HeaderLimit, strconv.Itoa(decision.Limit))
+	w.Header().Set(HeaderRemaining, strconv.Itoa(decision.Remaining))
+	w.Header().Set(HeaderReset, strconv.Itoa(ceilSeconds(decision.ResetAfter)))
+	w.Header().Set(HeaderPolicy, policy)
+}
+
+// ceilSeconds rounds d up to whole seconds, with a minimum of one for positive durations
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	if d.Seconds() > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// Start prunes idle buckets in the background every interval
+func (e *Enforcer) Start(interval time.Duration) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := e.backend.Prune(interval); err != nil {
+					log.Printf("Failed to prune rate limit buckets: %v", err)
+				}
+			case <-e.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops pruning
+func (e *Enforcer) Stop() {
+	e.stopOnce.Do(func() { close(e.stopCh) })
+	e.wg.Wait()
+}
@@ -1,19 +1,35 @@
 package ratelimit
 
 import (
+	"container/list"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 	"golang.org/x/time/rate"
 )
 
-// Limiter provides rate limiting functionality
+// DefaultMaxKeys is the number of keys a Limiter tracks before evicting the least recently used
+const DefaultMaxKeys = 50000
+
+// Limiter provides in-process rate limiting with a token bucket per key.
+// The least recently used buckets are evicted once it tracks more than
+// maxKeys keys, and Prune removes idle buckets.
 type Limiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
+	limiters map[string]*list.Element // Values are *limiterEntry
+	lru      *list.List               // Most recently used first
+	mu       sync.Mutex
 	rps      rate.Limit
 	burst    int
+	maxKeys  int
+}
+
+// limiterEntry is the bucket of one key
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
 }
 
 // NewLimiter creates a new rate limiter
@@ -21,24 +37,72 @@ type Limiter struct {
 // burst: maximum burst size
 func NewLimiter(rps float64, burst int) *Limiter {
 	return &Limiter{
-		limiters: make(map[string]*rate.Limiter),
+		limiters: make(map[string]*list.Element),
+		lru:      list.New(),
 		rps:      rate.Limit(rps),
 		burst:    burst,
+		maxKeys:  DefaultMaxKeys,
 	}
 }
 
+// SetMaxKeys sets how many keys are tracked before the least recently used are evicted
+func (l *Limiter) SetMaxKeys(maxKeys int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxKeys = maxKeys
+	l.evict()
+}
+
+// Len returns the number of keys being tracked
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
+
 // GetLimiter returns a rate limiter for the given key (e.g., IP address or API key)
 func (l *Limiter) GetLimiter(key string) *rate.Limiter {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	limiter, exists := l.limiters[key]
-	if !exists {
-		limiter = rate.NewLimiter(l.rps, l.burst)
-		l.limiters[key] = limiter
+	return l.touch(key, l.rps, l.burst, false)
+}
+
+// touch returns the limiter for key, creating it with rps and burst if needed and
+// marking it as recently used. With update, existing limiters get rps and burst.
+// The caller must hold the lock.
+func (l *Limiter) touch(key string, rps rate.Limit, burst int, update bool) *rate.Limiter {
+	now := time.Now()
+	if elem, exists := l.limiters[key]; exists {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastSeen = now
+		l.lru.MoveToFront(elem)
+		if update {
+			if entry.limiter.Limit() != rps {
+				entry.limiter.SetLimitAt(now, rps)
+			}
+			if entry.limiter.Burst() != burst {
+				entry.limiter.SetBurstAt(now, burst)
+			}
+		}
+		return entry.limiter
 	}
 
-	return limiter
+	// New keys start with a full bucket
+	entry := &limiterEntry{key: key, limiter: rate.NewLimiter(rps, burst), lastSeen: now}
+	l.limiters[key] = l.lru.PushFront(entry)
+	l.evict()
+	return entry.limiter
+}
+
+// evict removes the least recently used limiters over maxKeys; the caller must hold the lock
+func (l *Limiter) evict() {
+	for l.maxKeys > 0 && l.lru.Len() > l.maxKeys {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.limiters, oldest.Value.(*limiterEntry).key)
+	}
 }
 
 // Allow checks if a request should be allowed
@@ -46,12 +110,95 @@ func (l *Limiter) Allow(key string) bool {
 	return l.GetLimiter(key).Allow()
 }
 
+// SetKeyLimit overrides the rate and burst for a single key (e.g., a tenant with its own quota)
+func (l *Limiter) SetKeyLimit(key string, rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.touch(key, rate.Limit(rps), burst, true)
+}
+
+// Reserve checks if a request should be allowed, and if not, how long the
+// caller should wait before retrying
+func (l *Limiter) Reserve(key string) (bool, time.Duration) {
+	reservation := l.GetLimiter(key).Reserve()
+	if !reservation.OK() {
+		return false, 0
+	}
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
+
+// Take takes a token for key from a bucket refilled at rps up to burst.
+// It implements Backend for limits that only need to hold within one process.
+func (l *Limiter) Take(key string, rps float64, burst int) (models.RateLimitDecision, error) {
+	l.mu.Lock()
+	limiter := l.touch(key, rate.Limit(rps), burst, true)
+	l.mu.Unlock()
+
+	now := time.Now()
+	decision := models.RateLimitDecision{Limit: burst}
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		decision.RetryAfter = time.Hour
+		return decision, nil
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		decision.RetryAfter = delay
+		decision.ResetAfter = refillTime(float64(burst)-limiter.TokensAt(now), rps)
+		return decision, nil
+	}
+
+	tokens := limiter.TokensAt(now)
+	decision.Allowed = true
+	decision.Remaining = int(tokens)
+	decision.ResetAfter = refillTime(float64(burst)-tokens, rps)
+	return decision, nil
+}
+
+// refillTime returns how long refilling tokens takes at rps
+func refillTime(tokens, rps float64) time.Duration {
+	if tokens <= 0 || rps <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / rps * float64(time.Second))
+}
+
+// Prune removes the buckets of keys unused for idle that have refilled completely,
+// so no key regains tokens early. It returns how many were removed.
+func (l *Limiter) Prune(idle time.Duration) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-idle)
+	removed := 0
+	for elem := l.lru.Back(); elem != nil; {
+		entry := elem.Value.(*limiterEntry)
+		if entry.lastSeen.After(cutoff) {
+			break
+		}
+		prev := elem.Prev()
+		if entry.limiter.TokensAt(now) >= float64(entry.limiter.Burst()) {
+			l.lru.Remove(elem)
+			delete(l.limiters, entry.key)
+			removed++
+		}
+		elem = prev
+	}
+	return removed, nil
+}
+
 // Middleware creates an HTTP middleware for rate limiting
 func (l *Limiter) Middleware(keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			key := keyFunc(r)
-			
+
 			if !l.Allow(key) {
 				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
@@ -64,12 +211,7 @@ func (l *Limiter) Middleware(keyFunc func(*http.Request) string) func(http.Handl
 
 // CleanupOldLimiters removes limiters that haven't been used recently
 func (l *Limiter) CleanupOldLimiters(maxAge time.Duration) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// Note: In production, you'd want to track last access time
-	// For simplicity, we'll just keep all limiters
-	// A more sophisticated implementation would use LRU cache
+	l.Prune(maxAge)
 }
 
 // IPKeyFunc extracts the IP address from the request as the rate limit key
@@ -78,7 +220,7 @@ func IPKeyFunc(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		return xff
 	}
-	
+
 	// Fall back to RemoteAddr
 	return r.RemoteAddr
 }
@@ -1,10 +1,15 @@
 package ratelimit
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 )
 
 func TestLimiter(t *testing.T) {
@@ -113,3 +118,217 @@ func TestIPKeyFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestSetKeyLimit(t *testing.T) {
+	limiter := NewLimiter(1, 1)
+	limiter.SetKeyLimit("tenant-a", 1, 3)
+
+	// The key uses its own burst rather than the limiter default
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("tenant-a") {
+			t.Fatalf("Request %d should be allowed", i+1)
+		}
+	}
+	if limiter.Allow("tenant-a") {
+		t.Error("Request beyond the key burst should be rate limited")
+	}
+
+	// Other keys keep the default limits
+	if !limiter.Allow("tenant-b") {
+		t.Error("First request for another key should be allowed")
+	}
+	if limiter.Allow("tenant-b") {
+		t.Error("Second request for another key should be rate limited")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	limiter := NewLimiter(10, 1)
+
+	if ok, _ := limiter.Reserve("test-key"); !ok {
+		t.Error("First request should be allowed")
+	}
+
+	ok, retryAfter := limiter.Reserve("test-key")
+	if ok {
+		t.Fatal("Second request should be rate limited")
+	}
+	if retryAfter <= 0 || retryAfter > 100*time.Millisecond {
+		t.Errorf("Expected retry delay up to 100ms, got %v", retryAfter)
+	}
+
+	// A rejected request must not consume the next token
+	time.Sleep(120 * time.Millisecond)
+	if ok, _ := limiter.Reserve("test-key"); !ok {
+		t.Error("Request after waiting should be allowed")
+	}
+}
+
+func TestLimiterEviction(t *testing.T) {
+	limiter := NewLimiter(1, 1)
+	limiter.SetMaxKeys(2)
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+	limiter.Allow("a") // b is now the least recently used
+	limiter.Allow("c")
+
+	if limiter.Len() != 2 {
+		t.Fatalf("Expected 2 tracked keys, got %d", limiter.Len())
+	}
+	// a kept its empty bucket; b was evicted and starts full again
+	if limiter.Allow("a") {
+		t.Error("Expected the recently used key to keep its bucket")
+	}
+	if !limiter.Allow("b") {
+		t.Error("Expected the evicted key to start with a full bucket")
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	limiter := NewLimiter(100, 1)
+	limiter.Allow("refilled")
+	limiter.SetKeyLimit("slow", 0.001, 1)
+	limiter.Allow("slow")
+
+	time.Sleep(30 * time.Millisecond)
+	removed, _ := limiter.Prune(10 * time.Millisecond)
+	if removed != 1 || limiter.Len() != 1 {
+		t.Errorf("Expected only the refilled bucket to be pruned, removed %d, %d left", removed, limiter.Len())
+	}
+	if limiter.Allow("slow") {
+		t.Error("Expected the pruned limiter to keep the empty bucket")
+	}
+}
+
+func TestEnforcer(t *testing.T) {
+	keyFuncs := map[string]KeyFunc{
+		KeyIP:     func(r *http.Request) string { return r.RemoteAddr },
+		KeyAPIKey: func(r *http.Request) string { return r.Header.Get("X-Key-ID") },
+	}
+	policies := []Policy{
+		{Name: "login", Paths: []string{"/auth/login"}, Methods: []string{"POST"}, Key: KeyIP, Limit: Limit{Requests: 1, Per: time.Hour}},
+		{Name: "api", Key: KeyAPIKey, Limit: Limit{Requests: 3, Per: time.Hour},
+			Overrides: map[string]Limit{"ci": {Requests: 1, Per: time.Hour}}},
+	}
+	if _, err := NewEnforcer(policies, NewLimiter(1, 1), map[string]KeyFunc{KeyIP: keyFuncs[KeyIP]}); err == nil {
+		t.Error("Expected error for a policy with an unknown key function")
+	}
+	enforcer, err := NewEnforcer(policies, NewLimiter(1, 1), keyFuncs)
+	if err != nil {
+		t.Fatalf("Failed to create enforcer: %v", err)
+	}
+	handler := enforcer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(method, path, keyID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "192.0.2.1"
+		if keyID != "" {
+			req.Header.Set("X-Key-ID", keyID)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("GET", "/jobs", "team")
+	if rr.Code != http.StatusOK || rr.Header().Get(HeaderLimit) != "3" || rr.Header().Get(HeaderRemaining) != "2" ||
+		rr.Header().Get(HeaderPolicy) != "api" || rr.Header().Get(HeaderReset) != "1200" {
+		t.Errorf("Unexpected response %d with headers %v", rr.Code, rr.Header())
+	}
+
+	// Overrides apply to their key only
+	do("GET", "/jobs", "ci")
+	if rr := do("GET", "/jobs", "ci"); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "3600" {
+		t.Errorf("Expected the overridden key to be limited with Retry-After, got %d %v", rr.Code, rr.Header())
+	}
+
+	// Requests without a key are exempt from the policy
+	for i := 0; i < 5; i++ {
+		if rr := do("GET", "/health", ""); rr.Code != http.StatusOK || rr.Header().Get(HeaderLimit) != "" {
+			t.Fatalf("Expected keyless request to pass without headers, got %d", rr.Code)
+		}
+	}
+
+	// Route policies only match their paths and methods; the tightest policy is reported
+	if rr := do("POST", "/auth/login", ""); rr.Code != http.StatusOK || rr.Header().Get(HeaderPolicy) != "login" {
+		t.Errorf("Expected first login to pass, got %d %v", rr.Code, rr.Header())
+	}
+	if rr := do("GET", "/auth/login", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected GET to be exempt from the login policy, got %d", rr.Code)
+	}
+	if rr := do("POST", "/auth/login", ""); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected second login to be limited, got %d", rr.Code)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.yaml")
+	os.WriteFile(path, []byte(`
+policies:
+  - name: submit
+    paths: [/jobs]
+    methods: [POST]
+    key: tenant
+    requests: 100
+    per: 1h
+    overrides:
+      tenant-big:
+        requests: 1000
+        per: 1h
+        burst: 50
+`), 0644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	enforcer, err := NewEnforcer(config.Policies, NewLimiter(1, 1), map[string]KeyFunc{KeyTenant: func(*http.Request) string { return "" }})
+	if err != nil {
+		t.Fatalf("Failed to create enforcer: %v", err)
+	}
+	policy := enforcer.Policies()[0]
+	if policy.Requests != 100 || policy.Per != time.Hour || policy.Burst != 100 || policy.Overrides["tenant-big"].Burst != 50 {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	if _, err := NewEnforcer([]Policy{{Name: "bad", Key: KeyTenant}}, NewLimiter(1, 1), map[string]KeyFunc{KeyTenant: IPKeyFunc}); err == nil {
+		t.Error("Expected error for a policy without requests")
+	}
+}
+
+// failingStore is a TokenStore that can be made to fail
+type failingStore struct {
+	fail  bool
+	taken int
+}
+
+func (s *failingStore) TakeRateLimitToken(key string, rate float64, burst int, now time.Time) (models.RateLimitDecision, error) {
+	if s.fail {
+		return models.RateLimitDecision{}, errors.New("database unavailable")
+	}
+	s.taken++
+	return models.RateLimitDecision{Allowed: true, Limit: burst, Remaining: burst - s.taken}, nil
+}
+
+func (s *failingStore) DeleteFullRateLimitBuckets(before time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestStoreBackendFallback(t *testing.T) {
+	store := &failingStore{}
+	backend := NewStoreBackend(store)
+
+	if decision, err := backend.Take("api:key", 1, 1); err != nil || !decision.Allowed || store.taken != 1 {
+		t.Fatalf("Expected the store to be used, got %+v (%v)", decision, err)
+	}
+
+	// While the store fails, limits are enforced locally
+	store.fail = true
+	if decision, _ := backend.Take("api:key", 1, 1); !decision.Allowed {
+		t.Error("Expected the first local request to be allowed")
+	}
+	if decision, _ := backend.Take("api:key", 1, 1); decision.Allowed {
+		t.Error("Expected the local fallback to limit requests")
+	}
+}
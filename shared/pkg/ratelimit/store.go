@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// TokenStore is the subset of the data store that keeps shared token buckets
+type TokenStore interface {
+	TakeRateLimitToken(key string, rate float64, burst int, now time.Time) (models.RateLimitDecision, error)
+	DeleteFullRateLimitBuckets(before time.Time) (int, error)
+}
+
+// StoreBackend keeps token buckets in the data store, so limits hold across
+// all masters sharing it. While the store fails, limits are enforced per
+// master with an in-process Limiter instead.
+type StoreBackend struct {
+	store    TokenStore
+	fallback *Limiter
+	failing  atomic.Bool
+}
+
+// NewStoreBackend creates a backend keeping its buckets in store
+func NewStoreBackend(store TokenStore) *StoreBackend {
+	return &StoreBackend{
+		store:    store,
+		fallback: NewLimiter(1, 1),
+	}
+}
+
+// Take takes a token for key from the shared bucket
+func (b *StoreBackend) Take(key string, rate float64, burst int) (models.RateLimitDecision, error) {
+	decision, err := b.store.TakeRateLimitToken(key, rate, burst, time.Now())
+	if err != nil {
+		if !b.failing.Swap(true) {
+			log.Printf("Warning: shared rate limits unavailable, limiting per master: %v", err)
+		}
+		return b.fallback.Take(key, rate, burst)
+	}
+	if b.failing.Swap(false) {
+		log.Printf("Shared rate limits available again")
+	}
+	return decision, nil
+}
+
+// Prune removes shared buckets that have been full for idle, and idle fallback buckets
+func (b *StoreBackend) Prune(idle time.Duration) (int, error) {
+	removed, _ := b.fallback.Prune(idle)
+	deleted, err := b.store.DeleteFullRateLimitBuckets(time.Now().Add(-idle))
+	return removed + deleted, err
+}
@@ -14,8 +14,18 @@ var (
 	ErrInvalidRole      = errors.New("invalid role")
 )
 
-// HasPermission checks if the current user has a specific permission
+// HasPermission checks if the current user has a specific permission.
+// Requests made with a tenant API key have the key's permissions instead of a role's.
 func HasPermission(ctx context.Context, perm models.Permission) bool {
+	if perms, ok := tenancy.GetKeyPermissions(ctx); ok {
+		for _, p := range perms {
+			if p == perm {
+				return true
+			}
+		}
+		return false
+	}
+
 	roleStr := tenancy.GetUserRole(ctx)
 	if roleStr == "" {
 		return false
@@ -118,6 +128,10 @@ func CheckPermission(ctx context.Context, perm models.Permission) error {
 
 // GetUserPermissions returns all permissions for the current user
 func GetUserPermissions(ctx context.Context) []models.Permission {
+	if perms, ok := tenancy.GetKeyPermissions(ctx); ok {
+		return perms
+	}
+
 	roleStr := tenancy.GetUserRole(ctx)
 	if roleStr == "" {
 		return nil
@@ -14,12 +14,14 @@ type CapabilityRequirements struct {
 	RequiredEngine    string // "ffmpeg", "gstreamer", or "auto"
 	MinCPUThreads     int
 	MinRAMBytes       uint64
+	TenantID          string // Owning tenant; nodes dedicated to other tenants are excluded
 }
 
 // ExtractJobRequirements analyzes a job and extracts capability requirements
 func ExtractJobRequirements(job *models.Job) *CapabilityRequirements {
 	req := &CapabilityRequirements{
 		RequiredEngine: job.Engine,
+		TenantID:       job.TenantID,
 	}
 
 	if job.Parameters == nil {
@@ -58,6 +60,11 @@ func ExtractJobRequirements(job *models.Job) *CapabilityRequirements {
 
 // CanNodeSatisfyJob checks if a node has the capabilities to run a job
 func CanNodeSatisfyJob(node *models.Node, requirements *CapabilityRequirements) (bool, string) {
+	// Nodes dedicated to a tenant only run that tenant's jobs
+	if node.TenantID != "" && node.TenantID != requirements.TenantID {
+		return false, fmt.Sprintf("node %s is dedicated to tenant %s", node.Name, node.TenantID)
+	}
+
 	// Check GPU requirement
 	if requirements.RequiresGPU && !node.HasGPU {
 		return false, fmt.Sprintf("job requires GPU but node %s has no GPU", node.Name)
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// jobWriter makes the job writes of the scheduler and recovery manager.
+// With leader election enabled every write carries the leader's fencing token,
+// so a deposed leader's writes are rejected by stores that implement store.FencedStore.
+type jobWriter struct {
+	store      store.Store
+	leadership Leadership
+	leaseName  string
+}
+
+// fence returns the fenced store and current token to write with, or a nil store for unfenced writes.
+// It fails with store.ErrStaleFencingToken when leader election is enabled and this master is not the leader.
+func (w *jobWriter) fence() (store.FencedStore, int64, error) {
+	if w.leadership == nil {
+		return nil, 0, nil
+	}
+	token, ok := w.leadership.FencingToken()
+	if !ok {
+		return nil, 0, store.ErrStaleFencingToken
+	}
+	fenced, ok := w.store.(store.FencedStore)
+	if !ok {
+		return nil, 0, nil
+	}
+	return fenced, token, nil
+}
+
+// TryQueuePendingJob queues a pending job
+func (w *jobWriter) TryQueuePendingJob(jobID string) (bool, error) {
+	fenced, token, err := w.fence()
+	if err != nil {
+		return false, err
+	}
+	if fenced != nil {
+		return fenced.TryQueuePendingJobFenced(jobID, w.leaseName, token)
+	}
+	return w.store.TryQueuePendingJob(jobID)
+}
+
+// UpdateJobStatus updates the status of a job
+func (w *jobWriter) UpdateJobStatus(id string, status models.JobStatus, errorMsg string) error {
+	fenced, token, err := w.fence()
+	if err != nil {
+		return err
+	}
+	if fenced != nil {
+		return fenced.UpdateJobStatusFenced(id, status, errorMsg, w.leaseName, token)
+	}
+	return w.store.UpdateJobStatus(id, status, errorMsg)
+}
+
+// RetryJob requeues a job for retry
+func (w *jobWriter) RetryJob(jobID, errorMsg string) error {
+	fenced, token, err := w.fence()
+	if err != nil {
+		return err
+	}
+	if fenced != nil {
+		return fenced.RetryJobFenced(jobID, errorMsg, w.leaseName, token)
+	}
+	return w.store.RetryJob(jobID, errorMsg)
+}
+
+// AssignJobToWorker assigns a job to a worker through the FSM store methods
+func (w *jobWriter) AssignJobToWorker(ext ExtendedStore, jobID, nodeID string) (bool, error) {
+	fenced, token, err := w.fence()
+	if err != nil {
+		return false, err
+	}
+	if fenced != nil {
+		return fenced.AssignJobToWorkerFenced(jobID, nodeID, w.leaseName, token)
+	}
+	return ext.AssignJobToWorker(jobID, nodeID)
+}
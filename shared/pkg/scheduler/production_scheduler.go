@@ -30,6 +30,9 @@ type ProductionScheduler struct {
 	schedulingStopCh   chan struct{}
 	healthStopCh       chan struct{}
 	cleanupStopCh      chan struct{}
+	leadership         Leadership
+	leaseName          string
+	notifier           JobEventNotifier
 }
 
 // SchedulerConfig holds scheduler configuration
@@ -91,6 +94,23 @@ func NewProductionScheduler(st store.Store, config *SchedulerConfig) *Production
 	}
 }
 
+// SetLeadership restricts all scheduler loops to the elected leader.
+// Assignments carry the leader's fencing token for the named lease when the store supports it.
+func (s *ProductionScheduler) SetLeadership(leadership Leadership, leaseName string) {
+	s.leadership = leadership
+	s.leaseName = leaseName
+}
+
+// SetNotifier sets the notifier for job state changes made by the scheduler loops
+func (s *ProductionScheduler) SetNotifier(notifier JobEventNotifier) {
+	s.notifier = notifier
+}
+
+// isFollower returns true if leader election is enabled and this master is not the leader
+func (s *ProductionScheduler) isFollower() bool {
+	return s.leadership != nil && !s.leadership.IsLeader()
+}
+
 // Start begins all scheduler loops
 func (s *ProductionScheduler) Start() {
 	log.Printf("[Scheduler] Starting production scheduler (scheduling: %v, health: %v, cleanup: %v)",
@@ -138,6 +158,9 @@ func (s *ProductionScheduler) schedulingLoop() {
 	for {
 		select {
 		case <-ticker.C:
+			if s.isFollower() {
+				continue
+			}
 			s.runSchedulingCycle()
 		case <-s.stopCh:
 			log.Println("[Scheduler] Scheduling loop stopped")
@@ -158,6 +181,9 @@ func (s *ProductionScheduler) healthLoop() {
 	for {
 		select {
 		case <-ticker.C:
+			if s.isFollower() {
+				continue
+			}
 			s.runHealthCheck()
 		case <-s.stopCh:
 			log.Println("[Scheduler] Health loop stopped")
@@ -178,6 +204,9 @@ func (s *ProductionScheduler) cleanupLoop() {
 	for {
 		select {
 		case <-ticker.C:
+			if s.isFollower() {
+				continue
+			}
 			s.runCleanupCycle()
 		case <-s.stopCh:
 			log.Println("[Scheduler] Cleanup loop stopped")
@@ -215,6 +244,8 @@ func (s *ProductionScheduler) runSchedulingCycle() {
 	log.Printf("[Scheduler] Scheduling: %d queued jobs, %d available workers",
 		len(queuedJobs), len(availableWorkers))
 
+	slots := newTenantSlots(s.store)
+
 	// Process each queued job
 	for _, job := range queuedJobs {
 		// First, check if ANY worker in cluster can ever run this job
@@ -232,6 +263,11 @@ func (s *ProductionScheduler) runSchedulingCycle() {
 			continue
 		}
 
+		// Keep jobs queued while their tenant is at its concurrent job quota
+		if !slots.available(job.TenantID) {
+			continue
+		}
+
 		// Find compatible available workers
 		compatibleWorkers, reason := FindCompatibleWorkers(job, availableWorkers)
 		if len(compatibleWorkers) == 0 {
@@ -252,7 +288,12 @@ func (s *ProductionScheduler) runSchedulingCycle() {
 			continue
 		}
 
-		success, err := ext.AssignJobToWorker(job.ID, worker.ID)
+		success, err := s.assignJob(ext, job.ID, worker.ID)
+		if err == store.ErrStaleFencingToken {
+			log.Printf("[Scheduler] Leadership lost, aborting scheduling cycle")
+			s.metrics.AssignmentFailures++
+			return
+		}
 		if err != nil {
 			log.Printf("[Scheduler] Failed to assign job %s to worker %s: %v",
 				job.ID, worker.ID, err)
@@ -262,6 +303,7 @@ func (s *ProductionScheduler) runSchedulingCycle() {
 
 		if success {
 			s.metrics.AssignmentSuccesses++
+			slots.take(job.TenantID)
 			log.Printf("[Scheduler] Assigned job %d (queue=%s, priority=%s) to worker %s",
 				job.SequenceNumber, job.Queue, job.Priority, worker.Name)
 			
@@ -276,6 +318,12 @@ func (s *ProductionScheduler) runSchedulingCycle() {
 	}
 }
 
+// assignJob assigns a job, guarded by the leader's fencing token when leader election is enabled
+func (s *ProductionScheduler) assignJob(ext ExtendedStore, jobID, nodeID string) (bool, error) {
+	writer := &jobWriter{store: s.store, leadership: s.leadership, leaseName: s.leaseName}
+	return writer.AssignJobToWorker(ext, jobID, nodeID)
+}
+
 // runHealthCheck monitors worker heartbeats and marks dead workers
 func (s *ProductionScheduler) runHealthCheck() {
 	s.metrics.LastHealthCheck = time.Now()
@@ -397,7 +445,8 @@ func (s *ProductionScheduler) checkTimedOutJobs() {
 		s.metrics.TimeoutCount++
 
 		// Transition to TIMED_OUT state
-		_, err := ext.TransitionJobState(
+		_, err := s.transition(
+			ext,
 			job.ID,
 			models.JobStatusTimedOut,
 			fmt.Sprintf("Exceeded timeout threshold (last activity: %v)", job.LastActivityAt),
@@ -413,7 +462,8 @@ func (s *ProductionScheduler) checkTimedOutJobs() {
 			s.scheduleRetry(job, "timeout")
 		} else {
 			// Max retries exceeded - mark as failed
-			ext.TransitionJobState(
+			s.transition(
+				ext,
 				job.ID,
 				models.JobStatusFailed,
 				fmt.Sprintf("Max retries exceeded after timeout (%d/%d)",
@@ -434,8 +484,22 @@ func (s *ProductionScheduler) recoverOrphanedJob(job *models.Job) {
 		return
 	}
 
+	// External jobs cannot be restarted elsewhere: their process is gone or unknown
+	if job.IsExternal() {
+		if _, err := s.transition(
+			ext,
+			job.ID,
+			models.JobStatusFailed,
+			fmt.Sprintf("Worker %s died while reporting an external process", job.NodeID),
+		); err != nil {
+			log.Printf("[Cleanup] Failed to fail external job %s: %v", job.ID, err)
+		}
+		return
+	}
+
 	// First transition to RETRYING state
-	_, err = ext.TransitionJobState(
+	_, err = s.transition(
+		ext,
 		job.ID,
 		models.JobStatusRetrying,
 		fmt.Sprintf("Worker %s died mid-execution", job.NodeID),
@@ -463,7 +527,8 @@ func (s *ProductionScheduler) scheduleRetry(job *models.Job, reason string) {
 		log.Printf("[Cleanup] Job %d exceeded max retries (%d/%d)",
 			job.SequenceNumber, job.RetryCount, s.config.RetryPolicy.MaxRetries)
 
-		ext.TransitionJobState(
+		s.transition(
+			ext,
 			job.ID,
 			models.JobStatusFailed,
 			fmt.Sprintf("Max retries exceeded (%d/%d)", job.RetryCount, s.config.RetryPolicy.MaxRetries),
@@ -488,6 +553,7 @@ func (s *ProductionScheduler) scheduleRetry(job *models.Job, reason string) {
 	}
 
 	s.metrics.RetryCount++
+	notifyJobEvent(s.store, s.notifier, job.ID)
 	log.Printf("[Cleanup] Job %d re-queued for retry", job.SequenceNumber)
 }
 
@@ -507,7 +573,8 @@ func (s *ProductionScheduler) processRetryingJobs() {
 
 	for _, job := range retryingJobs {
 		// Transition from RETRYING → QUEUED
-		_, err := ext.TransitionJobState(
+		_, err := s.transition(
+			ext,
 			job.ID,
 			models.JobStatusQueued,
 			fmt.Sprintf("Retry attempt %d/%d", job.RetryCount, s.config.RetryPolicy.MaxRetries),
@@ -576,6 +643,16 @@ func (s *ProductionScheduler) storeExt() (ExtendedStore, error) {
 	return ext, nil
 }
 
+// transition performs a validated state transition and notifies subscribers
+// if the job changed state
+func (s *ProductionScheduler) transition(ext ExtendedStore, jobID string, toState models.JobStatus, reason string) (bool, error) {
+	transitioned, err := ext.TransitionJobState(jobID, toState, reason)
+	if err == nil && transitioned {
+		notifyJobEvent(s.store, s.notifier, jobID)
+	}
+	return transitioned, err
+}
+
 // rejectJob marks a job as rejected due to capability mismatch
 func (s *ProductionScheduler) rejectJob(job *models.Job, reason string) {
 	ext, err := s.storeExt()
@@ -584,7 +661,8 @@ func (s *ProductionScheduler) rejectJob(job *models.Job, reason string) {
 		return
 	}
 	
-	_, err = ext.TransitionJobState(
+	_, err = s.transition(
+		ext,
 		job.ID,
 		models.JobStatusRejected,
 		reason,
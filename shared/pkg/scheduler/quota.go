@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+// tenantSlots tracks how many jobs each tenant is running during a scheduling
+// cycle, so tenants at their concurrent job quota are skipped and cannot
+// starve the queue for everyone else
+type tenantSlots struct {
+	store   store.Store
+	running map[string]int
+	quotas  map[string]*models.TenantQuota
+}
+
+// newTenantSlots counts the running jobs of every tenant
+func newTenantSlots(st store.Store) *tenantSlots {
+	slots := &tenantSlots{
+		store:   st,
+		running: make(map[string]int),
+		quotas:  make(map[string]*models.TenantQuota),
+	}
+	for _, job := range st.GetAllJobs() {
+		if models.IsActiveState(job.Status) {
+			slots.running[job.TenantID]++
+		}
+	}
+	return slots
+}
+
+// available reports whether the tenant may start another job
+func (t *tenantSlots) available(tenantID string) bool {
+	quota, ok := t.quotas[tenantID]
+	if !ok {
+		// Jobs of unknown tenants are not limited
+		if tenant, err := t.store.GetTenant(tenantID); err == nil {
+			quota = &tenant.Quotas
+		}
+		t.quotas[tenantID] = quota
+	}
+	return quota == nil || quota.AllowsConcurrentJob(t.running[tenantID])
+}
+
+// take records that the tenant started a job
+func (t *tenantSlots) take(tenantID string) {
+	t.running[tenantID]++
+}
@@ -14,6 +14,8 @@ type RecoveryManager struct {
 	store                store.Store
 	maxRetries           int
 	nodeFailureThreshold time.Duration
+	notifier             JobEventNotifier
+	writer               *jobWriter
 }
 
 // NewRecoveryManager creates a new RecoveryManager
@@ -29,6 +31,7 @@ func NewRecoveryManager(st store.Store, maxRetries int, nodeFailureThreshold tim
 		store:                st,
 		maxRetries:           maxRetries,
 		nodeFailureThreshold: nodeFailureThreshold,
+		writer:               &jobWriter{store: st},
 	}
 }
 
@@ -38,7 +41,8 @@ func (rm *RecoveryManager) RecoverFailedJobs() {
 	recoveredCount := 0
 
 	for _, job := range allJobs {
-		if job.Status != models.JobStatusFailed {
+		// External jobs are processes the platform did not start; never retry them
+		if job.Status != models.JobStatusFailed || job.IsExternal() {
 			continue
 		}
 
@@ -49,18 +53,24 @@ func (rm *RecoveryManager) RecoverFailedJobs() {
 			continue
 		}
 
+		// A workload whose exit status was lost may have completed
+		if job.FailureReason == models.FailureReasonExitUnknown {
+			continue
+		}
+
 		// Check if failure was due to transient issues
 		if rm.isTransientFailure(job) {
 			log.Printf("Recovery: Retrying job %s (seq#%d) - attempt %d/%d",
 				job.ID, job.SequenceNumber, job.RetryCount+1, rm.maxRetries)
 
 			// Reset job to pending with incremented retry count
-			if err := rm.store.RetryJob(job.ID, fmt.Sprintf("Retry after transient failure: %s", job.Error)); err != nil {
+			if err := rm.writer.RetryJob(job.ID, fmt.Sprintf("Retry after transient failure: %s", job.Error)); err != nil {
 				log.Printf("Recovery: Failed to reset job %s: %v", job.ID, err)
 				continue
 			}
 
 			recoveredCount++
+			notifyJobEvent(rm.store, rm.notifier, job.ID)
 			log.Printf("Recovery: Job %s (seq#%d) reset to pending for retry", job.ID, job.SequenceNumber)
 		}
 	}
@@ -107,8 +117,10 @@ func (rm *RecoveryManager) ReassignJobsFromDeadNodes(deadNodeIDs []string) int {
 	reassignedCount := 0
 
 	for _, job := range allJobs {
-		// Only reassign jobs that are processing or assigned
-		if job.Status != models.JobStatusProcessing && job.Status != models.JobStatusAssigned {
+		// Only reassign jobs that are processing or assigned; external jobs
+		// run from registration until their worker reports the exit
+		external := job.IsExternal() && job.Status == models.JobStatusRunning
+		if job.Status != models.JobStatusProcessing && job.Status != models.JobStatusAssigned && !external {
 			continue
 		}
 
@@ -125,16 +137,30 @@ func (rm *RecoveryManager) ReassignJobsFromDeadNodes(deadNodeIDs []string) int {
 			continue
 		}
 
+		// External jobs cannot be restarted elsewhere: the platform did not start their process
+		if external {
+			log.Printf("Recovery: Failing external job %s (seq#%d) of dead node %s",
+				job.ID, job.SequenceNumber, job.NodeID)
+			if err := rm.writer.UpdateJobStatus(job.ID, models.JobStatusFailed,
+				fmt.Sprintf("Worker %s died while reporting an external process", job.NodeID)); err != nil {
+				log.Printf("Recovery: Failed to fail external job %s: %v", job.ID, err)
+				continue
+			}
+			notifyJobEvent(rm.store, rm.notifier, job.ID)
+			continue
+		}
+
 		log.Printf("Recovery: Reassigning job %s (seq#%d) from dead node %s",
 			job.ID, job.SequenceNumber, job.NodeID)
 
 		// Reassign job
-		if err := rm.store.RetryJob(job.ID, fmt.Sprintf("Reassigning from dead node: %s", job.NodeID)); err != nil {
+		if err := rm.writer.RetryJob(job.ID, fmt.Sprintf("Reassigning from dead node: %s", job.NodeID)); err != nil {
 			log.Printf("Recovery: Failed to reassign job %s: %v", job.ID, err)
 			continue
 		}
 
 		reassignedCount++
+		notifyJobEvent(rm.store, rm.notifier, job.ID)
 	}
 
 	if reassignedCount > 0 {
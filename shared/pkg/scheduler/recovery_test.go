@@ -70,6 +70,31 @@ func TestRecoveryManager_RecoverFailedJobs_MaxRetriesExceeded(t *testing.T) {
 	}
 }
 
+func TestRecoveryManager_RecoverFailedJobs_ExitUnknown(t *testing.T) {
+	st := store.NewMemoryStore()
+	rm := NewRecoveryManager(st, 3, 2*time.Minute)
+
+	// The workload may have completed; retrying could encode it twice
+	st.CreateJob(&models.Job{
+		ID:            "job1",
+		Scenario:      "test",
+		Status:        models.JobStatusFailed,
+		Error:         "worker died: recovered after agent restart, exit status unknown",
+		FailureReason: models.FailureReasonExitUnknown,
+		CreatedAt:     time.Now(),
+	})
+
+	rm.RecoverFailedJobs()
+
+	job, err := st.GetJob("job1")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.Status != models.JobStatusFailed || job.RetryCount != 0 {
+		t.Errorf("Expected the job to stay failed, got %s (retries %d)", job.Status, job.RetryCount)
+	}
+}
+
 func TestRecoveryManager_DetectDeadNodes(t *testing.T) {
 	st := store.NewMemoryStore()
 	rm := NewRecoveryManager(st, 3, 2*time.Minute)
@@ -154,6 +179,53 @@ func TestRecoveryManager_ReassignJobsFromDeadNodes(t *testing.T) {
 	}
 }
 
+func TestRecoveryManager_FailsExternalJobsOfDeadNodes(t *testing.T) {
+	st := store.NewMemoryStore()
+	rm := NewRecoveryManager(st, 3, 2*time.Minute)
+
+	st.RegisterNode(&models.Node{
+		ID:            "node1",
+		Address:       "test-node",
+		Status:        "offline",
+		LastHeartbeat: time.Now().Add(-5 * time.Minute),
+		RegisteredAt:  time.Now(),
+	})
+
+	// An external job stays running until its worker reports the exit
+	now := time.Now()
+	st.CreateJob(&models.Job{
+		ID:             "external1",
+		Scenario:       "external",
+		Classification: models.JobClassificationExternal,
+		Status:         models.JobStatusRunning,
+		NodeID:         "node1",
+		CreatedAt:      now,
+		StartedAt:      &now,
+	})
+
+	if count := rm.ReassignJobsFromDeadNodes([]string{"node1"}); count != 0 {
+		t.Errorf("Expected no jobs reassigned, got %d", count)
+	}
+
+	job, err := st.GetJob("external1")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected status failed, got %s", job.Status)
+	}
+	if job.RetryCount != 0 {
+		t.Errorf("Expected no retries, got %d", job.RetryCount)
+	}
+
+	// Failed external jobs are not retried by the recovery check
+	rm.RecoverFailedJobs()
+	job, _ = st.GetJob("external1")
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected external job to stay failed, got %s", job.Status)
+	}
+}
+
 func TestRecoveryManager_isTransientFailure(t *testing.T) {
 	rm := NewRecoveryManager(nil, 3, 2*time.Minute)
 
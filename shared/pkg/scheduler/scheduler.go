@@ -9,12 +9,37 @@ import (
 	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
 )
 
+// JobEventNotifier is notified when the scheduler changes a job's state
+type JobEventNotifier interface {
+	NotifyJobEvent(job *models.Job)
+}
+
+// MultiNotifier fans job events out to several notifiers
+type MultiNotifier []JobEventNotifier
+
+// NotifyJobEvent notifies every notifier of the job's current state
+func (m MultiNotifier) NotifyJobEvent(job *models.Job) {
+	for _, notifier := range m {
+		notifier.NotifyJobEvent(job)
+	}
+}
+
+// Leadership reports whether this master is the elected leader (see pkg/leader).
+// When set, background loops only run on the leader and job writes carry its fencing token.
+type Leadership interface {
+	IsLeader() bool
+	FencingToken() (int64, bool)
+}
+
 // Scheduler manages background job scheduling tasks
 type Scheduler struct {
 	store           store.Store
 	recoveryManager *RecoveryManager
 	checkInterval   time.Duration
 	stopCh          chan struct{}
+	notifier        JobEventNotifier
+	leadership      Leadership
+	writer          *jobWriter
 }
 
 // New creates a new Scheduler instance
@@ -32,9 +57,24 @@ func New(st store.Store, checkInterval time.Duration) *Scheduler {
 		recoveryManager: recoveryManager,
 		checkInterval:   checkInterval,
 		stopCh:          make(chan struct{}),
+		writer:          recoveryManager.writer,
 	}
 }
 
+// SetNotifier sets the notifier for job state changes made by the scheduler and recovery manager
+func (s *Scheduler) SetNotifier(notifier JobEventNotifier) {
+	s.notifier = notifier
+	s.recoveryManager.notifier = notifier
+}
+
+// SetLeadership restricts scheduling and recovery to the elected leader.
+// Job writes carry the leader's fencing token for the named lease when the store supports it.
+func (s *Scheduler) SetLeadership(leadership Leadership, leaseName string) {
+	s.leadership = leadership
+	s.writer.leadership = leadership
+	s.writer.leaseName = leaseName
+}
+
 // Start begins the background scheduling loop
 func (s *Scheduler) Start() {
 	log.Printf("Scheduler started (check interval: %v)", s.checkInterval)
@@ -55,6 +95,9 @@ func (s *Scheduler) run() {
 	for {
 		select {
 		case <-ticker.C:
+			if s.leadership != nil && !s.leadership.IsLeader() {
+				continue // Followers only serve the API
+			}
 			s.processPendingJobs()
 			s.checkStaleJobs()
 			s.recoveryManager.RunRecoveryCheck()
@@ -86,7 +129,7 @@ func (s *Scheduler) processPendingJobs() {
 	// Use atomic TryQueuePendingJob to avoid race conditions
 	queuedCount := 0
 	for _, job := range pendingJobs {
-		queued, err := s.store.TryQueuePendingJob(job.ID)
+		queued, err := s.writer.TryQueuePendingJob(job.ID)
 		if err != nil {
 			log.Printf("Scheduler: failed to queue job %s: %v", job.ID, err)
 			continue
@@ -102,7 +145,7 @@ func (s *Scheduler) processPendingJobs() {
 	}
 }
 
-// checkStaleJobs finds jobs that have been processing for too long and marks them as failed
+// checkStaleJobs finds jobs that have been processing for too long and times them out
 func (s *Scheduler) checkStaleJobs() {
 	// Get all processing jobs
 	allJobs := s.store.GetAllJobs()
@@ -126,44 +169,62 @@ func (s *Scheduler) checkStaleJobs() {
 			if job.LastActivityAt != nil {
 				timeSinceActivity := now.Sub(*job.LastActivityAt)
 				if timeSinceActivity > liveStaleThreshold {
-					log.Printf("Scheduler: Live job %s is stale (no activity for %v), marking as failed", 
+					log.Printf("Scheduler: Live job %s is stale (no activity for %v), timing out", 
 						job.ID, timeSinceActivity)
 					
-					// Mark as failed
-					if err := s.store.UpdateJobStatus(job.ID, models.JobStatusFailed, 
-						fmt.Sprintf("Live job stale - no activity for %v (threshold: %v)", 
-							timeSinceActivity, liveStaleThreshold)); err != nil {
-						log.Printf("Scheduler: failed to fail stale live job %s: %v", job.ID, err)
-					}
+					s.timeOutJob(job.ID, fmt.Sprintf("Live job stale - no activity for %v (threshold: %v)", 
+						timeSinceActivity, liveStaleThreshold))
 				}
 			} else if job.StartedAt != nil {
 				// Defensive fallback: if LastActivityAt is not set (shouldn't happen for new jobs),
 				// use StartedAt as the activity reference point
 				timeSinceStart := now.Sub(*job.StartedAt)
 				if timeSinceStart > liveStaleThreshold {
-					log.Printf("Scheduler: Live job %s is stale (no activity since start for %v), marking as failed", 
+					log.Printf("Scheduler: Live job %s is stale (no activity since start for %v), timing out", 
 						job.ID, timeSinceStart)
 					
-					if err := s.store.UpdateJobStatus(job.ID, models.JobStatusFailed, 
-						fmt.Sprintf("Live job stale - no activity since start for %v (threshold: %v)", 
-							timeSinceStart, liveStaleThreshold)); err != nil {
-						log.Printf("Scheduler: failed to fail stale live job %s: %v", job.ID, err)
-					}
+					s.timeOutJob(job.ID, fmt.Sprintf("Live job stale - no activity since start for %v (threshold: %v)", 
+						timeSinceStart, liveStaleThreshold))
 				}
 			}
 		} else {
 			// For batch jobs: check total processing time (time-based staleness)
 			// Batch jobs are expected to complete within a fixed time
 			if job.StartedAt != nil && job.StartedAt.Add(batchStaleThreshold).Before(now) {
-				log.Printf("Scheduler: Batch job %s is stale (processing for %v), marking as failed", 
+				log.Printf("Scheduler: Batch job %s is stale (processing for %v), timing out", 
 					job.ID, now.Sub(*job.StartedAt))
 				
-				// Mark as failed
-				if err := s.store.UpdateJobStatus(job.ID, models.JobStatusFailed, 
-					fmt.Sprintf("Batch job stale - exceeded %v timeout", batchStaleThreshold)); err != nil {
-					log.Printf("Scheduler: failed to fail stale batch job %s: %v", job.ID, err)
-				}
+				s.timeOutJob(job.ID, fmt.Sprintf("Batch job stale - exceeded %v timeout", batchStaleThreshold))
 			}
 		}
 	}
 }
+
+// timeOutJob marks a stale job as timed out and then as failed, so recovery can
+// retry it. Subscribers see both transitions.
+func (s *Scheduler) timeOutJob(jobID, reason string) {
+	if err := s.writer.UpdateJobStatus(jobID, models.JobStatusTimedOut, reason); err != nil {
+		log.Printf("Scheduler: failed to time out stale job %s: %v", jobID, err)
+		return
+	}
+	notifyJobEvent(s.store, s.notifier, jobID)
+
+	if err := s.writer.UpdateJobStatus(jobID, models.JobStatusFailed, reason); err != nil {
+		log.Printf("Scheduler: failed to fail timed out job %s: %v", jobID, err)
+		return
+	}
+	notifyJobEvent(s.store, s.notifier, jobID)
+}
+
+// notifyJobEvent reports the current state of a job to the notifier, if one is set
+func notifyJobEvent(st store.Store, notifier JobEventNotifier, jobID string) {
+	if notifier == nil {
+		return
+	}
+	job, err := st.GetJob(jobID)
+	if err != nil {
+		log.Printf("Scheduler: failed to load job %s for notification: %v", jobID, err)
+		return
+	}
+	notifier.NotifyJobEvent(job)
+}
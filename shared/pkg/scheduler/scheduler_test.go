@@ -183,3 +183,81 @@ func TestCheckStaleJobs_DefaultQueue(t *testing.T) {
 		t.Errorf("Expected stale default queue job to be marked as failed, got %s", updatedStaleJob.Status)
 	}
 }
+
+// fakeLeadership is a Leadership with a fixed role and token
+type fakeLeadership struct {
+	leader bool
+	token  int64
+}
+
+func (f *fakeLeadership) IsLeader() bool { return f.leader }
+
+func (f *fakeLeadership) FencingToken() (int64, bool) { return f.token, f.leader }
+
+// TestCheckStaleJobs_DeposedLeader tests that a master without a fencing token does not fail jobs
+func TestCheckStaleJobs_DeposedLeader(t *testing.T) {
+	st := store.NewMemoryStore()
+
+	startedAt := time.Now().Add(-35 * time.Minute)
+	st.CreateJob(&models.Job{
+		ID:        "batch-stale-job",
+		Scenario:  "test-batch",
+		Status:    models.JobStatusProcessing,
+		Queue:     "batch",
+		StartedAt: &startedAt,
+		CreatedAt: time.Now(),
+	})
+
+	// Leadership was lost after the loop's leader check
+	leadership := &fakeLeadership{leader: false}
+	scheduler := New(st, 5*time.Second)
+	scheduler.SetLeadership(leadership, "master")
+	scheduler.checkStaleJobs()
+
+	job, _ := st.GetJob("batch-stale-job")
+	if job.Status != models.JobStatusProcessing {
+		t.Errorf("Expected deposed leader to leave the job processing, got %s", job.Status)
+	}
+
+	leadership.leader = true
+	scheduler.checkStaleJobs()
+
+	job, _ = st.GetJob("batch-stale-job")
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected leader to fail the stale job, got %s", job.Status)
+	}
+}
+
+// recordingNotifier records the status of every notified job event
+type recordingNotifier struct {
+	statuses []models.JobStatus
+}
+
+func (r *recordingNotifier) NotifyJobEvent(job *models.Job) {
+	r.statuses = append(r.statuses, job.Status)
+}
+
+// TestCheckStaleJobs_NotifiesTimeout tests that subscribers see a stale job time out before it fails
+func TestCheckStaleJobs_NotifiesTimeout(t *testing.T) {
+	st := store.NewMemoryStore()
+
+	startedAt := time.Now().Add(-35 * time.Minute)
+	st.CreateJob(&models.Job{
+		ID:        "batch-stale-job",
+		Scenario:  "test-batch",
+		Status:    models.JobStatusProcessing,
+		Queue:     "batch",
+		StartedAt: &startedAt,
+		CreatedAt: time.Now(),
+	})
+
+	notifier := &recordingNotifier{}
+	scheduler := New(st, 5*time.Second)
+	scheduler.SetNotifier(notifier)
+	scheduler.checkStaleJobs()
+
+	want := []models.JobStatus{models.JobStatusTimedOut, models.JobStatusFailed}
+	if len(notifier.statuses) != len(want) || notifier.statuses[0] != want[0] || notifier.statuses[1] != want[1] {
+		t.Errorf("Expected events %v, got %v", want, notifier.statuses)
+	}
+}
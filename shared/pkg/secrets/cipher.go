@@ -0,0 +1,107 @@
+// Package secrets encrypts secret values at rest, resolves secret references in
+// job parameters and redacts secret values from logs and results.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySize is the size of the master key in bytes (AES-256)
+const KeySize = 32
+
+// ErrDecrypt is returned when a ciphertext was not sealed with this key for this secret
+var ErrDecrypt = errors.New("failed to decrypt secret: wrong key or corrupted value")
+
+// Cipher encrypts secret values with the master key using AES-256-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a KeySize-byte master key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secrets key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey decodes a base64-encoded master key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("secrets key is not valid base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secrets key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// LoadOrCreateKey loads the base64-encoded master key from path, generating
+// a new key if the file does not exist
+func LoadOrCreateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		key := make([]byte, KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate secrets key: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create secrets key directory: %w", err)
+		}
+		encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+		// O_EXCL: never overwrite a key created concurrently by another master
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create secrets key: %w", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(encoded); err != nil {
+			return nil, fmt.Errorf("failed to write secrets key: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key: %w", err)
+	}
+	return ParseKey(string(data))
+}
+
+// Seal encrypts a secret value. The ciphertext is bound to id (the secret's
+// tenant and name), so it cannot be copied to another secret.
+func (c *Cipher) Seal(id string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, []byte(id)), nil
+}
+
+// Open decrypts a value sealed for id
+func (c *Cipher) Open(id string, ciphertext []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := c.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], []byte(id))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
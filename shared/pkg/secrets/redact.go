@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// Placeholder replaces secret values in redacted text
+const Placeholder = "[REDACTED]"
+
+// Redactor replaces secret values with Placeholder. Values can be added and
+// removed while it is in use, e.g. as jobs using them start and finish.
+type Redactor struct {
+	mu       sync.RWMutex
+	values   map[string]int // Reference counts: concurrent jobs may share a secret
+	replacer *strings.Replacer
+}
+
+// NewRedactor creates a redactor for values
+func NewRedactor(values ...string) *Redactor {
+	r := &Redactor{values: make(map[string]int)}
+	r.Add(values...)
+	return r
+}
+
+// Add starts redacting values
+func (r *Redactor) Add(values ...string) {
+	if len(values) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range values {
+		if value != "" {
+			r.values[value]++
+		}
+	}
+	r.rebuild()
+}
+
+// Remove stops redacting values added once for each call to Add
+func (r *Redactor) Remove(values ...string) {
+	if len(values) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range values {
+		if r.values[value] > 1 {
+			r.values[value]--
+		} else {
+			delete(r.values, value)
+		}
+	}
+	r.rebuild()
+}
+
+// rebuild recreates the replacer; the caller must hold the write lock
+func (r *Redactor) rebuild() {
+	if len(r.values) == 0 {
+		r.replacer = nil
+		return
+	}
+
+	// Values also appear URL-encoded, e.g. in RTMP and SRT URLs
+	forms := make(map[string]bool)
+	for value := range r.values {
+		forms[value] = true
+		forms[url.QueryEscape(value)] = true
+		forms[url.PathEscape(value)] = true
+	}
+	sorted := make([]string, 0, len(forms))
+	for form := range forms {
+		sorted = append(sorted, form)
+	}
+	// Longest first, so a value containing another is replaced whole
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, form := range sorted {
+		pairs = append(pairs, form, Placeholder)
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact returns s with all secret values replaced
+func (r *Redactor) Redact(s string) string {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// RedactResult redacts the logs, error, metrics and analyzer output of a job result in place
+func (r *Redactor) RedactResult(result *models.JobResult) {
+	result.Logs = r.Redact(result.Logs)
+	result.Error = r.Redact(result.Error)
+	for key, value := range result.Metrics {
+		result.Metrics[key] = r.redactValue(value)
+	}
+	for key, value := range result.AnalyzerOutput {
+		result.AnalyzerOutput[key] = r.redactValue(value)
+	}
+}
+
+// redactValue redacts the strings in a decoded JSON value
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.Redact(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = r.redactValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// Writer returns a writer that redacts secret values before writing to w.
+// Each write is redacted on its own, so values split across writes are not
+// matched; the standard logger writes each line at once.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactingWriter{redactor: r, w: w}
+}
+
+type redactingWriter struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, rw.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RefKey marks a secret reference in job parameters: {"$secret": "<name>"}.
+// References may appear at any depth, including inside lists.
+const RefKey = "$secret"
+
+// Refs returns the sorted, unique names of the secrets referenced in params.
+// It returns an error for malformed references.
+func Refs(params map[string]interface{}) ([]string, error) {
+	seen := make(map[string]bool)
+	if _, err := walk(params, func(name string) (interface{}, error) {
+		seen[name] = true
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Resolve returns a copy of params with every secret reference replaced by the
+// value returned by lookup, and the resolved values. params is not modified.
+func Resolve(params map[string]interface{}, lookup func(name string) (string, error)) (map[string]interface{}, []string, error) {
+	var values []string
+	resolved, err := walk(params, func(name string) (interface{}, error) {
+		value, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		return value, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if resolved == nil {
+		return nil, values, nil
+	}
+	return resolved.(map[string]interface{}), values, nil
+}
+
+// walk copies value, replacing secret references with the result of replace
+func walk(value interface{}, replace func(name string) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		if ref, ok := v[RefKey]; ok {
+			name, isString := ref.(string)
+			if !isString || name == "" || len(v) != 1 {
+				return nil, fmt.Errorf(`invalid secret reference: use {"%s": "<name>"}`, RefKey)
+			}
+			return replace(name)
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied, err := walk(item, replace)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = copied
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			copied, err := walk(item, replace)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = copied
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+func TestCipher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets", "master.key")
+	key, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file with mode 0600, got %v (%v)", info.Mode().Perm(), err)
+	}
+	reloaded, err := LoadOrCreateKey(path)
+	if err != nil || !bytes.Equal(key, reloaded) {
+		t.Fatalf("Expected the existing key to be loaded, got error %v", err)
+	}
+
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	sealed, err := c.Seal("tenant-a/stream_key", []byte("live_123"))
+	if err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("live_123")) {
+		t.Error("Ciphertext contains the plaintext")
+	}
+	if plaintext, err := c.Open("tenant-a/stream_key", sealed); err != nil || string(plaintext) != "live_123" {
+		t.Errorf("Expected live_123, got %q (%v)", plaintext, err)
+	}
+
+	// Ciphertexts are bound to their secret and key
+	if _, err := c.Open("tenant-b/stream_key", sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt opening another secret's value, got %v", err)
+	}
+	other, _ := NewCipher(bytes.Repeat([]byte{1}, KeySize))
+	if _, err := other.Open("tenant-a/stream_key", sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt with the wrong key, got %v", err)
+	}
+
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Error("Expected error for a short key")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	params := map[string]interface{}{
+		"output":  "rtmp://live.example.com/app",
+		"bitrate": "4000k",
+		"stream":  map[string]interface{}{RefKey: "stream_key"},
+		"outputs": []interface{}{
+			map[string]interface{}{"passphrase": map[string]interface{}{RefKey: "srt_pass"}},
+		},
+	}
+
+	names, err := Refs(params)
+	if err != nil || !reflect.DeepEqual(names, []string{"srt_pass", "stream_key"}) {
+		t.Fatalf("Unexpected refs %v (%v)", names, err)
+	}
+
+	values := map[string]string{"stream_key": "live_123", "srt_pass": "hunter2hunter2"}
+	resolved, resolvedValues, err := Resolve(params, func(name string) (string, error) {
+		return values[name], nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if resolved["stream"] != "live_123" || resolved["bitrate"] != "4000k" {
+		t.Errorf("Unexpected resolved parameters: %v", resolved)
+	}
+	nested := resolved["outputs"].([]interface{})[0].(map[string]interface{})
+	if nested["passphrase"] != "hunter2hunter2" {
+		t.Errorf("Expected nested reference to be resolved, got %v", nested)
+	}
+	if len(resolvedValues) != 2 {
+		t.Errorf("Expected 2 resolved values, got %v", resolvedValues)
+	}
+	if _, ok := params["stream"].(map[string]interface{}); !ok {
+		t.Error("Resolve modified the original parameters")
+	}
+
+	lookupErr := errors.New("not found")
+	if _, _, err := Resolve(params, func(string) (string, error) { return "", lookupErr }); !errors.Is(err, lookupErr) {
+		t.Errorf("Expected lookup error, got %v", err)
+	}
+
+	for _, invalid := range []map[string]interface{}{
+		{"key": map[string]interface{}{RefKey: 42}},
+		{"key": map[string]interface{}{RefKey: "name", "extra": true}},
+	} {
+		if _, err := Refs(invalid); err == nil {
+			t.Errorf("Expected error for invalid reference %v", invalid)
+		}
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor("live_123", "pa ss/word")
+
+	got := r.Redact("ffmpeg -f flv rtmp://live.example.com/app/live_123 srt://host?passphrase=pa+ss%2Fword")
+	if strings.Contains(got, "live_123") || strings.Contains(got, "pa+ss%2Fword") {
+		t.Errorf("Expected secret values to be redacted, got %q", got)
+	}
+	if !strings.Contains(got, "rtmp://live.example.com/app/"+Placeholder) {
+		t.Errorf("Expected placeholder in %q", got)
+	}
+
+	result := &models.JobResult{
+		Logs:           "Command: ffmpeg ... live_123",
+		Error:          "connection to live_123 refused",
+		Metrics:        map[string]interface{}{"output": "rtmp://host/live_123", "fps": 30.0},
+		AnalyzerOutput: map[string]interface{}{"streams": []interface{}{"live_123"}},
+	}
+	r.RedactResult(result)
+	if strings.Contains(result.Logs+result.Error, "live_123") || result.Metrics["output"] != "rtmp://host/"+Placeholder ||
+		result.Metrics["fps"] != 30.0 || result.AnalyzerOutput["streams"].([]interface{})[0] != Placeholder {
+		t.Errorf("Unexpected redacted result: %+v", result)
+	}
+
+	// Values shared by two jobs stay redacted until both are removed
+	var buf bytes.Buffer
+	w := r.Writer(&buf)
+	r.Add("live_123")
+	r.Remove("live_123")
+	w.Write([]byte("key=live_123\n"))
+	r.Remove("live_123")
+	w.Write([]byte("key=live_123\n"))
+	if buf.String() != "key="+Placeholder+"\nkey=live_123\n" {
+		t.Errorf("Unexpected writer output %q", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testAuditEvents exercises audit log storage and filtering
+func testAuditEvents(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+	events := []*models.AuditEvent{
+		{ID: "a1", Timestamp: now.Add(-2 * time.Hour), ActorType: models.AuditActorUser, ActorID: "user-1",
+			Action: "job.cancel", TargetType: "job", TargetID: "job-1", Method: "POST", Path: "/jobs/job-1/cancel",
+			RequestID: "req-1", SourceIP: "10.0.0.1", StatusCode: 200, Outcome: models.AuditOutcomeSuccess},
+		{ID: "a2", Timestamp: now.Add(-time.Hour), TenantID: "tenant-1", ActorType: models.AuditActorAPIKey, ActorID: "key-1",
+			Action: "job.create", TargetType: "job", TargetID: "job-2", Method: "POST", Path: "/jobs",
+			RequestID: "req-2", SourceIP: "10.0.0.2", ForwardedFor: "203.0.113.9", StatusCode: 201, Outcome: models.AuditOutcomeSuccess},
+		{ID: "a3", Timestamp: now, ActorType: models.AuditActorUser, ActorID: "user-2",
+			Action: "node.delete", TargetType: "node", TargetID: "node-1", Method: "DELETE", Path: "/nodes/node-1",
+			RequestID: "req-3", SourceIP: "10.0.0.3", StatusCode: 403, Outcome: models.AuditOutcomeDenied},
+	}
+	for _, event := range events {
+		if err := s.CreateAuditEvent(event); err != nil {
+			t.Fatalf("Failed to create audit event: %v", err)
+		}
+	}
+
+	ids := func(filter models.AuditFilter) []string {
+		t.Helper()
+		list, err := s.ListAuditEvents(filter)
+		if err != nil {
+			t.Fatalf("Failed to list audit events: %v", err)
+		}
+		var result []string
+		for _, event := range list {
+			result = append(result, event.ID)
+		}
+		return result
+	}
+	expect := func(name string, filter models.AuditFilter, want ...string) {
+		t.Helper()
+		got := ids(filter)
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %v, got %v", name, want, got)
+				return
+			}
+		}
+	}
+
+	expect("all, newest first", models.AuditFilter{}, "a3", "a2", "a1")
+	expect("limit", models.AuditFilter{Limit: 2}, "a3", "a2")
+	expect("actor", models.AuditFilter{ActorID: "user-1"}, "a1")
+	expect("actor type", models.AuditFilter{ActorType: models.AuditActorUser}, "a3", "a1")
+	expect("action", models.AuditFilter{Action: "job.create"}, "a2")
+	expect("target", models.AuditFilter{TargetID: "node-1"}, "a3")
+	expect("tenant", models.AuditFilter{TenantID: "tenant-1"}, "a2")
+	expect("outcome", models.AuditFilter{Outcome: models.AuditOutcomeDenied}, "a3")
+	expect("time range", models.AuditFilter{Since: now.Add(-time.Hour), Until: now}, "a2")
+
+	list, err := s.ListAuditEvents(models.AuditFilter{Action: "job.create"})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Failed to get audit event: %v", err)
+	}
+	got := list[0]
+	if !got.Timestamp.Equal(now.Add(-time.Hour)) || got.ForwardedFor != "203.0.113.9" || got.StatusCode != 201 ||
+		got.RequestID != "req-2" || got.TargetType != "job" || got.Method != "POST" {
+		t.Errorf("Unexpected audit event: %+v", got)
+	}
+}
+
+func TestMemoryAuditEvents(t *testing.T) {
+	testAuditEvents(t, NewMemoryStore())
+}
+
+func TestSQLiteAuditEvents(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testAuditEvents(t, s)
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Backup formats produced by Store.Backup
+const (
+	BackupFormatSQLite   = "sqlite" // SQLite database file (online backup API)
+	BackupFormatPostgres = "pgdump" // pg_dump-compatible SQL script with COPY blocks (loadable with psql)
+	BackupFormatJSON     = "json"   // JSON snapshot of the in-memory store
+)
+
+// ErrInvalidBackup is returned when a backup fails validation
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// sqliteHeader is the magic string at the start of every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// pgdumpHeader is the first line of a PostgreSQL logical backup
+const pgdumpHeader = "-- ffrtmp PostgreSQL logical backup"
+
+// DetectBackupFormat identifies a backup format from its first bytes (the first 64 bytes are sufficient)
+func DetectBackupFormat(header []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(header, sqliteHeader):
+		return BackupFormatSQLite, nil
+	case bytes.HasPrefix(header, []byte(pgdumpHeader)):
+		return BackupFormatPostgres, nil
+	case bytes.HasPrefix(bytes.TrimSpace(header), []byte("{")):
+		return BackupFormatJSON, nil
+	}
+	return "", fmt.Errorf("%w: unrecognized backup format", ErrInvalidBackup)
+}
+
+// invalidBackup wraps a validation failure in ErrInvalidBackup
+func invalidBackup(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidBackup, fmt.Sprintf(format, args...))
+}
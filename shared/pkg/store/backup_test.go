@@ -0,0 +1,146 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+func backupTestJob(id string) *models.Job {
+	return &models.Job{
+		ID:        id,
+		Scenario:  "4K60-h264",
+		Engine:    "auto",
+		Status:    models.JobStatusPending,
+		Queue:     "default",
+		Priority:  "medium",
+		CreatedAt: time.Now(),
+	}
+}
+
+// TestSQLiteBackupRestore verifies a snapshot restores the exact database state
+func TestSQLiteBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	src, err := NewSQLiteStore(filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer src.Close()
+
+	if err := src.CreateJob(backupTestJob("job-1")); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Backup(&buf); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if format, err := DetectBackupFormat(buf.Bytes()); err != nil || format != BackupFormatSQLite {
+		t.Fatalf("Expected sqlite backup format, got %q (%v)", format, err)
+	}
+
+	// Changes after the backup must be rolled back by restore
+	if err := src.CreateJob(backupTestJob("job-2")); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	if err := src.ValidateBackup(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ValidateBackup failed: %v", err)
+	}
+	if err := src.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	if _, err := src.GetJob("job-1"); err != nil {
+		t.Errorf("Expected job-1 after restore: %v", err)
+	}
+	if _, err := src.GetJob("job-2"); err != ErrJobNotFound {
+		t.Errorf("Expected job-2 to be gone after restore, got %v", err)
+	}
+
+	// Corrupt backups are rejected
+	corrupt := append([]byte(nil), buf.Bytes()[:len(buf.Bytes())/2]...)
+	if err := src.ValidateBackup(bytes.NewReader(corrupt)); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("Expected ErrInvalidBackup for truncated database, got %v", err)
+	}
+	if err := src.Restore(strings.NewReader(`{"format":"json"}`)); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("Expected ErrInvalidBackup for wrong format, got %v", err)
+	}
+}
+
+// TestMemoryBackupRestore verifies the JSON snapshot round trip
+func TestMemoryBackupRestore(t *testing.T) {
+	src := NewMemoryStore()
+	src.CreateJob(backupTestJob("job-1"))
+	src.RegisterNode(&models.Node{ID: "node-1", Address: "localhost:9000", Status: "available"})
+	src.CreateWebhook(&models.Webhook{ID: "hook-1", URL: "http://example.com", Enabled: true})
+
+	var buf bytes.Buffer
+	if err := src.Backup(&buf); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if format, _ := DetectBackupFormat(buf.Bytes()); format != BackupFormatJSON {
+		t.Fatalf("Expected json backup format, got %q", format)
+	}
+
+	dst := NewMemoryStore()
+	if err := dst.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if _, err := dst.GetJob("job-1"); err != nil {
+		t.Errorf("Expected job-1 after restore: %v", err)
+	}
+	if _, err := dst.GetNode("node-1"); err != nil {
+		t.Errorf("Expected node-1 after restore: %v", err)
+	}
+	if _, err := dst.GetWebhook("hook-1"); err != nil {
+		t.Errorf("Expected hook-1 after restore: %v", err)
+	}
+
+	// New jobs continue the sequence
+	dst.CreateJob(backupTestJob("job-2"))
+	job2, _ := dst.GetJob("job-2")
+	job1, _ := dst.GetJob("job-1")
+	if job2.SequenceNumber <= job1.SequenceNumber {
+		t.Errorf("Expected sequence to continue after restore: %d <= %d", job2.SequenceNumber, job1.SequenceNumber)
+	}
+
+	invalid := `{"format":"json","version":1,"jobs":[{"id":"a"},{"id":"a"}]}`
+	if err := dst.ValidateBackup(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("Expected ErrInvalidBackup for duplicate jobs, got %v", err)
+	}
+}
+
+// TestParseCopyBackup verifies parsing of the PostgreSQL logical backup format
+func TestParseCopyBackup(t *testing.T) {
+	value := "line1\nline2\twith tab and \\ backslash"
+	dump := pgdumpHeader + "\n" +
+		"BEGIN;\n" +
+		"COPY \"jobs\" (\"id\", \"logs\", \"error\") FROM stdin;\n" +
+		"job-1\t" + copyEscape(value) + "\t\\N\n" +
+		"\\.\n" +
+		"COMMIT;\n"
+
+	blocks, err := parseCopyBackup(strings.NewReader(dump))
+	if err != nil {
+		t.Fatalf("parseCopyBackup failed: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].table != "jobs" || len(blocks[0].columns) != 3 {
+		t.Fatalf("Unexpected blocks: %+v", blocks)
+	}
+	row := blocks[0].rows[0]
+	if *row[0] != "job-1" || *row[1] != value || row[2] != nil {
+		t.Errorf("Unexpected row values: %q %q %v", *row[0], *row[1], row[2])
+	}
+
+	truncated := strings.TrimSuffix(dump, "COMMIT;\n")
+	if _, err := parseCopyBackup(strings.NewReader(truncated)); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("Expected ErrInvalidBackup for truncated dump, got %v", err)
+	}
+}
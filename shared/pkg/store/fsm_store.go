@@ -289,7 +289,7 @@ func (s *SQLiteStore) GetJobsInState(state models.JobStatus) ([]*models.Job, err
 	rows, err := s.db.Query(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, 
 		       progress, node_id, created_at, started_at, last_activity_at, completed_at, 
-		       retry_count, error, logs, state_transitions
+		       retry_count, error, logs, state_transitions, tenant_id
 		FROM jobs 
 		WHERE status = ?
 		ORDER BY created_at ASC
@@ -303,6 +303,24 @@ func (s *SQLiteStore) GetJobsInState(state models.JobStatus) ([]*models.Job, err
 	return s.scanJobs(rows)
 }
 
+// GetNodeJobsInState returns the jobs of a node in a specific state
+func (s *SQLiteStore) GetNodeJobsInState(nodeID string, state models.JobStatus) ([]*models.Job, error) {
+	rows, err := s.db.Query(`
+		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority,
+		       progress, node_id, created_at, started_at, last_activity_at, completed_at,
+		       retry_count, error, logs, state_transitions, tenant_id
+		FROM jobs
+		WHERE node_id = ? AND status = ?
+		ORDER BY created_at ASC
+	`, nodeID, string(state))
+	if err != nil {
+		return nil, fmt.Errorf("query node jobs: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanJobs(rows)
+}
+
 // GetOrphanedJobs finds jobs assigned/running on offline/dead workers
 func (s *SQLiteStore) GetOrphanedJobs(workerTimeout time.Duration) ([]*models.Job, error) {
 	cutoff := time.Now().Add(-workerTimeout)
@@ -310,7 +328,7 @@ func (s *SQLiteStore) GetOrphanedJobs(workerTimeout time.Duration) ([]*models.Jo
 	rows, err := s.db.Query(`
 		SELECT j.id, j.sequence_number, j.scenario, j.confidence, j.engine, j.parameters, j.status, 
 		       j.queue, j.priority, j.progress, j.node_id, j.created_at, j.started_at, 
-		       j.last_activity_at, j.completed_at, j.retry_count, j.error, j.logs, j.state_transitions
+		       j.last_activity_at, j.completed_at, j.retry_count, j.error, j.logs, j.state_transitions, j.tenant_id
 		FROM jobs j
 		INNER JOIN nodes n ON j.node_id = n.id
 		WHERE j.status IN (?, ?)
@@ -332,7 +350,7 @@ func (s *SQLiteStore) GetTimedOutJobs() ([]*models.Job, error) {
 	rows, err := s.db.Query(`
 		SELECT j.id, j.sequence_number, j.scenario, j.confidence, j.engine, j.parameters, j.status, 
 		       j.queue, j.priority, j.progress, j.node_id, j.created_at, j.started_at, 
-		       j.last_activity_at, j.completed_at, j.retry_count, j.error, j.logs, j.state_transitions
+		       j.last_activity_at, j.completed_at, j.retry_count, j.error, j.logs, j.state_transitions, j.tenant_id
 		FROM jobs j
 		WHERE j.status IN (?, ?)
 		  AND j.last_activity_at IS NOT NULL
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"io"
 	"time"
 
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
@@ -47,6 +48,7 @@ type Store interface {
 	CompleteJob(jobID, nodeID string) (bool, error)
 	UpdateJobHeartbeat(jobID string) error
 	GetJobsInState(state models.JobStatus) ([]*models.Job, error)
+	GetNodeJobsInState(nodeID string, state models.JobStatus) ([]*models.Job, error)
 	GetOrphanedJobs(workerTimeout time.Duration) ([]*models.Job, error)
 	GetTimedOutJobs() ([]*models.Job, error)
 
@@ -64,11 +66,85 @@ type Store interface {
 	GetJobsByTenant(tenantID string) ([]*models.Job, error)
 	GetNodesByTenant(tenantID string) ([]*models.Node, error)
 
+	// Tenant API key operations
+	CreateTenantAPIKey(key *models.TenantAPIKey) error
+	GetTenantAPIKey(id string) (*models.TenantAPIKey, error)
+	GetTenantAPIKeyByPrefix(prefix string) (*models.TenantAPIKey, error)
+	ListTenantAPIKeys(tenantID string) ([]*models.TenantAPIKey, error)
+	UpdateTenantAPIKey(key *models.TenantAPIKey) error
+	TouchTenantAPIKey(id string, usedAt time.Time) error
+
+	// User operations
+	CreateUser(user *models.User) error
+	GetUser(id string) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	ListUsers(tenantID string) ([]*models.User, error)
+	UpdateUser(user *models.User) error
+	DeleteUser(id string) error
+
+	// Session operations
+	CreateSession(session *models.Session) error
+	GetSessionByTokenHash(tokenHash string) (*models.Session, error)
+	DeleteSession(tokenHash string) error
+	DeleteUserSessions(userID string) error
+	DeleteExpiredSessions() (int, error)
+
+	// Node identity token operations
+	SaveNodeToken(token *models.NodeToken) error
+	GetNodeToken(nodeID string) (*models.NodeToken, error)
+	DeleteNodeToken(nodeID string) error
+
+	// Worker certificate authority operations
+	CreateBootstrapToken(token *models.BootstrapToken) error
+	ListBootstrapTokens() ([]*models.BootstrapToken, error)
+	UseBootstrapToken(tokenHash, usedBy string, usedAt time.Time) (*models.BootstrapToken, error)
+	SaveCertificate(cert *models.IssuedCertificate) error
+	GetCertificate(serial string) (*models.IssuedCertificate, error)
+	ListCertificates(revokedOnly bool) ([]*models.IssuedCertificate, error)
+	RevokeCertificate(serial string, revokedAt time.Time) error
+
+	// Secret operations (values are encrypted by the caller)
+	CreateSecret(secret *models.Secret) error
+	GetSecret(tenantID, name string) (*models.Secret, error)
+	ListSecrets(tenantID string) ([]*models.Secret, error)
+	UpdateSecret(secret *models.Secret) error
+	DeleteSecret(tenantID, name string) error
+
+	// Rate limit operations (token buckets shared by all masters using the store)
+	TakeRateLimitToken(key string, rate float64, burst int, now time.Time) (models.RateLimitDecision, error)
+	DeleteFullRateLimitBuckets(before time.Time) (int, error)
+
+	// Job result operations
+	SaveJobResult(result *models.JobResult) error
+	GetJobResult(jobID string) (*models.JobResult, error)
+	GetResultAggregates(groupBy string, since time.Time) ([]*models.ResultAggregate, error)
+
+	// Webhook operations
+	CreateWebhook(hook *models.Webhook) error
+	GetWebhook(id string) (*models.Webhook, error)
+	ListWebhooks() ([]*models.Webhook, error)
+	UpdateWebhook(hook *models.Webhook) error
+	DeleteWebhook(id string) error
+	SaveWebhookDelivery(delivery *models.WebhookDelivery) error
+	GetWebhookDelivery(id string) (*models.WebhookDelivery, error)
+	ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error)
+	DeleteWebhookDeliveries(before time.Time) (int, error)
+
+	// Audit log operations (append-only)
+	CreateAuditEvent(event *models.AuditEvent) error
+	ListAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error)
+
 	// Lifecycle
 	Close() error
 	HealthCheck() error
 	Vacuum() error
 
+	// Backup operations (see backup.go for formats)
+	BackupFormat() string
+	Backup(w io.Writer) error
+	ValidateBackup(r io.Reader) error
+	Restore(r io.Reader) error
+
 	// Metrics operations (optimized for large datasets)
 	GetJobMetrics() (*JobMetrics, error)
 }
@@ -92,6 +168,17 @@ type ExtendedStore interface {
 	Store
 }
 
+// FencedStore is implemented by stores that reject job writes made with a stale fencing token.
+// Each method applies its write only if fencingToken is still the current token of the named
+// leader lease, and returns ErrStaleFencingToken otherwise.
+type FencedStore interface {
+	AssignJobToWorkerFenced(jobID, nodeID, lease string, fencingToken int64) (bool, error)
+	TryQueuePendingJobFenced(jobID, lease string, fencingToken int64) (bool, error)
+	UpdateJobStatusFenced(id string, status models.JobStatus, errorMsg, lease string, fencingToken int64) error
+	RetryJobFenced(jobID, errorMsg, lease string, fencingToken int64) error
+	DeleteJobFenced(id, lease string, fencingToken int64) error
+}
+
 // Config holds database configuration
 type Config struct {
 	Type string // "sqlite" or "postgres"
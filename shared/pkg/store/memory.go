@@ -1,8 +1,11 @@
 package store
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,6 +15,23 @@ import (
 var (
 	ErrNodeNotFound = errors.New("node not found")
 	ErrJobNotFound  = errors.New("job not found")
+
+	ErrTenantNotFound          = errors.New("tenant not found")
+	ErrTenantExists            = errors.New("tenant with this name already exists")
+	ErrDefaultTenant           = errors.New("the default tenant cannot be deleted")
+	ErrTenantAPIKeyNotFound    = errors.New("tenant API key not found")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrUserExists              = errors.New("user with this email already exists")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrNodeTokenNotFound       = errors.New("node token not found")
+	ErrBootstrapTokenNotFound  = errors.New("bootstrap token not found, expired or already used")
+	ErrCertificateNotFound     = errors.New("certificate not found")
+	ErrSecretNotFound          = errors.New("secret not found")
+	ErrSecretExists            = errors.New("secret with this name already exists")
+	ErrJobResultNotFound       = errors.New("job result not found")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrStaleFencingToken       = errors.New("stale fencing token: leadership lost")
 )
 
 // MemoryStore is an in-memory implementation of the data store
@@ -22,6 +42,20 @@ type MemoryStore struct {
 	jobs       map[string]*models.Job
 	jobQueue   []string // FIFO queue of job IDs
 	nextSeqNum int      // Auto-incrementing sequence number for jobs
+
+	tenants    map[string]*models.Tenant
+	apiKeys    map[string]*models.TenantAPIKey // Keyed by key prefix
+	users      map[string]*models.User
+	sessions   map[string]*models.Session           // Keyed by token hash
+	nodeTokens map[string]*models.NodeToken         // Keyed by node ID
+	bootstrap  map[string]*models.BootstrapToken    // Keyed by token hash
+	certs      map[string]*models.IssuedCertificate // Keyed by serial
+	secrets    map[string]*models.Secret            // Keyed by secretKey
+	buckets    map[string]*models.RateLimitBucket   // Keyed by rate limit key
+	results    map[string]*models.JobResult         // Keyed by job ID
+	webhooks   map[string]*models.Webhook
+	deliveries map[string]*models.WebhookDelivery
+	audit      []*models.AuditEvent // Append-only, oldest first
 }
 
 // NewMemoryStore creates a new in-memory store
@@ -31,6 +65,25 @@ func NewMemoryStore() *MemoryStore {
 		jobs:       make(map[string]*models.Job),
 		nextSeqNum: 1,
 		jobQueue:   make([]string, 0),
+		tenants:    defaultTenants(),
+		apiKeys:    make(map[string]*models.TenantAPIKey),
+		users:      make(map[string]*models.User),
+		sessions:   make(map[string]*models.Session),
+		nodeTokens: make(map[string]*models.NodeToken),
+		bootstrap:  make(map[string]*models.BootstrapToken),
+		certs:      make(map[string]*models.IssuedCertificate),
+		secrets:    make(map[string]*models.Secret),
+		buckets:    make(map[string]*models.RateLimitBucket),
+		results:    make(map[string]*models.JobResult),
+		webhooks:   make(map[string]*models.Webhook),
+		deliveries: make(map[string]*models.WebhookDelivery),
+	}
+}
+
+// defaultTenants returns the tenant map of a new store, holding the default tenant
+func defaultTenants() map[string]*models.Tenant {
+	return map[string]*models.Tenant{
+		models.DefaultTenantID: newDefaultTenant(),
 	}
 }
 
@@ -111,7 +164,7 @@ func (s *MemoryStore) UpdateNodeHeartbeat(id string) error {
 	return nil
 }
 
-// DeleteNode removes a node from the store
+// DeleteNode removes a node and its identity token from the store
 func (s *MemoryStore) DeleteNode(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -121,6 +174,7 @@ func (s *MemoryStore) DeleteNode(id string) error {
 	}
 
 	delete(s.nodes, id)
+	delete(s.nodeTokens, id)
 	return nil
 }
 
@@ -136,6 +190,9 @@ func (s *MemoryStore) CreateJob(job *models.Job) error {
 		job.SequenceNumber = s.nextSeqNum
 		s.nextSeqNum++
 	}
+	if job.TenantID == "" {
+		job.TenantID = models.DefaultTenantID
+	}
 
 	s.jobs[job.ID] = job
 	s.jobQueue = append(s.jobQueue, job.ID)
@@ -185,13 +242,30 @@ func (s *MemoryStore) GetNextJob(nodeID string) (*models.Job, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Nodes dedicated to a tenant only take that tenant's jobs
+	nodeTenantID := ""
+	if node, ok := s.nodes[nodeID]; ok {
+		nodeTenantID = node.TenantID
+	}
+
+	// Tenants at their concurrent job quota are skipped until a job finishes
+	running := make(map[string]int)
+	for _, job := range s.jobs {
+		if models.IsActiveState(job.Status) {
+			running[job.TenantID]++
+		}
+	}
+
 	// Find first pending job
 	for i, jobID := range s.jobQueue {
-		// Lock both jobs and nodes for atomic operation
-		s.mu.Lock()
 		job, ok := s.jobs[jobID]
 		if !ok || job.Status != models.JobStatusPending {
-			s.mu.Unlock()
+			continue
+		}
+		if nodeTenantID != "" && job.TenantID != nodeTenantID {
+			continue
+		}
+		if tenant, ok := s.tenants[job.TenantID]; ok && !tenant.Quotas.AllowsConcurrentJob(running[job.TenantID]) {
 			continue
 		}
 
@@ -201,18 +275,15 @@ func (s *MemoryStore) GetNextJob(nodeID string) (*models.Job, error) {
 		job.NodeID = nodeID
 		job.StartedAt = &now
 		job.LastActivityAt = &now
-		s.mu.Unlock()
 
 		// Remove from queue
 		s.jobQueue = append(s.jobQueue[:i], s.jobQueue[i+1:]...)
 
 		// Update node status
-		s.mu.Lock()
 		if node, ok := s.nodes[nodeID]; ok {
 			node.Status = "busy"
 			node.CurrentJobID = jobID
 		}
-		s.mu.Unlock()
 
 		return job, nil
 	}
@@ -241,12 +312,10 @@ func (s *MemoryStore) UpdateJobStatus(id string, status models.JobStatus, errorM
 
 		// Update node status back to available
 		if job.NodeID != "" {
-			s.mu.Lock()
 			if node, ok := s.nodes[job.NodeID]; ok {
 				node.Status = "available"
 				node.CurrentJobID = ""
 			}
-			s.mu.Unlock()
 		}
 	}
 
@@ -387,12 +456,10 @@ func (s *MemoryStore) CancelJob(id string) error {
 
 	// Free up node if assigned
 	if job.NodeID != "" {
-		s.mu.Lock()
 		if node, ok := s.nodes[job.NodeID]; ok {
 			node.Status = "available"
 			node.CurrentJobID = ""
 		}
-		s.mu.Unlock()
 	}
 
 	// Set completed_at
@@ -650,6 +717,21 @@ func (s *MemoryStore) GetJobsInState(state models.JobStatus) ([]*models.Job, err
 	return result, nil
 }
 
+// GetNodeJobsInState returns the jobs of a node in a specific state
+func (s *MemoryStore) GetNodeJobsInState(nodeID string, state models.JobStatus) ([]*models.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []*models.Job{}
+	for _, job := range s.jobs {
+		if job.NodeID == nodeID && job.Status == state {
+			result = append(result, job)
+		}
+	}
+
+	return result, nil
+}
+
 // GetOrphanedJobs finds jobs assigned/running on offline/dead workers
 func (s *MemoryStore) GetOrphanedJobs(workerTimeout time.Duration) ([]*models.Job, error) {
 	s.mu.RLock()
@@ -734,138 +816,1332 @@ func (s *MemoryStore) HealthCheck() error {
 	return nil
 }
 
-// Tenant operations (multi-tenancy) - Stub implementations
+// Tenant operations
+
+// CreateTenant adds a new tenant
 func (s *MemoryStore) CreateTenant(tenant *models.Tenant) error {
-	return fmt.Errorf("multi-tenancy not supported in memory store")
+	if err := tenant.Validate(); err != nil {
+		return fmt.Errorf("invalid tenant: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.tenants {
+		if existing.ID == tenant.ID || existing.Name == tenant.Name {
+			return ErrTenantExists
+		}
+	}
+
+	t := *tenant
+	s.tenants[tenant.ID] = &t
+	return nil
 }
 
+// GetTenant retrieves a tenant by ID
 func (s *MemoryStore) GetTenant(id string) (*models.Tenant, error) {
-	return nil, fmt.Errorf("multi-tenancy not supported in memory store")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return nil, ErrTenantNotFound
+	}
+	t := *tenant
+	return &t, nil
 }
 
+// GetTenantByName retrieves a tenant by its unique name
 func (s *MemoryStore) GetTenantByName(name string) (*models.Tenant, error) {
-	return nil, fmt.Errorf("multi-tenancy not supported in memory store")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tenant := range s.tenants {
+		if tenant.Name == name {
+			t := *tenant
+			return &t, nil
+		}
+	}
+	return nil, ErrTenantNotFound
 }
 
+// ListTenants returns all tenants that have not been deleted, oldest first
 func (s *MemoryStore) ListTenants() ([]*models.Tenant, error) {
-	return nil, fmt.Errorf("multi-tenancy not supported in memory store")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenants := make([]*models.Tenant, 0, len(s.tenants))
+	for _, tenant := range s.tenants {
+		if tenant.Status == models.TenantStatusDeleted {
+			continue
+		}
+		t := *tenant
+		tenants = append(tenants, &t)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].CreatedAt.Before(tenants[j].CreatedAt)
+	})
+	return tenants, nil
 }
 
+// UpdateTenant replaces an existing tenant
 func (s *MemoryStore) UpdateTenant(tenant *models.Tenant) error {
-	return fmt.Errorf("multi-tenancy not supported in memory store")
+	if err := tenant.Validate(); err != nil {
+		return fmt.Errorf("invalid tenant: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tenants[tenant.ID]; !ok {
+		return ErrTenantNotFound
+	}
+	for _, existing := range s.tenants {
+		if existing.ID != tenant.ID && existing.Name == tenant.Name {
+			return ErrTenantExists
+		}
+	}
+
+	t := *tenant
+	s.tenants[tenant.ID] = &t
+	return nil
 }
 
+// DeleteTenant soft-deletes a tenant by marking it deleted
 func (s *MemoryStore) DeleteTenant(id string) error {
-	return fmt.Errorf("multi-tenancy not supported in memory store")
+	if id == models.DefaultTenantID {
+		return ErrDefaultTenant
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return ErrTenantNotFound
+	}
+	tenant.Status = models.TenantStatusDeleted
+	tenant.UpdatedAt = time.Now()
+	return nil
 }
 
+// UpdateTenantUsage stores a usage snapshot for a tenant
 func (s *MemoryStore) UpdateTenantUsage(id string, usage *models.TenantUsage) error {
-	return fmt.Errorf("multi-tenancy not supported in memory store")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return ErrTenantNotFound
+	}
+	tenant.Usage = *usage
+	tenant.Usage.TenantID = id
+	return nil
 }
 
+// GetTenantStats returns the live usage of a tenant
 func (s *MemoryStore) GetTenantStats(id string) (*models.TenantUsage, error) {
-	return nil, fmt.Errorf("multi-tenancy not supported in memory store")
+	tenant, err := s.GetTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	jobs, _ := s.GetJobsByTenant(id)
+	nodes, _ := s.GetNodesByTenant(id)
+	return computeTenantUsage(id, tenant.Usage, jobs, nodes), nil
 }
 
+// GetJobsByTenant returns the jobs owned by a tenant, ordered by sequence number
 func (s *MemoryStore) GetJobsByTenant(tenantID string) ([]*models.Job, error) {
-	return nil, fmt.Errorf("multi-tenancy not supported in memory store")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]*models.Job, 0)
+	for _, job := range s.jobs {
+		if job.TenantID == tenantID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].SequenceNumber < jobs[j].SequenceNumber
+	})
+	return jobs, nil
 }
 
+// GetNodesByTenant returns the nodes dedicated to a tenant
 func (s *MemoryStore) GetNodesByTenant(tenantID string) ([]*models.Node, error) {
-	return nil, fmt.Errorf("multi-tenancy not supported in memory store")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nodes := make([]*models.Node, 0)
+	for _, node := range s.nodes {
+		if node.TenantID == tenantID {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
 }
 
-// DeleteJob permanently deletes a job from the store
-func (s *MemoryStore) DeleteJob(id string) error {
+// CreateTenantAPIKey stores a new tenant API key
+func (s *MemoryStore) CreateTenantAPIKey(key *models.TenantAPIKey) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.jobs, id)
+	if _, ok := s.tenants[key.TenantID]; !ok {
+		return ErrTenantNotFound
+	}
+	if _, exists := s.apiKeys[key.KeyPrefix]; exists {
+		return fmt.Errorf("API key prefix %s already exists", key.KeyPrefix)
+	}
+
+	k := *key
+	if k.Kind == "" {
+		k.Kind = models.TenantAPIKeyKindAPI
+	}
+	s.apiKeys[key.KeyPrefix] = &k
 	return nil
 }
 
-// GetJobs retrieves all jobs with a specific status
-func (s *MemoryStore) GetJobs(status string) ([]models.Job, error) {
+// GetTenantAPIKey retrieves a tenant API key by ID
+func (s *MemoryStore) GetTenantAPIKey(id string) (*models.TenantAPIKey, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var jobs []models.Job
-	for _, job := range s.jobs {
-		if string(job.Status) == status {
-			jobs = append(jobs, *job)
+	for _, key := range s.apiKeys {
+		if key.ID == id {
+			k := *key
+			return &k, nil
 		}
 	}
-	return jobs, nil
+	return nil, ErrTenantAPIKeyNotFound
 }
 
-// Vacuum is a no-op for memory store
-func (s *MemoryStore) Vacuum() error {
+// GetTenantAPIKeyByPrefix retrieves a tenant API key by its lookup prefix
+func (s *MemoryStore) GetTenantAPIKeyByPrefix(prefix string) (*models.TenantAPIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.apiKeys[prefix]
+	if !ok {
+		return nil, ErrTenantAPIKeyNotFound
+	}
+	k := *key
+	return &k, nil
+}
+
+// ListTenantAPIKeys returns the API keys of a tenant, newest first
+func (s *MemoryStore) ListTenantAPIKeys(tenantID string) ([]*models.TenantAPIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*models.TenantAPIKey, 0)
+	for _, key := range s.apiKeys {
+		if key.TenantID == tenantID {
+			k := *key
+			keys = append(keys, &k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// UpdateTenantAPIKey updates the name, scopes, status and expiry of a tenant API key
+func (s *MemoryStore) UpdateTenantAPIKey(key *models.TenantAPIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.apiKeys {
+		if existing.ID == key.ID {
+			existing.Name = key.Name
+			existing.Scopes = append([]string(nil), key.Scopes...)
+			existing.Status = key.Status
+			existing.ExpiresAt = key.ExpiresAt
+			return nil
+		}
+	}
+	return ErrTenantAPIKeyNotFound
+}
+
+// TouchTenantAPIKey records when a tenant API key was last used
+func (s *MemoryStore) TouchTenantAPIKey(id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.ID == id {
+			key.LastUsedAt = &usedAt
+			return nil
+		}
+	}
+	return ErrTenantAPIKeyNotFound
+}
+
+// CreateUser adds a new user
+func (s *MemoryStore) CreateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tenants[user.TenantID]; !ok {
+		return ErrTenantNotFound
+	}
+	for _, existing := range s.users {
+		if existing.ID == user.ID || existing.Email == user.Email {
+			return ErrUserExists
+		}
+	}
+
+	u := *user
+	s.users[user.ID] = &u
 	return nil
 }
 
-// GetJobMetrics returns aggregated job statistics optimized for metrics endpoint
-func (s *MemoryStore) GetJobMetrics() (*JobMetrics, error) {
+// GetUser retrieves a user by ID
+func (s *MemoryStore) GetUser(id string) (*models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	metrics := &JobMetrics{
-		JobsByState:       make(map[models.JobStatus]int),
-		JobsByEngine:      make(map[string]int),
-		CompletedByEngine: make(map[string]int),
-		QueueByPriority:   make(map[string]int),
-		QueueByType:       make(map[string]int),
+	user, ok := s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
 	}
+	u := *user
+	return &u, nil
+}
 
-	var totalDuration float64
-	var durationCount int
+// GetUserByEmail retrieves a user by their unique email address
+func (s *MemoryStore) GetUserByEmail(email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	for _, job := range s.jobs {
-		// Count by state
-		metrics.JobsByState[job.Status]++
-		metrics.TotalJobs++
+	for _, user := range s.users {
+		if user.Email == email {
+			u := *user
+			return &u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
 
-		// Count by engine
-		if job.Engine != "" {
-			metrics.JobsByEngine[job.Engine]++
+// ListUsers returns the users of a tenant (or all users if tenantID is empty), oldest first
+func (s *MemoryStore) ListUsers(tenantID string) ([]*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]*models.User, 0)
+	for _, user := range s.users {
+		if tenantID == "" || user.TenantID == tenantID {
+			u := *user
+			users = append(users, &u)
 		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+	return users, nil
+}
 
-		// Count active jobs
-		if job.Status == models.JobStatusProcessing || job.Status == models.JobStatusAssigned {
-			metrics.ActiveJobs++
+// UpdateUser replaces an existing user
+func (s *MemoryStore) UpdateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.ID]; !ok {
+		return ErrUserNotFound
+	}
+	for _, existing := range s.users {
+		if existing.ID != user.ID && existing.Email == user.Email {
+			return ErrUserExists
 		}
+	}
 
-		// Count queued jobs
-		if job.Status == models.JobStatusQueued || job.Status == models.JobStatusPending {
-			metrics.QueueLength++
-			
-			// Count by priority and type for queued jobs
-			metrics.QueueByPriority[job.Priority]++
-			metrics.QueueByType[job.Queue]++
+	u := *user
+	s.users[user.ID] = &u
+	return nil
+}
+
+// DeleteUser removes a user and all of their sessions
+func (s *MemoryStore) DeleteUser(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(s.users, id)
+	for hash, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, hash)
 		}
+	}
+	return nil
+}
 
-		// Count completed by engine and calculate durations
-		if job.Status == models.JobStatusCompleted || job.Status == models.JobStatusFailed {
-			if job.CompletedAt != nil && !job.CreatedAt.IsZero() {
-				duration := job.CompletedAt.Sub(job.CreatedAt).Seconds()
-				totalDuration += duration
-				durationCount++
-			}
+// CreateSession stores a new session
+func (s *MemoryStore) CreateSession(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-			// Track completed jobs by actual engine used
-			if job.Status == models.JobStatusCompleted && job.Engine != "" {
-				engine := job.Engine
-				if engine == "auto" {
-					engine = "ffmpeg" // Auto defaults to ffmpeg
-				}
-				metrics.CompletedByEngine[engine]++
-			}
+	if _, ok := s.users[session.UserID]; !ok {
+		return ErrUserNotFound
+	}
+
+	sess := *session
+	sess.Token = "" // Only the hash is kept
+	s.sessions[session.TokenHash] = &sess
+	return nil
+}
+
+// GetSessionByTokenHash retrieves a session by the hash of its token
+func (s *MemoryStore) GetSessionByTokenHash(tokenHash string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[tokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	sess := *session
+	return &sess, nil
+}
+
+// DeleteSession removes a session
+func (s *MemoryStore) DeleteSession(tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[tokenHash]; !ok {
+		return ErrSessionNotFound
+	}
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+// DeleteUserSessions removes all sessions of a user
+func (s *MemoryStore) DeleteUserSessions(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, hash)
 		}
 	}
+	return nil
+}
 
-	// Calculate average duration
-	if durationCount > 0 {
-		metrics.AvgDuration = totalDuration / float64(durationCount)
+// DeleteExpiredSessions removes expired sessions and returns how many were removed
+func (s *MemoryStore) DeleteExpiredSessions() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for hash, session := range s.sessions {
+		if session.IsExpired() {
+			delete(s.sessions, hash)
+			removed++
+		}
 	}
+	return removed, nil
+}
 
-	return metrics, nil
+// SaveNodeToken stores the identity token of a node, replacing any previous token
+func (s *MemoryStore) SaveNodeToken(token *models.NodeToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[token.NodeID]; !ok {
+		return ErrNodeNotFound
+	}
+
+	t := *token
+	s.nodeTokens[token.NodeID] = &t
+	return nil
+}
+
+// GetNodeToken retrieves the identity token of a node
+func (s *MemoryStore) GetNodeToken(nodeID string) (*models.NodeToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.nodeTokens[nodeID]
+	if !ok {
+		return nil, ErrNodeTokenNotFound
+	}
+	t := *token
+	return &t, nil
+}
+
+// DeleteNodeToken removes the identity token of a node
+func (s *MemoryStore) DeleteNodeToken(nodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodeTokens[nodeID]; !ok {
+		return ErrNodeTokenNotFound
+	}
+	delete(s.nodeTokens, nodeID)
+	return nil
+}
+
+// CreateBootstrapToken stores a new bootstrap token
+func (s *MemoryStore) CreateBootstrapToken(token *models.BootstrapToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := *token
+	s.bootstrap[token.TokenHash] = &t
+	return nil
+}
+
+// ListBootstrapTokens returns all bootstrap tokens, newest first
+func (s *MemoryStore) ListBootstrapTokens() ([]*models.BootstrapToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]*models.BootstrapToken, 0, len(s.bootstrap))
+	for _, token := range s.bootstrap {
+		t := *token
+		tokens = append(tokens, &t)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+// UseBootstrapToken marks an unused, unexpired token as used by the named worker and returns it.
+// It returns ErrBootstrapTokenNotFound if no such token exists, so each token is only used once.
+func (s *MemoryStore) UseBootstrapToken(tokenHash, usedBy string, usedAt time.Time) (*models.BootstrapToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.bootstrap[tokenHash]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(usedAt) {
+		return nil, ErrBootstrapTokenNotFound
+	}
+	token.UsedAt = &usedAt
+	token.UsedBy = usedBy
+
+	t := *token
+	return &t, nil
+}
+
+// SaveCertificate records an issued certificate
+func (s *MemoryStore) SaveCertificate(cert *models.IssuedCertificate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *cert
+	s.certs[cert.Serial] = &c
+	return nil
+}
+
+// GetCertificate retrieves an issued certificate by serial number
+func (s *MemoryStore) GetCertificate(serial string) (*models.IssuedCertificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cert, ok := s.certs[serial]
+	if !ok {
+		return nil, ErrCertificateNotFound
+	}
+	c := *cert
+	return &c, nil
+}
+
+// ListCertificates returns issued certificates, newest first, optionally only revoked ones
+func (s *MemoryStore) ListCertificates(revokedOnly bool) ([]*models.IssuedCertificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	certs := make([]*models.IssuedCertificate, 0, len(s.certs))
+	for _, cert := range s.certs {
+		if revokedOnly && cert.RevokedAt == nil {
+			continue
+		}
+		c := *cert
+		certs = append(certs, &c)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		return certs[i].NotBefore.After(certs[j].NotBefore)
+	})
+	return certs, nil
+}
+
+// RevokeCertificate marks a certificate as revoked. Revoking it again keeps the original revocation time.
+func (s *MemoryStore) RevokeCertificate(serial string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cert, ok := s.certs[serial]
+	if !ok {
+		return ErrCertificateNotFound
+	}
+	if cert.RevokedAt == nil {
+		cert.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+// Secret operations
+
+// secretKey is the key of a secret in the secrets map
+func secretKey(tenantID, name string) string {
+	return tenantID + "/" + name
+}
+
+// CreateSecret stores a new secret. It returns ErrSecretExists if the tenant
+// already has a secret with the same name.
+func (s *MemoryStore) CreateSecret(secret *models.Secret) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := secretKey(secret.TenantID, secret.Name)
+	if _, exists := s.secrets[key]; exists {
+		return ErrSecretExists
+	}
+	stored := *secret
+	s.secrets[key] = &stored
+	return nil
+}
+
+// GetSecret retrieves a tenant's secret by name
+func (s *MemoryStore) GetSecret(tenantID, name string) (*models.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secret, ok := s.secrets[secretKey(tenantID, name)]
+	if !ok {
+		return nil, ErrSecretNotFound
+	}
+	copied := *secret
+	return &copied, nil
+}
+
+// ListSecrets returns a tenant's secrets ordered by name
+func (s *MemoryStore) ListSecrets(tenantID string) ([]*models.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secrets := make([]*models.Secret, 0)
+	for _, secret := range s.secrets {
+		if secret.TenantID == tenantID {
+			copied := *secret
+			secrets = append(secrets, &copied)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
+
+// UpdateSecret replaces the value and description of a secret
+func (s *MemoryStore) UpdateSecret(secret *models.Secret) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.secrets[secretKey(secret.TenantID, secret.Name)]
+	if !ok {
+		return ErrSecretNotFound
+	}
+	stored.Description = secret.Description
+	stored.Ciphertext = secret.Ciphertext
+	stored.UpdatedAt = secret.UpdatedAt
+	return nil
+}
+
+// DeleteSecret deletes a tenant's secret
+func (s *MemoryStore) DeleteSecret(tenantID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := secretKey(tenantID, name)
+	if _, ok := s.secrets[key]; !ok {
+		return ErrSecretNotFound
+	}
+	delete(s.secrets, key)
+	return nil
+}
+
+// Rate limit operations
+
+// TakeRateLimitToken takes a token from the bucket for key, refilled at rate tokens per second up to burst
+func (s *MemoryStore) TakeRateLimitToken(key string, rate float64, burst int, now time.Time) (models.RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = models.NewRateLimitBucket(key, burst, now)
+	}
+	decision := bucket.Take(rate, burst, now)
+	if decision.Allowed {
+		s.buckets[key] = bucket
+	}
+	return decision, nil
+}
+
+// DeleteFullRateLimitBuckets deletes buckets that were full before the given time
+func (s *MemoryStore) DeleteFullRateLimitBuckets(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, bucket := range s.buckets {
+		if bucket.FullAt.Before(before) {
+			delete(s.buckets, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// DeleteJob permanently deletes a job from the store
+func (s *MemoryStore) DeleteJob(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	delete(s.results, id)
+	return nil
+}
+
+// GetJobs retrieves all jobs with a specific status
+func (s *MemoryStore) GetJobs(status string) ([]models.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var jobs []models.Job
+	for _, job := range s.jobs {
+		if string(job.Status) == status {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+// Vacuum is a no-op for memory store
+func (s *MemoryStore) Vacuum() error {
+	return nil
+}
+
+// GetJobMetrics returns aggregated job statistics optimized for metrics endpoint
+func (s *MemoryStore) GetJobMetrics() (*JobMetrics, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metrics := &JobMetrics{
+		JobsByState:       make(map[models.JobStatus]int),
+		JobsByEngine:      make(map[string]int),
+		CompletedByEngine: make(map[string]int),
+		QueueByPriority:   make(map[string]int),
+		QueueByType:       make(map[string]int),
+	}
+
+	var totalDuration float64
+	var durationCount int
+
+	for _, job := range s.jobs {
+		// Count by state
+		metrics.JobsByState[job.Status]++
+		metrics.TotalJobs++
+
+		// Count by engine
+		if job.Engine != "" {
+			metrics.JobsByEngine[job.Engine]++
+		}
+
+		// Count active jobs
+		if job.Status == models.JobStatusProcessing || job.Status == models.JobStatusAssigned {
+			metrics.ActiveJobs++
+		}
+
+		// Count queued jobs
+		if job.Status == models.JobStatusQueued || job.Status == models.JobStatusPending {
+			metrics.QueueLength++
+			
+			// Count by priority and type for queued jobs
+			metrics.QueueByPriority[job.Priority]++
+			metrics.QueueByType[job.Queue]++
+		}
+
+		// Count completed by engine and calculate durations
+		if job.Status == models.JobStatusCompleted || job.Status == models.JobStatusFailed {
+			if job.CompletedAt != nil && !job.CreatedAt.IsZero() {
+				duration := job.CompletedAt.Sub(job.CreatedAt).Seconds()
+				totalDuration += duration
+				durationCount++
+			}
+
+			// Track completed jobs by actual engine used
+			if job.Status == models.JobStatusCompleted && job.Engine != "" {
+				engine := job.Engine
+				if engine == "auto" {
+					engine = "ffmpeg" // Auto defaults to ffmpeg
+				}
+				metrics.CompletedByEngine[engine]++
+			}
+		}
+	}
+
+	// Calculate average duration
+	if durationCount > 0 {
+		metrics.AvgDuration = totalDuration / float64(durationCount)
+	}
+
+	return metrics, nil
+}
+
+// Webhook operations
+
+// CreateWebhook adds a new webhook subscription
+func (s *MemoryStore) CreateWebhook(hook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *hook
+	s.webhooks[hook.ID] = &copied
+	return nil
+}
+
+// GetWebhook retrieves a webhook subscription by ID
+func (s *MemoryStore) GetWebhook(id string) (*models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hook, ok := s.webhooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	copied := *hook
+	return &copied, nil
+}
+
+// ListWebhooks returns all webhook subscriptions ordered by creation time
+func (s *MemoryStore) ListWebhooks() ([]*models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hooks := make([]*models.Webhook, 0, len(s.webhooks))
+	for _, hook := range s.webhooks {
+		copied := *hook
+		hooks = append(hooks, &copied)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+	})
+	return hooks, nil
+}
+
+// UpdateWebhook replaces an existing webhook subscription
+func (s *MemoryStore) UpdateWebhook(hook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[hook.ID]; !ok {
+		return ErrWebhookNotFound
+	}
+	copied := *hook
+	s.webhooks[hook.ID] = &copied
+	return nil
+}
+
+// DeleteWebhook removes a webhook subscription and its delivery history
+func (s *MemoryStore) DeleteWebhook(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	for deliveryID, delivery := range s.deliveries {
+		if delivery.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// SaveWebhookDelivery creates or updates a webhook delivery record
+func (s *MemoryStore) SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *delivery
+	s.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+// GetWebhookDelivery retrieves a webhook delivery record by ID
+func (s *MemoryStore) GetWebhookDelivery(id string) (*models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+// DeleteWebhookDeliveries deletes finished deliveries created before the given time
+func (s *MemoryStore) DeleteWebhookDeliveries(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, delivery := range s.deliveries {
+		if delivery.Status != models.WebhookDeliveryPending && delivery.CreatedAt.Before(before) {
+			delete(s.deliveries, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// ListWebhookDeliveries returns the most recent deliveries for a webhook (limit <= 0 returns all)
+func (s *MemoryStore) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// Audit log operations
+
+// CreateAuditEvent appends an event to the audit log
+func (s *MemoryStore) CreateAuditEvent(event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := *event
+	s.audit = append(s.audit, &e)
+	return nil
+}
+
+// ListAuditEvents returns the events selected by filter, newest first
+func (s *MemoryStore) ListAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]*models.AuditEvent, 0)
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		if filter.Matches(s.audit[i]) {
+			e := *s.audit[i]
+			events = append(events, &e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// Job result operations
+
+// SaveJobResult stores the result of a job, replacing any previous result
+func (s *MemoryStore) SaveJobResult(result *models.JobResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[result.JobID]; !exists {
+		return ErrJobNotFound
+	}
+	r := *result
+	r.Logs = "" // Logs are kept on the job
+	if r.CompletedAt.IsZero() {
+		r.CompletedAt = time.Now()
+	}
+	s.results[result.JobID] = &r
+	return nil
+}
+
+// GetJobResult retrieves the result of a job
+func (s *MemoryStore) GetJobResult(jobID string) (*models.JobResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result, exists := s.results[jobID]
+	if !exists {
+		return nil, ErrJobResultNotFound
+	}
+	r := *result
+	return &r, nil
+}
+
+// GetResultAggregates summarizes completed job results by scenario, engine or node
+func (s *MemoryStore) GetResultAggregates(groupBy string, since time.Time) ([]*models.ResultAggregate, error) {
+	if !models.IsValidResultGroup(groupBy) {
+		return nil, fmt.Errorf("invalid result grouping %q", groupBy)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type sums struct {
+		agg                                                          *models.ResultAggregate
+		fps, bitrate, duration, vmaf, qoe, efficiency, energy        float64
+		nFPS, nBitrate, nDuration, nVMAF, nQoE, nEfficiency, nEnergy int
+	}
+	groups := make(map[string]*sums)
+
+	for _, result := range s.results {
+		if result.Status != models.JobStatusCompleted || result.CompletedAt.Before(since) {
+			continue
+		}
+		job := s.jobs[result.JobID]
+		metrics := result.ExtractMetrics()
+
+		var key string
+		switch groupBy {
+		case models.ResultGroupScenario:
+			if job != nil {
+				key = job.Scenario
+			}
+		case models.ResultGroupEngine:
+			key = metrics.Engine
+			if key == "" && job != nil {
+				key = job.Engine
+			}
+		case models.ResultGroupNode:
+			key = result.NodeID
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &sums{agg: &models.ResultAggregate{GroupBy: groupBy, Key: key}}
+			groups[key] = g
+		}
+		g.agg.Count++
+		g.agg.TotalFrames += metrics.Frames
+		g.agg.TotalDroppedFrames += metrics.DroppedFrames
+		g.agg.TotalEnergyJoules += result.EnergyJoules
+		if result.CompletedAt.After(g.agg.LastCompletedAt) {
+			g.agg.LastCompletedAt = result.CompletedAt
+		}
+
+		addMetric := func(v float64, sum *float64, n *int) {
+			if v > 0 {
+				*sum += v
+				*n++
+			}
+		}
+		addMetric(metrics.FPS, &g.fps, &g.nFPS)
+		addMetric(metrics.BitrateKbps, &g.bitrate, &g.nBitrate)
+		addMetric(metrics.DurationSeconds, &g.duration, &g.nDuration)
+		addMetric(result.VMAFScore, &g.vmaf, &g.nVMAF)
+		addMetric(result.QoEScore, &g.qoe, &g.nQoE)
+		addMetric(result.EfficiencyScore, &g.efficiency, &g.nEfficiency)
+		addMetric(result.EnergyJoules, &g.energy, &g.nEnergy)
+	}
+
+	avg := func(sum float64, n int) float64 {
+		if n == 0 {
+			return 0
+		}
+		return sum / float64(n)
+	}
+
+	aggregates := make([]*models.ResultAggregate, 0, len(groups))
+	for _, g := range groups {
+		g.agg.AvgFPS = avg(g.fps, g.nFPS)
+		g.agg.AvgBitrateKbps = avg(g.bitrate, g.nBitrate)
+		g.agg.AvgDurationSeconds = avg(g.duration, g.nDuration)
+		g.agg.AvgVMAFScore = avg(g.vmaf, g.nVMAF)
+		g.agg.AvgQoEScore = avg(g.qoe, g.nQoE)
+		g.agg.AvgEfficiencyScore = avg(g.efficiency, g.nEfficiency)
+		g.agg.AvgEnergyJoules = avg(g.energy, g.nEnergy)
+		aggregates = append(aggregates, g.agg)
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		return aggregates[i].Key < aggregates[j].Key
+	})
+	return aggregates, nil
+}
+
+// Backup operations
+
+// memorySnapshot is the JSON backup format of the in-memory store
+type memorySnapshot struct {
+	Format     string                      `json:"format"`
+	Version    int                         `json:"version"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	NextSeqNum int                         `json:"next_sequence_number"`
+	Nodes      []*models.Node              `json:"nodes"`
+	NodeTokens []*models.NodeToken         `json:"node_tokens,omitempty"`
+	Certs      []*models.IssuedCertificate `json:"issued_certificates,omitempty"`
+	Secrets    []*secretRecord             `json:"secrets,omitempty"`
+	Jobs       []*models.Job               `json:"jobs"`
+	JobQueue   []string                    `json:"job_queue"`
+	Tenants    []*models.Tenant            `json:"tenants,omitempty"`
+	APIKeys    []*apiKeyRecord             `json:"tenant_api_keys,omitempty"`
+	Users      []*userRecord               `json:"users,omitempty"`
+	Results    []*models.JobResult         `json:"job_results,omitempty"`
+	Webhooks   []*models.Webhook           `json:"webhooks"`
+	Deliveries []*models.WebhookDelivery   `json:"webhook_deliveries"`
+	Audit      []*models.AuditEvent        `json:"audit_events,omitempty"`
+}
+
+// userRecord is a user in a JSON snapshot. The password hash is hidden from
+// API responses, so it is carried alongside the user.
+type userRecord struct {
+	*models.User
+	PasswordHash string `json:"password_hash"`
+}
+
+// apiKeyRecord is a tenant API key in a JSON snapshot. The key hash is
+// hidden from API responses, so it is carried alongside the key.
+type apiKeyRecord struct {
+	*models.TenantAPIKey
+	KeyHash string `json:"key_hash"`
+}
+
+// secretRecord is a secret in a JSON snapshot. The (encrypted) value is
+// hidden from API responses, so it is carried alongside the secret.
+type secretRecord struct {
+	*models.Secret
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// memorySnapshotVersion is the current JSON backup version
+const memorySnapshotVersion = 1
+
+// BackupFormat returns the format produced by Backup
+func (s *MemoryStore) BackupFormat() string {
+	return BackupFormatJSON
+}
+
+// Backup writes a JSON snapshot of all store contents
+func (s *MemoryStore) Backup(w io.Writer) error {
+	s.mu.RLock()
+	snapshot := memorySnapshot{
+		Format:     BackupFormatJSON,
+		Version:    memorySnapshotVersion,
+		CreatedAt:  time.Now(),
+		NextSeqNum: s.nextSeqNum,
+		Nodes:      make([]*models.Node, 0, len(s.nodes)),
+		NodeTokens: make([]*models.NodeToken, 0, len(s.nodeTokens)),
+		Certs:      make([]*models.IssuedCertificate, 0, len(s.certs)),
+		Secrets:    make([]*secretRecord, 0, len(s.secrets)),
+		Jobs:       make([]*models.Job, 0, len(s.jobs)),
+		JobQueue:   append([]string(nil), s.jobQueue...),
+		Tenants:    make([]*models.Tenant, 0, len(s.tenants)),
+		APIKeys:    make([]*apiKeyRecord, 0, len(s.apiKeys)),
+		Users:      make([]*userRecord, 0, len(s.users)),
+		Results:    make([]*models.JobResult, 0, len(s.results)),
+		Webhooks:   make([]*models.Webhook, 0, len(s.webhooks)),
+		Deliveries: make([]*models.WebhookDelivery, 0, len(s.deliveries)),
+		Audit:      append([]*models.AuditEvent(nil), s.audit...),
+	}
+	for _, node := range s.nodes {
+		snapshot.Nodes = append(snapshot.Nodes, node)
+	}
+	for _, token := range s.nodeTokens {
+		snapshot.NodeTokens = append(snapshot.NodeTokens, token)
+	}
+	for _, cert := range s.certs {
+		snapshot.Certs = append(snapshot.Certs, cert)
+	}
+	for _, secret := range s.secrets {
+		snapshot.Secrets = append(snapshot.Secrets, &secretRecord{Secret: secret, Ciphertext: secret.Ciphertext})
+	}
+	for _, job := range s.jobs {
+		snapshot.Jobs = append(snapshot.Jobs, job)
+	}
+	for _, tenant := range s.tenants {
+		snapshot.Tenants = append(snapshot.Tenants, tenant)
+	}
+	for _, key := range s.apiKeys {
+		snapshot.APIKeys = append(snapshot.APIKeys, &apiKeyRecord{TenantAPIKey: key, KeyHash: key.KeyHash})
+	}
+	for _, user := range s.users {
+		snapshot.Users = append(snapshot.Users, &userRecord{User: user, PasswordHash: user.PasswordHash})
+	}
+	for _, result := range s.results {
+		snapshot.Results = append(snapshot.Results, result)
+	}
+	for _, hook := range s.webhooks {
+		snapshot.Webhooks = append(snapshot.Webhooks, hook)
+	}
+	for _, delivery := range s.deliveries {
+		snapshot.Deliveries = append(snapshot.Deliveries, delivery)
+	}
+
+	// Encode while holding the lock so the snapshot is consistent
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// ValidateBackup checks that r contains a well-formed JSON snapshot
+func (s *MemoryStore) ValidateBackup(r io.Reader) error {
+	_, err := readMemorySnapshot(r)
+	return err
+}
+
+// Restore replaces all store contents with a JSON snapshot
+func (s *MemoryStore) Restore(r io.Reader) error {
+	snapshot, err := readMemorySnapshot(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nodes = make(map[string]*models.Node, len(snapshot.Nodes))
+	for _, node := range snapshot.Nodes {
+		s.nodes[node.ID] = node
+	}
+	s.nodeTokens = make(map[string]*models.NodeToken, len(snapshot.NodeTokens))
+	for _, token := range snapshot.NodeTokens {
+		s.nodeTokens[token.NodeID] = token
+	}
+	s.certs = make(map[string]*models.IssuedCertificate, len(snapshot.Certs))
+	for _, cert := range snapshot.Certs {
+		s.certs[cert.Serial] = cert
+	}
+	s.secrets = make(map[string]*models.Secret, len(snapshot.Secrets))
+	for _, record := range snapshot.Secrets {
+		if record.Secret == nil {
+			continue
+		}
+		record.Secret.Ciphertext = record.Ciphertext
+		s.secrets[secretKey(record.TenantID, record.Name)] = record.Secret
+	}
+	s.jobs = make(map[string]*models.Job, len(snapshot.Jobs))
+	for _, job := range snapshot.Jobs {
+		s.jobs[job.ID] = job
+	}
+	// Snapshots taken before tenancy was enabled only have the default tenant
+	s.tenants = defaultTenants()
+	for _, tenant := range snapshot.Tenants {
+		s.tenants[tenant.ID] = tenant
+	}
+	s.apiKeys = make(map[string]*models.TenantAPIKey, len(snapshot.APIKeys))
+	for _, record := range snapshot.APIKeys {
+		if record.TenantAPIKey == nil {
+			continue
+		}
+		record.TenantAPIKey.KeyHash = record.KeyHash
+		if record.TenantAPIKey.Kind == "" {
+			record.TenantAPIKey.Kind = models.TenantAPIKeyKindAPI
+		}
+		s.apiKeys[record.KeyPrefix] = record.TenantAPIKey
+	}
+	// Sessions are not part of snapshots, so users log in again after a restore
+	s.users = make(map[string]*models.User, len(snapshot.Users))
+	s.sessions = make(map[string]*models.Session)
+	for _, record := range snapshot.Users {
+		if record.User == nil {
+			continue
+		}
+		record.User.PasswordHash = record.PasswordHash
+		s.users[record.User.ID] = record.User
+	}
+	s.results = make(map[string]*models.JobResult, len(snapshot.Results))
+	for _, result := range snapshot.Results {
+		s.results[result.JobID] = result
+	}
+	s.webhooks = make(map[string]*models.Webhook, len(snapshot.Webhooks))
+	for _, hook := range snapshot.Webhooks {
+		s.webhooks[hook.ID] = hook
+	}
+	s.deliveries = make(map[string]*models.WebhookDelivery, len(snapshot.Deliveries))
+	for _, delivery := range snapshot.Deliveries {
+		s.deliveries[delivery.ID] = delivery
+	}
+	s.audit = snapshot.Audit
+	s.jobQueue = snapshot.JobQueue
+	if s.jobQueue == nil {
+		s.jobQueue = make([]string, 0)
+	}
+	s.nextSeqNum = snapshot.NextSeqNum
+	return nil
+}
+
+// readMemorySnapshot decodes and validates a JSON snapshot
+func readMemorySnapshot(r io.Reader) (*memorySnapshot, error) {
+	var snapshot memorySnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, invalidBackup("failed to decode JSON: %v", err)
+	}
+
+	if snapshot.Format != BackupFormatJSON {
+		return nil, invalidBackup("unexpected format %q", snapshot.Format)
+	}
+	if snapshot.Version < 1 || snapshot.Version > memorySnapshotVersion {
+		return nil, invalidBackup("unsupported version %d", snapshot.Version)
+	}
+
+	maxSeq := 0
+	jobIDs := make(map[string]bool, len(snapshot.Jobs))
+	for _, job := range snapshot.Jobs {
+		if job == nil || job.ID == "" {
+			return nil, invalidBackup("job without ID")
+		}
+		if jobIDs[job.ID] {
+			return nil, invalidBackup("duplicate job %s", job.ID)
+		}
+		jobIDs[job.ID] = true
+		if job.SequenceNumber > maxSeq {
+			maxSeq = job.SequenceNumber
+		}
+	}
+	for _, node := range snapshot.Nodes {
+		if node == nil || node.ID == "" {
+			return nil, invalidBackup("node without ID")
+		}
+	}
+	for _, result := range snapshot.Results {
+		if result == nil || !jobIDs[result.JobID] {
+			return nil, invalidBackup("job result without matching job")
+		}
+	}
+	for _, hook := range snapshot.Webhooks {
+		if hook == nil || hook.ID == "" {
+			return nil, invalidBackup("webhook without ID")
+		}
+	}
+	for _, delivery := range snapshot.Deliveries {
+		if delivery == nil || delivery.ID == "" {
+			return nil, invalidBackup("webhook delivery without ID")
+		}
+	}
+	for _, id := range snapshot.JobQueue {
+		if !jobIDs[id] {
+			return nil, invalidBackup("queued job %s not found", id)
+		}
+	}
+
+	// Never hand out a sequence number that is already in use
+	if snapshot.NextSeqNum <= maxSeq {
+		snapshot.NextSeqNum = maxSeq + 1
+	}
+	return &snapshot, nil
 }
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// withinDeadline fails the test if fn does not return in time, as a store
+// method that locks its own mutex twice never does
+func withinDeadline(t *testing.T, name string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not return (deadlock)", name)
+	}
+}
+
+// TestMemoryStore_NodeStatusFollowsJobs verifies that assigning, finishing and
+// canceling jobs update the node status without deadlocking
+func TestMemoryStore_NodeStatusFollowsJobs(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.RegisterNode(&models.Node{ID: "node-1", Status: "available"}); err != nil {
+		t.Fatalf("Failed to register node: %v", err)
+	}
+	for _, id := range []string{"job-1", "job-2"} {
+		if err := s.CreateJob(&models.Job{ID: id, Status: models.JobStatusPending, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+
+	nodeStatus := func() (string, string) {
+		node, err := s.GetNode("node-1")
+		if err != nil {
+			t.Fatalf("Failed to get node: %v", err)
+		}
+		return node.Status, node.CurrentJobID
+	}
+
+	var job *models.Job
+	withinDeadline(t, "GetNextJob", func() {
+		var err error
+		job, err = s.GetNextJob("node-1")
+		if err != nil {
+			t.Errorf("GetNextJob failed: %v", err)
+		}
+	})
+	if job == nil || job.ID != "job-1" || job.Status != models.JobStatusRunning || job.NodeID != "node-1" {
+		t.Fatalf("Unexpected assigned job: %+v", job)
+	}
+	if status, current := nodeStatus(); status != "busy" || current != "job-1" {
+		t.Errorf("Expected node busy with job-1, got %s/%s", status, current)
+	}
+
+	withinDeadline(t, "UpdateJobStatus", func() {
+		if err := s.UpdateJobStatus("job-1", models.JobStatusCompleted, ""); err != nil {
+			t.Errorf("UpdateJobStatus failed: %v", err)
+		}
+	})
+	if status, current := nodeStatus(); status != "available" || current != "" {
+		t.Errorf("Expected node available after completion, got %s/%s", status, current)
+	}
+
+	withinDeadline(t, "GetNextJob", func() {
+		if _, err := s.GetNextJob("node-1"); err != nil {
+			t.Errorf("GetNextJob failed: %v", err)
+		}
+	})
+	withinDeadline(t, "CancelJob", func() {
+		if err := s.CancelJob("job-2"); err != nil {
+			t.Errorf("CancelJob failed: %v", err)
+		}
+	})
+	if status, current := nodeStatus(); status != "available" || current != "" {
+		t.Errorf("Expected node available after cancel, got %s/%s", status, current)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testNodeTokens exercises node identity token storage
+func testNodeTokens(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := s.RegisterNode(&models.Node{ID: "node-1", Name: "worker-1", Address: "http://worker-1:9000",
+		Type: models.NodeTypeServer, Status: "available", LastHeartbeat: now, RegisteredAt: now}); err != nil {
+		t.Fatalf("Failed to register node: %v", err)
+	}
+
+	if err := s.SaveNodeToken(&models.NodeToken{NodeID: "missing", TokenHash: "hash", CreatedAt: now}); err != ErrNodeNotFound {
+		t.Errorf("Expected ErrNodeNotFound for unknown node, got %v", err)
+	}
+	if _, err := s.GetNodeToken("node-1"); err != ErrNodeTokenNotFound {
+		t.Errorf("Expected ErrNodeTokenNotFound before a token is saved, got %v", err)
+	}
+
+	if err := s.SaveNodeToken(&models.NodeToken{NodeID: "node-1", TokenHash: "hash-1", CreatedAt: now}); err != nil {
+		t.Fatalf("Failed to save node token: %v", err)
+	}
+	expiresAt := now.Add(time.Hour)
+	if err := s.SaveNodeToken(&models.NodeToken{NodeID: "node-1", TokenHash: "hash-2", CreatedAt: now, ExpiresAt: &expiresAt}); err != nil {
+		t.Fatalf("Failed to replace node token: %v", err)
+	}
+
+	token, err := s.GetNodeToken("node-1")
+	if err != nil {
+		t.Fatalf("Failed to get node token: %v", err)
+	}
+	if token.TokenHash != "hash-2" || token.ExpiresAt == nil || !token.ExpiresAt.Equal(expiresAt) || token.IsExpired() {
+		t.Errorf("Unexpected node token: %+v", token)
+	}
+
+	if err := s.DeleteNode("node-1"); err != nil {
+		t.Fatalf("Failed to delete node: %v", err)
+	}
+	if _, err := s.GetNodeToken("node-1"); err != ErrNodeTokenNotFound {
+		t.Errorf("Expected token of deleted node to be removed, got %v", err)
+	}
+	if err := s.DeleteNodeToken("node-1"); err != ErrNodeTokenNotFound {
+		t.Errorf("Expected ErrNodeTokenNotFound, got %v", err)
+	}
+}
+
+func TestMemoryNodeTokens(t *testing.T) {
+	testNodeTokens(t, NewMemoryStore())
+}
+
+func TestSQLiteNodeTokens(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "tokens.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testNodeTokens(t, s)
+}
+
+// TestSQLiteNodeTokensSurviveRestart verifies that workers keep their identity
+// when the master reopens its database
+func TestSQLiteNodeTokensSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restart.db")
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	now := time.Now()
+	if err := s.RegisterNode(&models.Node{ID: "node-1", Name: "worker-1", Address: "http://worker-1:9000",
+		Type: models.NodeTypeServer, Status: "available", LastHeartbeat: now, RegisteredAt: now}); err != nil {
+		t.Fatalf("Failed to register node: %v", err)
+	}
+	if err := s.SaveNodeToken(&models.NodeToken{NodeID: "node-1", TokenHash: "hash-1", CreatedAt: now}); err != nil {
+		t.Fatalf("Failed to save node token: %v", err)
+	}
+	s.Close()
+
+	s, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer s.Close()
+
+	token, err := s.GetNodeToken("node-1")
+	if err != nil {
+		t.Fatalf("Expected node token after reopening: %v", err)
+	}
+	if token.TokenHash != "hash-1" || token.ExpiresAt != nil {
+		t.Errorf("Unexpected node token: %+v", token)
+	}
+}
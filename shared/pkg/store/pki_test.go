@@ -0,0 +1,107 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testPKI exercises bootstrap token and issued certificate storage
+func testPKI(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, token := range []*models.BootstrapToken{
+		{ID: "tok-1", TokenHash: "hash-1", Description: "rack 1", CreatedBy: "admin", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "tok-2", TokenHash: "hash-2", CreatedBy: "admin", CreatedAt: now.Add(time.Second), ExpiresAt: now.Add(-time.Minute)},
+	} {
+		if err := s.CreateBootstrapToken(token); err != nil {
+			t.Fatalf("Failed to create bootstrap token: %v", err)
+		}
+	}
+
+	tokens, err := s.ListBootstrapTokens()
+	if err != nil {
+		t.Fatalf("Failed to list bootstrap tokens: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].ID != "tok-2" || tokens[1].Description != "rack 1" {
+		t.Errorf("Unexpected bootstrap tokens: %+v", tokens)
+	}
+
+	used, err := s.UseBootstrapToken("hash-1", "worker-1", now)
+	if err != nil {
+		t.Fatalf("Failed to use bootstrap token: %v", err)
+	}
+	if used.ID != "tok-1" || used.UsedBy != "worker-1" || used.UsedAt == nil {
+		t.Errorf("Unexpected used token: %+v", used)
+	}
+	if _, err := s.UseBootstrapToken("hash-1", "worker-2", now); err != ErrBootstrapTokenNotFound {
+		t.Errorf("Expected a bootstrap token to be usable only once, got %v", err)
+	}
+	if _, err := s.UseBootstrapToken("hash-2", "worker-2", now); err != ErrBootstrapTokenNotFound {
+		t.Errorf("Expected ErrBootstrapTokenNotFound for expired token, got %v", err)
+	}
+	if _, err := s.UseBootstrapToken("missing", "worker-2", now); err != ErrBootstrapTokenNotFound {
+		t.Errorf("Expected ErrBootstrapTokenNotFound for unknown token, got %v", err)
+	}
+
+	for _, cert := range []*models.IssuedCertificate{
+		{Serial: "0a", CommonName: "worker-1", NotBefore: now, NotAfter: now.Add(24 * time.Hour)},
+		{Serial: "0b", CommonName: "worker-1", NotBefore: now.Add(time.Hour), NotAfter: now.Add(25 * time.Hour)},
+	} {
+		if err := s.SaveCertificate(cert); err != nil {
+			t.Fatalf("Failed to save certificate: %v", err)
+		}
+	}
+
+	if _, err := s.GetCertificate("ff"); err != ErrCertificateNotFound {
+		t.Errorf("Expected ErrCertificateNotFound, got %v", err)
+	}
+	if err := s.RevokeCertificate("ff", now); err != ErrCertificateNotFound {
+		t.Errorf("Expected ErrCertificateNotFound revoking unknown certificate, got %v", err)
+	}
+
+	if err := s.RevokeCertificate("0a", now); err != nil {
+		t.Fatalf("Failed to revoke certificate: %v", err)
+	}
+	if err := s.RevokeCertificate("0a", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to revoke certificate again: %v", err)
+	}
+	cert, err := s.GetCertificate("0a")
+	if err != nil {
+		t.Fatalf("Failed to get certificate: %v", err)
+	}
+	if !cert.IsRevoked() || !cert.RevokedAt.Equal(now) || cert.CommonName != "worker-1" {
+		t.Errorf("Expected original revocation time to be kept: %+v", cert)
+	}
+
+	all, err := s.ListCertificates(false)
+	if err != nil {
+		t.Fatalf("Failed to list certificates: %v", err)
+	}
+	if len(all) != 2 || all[0].Serial != "0b" {
+		t.Errorf("Unexpected certificates: %+v", all)
+	}
+	revoked, err := s.ListCertificates(true)
+	if err != nil {
+		t.Fatalf("Failed to list revoked certificates: %v", err)
+	}
+	if len(revoked) != 1 || revoked[0].Serial != "0a" {
+		t.Errorf("Unexpected revoked certificates: %+v", revoked)
+	}
+}
+
+func TestMemoryPKI(t *testing.T) {
+	testPKI(t, NewMemoryStore())
+}
+
+func TestSQLitePKI(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "pki.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testPKI(t, s)
+}
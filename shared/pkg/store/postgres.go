@@ -149,28 +149,206 @@ func (s *PostgreSQLStore) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_jobs_sequence ON jobs(sequence_number);
 	CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
 	CREATE INDEX IF NOT EXISTS idx_jobs_queue_priority ON jobs(queue, priority, created_at);
+	CREATE INDEX IF NOT EXISTS idx_jobs_node_status ON jobs(node_id, status);
 	CREATE INDEX IF NOT EXISTS idx_jobs_tenant_id ON jobs(tenant_id);
 	CREATE INDEX IF NOT EXISTS idx_jobs_tenant_status ON jobs(tenant_id, status);
 
-	-- Create default tenant if none exists
-	INSERT INTO tenants (id, name, display_name, status, plan, quotas, usage, metadata, created_at, updated_at)
-	VALUES (
-		'default',
-		'default',
-		'Default Tenant',
-		'active',
-		'enterprise',
-		'{"max_jobs": 1000, "max_workers": 100, "max_jobs_per_hour": 10000, "max_cpu_cores": 256, "max_gpus": 16, "max_storage_gb": 10000, "max_bandwidth_mbps": 100000, "job_timeout_minutes": 1440}'::jsonb,
-		'{"active_jobs": 0, "total_jobs": 0, "completed_jobs": 0, "failed_jobs": 0, "active_workers": 0, "cpu_cores_used": 0, "gpus_used": 0, "storage_used_gb": 0, "jobs_this_hour": 0}'::jsonb,
-		'{}'::jsonb,
-		NOW(),
-		NOW()
-	)
-	ON CONFLICT (id) DO NOTHING;
+	-- Final job results with typed columns for common metrics
+	CREATE TABLE IF NOT EXISTS job_results (
+		job_id TEXT PRIMARY KEY REFERENCES jobs(id) ON DELETE CASCADE,
+		node_id TEXT,
+		status TEXT NOT NULL,
+		engine TEXT,
+		fps DOUBLE PRECISION,
+		bitrate_kbps DOUBLE PRECISION,
+		frames BIGINT,
+		dropped_frames BIGINT,
+		duration_seconds DOUBLE PRECISION,
+		output_bytes BIGINT,
+		vmaf_score DOUBLE PRECISION,
+		qoe_score DOUBLE PRECISION,
+		efficiency_score DOUBLE PRECISION,
+		energy_joules DOUBLE PRECISION,
+		progress INTEGER DEFAULT 0,
+		error TEXT,
+		metrics JSONB,
+		analyzer_output JSONB,
+		completed_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_job_results_completed ON job_results(status, completed_at);
+
+	-- Webhook subscriptions and delivery history
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT,
+		url TEXT NOT NULL,
+		events JSONB,
+		secret TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT true,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		job_id TEXT,
+		event TEXT NOT NULL,
+		payload TEXT,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_code INTEGER DEFAULT 0,
+		last_error TEXT,
+		created_at TIMESTAMP NOT NULL,
+		delivered_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
+
+	-- Leader election leases (fencing tokens for HA masters)
+	CREATE TABLE IF NOT EXISTS leader_leases (
+		name TEXT PRIMARY KEY,
+		holder_id TEXT NOT NULL,
+		token BIGINT NOT NULL DEFAULT 0,
+		acquired_at TIMESTAMP NOT NULL,
+		renewed_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+	-- Tenant API keys (bcrypt hashes, looked up by prefix)
+	CREATE TABLE IF NOT EXISTS tenant_api_keys (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		key_hash TEXT NOT NULL,
+		key_prefix TEXT NOT NULL UNIQUE,
+		scopes JSONB,
+		status TEXT NOT NULL DEFAULT 'active',
+		created_by TEXT,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP,
+		last_used_at TIMESTAMP,
+		kind TEXT NOT NULL DEFAULT 'api'
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_tenant ON tenant_api_keys(tenant_id);
+	-- Keys created before key kinds existed become API keys
+	ALTER TABLE tenant_api_keys ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'api';
+
+	-- User accounts (bcrypt password hashes)
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+		email TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		full_name TEXT,
+		role TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active',
+		last_login_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_users_tenant ON users(tenant_id);
+
+	-- Login sessions (SHA-256 token hashes)
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		tenant_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		ip_address TEXT,
+		user_agent TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+	-- Node identity tokens (SHA-256 token hashes)
+	CREATE TABLE IF NOT EXISTS node_tokens (
+		node_id TEXT PRIMARY KEY REFERENCES nodes(id) ON DELETE CASCADE,
+		token_hash TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP
+	);
+
+	-- One-time worker enrollment tokens (SHA-256 token hashes)
+	CREATE TABLE IF NOT EXISTS bootstrap_tokens (
+		id TEXT PRIMARY KEY,
+		token_hash TEXT NOT NULL UNIQUE,
+		description TEXT,
+		created_by TEXT,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		used_by TEXT
+	);
+
+	-- Client certificates issued by the worker CA
+	CREATE TABLE IF NOT EXISTS issued_certificates (
+		serial TEXT PRIMARY KEY,
+		common_name TEXT NOT NULL,
+		not_before TIMESTAMP NOT NULL,
+		not_after TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_issued_certificates_revoked ON issued_certificates(revoked_at);
+
+	-- Job parameter secrets, encrypted with the master's secrets key
+	CREATE TABLE IF NOT EXISTS secrets (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		description TEXT,
+		ciphertext BYTEA NOT NULL,
+		created_by TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		UNIQUE(tenant_id, name)
+	);
+
+	-- API rate limit token buckets shared by all masters; full buckets are deleted
+	CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+		key TEXT PRIMARY KEY,
+		tokens DOUBLE PRECISION NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		full_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);
+
+	-- Append-only audit log of mutating API requests
+	CREATE TABLE IF NOT EXISTS audit_events (
+		id TEXT PRIMARY KEY,
+		timestamp TIMESTAMP NOT NULL,
+		tenant_id TEXT,
+		actor_type TEXT NOT NULL,
+		actor_id TEXT,
+		action TEXT NOT NULL,
+		target_type TEXT,
+		target_id TEXT,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		source_ip TEXT NOT NULL,
+		forwarded_for TEXT,
+		status_code INTEGER NOT NULL,
+		outcome TEXT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_events_timestamp ON audit_events(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_id, timestamp);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return s.ensureDefaultTenant()
 }
 
 // Close closes the database connection
@@ -292,8 +470,8 @@ func (s *PostgreSQLStore) RegisterNode(node *models.Node) error {
 		INSERT INTO nodes 
 		(id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type, 
 		 gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat, 
-		 registered_at, current_job_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		 registered_at, current_job_id, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NULLIF($18, ''))
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			address = EXCLUDED.address,
@@ -309,10 +487,11 @@ func (s *PostgreSQLStore) RegisterNode(node *models.Node) error {
 			labels = EXCLUDED.labels,
 			status = EXCLUDED.status,
 			last_heartbeat = EXCLUDED.last_heartbeat,
-			current_job_id = EXCLUDED.current_job_id
+			current_job_id = EXCLUDED.current_job_id,
+			tenant_id = EXCLUDED.tenant_id
 	`, node.ID, node.Name, node.Address, node.Type, node.CPUThreads, node.CPUModel, node.CPULoadPercent,
 		node.HasGPU, node.GPUType, string(gpuCaps), node.RAMTotalBytes, node.RAMFreeBytes,
-		string(labels), node.Status, node.LastHeartbeat, node.RegisteredAt, node.CurrentJobID)
+		string(labels), node.Status, node.LastHeartbeat, node.RegisteredAt, node.CurrentJobID, node.TenantID)
 
 	return err
 }
@@ -325,12 +504,12 @@ func (s *PostgreSQLStore) GetNode(id string) (*models.Node, error) {
 	err := s.db.QueryRow(`
 		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
 		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
-		       registered_at, current_job_id
+		       registered_at, current_job_id, COALESCE(tenant_id, '')
 		FROM nodes WHERE id = $1
 	`, id).Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads, &node.CPUModel,
 		&node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON, &node.RAMTotalBytes,
 		&node.RAMFreeBytes, &labelsJSON, &node.Status, &node.LastHeartbeat,
-		&node.RegisteredAt, &node.CurrentJobID)
+		&node.RegisteredAt, &node.CurrentJobID, &node.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNodeNotFound
@@ -362,12 +541,12 @@ func (s *PostgreSQLStore) GetNodeByAddress(address string) (*models.Node, error)
 	err := s.db.QueryRow(`
 		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
 		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
-		       registered_at, current_job_id
+		       registered_at, current_job_id, COALESCE(tenant_id, '')
 		FROM nodes WHERE address = $1
 	`, address).Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads, &node.CPUModel,
 		&node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON, &node.RAMTotalBytes,
 		&node.RAMFreeBytes, &labelsJSON, &node.Status, &node.LastHeartbeat,
-		&node.RegisteredAt, &node.CurrentJobID)
+		&node.RegisteredAt, &node.CurrentJobID, &node.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNodeNotFound
@@ -396,7 +575,7 @@ func (s *PostgreSQLStore) GetAllNodes() []*models.Node {
 	rows, err := s.db.Query(`
 		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
 		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
-		       registered_at, current_job_id
+		       registered_at, current_job_id, COALESCE(tenant_id, '')
 		FROM nodes
 	`)
 	if err != nil {
@@ -412,7 +591,7 @@ func (s *PostgreSQLStore) GetAllNodes() []*models.Node {
 		if err := rows.Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads,
 			&node.CPUModel, &node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON,
 			&node.RAMTotalBytes, &node.RAMFreeBytes, &labelsJSON, &node.Status,
-			&node.LastHeartbeat, &node.RegisteredAt, &node.CurrentJobID); err != nil {
+			&node.LastHeartbeat, &node.RegisteredAt, &node.CurrentJobID, &node.TenantID); err != nil {
 			continue
 		}
 
@@ -515,6 +694,9 @@ func (s *PostgreSQLStore) CreateJob(job *models.Job) error {
 	if job.Engine == "" {
 		job.Engine = "auto"
 	}
+	if job.TenantID == "" {
+		job.TenantID = models.DefaultTenantID
+	}
 
 	// Generate sequence number if not set (protected by mutex for concurrency)
 	needsSequenceNumber := job.SequenceNumber == 0
@@ -537,78 +719,11 @@ func (s *PostgreSQLStore) CreateJob(job *models.Job) error {
 	_, err = s.db.Exec(`
 		INSERT INTO jobs 
 		(id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id, 
-		 created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		 created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 	`, job.ID, job.SequenceNumber, job.Scenario, job.Confidence, job.Engine, string(params), job.Status, job.Queue,
 		job.Priority, job.Progress, job.NodeID, job.CreatedAt, job.StartedAt, job.LastActivityAt,
-		job.CompletedAt, job.RetryCount, job.Error, string(job.FailureReason), job.Logs, string(transitions))
+		job.CompletedAt, job.RetryCount, job.Error, string(job.FailureReason), job.Logs, string(transitions), job.TenantID)
 
 	return err
 }
-
-// Implement remaining methods in next file...
-
-// Tenant management stubs (not yet implemented)
-func (s *PostgreSQLStore) CreateTenant(tenant *models.Tenant) error {
-return fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) GetTenant(id string) (*models.Tenant, error) {
-return nil, fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) UpdateTenant(tenant *models.Tenant) error {
-return fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) DeleteTenant(id string) error {
-return fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) ListTenants() ([]*models.Tenant, error) {
-return nil, fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) GetJobsByTenant(tenantID string) ([]*models.Job, error) {
-return s.GetAllJobs(), nil
-}
-
-func (s *PostgreSQLStore) GetNodesByTenant(tenantID string) ([]*models.Node, error) {
-return s.GetAllNodes(), nil
-}
-
-func (s *PostgreSQLStore) GetTenantByName(name string) (*models.Tenant, error) {
-return nil, fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) CreateUser(user *models.User) error {
-return fmt.Errorf("user management not yet implemented")
-}
-
-func (s *PostgreSQLStore) GetUser(id string) (*models.User, error) {
-return nil, fmt.Errorf("user management not yet implemented")
-}
-
-func (s *PostgreSQLStore) GetUserByEmail(email string) (*models.User, error) {
-return nil, fmt.Errorf("user management not yet implemented")
-}
-
-func (s *PostgreSQLStore) UpdateUser(user *models.User) error {
-return fmt.Errorf("user management not yet implemented")
-}
-
-func (s *PostgreSQLStore) DeleteUser(id string) error {
-return fmt.Errorf("user management not yet implemented")
-}
-
-func (s *PostgreSQLStore) ListUsersByTenant(tenantID string) ([]*models.User, error) {
-return nil, fmt.Errorf("user management not yet implemented")
-}
-
-func (s *PostgreSQLStore) UpdateTenantUsage(id string, usage *models.TenantUsage) error {
-return fmt.Errorf("tenant management not yet implemented")
-}
-
-func (s *PostgreSQLStore) GetTenantStats(id string) (*models.TenantUsage, error) {
-return nil, fmt.Errorf("tenant management not yet implemented")
-}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"strconv"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// CreateAuditEvent appends an event to the audit log
+func (s *PostgreSQLStore) CreateAuditEvent(event *models.AuditEvent) error {
+	_, err := s.db.Exec(`
+		INSERT INTO audit_events (`+auditEventColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, auditEventArgs(event)...)
+	return err
+}
+
+// ListAuditEvents returns the events selected by filter, newest first
+func (s *PostgreSQLStore) ListAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	query, args := auditEventQuery(filter, func(n int) string { return "$" + strconv.Itoa(n) })
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEvents(rows)
+}
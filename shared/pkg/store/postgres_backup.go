@@ -0,0 +1,369 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresBackupTables lists the tables included in a logical backup, in foreign key order.
+// Runtime-only tables such as leader_leases, sessions, bootstrap_tokens and rate_limit_buckets are intentionally excluded.
+var postgresBackupTables = []string{
+	"tenants",
+	"tenant_api_keys",
+	"users",
+	"nodes",
+	"node_tokens",
+	"jobs",
+	"job_results",
+	"webhooks",
+	"webhook_deliveries",
+	"issued_certificates",
+	"secrets",
+	"audit_events",
+}
+
+// copyBlock is one table's data in a logical backup
+type copyBlock struct {
+	table   string
+	columns []string
+	rows    [][]*string // nil entries are NULL
+}
+
+// BackupFormat returns the format produced by Backup
+func (s *PostgreSQLStore) BackupFormat() string {
+	return BackupFormatPostgres
+}
+
+// Backup writes a pg_dump-compatible logical export of all tables.
+// The export is taken from a single REPEATABLE READ snapshot and can be loaded with psql.
+func (s *PostgreSQLStore) Backup(w io.Writer) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s\n", pgdumpHeader)
+	fmt.Fprintf(bw, "-- Created: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(bw, "-- Restore with: ffrtmp db restore <file>  or  psql \"$DSN\" -v ON_ERROR_STOP=1 -f <file>\n\n")
+	fmt.Fprintf(bw, "SET client_encoding = 'UTF8';\n")
+	fmt.Fprintf(bw, "SET standard_conforming_strings = on;\n\n")
+	fmt.Fprintf(bw, "BEGIN;\n\n")
+	fmt.Fprintf(bw, "TRUNCATE TABLE %s CASCADE;\n\n", strings.Join(postgresBackupTables, ", "))
+
+	for _, table := range postgresBackupTables {
+		if err := s.dumpTable(ctx, tx, bw, table); err != nil {
+			return fmt.Errorf("failed to export %s: %w", table, err)
+		}
+	}
+
+	fmt.Fprintf(bw, "COMMIT;\n")
+	return bw.Flush()
+}
+
+// dumpTable writes a COPY ... FROM stdin block for one table
+func (s *PostgreSQLStore) dumpTable(ctx context.Context, tx *sql.Tx, w *bufio.Writer, table string) error {
+	columns, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+
+	// Cast every column to text so values use PostgreSQL's own COPY text representation
+	quoted := make([]string, len(columns))
+	selects := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = pq.QuoteIdentifier(col)
+		selects[i] = quoted[i] + "::text"
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), pq.QuoteIdentifier(table)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fmt.Fprintf(w, "COPY %s (%s) FROM stdin;\n", pq.QuoteIdentifier(table), strings.Join(quoted, ", "))
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			if v.Valid {
+				fields[i] = copyEscape(v.String)
+			} else {
+				fields[i] = `\N`
+			}
+		}
+		w.WriteString(strings.Join(fields, "\t"))
+		w.WriteString("\n")
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\\.\n\n")
+	fmt.Fprintf(w, "-- %s: %d rows\n\n", table, count)
+	return nil
+}
+
+// ValidateBackup parses a logical backup and checks it against the current schema
+func (s *PostgreSQLStore) ValidateBackup(r io.Reader) error {
+	blocks, err := parseCopyBackup(r)
+	if err != nil {
+		return err
+	}
+	return s.validateCopyBlocks(context.Background(), blocks)
+}
+
+// Restore replaces all backed-up tables with the contents of a logical backup in one transaction
+func (s *PostgreSQLStore) Restore(r io.Reader) error {
+	ctx := context.Background()
+
+	blocks, err := parseCopyBackup(r)
+	if err != nil {
+		return err
+	}
+	if err := s.validateCopyBlocks(ctx, blocks); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s CASCADE", strings.Join(postgresBackupTables, ", "))); err != nil {
+		return fmt.Errorf("failed to truncate tables: %w", err)
+	}
+
+	for _, block := range blocks {
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(block.table, block.columns...))
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", block.table, err)
+		}
+		for _, row := range block.rows {
+			args := make([]interface{}, len(row))
+			for i, v := range row {
+				if v != nil {
+					args[i] = *v
+				}
+			}
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				stmt.Close()
+				return fmt.Errorf("failed to restore %s: %w", block.table, err)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to restore %s: %w", block.table, err)
+		}
+		stmt.Close()
+	}
+
+	return tx.Commit()
+}
+
+// validateCopyBlocks checks that every table and column in the backup exists in the current schema
+func (s *PostgreSQLStore) validateCopyBlocks(ctx context.Context, blocks []copyBlock) error {
+	known := make(map[string]bool, len(postgresBackupTables))
+	for _, table := range postgresBackupTables {
+		known[table] = true
+	}
+
+	for _, block := range blocks {
+		if !known[block.table] {
+			return invalidBackup("unexpected table %s", block.table)
+		}
+
+		columns, err := tableColumns(ctx, s.db, block.table)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]bool, len(columns))
+		for _, col := range columns {
+			existing[col] = true
+		}
+		for _, col := range block.columns {
+			if !existing[col] {
+				return invalidBackup("table %s has no column %s", block.table, col)
+			}
+		}
+	}
+	return nil
+}
+
+// tableColumns returns the column names of a table in ordinal order
+func tableColumns(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}, table string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	return columns, rows.Err()
+}
+
+// parseCopyBackup reads the COPY blocks from a logical backup
+func parseCopyBackup(r io.Reader) ([]copyBlock, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 256*1024*1024)
+
+	if !scanner.Scan() || scanner.Text() != pgdumpHeader {
+		return nil, invalidBackup("missing PostgreSQL backup header")
+	}
+
+	var blocks []copyBlock
+	var current *copyBlock
+	committed := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if current != nil {
+			if line == `\.` {
+				blocks = append(blocks, *current)
+				current = nil
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			if len(fields) != len(current.columns) {
+				return nil, invalidBackup("table %s: row has %d fields, expected %d", current.table, len(fields), len(current.columns))
+			}
+			row := make([]*string, len(fields))
+			for i, f := range fields {
+				if f == `\N` {
+					continue
+				}
+				v := copyUnescape(f)
+				row[i] = &v
+			}
+			current.rows = append(current.rows, row)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "COPY "):
+			block, err := parseCopyHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			current = block
+		case line == "COMMIT;":
+			committed = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, invalidBackup("table %s: unterminated COPY block", current.table)
+	}
+	if !committed {
+		return nil, invalidBackup("backup is truncated (missing COMMIT)")
+	}
+	return blocks, nil
+}
+
+// parseCopyHeader parses `COPY "table" ("a", "b") FROM stdin;`
+func parseCopyHeader(line string) (*copyBlock, error) {
+	rest := strings.TrimPrefix(line, "COPY ")
+	open := strings.Index(rest, " (")
+	end := strings.LastIndex(rest, ") FROM stdin;")
+	if open < 0 || end < open {
+		return nil, invalidBackup("malformed COPY statement: %s", line)
+	}
+
+	block := &copyBlock{table: unquoteIdentifier(rest[:open])}
+	for _, col := range strings.Split(rest[open+2:end], ", ") {
+		block.columns = append(block.columns, unquoteIdentifier(col))
+	}
+	return block, nil
+}
+
+// unquoteIdentifier reverses pq.QuoteIdentifier
+func unquoteIdentifier(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+// copyEscape escapes a value for the COPY text format
+func copyEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	return r.Replace(s)
+}
+
+// copyUnescape reverses copyEscape (and the other COPY text escapes used by pg_dump)
+func copyUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
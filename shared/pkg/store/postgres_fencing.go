@@ -0,0 +1,85 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// Fenced writes for PostgreSQL Store
+// Each write runs in a transaction that first checks the leader's fencing token,
+// so a master that lost leadership cannot assign, requeue, fail or delete jobs.
+
+var _ FencedStore = (*PostgreSQLStore)(nil)
+
+// pgExecer is satisfied by *sql.DB and *sql.Tx
+type pgExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// checkFencingToken returns ErrStaleFencingToken unless fencingToken is the current
+// token of the named, unexpired lease. FOR SHARE blocks a concurrent takeover until tx commits.
+func checkFencingToken(tx *sql.Tx, lease string, fencingToken int64) error {
+	var currentToken int64
+	err := tx.QueryRow(`
+		SELECT token FROM leader_leases
+		WHERE name = $1 AND expires_at > NOW()
+		FOR SHARE
+	`, lease).Scan(&currentToken)
+	if err == sql.ErrNoRows || (err == nil && currentToken != fencingToken) {
+		return ErrStaleFencingToken
+	}
+	return err
+}
+
+// fenced runs fn in a transaction guarded by the fencing token of the named lease
+func (s *PostgreSQLStore) fenced(lease string, fencingToken int64, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkFencingToken(tx, lease, fencingToken); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TryQueuePendingJobFenced queues a pending job if fencingToken is still current
+func (s *PostgreSQLStore) TryQueuePendingJobFenced(jobID, lease string, fencingToken int64) (bool, error) {
+	var queued bool
+	err := s.fenced(lease, fencingToken, func(tx *sql.Tx) error {
+		var err error
+		queued, err = tryQueuePendingJob(tx, jobID)
+		return err
+	})
+	return queued, err
+}
+
+// UpdateJobStatusFenced updates the status of a job if fencingToken is still current
+func (s *PostgreSQLStore) UpdateJobStatusFenced(id string, status models.JobStatus, errorMsg, lease string, fencingToken int64) error {
+	return s.fenced(lease, fencingToken, func(tx *sql.Tx) error {
+		return updateJobStatus(tx, id, status, errorMsg)
+	})
+}
+
+// RetryJobFenced requeues a job for retry if fencingToken is still current
+func (s *PostgreSQLStore) RetryJobFenced(jobID, errorMsg, lease string, fencingToken int64) error {
+	return s.fenced(lease, fencingToken, func(tx *sql.Tx) error {
+		return retryJob(tx, jobID, errorMsg)
+	})
+}
+
+// DeleteJobFenced deletes a job if fencingToken is still current
+func (s *PostgreSQLStore) DeleteJobFenced(id, lease string, fencingToken int64) error {
+	return s.fenced(lease, fencingToken, func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM jobs WHERE id = $1", id)
+		return err
+	})
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// TestPostgreSQLFencing checks that job writes made with a deposed leader's token are rejected
+// Set DATABASE_DSN environment variable to run: export DATABASE_DSN="postgresql://..."
+func TestPostgreSQLFencing(t *testing.T) {
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("Skipping PostgreSQL fencing test: DATABASE_DSN not set")
+	}
+
+	st, err := NewStore(Config{
+		Type: "postgres",
+		DSN:  dsn,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PostgreSQL store: %v", err)
+	}
+	defer st.Close()
+	pg := st.(*PostgreSQLStore)
+
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	lease := fmt.Sprintf("fencing-test-%d", suffix)
+
+	// Master A leads, then loses the lease to master B
+	lockA := pg.NewLeaderLock(lease, "master-a", time.Minute)
+	staleToken, acquired, err := lockA.TryAcquire(ctx)
+	if err != nil || !acquired {
+		t.Fatalf("master-a failed to acquire lease: acquired=%v err=%v", acquired, err)
+	}
+	if err := lockA.Release(ctx); err != nil {
+		t.Fatalf("master-a failed to release lease: %v", err)
+	}
+	lockB := pg.NewLeaderLock(lease, "master-b", time.Minute)
+	token, acquired, err := lockB.TryAcquire(ctx)
+	if err != nil || !acquired {
+		t.Fatalf("master-b failed to acquire lease: acquired=%v err=%v", acquired, err)
+	}
+	defer lockB.Release(ctx)
+	if token <= staleToken {
+		t.Fatalf("takeover token %d should be greater than %d", token, staleToken)
+	}
+
+	node := &models.Node{
+		ID:            fmt.Sprintf("fencing-node-%d", suffix),
+		Name:          "Fencing Node",
+		Address:       fmt.Sprintf("fencing-%d:9000", suffix),
+		Type:          "worker",
+		Status:        "available",
+		LastHeartbeat: time.Now(),
+	}
+	if err := st.RegisterNode(node); err != nil {
+		t.Fatalf("Failed to register node: %v", err)
+	}
+	defer st.DeleteNode(node.ID)
+
+	newJob := func(name string, status models.JobStatus) *models.Job {
+		job := &models.Job{
+			ID:         fmt.Sprintf("fencing-%s-%d", name, suffix),
+			Scenario:   "fencing-test",
+			Confidence: "auto",
+			Engine:     "ffmpeg",
+			Queue:      "live",
+			Priority:   "high",
+			Status:     status,
+			CreatedAt:  time.Now(),
+		}
+		if err := st.CreateJob(job); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+		t.Cleanup(func() { st.DeleteJob(job.ID) })
+		return job
+	}
+
+	t.Run("TryQueuePendingJob", func(t *testing.T) {
+		job := newJob("queue", models.JobStatusPending)
+		if _, err := pg.TryQueuePendingJobFenced(job.ID, lease, staleToken); err != ErrStaleFencingToken {
+			t.Fatalf("expected ErrStaleFencingToken, got %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusPending)
+
+		queued, err := pg.TryQueuePendingJobFenced(job.ID, lease, token)
+		if err != nil || !queued {
+			t.Fatalf("leader failed to queue job: queued=%v err=%v", queued, err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusQueued)
+	})
+
+	t.Run("UpdateJobStatus", func(t *testing.T) {
+		job := newJob("fail", models.JobStatusProcessing)
+		if err := pg.UpdateJobStatusFenced(job.ID, models.JobStatusFailed, "stale", lease, staleToken); err != ErrStaleFencingToken {
+			t.Fatalf("expected ErrStaleFencingToken, got %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusProcessing)
+
+		if err := pg.UpdateJobStatusFenced(job.ID, models.JobStatusFailed, "stale", lease, token); err != nil {
+			t.Fatalf("leader failed to fail job: %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusFailed)
+	})
+
+	t.Run("RetryJob", func(t *testing.T) {
+		job := newJob("retry", models.JobStatusFailed)
+		if err := pg.RetryJobFenced(job.ID, "retry", lease, staleToken); err != ErrStaleFencingToken {
+			t.Fatalf("expected ErrStaleFencingToken, got %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusFailed)
+
+		if err := pg.RetryJobFenced(job.ID, "retry", lease, token); err != nil {
+			t.Fatalf("leader failed to retry job: %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusQueued)
+	})
+
+	t.Run("AssignJobToWorker", func(t *testing.T) {
+		job := newJob("assign", models.JobStatusQueued)
+		if _, err := pg.AssignJobToWorkerFenced(job.ID, node.ID, lease, staleToken); err != ErrStaleFencingToken {
+			t.Fatalf("expected ErrStaleFencingToken, got %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusQueued)
+	})
+
+	t.Run("DeleteJob", func(t *testing.T) {
+		job := newJob("delete", models.JobStatusCompleted)
+		if err := pg.DeleteJobFenced(job.ID, lease, staleToken); err != ErrStaleFencingToken {
+			t.Fatalf("expected ErrStaleFencingToken, got %v", err)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusCompleted)
+
+		if err := pg.DeleteJobFenced(job.ID, lease, token); err != nil {
+			t.Fatalf("leader failed to delete job: %v", err)
+		}
+		if _, err := st.GetJob(job.ID); err == nil {
+			t.Errorf("expected job %s to be deleted", job.ID)
+		}
+	})
+
+	// Job assignment is served by every master: the row lock, not the token, prevents double assignment
+	t.Run("GetNextJob", func(t *testing.T) {
+		job, err := st.GetNextJob(node.ID)
+		if err != nil {
+			t.Fatalf("failed to assign next job: %v", err)
+		}
+		if job.NodeID != node.ID || job.Status != models.JobStatusAssigned {
+			t.Errorf("expected job assigned to %s, got status %s on %q", node.ID, job.Status, job.NodeID)
+		}
+		expectStatus(t, st, job.ID, models.JobStatusAssigned)
+	})
+}
+
+// expectStatus fails the test unless the stored job has the given status
+func expectStatus(t *testing.T, st Store, jobID string, status models.JobStatus) {
+	t.Helper()
+	job, err := st.GetJob(jobID)
+	if err != nil {
+		t.Fatalf("Failed to get job %s: %v", jobID, err)
+	}
+	if job.Status != status {
+		t.Errorf("job %s: expected status %s, got %s", jobID, status, job.Status)
+	}
+}
@@ -84,12 +84,29 @@ func (s *PostgreSQLStore) TransitionJobState(jobID string, toState models.JobSta
 
 // AssignJobToWorker atomically assigns a job to a worker with idempotency
 func (s *PostgreSQLStore) AssignJobToWorker(jobID, nodeID string) (bool, error) {
+	return s.assignJobToWorker(jobID, nodeID, "", 0)
+}
+
+// AssignJobToWorkerFenced assigns a job only if fencingToken is still the current token
+// for the named leader lease. Assignments from a deposed leader are rejected with ErrStaleFencingToken.
+func (s *PostgreSQLStore) AssignJobToWorkerFenced(jobID, nodeID, lease string, fencingToken int64) (bool, error) {
+	return s.assignJobToWorker(jobID, nodeID, lease, fencingToken)
+}
+
+// assignJobToWorker implements job assignment, checking the fencing token when a lease is given
+func (s *PostgreSQLStore) assignJobToWorker(jobID, nodeID, lease string, fencingToken int64) (bool, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return false, err
 	}
 	defer tx.Rollback()
 
+	if lease != "" {
+		if err := checkFencingToken(tx, lease, fencingToken); err != nil {
+			return false, err
+		}
+	}
+
 	// Get job with lock
 	var currentStatus, currentNodeID string
 	var transitionsJSON []byte
@@ -240,7 +257,7 @@ func (s *PostgreSQLStore) GetJobsInState(state models.JobStatus) ([]*models.Job,
 	rows, err := s.db.Query(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, 
 		       progress, node_id, created_at, started_at, last_activity_at, completed_at, 
-		       retry_count, error, failure_reason, logs, state_transitions
+		       retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
 		FROM jobs 
 		WHERE status = $1
 		ORDER BY created_at ASC
@@ -263,6 +280,32 @@ func (s *PostgreSQLStore) GetJobsInState(state models.JobStatus) ([]*models.Job,
 	return jobs, nil
 }
 
+// GetNodeJobsInState returns the jobs of a node in a specific state
+func (s *PostgreSQLStore) GetNodeJobsInState(nodeID string, state models.JobStatus) ([]*models.Job, error) {
+	rows, err := s.db.Query(`
+		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority,
+		       progress, node_id, created_at, started_at, last_activity_at, completed_at,
+		       retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
+		FROM jobs
+		WHERE node_id = $1 AND status = $2
+		ORDER BY created_at ASC
+	`, nodeID, string(state))
+	if err != nil {
+		return nil, fmt.Errorf("query node jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*models.Job, 0)
+	for rows.Next() {
+		job, err := s.scanJobRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
 // GetOrphanedJobs returns jobs assigned to dead workers
 func (s *PostgreSQLStore) GetOrphanedJobs(workerTimeout time.Duration) ([]*models.Job, error) {
 	cutoff := time.Now().Add(-workerTimeout)
@@ -271,7 +314,7 @@ func (s *PostgreSQLStore) GetOrphanedJobs(workerTimeout time.Duration) ([]*model
 		SELECT j.id, j.sequence_number, j.scenario, j.confidence, j.engine, j.parameters, j.status, 
 		       j.queue, j.priority, j.progress, j.node_id, j.created_at, j.started_at, 
 		       j.last_activity_at, j.completed_at, j.retry_count, j.error, j.failure_reason, 
-		       j.logs, j.state_transitions
+		       j.logs, j.state_transitions, COALESCE(j.tenant_id, '')
 		FROM jobs j
 		INNER JOIN nodes n ON j.node_id = n.id
 		WHERE j.status IN ($1, $2)
@@ -304,7 +347,7 @@ func (s *PostgreSQLStore) GetTimedOutJobs() ([]*models.Job, error) {
 	rows, err := s.db.Query(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, 
 		       progress, node_id, created_at, started_at, last_activity_at, completed_at, 
-		       retry_count, error, failure_reason, logs, state_transitions
+		       retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
 		FROM jobs 
 		WHERE status IN ($1, $2)
 		  AND COALESCE(last_activity_at, started_at, created_at) < $3
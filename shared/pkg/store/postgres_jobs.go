@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 )
 
@@ -18,11 +19,11 @@ func (s *PostgreSQLStore) GetJob(id string) (*models.Job, error) {
 
 	err := s.db.QueryRow(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
 		FROM jobs WHERE id = $1
 	`, id).Scan(&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON, &job.Status,
 		&job.Queue, &job.Priority, &job.Progress, &nodeID, &job.CreatedAt,
-		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &failureReason, &logs, &transitionsJSON)
+		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &failureReason, &logs, &transitionsJSON, &job.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrJobNotFound
@@ -76,11 +77,11 @@ func (s *PostgreSQLStore) GetJobBySequenceNumber(seqNum int) (*models.Job, error
 
 	err := s.db.QueryRow(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
 		FROM jobs WHERE sequence_number = $1
 	`, seqNum).Scan(&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON, &job.Status,
 		&job.Queue, &job.Priority, &job.Progress, &nodeID, &job.CreatedAt,
-		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &failureReason, &logs, &transitionsJSON)
+		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &failureReason, &logs, &transitionsJSON, &job.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrJobNotFound
@@ -124,7 +125,7 @@ func (s *PostgreSQLStore) GetJobBySequenceNumber(seqNum int) (*models.Job, error
 func (s *PostgreSQLStore) GetAllJobs() []*models.Job {
 	rows, err := s.db.Query(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
 		FROM jobs
 		ORDER BY sequence_number ASC
 	`)
@@ -307,7 +308,7 @@ func (s *PostgreSQLStore) scanJobRow(scanner interface {
 		&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine,
 		&paramsJSON, &job.Status, &job.Queue, &job.Priority, &job.Progress,
 		&nodeID, &job.CreatedAt, &startedAt, &lastActivityAt, &completedAt,
-		&job.RetryCount, &job.Error, &failureReason, &logs, &transitionsJSON,
+		&job.RetryCount, &job.Error, &failureReason, &logs, &transitionsJSON, &job.TenantID,
 	)
 
 	if err != nil {
@@ -346,17 +347,116 @@ func (s *PostgreSQLStore) scanJobRow(scanner interface {
 	return &job, nil
 }
 
-// GetNextJob retrieves the next pending job for a worker (legacy, use scheduler)
+// GetNextJob assigns the next queued job to a node.
+// It needs no fencing token: the job row is locked, so masters polled concurrently
+// never assign the same job, and any master can serve /jobs/next.
 func (s *PostgreSQLStore) GetNextJob(nodeID string) (*models.Job, error) {
-	return nil, fmt.Errorf("GetNextJob is deprecated, use production scheduler")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	job, err := s.assignNextJob(tx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// assignNextJob assigns the next queued job to a node within tx.
+// Priority order: live > default > batch, then high > medium > low, then FIFO.
+func (s *PostgreSQLStore) assignNextJob(tx *sql.Tx, nodeID string) (*models.Job, error) {
+	// Nodes dedicated to a tenant only take that tenant's jobs
+	var tenantID string
+	err := tx.QueryRow("SELECT COALESCE(tenant_id, '') FROM nodes WHERE id = $1", nodeID).Scan(&tenantID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tenants at their concurrent job quota are skipped until a job finishes
+	capped, err := cappedTenantIDs(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// SKIP LOCKED lets concurrent polls pick different jobs
+	job, err := s.scanJobRow(tx.QueryRow(`
+		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority,
+		       progress, node_id, created_at, started_at, last_activity_at, completed_at,
+		       retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
+		FROM jobs
+		WHERE status IN ($1, $2)
+		  AND ($3 = '' OR tenant_id = $3)
+		  AND NOT (COALESCE(tenant_id, '') = ANY($4))
+		ORDER BY
+			CASE queue WHEN 'live' THEN 3 WHEN 'default' THEN 2 WHEN 'batch' THEN 1 ELSE 2 END DESC,
+			CASE priority WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 2 END DESC,
+			created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, models.JobStatusPending, models.JobStatusQueued, tenantID, pq.Array(capped)))
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job.StateTransitions = append(job.StateTransitions, models.StateTransition{
+		From:      job.Status,
+		To:        models.JobStatusAssigned,
+		Timestamp: now,
+		Reason:    fmt.Sprintf("Assigned to node %s", nodeID),
+	})
+	transitionsJSON, err := json.Marshal(job.StateTransitions)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE jobs
+		SET status = $1, node_id = $2, started_at = $3, last_activity_at = $4, state_transitions = $5
+		WHERE id = $6
+	`, models.JobStatusAssigned, nodeID, now, now, string(transitionsJSON), job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE nodes
+		SET status = $1, current_job_id = $2
+		WHERE id = $3
+	`, "busy", job.ID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = models.JobStatusAssigned
+	job.NodeID = nodeID
+	job.StartedAt = &now
+	job.LastActivityAt = &now
+	return job, nil
 }
 
 // UpdateJobStatus updates the status of a job
 func (s *PostgreSQLStore) UpdateJobStatus(id string, status models.JobStatus, errorMsg string) error {
+	return updateJobStatus(s.db, id, status, errorMsg)
+}
+
+// updateJobStatus updates the status of a job on a connection or transaction
+func updateJobStatus(q pgExecer, id string, status models.JobStatus, errorMsg string) error {
 	now := time.Now()
 
 	if status == models.JobStatusCompleted || status == models.JobStatusFailed {
-		_, err := s.db.Exec(`
+		_, err := q.Exec(`
 			UPDATE jobs 
 			SET status = $1, error = $2, completed_at = $3
 			WHERE id = $4
@@ -364,7 +464,7 @@ func (s *PostgreSQLStore) UpdateJobStatus(id string, status models.JobStatus, er
 		return err
 	}
 
-	_, err := s.db.Exec(`
+	_, err := q.Exec(`
 		UPDATE jobs 
 		SET status = $1, error = $2
 		WHERE id = $3
@@ -496,10 +596,19 @@ return err
 }
 defer tx.Rollback()
 
+if err := retryJob(tx, jobID, errorMsg); err != nil {
+return err
+}
+
+return tx.Commit()
+}
+
+// retryJob requeues a job with an incremented retry count within tx
+func retryJob(tx *sql.Tx, jobID string, errorMsg string) error {
 // Get current job
 var retryCount int
 var status string
-err = tx.QueryRow("SELECT retry_count, status FROM jobs WHERE id = $1 FOR UPDATE", jobID).
+err := tx.QueryRow("SELECT retry_count, status FROM jobs WHERE id = $1 FOR UPDATE", jobID).
 Scan(&retryCount, &status)
 if err != nil {
 return err
@@ -512,16 +621,17 @@ SET status = $1, retry_count = $2, error = $3, node_id = NULL
 WHERE id = $4
 `, models.JobStatusQueued, retryCount+1, errorMsg, jobID)
 
-if err != nil {
 return err
 }
 
-return tx.Commit()
-}
-
 // TryQueuePendingJob atomically queues a pending job (for legacy scheduler)
 func (s *PostgreSQLStore) TryQueuePendingJob(jobID string) (bool, error) {
-result, err := s.db.Exec(`
+return tryQueuePendingJob(s.db, jobID)
+}
+
+// tryQueuePendingJob queues a pending job on a connection or transaction
+func tryQueuePendingJob(q pgExecer, jobID string) (bool, error) {
+result, err := q.Exec(`
 UPDATE jobs 
 SET status = $1
 WHERE id = $2 AND status = $3
@@ -544,7 +654,7 @@ func (s *PostgreSQLStore) GetQueuedJobs(queue string, priority string) []*models
 query := `
 SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, 
        progress, node_id, created_at, started_at, last_activity_at, completed_at, 
-       retry_count, error, failure_reason, logs, state_transitions
+       retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
 FROM jobs 
 WHERE status IN ($1, $2)
 `
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// PostgresLeaderLock implements leader election using a PostgreSQL session-level advisory lock.
+// The lock is held on a dedicated connection; if that session dies PostgreSQL releases the lock
+// and another master can take over. Each acquisition increments the fencing token in leader_leases.
+type PostgresLeaderLock struct {
+	db            *sql.DB
+	name          string
+	holderID      string
+	key           int64
+	leaseDuration time.Duration
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewLeaderLock creates an advisory lock for the named lease, held by holderID
+func (s *PostgreSQLStore) NewLeaderLock(name, holderID string, leaseDuration time.Duration) *PostgresLeaderLock {
+	h := fnv.New64a()
+	h.Write([]byte("ffrtmp-leader:" + name))
+
+	return &PostgresLeaderLock{
+		db:            s.db,
+		name:          name,
+		holderID:      holderID,
+		key:           int64(h.Sum64()),
+		leaseDuration: leaseDuration,
+	}
+}
+
+// TryAcquire attempts to take the advisory lock without blocking.
+// On success the lease token is incremented and returned as the new fencing token.
+func (l *PostgresLeaderLock) TryAcquire(ctx context.Context) (int64, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		conn, err := l.db.Conn(ctx)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to open lock connection: %w", err)
+		}
+		l.conn = conn
+	}
+
+	var acquired bool
+	if err := l.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil {
+		l.closeConn()
+		return 0, false, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+	if !acquired {
+		return 0, false, nil
+	}
+
+	var token int64
+	err := l.conn.QueryRowContext(ctx, `
+		INSERT INTO leader_leases (name, holder_id, token, acquired_at, renewed_at, expires_at)
+		VALUES ($1, $2, 1, NOW(), NOW(), NOW() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE SET
+			holder_id = EXCLUDED.holder_id,
+			token = leader_leases.token + 1,
+			acquired_at = EXCLUDED.acquired_at,
+			renewed_at = EXCLUDED.renewed_at,
+			expires_at = EXCLUDED.expires_at
+		RETURNING token
+	`, l.name, l.holderID, l.leaseDuration.Seconds()).Scan(&token)
+	if err != nil {
+		l.unlock(ctx)
+		return 0, false, fmt.Errorf("failed to record lease: %w", err)
+	}
+
+	return token, true, nil
+}
+
+// Renew extends the lease. It returns false if another holder has taken over.
+func (l *PostgresLeaderLock) Renew(ctx context.Context, token int64) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return false, nil
+	}
+
+	// Running on the lock connection also verifies the session (and so the lock) is still alive
+	result, err := l.conn.ExecContext(ctx, `
+		UPDATE leader_leases
+		SET renewed_at = NOW(), expires_at = NOW() + make_interval(secs => $1)
+		WHERE name = $2 AND holder_id = $3 AND token = $4
+	`, l.leaseDuration.Seconds(), l.name, l.holderID, token)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// Release gives up the advisory lock and closes the lock connection
+func (l *PostgresLeaderLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	// Expire the lease so followers don't wait on it
+	l.conn.ExecContext(ctx, `
+		UPDATE leader_leases SET expires_at = NOW()
+		WHERE name = $1 AND holder_id = $2
+	`, l.name, l.holderID)
+
+	return l.unlock(ctx)
+}
+
+// unlock releases the advisory lock and closes the connection (caller holds l.mu)
+func (l *PostgresLeaderLock) unlock(ctx context.Context) error {
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	l.closeConn()
+	return err
+}
+
+// closeConn discards the lock connection (caller holds l.mu).
+// The connection is never returned to the pool, so a lock that failed to unlock
+// cannot linger in an unrelated session.
+func (l *PostgresLeaderLock) closeConn() {
+	if l.conn != nil {
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		l.conn.Close()
+		l.conn = nil
+	}
+}
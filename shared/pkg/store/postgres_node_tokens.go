@@ -0,0 +1,42 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// SaveNodeToken stores the identity token of a node, replacing any previous token
+func (s *PostgreSQLStore) SaveNodeToken(token *models.NodeToken) error {
+	if _, err := s.GetNode(token.NodeID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO node_tokens (`+nodeTokenColumns+`)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (node_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+	`, nodeTokenArgs(token)...)
+	return err
+}
+
+// GetNodeToken retrieves the identity token of a node
+func (s *PostgreSQLStore) GetNodeToken(nodeID string) (*models.NodeToken, error) {
+	token, err := scanNodeToken(s.db.QueryRow(`SELECT `+nodeTokenColumns+` FROM node_tokens WHERE node_id = $1`, nodeID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNodeTokenNotFound
+	}
+	return token, err
+}
+
+// DeleteNodeToken removes the identity token of a node
+func (s *PostgreSQLStore) DeleteNodeToken(nodeID string) error {
+	result, err := s.db.Exec("DELETE FROM node_tokens WHERE node_id = $1", nodeID)
+	if err != nil {
+		return err
+	}
+	return nodeTokenRowsAffected(result)
+}
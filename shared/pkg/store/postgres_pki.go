@@ -0,0 +1,80 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// CreateBootstrapToken stores a new bootstrap token
+func (s *PostgreSQLStore) CreateBootstrapToken(token *models.BootstrapToken) error {
+	_, err := s.db.Exec(`
+		INSERT INTO bootstrap_tokens (`+bootstrapTokenColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, bootstrapTokenArgs(token)...)
+	return err
+}
+
+// ListBootstrapTokens returns all bootstrap tokens, newest first
+func (s *PostgreSQLStore) ListBootstrapTokens() ([]*models.BootstrapToken, error) {
+	rows, err := s.db.Query(`SELECT ` + bootstrapTokenColumns + ` FROM bootstrap_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanBootstrapTokens(rows)
+}
+
+// UseBootstrapToken marks an unused, unexpired token as used by the named worker and returns it.
+// It returns ErrBootstrapTokenNotFound if no such token exists, so each token is only used once.
+func (s *PostgreSQLStore) UseBootstrapToken(tokenHash, usedBy string, usedAt time.Time) (*models.BootstrapToken, error) {
+	token, err := scanBootstrapToken(s.db.QueryRow(`
+		UPDATE bootstrap_tokens SET used_at = $1, used_by = $2
+		WHERE token_hash = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING `+bootstrapTokenColumns, usedAt.UTC(), usedBy, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrBootstrapTokenNotFound
+	}
+	return token, err
+}
+
+// SaveCertificate records an issued certificate
+func (s *PostgreSQLStore) SaveCertificate(cert *models.IssuedCertificate) error {
+	_, err := s.db.Exec(`
+		INSERT INTO issued_certificates (`+certificateColumns+`)
+		VALUES ($1, $2, $3, $4, $5)
+	`, certificateArgs(cert)...)
+	return err
+}
+
+// GetCertificate retrieves an issued certificate by serial number
+func (s *PostgreSQLStore) GetCertificate(serial string) (*models.IssuedCertificate, error) {
+	cert, err := scanCertificate(s.db.QueryRow(`SELECT `+certificateColumns+` FROM issued_certificates WHERE serial = $1`, serial))
+	if err == sql.ErrNoRows {
+		return nil, ErrCertificateNotFound
+	}
+	return cert, err
+}
+
+// ListCertificates returns issued certificates, newest first, optionally only revoked ones
+func (s *PostgreSQLStore) ListCertificates(revokedOnly bool) ([]*models.IssuedCertificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM issued_certificates`
+	if revokedOnly {
+		query += ` WHERE revoked_at IS NOT NULL`
+	}
+	rows, err := s.db.Query(query + ` ORDER BY not_before DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCertificates(rows)
+}
+
+// RevokeCertificate marks a certificate as revoked. Revoking it again keeps the original revocation time.
+func (s *PostgreSQLStore) RevokeCertificate(serial string, revokedAt time.Time) error {
+	result, err := s.db.Exec(`UPDATE issued_certificates SET revoked_at = COALESCE(revoked_at, $1) WHERE serial = $2`,
+		revokedAt.UTC(), serial)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOr(result, ErrCertificateNotFound)
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// TakeRateLimitToken takes a token from the bucket for key, refilled at rate tokens per second up to burst.
+// The bucket row is locked, so masters sharing the database take tokens from the same bucket.
+func (s *PostgreSQLStore) TakeRateLimitToken(key string, rate float64, burst int, now time.Time) (models.RateLimitDecision, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.RateLimitDecision{}, err
+	}
+	defer tx.Rollback()
+
+	// Create the bucket full, then lock it
+	if _, err := tx.Exec(`
+		INSERT INTO rate_limit_buckets (`+rateLimitBucketColumns+`) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO NOTHING
+	`, rateLimitBucketArgs(models.NewRateLimitBucket(key, burst, now))...); err != nil {
+		return models.RateLimitDecision{}, err
+	}
+	bucket, err := scanRateLimitBucket(tx.QueryRow(`SELECT `+rateLimitBucketColumns+` FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`, key))
+	if err == sql.ErrNoRows {
+		// Deleted as full between the insert and the select
+		bucket, err = models.NewRateLimitBucket(key, burst, now), nil
+	}
+	if err != nil {
+		return models.RateLimitDecision{}, err
+	}
+
+	decision := bucket.Take(rate, burst, now)
+	if !decision.Allowed {
+		return decision, tx.Commit()
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO rate_limit_buckets (`+rateLimitBucketColumns+`) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET tokens = EXCLUDED.tokens, updated_at = EXCLUDED.updated_at, full_at = EXCLUDED.full_at
+	`, rateLimitBucketArgs(bucket)...); err != nil {
+		return models.RateLimitDecision{}, err
+	}
+	return decision, tx.Commit()
+}
+
+// DeleteFullRateLimitBuckets deletes buckets that were full before the given time
+func (s *PostgreSQLStore) DeleteFullRateLimitBuckets(before time.Time) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM rate_limit_buckets WHERE full_at < $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
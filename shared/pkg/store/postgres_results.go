@@ -0,0 +1,86 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// SaveJobResult stores (or replaces) the final result of a job
+func (s *PostgreSQLStore) SaveJobResult(result *models.JobResult) error {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)", result.JobID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrJobNotFound
+	}
+
+	args, err := jobResultArgs(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO job_results (`+jobResultColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (job_id) DO UPDATE SET
+			node_id = EXCLUDED.node_id,
+			status = EXCLUDED.status,
+			engine = EXCLUDED.engine,
+			fps = EXCLUDED.fps,
+			bitrate_kbps = EXCLUDED.bitrate_kbps,
+			frames = EXCLUDED.frames,
+			dropped_frames = EXCLUDED.dropped_frames,
+			duration_seconds = EXCLUDED.duration_seconds,
+			output_bytes = EXCLUDED.output_bytes,
+			vmaf_score = EXCLUDED.vmaf_score,
+			qoe_score = EXCLUDED.qoe_score,
+			efficiency_score = EXCLUDED.efficiency_score,
+			energy_joules = EXCLUDED.energy_joules,
+			progress = EXCLUDED.progress,
+			error = EXCLUDED.error,
+			metrics = EXCLUDED.metrics,
+			analyzer_output = EXCLUDED.analyzer_output,
+			completed_at = EXCLUDED.completed_at
+	`, args...)
+	return err
+}
+
+// GetJobResult retrieves the stored result of a job
+func (s *PostgreSQLStore) GetJobResult(jobID string) (*models.JobResult, error) {
+	row := s.db.QueryRow("SELECT "+jobResultColumns+" FROM job_results WHERE job_id = $1", jobID)
+
+	result, err := scanJobResult(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobResultNotFound
+	}
+	return result, err
+}
+
+// GetResultAggregates summarizes completed job results by scenario, engine or node
+func (s *PostgreSQLStore) GetResultAggregates(groupBy string, since time.Time) ([]*models.ResultAggregate, error) {
+	query, err := resultAggregateQuery(groupBy, "$1")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(query, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]*models.ResultAggregate, 0)
+	for rows.Next() {
+		var lastCompleted sql.NullTime
+		agg, err := scanResultAggregate(rows, groupBy, &lastCompleted)
+		if err != nil {
+			return nil, err
+		}
+		agg.LastCompletedAt = lastCompleted.Time
+		aggregates = append(aggregates, agg)
+	}
+	return aggregates, rows.Err()
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// CreateSecret stores a new secret. It returns ErrSecretExists if the tenant
+// already has a secret with the same name.
+func (s *PostgreSQLStore) CreateSecret(secret *models.Secret) error {
+	_, err := s.db.Exec(`
+		INSERT INTO secrets (`+secretColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, secretArgs(secret)...)
+	if err != nil && isUniqueViolation(err) {
+		return ErrSecretExists
+	}
+	return err
+}
+
+// GetSecret retrieves a tenant's secret by name
+func (s *PostgreSQLStore) GetSecret(tenantID, name string) (*models.Secret, error) {
+	secret, err := scanSecret(s.db.QueryRow(`SELECT `+secretColumns+` FROM secrets WHERE tenant_id = $1 AND name = $2`, tenantID, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrSecretNotFound
+	}
+	return secret, err
+}
+
+// ListSecrets returns a tenant's secrets ordered by name
+func (s *PostgreSQLStore) ListSecrets(tenantID string) ([]*models.Secret, error) {
+	rows, err := s.db.Query(`SELECT `+secretColumns+` FROM secrets WHERE tenant_id = $1 ORDER BY name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanSecrets(rows)
+}
+
+// UpdateSecret replaces the value and description of a secret
+func (s *PostgreSQLStore) UpdateSecret(secret *models.Secret) error {
+	result, err := s.db.Exec(`UPDATE secrets SET description = $1, ciphertext = $2, updated_at = $3 WHERE tenant_id = $4 AND name = $5`,
+		secret.Description, secret.Ciphertext, secret.UpdatedAt.UTC(), secret.TenantID, secret.Name)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOr(result, ErrSecretNotFound)
+}
+
+// DeleteSecret deletes a tenant's secret
+func (s *PostgreSQLStore) DeleteSecret(tenantID, name string) error {
+	result, err := s.db.Exec(`DELETE FROM secrets WHERE tenant_id = $1 AND name = $2`, tenantID, name)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOr(result, ErrSecretNotFound)
+}
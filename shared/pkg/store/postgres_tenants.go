@@ -0,0 +1,329 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// ensureDefaultTenant creates the default tenant if it does not exist and
+// migrates jobs created before tenancy was enabled to it
+func (s *PostgreSQLStore) ensureDefaultTenant() error {
+	tenant := newDefaultTenant()
+	args, err := tenantArgs(tenant)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO tenants (`+tenantColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO NOTHING
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create default tenant: %w", err)
+	}
+
+	// Earlier schema versions seeded the default tenant with a different quota layout
+	_, err = s.db.Exec(`
+		UPDATE tenants SET quotas = $1, usage = $2
+		WHERE id = $3 AND NOT (quotas ? 'max_concurrent_jobs')
+	`, args[5], args[6], models.DefaultTenantID)
+	if err != nil {
+		return fmt.Errorf("failed to migrate default tenant quotas: %w", err)
+	}
+
+	_, err = s.db.Exec("UPDATE jobs SET tenant_id = $1 WHERE tenant_id IS NULL", models.DefaultTenantID)
+	if err != nil {
+		return fmt.Errorf("failed to assign jobs to the default tenant: %w", err)
+	}
+	return nil
+}
+
+// CreateTenant adds a new tenant
+func (s *PostgreSQLStore) CreateTenant(tenant *models.Tenant) error {
+	if err := tenant.Validate(); err != nil {
+		return fmt.Errorf("invalid tenant: %w", err)
+	}
+
+	args, err := tenantArgs(tenant)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO tenants (`+tenantColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, args...)
+	if err != nil && isUniqueViolation(err) {
+		return ErrTenantExists
+	}
+	return err
+}
+
+// GetTenant retrieves a tenant by ID
+func (s *PostgreSQLStore) GetTenant(id string) (*models.Tenant, error) {
+	tenant, err := scanTenant(s.db.QueryRow(`SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+// GetTenantByName retrieves a tenant by its unique name
+func (s *PostgreSQLStore) GetTenantByName(name string) (*models.Tenant, error) {
+	tenant, err := scanTenant(s.db.QueryRow(`SELECT `+tenantColumns+` FROM tenants WHERE name = $1`, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+// ListTenants returns all tenants that have not been deleted, oldest first
+func (s *PostgreSQLStore) ListTenants() ([]*models.Tenant, error) {
+	rows, err := s.db.Query(`SELECT `+tenantColumns+` FROM tenants WHERE status != $1 ORDER BY created_at ASC`,
+		models.TenantStatusDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := make([]*models.Tenant, 0)
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, rows.Err()
+}
+
+// UpdateTenant replaces an existing tenant
+func (s *PostgreSQLStore) UpdateTenant(tenant *models.Tenant) error {
+	if err := tenant.Validate(); err != nil {
+		return fmt.Errorf("invalid tenant: %w", err)
+	}
+
+	args, err := tenantArgs(tenant)
+	if err != nil {
+		return err
+	}
+
+	// Arguments are in tenantColumns order, so $1 is the ID
+	result, err := s.db.Exec(`
+		UPDATE tenants
+		SET name = $2, display_name = $3, status = $4, plan = $5, quotas = $6, usage = $7, metadata = $8,
+		    created_at = $9, updated_at = $10, expires_at = $11
+		WHERE id = $1
+	`, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTenantExists
+		}
+		return err
+	}
+	return tenantRowsAffected(result)
+}
+
+// DeleteTenant soft-deletes a tenant by marking it deleted
+func (s *PostgreSQLStore) DeleteTenant(id string) error {
+	if id == models.DefaultTenantID {
+		return ErrDefaultTenant
+	}
+
+	result, err := s.db.Exec("UPDATE tenants SET status = $1, updated_at = NOW() WHERE id = $2",
+		models.TenantStatusDeleted, id)
+	if err != nil {
+		return err
+	}
+	return tenantRowsAffected(result)
+}
+
+// UpdateTenantUsage stores a usage snapshot for a tenant
+func (s *PostgreSQLStore) UpdateTenantUsage(id string, usage *models.TenantUsage) error {
+	usageJSON, err := marshalJSON(usage)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	result, err := s.db.Exec("UPDATE tenants SET usage = $1 WHERE id = $2", string(usageJSON), id)
+	if err != nil {
+		return err
+	}
+	return tenantRowsAffected(result)
+}
+
+// GetTenantStats returns the live usage of a tenant
+func (s *PostgreSQLStore) GetTenantStats(id string) (*models.TenantUsage, error) {
+	tenant, err := s.GetTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := s.GetJobsByTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.GetNodesByTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	return computeTenantUsage(id, tenant.Usage, jobs, nodes), nil
+}
+
+// GetJobsByTenant returns the jobs owned by a tenant, ordered by sequence number
+func (s *PostgreSQLStore) GetJobsByTenant(tenantID string) ([]*models.Job, error) {
+	rows, err := s.db.Query(`
+		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, COALESCE(tenant_id, '')
+		FROM jobs
+		WHERE tenant_id = $1
+		ORDER BY sequence_number ASC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*models.Job, 0)
+	for rows.Next() {
+		job, err := s.scanJobRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetNodesByTenant returns the nodes dedicated to a tenant
+func (s *PostgreSQLStore) GetNodesByTenant(tenantID string) ([]*models.Node, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
+		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
+		       registered_at, current_job_id, COALESCE(tenant_id, '')
+		FROM nodes
+		WHERE tenant_id = $1
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := make([]*models.Node, 0)
+	for rows.Next() {
+		var node models.Node
+		var labelsJSON, gpuCapsJSON []byte
+		var currentJobID sql.NullString
+
+		if err := rows.Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads,
+			&node.CPUModel, &node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON,
+			&node.RAMTotalBytes, &node.RAMFreeBytes, &labelsJSON, &node.Status,
+			&node.LastHeartbeat, &node.RegisteredAt, &currentJobID, &node.TenantID); err != nil {
+			return nil, err
+		}
+
+		node.CurrentJobID = currentJobID.String
+		if len(labelsJSON) > 0 && string(labelsJSON) != "null" {
+			if err := unmarshalJSON(labelsJSON, &node.Labels); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+			}
+		}
+		if len(gpuCapsJSON) > 0 && string(gpuCapsJSON) != "null" {
+			if err := unmarshalJSON(gpuCapsJSON, &node.GPUCapabilities); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal gpu_capabilities: %w", err)
+			}
+		}
+
+		nodes = append(nodes, &node)
+	}
+	return nodes, rows.Err()
+}
+
+// CreateTenantAPIKey stores a new tenant API key
+func (s *PostgreSQLStore) CreateTenantAPIKey(key *models.TenantAPIKey) error {
+	args, err := tenantAPIKeyArgs(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.GetTenant(key.TenantID); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO tenant_api_keys (`+tenantAPIKeyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, args...)
+	return err
+}
+
+// GetTenantAPIKey retrieves a tenant API key by ID
+func (s *PostgreSQLStore) GetTenantAPIKey(id string) (*models.TenantAPIKey, error) {
+	key, err := scanTenantAPIKey(s.db.QueryRow(`SELECT `+tenantAPIKeyColumns+` FROM tenant_api_keys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantAPIKeyNotFound
+	}
+	return key, err
+}
+
+// GetTenantAPIKeyByPrefix retrieves a tenant API key by its lookup prefix
+func (s *PostgreSQLStore) GetTenantAPIKeyByPrefix(prefix string) (*models.TenantAPIKey, error) {
+	key, err := scanTenantAPIKey(s.db.QueryRow(`SELECT `+tenantAPIKeyColumns+` FROM tenant_api_keys WHERE key_prefix = $1`, prefix))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantAPIKeyNotFound
+	}
+	return key, err
+}
+
+// ListTenantAPIKeys returns the API keys of a tenant, newest first
+func (s *PostgreSQLStore) ListTenantAPIKeys(tenantID string) ([]*models.TenantAPIKey, error) {
+	rows, err := s.db.Query(`SELECT `+tenantAPIKeyColumns+` FROM tenant_api_keys WHERE tenant_id = $1 ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*models.TenantAPIKey, 0)
+	for rows.Next() {
+		key, err := scanTenantAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// UpdateTenantAPIKey updates the name, scopes, status and expiry of a tenant API key
+func (s *PostgreSQLStore) UpdateTenantAPIKey(key *models.TenantAPIKey) error {
+	scopes, err := marshalJSON(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	result, err := s.db.Exec(`UPDATE tenant_api_keys SET name = $1, scopes = $2, status = $3, expires_at = $4 WHERE id = $5`,
+		key.Name, string(scopes), key.Status, key.ExpiresAt, key.ID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrTenantAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchTenantAPIKey records when a tenant API key was last used.
+// Only last_used_at is written, so it never undoes a concurrent revocation.
+func (s *PostgreSQLStore) TouchTenantAPIKey(id string, usedAt time.Time) error {
+	result, err := s.db.Exec(`UPDATE tenant_api_keys SET last_used_at = $1 WHERE id = $2`, usedAt, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrTenantAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// CreateUser adds a new user
+func (s *PostgreSQLStore) CreateUser(user *models.User) error {
+	if _, err := s.GetTenant(user.TenantID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO users (`+userColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, userArgs(user)...)
+	if err != nil && isUniqueViolation(err) {
+		return ErrUserExists
+	}
+	return err
+}
+
+// GetUser retrieves a user by ID
+func (s *PostgreSQLStore) GetUser(id string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetUserByEmail retrieves a user by their unique email address
+func (s *PostgreSQLStore) GetUserByEmail(email string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = $1`, email))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// ListUsers returns the users of a tenant (or all users if tenantID is empty), oldest first
+func (s *PostgreSQLStore) ListUsers(tenantID string) ([]*models.User, error) {
+	rows, err := s.db.Query(`SELECT `+userColumns+` FROM users WHERE $1 = '' OR tenant_id = $1 ORDER BY created_at ASC`,
+		tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsers(rows)
+}
+
+// UpdateUser replaces an existing user
+func (s *PostgreSQLStore) UpdateUser(user *models.User) error {
+	// Arguments are in userColumns order, so $1 is the ID
+	result, err := s.db.Exec(`
+		UPDATE users
+		SET tenant_id = $2, email = $3, password_hash = $4, full_name = $5, role = $6, status = $7,
+		    last_login_at = $8, created_at = $9, updated_at = $10
+		WHERE id = $1
+	`, userArgs(user)...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+	return userRowsAffected(result)
+}
+
+// DeleteUser removes a user; their sessions are removed by the foreign key cascade
+func (s *PostgreSQLStore) DeleteUser(id string) error {
+	result, err := s.db.Exec("DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return userRowsAffected(result)
+}
+
+// CreateSession stores a new session
+func (s *PostgreSQLStore) CreateSession(session *models.Session) error {
+	if _, err := s.GetUser(session.UserID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (`+sessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, sessionArgs(session)...)
+	return err
+}
+
+// GetSessionByTokenHash retrieves a session by the hash of its token
+func (s *PostgreSQLStore) GetSessionByTokenHash(tokenHash string) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE token_hash = $1`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	return session, err
+}
+
+// DeleteSession removes a session
+func (s *PostgreSQLStore) DeleteSession(tokenHash string) error {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE token_hash = $1", tokenHash)
+	if err != nil {
+		return err
+	}
+	return sessionRowsAffected(result)
+}
+
+// DeleteUserSessions removes all sessions of a user
+func (s *PostgreSQLStore) DeleteUserSessions(userID string) error {
+	_, err := s.db.Exec("DELETE FROM sessions WHERE user_id = $1", userID)
+	return err
+}
+
+// DeleteExpiredSessions removes expired sessions and returns how many were removed
+func (s *PostgreSQLStore) DeleteExpiredSessions() (int, error) {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE expires_at < NOW()")
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
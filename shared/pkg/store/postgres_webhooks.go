@@ -0,0 +1,174 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// CreateWebhook adds a new webhook subscription
+func (s *PostgreSQLStore) CreateWebhook(hook *models.Webhook) error {
+	events, err := json.Marshal(hook.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO webhooks (id, tenant_id, url, events, secret, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, hook.ID, hook.TenantID, hook.URL, string(events), hook.Secret, hook.Enabled, hook.CreatedAt, hook.UpdatedAt)
+	return err
+}
+
+// GetWebhook retrieves a webhook subscription by ID
+func (s *PostgreSQLStore) GetWebhook(id string) (*models.Webhook, error) {
+	row := s.db.QueryRow(`
+		SELECT id, tenant_id, url, events, secret, enabled, created_at, updated_at
+		FROM webhooks WHERE id = $1
+	`, id)
+
+	hook, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, err
+}
+
+// ListWebhooks returns all webhook subscriptions ordered by creation time
+func (s *PostgreSQLStore) ListWebhooks() ([]*models.Webhook, error) {
+	rows, err := s.db.Query(`
+		SELECT id, tenant_id, url, events, secret, enabled, created_at, updated_at
+		FROM webhooks ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// UpdateWebhook replaces an existing webhook subscription
+func (s *PostgreSQLStore) UpdateWebhook(hook *models.Webhook) error {
+	events, err := json.Marshal(hook.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE webhooks SET url = $1, events = $2, secret = $3, enabled = $4, updated_at = $5
+		WHERE id = $6
+	`, hook.URL, string(events), hook.Secret, hook.Enabled, hook.UpdatedAt, hook.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook subscription (deliveries are removed by cascade)
+func (s *PostgreSQLStore) DeleteWebhook(id string) error {
+	result, err := s.db.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// SaveWebhookDelivery creates or updates a webhook delivery record
+func (s *PostgreSQLStore) SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_deliveries
+		(id, webhook_id, job_id, event, payload, status, attempts, response_code, last_error, created_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			response_code = EXCLUDED.response_code,
+			last_error = EXCLUDED.last_error,
+			delivered_at = EXCLUDED.delivered_at
+	`, delivery.ID, delivery.WebhookID, delivery.JobID, delivery.Event, delivery.Payload, delivery.Status,
+		delivery.Attempts, delivery.ResponseCode, delivery.LastError, delivery.CreatedAt, delivery.DeliveredAt)
+	return err
+}
+
+// GetWebhookDelivery retrieves a webhook delivery record by ID
+func (s *PostgreSQLStore) GetWebhookDelivery(id string) (*models.WebhookDelivery, error) {
+	row := s.db.QueryRow(`
+		SELECT id, webhook_id, job_id, event, payload, status, attempts, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE id = $1
+	`, id)
+
+	delivery, err := scanWebhookDelivery(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// DeleteWebhookDeliveries deletes finished deliveries created before the given time
+func (s *PostgreSQLStore) DeleteWebhookDeliveries(before time.Time) (int, error) {
+	result, err := s.db.Exec("DELETE FROM webhook_deliveries WHERE status != $1 AND created_at < $2",
+		models.WebhookDeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// ListWebhookDeliveries returns the most recent deliveries for a webhook (limit <= 0 returns all)
+func (s *PostgreSQLStore) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, job_id, event, payload, status, attempts, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE webhook_id = $1
+		ORDER BY created_at DESC
+	`
+	args := []interface{}{webhookID}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testRateLimitBuckets exercises shared token buckets
+func testRateLimitBuckets(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// 1 token per second, burst 2
+	for i := 0; i < 2; i++ {
+		decision, err := s.TakeRateLimitToken("api:user:u1", 1, 2, now)
+		if err != nil {
+			t.Fatalf("Failed to take token: %v", err)
+		}
+		if !decision.Allowed || decision.Remaining != 1-i || decision.Limit != 2 {
+			t.Errorf("Request %d: unexpected decision %+v", i+1, decision)
+		}
+	}
+	decision, err := s.TakeRateLimitToken("api:user:u1", 1, 2, now)
+	if err != nil || decision.Allowed || decision.RetryAfter != time.Second {
+		t.Errorf("Expected request over the burst to wait 1s, got %+v (%v)", decision, err)
+	}
+
+	// Other keys have their own buckets
+	if decision, _ := s.TakeRateLimitToken("api:user:u2", 1, 2, now); !decision.Allowed {
+		t.Error("Expected another key to be allowed")
+	}
+
+	// Tokens refill over time
+	if decision, _ := s.TakeRateLimitToken("api:user:u1", 1, 2, now.Add(1500*time.Millisecond)); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("Expected a refilled token, got %+v", decision)
+	}
+
+	// Only buckets that have refilled completely are deleted
+	removed, err := s.DeleteFullRateLimitBuckets(now.Add(2 * time.Second))
+	if err != nil || removed != 1 {
+		t.Errorf("Expected the full bucket of u2 to be deleted, got %d (%v)", removed, err)
+	}
+	if decision, _ := s.TakeRateLimitToken("api:user:u1", 1, 2, now.Add(1500*time.Millisecond)); decision.Allowed {
+		t.Error("Expected the partially refilled bucket to be kept")
+	}
+}
+
+func TestMemoryRateLimitBuckets(t *testing.T) {
+	testRateLimitBuckets(t, NewMemoryStore())
+}
+
+func TestSQLiteRateLimitBuckets(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "ratelimit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create SQLite store: %v", err)
+	}
+	defer s.Close()
+	testRateLimitBuckets(t, s)
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testJobResults exercises the job result operations of a store
+func testJobResults(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	jobs := []*models.Job{
+		{ID: "job-1", Scenario: "1080p", Engine: "auto"},
+		{ID: "job-2", Scenario: "1080p", Engine: "ffmpeg"},
+		{ID: "job-3", Scenario: "720p", Engine: "gstreamer"},
+		{ID: "job-4", Scenario: "720p", Engine: "ffmpeg"},
+	}
+	for _, job := range jobs {
+		job.Status = models.JobStatusPending
+		job.Queue = "default"
+		job.Priority = "medium"
+		job.CreatedAt = now
+		if err := s.CreateJob(job); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+
+	results := []*models.JobResult{
+		{JobID: "job-1", NodeID: "n1", Status: models.JobStatusCompleted, CompletedAt: now,
+			Metrics: map[string]interface{}{"fps": 30.0, "bitrate": "4M", "engine": "ffmpeg", "frames": 900.0}, EnergyJoules: 100},
+		{JobID: "job-2", NodeID: "n2", Status: models.JobStatusCompleted, CompletedAt: now.Add(time.Minute),
+			Metrics: map[string]interface{}{"fps": 50.0, "frames": 1500.0}, EnergyJoules: 300, VMAFScore: 95},
+		{JobID: "job-3", NodeID: "n1", Status: models.JobStatusCompleted, CompletedAt: now,
+			Metrics: map[string]interface{}{"fps": "25.5"}},
+		{JobID: "job-4", NodeID: "n1", Status: models.JobStatusFailed, CompletedAt: now, Error: "boom"},
+	}
+	for _, result := range results {
+		if err := s.SaveJobResult(result); err != nil {
+			t.Fatalf("Failed to save result: %v", err)
+		}
+	}
+
+	if err := s.SaveJobResult(&models.JobResult{JobID: "missing", Status: models.JobStatusCompleted}); err != ErrJobNotFound {
+		t.Errorf("Expected ErrJobNotFound for unknown job, got %v", err)
+	}
+
+	got, err := s.GetJobResult("job-1")
+	if err != nil {
+		t.Fatalf("Failed to get result: %v", err)
+	}
+	if got.NodeID != "n1" || got.EnergyJoules != 100 || got.Metrics["bitrate"] != "4M" || !got.CompletedAt.Equal(now) {
+		t.Errorf("Unexpected result: %+v", got)
+	}
+
+	// Saving again replaces the result
+	results[0].EnergyJoules = 200
+	if err := s.SaveJobResult(results[0]); err != nil {
+		t.Fatalf("Failed to replace result: %v", err)
+	}
+
+	byScenario, err := s.GetResultAggregates(models.ResultGroupScenario, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to aggregate: %v", err)
+	}
+	if len(byScenario) != 2 || byScenario[0].Key != "1080p" || byScenario[1].Key != "720p" {
+		t.Fatalf("Unexpected scenario aggregates: %+v", byScenario)
+	}
+	agg := byScenario[0]
+	if agg.Count != 2 || agg.AvgFPS != 40 || agg.AvgBitrateKbps != 4000 || agg.AvgVMAFScore != 95 ||
+		agg.TotalEnergyJoules != 500 || agg.AvgEnergyJoules != 250 || agg.TotalFrames != 2400 {
+		t.Errorf("Unexpected 1080p aggregate: %+v", agg)
+	}
+	if !agg.LastCompletedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected last completion %v, got %v", now.Add(time.Minute), agg.LastCompletedAt)
+	}
+	if byScenario[1].Count != 1 || byScenario[1].AvgFPS != 25.5 {
+		t.Errorf("Unexpected 720p aggregate: %+v", byScenario[1])
+	}
+
+	// The reported engine takes precedence over the requested one
+	byEngine, err := s.GetResultAggregates(models.ResultGroupEngine, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to aggregate: %v", err)
+	}
+	if len(byEngine) != 2 || byEngine[0].Key != "ffmpeg" || byEngine[0].Count != 2 || byEngine[1].Key != "gstreamer" {
+		t.Errorf("Unexpected engine aggregates: %+v", byEngine)
+	}
+
+	// Results completed before the window are excluded
+	recent, err := s.GetResultAggregates(models.ResultGroupNode, now.Add(30*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to aggregate: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Key != "n2" {
+		t.Errorf("Unexpected node aggregates: %+v", recent)
+	}
+
+	if _, err := s.GetResultAggregates("tenant", time.Time{}); err == nil {
+		t.Error("Expected error for invalid grouping")
+	}
+
+	// Deleting the job deletes its result
+	if err := s.DeleteJob("job-1"); err != nil {
+		t.Fatalf("Failed to delete job: %v", err)
+	}
+	if _, err := s.GetJobResult("job-1"); err != ErrJobResultNotFound {
+		t.Errorf("Expected ErrJobResultNotFound after delete, got %v", err)
+	}
+}
+
+func TestMemoryJobResults(t *testing.T) {
+	testJobResults(t, NewMemoryStore())
+}
+
+func TestSQLiteJobResults(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testJobResults(t, s)
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testSecrets exercises tenant-scoped secret storage
+func testSecrets(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, secret := range []*models.Secret{
+		{ID: "sec-1", TenantID: "tenant-a", Name: "stream_key", Description: "primary", Ciphertext: []byte{1, 2, 3}, CreatedBy: "admin", CreatedAt: now, UpdatedAt: now},
+		{ID: "sec-2", TenantID: "tenant-a", Name: "aws_key", Ciphertext: []byte{4}, CreatedAt: now, UpdatedAt: now},
+		{ID: "sec-3", TenantID: "tenant-b", Name: "stream_key", Ciphertext: []byte{5}, CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := s.CreateSecret(secret); err != nil {
+			t.Fatalf("Failed to create secret %s: %v", secret.ID, err)
+		}
+	}
+	duplicate := &models.Secret{ID: "sec-4", TenantID: "tenant-a", Name: "stream_key", Ciphertext: []byte{6}, CreatedAt: now, UpdatedAt: now}
+	if err := s.CreateSecret(duplicate); err != ErrSecretExists {
+		t.Errorf("Expected ErrSecretExists for a duplicate name, got %v", err)
+	}
+
+	secret, err := s.GetSecret("tenant-a", "stream_key")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.ID != "sec-1" || secret.Description != "primary" || !bytes.Equal(secret.Ciphertext, []byte{1, 2, 3}) || !secret.CreatedAt.Equal(now) {
+		t.Errorf("Unexpected secret: %+v", secret)
+	}
+	if _, err := s.GetSecret("tenant-c", "stream_key"); err != ErrSecretNotFound {
+		t.Errorf("Expected ErrSecretNotFound for another tenant, got %v", err)
+	}
+
+	secrets, err := s.ListSecrets("tenant-a")
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(secrets) != 2 || secrets[0].Name != "aws_key" || secrets[1].Name != "stream_key" {
+		t.Errorf("Unexpected secrets: %+v", secrets)
+	}
+
+	secret.Ciphertext = []byte{9, 9}
+	secret.Description = "rotated"
+	secret.UpdatedAt = now.Add(time.Minute)
+	if err := s.UpdateSecret(secret); err != nil {
+		t.Fatalf("Failed to update secret: %v", err)
+	}
+	if updated, _ := s.GetSecret("tenant-a", "stream_key"); !bytes.Equal(updated.Ciphertext, []byte{9, 9}) || updated.Description != "rotated" {
+		t.Errorf("Expected updated secret, got %+v", updated)
+	}
+	if other, _ := s.GetSecret("tenant-b", "stream_key"); !bytes.Equal(other.Ciphertext, []byte{5}) {
+		t.Errorf("Expected other tenant's secret to be unchanged, got %+v", other)
+	}
+
+	if err := s.DeleteSecret("tenant-a", "stream_key"); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	if err := s.DeleteSecret("tenant-a", "stream_key"); err != ErrSecretNotFound {
+		t.Errorf("Expected ErrSecretNotFound deleting twice, got %v", err)
+	}
+	if err := s.UpdateSecret(secret); err != ErrSecretNotFound {
+		t.Errorf("Expected ErrSecretNotFound updating a deleted secret, got %v", err)
+	}
+}
+
+func TestMemorySecrets(t *testing.T) {
+	testSecrets(t, NewMemoryStore())
+}
+
+func TestSQLiteSecrets(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "secrets.db"))
+	if err != nil {
+		t.Fatalf("Failed to create SQLite store: %v", err)
+	}
+	defer s.Close()
+	testSecrets(t, s)
+}
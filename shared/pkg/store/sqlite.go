@@ -76,7 +76,8 @@ func (s *SQLiteStore) initSchema() error {
 		status TEXT NOT NULL,
 		last_heartbeat DATETIME NOT NULL,
 		registered_at DATETIME NOT NULL,
-		current_job_id TEXT
+		current_job_id TEXT,
+		tenant_id TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE TABLE IF NOT EXISTS jobs (
@@ -101,14 +102,197 @@ func (s *SQLiteStore) initSchema() error {
 		error TEXT,
 		failure_reason TEXT,
 		logs TEXT,
-		state_transitions TEXT
+		state_transitions TEXT,
+		tenant_id TEXT NOT NULL DEFAULT 'default'
 	);
 
 	CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_sequence ON jobs(sequence_number);
 	CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
 	CREATE INDEX IF NOT EXISTS idx_jobs_queue_priority ON jobs(queue, priority, created_at);
+	CREATE INDEX IF NOT EXISTS idx_jobs_node_status ON jobs(node_id, status);
 	CREATE INDEX IF NOT EXISTS idx_nodes_status ON nodes(status);
 	CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_address ON nodes(address);
+
+	CREATE TABLE IF NOT EXISTS job_results (
+		job_id TEXT PRIMARY KEY,
+		node_id TEXT,
+		status TEXT NOT NULL,
+		engine TEXT,
+		fps REAL,
+		bitrate_kbps REAL,
+		frames INTEGER,
+		dropped_frames INTEGER,
+		duration_seconds REAL,
+		output_bytes INTEGER,
+		vmaf_score REAL,
+		qoe_score REAL,
+		efficiency_score REAL,
+		energy_joules REAL,
+		progress INTEGER DEFAULT 0,
+		error TEXT,
+		metrics TEXT,
+		analyzer_output TEXT,
+		completed_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_job_results_completed ON job_results(status, completed_at);
+
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT,
+		url TEXT NOT NULL,
+		events TEXT,
+		secret TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		webhook_id TEXT NOT NULL,
+		job_id TEXT,
+		event TEXT NOT NULL,
+		payload TEXT,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_code INTEGER DEFAULT 0,
+		last_error TEXT,
+		created_at DATETIME NOT NULL,
+		delivered_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS tenants (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		display_name TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active',
+		plan TEXT NOT NULL DEFAULT 'free',
+		quotas TEXT NOT NULL,
+		usage TEXT NOT NULL,
+		metadata TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		expires_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS tenant_api_keys (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		key_hash TEXT NOT NULL,
+		key_prefix TEXT NOT NULL UNIQUE,
+		scopes TEXT,
+		status TEXT NOT NULL DEFAULT 'active',
+		created_by TEXT,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		kind TEXT NOT NULL DEFAULT 'api'
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_tenant ON tenant_api_keys(tenant_id);
+
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		email TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		full_name TEXT,
+		role TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active',
+		last_login_at DATETIME,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_users_tenant ON users(tenant_id);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		tenant_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL,
+		ip_address TEXT,
+		user_agent TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+	CREATE TABLE IF NOT EXISTS node_tokens (
+		node_id TEXT PRIMARY KEY,
+		token_hash TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS bootstrap_tokens (
+		id TEXT PRIMARY KEY,
+		token_hash TEXT NOT NULL UNIQUE,
+		description TEXT,
+		created_by TEXT,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME,
+		used_by TEXT
+	);
+
+	CREATE TABLE IF NOT EXISTS issued_certificates (
+		serial TEXT PRIMARY KEY,
+		common_name TEXT NOT NULL,
+		not_before DATETIME NOT NULL,
+		not_after DATETIME NOT NULL,
+		revoked_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_issued_certificates_revoked ON issued_certificates(revoked_at);
+
+	CREATE TABLE IF NOT EXISTS secrets (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		description TEXT,
+		ciphertext BLOB NOT NULL,
+		created_by TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		UNIQUE(tenant_id, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+		key TEXT PRIMARY KEY,
+		tokens REAL NOT NULL,
+		updated_at DATETIME NOT NULL,
+		full_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);
+
+	CREATE TABLE IF NOT EXISTS audit_events (
+		id TEXT PRIMARY KEY,
+		timestamp DATETIME NOT NULL,
+		tenant_id TEXT,
+		actor_type TEXT NOT NULL,
+		actor_id TEXT,
+		action TEXT NOT NULL,
+		target_type TEXT,
+		target_id TEXT,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		source_ip TEXT NOT NULL,
+		forwarded_for TEXT,
+		status_code INTEGER NOT NULL,
+		outcome TEXT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_events_timestamp ON audit_events(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_id, timestamp);
 	`
 
 	_, err := s.db.Exec(schema)
@@ -241,7 +425,45 @@ func (s *SQLiteStore) initSchema() error {
 		}
 	}
 
-	return nil
+	// Migration 8: Add tenant_id column to jobs and nodes (if missing)
+	// Existing jobs belong to the default tenant; existing nodes stay shared
+	for table, defaultTenant := range map[string]string{"jobs": models.DefaultTenantID, "nodes": ""} {
+		var tenantIDExists int
+		row = s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('" + table + "') WHERE name='tenant_id'")
+		if err := row.Scan(&tenantIDExists); err != nil {
+			return fmt.Errorf("failed to check %s.tenant_id column: %w", table, err)
+		}
+		if tenantIDExists == 0 {
+			_, err = s.db.Exec("ALTER TABLE " + table + " ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '" + defaultTenant + "'")
+			if err != nil {
+				return fmt.Errorf("failed to add %s.tenant_id column: %w", table, err)
+			}
+		}
+	}
+
+	// Migration 9: Add kind column to tenant_api_keys (if missing)
+	// Existing keys become API keys, which cannot register workers
+	var keyKindExists int
+	row = s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('tenant_api_keys') WHERE name='kind'")
+	if err := row.Scan(&keyKindExists); err != nil {
+		return fmt.Errorf("failed to check tenant_api_keys.kind column: %w", err)
+	}
+	if keyKindExists == 0 {
+		_, err = s.db.Exec("ALTER TABLE tenant_api_keys ADD COLUMN kind TEXT NOT NULL DEFAULT 'api'")
+		if err != nil {
+			return fmt.Errorf("failed to add tenant_api_keys.kind column: %w", err)
+		}
+	}
+
+	_, err = s.db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_jobs_tenant_status ON jobs(tenant_id, status);
+		CREATE INDEX IF NOT EXISTS idx_nodes_tenant ON nodes(tenant_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant indexes: %w", err)
+	}
+
+	return s.ensureDefaultTenant()
 }
 
 // RegisterNode adds or updates a node in the store
@@ -260,11 +482,11 @@ func (s *SQLiteStore) RegisterNode(node *models.Node) error {
 		INSERT OR REPLACE INTO nodes 
 		(id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type, 
 		 gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat, 
-		 registered_at, current_job_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 registered_at, current_job_id, tenant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, node.ID, node.Name, node.Address, node.Type, node.CPUThreads, node.CPUModel, node.CPULoadPercent,
 		node.HasGPU, node.GPUType, string(gpuCaps), node.RAMTotalBytes, node.RAMFreeBytes,
-		string(labels), node.Status, node.LastHeartbeat, node.RegisteredAt, node.CurrentJobID)
+		string(labels), node.Status, node.LastHeartbeat, node.RegisteredAt, node.CurrentJobID, node.TenantID)
 
 	return err
 }
@@ -277,12 +499,12 @@ func (s *SQLiteStore) GetNode(id string) (*models.Node, error) {
 	err := s.db.QueryRow(`
 		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
 		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
-		       registered_at, current_job_id
+		       registered_at, current_job_id, tenant_id
 		FROM nodes WHERE id = ?
 	`, id).Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads, &node.CPUModel,
 		&node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON, &node.RAMTotalBytes,
 		&node.RAMFreeBytes, &labelsJSON, &node.Status, &node.LastHeartbeat,
-		&node.RegisteredAt, &node.CurrentJobID)
+		&node.RegisteredAt, &node.CurrentJobID, &node.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNodeNotFound
@@ -312,12 +534,12 @@ func (s *SQLiteStore) GetNodeByAddress(address string) (*models.Node, error) {
 	err := s.db.QueryRow(`
 		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
 		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
-		       registered_at, current_job_id
+		       registered_at, current_job_id, tenant_id
 		FROM nodes WHERE address = ?
 	`, address).Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads, &node.CPUModel,
 		&node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON, &node.RAMTotalBytes,
 		&node.RAMFreeBytes, &labelsJSON, &node.Status, &node.LastHeartbeat,
-		&node.RegisteredAt, &node.CurrentJobID)
+		&node.RegisteredAt, &node.CurrentJobID, &node.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNodeNotFound
@@ -344,7 +566,7 @@ func (s *SQLiteStore) GetAllNodes() []*models.Node {
 	rows, err := s.db.Query(`
 		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
 		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
-		       registered_at, current_job_id
+		       registered_at, current_job_id, tenant_id
 		FROM nodes
 	`)
 	if err != nil {
@@ -360,7 +582,7 @@ func (s *SQLiteStore) GetAllNodes() []*models.Node {
 		if err := rows.Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads,
 			&node.CPUModel, &node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON,
 			&node.RAMTotalBytes, &node.RAMFreeBytes, &labelsJSON, &node.Status,
-			&node.LastHeartbeat, &node.RegisteredAt, &node.CurrentJobID); err != nil {
+			&node.LastHeartbeat, &node.RegisteredAt, &node.CurrentJobID, &node.TenantID); err != nil {
 			continue
 		}
 
@@ -422,7 +644,7 @@ func (s *SQLiteStore) UpdateNodeHeartbeat(id string) error {
 	return nil
 }
 
-// DeleteNode removes a node from the store
+// DeleteNode removes a node and its identity token from the store
 func (s *SQLiteStore) DeleteNode(id string) error {
 	result, err := s.db.Exec(`
 		DELETE FROM nodes WHERE id = ?
@@ -440,6 +662,10 @@ func (s *SQLiteStore) DeleteNode(id string) error {
 		return ErrNodeNotFound
 	}
 
+	if _, err := s.db.Exec("DELETE FROM node_tokens WHERE node_id = ?", id); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -465,6 +691,9 @@ func (s *SQLiteStore) CreateJob(job *models.Job) error {
 	if job.Engine == "" {
 		job.Engine = "auto"
 	}
+	if job.TenantID == "" {
+		job.TenantID = models.DefaultTenantID
+	}
 
 	// Generate sequence number if not set (protected by mutex for concurrency)
 	// Keep mutex locked until after INSERT to prevent race condition
@@ -488,11 +717,11 @@ func (s *SQLiteStore) CreateJob(job *models.Job) error {
 	_, err = s.db.Exec(`
 		INSERT INTO jobs 
 		(id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id, 
-		 created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, tenant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, job.ID, job.SequenceNumber, job.Scenario, job.Confidence, job.Engine, string(params), job.Status, job.Queue,
 		job.Priority, job.Progress, job.NodeID, job.CreatedAt, job.StartedAt, job.LastActivityAt,
-		job.CompletedAt, job.RetryCount, job.Error, string(job.FailureReason), job.Logs, string(transitions))
+		job.CompletedAt, job.RetryCount, job.Error, string(job.FailureReason), job.Logs, string(transitions), job.TenantID)
 
 	return err
 }
@@ -505,11 +734,11 @@ func (s *SQLiteStore) GetJob(id string) (*models.Job, error) {
 
 	err := s.db.QueryRow(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, failure_reason, logs, state_transitions, tenant_id
 		FROM jobs WHERE id = ?
 	`, id).Scan(&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON, &job.Status,
 		&job.Queue, &job.Priority, &job.Progress, &nodeIDNull, &job.CreatedAt, 
-		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &failureReasonNull, &logsNull, &transitionsJSON)
+		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &failureReasonNull, &logsNull, &transitionsJSON, &job.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrJobNotFound
@@ -566,11 +795,11 @@ func (s *SQLiteStore) GetJobBySequenceNumber(seqNum int) (*models.Job, error) {
 
 	err := s.db.QueryRow(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions, tenant_id
 		FROM jobs WHERE sequence_number = ?
 	`, seqNum).Scan(&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON, &job.Status,
 		&job.Queue, &job.Priority, &job.Progress, &nodeIDNull, &job.CreatedAt, 
-		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &logsNull, &transitionsJSON)
+		&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &logsNull, &transitionsJSON, &job.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrJobNotFound
@@ -618,7 +847,7 @@ func (s *SQLiteStore) GetJobBySequenceNumber(seqNum int) (*models.Job, error) {
 func (s *SQLiteStore) GetAllJobs() []*models.Job {
 	rows, err := s.db.Query(`
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions, tenant_id
 		FROM jobs ORDER BY sequence_number DESC
 	`)
 	if err != nil {
@@ -634,7 +863,7 @@ func (s *SQLiteStore) GetAllJobs() []*models.Job {
 
 		if err := rows.Scan(&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON,
 			&job.Status, &job.Queue, &job.Priority, &job.Progress, &nodeIDNull, &job.CreatedAt,
-			&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &logsNull, &transitionsJSON); err != nil {
+			&startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error, &logsNull, &transitionsJSON, &job.TenantID); err != nil {
 			continue
 		}
 
@@ -685,12 +914,12 @@ func (s *SQLiteStore) GetNextJob(nodeID string) (*models.Job, error) {
 	}
 	defer tx.Rollback()
 
-	// Get node capabilities for GPU filtering
+	// Get node capabilities for GPU filtering and its tenant for isolation
 	var node models.Node
 	var gpuCapsJSON string
 	err = tx.QueryRow(`
-		SELECT has_gpu, gpu_capabilities FROM nodes WHERE id = ?
-	`, nodeID).Scan(&node.HasGPU, &gpuCapsJSON)
+		SELECT has_gpu, gpu_capabilities, tenant_id FROM nodes WHERE id = ?
+	`, nodeID).Scan(&node.HasGPU, &gpuCapsJSON, &node.TenantID)
 	
 	if err != nil {
 		return nil, fmt.Errorf("node not found: %w", err)
@@ -700,6 +929,13 @@ func (s *SQLiteStore) GetNextJob(nodeID string) (*models.Job, error) {
 		json.Unmarshal([]byte(gpuCapsJSON), &node.GPUCapabilities)
 	}
 
+	// Tenants at their concurrent job quota are skipped until a job finishes
+	capped, err := cappedTenantIDs(tx)
+	if err != nil {
+		return nil, err
+	}
+	cappedCond, cappedArgs := notInClause("tenant_id", capped)
+
 	// Select job with priority: queue (live>default>batch), priority (high>medium>low), then FIFO
 	// Queue priority: live=3, default=2, batch=1
 	// Priority: high=3, medium=2, low=1
@@ -709,9 +945,9 @@ func (s *SQLiteStore) GetNextJob(nodeID string) (*models.Job, error) {
 
 	query := `
 		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions, tenant_id
 		FROM jobs 
-		WHERE status IN (?, ?)
+		WHERE status IN (?, ?) AND (? = '' OR tenant_id = ?) AND ` + cappedCond + `
 		ORDER BY 
 			CASE queue 
 				WHEN 'live' THEN 3 
@@ -729,10 +965,12 @@ func (s *SQLiteStore) GetNextJob(nodeID string) (*models.Job, error) {
 		LIMIT 1
 	`
 
-	err = tx.QueryRow(query, models.JobStatusPending, models.JobStatusQueued).Scan(
+	// Nodes dedicated to a tenant only take that tenant's jobs
+	args := append([]interface{}{models.JobStatusPending, models.JobStatusQueued, node.TenantID, node.TenantID}, cappedArgs...)
+	err = tx.QueryRow(query, args...).Scan(
 		&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON, &job.Status, &job.Queue,
 		&job.Priority, &job.Progress, &nodeIDNull, &job.CreatedAt, &startedAt, &lastActivityAt, &completedAt,
-		&job.RetryCount, &job.Error, &logsNull, &transitionsJSON)
+		&job.RetryCount, &job.Error, &logsNull, &transitionsJSON, &job.TenantID)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrJobNotFound
@@ -1103,7 +1341,7 @@ func (s *SQLiteStore) CancelJob(id string) error {
 func (s *SQLiteStore) GetQueuedJobs(queue string, priority string) []*models.Job {
 	query := `
 		SELECT id, scenario, confidence, engine, parameters, status, queue, priority, progress, node_id,
-		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions
+		       created_at, started_at, last_activity_at, completed_at, retry_count, error, logs, state_transitions, tenant_id
 		FROM jobs 
 		WHERE status IN (?, ?) AND queue = ?
 	`
@@ -1131,7 +1369,7 @@ func (s *SQLiteStore) GetQueuedJobs(queue string, priority string) []*models.Job
 		if err := rows.Scan(&job.ID, &job.Scenario, &job.Confidence, &job.Engine, &paramsJSON,
 			&job.Status, &job.Queue, &job.Priority, &job.Progress, &nodeIDNull,
 			&job.CreatedAt, &startedAt, &lastActivityAt, &completedAt, &job.RetryCount, &job.Error,
-			&logsNull, &transitionsJSON); err != nil {
+			&logsNull, &transitionsJSON, &job.TenantID); err != nil {
 			continue
 		}
 
@@ -1184,6 +1422,9 @@ func (s *SQLiteStore) DeleteJob(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, err := s.db.Exec("DELETE FROM job_results WHERE job_id = ?", id); err != nil {
+		return err
+	}
 	_, err := s.db.Exec("DELETE FROM jobs WHERE id = ?", id)
 	return err
 }
@@ -1373,7 +1614,7 @@ func (s *SQLiteStore) scanJobRow(scanner interface{
 		&job.ID, &job.SequenceNumber, &job.Scenario, &job.Confidence, &job.Engine,
 		&paramsJSON, &job.Status, &job.Queue, &job.Priority, &job.Progress,
 		&nodeIDNull, &job.CreatedAt, &startedAt, &lastActivityAt, &completedAt,
-		&job.RetryCount, &job.Error, &logsNull, &transitionsJSON,
+		&job.RetryCount, &job.Error, &logsNull, &transitionsJSON, &job.TenantID,
 	)
 
 	if err != nil {
@@ -1427,47 +1668,6 @@ func (s *SQLiteStore) scanJobRow(scanner interface{
 }
 
 
-// Tenant operations (multi-tenancy) - Stub implementations for backward compatibility
-func (s *SQLiteStore) CreateTenant(tenant *models.Tenant) error {
-return fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) GetTenant(id string) (*models.Tenant, error) {
-return nil, fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) GetTenantByName(name string) (*models.Tenant, error) {
-return nil, fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) ListTenants() ([]*models.Tenant, error) {
-return nil, fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) UpdateTenant(tenant *models.Tenant) error {
-return fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) DeleteTenant(id string) error {
-return fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) UpdateTenantUsage(id string, usage *models.TenantUsage) error {
-return fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) GetTenantStats(id string) (*models.TenantUsage, error) {
-return nil, fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) GetJobsByTenant(tenantID string) ([]*models.Job, error) {
-return nil, fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
-func (s *SQLiteStore) GetNodesByTenant(tenantID string) ([]*models.Node, error) {
-return nil, fmt.Errorf("multi-tenancy not supported in SQLite store")
-}
-
 // GetJobMetrics returns aggregated job statistics optimized for metrics endpoint
 // This avoids loading all jobs into memory
 func (s *SQLiteStore) GetJobMetrics() (*JobMetrics, error) {
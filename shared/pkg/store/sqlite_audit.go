@@ -0,0 +1,112 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// auditEventColumns is the column list shared by the SQLite and PostgreSQL audit queries
+const auditEventColumns = `id, timestamp, tenant_id, actor_type, actor_id, action, target_type, target_id,
+	method, path, request_id, source_ip, forwarded_for, status_code, outcome`
+
+// CreateAuditEvent appends an event to the audit log
+func (s *SQLiteStore) CreateAuditEvent(event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO audit_events (`+auditEventColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, auditEventArgs(event)...)
+	return err
+}
+
+// ListAuditEvents returns the events selected by filter, newest first
+func (s *SQLiteStore) ListAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	query, args := auditEventQuery(filter, func(int) string { return "?" })
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEvents(rows)
+}
+
+// auditEventQuery builds the query for a filter (shared by SQLite and PostgreSQL stores).
+// placeholder returns the dialect's n-th bind parameter ("?" or "$n").
+func auditEventQuery(filter models.AuditFilter, placeholder func(n int) string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, placeholder(len(args))))
+	}
+
+	if filter.TenantID != "" {
+		add("tenant_id = %s", filter.TenantID)
+	}
+	if filter.ActorType != "" {
+		add("actor_type = %s", filter.ActorType)
+	}
+	if filter.ActorID != "" {
+		add("actor_id = %s", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = %s", filter.Action)
+	}
+	if filter.TargetID != "" {
+		add("target_id = %s", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		add("outcome = %s", filter.Outcome)
+	}
+	if !filter.Since.IsZero() {
+		add("timestamp >= %s", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		add("timestamp < %s", filter.Until.UTC())
+	}
+
+	query := `SELECT ` + auditEventColumns + ` FROM audit_events`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY timestamp DESC, id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += ` LIMIT ` + placeholder(len(args))
+	}
+	return query, args
+}
+
+// auditEventArgs returns the auditEventColumns values of an event (shared by SQLite and PostgreSQL stores)
+func auditEventArgs(event *models.AuditEvent) []interface{} {
+	return []interface{}{
+		event.ID, event.Timestamp.UTC(), event.TenantID, event.ActorType, event.ActorID, event.Action,
+		event.TargetType, event.TargetID, event.Method, event.Path, event.RequestID, event.SourceIP,
+		event.ForwardedFor, event.StatusCode, event.Outcome,
+	}
+}
+
+// scanAuditEvents scans and closes a set of audit event rows (shared by SQLite and PostgreSQL stores)
+func scanAuditEvents(rows *sql.Rows) ([]*models.AuditEvent, error) {
+	defer rows.Close()
+
+	events := make([]*models.AuditEvent, 0)
+	for rows.Next() {
+		var event models.AuditEvent
+		var tenantID, actorID, targetType, targetID, forwardedFor sql.NullString
+		if err := rows.Scan(&event.ID, &event.Timestamp, &tenantID, &event.ActorType, &actorID, &event.Action,
+			&targetType, &targetID, &event.Method, &event.Path, &event.RequestID, &event.SourceIP,
+			&forwardedFor, &event.StatusCode, &event.Outcome); err != nil {
+			return nil, err
+		}
+		event.TenantID = tenantID.String
+		event.ActorID = actorID.String
+		event.TargetType = targetType.String
+		event.TargetID = targetID.String
+		event.ForwardedFor = forwardedFor.String
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// sqliteRequiredTables must exist in a SQLite backup for it to be restorable
+var sqliteRequiredTables = []string{"nodes", "jobs"}
+
+// BackupFormat returns the format produced by Backup
+func (s *SQLiteStore) BackupFormat() string {
+	return BackupFormatSQLite
+}
+
+// Backup writes a consistent snapshot of the database using the SQLite online backup API
+func (s *SQLiteStore) Backup(w io.Writer) error {
+	tmp, err := os.CreateTemp("", "ffrtmp-backup-*.db")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	// Copy live database -> temp file
+	if err := copySQLiteDatabase(s.db, tmpPath, false); err != nil {
+		return err
+	}
+
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// ValidateBackup checks that r contains an intact SQLite database with the expected tables
+func (s *SQLiteStore) ValidateBackup(r io.Reader) error {
+	path, err := spoolBackup(r)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	return validateSQLiteFile(path)
+}
+
+// Restore replaces the database contents with a validated backup using the online backup API
+func (s *SQLiteStore) Restore(r io.Reader) error {
+	path, err := spoolBackup(r)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	if err := validateSQLiteFile(path); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	// Copy temp file -> live database
+	err = copySQLiteDatabase(s.db, path, true)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Bring backups from older versions up to the current schema
+	return s.initSchema()
+}
+
+// copySQLiteDatabase copies between db and the database file at path.
+// If restore is false db is copied to path, otherwise path is copied into db.
+func copySQLiteDatabase(db *sql.DB, path string, restore bool) error {
+	ctx := context.Background()
+
+	other, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer other.Close()
+
+	liveConn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer liveConn.Close()
+
+	otherConn, err := other.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer otherConn.Close()
+
+	return liveConn.Raw(func(liveRaw interface{}) error {
+		return otherConn.Raw(func(otherRaw interface{}) error {
+			live, ok := liveRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", liveRaw)
+			}
+			otherLite, ok := otherRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", otherRaw)
+			}
+
+			src, dest := live, otherLite
+			if restore {
+				src, dest = otherLite, live
+			}
+
+			bk, err := dest.Backup("main", src, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+			// Copy all pages in one step so the snapshot is consistent
+			if _, err := bk.Step(-1); err != nil {
+				bk.Finish()
+				return fmt.Errorf("backup step failed: %w", err)
+			}
+			return bk.Finish()
+		})
+	})
+}
+
+// validateSQLiteFile runs an integrity check and verifies the required tables exist
+func validateSQLiteFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(f, header)
+	f.Close()
+	if err != nil {
+		return invalidBackup("file too short")
+	}
+	if format, _ := DetectBackupFormat(header); format != BackupFormatSQLite {
+		return invalidBackup("not a SQLite database")
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return invalidBackup("integrity check failed: %v", err)
+	}
+	if result != "ok" {
+		return invalidBackup("integrity check failed: %s", result)
+	}
+
+	for _, table := range sqliteRequiredTables {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return invalidBackup("missing table %s", table)
+		}
+	}
+	return nil
+}
+
+// spoolBackup copies a backup stream to a temp file so it can be opened as a database
+func spoolBackup(r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "ffrtmp-restore-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// nodeTokenColumns is the column list shared by the SQLite and PostgreSQL node token queries
+const nodeTokenColumns = `node_id, token_hash, created_at, expires_at`
+
+// SaveNodeToken stores the identity token of a node, replacing any previous token
+func (s *SQLiteStore) SaveNodeToken(token *models.NodeToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM nodes WHERE id = ?", token.NodeID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrNodeNotFound
+	}
+
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO node_tokens (`+nodeTokenColumns+`) VALUES (?, ?, ?, ?)`, nodeTokenArgs(token)...)
+	return err
+}
+
+// GetNodeToken retrieves the identity token of a node
+func (s *SQLiteStore) GetNodeToken(nodeID string) (*models.NodeToken, error) {
+	token, err := scanNodeToken(s.db.QueryRow(`SELECT `+nodeTokenColumns+` FROM node_tokens WHERE node_id = ?`, nodeID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNodeTokenNotFound
+	}
+	return token, err
+}
+
+// DeleteNodeToken removes the identity token of a node
+func (s *SQLiteStore) DeleteNodeToken(nodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM node_tokens WHERE node_id = ?", nodeID)
+	if err != nil {
+		return err
+	}
+	return nodeTokenRowsAffected(result)
+}
+
+// nodeTokenArgs returns the nodeTokenColumns values of a node token (shared by SQLite and PostgreSQL stores)
+func nodeTokenArgs(token *models.NodeToken) []interface{} {
+	var expiresAt interface{}
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.UTC()
+	}
+	return []interface{}{token.NodeID, token.TokenHash, token.CreatedAt.UTC(), expiresAt}
+}
+
+// scanNodeToken scans a node token row (shared by SQLite and PostgreSQL stores)
+func scanNodeToken(scanner interface{ Scan(...interface{}) error }) (*models.NodeToken, error) {
+	var token models.NodeToken
+	var expiresAt sql.NullTime
+
+	if err := scanner.Scan(&token.NodeID, &token.TokenHash, &token.CreatedAt, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	return &token, nil
+}
+
+// nodeTokenRowsAffected maps a delete that matched no rows to ErrNodeTokenNotFound
+func nodeTokenRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNodeTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// bootstrapTokenColumns is the column list shared by the SQLite and PostgreSQL bootstrap token queries
+const bootstrapTokenColumns = `id, token_hash, description, created_by, created_at, expires_at, used_at, used_by`
+
+// certificateColumns is the column list shared by the SQLite and PostgreSQL certificate queries
+const certificateColumns = `serial, common_name, not_before, not_after, revoked_at`
+
+// CreateBootstrapToken stores a new bootstrap token
+func (s *SQLiteStore) CreateBootstrapToken(token *models.BootstrapToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO bootstrap_tokens (`+bootstrapTokenColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		bootstrapTokenArgs(token)...)
+	return err
+}
+
+// ListBootstrapTokens returns all bootstrap tokens, newest first
+func (s *SQLiteStore) ListBootstrapTokens() ([]*models.BootstrapToken, error) {
+	rows, err := s.db.Query(`SELECT ` + bootstrapTokenColumns + ` FROM bootstrap_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanBootstrapTokens(rows)
+}
+
+// UseBootstrapToken marks an unused, unexpired token as used by the named worker and returns it.
+// It returns ErrBootstrapTokenNotFound if no such token exists, so each token is only used once.
+func (s *SQLiteStore) UseBootstrapToken(tokenHash, usedBy string, usedAt time.Time) (*models.BootstrapToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Timestamps are stored in UTC, which SQLite compares as text
+	result, err := s.db.Exec(`
+		UPDATE bootstrap_tokens SET used_at = ?, used_by = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+	`, usedAt.UTC(), usedBy, tokenHash, usedAt.UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := rowsAffectedOr(result, ErrBootstrapTokenNotFound); err != nil {
+		return nil, err
+	}
+
+	return scanBootstrapToken(s.db.QueryRow(`SELECT `+bootstrapTokenColumns+` FROM bootstrap_tokens WHERE token_hash = ?`, tokenHash))
+}
+
+// SaveCertificate records an issued certificate
+func (s *SQLiteStore) SaveCertificate(cert *models.IssuedCertificate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO issued_certificates (`+certificateColumns+`) VALUES (?, ?, ?, ?, ?)`, certificateArgs(cert)...)
+	return err
+}
+
+// GetCertificate retrieves an issued certificate by serial number
+func (s *SQLiteStore) GetCertificate(serial string) (*models.IssuedCertificate, error) {
+	cert, err := scanCertificate(s.db.QueryRow(`SELECT `+certificateColumns+` FROM issued_certificates WHERE serial = ?`, serial))
+	if err == sql.ErrNoRows {
+		return nil, ErrCertificateNotFound
+	}
+	return cert, err
+}
+
+// ListCertificates returns issued certificates, newest first, optionally only revoked ones
+func (s *SQLiteStore) ListCertificates(revokedOnly bool) ([]*models.IssuedCertificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM issued_certificates`
+	if revokedOnly {
+		query += ` WHERE revoked_at IS NOT NULL`
+	}
+	rows, err := s.db.Query(query + ` ORDER BY not_before DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCertificates(rows)
+}
+
+// RevokeCertificate marks a certificate as revoked. Revoking it again keeps the original revocation time.
+func (s *SQLiteStore) RevokeCertificate(serial string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`UPDATE issued_certificates SET revoked_at = COALESCE(revoked_at, ?) WHERE serial = ?`,
+		revokedAt.UTC(), serial)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOr(result, ErrCertificateNotFound)
+}
+
+// bootstrapTokenArgs returns the bootstrapTokenColumns values of a token (shared by SQLite and PostgreSQL stores)
+func bootstrapTokenArgs(token *models.BootstrapToken) []interface{} {
+	var usedAt interface{}
+	if token.UsedAt != nil {
+		usedAt = token.UsedAt.UTC()
+	}
+	return []interface{}{
+		token.ID, token.TokenHash, token.Description, token.CreatedBy,
+		token.CreatedAt.UTC(), token.ExpiresAt.UTC(), usedAt, token.UsedBy,
+	}
+}
+
+// scanBootstrapToken scans a bootstrap token row (shared by SQLite and PostgreSQL stores)
+func scanBootstrapToken(scanner interface{ Scan(...interface{}) error }) (*models.BootstrapToken, error) {
+	var token models.BootstrapToken
+	var description, createdBy, usedBy sql.NullString
+	var usedAt sql.NullTime
+
+	if err := scanner.Scan(&token.ID, &token.TokenHash, &description, &createdBy,
+		&token.CreatedAt, &token.ExpiresAt, &usedAt, &usedBy); err != nil {
+		return nil, err
+	}
+
+	token.Description = description.String
+	token.CreatedBy = createdBy.String
+	token.UsedBy = usedBy.String
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return &token, nil
+}
+
+// scanBootstrapTokens scans and closes a set of bootstrap token rows (shared by SQLite and PostgreSQL stores)
+func scanBootstrapTokens(rows *sql.Rows) ([]*models.BootstrapToken, error) {
+	defer rows.Close()
+
+	tokens := make([]*models.BootstrapToken, 0)
+	for rows.Next() {
+		token, err := scanBootstrapToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// certificateArgs returns the certificateColumns values of a certificate (shared by SQLite and PostgreSQL stores)
+func certificateArgs(cert *models.IssuedCertificate) []interface{} {
+	var revokedAt interface{}
+	if cert.RevokedAt != nil {
+		revokedAt = cert.RevokedAt.UTC()
+	}
+	return []interface{}{cert.Serial, cert.CommonName, cert.NotBefore.UTC(), cert.NotAfter.UTC(), revokedAt}
+}
+
+// scanCertificate scans a certificate row (shared by SQLite and PostgreSQL stores)
+func scanCertificate(scanner interface{ Scan(...interface{}) error }) (*models.IssuedCertificate, error) {
+	var cert models.IssuedCertificate
+	var revokedAt sql.NullTime
+
+	if err := scanner.Scan(&cert.Serial, &cert.CommonName, &cert.NotBefore, &cert.NotAfter, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		cert.RevokedAt = &revokedAt.Time
+	}
+	return &cert, nil
+}
+
+// scanCertificates scans and closes a set of certificate rows (shared by SQLite and PostgreSQL stores)
+func scanCertificates(rows *sql.Rows) ([]*models.IssuedCertificate, error) {
+	defer rows.Close()
+
+	certs := make([]*models.IssuedCertificate, 0)
+	for rows.Next() {
+		cert, err := scanCertificate(rows)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, rows.Err()
+}
+
+// rowsAffectedOr maps a statement that matched no rows to notFound
+func rowsAffectedOr(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+	return nil
+}
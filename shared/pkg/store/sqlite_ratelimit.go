@@ -0,0 +1,70 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// rateLimitBucketColumns is the column list shared by the SQLite and PostgreSQL rate limit queries
+const rateLimitBucketColumns = `key, tokens, updated_at, full_at`
+
+// TakeRateLimitToken takes a token from the bucket for key, refilled at rate tokens per second up to burst
+func (s *SQLiteStore) TakeRateLimitToken(key string, rate float64, burst int, now time.Time) (models.RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.RateLimitDecision{}, err
+	}
+	defer tx.Rollback()
+
+	bucket, err := scanRateLimitBucket(tx.QueryRow(`SELECT `+rateLimitBucketColumns+` FROM rate_limit_buckets WHERE key = ?`, key))
+	if err == sql.ErrNoRows {
+		bucket, err = models.NewRateLimitBucket(key, burst, now), nil
+	}
+	if err != nil {
+		return models.RateLimitDecision{}, err
+	}
+
+	decision := bucket.Take(rate, burst, now)
+	if !decision.Allowed {
+		return decision, nil
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO rate_limit_buckets (`+rateLimitBucketColumns+`) VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at, full_at = excluded.full_at
+	`, rateLimitBucketArgs(bucket)...); err != nil {
+		return models.RateLimitDecision{}, err
+	}
+	return decision, tx.Commit()
+}
+
+// DeleteFullRateLimitBuckets deletes buckets that were full before the given time
+func (s *SQLiteStore) DeleteFullRateLimitBuckets(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`DELETE FROM rate_limit_buckets WHERE full_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// rateLimitBucketArgs returns the values of rateLimitBucketColumns for bucket
+func rateLimitBucketArgs(bucket *models.RateLimitBucket) []interface{} {
+	return []interface{}{bucket.Key, bucket.Tokens, bucket.UpdatedAt.UTC(), bucket.FullAt.UTC()}
+}
+
+// scanRateLimitBucket scans a row selected with rateLimitBucketColumns
+func scanRateLimitBucket(row *sql.Row) (*models.RateLimitBucket, error) {
+	var bucket models.RateLimitBucket
+	if err := row.Scan(&bucket.Key, &bucket.Tokens, &bucket.UpdatedAt, &bucket.FullAt); err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// jobResultColumns is the column list used by SaveJobResult and GetJobResult
+const jobResultColumns = `job_id, node_id, status, engine, fps, bitrate_kbps, frames, dropped_frames,
+	duration_seconds, output_bytes, vmaf_score, qoe_score, efficiency_score, energy_joules,
+	progress, error, metrics, analyzer_output, completed_at`
+
+// SaveJobResult stores (or replaces) the final result of a job
+func (s *SQLiteStore) SaveJobResult(result *models.JobResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM jobs WHERE id = ?", result.JobID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrJobNotFound
+	}
+
+	args, err := jobResultArgs(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT OR REPLACE INTO job_results (`+jobResultColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, args...)
+	return err
+}
+
+// GetJobResult retrieves the stored result of a job
+func (s *SQLiteStore) GetJobResult(jobID string) (*models.JobResult, error) {
+	row := s.db.QueryRow("SELECT "+jobResultColumns+" FROM job_results WHERE job_id = ?", jobID)
+
+	result, err := scanJobResult(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobResultNotFound
+	}
+	return result, err
+}
+
+// GetResultAggregates summarizes completed job results by scenario, engine or node
+func (s *SQLiteStore) GetResultAggregates(groupBy string, since time.Time) ([]*models.ResultAggregate, error) {
+	query, err := resultAggregateQuery(groupBy, "?")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(query, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]*models.ResultAggregate, 0)
+	for rows.Next() {
+		var lastCompleted sql.NullString
+		agg, err := scanResultAggregate(rows, groupBy, &lastCompleted)
+		if err != nil {
+			return nil, err
+		}
+		// MAX() loses the column type in SQLite, so the timestamp comes back as text
+		if lastCompleted.Valid {
+			for _, layout := range sqlite3.SQLiteTimestampFormats {
+				if t, err := time.Parse(layout, lastCompleted.String); err == nil {
+					agg.LastCompletedAt = t
+					break
+				}
+			}
+		}
+		aggregates = append(aggregates, agg)
+	}
+	return aggregates, rows.Err()
+}
+
+// jobResultArgs returns the values for jobResultColumns (shared by SQLite and PostgreSQL stores).
+// Unreported metrics are stored as NULL so that AVG() ignores them.
+func jobResultArgs(result *models.JobResult) ([]interface{}, error) {
+	metrics, err := marshalJSON(result.Metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+	analyzerOutput, err := marshalJSON(result.AnalyzerOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal analyzer output: %w", err)
+	}
+
+	m := result.ExtractMetrics()
+	completedAt := result.CompletedAt
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+
+	return []interface{}{
+		result.JobID, result.NodeID, string(result.Status), m.Engine,
+		nullIfZero(m.FPS), nullIfZero(m.BitrateKbps), nullIfZero(float64(m.Frames)), nullIfZero(float64(m.DroppedFrames)),
+		nullIfZero(m.DurationSeconds), nullIfZero(float64(m.OutputBytes)),
+		nullIfZero(result.VMAFScore), nullIfZero(result.QoEScore), nullIfZero(result.EfficiencyScore), nullIfZero(result.EnergyJoules),
+		result.Progress, result.Error, string(metrics), string(analyzerOutput), completedAt.UTC(),
+	}, nil
+}
+
+// nullIfZero maps unreported (zero or negative) metrics to NULL
+func nullIfZero(v float64) interface{} {
+	if v <= 0 {
+		return nil
+	}
+	return v
+}
+
+// scanJobResult scans a job_results row selected with jobResultColumns.
+// The typed columns are derived from the metrics blob, so they are not read back.
+func scanJobResult(scanner interface{ Scan(...interface{}) error }) (*models.JobResult, error) {
+	var result models.JobResult
+	var nodeID, engine, errMsg, metrics, analyzerOutput sql.NullString
+	var fps, bitrate, duration, vmaf, qoe, efficiency, energy sql.NullFloat64
+	var frames, dropped, outputBytes, progress sql.NullInt64
+
+	if err := scanner.Scan(&result.JobID, &nodeID, &result.Status, &engine, &fps, &bitrate, &frames, &dropped,
+		&duration, &outputBytes, &vmaf, &qoe, &efficiency, &energy,
+		&progress, &errMsg, &metrics, &analyzerOutput, &result.CompletedAt); err != nil {
+		return nil, err
+	}
+
+	result.NodeID = nodeID.String
+	result.Error = errMsg.String
+	result.Progress = int(progress.Int64)
+	result.VMAFScore = vmaf.Float64
+	result.QoEScore = qoe.Float64
+	result.EfficiencyScore = efficiency.Float64
+	result.EnergyJoules = energy.Float64
+
+	if metrics.Valid && metrics.String != "" && metrics.String != "null" {
+		if err := unmarshalJSON([]byte(metrics.String), &result.Metrics); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metrics: %w", err)
+		}
+	}
+	if analyzerOutput.Valid && analyzerOutput.String != "" && analyzerOutput.String != "null" {
+		if err := unmarshalJSON([]byte(analyzerOutput.String), &result.AnalyzerOutput); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal analyzer output: %w", err)
+		}
+	}
+	return &result, nil
+}
+
+// resultAggregateQuery builds the aggregate query for a grouping (shared by SQLite and PostgreSQL stores).
+// placeholder is the dialect's first bind parameter ("?" or "$1").
+func resultAggregateQuery(groupBy, placeholder string) (string, error) {
+	var key string
+	switch groupBy {
+	case models.ResultGroupScenario:
+		key = "COALESCE(j.scenario, '')"
+	case models.ResultGroupEngine:
+		key = "COALESCE(NULLIF(r.engine, ''), j.engine, '')"
+	case models.ResultGroupNode:
+		key = "COALESCE(r.node_id, '')"
+	default:
+		return "", fmt.Errorf("invalid result grouping %q", groupBy)
+	}
+
+	return `
+		SELECT ` + key + ` AS group_key, COUNT(*),
+			AVG(r.fps), AVG(r.bitrate_kbps), AVG(r.duration_seconds),
+			AVG(r.vmaf_score), AVG(r.qoe_score), AVG(r.efficiency_score), AVG(r.energy_joules),
+			COALESCE(SUM(r.energy_joules), 0), COALESCE(SUM(r.frames), 0), COALESCE(SUM(r.dropped_frames), 0),
+			MAX(r.completed_at)
+		FROM job_results r
+		LEFT JOIN jobs j ON j.id = r.job_id
+		WHERE r.status = 'completed' AND r.completed_at >= ` + placeholder + `
+		GROUP BY group_key
+		ORDER BY group_key
+	`, nil
+}
+
+// scanResultAggregate scans a row of resultAggregateQuery into an aggregate.
+// lastCompleted receives MAX(completed_at), whose type depends on the driver.
+func scanResultAggregate(scanner interface{ Scan(...interface{}) error }, groupBy string, lastCompleted interface{}) (*models.ResultAggregate, error) {
+	agg := &models.ResultAggregate{GroupBy: groupBy}
+	var fps, bitrate, duration, vmaf, qoe, efficiency, energy sql.NullFloat64
+	var totalFrames, totalDropped float64
+
+	if err := scanner.Scan(&agg.Key, &agg.Count, &fps, &bitrate, &duration, &vmaf, &qoe, &efficiency, &energy,
+		&agg.TotalEnergyJoules, &totalFrames, &totalDropped, lastCompleted); err != nil {
+		return nil, err
+	}
+
+	agg.AvgFPS = fps.Float64
+	agg.AvgBitrateKbps = bitrate.Float64
+	agg.AvgDurationSeconds = duration.Float64
+	agg.AvgVMAFScore = vmaf.Float64
+	agg.AvgQoEScore = qoe.Float64
+	agg.AvgEfficiencyScore = efficiency.Float64
+	agg.AvgEnergyJoules = energy.Float64
+	agg.TotalFrames = int64(totalFrames)
+	agg.TotalDroppedFrames = int64(totalDropped)
+	return agg, nil
+}
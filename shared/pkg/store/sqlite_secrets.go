@@ -0,0 +1,109 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// secretColumns is the column list shared by the SQLite and PostgreSQL secret queries
+const secretColumns = `id, tenant_id, name, description, ciphertext, created_by, created_at, updated_at`
+
+// CreateSecret stores a new secret. It returns ErrSecretExists if the tenant
+// already has a secret with the same name.
+func (s *SQLiteStore) CreateSecret(secret *models.Secret) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM secrets WHERE tenant_id = ? AND name = ?", secret.TenantID, secret.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrSecretExists
+	}
+
+	_, err := s.db.Exec(`INSERT INTO secrets (`+secretColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, secretArgs(secret)...)
+	return err
+}
+
+// GetSecret retrieves a tenant's secret by name
+func (s *SQLiteStore) GetSecret(tenantID, name string) (*models.Secret, error) {
+	secret, err := scanSecret(s.db.QueryRow(`SELECT `+secretColumns+` FROM secrets WHERE tenant_id = ? AND name = ?`, tenantID, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrSecretNotFound
+	}
+	return secret, err
+}
+
+// ListSecrets returns a tenant's secrets ordered by name
+func (s *SQLiteStore) ListSecrets(tenantID string) ([]*models.Secret, error) {
+	rows, err := s.db.Query(`SELECT `+secretColumns+` FROM secrets WHERE tenant_id = ? ORDER BY name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanSecrets(rows)
+}
+
+// UpdateSecret replaces the value and description of a secret
+func (s *SQLiteStore) UpdateSecret(secret *models.Secret) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`UPDATE secrets SET description = ?, ciphertext = ?, updated_at = ? WHERE tenant_id = ? AND name = ?`,
+		secret.Description, secret.Ciphertext, secret.UpdatedAt.UTC(), secret.TenantID, secret.Name)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOr(result, ErrSecretNotFound)
+}
+
+// DeleteSecret deletes a tenant's secret
+func (s *SQLiteStore) DeleteSecret(tenantID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`DELETE FROM secrets WHERE tenant_id = ? AND name = ?`, tenantID, name)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOr(result, ErrSecretNotFound)
+}
+
+// secretArgs returns the secretColumns values of a secret (shared by SQLite and PostgreSQL stores)
+func secretArgs(secret *models.Secret) []interface{} {
+	return []interface{}{
+		secret.ID, secret.TenantID, secret.Name, secret.Description, secret.Ciphertext,
+		secret.CreatedBy, secret.CreatedAt.UTC(), secret.UpdatedAt.UTC(),
+	}
+}
+
+// scanSecret scans a secret row (shared by SQLite and PostgreSQL stores)
+func scanSecret(scanner interface{ Scan(...interface{}) error }) (*models.Secret, error) {
+	var secret models.Secret
+	var description, createdBy sql.NullString
+
+	if err := scanner.Scan(&secret.ID, &secret.TenantID, &secret.Name, &description, &secret.Ciphertext,
+		&createdBy, &secret.CreatedAt, &secret.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	secret.Description = description.String
+	secret.CreatedBy = createdBy.String
+	return &secret, nil
+}
+
+// scanSecrets scans and closes a set of secret rows (shared by SQLite and PostgreSQL stores)
+func scanSecrets(rows *sql.Rows) ([]*models.Secret, error) {
+	defer rows.Close()
+
+	secrets := make([]*models.Secret, 0)
+	for rows.Next() {
+		secret, err := scanSecret(rows)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, rows.Err()
+}
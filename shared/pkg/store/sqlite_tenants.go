@@ -0,0 +1,506 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// tenantColumns is the column list shared by the SQLite and PostgreSQL tenant queries
+const tenantColumns = `id, name, display_name, status, plan, quotas, usage, metadata, created_at, updated_at, expires_at`
+
+// tenantAPIKeyColumns is the column list shared by the SQLite and PostgreSQL API key queries
+const tenantAPIKeyColumns = `id, tenant_id, name, key_hash, key_prefix, scopes, status, created_by, created_at, expires_at, last_used_at, kind`
+
+// ensureDefaultTenant creates the default tenant if it does not exist
+func (s *SQLiteStore) ensureDefaultTenant() error {
+	args, err := tenantArgs(newDefaultTenant())
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR IGNORE INTO tenants (`+tenantColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create default tenant: %w", err)
+	}
+	return nil
+}
+
+// CreateTenant adds a new tenant
+func (s *SQLiteStore) CreateTenant(tenant *models.Tenant) error {
+	if err := tenant.Validate(); err != nil {
+		return fmt.Errorf("invalid tenant: %w", err)
+	}
+
+	args, err := tenantArgs(tenant)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tenants WHERE id = ? OR name = ?", tenant.ID, tenant.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrTenantExists
+	}
+
+	_, err = s.db.Exec(`INSERT INTO tenants (`+tenantColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+// GetTenant retrieves a tenant by ID
+func (s *SQLiteStore) GetTenant(id string) (*models.Tenant, error) {
+	tenant, err := scanTenant(s.db.QueryRow(`SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+// GetTenantByName retrieves a tenant by its unique name
+func (s *SQLiteStore) GetTenantByName(name string) (*models.Tenant, error) {
+	tenant, err := scanTenant(s.db.QueryRow(`SELECT `+tenantColumns+` FROM tenants WHERE name = ?`, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+// ListTenants returns all tenants that have not been deleted, oldest first
+func (s *SQLiteStore) ListTenants() ([]*models.Tenant, error) {
+	rows, err := s.db.Query(`SELECT `+tenantColumns+` FROM tenants WHERE status != ? ORDER BY created_at ASC`,
+		models.TenantStatusDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := make([]*models.Tenant, 0)
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, rows.Err()
+}
+
+// UpdateTenant replaces an existing tenant
+func (s *SQLiteStore) UpdateTenant(tenant *models.Tenant) error {
+	if err := tenant.Validate(); err != nil {
+		return fmt.Errorf("invalid tenant: %w", err)
+	}
+
+	args, err := tenantArgs(tenant)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tenants WHERE id != ? AND name = ?", tenant.ID, tenant.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrTenantExists
+	}
+
+	// Arguments are in tenantColumns order; the ID moves to the WHERE clause
+	result, err := s.db.Exec(`
+		UPDATE tenants
+		SET name = ?, display_name = ?, status = ?, plan = ?, quotas = ?, usage = ?, metadata = ?,
+		    created_at = ?, updated_at = ?, expires_at = ?
+		WHERE id = ?
+	`, append(args[1:], tenant.ID)...)
+	if err != nil {
+		return err
+	}
+	return tenantRowsAffected(result)
+}
+
+// DeleteTenant soft-deletes a tenant by marking it deleted
+func (s *SQLiteStore) DeleteTenant(id string) error {
+	if id == models.DefaultTenantID {
+		return ErrDefaultTenant
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("UPDATE tenants SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		models.TenantStatusDeleted, id)
+	if err != nil {
+		return err
+	}
+	return tenantRowsAffected(result)
+}
+
+// UpdateTenantUsage stores a usage snapshot for a tenant
+func (s *SQLiteStore) UpdateTenantUsage(id string, usage *models.TenantUsage) error {
+	usageJSON, err := marshalJSON(usage)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("UPDATE tenants SET usage = ? WHERE id = ?", string(usageJSON), id)
+	if err != nil {
+		return err
+	}
+	return tenantRowsAffected(result)
+}
+
+// GetTenantStats returns the live usage of a tenant
+func (s *SQLiteStore) GetTenantStats(id string) (*models.TenantUsage, error) {
+	tenant, err := s.GetTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := s.GetJobsByTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.GetNodesByTenant(id)
+	if err != nil {
+		return nil, err
+	}
+	return computeTenantUsage(id, tenant.Usage, jobs, nodes), nil
+}
+
+// GetJobsByTenant returns the jobs owned by a tenant, ordered by sequence number
+func (s *SQLiteStore) GetJobsByTenant(tenantID string) ([]*models.Job, error) {
+	rows, err := s.db.Query(`
+		SELECT id, sequence_number, scenario, confidence, engine, parameters, status, queue, priority,
+		       progress, node_id, created_at, started_at, last_activity_at, completed_at,
+		       retry_count, error, logs, state_transitions, tenant_id
+		FROM jobs
+		WHERE tenant_id = ?
+		ORDER BY sequence_number ASC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant jobs: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanJobs(rows)
+}
+
+// GetNodesByTenant returns the nodes dedicated to a tenant
+func (s *SQLiteStore) GetNodesByTenant(tenantID string) ([]*models.Node, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, address, type, cpu_threads, cpu_model, cpu_load_percent, has_gpu, gpu_type,
+		       gpu_capabilities, ram_total_bytes, ram_free_bytes, labels, status, last_heartbeat,
+		       registered_at, current_job_id, tenant_id
+		FROM nodes
+		WHERE tenant_id = ?
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := make([]*models.Node, 0)
+	for rows.Next() {
+		var node models.Node
+		var labelsJSON, gpuCapsJSON, currentJobID sql.NullString
+
+		if err := rows.Scan(&node.ID, &node.Name, &node.Address, &node.Type, &node.CPUThreads,
+			&node.CPUModel, &node.CPULoadPercent, &node.HasGPU, &node.GPUType, &gpuCapsJSON,
+			&node.RAMTotalBytes, &node.RAMFreeBytes, &labelsJSON, &node.Status,
+			&node.LastHeartbeat, &node.RegisteredAt, &currentJobID, &node.TenantID); err != nil {
+			return nil, err
+		}
+
+		node.CurrentJobID = currentJobID.String
+		if labelsJSON.Valid && labelsJSON.String != "" && labelsJSON.String != "null" {
+			if err := unmarshalJSON([]byte(labelsJSON.String), &node.Labels); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+			}
+		}
+		if gpuCapsJSON.Valid && gpuCapsJSON.String != "" && gpuCapsJSON.String != "null" {
+			if err := unmarshalJSON([]byte(gpuCapsJSON.String), &node.GPUCapabilities); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal gpu_capabilities: %w", err)
+			}
+		}
+
+		nodes = append(nodes, &node)
+	}
+	return nodes, rows.Err()
+}
+
+// CreateTenantAPIKey stores a new tenant API key
+func (s *SQLiteStore) CreateTenantAPIKey(key *models.TenantAPIKey) error {
+	args, err := tenantAPIKeyArgs(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tenants WHERE id = ?", key.TenantID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrTenantNotFound
+	}
+
+	_, err = s.db.Exec(`INSERT INTO tenant_api_keys (`+tenantAPIKeyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+// GetTenantAPIKey retrieves a tenant API key by ID
+func (s *SQLiteStore) GetTenantAPIKey(id string) (*models.TenantAPIKey, error) {
+	key, err := scanTenantAPIKey(s.db.QueryRow(`SELECT `+tenantAPIKeyColumns+` FROM tenant_api_keys WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantAPIKeyNotFound
+	}
+	return key, err
+}
+
+// GetTenantAPIKeyByPrefix retrieves a tenant API key by its lookup prefix
+func (s *SQLiteStore) GetTenantAPIKeyByPrefix(prefix string) (*models.TenantAPIKey, error) {
+	key, err := scanTenantAPIKey(s.db.QueryRow(`SELECT `+tenantAPIKeyColumns+` FROM tenant_api_keys WHERE key_prefix = ?`, prefix))
+	if err == sql.ErrNoRows {
+		return nil, ErrTenantAPIKeyNotFound
+	}
+	return key, err
+}
+
+// ListTenantAPIKeys returns the API keys of a tenant, newest first
+func (s *SQLiteStore) ListTenantAPIKeys(tenantID string) ([]*models.TenantAPIKey, error) {
+	rows, err := s.db.Query(`SELECT `+tenantAPIKeyColumns+` FROM tenant_api_keys WHERE tenant_id = ? ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*models.TenantAPIKey, 0)
+	for rows.Next() {
+		key, err := scanTenantAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// UpdateTenantAPIKey updates the name, scopes, status and expiry of a tenant API key
+func (s *SQLiteStore) UpdateTenantAPIKey(key *models.TenantAPIKey) error {
+	scopes, err := marshalJSON(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`UPDATE tenant_api_keys SET name = ?, scopes = ?, status = ?, expires_at = ? WHERE id = ?`,
+		key.Name, string(scopes), key.Status, key.ExpiresAt, key.ID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrTenantAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchTenantAPIKey records when a tenant API key was last used.
+// Only last_used_at is written, so it never undoes a concurrent revocation.
+func (s *SQLiteStore) TouchTenantAPIKey(id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`UPDATE tenant_api_keys SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrTenantAPIKeyNotFound
+	}
+	return nil
+}
+
+// tenantAPIKeyArgs returns the tenantAPIKeyColumns values of a key (shared by SQLite and PostgreSQL stores).
+// Keys without a kind are API keys.
+func tenantAPIKeyArgs(key *models.TenantAPIKey) ([]interface{}, error) {
+	scopes, err := marshalJSON(key.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+	kind := key.Kind
+	if kind == "" {
+		kind = models.TenantAPIKeyKindAPI
+	}
+
+	return []interface{}{
+		key.ID, key.TenantID, key.Name, key.KeyHash, key.KeyPrefix, string(scopes), key.Status,
+		key.CreatedBy, key.CreatedAt, key.ExpiresAt, key.LastUsedAt, kind,
+	}, nil
+}
+
+// tenantArgs returns the tenantColumns values of a tenant (shared by SQLite and PostgreSQL stores)
+func tenantArgs(tenant *models.Tenant) ([]interface{}, error) {
+	quotas, err := marshalJSON(tenant.Quotas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal quotas: %w", err)
+	}
+	usage, err := marshalJSON(tenant.Usage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	metadata, err := marshalJSON(tenant.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return []interface{}{
+		tenant.ID, tenant.Name, tenant.DisplayName, tenant.Status, tenant.Plan,
+		string(quotas), string(usage), string(metadata),
+		tenant.CreatedAt, tenant.UpdatedAt, tenant.ExpiresAt,
+	}, nil
+}
+
+// scanTenant scans a tenant row (shared by SQLite and PostgreSQL stores)
+func scanTenant(scanner interface{ Scan(...interface{}) error }) (*models.Tenant, error) {
+	var tenant models.Tenant
+	var quotasJSON, usageJSON, metadataJSON sql.NullString
+	var expiresAt sql.NullTime
+
+	if err := scanner.Scan(&tenant.ID, &tenant.Name, &tenant.DisplayName, &tenant.Status, &tenant.Plan,
+		&quotasJSON, &usageJSON, &metadataJSON, &tenant.CreatedAt, &tenant.UpdatedAt, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	if quotasJSON.Valid && quotasJSON.String != "" {
+		if err := unmarshalJSON([]byte(quotasJSON.String), &tenant.Quotas); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal quotas: %w", err)
+		}
+	}
+	if usageJSON.Valid && usageJSON.String != "" {
+		if err := unmarshalJSON([]byte(usageJSON.String), &tenant.Usage); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal usage: %w", err)
+		}
+	}
+	if metadataJSON.Valid && metadataJSON.String != "" && metadataJSON.String != "null" {
+		if err := unmarshalJSON([]byte(metadataJSON.String), &tenant.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	if expiresAt.Valid {
+		tenant.ExpiresAt = &expiresAt.Time
+	}
+	return &tenant, nil
+}
+
+// scanTenantAPIKey scans a tenant API key row (shared by SQLite and PostgreSQL stores)
+func scanTenantAPIKey(scanner interface{ Scan(...interface{}) error }) (*models.TenantAPIKey, error) {
+	var key models.TenantAPIKey
+	var scopesJSON, createdBy, kind sql.NullString
+	var expiresAt, lastUsedAt sql.NullTime
+
+	if err := scanner.Scan(&key.ID, &key.TenantID, &key.Name, &key.KeyHash, &key.KeyPrefix, &scopesJSON,
+		&key.Status, &createdBy, &key.CreatedAt, &expiresAt, &lastUsedAt, &kind); err != nil {
+		return nil, err
+	}
+
+	key.CreatedBy = createdBy.String
+	key.Kind = kind.String
+	if key.Kind == "" {
+		key.Kind = models.TenantAPIKeyKindAPI
+	}
+	if scopesJSON.Valid && scopesJSON.String != "" && scopesJSON.String != "null" {
+		if err := unmarshalJSON([]byte(scopesJSON.String), &key.Scopes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scopes: %w", err)
+		}
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
+
+// tenantRowsAffected maps an update that matched no rows to ErrTenantNotFound
+func tenantRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate key")
+}
+
+// cappedTenantIDs returns the tenants whose running jobs have reached their
+// concurrent job quota (shared by SQLite and PostgreSQL stores). Jobs of these
+// tenants stay queued until one of their running jobs finishes.
+func cappedTenantIDs(q interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}) ([]string, error) {
+	rows, err := q.Query(`
+		SELECT t.id, t.quotas, COUNT(j.id)
+		FROM tenants t
+		JOIN jobs j ON j.tenant_id = t.id
+		WHERE j.status IN ('assigned', 'running', 'processing', 'paused')
+		GROUP BY t.id, t.quotas
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count running jobs per tenant: %w", err)
+	}
+	defer rows.Close()
+
+	capped := make([]string, 0)
+	for rows.Next() {
+		var tenantID string
+		var quotasJSON []byte
+		var running int
+		if err := rows.Scan(&tenantID, &quotasJSON, &running); err != nil {
+			return nil, err
+		}
+		var quota models.TenantQuota
+		if err := unmarshalJSON(quotasJSON, &quota); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal quotas: %w", err)
+		}
+		if !quota.AllowsConcurrentJob(running) {
+			capped = append(capped, tenantID)
+		}
+	}
+	return capped, rows.Err()
+}
+
+// notInClause returns a SQL condition excluding values from column using ?
+// placeholders, with its arguments. It returns an always-true condition for no values.
+func notInClause(column string, values []string) (string, []interface{}) {
+	if len(values) == 0 {
+		return "1 = 1", nil
+	}
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return column + " NOT IN (?" + strings.Repeat(", ?", len(values)-1) + ")", args
+}
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// userColumns is the column list shared by the SQLite and PostgreSQL user queries
+const userColumns = `id, tenant_id, email, password_hash, full_name, role, status, last_login_at, created_at, updated_at`
+
+// sessionColumns is the column list shared by the SQLite and PostgreSQL session queries
+const sessionColumns = `id, user_id, tenant_id, token_hash, expires_at, created_at, ip_address, user_agent`
+
+// CreateUser adds a new user
+func (s *SQLiteStore) CreateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tenants WHERE id = ?", user.TenantID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrTenantNotFound
+	}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ? OR email = ?", user.ID, user.Email).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrUserExists
+	}
+
+	_, err := s.db.Exec(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, userArgs(user)...)
+	return err
+}
+
+// GetUser retrieves a user by ID
+func (s *SQLiteStore) GetUser(id string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetUserByEmail retrieves a user by their unique email address
+func (s *SQLiteStore) GetUserByEmail(email string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = ?`, email))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// ListUsers returns the users of a tenant (or all users if tenantID is empty), oldest first
+func (s *SQLiteStore) ListUsers(tenantID string) ([]*models.User, error) {
+	rows, err := s.db.Query(`SELECT `+userColumns+` FROM users WHERE ? = '' OR tenant_id = ? ORDER BY created_at ASC`,
+		tenantID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsers(rows)
+}
+
+// UpdateUser replaces an existing user
+func (s *SQLiteStore) UpdateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE id != ? AND email = ?", user.ID, user.Email).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrUserExists
+	}
+
+	// Arguments are in userColumns order; the ID moves to the WHERE clause
+	args := userArgs(user)
+	result, err := s.db.Exec(`
+		UPDATE users
+		SET tenant_id = ?, email = ?, password_hash = ?, full_name = ?, role = ?, status = ?,
+		    last_login_at = ?, created_at = ?, updated_at = ?
+		WHERE id = ?
+	`, append(args[1:], user.ID)...)
+	if err != nil {
+		return err
+	}
+	return userRowsAffected(result)
+}
+
+// DeleteUser removes a user and all of their sessions
+func (s *SQLiteStore) DeleteUser(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = ?", id); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := userRowsAffected(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateSession stores a new session
+func (s *SQLiteStore) CreateSession(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", session.UserID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrUserNotFound
+	}
+
+	_, err := s.db.Exec(`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, sessionArgs(session)...)
+	return err
+}
+
+// GetSessionByTokenHash retrieves a session by the hash of its token
+func (s *SQLiteStore) GetSessionByTokenHash(tokenHash string) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE token_hash = ?`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	return session, err
+}
+
+// DeleteSession removes a session
+func (s *SQLiteStore) DeleteSession(tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return err
+	}
+	return sessionRowsAffected(result)
+}
+
+// DeleteUserSessions removes all sessions of a user
+func (s *SQLiteStore) DeleteUserSessions(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("DELETE FROM sessions WHERE user_id = ?", userID)
+	return err
+}
+
+// DeleteExpiredSessions removes expired sessions and returns how many were removed
+func (s *SQLiteStore) DeleteExpiredSessions() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Session timestamps are stored in UTC, which SQLite compares as text
+	result, err := s.db.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// userArgs returns the userColumns values of a user (shared by SQLite and PostgreSQL stores)
+func userArgs(user *models.User) []interface{} {
+	return []interface{}{
+		user.ID, user.TenantID, user.Email, user.PasswordHash, user.FullName, string(user.Role), user.Status,
+		user.LastLoginAt, user.CreatedAt, user.UpdatedAt,
+	}
+}
+
+// scanUser scans a user row (shared by SQLite and PostgreSQL stores)
+func scanUser(scanner interface{ Scan(...interface{}) error }) (*models.User, error) {
+	var user models.User
+	var role string
+	var fullName sql.NullString
+	var lastLoginAt sql.NullTime
+
+	if err := scanner.Scan(&user.ID, &user.TenantID, &user.Email, &user.PasswordHash, &fullName, &role,
+		&user.Status, &lastLoginAt, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	user.Role = models.Role(role)
+	user.FullName = fullName.String
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	return &user, nil
+}
+
+// scanUsers scans and closes a set of user rows (shared by SQLite and PostgreSQL stores)
+func scanUsers(rows *sql.Rows) ([]*models.User, error) {
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// sessionArgs returns the sessionColumns values of a session (shared by SQLite and PostgreSQL stores)
+func sessionArgs(session *models.Session) []interface{} {
+	return []interface{}{
+		session.ID, session.UserID, session.TenantID, session.TokenHash,
+		session.ExpiresAt.UTC(), session.CreatedAt.UTC(), session.IPAddress, session.UserAgent,
+	}
+}
+
+// scanSession scans a session row (shared by SQLite and PostgreSQL stores)
+func scanSession(scanner interface{ Scan(...interface{}) error }) (*models.Session, error) {
+	var session models.Session
+	var ipAddress, userAgent sql.NullString
+
+	if err := scanner.Scan(&session.ID, &session.UserID, &session.TenantID, &session.TokenHash,
+		&session.ExpiresAt, &session.CreatedAt, &ipAddress, &userAgent); err != nil {
+		return nil, err
+	}
+
+	session.IPAddress = ipAddress.String
+	session.UserAgent = userAgent.String
+	return &session, nil
+}
+
+// userRowsAffected maps an update that matched no rows to ErrUserNotFound
+func userRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// sessionRowsAffected maps a delete that matched no rows to ErrSessionNotFound
+func sessionRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
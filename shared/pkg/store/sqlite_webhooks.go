@@ -0,0 +1,228 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// CreateWebhook adds a new webhook subscription
+func (s *SQLiteStore) CreateWebhook(hook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := marshalJSON(hook.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO webhooks (id, tenant_id, url, events, secret, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, hook.ID, hook.TenantID, hook.URL, string(events), hook.Secret, hook.Enabled, hook.CreatedAt, hook.UpdatedAt)
+	return err
+}
+
+// GetWebhook retrieves a webhook subscription by ID
+func (s *SQLiteStore) GetWebhook(id string) (*models.Webhook, error) {
+	row := s.db.QueryRow(`
+		SELECT id, tenant_id, url, events, secret, enabled, created_at, updated_at
+		FROM webhooks WHERE id = ?
+	`, id)
+
+	hook, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, err
+}
+
+// ListWebhooks returns all webhook subscriptions ordered by creation time
+func (s *SQLiteStore) ListWebhooks() ([]*models.Webhook, error) {
+	rows, err := s.db.Query(`
+		SELECT id, tenant_id, url, events, secret, enabled, created_at, updated_at
+		FROM webhooks ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// UpdateWebhook replaces an existing webhook subscription
+func (s *SQLiteStore) UpdateWebhook(hook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := marshalJSON(hook.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE webhooks SET url = ?, events = ?, secret = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, hook.URL, string(events), hook.Secret, hook.Enabled, hook.UpdatedAt, hook.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook subscription and its delivery history
+func (s *SQLiteStore) DeleteWebhook(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+
+	_, err = s.db.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id)
+	return err
+}
+
+// SaveWebhookDelivery creates or updates a webhook delivery record
+func (s *SQLiteStore) SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_deliveries
+		(id, webhook_id, job_id, event, payload, status, attempts, response_code, last_error, created_at, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			attempts = excluded.attempts,
+			response_code = excluded.response_code,
+			last_error = excluded.last_error,
+			delivered_at = excluded.delivered_at
+	`, delivery.ID, delivery.WebhookID, delivery.JobID, delivery.Event, delivery.Payload, delivery.Status,
+		delivery.Attempts, delivery.ResponseCode, delivery.LastError, delivery.CreatedAt, delivery.DeliveredAt)
+	return err
+}
+
+// GetWebhookDelivery retrieves a webhook delivery record by ID
+func (s *SQLiteStore) GetWebhookDelivery(id string) (*models.WebhookDelivery, error) {
+	row := s.db.QueryRow(`
+		SELECT id, webhook_id, job_id, event, payload, status, attempts, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE id = ?
+	`, id)
+
+	delivery, err := scanWebhookDelivery(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// DeleteWebhookDeliveries deletes finished deliveries created before the given time
+func (s *SQLiteStore) DeleteWebhookDeliveries(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM webhook_deliveries WHERE status != ? AND created_at < ?",
+		models.WebhookDeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// ListWebhookDeliveries returns the most recent deliveries for a webhook (limit <= 0 returns all)
+func (s *SQLiteStore) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, webhook_id, job_id, event, payload, status, attempts, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE webhook_id = ?
+		ORDER BY created_at DESC LIMIT ?
+	`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// scanWebhook scans a webhook row (shared by SQLite and PostgreSQL stores)
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	var hook models.Webhook
+	var tenantID, eventsJSON sql.NullString
+
+	if err := scanner.Scan(&hook.ID, &tenantID, &hook.URL, &eventsJSON, &hook.Secret, &hook.Enabled,
+		&hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	hook.TenantID = tenantID.String
+	if eventsJSON.Valid && eventsJSON.String != "" && eventsJSON.String != "null" {
+		if err := unmarshalJSON([]byte(eventsJSON.String), &hook.Events); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal events: %w", err)
+		}
+	}
+	return &hook, nil
+}
+
+// scanWebhookDelivery scans a webhook delivery row (shared by SQLite and PostgreSQL stores)
+func scanWebhookDelivery(scanner interface{ Scan(...interface{}) error }) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var jobID, payload, lastError sql.NullString
+	var responseCode sql.NullInt64
+	var deliveredAt sql.NullTime
+
+	if err := scanner.Scan(&delivery.ID, &delivery.WebhookID, &jobID, &delivery.Event, &payload, &delivery.Status,
+		&delivery.Attempts, &responseCode, &lastError, &delivery.CreatedAt, &deliveredAt); err != nil {
+		return nil, err
+	}
+
+	delivery.JobID = jobID.String
+	delivery.Payload = payload.String
+	delivery.LastError = lastError.String
+	delivery.ResponseCode = int(responseCode.Int64)
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testTenants exercises tenant CRUD and tenant scoping of jobs and nodes
+func testTenants(t *testing.T, s Store) {
+	def, err := s.GetTenant(models.DefaultTenantID)
+	if err != nil {
+		t.Fatalf("Expected default tenant to exist: %v", err)
+	}
+	if def.Plan != "enterprise" || !def.IsActive() {
+		t.Errorf("Unexpected default tenant: %+v", def)
+	}
+
+	tenant := models.NewTenant("tenant-a", "business-a", "pro")
+	tenant.Metadata = map[string]interface{}{"cost_center": "42"}
+	if err := s.CreateTenant(tenant); err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+	if err := s.CreateTenant(models.NewTenant("tenant-dup", "business-a", "free")); err != ErrTenantExists {
+		t.Errorf("Expected ErrTenantExists for duplicate name, got %v", err)
+	}
+	if err := s.CreateTenant(models.NewTenant("tenant-bad", "Not Valid", "free")); err == nil {
+		t.Error("Expected error for invalid tenant name")
+	}
+
+	got, err := s.GetTenantByName("business-a")
+	if err != nil {
+		t.Fatalf("Failed to get tenant by name: %v", err)
+	}
+	if got.ID != "tenant-a" || got.Quotas.MaxConcurrentJobs != 20 || got.Metadata["cost_center"] != "42" {
+		t.Errorf("Unexpected tenant: %+v", got)
+	}
+
+	got.DisplayName = "Business Unit A"
+	if err := s.UpdateTenant(got); err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+	if got, _ = s.GetTenant("tenant-a"); got.DisplayName != "Business Unit A" {
+		t.Errorf("Expected updated display name, got %q", got.DisplayName)
+	}
+	if err := s.UpdateTenant(models.NewTenant("missing", "missing", "free")); err != ErrTenantNotFound {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+
+	if err := s.CreateTenant(models.NewTenant("tenant-b", "business-b", "free")); err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+
+	// Jobs and nodes are scoped by tenant
+	now := time.Now()
+	for i, owner := range []string{"tenant-a", "tenant-a", "tenant-b", ""} {
+		job := &models.Job{ID: "job-" + string(rune('1'+i)), Scenario: "test", Engine: "auto", Queue: "default",
+			Priority: "medium", Status: models.JobStatusPending, CreatedAt: now, TenantID: owner}
+		if err := s.CreateJob(job); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+	if job, _ := s.GetJob("job-4"); job.TenantID != models.DefaultTenantID {
+		t.Errorf("Expected job without tenant to belong to the default tenant, got %q", job.TenantID)
+	}
+
+	jobs, err := s.GetJobsByTenant("tenant-a")
+	if err != nil {
+		t.Fatalf("Failed to get tenant jobs: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "job-1" || jobs[1].ID != "job-2" {
+		t.Errorf("Expected jobs job-1 and job-2 for tenant-a, got %d jobs", len(jobs))
+	}
+
+	nodeB := &models.Node{ID: "node-b", Name: "node-b", Address: "http://node-b:9000", Type: "server",
+		CPUThreads: 8, Status: "available", LastHeartbeat: now, RegisteredAt: now, TenantID: "tenant-b"}
+	shared := &models.Node{ID: "node-shared", Name: "shared", Address: "http://shared:9000", Type: "server",
+		CPUThreads: 16, Status: "available", LastHeartbeat: now, RegisteredAt: now}
+	for _, node := range []*models.Node{nodeB, shared} {
+		if err := s.RegisterNode(node); err != nil {
+			t.Fatalf("Failed to register node: %v", err)
+		}
+	}
+
+	nodes, err := s.GetNodesByTenant("tenant-b")
+	if err != nil {
+		t.Fatalf("Failed to get tenant nodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != "node-b" {
+		t.Errorf("Expected only node-b for tenant-b, got %d nodes", len(nodes))
+	}
+
+	// A dedicated node only receives its tenant's jobs
+	job, err := s.GetNextJob("node-b")
+	if err != nil {
+		t.Fatalf("Failed to get next job for dedicated node: %v", err)
+	}
+	if job.ID != "job-3" {
+		t.Errorf("Expected dedicated node to get job-3, got %s", job.ID)
+	}
+	if _, err := s.GetNextJob("node-b"); err != ErrJobNotFound {
+		t.Errorf("Expected no more jobs for dedicated node, got %v", err)
+	}
+
+	stats, err := s.GetTenantStats("tenant-b")
+	if err != nil {
+		t.Fatalf("Failed to get tenant stats: %v", err)
+	}
+	if stats.CurrentJobs != 1 || stats.TotalJobsLifetime != 1 || stats.CurrentWorkers != 1 || stats.CurrentCPUCores != 8 {
+		t.Errorf("Unexpected tenant stats: %+v", stats)
+	}
+
+	// API keys are found by prefix and listed per tenant
+	key := &models.TenantAPIKey{ID: "key-1", TenantID: "tenant-a", Name: "ci", KeyHash: "hash",
+		KeyPrefix: "ffrtmp_business-a_abcd1234", Scopes: []string{"jobs:write"},
+		Status: models.TenantAPIKeyStatusActive, CreatedAt: now}
+	if err := s.CreateTenantAPIKey(key); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	gotKey, err := s.GetTenantAPIKeyByPrefix("ffrtmp_business-a_abcd1234")
+	if err != nil {
+		t.Fatalf("Failed to get API key: %v", err)
+	}
+	if gotKey.TenantID != "tenant-a" || len(gotKey.Scopes) != 1 {
+		t.Errorf("Unexpected API key: %+v", gotKey)
+	}
+	if _, err := s.GetTenantAPIKeyByPrefix("ffrtmp_business-a_ffffffff"); err != ErrTenantAPIKeyNotFound {
+		t.Errorf("Expected ErrTenantAPIKeyNotFound, got %v", err)
+	}
+	if keys, _ := s.ListTenantAPIKeys("tenant-b"); len(keys) != 0 {
+		t.Errorf("Expected no API keys for tenant-b, got %d", len(keys))
+	}
+	if gotKey.Kind != models.TenantAPIKeyKindAPI {
+		t.Errorf("Expected keys without a kind to be API keys, got %q", gotKey.Kind)
+	}
+
+	// Keys can be revoked, and last use is recorded without undoing a revocation
+	gotKey.Status = models.TenantAPIKeyStatusRevoked
+	if err := s.UpdateTenantAPIKey(gotKey); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+	usedAt := now.Add(time.Minute).Truncate(time.Second)
+	if err := s.TouchTenantAPIKey("key-1", usedAt); err != nil {
+		t.Fatalf("Failed to touch API key: %v", err)
+	}
+	gotKey, err = s.GetTenantAPIKey("key-1")
+	if err != nil {
+		t.Fatalf("Failed to get API key by ID: %v", err)
+	}
+	if gotKey.Status != models.TenantAPIKeyStatusRevoked || gotKey.LastUsedAt == nil || !gotKey.LastUsedAt.Equal(usedAt) {
+		t.Errorf("Expected revoked key used at %v, got %+v", usedAt, gotKey)
+	}
+	if gotKey.KeyHash != "hash" {
+		t.Errorf("Expected key hash to be preserved, got %q", gotKey.KeyHash)
+	}
+	if err := s.TouchTenantAPIKey("missing", usedAt); err != ErrTenantAPIKeyNotFound {
+		t.Errorf("Expected ErrTenantAPIKeyNotFound touching a missing key, got %v", err)
+	}
+
+	// Deletion is soft and the default tenant cannot be deleted
+	if err := s.DeleteTenant(models.DefaultTenantID); err != ErrDefaultTenant {
+		t.Errorf("Expected ErrDefaultTenant, got %v", err)
+	}
+	if err := s.DeleteTenant("tenant-b"); err != nil {
+		t.Fatalf("Failed to delete tenant: %v", err)
+	}
+	if got, err := s.GetTenant("tenant-b"); err != nil || got.Status != models.TenantStatusDeleted {
+		t.Errorf("Expected deleted tenant to remain readable with deleted status, got %v, %v", got, err)
+	}
+
+	tenants, err := s.ListTenants()
+	if err != nil {
+		t.Fatalf("Failed to list tenants: %v", err)
+	}
+	if len(tenants) != 2 || tenants[0].ID != models.DefaultTenantID || tenants[1].ID != "tenant-a" {
+		t.Errorf("Expected default and tenant-a, got %d tenants", len(tenants))
+	}
+}
+
+// testTenantConcurrencyQuota checks that nodes skip jobs of tenants at their concurrent job quota
+func testTenantConcurrencyQuota(t *testing.T, s Store) {
+	tenant := models.NewTenant("tenant-q", "quota-test", "free")
+	tenant.Quotas.MaxConcurrentJobs = 1
+	if err := s.CreateTenant(tenant); err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+
+	now := time.Now()
+	jobs := []*models.Job{
+		{ID: "capped-1", TenantID: "tenant-q", CreatedAt: now},
+		{ID: "capped-2", TenantID: "tenant-q", CreatedAt: now.Add(time.Second)},
+		{ID: "other-1", CreatedAt: now.Add(2 * time.Second)},
+	}
+	for _, job := range jobs {
+		job.Scenario = "test"
+		job.Engine = "auto"
+		job.Queue = "default"
+		job.Priority = "medium"
+		job.Status = models.JobStatusPending
+		if err := s.CreateJob(job); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+	for _, id := range []string{"node-1", "node-2", "node-3"} {
+		node := &models.Node{ID: id, Name: id, Address: "http://" + id + ":9000", Type: "server",
+			CPUThreads: 4, Status: "available", LastHeartbeat: now, RegisteredAt: now}
+		if err := s.RegisterNode(node); err != nil {
+			t.Fatalf("Failed to register node: %v", err)
+		}
+	}
+
+	first, err := s.GetNextJob("node-1")
+	if err != nil || first.ID != "capped-1" {
+		t.Fatalf("Expected capped-1 first, got %v, %v", first, err)
+	}
+	// tenant-q is now at its limit, so its second job is skipped
+	second, err := s.GetNextJob("node-2")
+	if err != nil || second.ID != "other-1" {
+		t.Fatalf("Expected other-1 while tenant is at its limit, got %v, %v", second, err)
+	}
+	if _, err := s.GetNextJob("node-3"); err != ErrJobNotFound {
+		t.Errorf("Expected no schedulable jobs, got %v", err)
+	}
+
+	if err := s.UpdateJobStatus("capped-1", models.JobStatusCompleted, ""); err != nil {
+		t.Fatalf("Failed to complete job: %v", err)
+	}
+	third, err := s.GetNextJob("node-3")
+	if err != nil || third.ID != "capped-2" {
+		t.Errorf("Expected capped-2 once a slot is free, got %v, %v", third, err)
+	}
+}
+
+func TestMemoryTenants(t *testing.T) {
+	testTenants(t, NewMemoryStore())
+}
+
+func TestMemoryTenantConcurrencyQuota(t *testing.T) {
+	testTenantConcurrencyQuota(t, NewMemoryStore())
+}
+
+func TestSQLiteTenants(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "tenants.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testTenants(t, s)
+}
+
+func TestSQLiteTenantConcurrencyQuota(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "quota.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testTenantConcurrencyQuota(t, s)
+}
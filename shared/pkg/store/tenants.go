@@ -0,0 +1,53 @@
+package store
+
+import (
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// computeTenantUsage derives a tenant's live usage from its jobs and nodes.
+// Counters that are not derived from jobs and nodes (storage, API requests)
+// are carried over from the stored usage.
+func computeTenantUsage(tenantID string, stored models.TenantUsage, jobs []*models.Job, nodes []*models.Node) *models.TenantUsage {
+	usage := stored
+	usage.TenantID = tenantID
+	usage.CurrentJobs = 0
+	usage.TotalJobsToday = 0
+	usage.TotalJobsLifetime = len(jobs)
+	usage.CurrentWorkers = 0
+	usage.CurrentCPUCores = 0
+	usage.CurrentGPUs = 0
+	usage.LastUpdated = time.Now()
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, job := range jobs {
+		if models.IsActiveState(job.Status) {
+			usage.CurrentJobs++
+		}
+		if !job.CreatedAt.Before(startOfDay) {
+			usage.TotalJobsToday++
+		}
+	}
+
+	for _, node := range nodes {
+		if node.Status == "offline" {
+			continue
+		}
+		usage.CurrentWorkers++
+		usage.CurrentCPUCores += node.CPUThreads
+		if node.HasGPU {
+			usage.CurrentGPUs++
+		}
+	}
+
+	return &usage
+}
+
+// newDefaultTenant returns the tenant that owns jobs submitted with the master API key
+func newDefaultTenant() *models.Tenant {
+	tenant := models.NewTenant(models.DefaultTenantID, models.DefaultTenantID, "enterprise")
+	tenant.DisplayName = "Default Tenant"
+	return tenant
+}
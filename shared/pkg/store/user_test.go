@@ -0,0 +1,169 @@
+package store
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testUsers exercises user CRUD and session lifecycle
+func testUsers(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+	user := &models.User{
+		ID:           "user-1",
+		TenantID:     models.DefaultTenantID,
+		Email:        "ops@example.com",
+		PasswordHash: "hash-1",
+		FullName:     "Ops Person",
+		Role:         models.RoleOperator,
+		Status:       models.UserStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	dup := *user
+	dup.ID = "user-2"
+	if err := s.CreateUser(&dup); err != ErrUserExists {
+		t.Errorf("Expected ErrUserExists for duplicate email, got %v", err)
+	}
+	orphan := *user
+	orphan.ID = "user-3"
+	orphan.Email = "orphan@example.com"
+	orphan.TenantID = "missing"
+	if err := s.CreateUser(&orphan); err != ErrTenantNotFound {
+		t.Errorf("Expected ErrTenantNotFound for unknown tenant, got %v", err)
+	}
+
+	got, err := s.GetUserByEmail("ops@example.com")
+	if err != nil {
+		t.Fatalf("Failed to get user by email: %v", err)
+	}
+	if got.ID != "user-1" || got.PasswordHash != "hash-1" || got.Role != models.RoleOperator {
+		t.Errorf("Unexpected user: %+v", got)
+	}
+
+	loginAt := now.Add(time.Minute)
+	got.Role = models.RoleViewer
+	got.LastLoginAt = &loginAt
+	if err := s.UpdateUser(got); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	got, err = s.GetUser("user-1")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if got.Role != models.RoleViewer || got.LastLoginAt == nil || !got.LastLoginAt.Equal(loginAt) {
+		t.Errorf("Update was not persisted: %+v", got)
+	}
+
+	other := models.NewTenant("tenant-u", "user-team", "pro")
+	if err := s.CreateTenant(other); err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+	if err := s.CreateUser(&models.User{ID: "user-4", TenantID: "tenant-u", Email: "dev@example.com",
+		PasswordHash: "hash-4", Role: models.RoleDeveloper, Status: models.UserStatusActive,
+		CreatedAt: now.Add(time.Second), UpdatedAt: now}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if users, _ := s.ListUsers(""); len(users) != 2 {
+		t.Errorf("Expected 2 users, got %d", len(users))
+	}
+	if users, _ := s.ListUsers("tenant-u"); len(users) != 1 || users[0].ID != "user-4" {
+		t.Errorf("Expected only user-4 for tenant-u, got %v", users)
+	}
+
+	session := &models.Session{
+		ID:        "session-1",
+		UserID:    "user-1",
+		TenantID:  models.DefaultTenantID,
+		TokenHash: "token-hash-1",
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+		IPAddress: "10.0.0.1",
+	}
+	if err := s.CreateSession(session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	expired := &models.Session{ID: "session-2", UserID: "user-1", TenantID: models.DefaultTenantID,
+		TokenHash: "token-hash-2", ExpiresAt: now.Add(-time.Hour), CreatedAt: now.Add(-2 * time.Hour)}
+	if err := s.CreateSession(expired); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	gotSession, err := s.GetSessionByTokenHash("token-hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if gotSession.UserID != "user-1" || gotSession.IPAddress != "10.0.0.1" || gotSession.IsExpired() {
+		t.Errorf("Unexpected session: %+v", gotSession)
+	}
+
+	if removed, err := s.DeleteExpiredSessions(); err != nil || removed != 1 {
+		t.Errorf("Expected 1 expired session removed, got %d, %v", removed, err)
+	}
+	if _, err := s.GetSessionByTokenHash("token-hash-2"); err != ErrSessionNotFound {
+		t.Errorf("Expected expired session to be removed, got %v", err)
+	}
+
+	if err := s.DeleteUser("user-1"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if _, err := s.GetUser("user-1"); err != ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound after delete, got %v", err)
+	}
+	if _, err := s.GetSessionByTokenHash("token-hash-1"); err != ErrSessionNotFound {
+		t.Errorf("Expected sessions of deleted user to be removed, got %v", err)
+	}
+	if err := s.DeleteSession("token-hash-1"); err != ErrSessionNotFound {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestMemoryUsers(t *testing.T) {
+	testUsers(t, NewMemoryStore())
+}
+
+func TestSQLiteUsers(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	testUsers(t, s)
+}
+
+// TestMemoryBackupKeepsPasswordHashes verifies that users survive a JSON backup
+// with their password hashes, which are hidden from API responses
+func TestMemoryBackupKeepsPasswordHashes(t *testing.T) {
+	src := NewMemoryStore()
+	now := time.Now()
+	if err := src.CreateUser(&models.User{ID: "user-1", TenantID: models.DefaultTenantID, Email: "a@example.com",
+		PasswordHash: "secret-hash", Role: models.RoleAdmin, Status: models.UserStatusActive,
+		CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Backup(&buf); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	dst := NewMemoryStore()
+	if err := dst.Restore(&buf); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	user, err := dst.GetUserByEmail("a@example.com")
+	if err != nil {
+		t.Fatalf("Expected restored user: %v", err)
+	}
+	if user.PasswordHash != "secret-hash" || user.Role != models.RoleAdmin {
+		t.Errorf("Unexpected restored user: %+v", user)
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+// testWebhookDeliveryRetention exercises deleting old finished deliveries
+func testWebhookDeliveryRetention(t *testing.T, s Store) {
+	hook := &models.Webhook{ID: "hook-1", URL: "https://example.com/hook", Enabled: true, CreatedAt: time.Now()}
+	if err := s.CreateWebhook(hook); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	deliveries := []*models.WebhookDelivery{
+		{ID: "old-succeeded", WebhookID: hook.ID, Event: "job.completed", Status: models.WebhookDeliverySucceeded, CreatedAt: old},
+		{ID: "old-failed", WebhookID: hook.ID, Event: "job.failed", Status: models.WebhookDeliveryFailed, CreatedAt: old},
+		{ID: "old-pending", WebhookID: hook.ID, Event: "job.completed", Status: models.WebhookDeliveryPending, CreatedAt: old},
+		{ID: "recent-failed", WebhookID: hook.ID, Event: "job.failed", Status: models.WebhookDeliveryFailed, CreatedAt: time.Now()},
+	}
+	for _, delivery := range deliveries {
+		if err := s.SaveWebhookDelivery(delivery); err != nil {
+			t.Fatalf("Failed to save delivery: %v", err)
+		}
+	}
+
+	removed, err := s.DeleteWebhookDeliveries(time.Now().Add(-24 * time.Hour))
+	if err != nil || removed != 2 {
+		t.Fatalf("Expected 2 deliveries deleted, got %d (%v)", removed, err)
+	}
+
+	remaining, err := s.ListWebhookDeliveries(hook.ID, 0)
+	if err != nil {
+		t.Fatalf("Failed to list deliveries: %v", err)
+	}
+	kept := make(map[string]bool)
+	for _, delivery := range remaining {
+		kept[delivery.ID] = true
+	}
+	if len(remaining) != 2 || !kept["old-pending"] || !kept["recent-failed"] {
+		t.Errorf("Expected old-pending and recent-failed to be kept, got %v", kept)
+	}
+}
+
+func TestMemoryWebhookDeliveryRetention(t *testing.T) {
+	testWebhookDeliveryRetention(t, NewMemoryStore())
+}
+
+func TestSQLiteWebhookDeliveryRetention(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "webhooks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create SQLite store: %v", err)
+	}
+	defer s.Close()
+	testWebhookDeliveryRetention(t, s)
+}
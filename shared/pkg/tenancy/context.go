@@ -5,15 +5,21 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
 )
 
 // Context keys for tenant and user information
 type contextKey string
 
 const (
-	TenantIDKey contextKey = "tenant_id"
-	UserIDKey   contextKey = "user_id"
-	UserRoleKey contextKey = "user_role"
+	TenantIDKey    contextKey = "tenant_id"
+	TenantKeyIDKey contextKey = "tenant_key_id"
+	KeyPermsKey    contextKey = "key_permissions"
+	TenantScopeKey contextKey = "tenant_scope"
+	UserIDKey      contextKey = "user_id"
+	UserRoleKey    contextKey = "user_role"
 )
 
 var (
@@ -58,46 +64,114 @@ func WithUser(ctx context.Context, userID, role string) context.Context {
 	return ctx
 }
 
-// TenantMiddleware extracts tenant from request and adds to context
-// Supports multiple methods:
-// 1. X-Tenant-ID header
-// 2. API key prefix (e.g., ffrtmp_<tenant_slug>_<key>)
-// 3. JWT token claim
-func TenantMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var tenantID string
+// TenantResolver resolves tenant API keys and user session tokens
+type TenantResolver interface {
+	ResolveAPIKey(apiKey string) (*models.TenantAPIKey, error)
+	ResolveSession(token string) (*models.User, error)
+}
 
-		// Method 1: Check X-Tenant-ID header
-		if headerTenant := r.Header.Get("X-Tenant-ID"); headerTenant != "" {
-			tenantID = headerTenant
-		}
+// WithTenantKey marks the context as authenticated with a tenant API key,
+// limited to the key's permissions
+func WithTenantKey(ctx context.Context, key *models.TenantAPIKey) context.Context {
+	ctx = WithTenantScope(ctx, key.TenantID)
+	ctx = context.WithValue(ctx, KeyPermsKey, key.Permissions())
+	return context.WithValue(ctx, TenantKeyIDKey, key.ID)
+}
+
+// GetTenantKeyID returns the ID of the tenant API key the request was
+// authenticated with, or "" for other credentials
+func GetTenantKeyID(ctx context.Context) string {
+	keyID, _ := ctx.Value(TenantKeyIDKey).(string)
+	return keyID
+}
+
+// GetKeyPermissions returns the permissions of the request's tenant API key.
+// ok is false for credentials that are not tenant API keys.
+func GetKeyPermissions(ctx context.Context) (perms []models.Permission, ok bool) {
+	perms, ok = ctx.Value(KeyPermsKey).([]models.Permission)
+	return perms, ok
+}
+
+// WithTenantScope binds the context to a tenant the caller cannot leave
+func WithTenantScope(ctx context.Context, tenantID string) context.Context {
+	ctx = WithTenant(ctx, tenantID)
+	return context.WithValue(ctx, TenantScopeKey, true)
+}
+
+// IsTenantScoped reports whether the request's credentials are bound to a
+// single tenant (a tenant API key or a user of a tenant other than the
+// default one), as opposed to cluster-wide credentials
+func IsTenantScoped(ctx context.Context) bool {
+	scoped, _ := ctx.Value(TenantScopeKey).(bool)
+	return scoped
+}
 
-		// Method 2: Extract from Authorization header (API key format: ffrtmp_<tenant>_<key>)
-		if tenantID == "" {
-			if auth := r.Header.Get("Authorization"); auth != "" {
-				// Remove "Bearer " prefix if present
-				token := strings.TrimPrefix(auth, "Bearer ")
-				if parts := strings.Split(token, "_"); len(parts) >= 3 && parts[0] == "ffrtmp" {
-					tenantID = parts[1]
+// IsTenantKey reports whether the request was authenticated with a tenant API key
+// (as opposed to an administrative credential acting on behalf of a tenant)
+func IsTenantKey(ctx context.Context) bool {
+	return GetTenantKeyID(ctx) != ""
+}
+
+// TenantMiddleware binds each request to a tenant and a role.
+// Requests carrying a tenant API key (Bearer ffrtmp_<tenant>_<secret>) are
+// verified with the resolver and bound to the key's tenant, which the client
+// cannot override, and to the key's permissions; invalid keys are rejected. Session tokens (Bearer
+// ffsess_<token>) act with their user's role; users of the default tenant act
+// across all tenants, other users are bound to their tenant. Other requests
+// act as administrators if isAdmin accepts their credentials.
+// Cluster-wide callers may select a tenant with the X-Tenant-ID header (or
+// tenant_id query parameter for WebSocket/SSE connections); requests without
+// a tenant act across all tenants.
+func TenantMiddleware(resolver TenantResolver, isAdmin func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if auth.IsTenantAPIKey(token) {
+				key, err := resolver.ResolveAPIKey(token)
+				if err != nil {
+					http.Error(w, `{"error":"unauthorized","message":"Invalid tenant API key"}`, http.StatusUnauthorized)
+					return
 				}
+				// Tenant keys act as tenant admins limited to the key's permissions
+				ctx := WithUser(WithTenantKey(r.Context(), key), "", string(models.RoleAdmin))
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
 			}
-		}
 
-		// Method 3: Check query parameter (for WebSocket/SSE connections)
-		if tenantID == "" {
-			if queryTenant := r.URL.Query().Get("tenant_id"); queryTenant != "" {
-				tenantID = queryTenant
+			if auth.IsSessionToken(token) {
+				user, err := resolver.ResolveSession(token)
+				if err != nil {
+					http.Error(w, `{"error":"unauthorized","message":"Invalid or expired session"}`, http.StatusUnauthorized)
+					return
+				}
+				ctx := WithUser(r.Context(), user.ID, string(user.Role))
+				if user.TenantID != models.DefaultTenantID {
+					next.ServeHTTP(w, r.WithContext(WithTenantScope(ctx, user.TenantID)))
+					return
+				}
+				r = r.WithContext(ctx)
+			} else if isAdmin(r) {
+				r = r.WithContext(WithUser(r.Context(), "", string(models.RoleAdmin)))
+			} else {
+				next.ServeHTTP(w, r)
+				return
 			}
-		}
 
-		// If tenant found, add to context
-		if tenantID != "" {
-			ctx := WithTenant(r.Context(), tenantID)
-			r = r.WithContext(ctx)
-		}
+			tenantID := r.Header.Get("X-Tenant-ID")
+			if tenantID == "" {
+				tenantID = r.URL.Query().Get("tenant_id")
+			}
+			if tenantID != "" {
+				if !isValidTenantID(tenantID) {
+					http.Error(w, `{"error":"invalid_tenant","message":"Invalid tenant ID format"}`, http.StatusBadRequest)
+					return
+				}
+				r = r.WithContext(WithTenant(r.Context(), tenantID))
+			}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // RequireTenant middleware ensures request has tenant context
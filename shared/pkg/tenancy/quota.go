@@ -0,0 +1,290 @@
+package tenancy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/ratelimit"
+)
+
+// Quota names reported in QuotaError
+const (
+	QuotaTotalJobs   = "max_total_jobs"
+	QuotaGPUs        = "max_gpus"
+	QuotaWorkers     = "max_workers"
+	QuotaCPUCores    = "max_cpu_cores"
+	QuotaAPIRequests = "max_api_requests_per_hour"
+)
+
+const (
+	// quotaCacheTTL bounds how long tenant quotas are cached for rate limiting
+	quotaCacheTTL = 30 * time.Second
+
+	// defaultUsageInterval is how often changed tenant usage is persisted
+	defaultUsageInterval = 10 * time.Second
+)
+
+// QuotaError reports a request that would exceed a tenant quota
+type QuotaError struct {
+	TenantID string
+	Quota    string
+	Limit    int
+	Used     int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("tenant %s has reached its %s quota (limit %d, used %d)", e.TenantID, e.Quota, e.Limit, e.Used)
+}
+
+// QuotaStore is the subset of the data store needed to enforce tenant quotas
+type QuotaStore interface {
+	GetTenant(id string) (*models.Tenant, error)
+	GetTenantStats(id string) (*models.TenantUsage, error)
+	UpdateTenantUsage(id string, usage *models.TenantUsage) error
+}
+
+// QuotaEnforcer enforces tenant quotas at submission time, rate limits API
+// requests per tenant and keeps stored tenant usage current.
+// Concurrent job quotas are enforced by the stores and scheduler, which
+// leave jobs queued while their tenant is at its limit.
+type QuotaEnforcer struct {
+	store         QuotaStore
+	limiter       *ratelimit.Limiter
+	usageInterval time.Duration
+
+	mu       sync.Mutex
+	limits   map[string]cachedLimit
+	requests map[string]*requestWindow
+	dirty    map[string]bool
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+type cachedLimit struct {
+	perHour   int
+	expiresAt time.Time
+}
+
+// requestWindow counts a tenant's API requests in the current hour
+type requestWindow struct {
+	start time.Time
+	count int
+}
+
+// NewQuotaEnforcer creates a quota enforcer backed by store
+func NewQuotaEnforcer(store QuotaStore) *QuotaEnforcer {
+	return &QuotaEnforcer{
+		store: store,
+		// Per-tenant rates are set from each tenant's quota; the default is never used
+		limiter:       ratelimit.NewLimiter(1, 1),
+		usageInterval: defaultUsageInterval,
+		limits:        make(map[string]cachedLimit),
+		requests:      make(map[string]*requestWindow),
+		dirty:         make(map[string]bool),
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// CheckJob verifies that a tenant may submit another job.
+// It returns a *QuotaError if the job would exceed the tenant's quotas.
+func (q *QuotaEnforcer) CheckJob(tenantID string, requiresGPU bool) error {
+	tenant, err := q.store.GetTenant(tenantID)
+	if err != nil {
+		return err
+	}
+	if !tenant.IsActive() {
+		return ErrTenantInactive
+	}
+
+	if requiresGPU && tenant.Quotas.MaxGPUs == 0 {
+		return &QuotaError{TenantID: tenantID, Quota: QuotaGPUs, Limit: 0}
+	}
+
+	if tenant.Quotas.MaxTotalJobs >= 0 {
+		usage, err := q.store.GetTenantStats(tenantID)
+		if err != nil {
+			return err
+		}
+		if usage.TotalJobsLifetime >= tenant.Quotas.MaxTotalJobs {
+			return &QuotaError{TenantID: tenantID, Quota: QuotaTotalJobs,
+				Limit: tenant.Quotas.MaxTotalJobs, Used: usage.TotalJobsLifetime}
+		}
+	}
+	return nil
+}
+
+// CheckNode verifies that a tenant may register another dedicated node
+func (q *QuotaEnforcer) CheckNode(tenantID string, node *models.Node) error {
+	tenant, err := q.store.GetTenant(tenantID)
+	if err != nil {
+		return err
+	}
+	if !tenant.IsActive() {
+		return ErrTenantInactive
+	}
+
+	usage, err := q.store.GetTenantStats(tenantID)
+	if err != nil {
+		return err
+	}
+
+	gpus := 0
+	if node.HasGPU {
+		gpus = 1
+	}
+	checks := []struct {
+		quota       string
+		limit, used int
+		adding      int
+	}{
+		{QuotaWorkers, tenant.Quotas.MaxWorkers, usage.CurrentWorkers, 1},
+		{QuotaCPUCores, tenant.Quotas.MaxCPUCores, usage.CurrentCPUCores, node.CPUThreads},
+		{QuotaGPUs, tenant.Quotas.MaxGPUs, usage.CurrentGPUs, gpus},
+	}
+	for _, c := range checks {
+		if c.limit >= 0 && c.adding > 0 && c.used+c.adding > c.limit {
+			return &QuotaError{TenantID: tenantID, Quota: c.quota, Limit: c.limit, Used: c.used}
+		}
+	}
+	return nil
+}
+
+// Allow records an API request for a tenant and reports whether it is within
+// the tenant's hourly request quota. If not, it returns how long to wait.
+func (q *QuotaEnforcer) Allow(tenantID string) (bool, time.Duration) {
+	perHour := q.requestLimit(tenantID)
+
+	q.mu.Lock()
+	now := time.Now()
+	window, ok := q.requests[tenantID]
+	if !ok || now.Sub(window.start) >= time.Hour {
+		window = &requestWindow{start: now}
+		q.requests[tenantID] = window
+	}
+	window.count++
+	q.mu.Unlock()
+
+	if perHour < 0 {
+		return true, 0
+	}
+	if perHour == 0 {
+		return false, time.Hour
+	}
+	q.limiter.SetKeyLimit(tenantID, float64(perHour)/3600, perHour)
+	return q.limiter.Reserve(tenantID)
+}
+
+// requestLimit returns the tenant's hourly request quota, caching it briefly
+func (q *QuotaEnforcer) requestLimit(tenantID string) int {
+	now := time.Now()
+	q.mu.Lock()
+	cached, ok := q.limits[tenantID]
+	q.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.perHour
+	}
+
+	perHour := -1
+	if tenant, err := q.store.GetTenant(tenantID); err == nil {
+		perHour = tenant.Quotas.MaxAPIRequestsPerHour
+	}
+
+	q.mu.Lock()
+	q.limits[tenantID] = cachedLimit{perHour: perHour, expiresAt: now.Add(quotaCacheTTL)}
+	q.mu.Unlock()
+	return perHour
+}
+
+// Middleware rate limits requests bound to a tenant by their tenant's hourly
+// request quota. It must be mounted after TenantMiddleware.
+func (q *QuotaEnforcer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := GetTenantID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ok, retryAfter := q.Allow(tenantID); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, `{"error":"rate_limited","message":"Tenant API request quota exceeded"}`, http.StatusTooManyRequests)
+			return
+		}
+		q.markDirty(tenantID)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NotifyJobEvent marks the job's tenant usage as changed
+func (q *QuotaEnforcer) NotifyJobEvent(job *models.Job) {
+	if job.TenantID != "" {
+		q.markDirty(job.TenantID)
+	}
+}
+
+func (q *QuotaEnforcer) markDirty(tenantID string) {
+	q.mu.Lock()
+	q.dirty[tenantID] = true
+	q.mu.Unlock()
+}
+
+// Start begins persisting changed tenant usage in the background
+func (q *QuotaEnforcer) Start() {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.usageInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				q.FlushUsage()
+			case <-q.stopCh:
+				q.FlushUsage()
+				return
+			}
+		}
+	}()
+}
+
+// Stop persists pending usage and stops the background loop
+func (q *QuotaEnforcer) Stop() {
+	close(q.stopCh)
+	q.wg.Wait()
+}
+
+// FlushUsage recomputes and stores the usage of every tenant that changed
+// since the last flush
+func (q *QuotaEnforcer) FlushUsage() {
+	q.mu.Lock()
+	dirty := q.dirty
+	q.dirty = make(map[string]bool)
+	q.mu.Unlock()
+
+	for tenantID := range dirty {
+		usage, err := q.store.GetTenantStats(tenantID)
+		if err != nil {
+			continue // Tenant may have been removed or is unknown
+		}
+
+		q.mu.Lock()
+		if window, ok := q.requests[tenantID]; ok && time.Since(window.start) < time.Hour {
+			usage.APIRequestsLastHour = window.count
+		} else {
+			usage.APIRequestsLastHour = 0
+		}
+		q.mu.Unlock()
+
+		if err := q.store.UpdateTenantUsage(tenantID, usage); err != nil {
+			log.Printf("Warning: Failed to update usage for tenant %s: %v", tenantID, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package tenancy
+
+import (
+	"crypto/sha256"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid tenant API key")
+	ErrInvalidSession = errors.New("invalid or expired session")
+	ErrTenantInactive = errors.New("tenant is not active")
+)
+
+// resolverCacheTTL bounds how long a successful bcrypt verification is reused
+const resolverCacheTTL = time.Minute
+
+// keyUsageInterval bounds how often a key's LastUsedAt is written back to the store
+const keyUsageInterval = time.Minute
+
+// KeyStore is the subset of the data store needed to resolve tenant API keys
+// and user sessions
+type KeyStore interface {
+	GetTenantAPIKeyByPrefix(prefix string) (*models.TenantAPIKey, error)
+	TouchTenantAPIKey(id string, usedAt time.Time) error
+	GetTenant(id string) (*models.Tenant, error)
+	GetSessionByTokenHash(tokenHash string) (*models.Session, error)
+	GetUser(id string) (*models.User, error)
+}
+
+// StoreResolver resolves tenant API keys against the data store.
+// Key and tenant status are checked on every request so revocation and
+// suspension take effect immediately; only the bcrypt verification, which
+// is deliberately slow, is cached.
+type StoreResolver struct {
+	store    KeyStore
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]verifiedKey
+}
+
+type verifiedKey struct {
+	keyHash   string
+	expiresAt time.Time
+}
+
+// NewStoreResolver creates a resolver backed by store
+func NewStoreResolver(store KeyStore) *StoreResolver {
+	return &StoreResolver{
+		store:    store,
+		verified: make(map[[sha256.Size]byte]verifiedKey),
+	}
+}
+
+// ResolveAPIKey verifies a tenant API key and returns its key record.
+// The key's LastUsedAt is updated at most once per keyUsageInterval.
+func (r *StoreResolver) ResolveAPIKey(apiKey string) (*models.TenantAPIKey, error) {
+	tenantName, prefix, ok := auth.ParseTenantAPIKey(apiKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := r.store.GetTenantAPIKeyByPrefix(prefix)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if !key.IsUsable() {
+		return nil, ErrInvalidAPIKey
+	}
+	if !r.verify(key.KeyHash, apiKey, now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	tenant, err := r.store.GetTenant(key.TenantID)
+	if err != nil || tenant.Name != tenantName {
+		return nil, ErrInvalidAPIKey
+	}
+	if !tenant.IsActive() {
+		return nil, ErrTenantInactive
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= keyUsageInterval {
+		if err := r.store.TouchTenantAPIKey(key.ID, now); err != nil {
+			log.Printf("Warning: Failed to record use of API key %s: %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+// ResolveSession verifies a session token and returns the session's user.
+// Sessions end when they expire, the user is suspended or deleted, or their
+// tenant is no longer active.
+func (r *StoreResolver) ResolveSession(token string) (*models.User, error) {
+	session, err := r.store.GetSessionByTokenHash(auth.HashSessionToken(token))
+	if err != nil || session.IsExpired() {
+		return nil, ErrInvalidSession
+	}
+
+	user, err := r.store.GetUser(session.UserID)
+	if err != nil || !user.IsActive() {
+		return nil, ErrInvalidSession
+	}
+
+	tenant, err := r.store.GetTenant(user.TenantID)
+	if err != nil || !tenant.IsActive() {
+		return nil, ErrTenantInactive
+	}
+
+	return user, nil
+}
+
+// verify checks apiKey against keyHash, reusing recent successful checks
+func (r *StoreResolver) verify(keyHash, apiKey string, now time.Time) bool {
+	digest := sha256.Sum256([]byte(apiKey))
+
+	r.mu.Lock()
+	cached, ok := r.verified[digest]
+	r.mu.Unlock()
+	if ok && cached.keyHash == keyHash && now.Before(cached.expiresAt) {
+		return true
+	}
+
+	if !auth.VerifyTenantAPIKey(keyHash, apiKey) {
+		return false
+	}
+
+	r.mu.Lock()
+	// Drop stale entries so the cache does not grow without bound
+	for d, v := range r.verified {
+		if now.After(v.expiresAt) {
+			delete(r.verified, d)
+		}
+	}
+	r.verified[digest] = verifiedKey{keyHash: keyHash, expiresAt: now.Add(resolverCacheTTL)}
+	r.mu.Unlock()
+	return true
+}
@@ -0,0 +1,268 @@
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// caValidity is the lifetime of a CA created by LoadOrCreateCA
+const caValidity = 10 * 365 * 24 * time.Hour
+
+// clockSkew backdates issued certificates so that hosts with slightly slow clocks accept them
+const clockSkew = 5 * time.Minute
+
+// CA is a certificate authority that issues client certificates to workers
+type CA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+}
+
+// LoadOrCreateCA loads the CA certificate and key from certFile and keyFile,
+// creating a new CA named commonName if the certificate does not exist
+func LoadOrCreateCA(certFile, keyFile, commonName string) (*CA, error) {
+	if _, err := os.Stat(certFile); os.IsNotExist(err) {
+		if err := createCA(certFile, keyFile, commonName); err != nil {
+			return nil, err
+		}
+	}
+	return LoadCA(certFile, keyFile)
+}
+
+// LoadCA loads a CA certificate and key
+func LoadCA(certFile, keyFile string) (*CA, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA key pair: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA key type %T", pair.PrivateKey)
+	}
+
+	return &CA{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+	}, nil
+}
+
+// createCA generates a self-signed CA certificate and key
+func createCA(certFile, keyFile, commonName string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"FFmpeg RTMP Distributed"},
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	return WriteKeyPair(certFile, keyFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}), keyPEM)
+}
+
+// Certificate returns the CA certificate
+func (ca *CA) Certificate() *x509.Certificate {
+	return ca.cert
+}
+
+// CertPEM returns the PEM-encoded CA certificate
+func (ca *CA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// Issued reports whether cert was signed by this CA
+func (ca *CA) Issued(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, ca.cert.RawSubject) && cert.CheckSignatureFrom(ca.cert) == nil
+}
+
+// SignClientCSR issues a client certificate for the public key in csrPEM.
+// Only the public key is taken from the CSR: the certificate is issued to
+// commonName and is only valid for client authentication.
+func (ca *CA) SignClientCSR(csrPEM []byte, commonName string, ttl time.Duration) (*x509.Certificate, []byte, error) {
+	csr, err := ParseCSR(csrPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	notAfter := now.Add(ttl)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"FFmpeg RTMP Distributed"},
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse issued certificate: %w", err)
+	}
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}), nil
+}
+
+// ParseCSR decodes a PEM-encoded certificate signing request and verifies its signature
+func ParseCSR(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("CSR is not a PEM-encoded certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSR: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid CSR signature: %w", err)
+	}
+	return csr, nil
+}
+
+// GenerateKeyAndCSR generates an ECDSA P-256 private key and a certificate
+// signing request for commonName. Both are PEM-encoded.
+func GenerateKeyAndCSR(commonName string) (keyPEM, csrPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	template := x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}
+	derBytes, err := x509.CreateCertificateRequest(rand.Reader, &template, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSR: %w", err)
+	}
+
+	keyPEM, err = encodePrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keyPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: derBytes}), nil
+}
+
+// SerialString formats a certificate serial number the way issued certificates are recorded
+func SerialString(serial *big.Int) string {
+	return fmt.Sprintf("%x", serial)
+}
+
+// RenewalDue reports whether a certificate has used up two thirds of its lifetime
+func RenewalDue(cert *x509.Certificate, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return now.After(cert.NotBefore.Add(lifetime * 2 / 3))
+}
+
+// WriteKeyPair writes a PEM certificate and private key, replacing any existing
+// files. The key is only readable by its owner.
+func WriteKeyPair(certFile, keyFile string, certPEM, keyPEM []byte) error {
+	if err := writeFileAtomic(keyFile, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	if err := writeFileAtomic(certFile, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path,
+// so readers never see a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encodePrivateKey PEM-encodes a private key in PKCS#8 form
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	privBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}), nil
+}
+
+// newSerialNumber returns a random 128-bit certificate serial number
+func newSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serialNumber, nil
+}
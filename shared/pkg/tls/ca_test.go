@@ -0,0 +1,110 @@
+package tls
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCAIssuesClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+
+	ca, err := LoadOrCreateCA(certFile, keyFile, "test-ca")
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	// A second load must reuse the CA on disk
+	reloaded, err := LoadOrCreateCA(certFile, keyFile, "other-ca")
+	if err != nil {
+		t.Fatalf("Failed to reload CA: %v", err)
+	}
+	if !reloaded.Certificate().Equal(ca.Certificate()) {
+		t.Fatal("Expected the existing CA to be loaded")
+	}
+
+	_, csrPEM, err := GenerateKeyAndCSR("requested-name")
+	if err != nil {
+		t.Fatalf("Failed to generate CSR: %v", err)
+	}
+	cert, certPEM, err := ca.SignClientCSR(csrPEM, "worker-1", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to sign CSR: %v", err)
+	}
+	if len(certPEM) == 0 {
+		t.Error("Expected PEM-encoded certificate")
+	}
+	if cert.Subject.CommonName != "worker-1" {
+		t.Errorf("Expected common name worker-1, got %q", cert.Subject.CommonName)
+	}
+	if !ca.Issued(cert) {
+		t.Error("Expected certificate to be recognized as issued by the CA")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("Expected a valid client certificate: %v", err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err == nil {
+		t.Error("Expected client certificates to be rejected for server authentication")
+	}
+
+	other, err := LoadOrCreateCA(filepath.Join(dir, "other.crt"), filepath.Join(dir, "other.key"), "test-ca")
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	if other.Issued(cert) {
+		t.Error("Expected certificate of another CA with the same name to be rejected")
+	}
+
+	if _, _, err := ca.SignClientCSR([]byte("not a csr"), "worker-1", time.Hour); err == nil {
+		t.Error("Expected invalid CSR to be rejected")
+	}
+}
+
+func TestRenewalDue(t *testing.T) {
+	now := time.Now()
+	cert := &x509.Certificate{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(2 * time.Hour)}
+	if RenewalDue(cert, now) {
+		t.Error("Expected no renewal after a third of the lifetime")
+	}
+	if !RenewalDue(cert, now.Add(90*time.Minute)) {
+		t.Error("Expected renewal after two thirds of the lifetime")
+	}
+}
+
+func TestClientCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), "test-ca")
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	var holder ClientCertificate
+	if cert, _ := holder.GetClientCertificate(nil); cert == nil || len(cert.Certificate) != 0 {
+		t.Error("Expected an empty certificate before one is loaded")
+	}
+
+	certFile, keyFile := filepath.Join(dir, "worker.crt"), filepath.Join(dir, "worker.key")
+	for _, name := range []string{"first", "second"} {
+		keyPEM, csrPEM, err := GenerateKeyAndCSR(name)
+		if err != nil {
+			t.Fatalf("Failed to generate CSR: %v", err)
+		}
+		_, certPEM, err := ca.SignClientCSR(csrPEM, name, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to sign CSR: %v", err)
+		}
+		if err := WriteKeyPair(certFile, keyFile, certPEM, keyPEM); err != nil {
+			t.Fatalf("Failed to write key pair: %v", err)
+		}
+		if err := holder.Load(certFile, keyFile); err != nil {
+			t.Fatalf("Failed to load certificate: %v", err)
+		}
+		if holder.Leaf().Subject.CommonName != name {
+			t.Errorf("Expected certificate %q, got %q", name, holder.Leaf().Subject.CommonName)
+		}
+	}
+}
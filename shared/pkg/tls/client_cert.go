@@ -0,0 +1,56 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+)
+
+// ClientCertificate holds a client certificate that can be replaced, e.g. after
+// renewal, without rebuilding the TLS configuration that presents it
+type ClientCertificate struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// Load reads the certificate and key from disk, replacing the current certificate
+func (c *ClientCertificate) Load(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+	return nil
+}
+
+// Leaf returns the current certificate, or nil if none is loaded
+func (c *ClientCertificate) Leaf() *x509.Certificate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cert == nil {
+		return nil
+	}
+	return c.cert.Leaf
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+// Without a loaded certificate the connection is made without one.
+func (c *ClientCertificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return c.cert, nil
+}
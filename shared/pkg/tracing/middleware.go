@@ -69,6 +69,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying connection
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // InjectHTTPHeaders injects trace context into HTTP request headers
 func InjectHTTPHeaders(ctx context.Context, req *http.Request) {
 	propagator := propagation.NewCompositeTextMapPropagator(
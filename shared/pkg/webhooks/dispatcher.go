@@ -0,0 +1,359 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/retry"
+)
+
+// Headers set on every webhook delivery
+const (
+	HeaderEvent     = "X-FFRTMP-Event"
+	HeaderDelivery  = "X-FFRTMP-Delivery"
+	HeaderSignature = "X-FFRTMP-Signature"
+)
+
+// ErrDeliveryPending is returned when redelivering a delivery that is still queued or in flight
+var ErrDeliveryPending = errors.New("delivery is still pending")
+
+// Store interface for webhook operations
+type Store interface {
+	ListWebhooks() ([]*models.Webhook, error)
+	GetWebhook(id string) (*models.Webhook, error)
+	SaveWebhookDelivery(delivery *models.WebhookDelivery) error
+	GetWebhookDelivery(id string) (*models.WebhookDelivery, error)
+	DeleteWebhookDeliveries(before time.Time) (int, error)
+}
+
+// Config holds webhook dispatcher configuration
+type Config struct {
+	Workers        int           // Number of concurrent delivery workers
+	QueueSize      int           // Maximum number of pending deliveries
+	RequestTimeout time.Duration // Timeout for a single delivery attempt
+	Retry          retry.Config  // Backoff between delivery attempts
+	Retention      time.Duration // How long finished deliveries are kept
+	PruneInterval  time.Duration // How often finished deliveries past Retention are deleted
+}
+
+// DefaultConfig returns sensible defaults for webhook delivery
+func DefaultConfig() Config {
+	return Config{
+		Workers:        4,
+		QueueSize:      1000,
+		RequestTimeout: 10 * time.Second,
+		Retry: retry.Config{
+			MaxRetries:     5,
+			InitialBackoff: 2 * time.Second,
+			MaxBackoff:     5 * time.Minute,
+			Multiplier:     2.0,
+		},
+		Retention:     7 * 24 * time.Hour,
+		PruneInterval: time.Hour,
+	}
+}
+
+// Dispatcher delivers signed job lifecycle events to webhook subscribers
+type Dispatcher struct {
+	config Config
+	store  Store
+	client *http.Client
+	queue  chan *models.WebhookDelivery
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	redeliverMu sync.Mutex // Serializes redelivery so a delivery is only queued once
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(config Config, store Store) *Dispatcher {
+	defaults := DefaultConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = defaults.RequestTimeout
+	}
+	if config.Retry.InitialBackoff <= 0 {
+		config.Retry = defaults.Retry
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+	if config.PruneInterval <= 0 {
+		config.PruneInterval = defaults.PruneInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		config: config,
+		store:  store,
+		client: &http.Client{Timeout: config.RequestTimeout},
+		queue:  make(chan *models.WebhookDelivery, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start launches the delivery workers
+func (d *Dispatcher) Start() {
+	log.Printf("[Webhooks] Starting dispatcher (workers: %d, max retries: %d)", d.config.Workers, d.config.Retry.MaxRetries)
+
+	for i := 0; i < d.config.Workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+
+	d.wg.Add(1)
+	go d.pruner()
+}
+
+// Stop cancels in-flight retries and waits for workers to exit
+func (d *Dispatcher) Stop() {
+	log.Println("[Webhooks] Stopping dispatcher...")
+	d.cancel()
+	d.wg.Wait()
+	log.Println("[Webhooks] Dispatcher stopped")
+}
+
+// NotifyJobEvent enqueues a delivery for every webhook subscribed to the job's current state
+func (d *Dispatcher) NotifyJobEvent(job *models.Job) {
+	if job == nil {
+		return
+	}
+
+	hooks, err := d.store.ListWebhooks()
+	if err != nil {
+		log.Printf("[Webhooks] Failed to list webhooks: %v", err)
+		return
+	}
+
+	event := models.WebhookEventForStatus(job.Status)
+	for _, hook := range hooks {
+		if !hook.Enabled || !hook.MatchesEvent(job.Status) {
+			continue
+		}
+		if hook.TenantID != "" && hook.TenantID != job.TenantID {
+			continue
+		}
+
+		delivery, err := newDelivery(hook, event, job)
+		if err != nil {
+			log.Printf("[Webhooks] Failed to build payload for webhook %s: %v", hook.ID, err)
+			continue
+		}
+		if err := d.store.SaveWebhookDelivery(delivery); err != nil {
+			log.Printf("[Webhooks] Failed to record delivery %s: %v", delivery.ID, err)
+			continue
+		}
+		d.enqueue(delivery)
+	}
+}
+
+// Redeliver re-sends a previously recorded delivery with its original payload.
+// Returns ErrDeliveryPending if the delivery is still queued or in flight.
+func (d *Dispatcher) Redeliver(deliveryID string) (*models.WebhookDelivery, error) {
+	d.redeliverMu.Lock()
+	defer d.redeliverMu.Unlock()
+
+	delivery, err := d.store.GetWebhookDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		return nil, ErrDeliveryPending
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.LastError = ""
+	if err := d.store.SaveWebhookDelivery(delivery); err != nil {
+		return nil, fmt.Errorf("failed to reset delivery: %w", err)
+	}
+
+	d.enqueue(delivery)
+	return delivery, nil
+}
+
+// enqueue hands a delivery to the workers without blocking the caller
+func (d *Dispatcher) enqueue(delivery *models.WebhookDelivery) {
+	select {
+	case d.queue <- delivery:
+	default:
+		log.Printf("[Webhooks] Delivery queue full, dropping delivery %s", delivery.ID)
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "delivery queue full"
+		if err := d.store.SaveWebhookDelivery(delivery); err != nil {
+			log.Printf("[Webhooks] Failed to record delivery %s: %v", delivery.ID, err)
+		}
+	}
+}
+
+// worker processes deliveries until the dispatcher is stopped
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		}
+	}
+}
+
+// pruner deletes old finished deliveries until the dispatcher is stopped
+func (d *Dispatcher) pruner() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.config.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.Prune()
+		}
+	}
+}
+
+// Prune deletes finished deliveries older than the retention period and
+// returns how many were deleted. Pending deliveries are kept.
+func (d *Dispatcher) Prune() int {
+	removed, err := d.store.DeleteWebhookDeliveries(time.Now().Add(-d.config.Retention))
+	if err != nil {
+		log.Printf("[Webhooks] Failed to prune deliveries: %v", err)
+		return 0
+	}
+	if removed > 0 {
+		log.Printf("[Webhooks] Pruned %d deliveries older than %v", removed, d.config.Retention)
+	}
+	return removed
+}
+
+// deliver posts a delivery with exponential backoff, recording each attempt
+func (d *Dispatcher) deliver(delivery *models.WebhookDelivery) {
+	hook, err := d.store.GetWebhook(delivery.WebhookID)
+	if err != nil {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = fmt.Sprintf("webhook unavailable: %v", err)
+		d.save(delivery)
+		return
+	}
+
+	err = retry.Do(d.ctx, d.config.Retry, func() error {
+		delivery.Attempts++
+		code, postErr := d.post(hook, delivery)
+		delivery.ResponseCode = code
+		if postErr != nil {
+			delivery.LastError = postErr.Error()
+			d.save(delivery)
+			return postErr
+		}
+		return nil
+	})
+
+	if err != nil {
+		delivery.Status = models.WebhookDeliveryFailed
+		log.Printf("[Webhooks] Delivery %s to %s failed after %d attempts: %v", delivery.ID, hook.URL, delivery.Attempts, err)
+	} else {
+		now := time.Now()
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	}
+	d.save(delivery)
+}
+
+// post performs a single signed HTTP POST to the webhook URL
+func (d *Dispatcher) post(hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ffrtmp-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// save persists delivery state, logging failures
+func (d *Dispatcher) save(delivery *models.WebhookDelivery) {
+	if err := d.store.SaveWebhookDelivery(delivery); err != nil {
+		log.Printf("[Webhooks] Failed to record delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// newDelivery builds a pending delivery with a JSON payload snapshot of the job
+func newDelivery(hook *models.Webhook, event string, job *models.Job) (*models.WebhookDelivery, error) {
+	// Logs can be large and are available via GET /jobs/{id}/logs
+	snapshot := *job
+	snapshot.Logs = ""
+
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.New().String(),
+		WebhookID: hook.ID,
+		JobID:     job.ID,
+		Event:     event,
+		Status:    models.WebhookDeliveryPending,
+		CreatedAt: time.Now(),
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		DeliveryID: delivery.ID,
+		Event:      event,
+		Timestamp:  delivery.CreatedAt,
+		Job:        &snapshot,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(payload)
+	return delivery, nil
+}
+
+// Sign returns the signature header value for a payload: "sha256=<hex HMAC-SHA256>"
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value against a payload in constant time
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
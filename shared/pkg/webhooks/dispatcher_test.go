@@ -0,0 +1,247 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/retry"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/store"
+)
+
+func testConfig() Config {
+	return Config{
+		Workers:        1,
+		QueueSize:      10,
+		RequestTimeout: time.Second,
+		Retry: retry.Config{
+			MaxRetries:     3,
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     50 * time.Millisecond,
+			Multiplier:     2.0,
+		},
+	}
+}
+
+func waitForDelivery(t *testing.T, s *store.MemoryStore, webhookID string, status string) *models.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := s.ListWebhookDeliveries(webhookID, 0)
+		if err != nil {
+			t.Fatalf("ListWebhookDeliveries failed: %v", err)
+		}
+		if len(deliveries) > 0 && deliveries[0].Status == status {
+			return deliveries[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for delivery with status %s", status)
+	return nil
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"job.completed"}`)
+	sig := Sign("secret", body)
+
+	if !Verify("secret", body, sig) {
+		t.Error("Signature should verify with the same secret")
+	}
+	if Verify("other", body, sig) {
+		t.Error("Signature should not verify with a different secret")
+	}
+	if Verify("secret", []byte(`{}`), sig) {
+		t.Error("Signature should not verify for a different body")
+	}
+}
+
+func TestDispatcherDeliversSignedEvent(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := store.NewMemoryStore()
+	hook := &models.Webhook{
+		ID:        "hook-1",
+		URL:       server.URL,
+		Events:    []string{"completed"},
+		Secret:    "s3cret",
+		Enabled:   true,
+		CreatedAt: time.Now(),
+	}
+	if err := s.CreateWebhook(hook); err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+
+	d := NewDispatcher(testConfig(), s)
+	d.Start()
+	defer d.Stop()
+
+	// Not subscribed: no delivery
+	d.NotifyJobEvent(&models.Job{ID: "job-1", Status: models.JobStatusRunning})
+	// Subscribed
+	d.NotifyJobEvent(&models.Job{ID: "job-1", Status: models.JobStatusCompleted, Logs: "large output"})
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not delivered")
+	}
+
+	if got := req.Header.Get(HeaderEvent); got != "job.completed" {
+		t.Errorf("Expected event header job.completed, got %s", got)
+	}
+	if !Verify("s3cret", body, req.Header.Get(HeaderSignature)) {
+		t.Error("Delivery signature did not verify")
+	}
+
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if payload.Job == nil || payload.Job.ID != "job-1" {
+		t.Errorf("Expected payload for job-1, got %+v", payload.Job)
+	}
+	if payload.Job.Logs != "" {
+		t.Error("Expected logs to be stripped from payload")
+	}
+
+	delivery := waitForDelivery(t, s, hook.ID, models.WebhookDeliverySucceeded)
+	if delivery.Attempts != 1 || delivery.ResponseCode != http.StatusOK {
+		t.Errorf("Unexpected delivery record: attempts=%d code=%d", delivery.Attempts, delivery.ResponseCode)
+	}
+
+	deliveries, _ := s.ListWebhookDeliveries(hook.ID, 0)
+	if len(deliveries) != 1 {
+		t.Errorf("Expected 1 delivery, got %d", len(deliveries))
+	}
+}
+
+func TestDispatcherRetriesFailedDelivery(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s := store.NewMemoryStore()
+	hook := &models.Webhook{ID: "hook-1", URL: server.URL, Enabled: true, CreatedAt: time.Now()}
+	s.CreateWebhook(hook)
+
+	d := NewDispatcher(testConfig(), s)
+	d.Start()
+	defer d.Stop()
+
+	d.NotifyJobEvent(&models.Job{ID: "job-1", Status: models.JobStatusFailed})
+
+	delivery := waitForDelivery(t, s, hook.ID, models.WebhookDeliverySucceeded)
+	if delivery.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", delivery.Attempts)
+	}
+	if delivery.DeliveredAt == nil {
+		t.Error("Expected DeliveredAt to be set")
+	}
+}
+
+func TestDispatcherTenantFilter(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateWebhook(&models.Webhook{ID: "hook-1", URL: "http://127.0.0.1:1", TenantID: "tenant-a", Enabled: true})
+	s.CreateWebhook(&models.Webhook{ID: "hook-2", URL: "http://127.0.0.1:1", Enabled: false})
+
+	// Not started: deliveries are recorded but not sent
+	d := NewDispatcher(testConfig(), s)
+	d.NotifyJobEvent(&models.Job{ID: "job-1", TenantID: "tenant-b", Status: models.JobStatusCompleted})
+
+	for _, id := range []string{"hook-1", "hook-2"} {
+		deliveries, _ := s.ListWebhookDeliveries(id, 0)
+		if len(deliveries) != 0 {
+			t.Errorf("Expected no deliveries for %s, got %d", id, len(deliveries))
+		}
+	}
+
+	d.NotifyJobEvent(&models.Job{ID: "job-2", TenantID: "tenant-a", Status: models.JobStatusCompleted})
+	deliveries, _ := s.ListWebhookDeliveries("hook-1", 0)
+	if len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliveryPending {
+		t.Errorf("Expected 1 pending delivery for tenant-a, got %+v", deliveries)
+	}
+}
+
+func TestDispatcherRedeliverPending(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateWebhook(&models.Webhook{ID: "hook-1", URL: "http://127.0.0.1:1", Enabled: true})
+
+	// Not started: the delivery stays pending in the queue
+	d := NewDispatcher(testConfig(), s)
+	d.NotifyJobEvent(&models.Job{ID: "job-1", Status: models.JobStatusTimedOut})
+	deliveries, _ := s.ListWebhookDeliveries("hook-1", 0)
+	if len(deliveries) != 1 || deliveries[0].Event != "job.timed_out" {
+		t.Fatalf("Expected 1 job.timed_out delivery, got %+v", deliveries)
+	}
+
+	if _, err := d.Redeliver(deliveries[0].ID); err != ErrDeliveryPending {
+		t.Errorf("Expected ErrDeliveryPending, got %v", err)
+	}
+	if len(d.queue) != 1 {
+		t.Errorf("Expected the delivery to be queued once, got %d", len(d.queue))
+	}
+
+	delivery := deliveries[0]
+	delivery.Status = models.WebhookDeliveryFailed
+	s.SaveWebhookDelivery(delivery)
+	if _, err := d.Redeliver(delivery.ID); err != nil {
+		t.Errorf("Expected a failed delivery to be redelivered, got %v", err)
+	}
+	if len(d.queue) != 2 {
+		t.Errorf("Expected the failed delivery to be queued again, got %d", len(d.queue))
+	}
+}
+
+func TestDispatcherPrunesFinishedDeliveries(t *testing.T) {
+	s := store.NewMemoryStore()
+	config := testConfig()
+	config.Retention = time.Hour
+	d := NewDispatcher(config, s)
+
+	old := time.Now().Add(-2 * time.Hour)
+	deliveries := []*models.WebhookDelivery{
+		{ID: "old-succeeded", WebhookID: "hook-1", Status: models.WebhookDeliverySucceeded, CreatedAt: old},
+		{ID: "old-failed", WebhookID: "hook-1", Status: models.WebhookDeliveryFailed, CreatedAt: old},
+		{ID: "old-pending", WebhookID: "hook-1", Status: models.WebhookDeliveryPending, CreatedAt: old},
+		{ID: "recent-succeeded", WebhookID: "hook-1", Status: models.WebhookDeliverySucceeded, CreatedAt: time.Now()},
+	}
+	for _, delivery := range deliveries {
+		if err := s.SaveWebhookDelivery(delivery); err != nil {
+			t.Fatalf("SaveWebhookDelivery failed: %v", err)
+		}
+	}
+
+	if removed := d.Prune(); removed != 2 {
+		t.Errorf("Expected 2 deliveries pruned, got %d", removed)
+	}
+
+	for _, id := range []string{"old-pending", "recent-succeeded"} {
+		if _, err := s.GetWebhookDelivery(id); err != nil {
+			t.Errorf("Expected delivery %s to be kept: %v", id, err)
+		}
+	}
+	for _, id := range []string{"old-succeeded", "old-failed"} {
+		if _, err := s.GetWebhookDelivery(id); err == nil {
+			t.Errorf("Expected delivery %s to be pruned", id)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package wrapper
 
+import "github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+
 // Constraints defines OS-level resource constraints
 type Constraints struct {
 	// CPU constraints
@@ -93,3 +95,25 @@ func (c *Constraints) Validate() error {
 	
 	return nil
 }
+
+// Limits converts the cgroup constraints to cgroup limits.
+// Nice priority and OOM score are per process and applied separately.
+func (c *Constraints) Limits() *cgroups.Limits {
+	limits := &cgroups.Limits{
+		CPUMax:    cgroups.CPUPercent(c.CPUQuotaPercent),
+		MemoryMax: c.MemoryLimitMB * cgroups.MiB,
+		IOWeight:  c.IOWeightPercent * 100, // io.weight is 1-10000
+	}
+	
+	// Default weight is what the kernel uses anyway
+	if c.CPUWeight != 100 {
+		limits.CPUWeight = c.CPUWeight
+	}
+	
+	// MemorySwapMB is memory + swap, the limits count swap on top of memory
+	if c.MemoryLimitMB > 0 && c.MemorySwapMB > c.MemoryLimitMB {
+		limits.MemorySwapMax = (c.MemorySwapMB - c.MemoryLimitMB) * cgroups.MiB
+	}
+	
+	return limits
+}
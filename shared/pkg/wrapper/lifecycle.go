@@ -16,6 +16,7 @@ const (
 	StateCompleted LifecycleState = "completed"
 	StateFailed    LifecycleState = "failed"
 	StateKilled    LifecycleState = "killed"
+	StateStalled   LifecycleState = "stalled" // No progress for the supervision window
 )
 
 // ExitReason describes why a workload terminated
@@ -0,0 +1,67 @@
+package wrapper
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+)
+
+// ApplyNicePriority applies nice priority to a process
+// This is a fallback when cgroups are not available or for fine-grained control
+func ApplyNicePriority(pid int, niceness int) error {
+	// Validate niceness range
+	if niceness < -20 {
+		niceness = -20
+	}
+	if niceness > 19 {
+		niceness = 19
+	}
+	
+	if niceness == 0 {
+		// No change needed
+		return nil
+	}
+	
+	log.Printf("[wrapper] Applying nice priority %d to PID %d", niceness, pid)
+	
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, niceness); err != nil {
+		// Negative nice values require privilege
+		if niceness < 0 && os.Geteuid() != 0 {
+			log.Printf("[wrapper] WARNING: Cannot set negative nice (requires root), using 0")
+			return nil
+		}
+		return fmt.Errorf("failed to set process priority: %w", err)
+	}
+	
+	return nil
+}
+
+// ApplyOOMScoreAdj applies OOM score adjustment to a process
+func ApplyOOMScoreAdj(pid int, score int) error {
+	if score == 0 {
+		return nil
+	}
+	
+	// Validate range
+	if score < -1000 {
+		score = -1000
+	}
+	if score > 1000 {
+		score = 1000
+	}
+	
+	oomScoreFile := fmt.Sprintf("/proc/%d/oom_score_adj", pid)
+	
+	if err := os.WriteFile(oomScoreFile, []byte(fmt.Sprintf("%d", score)), 0644); err != nil {
+		// Negative values require privilege
+		if score < 0 && os.Geteuid() != 0 {
+			log.Printf("[wrapper] WARNING: Cannot set negative OOM score (requires root)")
+			return nil
+		}
+		return fmt.Errorf("failed to set OOM score: %w", err)
+	}
+	
+	log.Printf("[wrapper] Applied OOM score adjustment: %d to PID %d", score, pid)
+	return nil
+}
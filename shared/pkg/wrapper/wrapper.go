@@ -9,6 +9,8 @@ import (
 	"os/exec"
 	"syscall"
 	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 )
 
 // Wrapper wraps an existing workload with OS-level governance
@@ -16,11 +18,14 @@ import (
 type Wrapper struct {
 	metadata  *WorkloadMetadata
 	constraints *Constraints
-	cgroup    *CgroupManager
+	cgroup    *cgroups.Manager
 	pid       int
 	cmd       *exec.Cmd // Only set in Run mode
 	attached  bool      // True if we attached to existing process
 	cgroupPath string
+	limits    []cgroups.Outcome
+	accounting *cgroups.Accountant
+	usage     *cgroups.Stats
 	
 	// Lifecycle tracking
 	startTime time.Time
@@ -45,12 +50,10 @@ func New(metadata *WorkloadMetadata, constraints *Constraints) *Wrapper {
 	metadata.Validate()
 	constraints.Validate()
 	
-	cgroup := NewCgroupManager("ffrtmp-wrapper")
-	
 	return &Wrapper{
 		metadata:    metadata,
 		constraints: constraints,
-		cgroup:      cgroup,
+		cgroup:      cgroups.New(),
 		events:      []LifecycleEvent{},
 	}
 }
@@ -134,17 +137,26 @@ func (w *Wrapper) Attach(ctx context.Context, pid int) error {
 func (w *Wrapper) applyConstraints() error {
 	log.Printf("[wrapper] Applying constraints to PID %d", w.pid)
 	
-	// Create or join cgroup
-	cgroupPath, err := w.cgroup.CreateOrJoinCgroup(w.metadata.JobID, w.constraints)
-	if err != nil {
-		return fmt.Errorf("failed to create cgroup: %w", err)
+	// Create and join cgroup, then apply limits (best effort, outcome recorded per limit)
+	limits := w.constraints.Limits()
+	cgroupPath, err := w.cgroup.Create(w.metadata.JobID)
+	switch {
+	case err != nil:
+		w.limits = w.cgroup.Skip(limits, fmt.Sprintf("cgroup not created: %v", err))
+	case cgroupPath == "":
+		w.limits = w.cgroup.Skip(limits, "cgroup not created: "+w.cgroup.Delegation().Reason)
+	default:
+		w.cgroupPath = cgroupPath
+		if err := w.cgroup.Join(cgroupPath, w.pid); err != nil {
+			w.limits = w.cgroup.Skip(limits, fmt.Sprintf("failed to join cgroup: %v", err))
+		} else {
+			w.limits = w.cgroup.Apply(cgroupPath, limits)
+			w.accounting = w.cgroup.Account(cgroupPath, cgroups.DefaultAccountingInterval)
+		}
 	}
-	w.cgroupPath = cgroupPath
-	
-	// Attach process to cgroup
-	if cgroupPath != "" {
-		if err := w.cgroup.AttachProcess(cgroupPath, w.pid); err != nil {
-			log.Printf("[wrapper] WARNING: Failed to attach to cgroup: %v", err)
+	for _, o := range w.limits {
+		if o.Status != cgroups.StatusApplied {
+			log.Printf("[wrapper] WARNING: %s %s: %s", o.Limit, o.Status, o.Reason)
 		}
 	}
 	
@@ -245,9 +257,14 @@ func (w *Wrapper) monitorAttached(ctx context.Context) error {
 func (w *Wrapper) cleanup() {
 	log.Printf("[wrapper] Cleaning up...")
 	
+	// Read the final usage before the cgroup is gone
+	if w.accounting != nil {
+		w.usage = w.accounting.Stop()
+	}
+	
 	// Remove cgroup
 	if w.cgroupPath != "" {
-		if err := w.cgroup.RemoveCgroup(w.cgroupPath); err != nil {
+		if err := w.cgroup.Delete(w.cgroupPath); err != nil {
 			log.Printf("[wrapper] WARNING: Failed to remove cgroup: %v", err)
 		}
 	}
@@ -282,6 +299,16 @@ func (w *Wrapper) GetExitReason() ExitReason {
 	return w.exitReason
 }
 
+// GetLimits returns the outcome of every cgroup limit
+func (w *Wrapper) GetLimits() []cgroups.Outcome {
+	return w.limits
+}
+
+// GetUsage returns the resource usage of the workload cgroup (nil without one)
+func (w *Wrapper) GetUsage() *cgroups.Stats {
+	return w.usage
+}
+
 // GetDuration returns how long the workload ran
 func (w *Wrapper) GetDuration() time.Duration {
 	return time.Since(w.startTime)
@@ -298,6 +325,23 @@ func (w *Wrapper) WriteReport(out io.Writer) error {
 	fmt.Fprintf(out, "Duration: %.2fs\n", w.GetDuration().Seconds())
 	fmt.Fprintf(out, "Exit Code: %d\n", w.exitCode)
 	fmt.Fprintf(out, "Exit Reason: %s\n", w.exitReason)
+	if len(w.limits) > 0 {
+		fmt.Fprintf(out, "\nLimits:\n")
+		for _, o := range w.limits {
+			fmt.Fprintf(out, "  %s: %s", o.Limit, o.Status)
+			if o.Reason != "" {
+				fmt.Fprintf(out, " (%s)", o.Reason)
+			}
+			fmt.Fprintf(out, "\n")
+		}
+	}
+	if u := w.usage; u != nil {
+		fmt.Fprintf(out, "\nUsage:\n")
+		fmt.Fprintf(out, "  CPU: %.1fs\n", u.CPUSeconds)
+		fmt.Fprintf(out, "  Peak Memory: %d MB\n", u.MemoryPeak/cgroups.MiB)
+		fmt.Fprintf(out, "  IO Read/Write: %d/%d MB\n", u.IOReadBytes/cgroups.MiB, u.IOWriteBytes/cgroups.MiB)
+		fmt.Fprintf(out, "  OOM Events/Kills: %d/%d\n", u.OOMEvents, u.OOMKills)
+	}
 	fmt.Fprintf(out, "\nLifecycle Events:\n")
 	for _, event := range w.events {
 		fmt.Fprintf(out, "  [%s] %s: %s\n", 
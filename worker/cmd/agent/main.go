@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,11 +21,15 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/psantana5/ffmpeg-rtmp/internal/cgroups"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"github.com/psantana5/ffmpeg-rtmp/internal/discover"
+	"github.com/psantana5/ffmpeg-rtmp/internal/report"
+	"github.com/psantana5/ffmpeg-rtmp/internal/wrapper"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/agent"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/auth"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/logging"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/models"
+	"github.com/psantana5/ffmpeg-rtmp/pkg/secrets"
 	"github.com/psantana5/ffmpeg-rtmp/pkg/shutdown"
 	tlsutil "github.com/psantana5/ffmpeg-rtmp/pkg/tls"
 	"github.com/psantana5/ffmpeg-rtmp/worker/exporters/prometheus"
@@ -31,7 +38,22 @@ import (
 
 var logger *logging.Logger
 
+// logRedactor hides the secret values of running jobs from the job execution log
+var logRedactor = secrets.NewRedactor()
+
+// jobGovernor adjusts the cgroup limits of running jobs, prioritizing the live queue
+var jobGovernor *resources.Governor
+
+// stallPolicy configures stall detection for wrapper workloads
+var stallPolicy agent.StallPolicy
+
+// jobLedger records wrapper workloads until their results are delivered (nil if disabled)
+var jobLedger *agent.JobLedger
+
 func main() {
+	// Workloads run under a shim of this binary that records their exit status
+	wrapper.Shim()
+	
 	masterURL := flag.String("master", "http://localhost:8080", "Master node URL")
 	register := flag.Bool("register", false, "Register with master node")
 	pollInterval := flag.Duration("poll-interval", 10*time.Second, "Job polling interval")
@@ -43,16 +65,31 @@ func main() {
 	keyFile := flag.String("key", "", "TLS client key file (for mTLS)")
 	caFile := flag.String("ca", "", "CA certificate file to verify server")
 	insecureSkipVerify := flag.Bool("insecure-skip-verify", false, "Skip TLS certificate verification (insecure, for development only)")
+	enrollToken := flag.String("enroll-token", "", "One-time bootstrap token to enroll with the master's certificate authority (or use FFRTMP_ENROLL_TOKEN env var)")
+	pkiDir := flag.String("pki-dir", "certs/worker", "Directory for the client certificate obtained by enrollment")
 	metricsPort := flag.String("metrics-port", "9091", "Prometheus metrics port")
 	generateInput := flag.Bool("generate-input", true, "Automatically generate input videos for jobs (default: true)")
 	maxConcurrentJobs := flag.Int("max-concurrent-jobs", 1, "Maximum number of concurrent jobs to process (default: 1)")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	stallWindow := flag.Duration("stall-window", 30*time.Second, "Time a wrapper workload may show no progress (output, file growth, CPU) before it is stalled (0 to disable)")
+	stallTerminate := flag.Bool("stall-terminate", false, "Send SIGTERM to stalled wrapper workloads instead of waiting for the job timeout")
+	jobLedgerPath := flag.String("job-ledger", "/var/lib/ffrtmp/job-ledger.json", "File recording running wrapper workloads, to re-adopt them after a restart (empty to disable)")
 	
 	// Auto-attach flags
 	enableAutoAttach := flag.Bool("enable-auto-attach", false, "Enable automatic discovery and attachment to running FFmpeg processes")
 	autoAttachScanInterval := flag.Duration("auto-attach-scan-interval", 10*time.Second, "Scan interval for auto-attach (default: 10s)")
+	autoAttachDiscovery := flag.String("auto-attach-discovery", "auto", "Process discovery for auto-attach: auto (events, else polling), events or poll")
+	autoAttachRescanInterval := flag.Duration("auto-attach-rescan-interval", discover.DefaultRescanInterval, "Scan interval for auto-attach while using process events")
 	autoAttachCPUQuota := flag.Int("auto-attach-cpu-quota", 0, "Default CPU quota for auto-attached processes (0=unlimited)")
 	autoAttachMemLimit := flag.Int("auto-attach-memory-limit", 0, "Default memory limit in MB for auto-attached processes (0=unlimited)")
+	autoAttachReport := flag.Bool("auto-attach-report", true, "Report auto-attached processes to the master as external jobs")
+	
+	// Live queue prioritization flags
+	defaultPolicy := resources.DefaultGovernorPolicy()
+	prioritizeLive := flag.Bool("prioritize-live", defaultPolicy.Enabled, "Raise the CPU weight of live jobs and throttle batch jobs while a live job runs (opt-in)")
+	liveCPUWeight := flag.Int("live-cpu-weight", defaultPolicy.LiveCPUWeight, "CPU weight of live jobs (1-10000)")
+	batchCPUWeight := flag.Int("batch-cpu-weight", defaultPolicy.BatchCPUWeight, "CPU weight of batch jobs while a live job runs (1-10000)")
+	batchCPUQuota := flag.Int("batch-cpu-quota", 0, "CPU quota of batch jobs while a live job runs, in percent of one core (0=unchanged)")
 	
 	flag.Parse()
 
@@ -63,6 +100,15 @@ func main() {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 	defer logger.Close()
+	log.SetOutput(logRedactor.Writer(os.Stderr))
+
+	stallPolicy = agent.StallPolicy{Window: *stallWindow, Terminate: *stallTerminate}
+	jobGovernor = resources.NewGovernor(cgroups.New(), resources.GovernorPolicy{
+		Enabled:        *prioritizeLive,
+		LiveCPUWeight:  *liveCPUWeight,
+		BatchCPUWeight: *batchCPUWeight,
+		BatchCPUMax:    cgroups.CPUPercent(*batchCPUQuota),
+	})
 
 	// Get API key from flag or environment variable
 	apiKey := *apiKeyFlag
@@ -245,6 +291,11 @@ func main() {
 		w.Write([]byte(violationsOutput))
 	}).Methods("GET")
 	
+	// Limits endpoint: rewrites the cgroup limits of a running job
+	metricsRouter.HandleFunc("/jobs/{id}/limits", func(w http.ResponseWriter, r *http.Request) {
+		handleUpdateLimits(w, r, apiKey)
+	}).Methods("PUT")
+	
 	// Set encoder availability metrics
 	metricsExporter.SetEncoderAvailability(nvencAvailable, qsvAvailable, vaapiAvailable)
 	
@@ -260,6 +311,22 @@ func main() {
 		}
 	}()
 
+	// Enroll with the master's certificate authority when given a bootstrap token
+	// or when a certificate was enrolled on a previous start
+	if *enrollToken == "" {
+		*enrollToken = os.Getenv("FFRTMP_ENROLL_TOKEN")
+	}
+	var enrolledCert *tlsutil.ClientCertificate
+	if *enrollToken != "" || agent.HasCertificate(*pkiDir) {
+		if *certFile != "" || *keyFile != "" {
+			log.Fatalf("--cert/--key cannot be combined with certificate enrollment (--enroll-token, --pki-dir)")
+		}
+		if !strings.HasPrefix(*masterURL, "https://") {
+			log.Fatalf("Certificate enrollment requires an https:// master URL")
+		}
+		enrolledCert = &tlsutil.ClientCertificate{}
+	}
+
 	// Create client with TLS support if certificates provided
 	var client *agent.Client
 	if *certFile != "" && *keyFile != "" {
@@ -295,6 +362,10 @@ func main() {
 			log.Println("  → For production, use --ca flag to verify server certificates")
 			tlsConfig.InsecureSkipVerify = true
 		}
+		if enrolledCert != nil {
+			// Present the enrolled certificate, picking up renewals on new connections
+			tlsConfig.GetClientCertificate = enrolledCert.GetClientCertificate
+		}
 		
 		client = agent.NewClientWithTLS(*masterURL, tlsConfig)
 		if *caFile == "" && !*insecureSkipVerify && !isLocalhost {
@@ -315,6 +386,18 @@ func main() {
 	// Set client for readiness checks
 	readyClient = client
 
+	var enroller *agent.CertificateEnroller
+	if enrolledCert != nil {
+		commonName, err := os.Hostname()
+		if err != nil {
+			log.Fatalf("Failed to determine hostname for certificate enrollment: %v", err)
+		}
+		enroller = agent.NewCertificateEnroller(client, enrolledCert, *pkiDir, commonName)
+		if err := enroller.EnsureCertificate(*enrollToken); err != nil {
+			log.Fatalf("Failed to obtain client certificate: %v", err)
+		}
+	}
+
 	// Register with master if requested
 	if *register {
 		log.Println("Registering with master node...")
@@ -402,6 +485,16 @@ func main() {
 		return
 	}
 
+	// Open the job ledger left by a previous run
+	if *jobLedgerPath != "" {
+		ledger, err := agent.OpenJobLedger(*jobLedgerPath)
+		if err != nil {
+			log.Printf("WARNING: Job ledger disabled: %v", err)
+		} else {
+			jobLedger = ledger
+		}
+	}
+	
 	// Initialize graceful shutdown manager
 	shutdownMgr := shutdown.New(30 * time.Second)
 	
@@ -420,9 +513,15 @@ func main() {
 	var autoAttachService *discover.AutoAttachService
 	
 	if *enableAutoAttach {
+		discovery, err := discover.ParseDiscoveryMode(*autoAttachDiscovery)
+		if err != nil {
+			log.Fatalf("Invalid -auto-attach-discovery: %v", err)
+		}
+		
 		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 		log.Println("✓ Auto-Attach Service Enabled")
 		log.Printf("  Scan Interval: %v", *autoAttachScanInterval)
+		log.Printf("  Discovery: %s", discovery)
 		log.Printf("  CPU Quota: %d%%", *autoAttachCPUQuota)
 		log.Printf("  Memory Limit: %d MB", *autoAttachMemLimit)
 		log.Println("  Automatically discovering and governing FFmpeg/GStreamer processes")
@@ -433,18 +532,14 @@ func main() {
 			CPUWeight: 100,
 		}
 		
-		if *autoAttachCPUQuota > 0 {
-			quota := *autoAttachCPUQuota * 1000
-			limits.CPUMax = fmt.Sprintf("%d 100000", quota)
-		}
-		
-		if *autoAttachMemLimit > 0 {
-			limits.MemoryMax = int64(*autoAttachMemLimit) * 1024 * 1024
-		}
+		limits.CPUMax = cgroups.CPUPercent(*autoAttachCPUQuota)
+		limits.MemoryMax = int64(*autoAttachMemLimit) * cgroups.MiB
 		
 		// Configure auto-attach service
 		config := &discover.AttachConfig{
 			ScanInterval:   *autoAttachScanInterval,
+			Discovery:      discovery,
+			RescanInterval: *autoAttachRescanInterval,
 			TargetCommands: []string{"ffmpeg", "gst-launch-1.0"},
 			DefaultLimits:  limits,
 			Logger:         log.New(os.Stdout, "[auto-attach] ", log.LstdFlags),
@@ -457,6 +552,25 @@ func main() {
 			},
 		}
 		
+		// Report discovered processes so the master sees the whole workload
+		if *autoAttachReport {
+			reporter := agent.NewExternalJobReporter(client)
+			reporter.OnFinished = func(job *models.Job) {
+				metricsExporter.RecordJobCompletion(job, models.GetDefaultSLATimingTargets())
+			}
+			config.OnProcessAttached = func(proc *discover.Process, jobID string) {
+				reporter.Attached(externalProcess(proc))
+			}
+			config.OnProcessExited = func(proc *discover.Process, jobID string, result *report.Result) {
+				if result == nil {
+					reporter.Exited(proc.PID, -1, nil)
+					return
+				}
+				reporter.Exited(proc.PID, result.ExitCode, result.Usage)
+			}
+			log.Println("  Reporting discovered processes to the master as external jobs")
+		}
+		
 		// Create and start service
 		autoAttachService = discover.NewAutoAttachService(config)
 		
@@ -503,6 +617,11 @@ func main() {
 		logger.Info("Shutdown signal received")
 	}()
 
+	// Renew the client certificate before it expires
+	if enroller != nil {
+		go enroller.Run(time.Minute, shutdownMgr.Done())
+	}
+
 	// Start heartbeat loop
 	heartbeatTicker := time.NewTicker(*heartbeatInterval)
 	defer heartbeatTicker.Stop()
@@ -536,6 +655,44 @@ func main() {
 	activeJobsCount := 0
 	var activeJobsMutex sync.Mutex
 	var wg sync.WaitGroup
+	
+	// Re-adopt workloads a previous run left running and report the ones
+	// that finished while the agent was down with the exit status their shim
+	// recorded, instead of leaving their jobs to orphan detection
+	for _, entry := range jobLedger.Entries() {
+		if !entry.Running() {
+			log.Printf("Job %s (PID %d) finished while the agent was down", entry.JobID, entry.PID)
+			reportRecoveredJob(client, entry, entry.ExitStatus(), entry.Collect())
+			continue
+		}
+		
+		log.Printf("Re-adopting job %s (PID %d, running since %s)", entry.JobID, entry.PID, entry.StartedAt.Format(time.RFC3339))
+		activeJobsMutex.Lock()
+		activeJobsCount++
+		metricsExporter.SetActiveJobs(activeJobsCount)
+		activeJobsMutex.Unlock()
+		
+		wg.Add(1)
+		go func(entry *agent.LedgerEntry) {
+			defer wg.Done()
+			defer func() {
+				activeJobsMutex.Lock()
+				activeJobsCount--
+				metricsExporter.SetActiveJobs(activeJobsCount)
+				activeJobsMutex.Unlock()
+			}()
+			
+			limits := jobGovernor.Start(entry.JobID, entry.Queue, entry.Limits)
+			defer jobGovernor.Finish(entry.JobID)
+			result, err := entry.Readopt(context.Background(), limits)
+			if err != nil {
+				// Exited before it could be attached
+				reportRecoveredJob(client, entry, entry.ExitStatus(), entry.Collect())
+				return
+			}
+			reportRecoveredJob(client, entry, entry.ExitStatus(), result.Usage)
+		}(entry)
+	}
 
 	for {
 		select {
@@ -589,14 +746,22 @@ func main() {
 					metricsExporter.SetActiveJobs(currentActive)
 				}()
 
+				// Hide resolved secret values from logs and results
+				logRedactor.Add(j.SecretValues...)
+				defer logRedactor.Remove(j.SecretValues...)
+
 				// Execute job with hardware-optimized parameters
 				result := executeJob(j, client, ffmpegOpt, engineSelector, inputGenerator, *generateInput, metricsExporter)
+				secrets.NewRedactor(j.SecretValues...).RedactResult(result)
 
 				// Send results
 				if err := client.SendResults(result); err != nil {
 					log.Printf("Failed to send results: %v", err)
 				} else {
 					log.Printf("Results sent for job %s (status: %s)", j.ID, result.Status)
+					if err := jobLedger.Remove(j.ID); err != nil {
+						log.Printf("WARNING: Failed to update the job ledger: %v", err)
+					}
 				}
 			}(job)
 			
@@ -890,7 +1055,10 @@ func executeJob(job *models.Job, client *agent.Client, ffmpegOpt *agent.FFmpegOp
 		// Determine failure reason based on error type
 		// This is critical for platform SLA calculation
 		errorStr := err.Error()
-		if strings.Contains(errorStr, "invalid") || strings.Contains(errorStr, "bad parameter") {
+		var stallErr *agent.StallError
+		if errors.As(err, &stallErr) {
+			job.FailureReason = stallErr.Reason
+		} else if strings.Contains(errorStr, "invalid") || strings.Contains(errorStr, "bad parameter") {
 			job.FailureReason = models.FailureReasonUserError
 		} else if strings.Contains(errorStr, "network") || strings.Contains(errorStr, "connection") {
 			job.FailureReason = models.FailureReasonNetworkError
@@ -934,8 +1102,9 @@ func executeJob(job *models.Job, client *agent.Client, ffmpegOpt *agent.FFmpegOp
 			Logs:        executionLogs,
 			CompletedAt: time.Now(),
 			Metrics: map[string]interface{}{
-				"duration": duration,
-				"engine":   selectedEngine.Name(),
+				"duration":       duration,
+				"engine":         selectedEngine.Name(),
+				"failure_reason": job.FailureReason,
 			},
 		}
 	}
@@ -1214,24 +1383,30 @@ func executeEngineJob(job *models.Job, client *agent.Client, engine agent.Engine
 	}
 	
 	// Create cgroup for resource limits (best effort, will warn if fails)
-	cgroupMgr, err := resources.NewCgroupManager()
-	cgroupPath := ""
+	jobLimits := jobGovernor.Start(job.ID, job.Queue, limits.CgroupLimits())
+	defer jobGovernor.Finish(job.ID)
+	cgroupMgr := cgroups.New()
+	cgroupPath, err := cgroupMgr.Create(job.ID)
+	joinedPath := ""
 	if err != nil {
-		log.Printf("WARNING: Failed to initialize cgroup manager: %v", err)
+		log.Printf("WARNING: Failed to create cgroup: %v", err)
+	} else if cgroupPath == "" {
+		log.Printf("WARNING: Running without cgroup limits: %s", cgroupMgr.Delegation().Reason)
+	} else if err := cgroupMgr.Join(cgroupPath, pid); err != nil {
+		log.Printf("WARNING: Failed to add process to cgroup: %v", err)
 	} else {
-		cgroupPath, err = cgroupMgr.CreateCgroup(job.ID, limits)
-		if err != nil {
-			log.Printf("WARNING: Failed to create cgroup: %v", err)
-		} else if cgroupPath != "" {
-			// Add process to cgroup
-			if err := cgroupMgr.AddProcessToCgroup(cgroupPath, pid); err != nil {
-				log.Printf("WARNING: Failed to add process to cgroup: %v", err)
-			} else {
-				log.Printf("✓ Process added to cgroup: %s", cgroupPath)
+		joinedPath = cgroupPath
+		log.Printf("✓ Process added to cgroup: %s", cgroupPath)
+		for _, o := range cgroupMgr.Apply(cgroupPath, jobLimits) {
+			if o.Status != cgroups.StatusApplied {
+				log.Printf("WARNING: Limit %s %s: %s", o.Limit, o.Status, o.Reason)
 			}
 		}
 	}
 	
+	// Account resource usage of the whole cgroup, children included
+	accounting := cgroupMgr.Account(joinedPath, cgroups.DefaultAccountingInterval)
+	
 	// Monitor process for resource limits and timeout
 	doneChan := make(chan struct{})
 	canceledChan := make(chan CancellationResult, 1)
@@ -1254,9 +1429,11 @@ func executeEngineJob(job *models.Job, client *agent.Client, engine agent.Engine
 		// Job completed normally or failed
 	}
 	
+	usage := accounting.Stop()
+	
 	// Cleanup cgroup
-	if cgroupPath != "" && cgroupMgr != nil {
-		if err := cgroupMgr.RemoveCgroup(cgroupPath); err != nil {
+	if cgroupPath != "" {
+		if err := cgroupMgr.Delete(cgroupPath); err != nil {
 			log.Printf("WARNING: Failed to remove cgroup: %v", err)
 		}
 	}
@@ -1352,6 +1529,7 @@ func executeEngineJob(job *models.Job, client *agent.Client, engine agent.Engine
 		metrics = make(map[string]interface{})
 		metrics["exec_duration"] = execDuration
 	}
+	addUsageMetrics(metrics, usage)
 
 	// Generate analyzer output
 	analyzerOutput = map[string]interface{}{
@@ -1365,6 +1543,34 @@ func executeEngineJob(job *models.Job, client *agent.Client, engine agent.Engine
 	return metrics, analyzerOutput, logBuffer.String(), nil, nil
 }
 
+// externalProcess describes a discovered process for the master
+func externalProcess(proc *discover.Process) *models.ExternalProcess {
+	return &models.ExternalProcess{
+		PID:         proc.PID,
+		Command:     proc.Command,
+		CommandLine: proc.CommandLine,
+		User:        proc.Username,
+		UID:         proc.UserID,
+		WorkingDir:  proc.WorkingDir,
+		StartTime:   proc.StartTime,
+	}
+}
+
+// addUsageMetrics adds the resource usage of the job cgroup to the job metrics,
+// for per-job cost accounting
+func addUsageMetrics(metrics map[string]interface{}, usage *cgroups.Stats) {
+	if usage == nil {
+		return
+	}
+	metrics["cpu_seconds"] = usage.CPUSeconds
+	metrics["peak_memory_bytes"] = usage.MemoryPeak
+	metrics["io_read_bytes"] = usage.IOReadBytes
+	metrics["io_write_bytes"] = usage.IOWriteBytes
+	metrics["oom_events"] = usage.OOMEvents
+	metrics["oom_kills"] = usage.OOMKills
+	metrics["peak_pids"] = usage.PIDsPeak
+}
+
 // executeWithWrapperPath executes a job using the edge workload wrapper
 func executeWithWrapperPath(job *models.Job, cmdPath string, cmdName string, args []string, limits *resources.ResourceLimits, metricsExporter *prometheus.WorkerExporter) (metrics map[string]interface{}, analyzerOutput map[string]interface{}, logs string, cancelResult *CancellationResult, err error) {
 	log.Printf("🔧 Wrapper mode enabled for job %s", job.ID)
@@ -1398,8 +1604,10 @@ func executeWithWrapperPath(job *models.Job, cmdPath string, cmdName string, arg
 	defer cancel()
 	
 	// Execute with wrapper
+	jobLimits := jobGovernor.Start(job.ID, job.Queue, agent.WrapperLimits(job))
+	defer jobGovernor.Finish(job.ID)
 	startTime := time.Now()
-	result, err := agent.ExecuteWithWrapper(ctx, job, cmdPath, args)
+	result, err := agent.ExecuteWithWrapper(ctx, job, jobLimits, cmdPath, args, jobLedger, stallPolicy)
 	execDuration := time.Since(startTime).Seconds()
 	
 	// Build logs
@@ -1410,12 +1618,22 @@ func executeWithWrapperPath(job *models.Job, cmdPath string, cmdName string, arg
 	logBuffer.WriteString(fmt.Sprintf("PID: %d\n", result.PID))
 	logBuffer.WriteString(fmt.Sprintf("Exit Code: %d\n", result.ExitCode))
 	logBuffer.WriteString(fmt.Sprintf("Platform SLA: %v (%s)\n", result.PlatformSLA, result.PlatformSLAReason))
+	for _, o := range result.Limits {
+		logBuffer.WriteString(fmt.Sprintf("Limit %s (%s): %s %s\n", o.Limit, o.Controller, o.Status, o.Reason))
+	}
 	
 	if err != nil {
 		logBuffer.WriteString(fmt.Sprintf("\n=== ERROR ===\n%v\n", err))
 		return nil, nil, logBuffer.String(), nil, fmt.Errorf("wrapper execution failed: %w", err)
 	}
 	
+	// A stalled workload fails as a stall, also when the job timeout ended it
+	if result.ExitCode != 0 && result.Stall != nil {
+		stallErr := agent.NewStallError(job, cmdPath, args, result.Stall)
+		logBuffer.WriteString(fmt.Sprintf("\n=== STALLED ===\n%v\n", stallErr))
+		return nil, nil, logBuffer.String(), nil, stallErr
+	}
+	
 	// Check exit code
 	if result.ExitCode != 0 {
 		logBuffer.WriteString(fmt.Sprintf("\n=== NON-ZERO EXIT ===\nProcess exited with code %d\n", result.ExitCode))
@@ -1433,6 +1651,10 @@ func executeWithWrapperPath(job *models.Job, cmdPath string, cmdName string, arg
 		"exit_code":       result.ExitCode,
 		"pid":             result.PID,
 	}
+	if len(result.Limits) > 0 {
+		metrics["wrapper_limits"] = result.Limits
+	}
+	addUsageMetrics(metrics, result.Usage)
 	
 	// Determine output mode
 	outputMode := "file"
@@ -1456,6 +1678,134 @@ func executeWithWrapperPath(job *models.Job, cmdPath string, cmdName string, arg
 	return metrics, analyzerOutput, logBuffer.String(), nil, nil
 }
 
+// reportRecoveredJob reports the result of a workload started by a previous
+// run of the agent. exitStatus is what the workload's shim recorded, nil if
+// it was lost; the job then fails as exit_unknown, which the master does not
+// retry, instead of trusting a possibly partial output. Jobs the master
+// reassigned or finished in the meantime are not reported.
+func reportRecoveredJob(client *agent.Client, entry *agent.LedgerEntry, exitStatus *wrapper.ExitStatus, usage *cgroups.Stats) {
+	job, err := client.GetJob(entry.JobID)
+	if err == nil && (job.NodeID != client.GetNodeID() || models.IsTerminalState(job.Status)) {
+		log.Printf("Job %s is %s on node %q; not reporting the recovered workload", entry.JobID, job.Status, job.NodeID)
+		if err := jobLedger.Remove(entry.JobID); err != nil {
+			log.Printf("WARNING: Failed to update the job ledger: %v", err)
+		}
+		return
+	}
+	
+	execDuration := time.Since(entry.StartedAt).Seconds()
+	var logBuffer bytes.Buffer
+	logBuffer.WriteString("=== Recovered After Agent Restart ===\n")
+	logBuffer.WriteString(fmt.Sprintf("Command: %s\n", entry.Command))
+	logBuffer.WriteString(fmt.Sprintf("PID: %d\n", entry.PID))
+	logBuffer.WriteString(fmt.Sprintf("Started: %s\n", entry.StartedAt.Format(time.RFC3339)))
+	exitCode := -1
+	switch {
+	case exitStatus == nil:
+		logBuffer.WriteString("Exit Code: unknown (lost with the previous agent)\n")
+	case exitStatus.Signal != "":
+		logBuffer.WriteString(fmt.Sprintf("Exit Code: killed by signal (%s)\n", exitStatus.Signal))
+	default:
+		exitCode = exitStatus.ExitCode
+		logBuffer.WriteString(fmt.Sprintf("Exit Code: %d\n", exitCode))
+	}
+	
+	metrics := map[string]interface{}{
+		"exec_duration":   execDuration,
+		"wrapper_enabled": true,
+		"recovered":       true,
+		"exit_code":       exitCode,
+		"pid":             entry.PID,
+	}
+	addUsageMetrics(metrics, usage)
+	if entry.OutputPath != "" && entry.OutputWritten() {
+		logBuffer.WriteString(fmt.Sprintf("Output: %s (%d bytes)\n", entry.OutputPath, getFileSize(entry.OutputPath)))
+		metrics["output_file_bytes"] = getFileSize(entry.OutputPath)
+	}
+	
+	status, failureReason, reason := entry.RecoveredStatus(exitStatus)
+	result := &models.JobResult{
+		JobID:         entry.JobID,
+		NodeID:        client.GetNodeID(),
+		Status:        status,
+		Error:         reason,
+		FailureReason: failureReason,
+		Metrics:       metrics,
+		CompletedAt:   time.Now(),
+	}
+	if reason != "" {
+		logBuffer.WriteString(fmt.Sprintf("\n=== ERROR ===\n%s\n", reason))
+	}
+	result.Logs = logBuffer.String()
+	
+	if err := client.SendResults(result); err != nil {
+		log.Printf("Failed to send results of recovered job %s: %v", entry.JobID, err)
+		return
+	}
+	log.Printf("Results sent for recovered job %s (status: %s)", entry.JobID, result.Status)
+	if err := jobLedger.Remove(entry.JobID); err != nil {
+		log.Printf("WARNING: Failed to update the job ledger: %v", err)
+	}
+}
+
+// handleUpdateLimits rewrites the cgroup limits of a running job.
+// The body is the job's wrapper constraints; constraints that are not set
+// are left as they are. Without an API key only local requests are accepted,
+// since the metrics port is reachable from other hosts.
+func handleUpdateLimits(w http.ResponseWriter, r *http.Request, apiKey string) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	if apiKey != "" {
+		if !auth.SecureCompare(r.Header.Get("Authorization"), "Bearer "+apiKey) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid or missing API key"})
+			return
+		}
+	} else if !isLocalRequest(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "updating limits from another host requires a worker API key"})
+		return
+	}
+	
+	jobID := mux.Vars(r)["id"]
+	var constraints models.WrapperConstraints
+	if err := json.NewDecoder(r.Body).Decode(&constraints); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	
+	outcomes, err := jobGovernor.Update(jobID, agent.ConstraintLimits(&constraints))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, cgroups.ErrNoCgroup) {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	
+	log.Printf("Updated limits of job %s", jobID)
+	for _, o := range outcomes {
+		log.Printf("  Limit %s: %s %s", o.Limit, o.Status, o.Reason)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id": jobID,
+		"limits": outcomes,
+	})
+}
+
+// isLocalRequest reports whether a request comes from a loopback address
+func isLocalRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // getFileSize safely gets the size of a file in bytes
 func getFileSize(filePath string) int64 {
 	if filePath == "" {
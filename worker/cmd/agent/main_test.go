@@ -1,6 +1,9 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -65,3 +68,24 @@ func TestIsMasterAsWorker(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleUpdateLimitsWithoutAPIKey(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		expected   int
+	}{
+		{"192.0.2.10:40000", http.StatusForbidden},
+		{"127.0.0.1:40000", http.StatusBadRequest}, // Accepted, then rejected for its body
+		{"[::1]:40000", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/jobs/job-1/limits", strings.NewReader("not json"))
+		req.RemoteAddr = tt.remoteAddr
+		w := httptest.NewRecorder()
+		handleUpdateLimits(w, req, "")
+		if w.Code != tt.expected {
+			t.Errorf("Request from %s: status %d, expected %d", tt.remoteAddr, w.Code, tt.expected)
+		}
+	}
+}
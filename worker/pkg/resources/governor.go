@@ -0,0 +1,259 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+)
+
+// Queues the governor treats differently
+const (
+	QueueLive  = "live"
+	QueueBatch = "batch"
+)
+
+// defaultCPUWeight is the kernel default CPU weight, restored when a job had none
+const defaultCPUWeight = 100
+
+// How often and how long the governor retries a CPU limit change for a job
+// whose cgroup has not been created yet
+const (
+	defaultCgroupRetryInterval = 100 * time.Millisecond
+	defaultCgroupRetryTimeout  = 30 * time.Second
+)
+
+// GovernorPolicy configures how live jobs are prioritized over batch jobs
+type GovernorPolicy struct {
+	Enabled        bool
+	LiveCPUWeight  int    // CPU weight of live jobs
+	BatchCPUWeight int    // CPU weight of batch jobs while a live job runs
+	BatchCPUMax    string // CPU max of batch jobs while a live job runs, "" = unchanged
+}
+
+// DefaultGovernorPolicy returns a disabled policy that, once enabled, gives
+// live jobs 100x the CPU share of batch jobs while both run
+func DefaultGovernorPolicy() GovernorPolicy {
+	return GovernorPolicy{
+		LiveCPUWeight:  1000,
+		BatchCPUWeight: 10,
+	}
+}
+
+// governedJob is a running job and the limits it was given
+type governedJob struct {
+	queue   string
+	base    *cgroups.Limits
+	waiting bool // A retry waits for the job's cgroup to be created
+}
+
+// Governor adjusts the cgroup limits of running jobs: live jobs get a higher
+// CPU weight and batch jobs are throttled while a live job runs on this node,
+// so the live queue does not wait for batch encodes.
+// All changes are best effort, like every other limit.
+type Governor struct {
+	mu     sync.Mutex
+	mgr    *cgroups.Manager
+	policy GovernorPolicy
+	jobs   map[string]*governedJob
+
+	retryInterval time.Duration
+	retryTimeout  time.Duration
+}
+
+// NewGovernor creates a governor that updates job cgroups through mgr
+func NewGovernor(mgr *cgroups.Manager, policy GovernorPolicy) *Governor {
+	return &Governor{
+		mgr:           mgr,
+		policy:        policy,
+		jobs:          make(map[string]*governedJob),
+		retryInterval: defaultCgroupRetryInterval,
+		retryTimeout:  defaultCgroupRetryTimeout,
+	}
+}
+
+// Start registers a job before its cgroup is created and returns the limits
+// to create it with. A live job throttles the batch jobs already running.
+func (g *Governor) Start(jobID, queue string, base *cgroups.Limits) *cgroups.Limits {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	job := &governedJob{queue: queue, base: base.Merge(nil)}
+	if queue == QueueLive && g.policy.Enabled && g.liveJobs() == 0 {
+		for id, other := range g.jobs {
+			if other.queue == QueueBatch {
+				g.update(id, other, g.throttle())
+			}
+		}
+	}
+	g.jobs[jobID] = job
+	return g.effective(job)
+}
+
+// Finish unregisters a job. When the last live job finishes the batch jobs
+// get their own CPU limits back.
+func (g *Governor) Finish(jobID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	job, ok := g.jobs[jobID]
+	if !ok {
+		return
+	}
+	delete(g.jobs, jobID)
+	if job.queue != QueueLive || !g.policy.Enabled || g.liveJobs() > 0 {
+		return
+	}
+	for id, other := range g.jobs {
+		if other.queue == QueueBatch {
+			g.update(id, other, restore(other.base))
+		}
+	}
+}
+
+// Update changes the limits of a running job. Limits that are not set are
+// left as they are. While the policy overrides the CPU limits of a job, new
+// CPU limits are kept and take effect when the override ends.
+func (g *Governor) Update(jobID string, limits *cgroups.Limits) ([]cgroups.Outcome, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	job, ok := g.jobs[jobID]
+	if !ok {
+		return g.mgr.Update(jobID, limits) // Not started by this worker
+	}
+	job.base = job.base.Merge(limits)
+
+	var deferred []cgroups.Outcome
+	applied := limits.Merge(nil)
+	if override := g.override(job.queue); override != nil {
+		reason := fmt.Sprintf("deferred while the %s queue policy applies", job.queue)
+		if applied.CPUWeight > 0 && override.CPUWeight > 0 {
+			applied.CPUWeight = 0
+			deferred = append(deferred, cgroups.Outcome{Limit: "cpu_weight", Controller: "cpu", Status: cgroups.StatusSkipped, Reason: reason})
+		}
+		if applied.CPUMax != "" && override.CPUMax != "" {
+			applied.CPUMax = ""
+			deferred = append(deferred, cgroups.Outcome{Limit: "cpu_max", Controller: "cpu", Status: cgroups.StatusSkipped, Reason: reason})
+		}
+	}
+	if *applied == (cgroups.Limits{}) {
+		return deferred, nil
+	}
+
+	outcomes, err := g.mgr.Update(jobID, applied)
+	return append(outcomes, deferred...), err
+}
+
+// effective returns the limits of a job with the policy applied
+func (g *Governor) effective(job *governedJob) *cgroups.Limits {
+	return job.base.Merge(g.override(job.queue))
+}
+
+// override returns the CPU limits the policy currently imposes on a queue
+func (g *Governor) override(queue string) *cgroups.Limits {
+	if !g.policy.Enabled {
+		return nil
+	}
+	switch {
+	case queue == QueueLive && g.policy.LiveCPUWeight > 0:
+		return &cgroups.Limits{CPUWeight: g.policy.LiveCPUWeight}
+	case queue == QueueBatch && g.liveJobs() > 0:
+		return g.throttle()
+	}
+	return nil
+}
+
+// throttle returns the CPU limits of batch jobs while a live job runs
+func (g *Governor) throttle() *cgroups.Limits {
+	return &cgroups.Limits{CPUWeight: g.policy.BatchCPUWeight, CPUMax: g.policy.BatchCPUMax}
+}
+
+// liveJobs returns the number of running live jobs
+func (g *Governor) liveJobs() int {
+	n := 0
+	for _, job := range g.jobs {
+		if job.queue == QueueLive {
+			n++
+		}
+	}
+	return n
+}
+
+// current returns the CPU limits a job should have under the policy right now
+func (g *Governor) current(job *governedJob) *cgroups.Limits {
+	if override := g.override(job.queue); override != nil {
+		return override
+	}
+	return restore(job.base)
+}
+
+// update applies limits to a running job, logging failures. A job registered
+// with Start may not have its cgroup yet; it then gets its current CPU limits
+// once the cgroup exists.
+func (g *Governor) update(jobID string, job *governedJob, limits *cgroups.Limits) {
+	outcomes, err := g.mgr.Update(jobID, limits)
+	if errors.Is(err, cgroups.ErrNoCgroup) && g.mgr.Delegation().Writable {
+		if !job.waiting {
+			job.waiting = true
+			go g.waitForCgroup(jobID, job)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("WARNING: Failed to update limits of job %s: %v", jobID, err)
+		return
+	}
+	for _, o := range outcomes {
+		if o.Status != cgroups.StatusApplied {
+			log.Printf("WARNING: Limit %s of job %s %s: %s", o.Limit, jobID, o.Status, o.Reason)
+		}
+	}
+}
+
+// restore returns the CPU limits that undo a throttle, back to the job's own
+func restore(base *cgroups.Limits) *cgroups.Limits {
+	limits := &cgroups.Limits{CPUWeight: base.CPUWeight, CPUMax: base.CPUMax}
+	if limits.CPUWeight == 0 {
+		limits.CPUWeight = defaultCPUWeight
+	}
+	if limits.CPUMax == "" {
+		limits.CPUMax = "max"
+	}
+	return limits
+}
+
+// waitForCgroup applies the current CPU limits of a job once its cgroup has
+// been created. The worker applies the limits returned by Start right after
+// creating the cgroup, so they are overridden one retry interval later.
+func (g *Governor) waitForCgroup(jobID string, job *governedJob) {
+	deadline := time.Now().Add(g.retryTimeout)
+	created := false
+	for time.Now().Before(deadline) {
+		time.Sleep(g.retryInterval)
+
+		g.mu.Lock()
+		if g.jobs[jobID] != job {
+			g.mu.Unlock()
+			return // Finished
+		}
+		if !created {
+			_, err := g.mgr.Lookup(jobID)
+			created = err == nil
+			g.mu.Unlock()
+			continue
+		}
+		job.waiting = false
+		g.update(jobID, job, g.current(job))
+		g.mu.Unlock()
+		return
+	}
+
+	g.mu.Lock()
+	job.waiting = false
+	g.mu.Unlock()
+	log.Printf("WARNING: Job %s has no cgroup after %s, its CPU limits were not updated", jobID, g.retryTimeout)
+}
@@ -0,0 +1,165 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
+)
+
+// startJob registers a job with the governor and creates its cgroup with the
+// limits the governor returns, as the worker does
+func startJob(t *testing.T, g *Governor, mgr *cgroups.Manager, jobID, queue string, base *cgroups.Limits) string {
+	t.Helper()
+	limits := g.Start(jobID, queue, base)
+	path, err := mgr.Create(jobID)
+	if err != nil || path == "" {
+		t.Fatalf("Create(%s) = %q, %v", jobID, path, err)
+	}
+	mgr.Apply(path, limits)
+	return path
+}
+
+func TestGovernorThrottlesBatchWhileLiveRuns(t *testing.T) {
+	fake := cgroups.NewFakeFS(2, "cpu", "memory")
+	mgr := cgroups.NewWithFS(fake, cgroups.DefaultRoot)
+	g := NewGovernor(mgr, GovernorPolicy{Enabled: true, LiveCPUWeight: 1000, BatchCPUWeight: 10, BatchCPUMax: "50000 100000"})
+
+	batch := startJob(t, g, mgr, "batch-1", QueueBatch, &cgroups.Limits{CPUMax: cgroups.CPUPercent(400)})
+	if got := fake.Value(batch + "/cpu.max"); got != "400000 100000" {
+		t.Fatalf("batch cpu.max = %q before a live job", got)
+	}
+
+	live := startJob(t, g, mgr, "live-1", QueueLive, nil)
+	if got := fake.Value(live + "/cpu.weight"); got != "1000" {
+		t.Errorf("live cpu.weight = %q, want 1000", got)
+	}
+	if got := fake.Value(batch + "/cpu.weight"); got != "10" {
+		t.Errorf("batch cpu.weight = %q while live runs, want 10", got)
+	}
+	if got := fake.Value(batch + "/cpu.max"); got != "50000 100000" {
+		t.Errorf("batch cpu.max = %q while live runs", got)
+	}
+
+	// A batch job started while the live job runs is throttled from the start
+	late := startJob(t, g, mgr, "batch-2", QueueBatch, nil)
+	if got := fake.Value(late + "/cpu.weight"); got != "10" {
+		t.Errorf("late batch cpu.weight = %q, want 10", got)
+	}
+
+	g.Finish("live-1")
+	if got := fake.Value(batch + "/cpu.weight"); got != "100" {
+		t.Errorf("batch cpu.weight = %q after live finished, want 100", got)
+	}
+	if got := fake.Value(batch + "/cpu.max"); got != "400000 100000" {
+		t.Errorf("batch cpu.max = %q after live finished, want its own limit", got)
+	}
+	if got := fake.Value(late + "/cpu.max"); got != "max 100000" {
+		t.Errorf("late batch cpu.max = %q after live finished, want max", got)
+	}
+}
+
+func TestGovernorUpdate(t *testing.T) {
+	fake := cgroups.NewFakeFS(2, "cpu", "memory")
+	mgr := cgroups.NewWithFS(fake, cgroups.DefaultRoot)
+	policy := DefaultGovernorPolicy()
+	policy.Enabled = true
+	g := NewGovernor(mgr, policy)
+
+	batch := startJob(t, g, mgr, "batch-1", QueueBatch, nil)
+	startJob(t, g, mgr, "live-1", QueueLive, nil)
+
+	outcomes, err := g.Update("batch-1", &cgroups.Limits{CPUWeight: 500, MemoryMax: 4096 * cgroups.MiB})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	statuses := make(map[string]cgroups.Status)
+	for _, o := range outcomes {
+		statuses[o.Limit] = o.Status
+	}
+	if statuses["memory_max"] != cgroups.StatusApplied || statuses["cpu_weight"] != cgroups.StatusSkipped {
+		t.Errorf("Unexpected outcomes: %+v", outcomes)
+	}
+	if got := fake.Value(batch + "/cpu.weight"); got != "10" {
+		t.Errorf("cpu.weight = %q, want the throttle kept while live runs", got)
+	}
+	if got := fake.Value(batch + "/memory.max"); got != "4294967296" {
+		t.Errorf("memory.max = %q", got)
+	}
+
+	// The deferred weight takes effect once the live job finishes
+	g.Finish("live-1")
+	if got := fake.Value(batch + "/cpu.weight"); got != "500" {
+		t.Errorf("cpu.weight = %q after live finished, want 500", got)
+	}
+
+	if _, err := g.Update("unknown", &cgroups.Limits{CPUWeight: 10}); err == nil {
+		t.Error("Expected an error for a job without a cgroup")
+	}
+}
+
+func TestGovernorDisabled(t *testing.T) {
+	fake := cgroups.NewFakeFS(2, "cpu")
+	mgr := cgroups.NewWithFS(fake, cgroups.DefaultRoot)
+	g := NewGovernor(mgr, GovernorPolicy{})
+
+	batch := startJob(t, g, mgr, "batch-1", QueueBatch, nil)
+	if limits := g.Start("live-1", QueueLive, nil); limits.CPUWeight != 0 {
+		t.Errorf("Disabled policy raised the live weight: %+v", limits)
+	}
+	if got := fake.Value(batch + "/cpu.weight"); got != "" {
+		t.Errorf("Disabled policy throttled a batch job: cpu.weight = %q", got)
+	}
+}
+
+func TestGovernorWaitsForCgroup(t *testing.T) {
+	fake := cgroups.NewFakeFS(2, "cpu")
+	mgr := cgroups.NewWithFS(fake, cgroups.DefaultRoot)
+	policy := DefaultGovernorPolicy()
+	policy.Enabled = true
+	g := NewGovernor(mgr, policy)
+	g.retryInterval = 10 * time.Millisecond
+
+	// The batch job is registered, but a live job starts before its cgroup exists
+	limits := g.Start("batch-1", QueueBatch, nil)
+	startJob(t, g, mgr, "live-1", QueueLive, nil)
+	batch, err := mgr.Create("batch-1")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	mgr.Apply(batch, limits)
+
+	waitForValue(t, fake, batch+"/cpu.weight", "10")
+
+	// Same when the live job finishes before the next batch job's cgroup exists
+	limits = g.Start("batch-2", QueueBatch, nil)
+	g.Finish("live-1")
+	late, err := mgr.Create("batch-2")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	mgr.Apply(late, limits)
+	if got := fake.Value(late + "/cpu.weight"); got != "10" {
+		t.Fatalf("batch-2 cpu.weight = %q, want it created throttled", got)
+	}
+
+	waitForValue(t, fake, late+"/cpu.weight", "100")
+}
+
+func TestDefaultGovernorPolicyDisabled(t *testing.T) {
+	if DefaultGovernorPolicy().Enabled {
+		t.Error("Live prioritization must be opt-in")
+	}
+}
+
+// waitForValue waits until a cgroup file has the wanted value
+func waitForValue(t *testing.T, fake *cgroups.FakeFS, path, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.Value(path) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s = %q, want %q", path, fake.Value(path), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
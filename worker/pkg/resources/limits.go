@@ -5,13 +5,13 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/psantana5/ffmpeg-rtmp/pkg/cgroups"
 	"golang.org/x/sys/unix"
 )
 
@@ -35,197 +35,12 @@ func DefaultLimits() *ResourceLimits {
 	}
 }
 
-// CgroupManager manages cgroup resources for jobs
-type CgroupManager struct {
-	cgroupRoot    string
-	cgroupVersion int // 1 for v1, 2 for v2
-}
-
-// NewCgroupManager creates a new cgroup manager
-func NewCgroupManager() (*CgroupManager, error) {
-	// Detect cgroup version
-	version := detectCgroupVersion()
-	
-	var cgroupRoot string
-	if version == 2 {
-		cgroupRoot = "/sys/fs/cgroup"
-	} else {
-		cgroupRoot = "/sys/fs/cgroup"
-	}
-	
-	log.Printf("Detected cgroup v%d (root: %s)", version, cgroupRoot)
-	
-	return &CgroupManager{
-		cgroupRoot:    cgroupRoot,
-		cgroupVersion: version,
-	}, nil
-}
-
-// detectCgroupVersion detects whether system uses cgroup v1 or v2
-func detectCgroupVersion() int {
-	// Check if cgroup v2 unified hierarchy exists
-	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
-		return 2
-	}
-	return 1
-}
-
-// CreateCgroup creates a cgroup for a job with specified limits
-func (cm *CgroupManager) CreateCgroup(jobID string, limits *ResourceLimits) (string, error) {
-	if limits == nil {
-		limits = DefaultLimits()
+// CgroupLimits converts the CPU and memory limits to cgroup limits
+func (l *ResourceLimits) CgroupLimits() *cgroups.Limits {
+	return &cgroups.Limits{
+		CPUMax:    cgroups.CPUPercent(l.MaxCPUPercent),
+		MemoryMax: int64(l.MaxMemoryMB) * cgroups.MiB,
 	}
-	
-	cgroupName := fmt.Sprintf("ffmpeg-job-%s", jobID)
-	
-	if cm.cgroupVersion == 2 {
-		return cm.createCgroupV2(cgroupName, limits)
-	}
-	return cm.createCgroupV1(cgroupName, limits)
-}
-
-// createCgroupV2 creates cgroup for v2 (unified hierarchy)
-func (cm *CgroupManager) createCgroupV2(cgroupName string, limits *ResourceLimits) (string, error) {
-	cgroupPath := filepath.Join(cm.cgroupRoot, cgroupName)
-	
-	// Create cgroup directory
-	if err := os.MkdirAll(cgroupPath, 0755); err != nil {
-		// If permission denied, cgroups might not be available
-		if os.IsPermission(err) {
-			log.Printf("WARNING: Cannot create cgroup (permission denied), running without cgroup limits")
-			return "", nil
-		}
-		return "", fmt.Errorf("failed to create cgroup: %w", err)
-	}
-	
-	// Set CPU limit (cpu.max format: "quota period")
-	// quota = (percent / 100) * period
-	// period = 100000 (100ms in microseconds)
-	if limits.MaxCPUPercent > 0 {
-		period := 100000
-		quota := (limits.MaxCPUPercent * period) / 100
-		cpuMax := fmt.Sprintf("%d %d", quota, period)
-		
-		cpuMaxFile := filepath.Join(cgroupPath, "cpu.max")
-		if err := os.WriteFile(cpuMaxFile, []byte(cpuMax), 0644); err != nil {
-			log.Printf("WARNING: Failed to set CPU limit: %v", err)
-		} else {
-			log.Printf("Set CPU limit: %d%% (%s)", limits.MaxCPUPercent, cpuMax)
-		}
-	}
-	
-	// Set memory limit
-	if limits.MaxMemoryMB > 0 {
-		memoryBytes := int64(limits.MaxMemoryMB) * 1024 * 1024
-		memoryMaxFile := filepath.Join(cgroupPath, "memory.max")
-		
-		if err := os.WriteFile(memoryMaxFile, []byte(fmt.Sprintf("%d", memoryBytes)), 0644); err != nil {
-			log.Printf("WARNING: Failed to set memory limit: %v", err)
-		} else {
-			log.Printf("Set memory limit: %d MB", limits.MaxMemoryMB)
-		}
-	}
-	
-	return cgroupPath, nil
-}
-
-// createCgroupV1 creates cgroup for v1 (separate hierarchies)
-func (cm *CgroupManager) createCgroupV1(cgroupName string, limits *ResourceLimits) (string, error) {
-	// For v1, we need separate paths for cpu and memory
-	cpuPath := filepath.Join(cm.cgroupRoot, "cpu", cgroupName)
-	memoryPath := filepath.Join(cm.cgroupRoot, "memory", cgroupName)
-	
-	// Create CPU cgroup
-	if err := os.MkdirAll(cpuPath, 0755); err != nil {
-		if os.IsPermission(err) {
-			log.Printf("WARNING: Cannot create cgroup (permission denied), running without cgroup limits")
-			return "", nil
-		}
-		return "", fmt.Errorf("failed to create CPU cgroup: %w", err)
-	}
-	
-	// Create memory cgroup
-	if err := os.MkdirAll(memoryPath, 0755); err != nil {
-		log.Printf("WARNING: Failed to create memory cgroup: %v", err)
-	}
-	
-	// Set CPU limit (cfs_quota_us / cfs_period_us = CPU fraction)
-	if limits.MaxCPUPercent > 0 {
-		period := 100000 // 100ms default period
-		quota := (limits.MaxCPUPercent * period) / 100
-		
-		quotaFile := filepath.Join(cpuPath, "cpu.cfs_quota_us")
-		periodFile := filepath.Join(cpuPath, "cpu.cfs_period_us")
-		
-		if err := os.WriteFile(periodFile, []byte(fmt.Sprintf("%d", period)), 0644); err != nil {
-			log.Printf("WARNING: Failed to set CPU period: %v", err)
-		}
-		
-		if err := os.WriteFile(quotaFile, []byte(fmt.Sprintf("%d", quota)), 0644); err != nil {
-			log.Printf("WARNING: Failed to set CPU quota: %v", err)
-		} else {
-			log.Printf("Set CPU limit: %d%% (quota=%d, period=%d)", limits.MaxCPUPercent, quota, period)
-		}
-	}
-	
-	// Set memory limit
-	if limits.MaxMemoryMB > 0 {
-		memoryBytes := int64(limits.MaxMemoryMB) * 1024 * 1024
-		limitFile := filepath.Join(memoryPath, "memory.limit_in_bytes")
-		
-		if err := os.WriteFile(limitFile, []byte(fmt.Sprintf("%d", memoryBytes)), 0644); err != nil {
-			log.Printf("WARNING: Failed to set memory limit: %v", err)
-		} else {
-			log.Printf("Set memory limit: %d MB", limits.MaxMemoryMB)
-		}
-	}
-	
-	return cpuPath, nil
-}
-
-// AddProcessToCgroup adds a process to the cgroup
-func (cm *CgroupManager) AddProcessToCgroup(cgroupPath string, pid int) error {
-	if cgroupPath == "" {
-		// Cgroup not created (likely due to permissions), skip
-		return nil
-	}
-	
-	if cm.cgroupVersion == 2 {
-		procsFile := filepath.Join(cgroupPath, "cgroup.procs")
-		return os.WriteFile(procsFile, []byte(fmt.Sprintf("%d", pid)), 0644)
-	}
-	
-	// For v1, add to both cpu and memory cgroups
-	cpuProcs := filepath.Join(cgroupPath, "cgroup.procs")
-	memoryPath := strings.Replace(cgroupPath, "/cpu/", "/memory/", 1)
-	memoryProcs := filepath.Join(memoryPath, "cgroup.procs")
-	
-	if err := os.WriteFile(cpuProcs, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
-		return fmt.Errorf("failed to add process to CPU cgroup: %w", err)
-	}
-	
-	if err := os.WriteFile(memoryProcs, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
-		log.Printf("WARNING: Failed to add process to memory cgroup: %v", err)
-	}
-	
-	return nil
-}
-
-// RemoveCgroup removes a cgroup after job completion
-func (cm *CgroupManager) RemoveCgroup(cgroupPath string) error {
-	if cgroupPath == "" {
-		return nil
-	}
-	
-	// For v1, also remove memory cgroup
-	if cm.cgroupVersion == 1 {
-		memoryPath := strings.Replace(cgroupPath, "/cpu/", "/memory/", 1)
-		if err := os.Remove(memoryPath); err != nil && !os.IsNotExist(err) {
-			log.Printf("WARNING: Failed to remove memory cgroup: %v", err)
-		}
-	}
-	
-	return os.Remove(cgroupPath)
 }
 
 // DiskSpaceInfo contains disk space information